@@ -0,0 +1,221 @@
+// Command kakao-sim plays the Kakao open builder against a relay instance
+// for offline end-to-end testing.
+//
+//	kakao-sim [flags]                     interactive REPL
+//	kakao-sim [flags] run a.yaml b.yaml   run scripted scenarios
+//
+// The relay must accept http://localhost callbacks
+// (CALLBACK_ALLOW_INSECURE_LOCALHOST=true) for callback replies to arrive.
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/kakaosim"
+)
+
+func main() {
+	relayURL := flag.String("relay", "http://localhost:8080", "relay base URL")
+	secret := flag.String("secret", os.Getenv("KAKAO_SIGNATURE_SECRET"), "webhook signing secret (X-Kakao-Signature)")
+	listen := flag.String("listen", "127.0.0.1:9090", "callback receiver listen address")
+	callbackBase := flag.String("callback-base", "", "callback base URL as seen by the relay (default http://<listen>)")
+	botID := flag.String("bot", "sim-bot", "simulated bot (channel) ID")
+	user := flag.String("user", "sim-user", "simulated plusfriendUserKey")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: kakao-sim [flags] [run scenario.yaml...]\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to listen on %s: %v\n", *listen, err)
+		os.Exit(1)
+	}
+	if *callbackBase == "" {
+		*callbackBase = "http://" + ln.Addr().String()
+	}
+
+	sim := kakaosim.New(kakaosim.Config{
+		RelayURL:        *relayURL,
+		Secret:          *secret,
+		BotID:           *botID,
+		UserKey:         *user,
+		CallbackBaseURL: *callbackBase,
+	})
+	agent := kakaosim.NewAgent(*relayURL)
+	defer agent.Close()
+
+	server := &http.Server{Handler: sim.Receiver, ReadHeaderTimeout: 5 * time.Second}
+	go server.Serve(ln)
+	defer server.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	args := flag.Args()
+	if len(args) > 0 && args[0] == "run" {
+		os.Exit(runScenarios(ctx, sim, agent, args[1:]))
+	}
+
+	fmt.Printf("kakao-sim → %s (callbacks at %s, user %s)\n", *relayURL, *callbackBase, *user)
+	fmt.Println("type a message, or :help for commands")
+	repl(ctx, sim, agent)
+}
+
+func runScenarios(ctx context.Context, sim *kakaosim.Simulator, agent *kakaosim.Agent, paths []string) int {
+	if len(paths) == 0 {
+		fmt.Fprintln(os.Stderr, "run: no scenario files given")
+		return 2
+	}
+
+	runner := &kakaosim.Runner{Sim: sim, Agent: agent, Out: os.Stdout}
+	failed := 0
+	for _, path := range paths {
+		sc, err := kakaosim.LoadScenario(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			failed++
+			continue
+		}
+		if err := runner.Run(ctx, sc); err != nil {
+			failed++
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", len(paths)-failed, failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+const replHelp = `commands:
+  <text>                    send a user message
+  :image <url>              send an image (userRequest.params.media)
+  :quick <label> [blockId]  tap a quick reply
+  :nocallback <text>        send without a callback URL
+  :user <key>               switch plusfriendUserKey
+  :session                  create a relay session (agent side) and print the pairing code
+  :pair                     send /pair with the last pairing code
+  :reply <text>             agent replies to the oldest unanswered message
+  :callbacks                list callbacks received so far
+  :quit`
+
+func repl(ctx context.Context, sim *kakaosim.Simulator, agent *kakaosim.Agent) {
+	var pairingCode string
+	scanner := bufio.NewScanner(os.Stdin)
+
+	for {
+		fmt.Printf("%s> ", sim.User())
+		if !scanner.Scan() {
+			return
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		cmd, arg, _ := strings.Cut(line, " ")
+		arg = strings.TrimSpace(arg)
+
+		switch {
+		case !strings.HasPrefix(line, ":"):
+			send(ctx, sim, kakaosim.Message{Utterance: line}, false)
+		case cmd == ":quit" || cmd == ":q":
+			return
+		case cmd == ":help":
+			fmt.Println(replHelp)
+		case cmd == ":image":
+			send(ctx, sim, kakaosim.Message{ImageURL: arg}, false)
+		case cmd == ":quick":
+			label, blockID, _ := strings.Cut(arg, " ")
+			send(ctx, sim, kakaosim.Message{QuickReply: &kakaosim.QuickReply{Label: label, BlockID: blockID}}, false)
+		case cmd == ":nocallback":
+			send(ctx, sim, kakaosim.Message{Utterance: arg}, true)
+		case cmd == ":user":
+			sim.SetUser(arg)
+		case cmd == ":session":
+			code, err := agent.CreateSession(ctx)
+			if err != nil {
+				fmt.Println("error:", err)
+				continue
+			}
+			pairingCode = code
+			fmt.Println("pairing code:", code)
+		case cmd == ":pair":
+			if pairingCode == "" {
+				fmt.Println("no pairing code yet, run :session first")
+				continue
+			}
+			send(ctx, sim, kakaosim.Message{Utterance: "/pair " + pairingCode}, false)
+		case cmd == ":reply":
+			agentReply(ctx, agent, arg)
+		case cmd == ":callbacks":
+			for _, cb := range sim.Receiver.All() {
+				fmt.Printf("[%s #%s] %s\n", cb.ReceivedAt.Format("15:04:05"), cb.ID, kakaosim.ResponseText(cb.Body))
+			}
+		default:
+			fmt.Println("unknown command, :help for help")
+		}
+	}
+}
+
+func send(ctx context.Context, sim *kakaosim.Simulator, msg kakaosim.Message, noCallback bool) {
+	reply, err := sim.Send(ctx, msg, noCallback)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+
+	switch {
+	case reply.StatusCode != http.StatusOK:
+		fmt.Printf("← %d %s\n", reply.StatusCode, strings.TrimSpace(string(reply.Body)))
+	case reply.UseCallback:
+		fmt.Printf("← (useCallback, waiting on #%s)\n", reply.CallbackID)
+		go printCallback(ctx, sim, reply.CallbackID)
+	default:
+		fmt.Printf("← %s\n", reply.Text)
+	}
+}
+
+func printCallback(ctx context.Context, sim *kakaosim.Simulator, id string) {
+	waitCtx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	cb, err := sim.Receiver.Wait(waitCtx, id)
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Printf("\n✗ no callback for #%s within 1m (Kakao would have expired it)\n", id)
+		return
+	}
+	if err != nil {
+		return
+	}
+	fmt.Printf("\n⇐ callback #%s: %s\n", id, kakaosim.ResponseText(cb.Body))
+}
+
+func agentReply(ctx context.Context, agent *kakaosim.Agent, text string) {
+	waitCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	msg, err := agent.NextMessage(waitCtx)
+	if err != nil {
+		fmt.Println("agent has no pending message (run :session and pair first)")
+		return
+	}
+	if err := agent.Reply(ctx, msg.ID, text); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Printf("agent replied to %q\n", msg.Text)
+}
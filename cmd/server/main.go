@@ -17,12 +17,15 @@ import (
 	"gitlab.tepseg.com/ai/kakao-relay/internal/config"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/database"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/handler"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/jobs"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/redis"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
 	"gitlab.tepseg.com/ai/kakao-relay/web"
 )
 
@@ -74,35 +77,129 @@ func main() {
 	inboundMsgRepo := repository.NewInboundMessageRepository(db.DB)
 	outboundMsgRepo := repository.NewOutboundMessageRepository(db.DB)
 	sessionRepo := repository.NewSessionRepository(db.DB)
+	attachmentRepo := repository.NewAttachmentRepository(db.DB)
+	mediaRepo := repository.NewMediaRepository(db.DB)
+	commandRepo := repository.NewCommandRepository(db.DB)
+	messageOverrideRepo := repository.NewMessageOverrideRepository(db.DB)
+	routingRuleRepo := repository.NewRoutingRuleRepository(db.DB)
+	channelRepo := repository.NewChannelRepository(db.DB)
+	pairingCodeRepo := repository.NewPairingCodeRepository(db.DB)
+	pairingRequestRepo := repository.NewPairingRequestRepository(db.DB)
+	conversationStateRepo := repository.NewConversationStateRepository(db.DB)
+
+	blobStore, err := storage.New(cfg.StorageConfig())
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to initialize blob store")
+	}
+	log.Info().Str("backend", cfg.BlobStore).Msg("blob store ready")
+
+	fileURLSecret := cfg.FileURLSigningSecret()
+	if fileURLSecret == "" {
+		fileURLSecret, err = util.GenerateToken()
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to generate file URL secret")
+		}
+		log.Warn().Msg("FILE_URL_SECRET not set: using a random key, attachment links reset on restart")
+	}
 
-	broker := sse.NewBroker(redisClient)
+	broker := sse.NewBroker(redisClient, cfg.SSEConfig())
 	defer broker.Close()
 
 	convService := service.NewConversationService(convRepo)
 	messageService := service.NewMessageService(inboundMsgRepo, outboundMsgRepo)
-	kakaoService := service.NewKakaoService()
-	sessionService := service.NewSessionService(db, sessionRepo, accountRepo, broker)
+	callbackHosts := cfg.CallbackAllowedHosts
+	if cfg.CallbackAllowlistFile != "" {
+		fileHosts, err := service.LoadCallbackRules(cfg.CallbackAllowlistFile)
+		if err != nil {
+			log.Fatal().Err(err).Msg("failed to load callback allowlist")
+		}
+		callbackHosts = append(callbackHosts, fileHosts...)
+	}
+	kakaoService, err := service.NewKakaoServiceFromConfig(service.CallbackClientConfig{
+		AllowedHosts:           callbackHosts,
+		AllowInsecureLocalhost: cfg.CallbackAllowInsecureLocalhost,
+		ProxyURL:               cfg.CallbackProxyURL,
+		CAFile:                 cfg.CallbackCAFile,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("failed to configure kakao callback client")
+	}
+	if cfg.CallbackAllowInsecureLocalhost {
+		log.Warn().Msg("CALLBACK_ALLOW_INSECURE_LOCALHOST enabled: http://localhost callbacks are allowed")
+	}
+	eventAPIClient := service.NewEventAPIClient(cfg.KakaoEventAPIBaseURL, cfg.KakaoRestAPIKey, cfg.KakaoBotID)
+	attachmentService := service.NewAttachmentService(attachmentRepo, blobStore, service.AttachmentConfig{
+		MaxBytes:      cfg.AttachmentMaxBytes,
+		URLTTL:        cfg.AttachmentURLTTL(),
+		Retention:     cfg.AttachmentRetention(),
+		PublicBaseURL: cfg.PublicBaseURL,
+		SigningSecret: fileURLSecret,
+	})
+	mediaService := service.NewMediaService(mediaRepo, blobStore, service.MediaConfig{
+		MaxBytes:      cfg.MediaMaxBytes,
+		AllowedTypes:  cfg.MediaAllowedTypes,
+		TTL:           cfg.MediaTTL(),
+		PublicBaseURL: cfg.PublicBaseURL,
+	})
 	ipRateLimiter := service.NewRateLimiter(redisClient.Client)
+	pairingGuard := service.NewPairingGuard(ipRateLimiter, service.DefaultPairingGuardConfig())
+	pairingCodeService := service.NewPairingCodeService(pairingCodeRepo)
+	conversationStateService := service.NewConversationStateService(conversationStateRepo, convRepo)
+	consumerRegistry := service.NewRedisConsumerRegistry(redisClient.Client)
+	deliveryService := service.NewDeliveryService(inboundMsgRepo, consumerRegistry, broker, cfg.DeliveryLeaseTTL())
+	orderingService := service.NewOrderingService(accountRepo, inboundMsgRepo, broker, attachmentService, cfg.OrderedDeliveryTimeout())
+	lifecycleService := service.NewLifecycleService(db, convRepo, sessionRepo, accountRepo, conversationStateRepo, broker)
+	sessionService := service.NewSessionService(db, sessionRepo, accountRepo, broker, pairingGuard, pairingCodeService, lifecycleService)
+	pairingRequestService := service.NewPairingRequestService(pairingRequestRepo, accountRepo, convRepo, broker, eventAPIClient, service.PairingApprovalConfig{
+		RequestTTL:  cfg.PairingRequestTTL(),
+		NotifyEvent: cfg.KakaoPairingEventName,
+	})
 
 	authMiddleware := middleware.NewAuthMiddleware(accountRepo, sessionRepo)
 	rateLimitMiddleware := middleware.NewRedisRateLimitMiddleware(redisClient.Client)
-	kakaoSignatureMiddleware := middleware.NewKakaoSignatureMiddleware(cfg.KakaoSignatureSecret)
+	channelService := service.NewChannelService(channelRepo, i18n.NewCatalog(), cfg.EncryptionKey)
+	kakaoSignatureMiddleware := middleware.NewKakaoSignatureMiddleware(cfg.KakaoSignatureSecret).
+		WithChannels(channelService).
+		AllowUnsignedChannels(cfg.KakaoAllowUnsignedChannels)
 	sessionCreateRateLimit := middleware.NewIPRateLimitMiddleware(ipRateLimiter, 10, 5*time.Minute, "session_create")
 	sessionStatusRateLimit := middleware.NewIPRateLimitMiddleware(ipRateLimiter, 30, 1*time.Minute, "session_status")
-	bodyLimitMiddleware := middleware.NewBodyLimitMiddleware(0)
+	// Leave headroom over the media limit for multipart framing.
+	bodyLimitMiddleware := middleware.NewBodyLimitMiddleware(0).
+		Override("/openclaw/media", cfg.MediaMaxBytes+64<<10)
+
+	commandService := service.NewCommandService(commandRepo, handler.BuiltinCommandNames())
+	localizationService := service.NewLocalizationService(i18n.NewCatalog(), messageOverrideRepo, accountRepo, convRepo, channelRepo, service.LocalizationConfig{
+		DefaultLocale:       cfg.DefaultLocale,
+		ChannelLocales:      cfg.ChannelLocales,
+		DetectFromUtterance: cfg.LocaleDetectFromUtterance,
+	})
+	routingService := service.NewRoutingService(routingRuleRepo, accountRepo, sessionRepo)
+	coalescingService := service.NewCoalescingService(accountRepo, inboundMsgRepo, broker, attachmentService, kakaoService, localizationService)
 
 	kakaoHandler := handler.NewKakaoHandler(
-		convService, sessionService, messageService, broker, cfg.CallbackTTL(),
+		convService, sessionService, pairingRequestService, lifecycleService, messageService, commandService,
+		localizationService, attachmentService, orderingService, coalescingService, routingService, broker, cfg.CallbackTTL(),
 	)
-	eventsHandler := handler.NewEventsHandler(broker, messageService)
-	openclawHandler := handler.NewOpenClawHandler(messageService, kakaoService)
+	eventsHandler := handler.NewEventsHandler(broker, messageService, attachmentService, conversationStateService, deliveryService)
+	attachmentHandler := handler.NewAttachmentHandler(attachmentService)
+	mediaHandler := handler.NewMediaHandler(mediaService)
+	openclawHandler := handler.NewOpenClawHandler(messageService, kakaoService, convService, eventAPIClient, mediaService, orderingService)
 	sessionHandler := handler.NewSessionHandler(sessionService)
+	accountCommandsHandler := handler.NewAccountCommandsHandler(commandService)
+	localizationHandler := handler.NewLocalizationHandler(localizationService)
+	routingRulesHandler := handler.NewRoutingRulesHandler(routingService)
+	pairingCodesHandler := handler.NewPairingCodesHandler(pairingCodeService)
+	pairingRequestsHandler := handler.NewPairingRequestsHandler(pairingRequestService)
+	deliverySettingsHandler := handler.NewDeliverySettingsHandler(orderingService, coalescingService)
+	meHandler := handler.NewMeHandler(convService, lifecycleService, messageService, broker)
+	conversationsHandler := handler.NewConversationsHandler(messageService, conversationStateService)
 
 	dashboardRepo := repository.NewDashboardRepository(db.DB)
+	channelsHandler := handler.NewChannelsHandler(channelService, dashboardRepo)
 	dashboardHandler := handler.NewDashboardHandler(
 		dashboardRepo, accountRepo, convRepo,
 		inboundMsgRepo, outboundMsgRepo,
-		sessionService, messageService, broker,
+		sessionService, lifecycleService, messageService, broker,
 		web.DashboardHTML,
 	)
 
@@ -128,20 +225,47 @@ func main() {
 		})
 	})
 
+	// The signature middleware runs inline so it sees the {channel} parameter.
 	r.Route("/kakao", func(r chi.Router) {
-		r.Use(kakaoSignatureMiddleware.Handler)
-		r.Post("/webhook", kakaoHandler.Webhook)
+		r.With(kakaoSignatureMiddleware.Handler).Post("/webhook", kakaoHandler.Webhook)
+		r.With(kakaoSignatureMiddleware.Handler).Post("/{channel}/webhook", kakaoHandler.Webhook)
 	})
 
+	r.Get("/files/attachments/{id}", attachmentHandler.Download)
+	r.Get("/media/{token}", mediaHandler.Serve)
+
 	r.Route("/v1", func(r chi.Router) {
 		r.Use(authMiddleware.Handler)
 		r.Use(rateLimitMiddleware.Handler)
 		r.Get("/events", eventsHandler.ServeHTTP)
+		r.Get("/me", meHandler.Get)
+		r.Get("/me/conversations", meHandler.ListConversations)
+		r.Post("/me/conversations/{id}/unpair", meHandler.UnpairConversation)
+		r.Post("/me/conversations/{id}/block", meHandler.BlockConversation)
+		r.Post("/me/conversations/{id}/unblock", meHandler.UnblockConversation)
+		r.Post("/me/token/rotate", meHandler.RotateToken)
+		r.Get("/conversations/{key}/messages", conversationsHandler.Messages)
+		r.Get("/conversations/{key}/state", conversationsHandler.ListState)
+		r.Get("/conversations/{key}/state/{name}", conversationsHandler.GetState)
+		r.Put("/conversations/{key}/state/{name}", conversationsHandler.PutState)
+		r.Delete("/conversations/{key}/state/{name}", conversationsHandler.DeleteState)
+		r.Post("/pairing-codes", pairingCodesHandler.Create)
+		r.Get("/pairing-codes", pairingCodesHandler.List)
+		r.Delete("/pairing-codes/{id}", pairingCodesHandler.Revoke)
+		r.Get("/pairing-settings", pairingRequestsHandler.GetSettings)
+		r.Put("/pairing-settings", pairingRequestsHandler.UpdateSettings)
+		r.Get("/pairing-requests", pairingRequestsHandler.List)
+		r.Post("/pairing-requests/{id}/approve", pairingRequestsHandler.Approve)
+		r.Post("/pairing-requests/{id}/reject", pairingRequestsHandler.Reject)
+		r.Get("/delivery-settings", deliverySettingsHandler.Get)
+		r.Put("/delivery-settings", deliverySettingsHandler.Update)
 	})
 
 	r.Route("/openclaw", func(r chi.Router) {
 		r.Use(authMiddleware.Handler)
 		r.Use(rateLimitMiddleware.Handler)
+		r.Get("/commands", accountCommandsHandler.List)
+		r.Put("/commands", accountCommandsHandler.Replace)
 		r.Mount("/", openclawHandler.Routes())
 	})
 
@@ -162,6 +286,17 @@ func main() {
 			r.Post("/accounts/{id}/regenerate-token", dashboardHandler.RegenerateToken)
 			r.Delete("/accounts/{id}", dashboardHandler.DeleteAccount)
 			r.Delete("/accounts/{id}/conversations/{convId}", dashboardHandler.DeleteConversation)
+			r.Get("/accounts/{id}/localization", localizationHandler.Get)
+			r.Put("/accounts/{id}/localization/locale", localizationHandler.SetLocale)
+			r.Put("/accounts/{id}/localization/messages/{locale}/{key}", localizationHandler.PutOverride)
+			r.Delete("/accounts/{id}/localization/messages/{locale}/{key}", localizationHandler.DeleteOverride)
+			r.Get("/routing-rules", routingRulesHandler.List)
+			r.Post("/routing-rules", routingRulesHandler.Create)
+			r.Put("/routing-rules/{id}", routingRulesHandler.Update)
+			r.Delete("/routing-rules/{id}", routingRulesHandler.Delete)
+			r.Get("/channels", channelsHandler.List)
+			r.Put("/channels/{id}", channelsHandler.Put)
+			r.Delete("/channels/{id}", channelsHandler.Delete)
 			r.Get("/sessions", dashboardHandler.ListSessions)
 			r.Post("/sessions/create", dashboardHandler.CreateSession)
 			r.Post("/sessions/{id}/disconnect", dashboardHandler.DisconnectSession)
@@ -172,9 +307,20 @@ func main() {
 	cleanupJob := jobs.NewCleanupJob(
 		inboundMsgRepo, sessionRepo, config.CleanupJobInterval,
 	)
+	cleanupJob.AddTask("attachments", attachmentService.PurgeExpired)
+	cleanupJob.AddTask("media", mediaService.PurgeExpired)
+	cleanupJob.AddTask("pairing_codes", pairingCodeService.PurgeExpired)
+	cleanupJob.AddTask("pairing_requests", pairingRequestService.ExpirePending)
+	cleanupJob.AddTask("conversation_state", conversationStateService.PurgeExpired)
 	cleanupJob.Start()
 	defer cleanupJob.Stop()
 
+	deliveryJob := jobs.NewDeliveryJob(config.DeliveryJobInterval)
+	deliveryJob.AddTask("held messages", orderingService.ReleaseDue)
+	deliveryJob.AddTask("coalesced messages", coalescingService.FlushDue)
+	deliveryJob.Start()
+	defer deliveryJob.Stop()
+
 	server := &http.Server{
 		Addr:         cfg.Addr(),
 		Handler:      r,
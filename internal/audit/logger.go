@@ -17,6 +17,11 @@ const (
 	EventRateLimitExceed EventType = "rate_limit_exceeded"
 	EventAuthFailure     EventType = "auth_failure"
 	EventSessionCreate   EventType = "session_create"
+	EventPairingSuccess  EventType = "pairing_success"
+	EventPairingFailure  EventType = "pairing_failure"
+	EventPairingLockout  EventType = "pairing_lockout"
+	EventPairingAnomaly  EventType = "pairing_anomaly"
+	EventPairingDecision EventType = "pairing_decision"
 )
 
 type Event struct {
@@ -7,6 +7,9 @@ import (
 
 	"github.com/caarlos0/env/v11"
 	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
 )
 
 type Config struct {
@@ -14,10 +17,53 @@ type Config struct {
 	DatabaseURL          string `env:"DATABASE_URL,required"`
 	RedisURL             string `env:"REDIS_URL,required"`
 	KakaoSignatureSecret string `env:"KAKAO_SIGNATURE_SECRET"`
+	KakaoRestAPIKey      string `env:"KAKAO_REST_API_KEY"`
+	KakaoBotID           string `env:"KAKAO_BOT_ID"`
+	KakaoEventAPIBaseURL string `env:"KAKAO_EVENT_API_BASE_URL"`
 	EncryptionKey        string `env:"ENCRYPTION_KEY"`
 	QueueTTLSeconds      int    `env:"QUEUE_TTL_SECONDS" envDefault:"900"`
 	CallbackTTLSeconds   int    `env:"CALLBACK_TTL_SECONDS" envDefault:"55"`
 	LogLevel             string `env:"LOG_LEVEL" envDefault:"info"`
+
+	KakaoAllowUnsignedChannels bool `env:"KAKAO_ALLOW_UNSIGNED_CHANNELS"`
+
+	PublicBaseURL            string `env:"PUBLIC_BASE_URL"`
+	FileURLSecret            string `env:"FILE_URL_SECRET"`
+	BlobStore                string `env:"BLOB_STORE" envDefault:"local"`
+	BlobLocalDir             string `env:"BLOB_LOCAL_DIR" envDefault:"./data/blobs"`
+	S3Endpoint               string `env:"S3_ENDPOINT"`
+	S3Region                 string `env:"S3_REGION" envDefault:"us-east-1"`
+	S3Bucket                 string `env:"S3_BUCKET"`
+	S3AccessKeyID            string `env:"S3_ACCESS_KEY_ID"`
+	S3SecretAccessKey        string `env:"S3_SECRET_ACCESS_KEY"`
+	S3UsePathStyle           bool   `env:"S3_USE_PATH_STYLE" envDefault:"true"`
+	AttachmentMaxBytes       int64  `env:"ATTACHMENT_MAX_BYTES" envDefault:"20971520"`
+	AttachmentURLTTLSeconds  int    `env:"ATTACHMENT_URL_TTL_SECONDS" envDefault:"3600"`
+	AttachmentRetentionHours int    `env:"ATTACHMENT_RETENTION_HOURS" envDefault:"168"`
+
+	MediaMaxBytes     int64    `env:"MEDIA_MAX_BYTES" envDefault:"5242880"`
+	MediaTTLHours     int      `env:"MEDIA_TTL_HOURS" envDefault:"72"`
+	MediaAllowedTypes []string `env:"MEDIA_ALLOWED_TYPES" envSeparator:"," envDefault:"image/jpeg,image/png,image/gif,image/webp"`
+
+	CallbackAllowedHosts           []string `env:"CALLBACK_ALLOWED_HOSTS" envSeparator:","`
+	CallbackAllowlistFile          string   `env:"CALLBACK_ALLOWLIST_FILE"`
+	CallbackProxyURL               string   `env:"CALLBACK_PROXY_URL"`
+	CallbackCAFile                 string   `env:"CALLBACK_CA_FILE"`
+	CallbackAllowInsecureLocalhost bool     `env:"CALLBACK_ALLOW_INSECURE_LOCALHOST"`
+
+	DefaultLocale             string            `env:"DEFAULT_LOCALE" envDefault:"ko"`
+	ChannelLocales            map[string]string `env:"CHANNEL_LOCALES" envSeparator:"," envKeyValSeparator:":"`
+	LocaleDetectFromUtterance bool              `env:"LOCALE_DETECT_FROM_UTTERANCE"`
+
+	PairingRequestTTLHours int    `env:"PAIRING_REQUEST_TTL_HOURS" envDefault:"24"`
+	KakaoPairingEventName  string `env:"KAKAO_PAIRING_EVENT_NAME"`
+
+	DeliveryLeaseTTLSeconds int `env:"DELIVERY_LEASE_TTL_SECONDS" envDefault:"30"`
+
+	OrderedDeliveryTimeoutSeconds int `env:"ORDERED_DELIVERY_TIMEOUT_SECONDS" envDefault:"60"`
+
+	SSEClientBufferSize int    `env:"SSE_CLIENT_BUFFER_SIZE" envDefault:"100"`
+	SSEOverflowPolicy   string `env:"SSE_OVERFLOW_POLICY" envDefault:"disconnect"`
 }
 
 func (c *Config) QueueTTL() time.Duration {
@@ -28,11 +74,82 @@ func (c *Config) CallbackTTL() time.Duration {
 	return time.Duration(c.CallbackTTLSeconds) * time.Second
 }
 
+// DeliveryLeaseTTL is how long a competing stream may hold a message
+// without acking it before it is offered again.
+func (c *Config) DeliveryLeaseTTL() time.Duration {
+	return time.Duration(c.DeliveryLeaseTTLSeconds) * time.Second
+}
+
+// OrderedDeliveryTimeout is how long an ordered conversation waits for a
+// reply or ack before its next held message is released anyway.
+func (c *Config) OrderedDeliveryTimeout() time.Duration {
+	return time.Duration(c.OrderedDeliveryTimeoutSeconds) * time.Second
+}
+
+func (c *Config) AttachmentURLTTL() time.Duration {
+	return time.Duration(c.AttachmentURLTTLSeconds) * time.Second
+}
+
+func (c *Config) AttachmentRetention() time.Duration {
+	return time.Duration(c.AttachmentRetentionHours) * time.Hour
+}
+
+func (c *Config) MediaTTL() time.Duration {
+	return time.Duration(c.MediaTTLHours) * time.Hour
+}
+
+func (c *Config) PairingRequestTTL() time.Duration {
+	return time.Duration(c.PairingRequestTTLHours) * time.Hour
+}
+
+// FileURLSigningSecret returns the key for signed download URLs, falling
+// back to ENCRYPTION_KEY so existing deployments keep working.
+func (c *Config) FileURLSigningSecret() string {
+	if c.FileURLSecret != "" {
+		return c.FileURLSecret
+	}
+	return c.EncryptionKey
+}
+
+func (c *Config) StorageConfig() storage.Config {
+	return storage.Config{
+		Backend:  c.BlobStore,
+		LocalDir: c.BlobLocalDir,
+		S3: storage.S3Config{
+			Endpoint:        c.S3Endpoint,
+			Region:          c.S3Region,
+			Bucket:          c.S3Bucket,
+			AccessKeyID:     c.S3AccessKeyID,
+			SecretAccessKey: c.S3SecretAccessKey,
+			UsePathStyle:    c.S3UsePathStyle,
+		},
+	}
+}
+
+// SSEConfig is how the broker treats streams that fall behind.
+func (c *Config) SSEConfig() sse.Config {
+	return sse.Config{
+		BufferSize:     c.SSEClientBufferSize,
+		OverflowPolicy: sse.OverflowPolicy(c.SSEOverflowPolicy),
+	}
+}
+
 func (c *Config) Addr() string {
 	return fmt.Sprintf(":%d", c.Port)
 }
 
 func (c *Config) Validate(isProduction bool) error {
+	if isProduction && c.CallbackAllowInsecureLocalhost {
+		return fmt.Errorf("CALLBACK_ALLOW_INSECURE_LOCALHOST must not be enabled in production")
+	}
+
+	if c.SSEClientBufferSize < 0 {
+		return fmt.Errorf("SSE_CLIENT_BUFFER_SIZE must not be negative")
+	}
+	if c.SSEOverflowPolicy != "" && !sse.OverflowPolicy(c.SSEOverflowPolicy).Valid() {
+		return fmt.Errorf("SSE_OVERFLOW_POLICY must be disconnect or drop")
+	}
+
 	if isProduction {
 		if c.KakaoSignatureSecret == "" {
 			log.Warn().Msg("KAKAO_SIGNATURE_SECRET is empty in production: webhook signature verification disabled")
@@ -43,6 +160,9 @@ func (c *Config) Validate(isProduction bool) error {
 		if c.EncryptionKey == "" {
 			log.Warn().Msg("ENCRYPTION_KEY is empty in production: sensitive data will not be encrypted at rest")
 		}
+		if c.FileURLSigningSecret() == "" {
+			log.Warn().Msg("FILE_URL_SECRET is empty in production: attachment links will not survive restarts or work across replicas")
+		}
 	}
 
 	return nil
@@ -24,6 +24,21 @@ func TestConfigMethods(t *testing.T) {
 		cfg := &Config{CallbackTTLSeconds: 55}
 		assert.Equal(t, 55*time.Second, cfg.CallbackTTL())
 	})
+
+	t.Run("DeliveryLeaseTTL converts seconds to duration", func(t *testing.T) {
+		cfg := &Config{DeliveryLeaseTTLSeconds: 30}
+		assert.Equal(t, 30*time.Second, cfg.DeliveryLeaseTTL())
+	})
+
+	t.Run("OrderedDeliveryTimeout converts seconds to duration", func(t *testing.T) {
+		cfg := &Config{OrderedDeliveryTimeoutSeconds: 60}
+		assert.Equal(t, time.Minute, cfg.OrderedDeliveryTimeout())
+	})
+
+	t.Run("PairingRequestTTL converts hours to duration", func(t *testing.T) {
+		cfg := &Config{PairingRequestTTLHours: 24}
+		assert.Equal(t, 24*time.Hour, cfg.PairingRequestTTL())
+	})
 }
 
 func TestLoad(t *testing.T) {
@@ -97,3 +112,33 @@ func TestLoad(t *testing.T) {
 		assert.Error(t, err)
 	})
 }
+
+func TestConfig_Validate(t *testing.T) {
+	t.Run("rejects insecure localhost callbacks in production", func(t *testing.T) {
+		cfg := &Config{CallbackAllowInsecureLocalhost: true}
+		assert.Error(t, cfg.Validate(true))
+	})
+
+	t.Run("allows insecure localhost callbacks outside production", func(t *testing.T) {
+		cfg := &Config{CallbackAllowInsecureLocalhost: true}
+		assert.NoError(t, cfg.Validate(false))
+	})
+
+	t.Run("rejects unknown sse overflow policies", func(t *testing.T) {
+		assert.NoError(t, (&Config{SSEOverflowPolicy: "drop"}).Validate(false))
+		assert.Error(t, (&Config{SSEOverflowPolicy: "block"}).Validate(false))
+		assert.Error(t, (&Config{SSEClientBufferSize: -1}).Validate(false))
+	})
+}
+
+func TestLoad_Localization(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost/test")
+	t.Setenv("REDIS_URL", "redis://localhost:6379")
+	t.Setenv("CHANNEL_LOCALES", "botA:en,botB:ko")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "ko", cfg.DefaultLocale)
+	assert.Equal(t, map[string]string{"botA": "en", "botB": "ko"}, cfg.ChannelLocales)
+	assert.False(t, cfg.LocaleDetectFromUtterance)
+}
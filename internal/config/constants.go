@@ -21,7 +21,13 @@ const (
 const DBPingTimeout = 5 * time.Second
 
 // Background job intervals
-const CleanupJobInterval = 5 * time.Minute
+const (
+	CleanupJobInterval = 5 * time.Minute
+	// DeliveryJobInterval bounds how late a timed-out ordered conversation
+	// releases its next message, and how late coalesced utterances are
+	// delivered when the replica that received them went away.
+	DeliveryJobInterval = 2 * time.Second
+)
 
 // Default rate limiting
 const DefaultRateLimitPerMin = 60
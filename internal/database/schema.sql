@@ -138,3 +138,222 @@ CREATE INDEX IF NOT EXISTS "sessions_expires_at_idx"
     ON "sessions" USING btree ("expires_at");
 CREATE INDEX IF NOT EXISTS "sessions_account_id_idx"
     ON "sessions" USING btree ("account_id");
+
+-- Outbound delivery via Kakao Event API (bot-initiated messages)
+ALTER TABLE "outbound_messages"
+    ADD COLUMN IF NOT EXISTS "delivery_type" text DEFAULT 'callback' NOT NULL;
+ALTER TABLE "outbound_messages"
+    ADD COLUMN IF NOT EXISTS "event_task_id" text;
+ALTER TABLE "outbound_messages"
+    ADD COLUMN IF NOT EXISTS "event_status" text;
+CREATE INDEX IF NOT EXISTS "outbound_messages_delivery_type_idx"
+    ON "outbound_messages" USING btree ("delivery_type");
+
+-- Attachments copied from Kakao CDN before the source URLs expire
+CREATE TABLE IF NOT EXISTS "attachments" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "inbound_message_id" uuid NOT NULL REFERENCES "inbound_messages"("id") ON DELETE CASCADE,
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "type" text NOT NULL,
+    "source_url" text NOT NULL,
+    "storage_key" text,
+    "content_type" text,
+    "size_bytes" bigint,
+    "status" text DEFAULT 'pending' NOT NULL,
+    "error_message" text,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "stored_at" timestamp with time zone
+);
+CREATE INDEX IF NOT EXISTS "attachments_inbound_message_id_idx"
+    ON "attachments" USING btree ("inbound_message_id");
+CREATE INDEX IF NOT EXISTS "attachments_created_at_idx"
+    ON "attachments" USING btree ("created_at");
+
+-- Agent-uploaded media served at public URLs for Kakao templates
+CREATE TABLE IF NOT EXISTS "media" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "public_token" text NOT NULL,
+    "storage_key" text NOT NULL,
+    "content_type" text NOT NULL,
+    "size_bytes" bigint NOT NULL,
+    "filename" text,
+    "expires_at" timestamp with time zone NOT NULL,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "media_public_token_idx"
+    ON "media" USING btree ("public_token");
+CREATE INDEX IF NOT EXISTS "media_account_id_idx"
+    ON "media" USING btree ("account_id");
+CREATE INDEX IF NOT EXISTS "media_expires_at_idx"
+    ON "media" USING btree ("expires_at");
+
+-- Per-account slash commands declared by OpenClaw agents
+CREATE TABLE IF NOT EXISTS "account_commands" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "name" text NOT NULL,
+    "aliases" text[] DEFAULT '{}' NOT NULL,
+    "usage" text DEFAULT '' NOT NULL,
+    "description" text DEFAULT '' NOT NULL,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS "account_commands_account_id_name_idx"
+    ON "account_commands" USING btree ("account_id", "name");
+
+-- Localization: account/conversation locales and per-account message overrides
+ALTER TABLE "accounts"
+    ADD COLUMN IF NOT EXISTS "locale" text;
+ALTER TABLE "conversation_mappings"
+    ADD COLUMN IF NOT EXISTS "locale" text;
+CREATE TABLE IF NOT EXISTS "account_message_overrides" (
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "locale" text NOT NULL,
+    "key" text NOT NULL,
+    "text" text NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY ("account_id", "locale", "key")
+);
+
+-- Account-scoped invite codes: /pair attaches more users to an existing account
+CREATE TABLE IF NOT EXISTS "pairing_codes" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "code" text NOT NULL UNIQUE,
+    "max_uses" integer DEFAULT 1 NOT NULL,
+    "use_count" integer DEFAULT 0 NOT NULL,
+    "expires_at" timestamp with time zone NOT NULL,
+    "revoked_at" timestamp with time zone,
+    "last_used_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "pairing_codes_account_id_idx"
+    ON "pairing_codes" USING btree ("account_id");
+CREATE INDEX IF NOT EXISTS "pairing_codes_expires_at_idx"
+    ON "pairing_codes" USING btree ("expires_at");
+
+-- Pairing approval: /pair with an account code waits for the account to decide
+ALTER TABLE "accounts"
+    ADD COLUMN IF NOT EXISTS "require_pairing_approval" boolean DEFAULT false NOT NULL;
+ALTER TABLE "conversation_mappings"
+    ADD COLUMN IF NOT EXISTS "pairing_notice" text;
+CREATE TABLE IF NOT EXISTS "pairing_requests" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "conversation_key" text NOT NULL,
+    "pairing_code_id" uuid REFERENCES "pairing_codes"("id") ON DELETE SET NULL,
+    "profile" jsonb DEFAULT '{}' NOT NULL,
+    "status" text DEFAULT 'pending' NOT NULL,
+    "expires_at" timestamp with time zone NOT NULL,
+    "decided_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "pairing_requests_account_id_status_idx"
+    ON "pairing_requests" USING btree ("account_id", "status");
+CREATE UNIQUE INDEX IF NOT EXISTS "pairing_requests_pending_conversation_idx"
+    ON "pairing_requests" USING btree ("conversation_key") WHERE "status" = 'pending';
+
+-- Conversation history: keyset pages per account and conversation
+CREATE INDEX IF NOT EXISTS "inbound_messages_history_idx"
+    ON "inbound_messages" USING btree ("account_id", "conversation_key", "created_at" DESC, "id" DESC);
+CREATE INDEX IF NOT EXISTS "outbound_messages_history_idx"
+    ON "outbound_messages" USING btree ("account_id", "conversation_key", "created_at" DESC, "id" DESC);
+
+-- Conversation state: per-conversation key-value store for OpenClaw agents
+CREATE TABLE IF NOT EXISTS "conversation_state" (
+    "account_id" uuid NOT NULL REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "conversation_key" text NOT NULL,
+    "key" text NOT NULL,
+    "value" jsonb NOT NULL,
+    "version" bigint DEFAULT 1 NOT NULL,
+    "expires_at" timestamp with time zone,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT now() NOT NULL,
+    PRIMARY KEY ("account_id", "conversation_key", "key")
+);
+CREATE INDEX IF NOT EXISTS "conversation_state_expires_at_idx"
+    ON "conversation_state" USING btree ("expires_at") WHERE "expires_at" IS NOT NULL;
+
+-- Conversation threads: /new starts a fresh topic within a conversation
+ALTER TABLE "conversation_mappings"
+    ADD COLUMN IF NOT EXISTS "thread_id" uuid DEFAULT gen_random_uuid() NOT NULL;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "thread_id" uuid;
+ALTER TABLE "outbound_messages"
+    ADD COLUMN IF NOT EXISTS "thread_id" uuid;
+
+-- Competing-consumer delivery: a message leased to one stream until acked
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "lease_owner" text;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "lease_expires_at" timestamp with time zone;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "delivery_attempts" integer DEFAULT 0 NOT NULL;
+CREATE INDEX IF NOT EXISTS "inbound_messages_claimable_idx"
+    ON "inbound_messages" USING btree ("account_id", "created_at")
+    WHERE "status" IN ('queued', 'delivered');
+CREATE INDEX IF NOT EXISTS "inbound_messages_lease_owner_idx"
+    ON "inbound_messages" USING btree ("lease_owner") WHERE "lease_owner" IS NOT NULL;
+
+-- Ordered delivery: later messages of a conversation wait for the earlier one
+ALTER TABLE "accounts"
+    ADD COLUMN IF NOT EXISTS "ordered_delivery" boolean DEFAULT false NOT NULL;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "held" boolean DEFAULT false NOT NULL;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "queue_position" integer;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "released_at" timestamp with time zone;
+CREATE INDEX IF NOT EXISTS "inbound_messages_held_idx"
+    ON "inbound_messages" USING btree ("account_id", "conversation_key", "created_at") WHERE "held";
+
+-- Coalescing: rapid-fire utterances of a conversation merge into one event
+ALTER TABLE "accounts"
+    ADD COLUMN IF NOT EXISTS "coalesce_window_ms" integer DEFAULT 0 NOT NULL;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "coalesce_due_at" timestamp with time zone;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "coalesced_into" uuid;
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "coalesced_ids" text[];
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "coalesced_message" jsonb;
+CREATE INDEX IF NOT EXISTS "inbound_messages_coalescing_idx"
+    ON "inbound_messages" USING btree ("account_id", "conversation_key", "created_at")
+    WHERE "coalesce_due_at" IS NOT NULL;
+
+-- Routing rules: send a conversation's messages to another account by
+-- channel, block, intent or utterance, optionally split by weight
+CREATE TABLE IF NOT EXISTS "routing_rules" (
+    "id" uuid PRIMARY KEY DEFAULT gen_random_uuid() NOT NULL,
+    "name" text NOT NULL,
+    "priority" integer DEFAULT 0 NOT NULL,
+    "enabled" boolean DEFAULT true NOT NULL,
+    "source_account_id" uuid REFERENCES "accounts"("id") ON DELETE CASCADE,
+    "channel_id" text,
+    "block_name" text,
+    "intent_name" text,
+    "utterance_pattern" text,
+    "targets" jsonb NOT NULL,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "routing_rules_priority_idx"
+    ON "routing_rules" USING btree ("priority", "created_at") WHERE "enabled";
+ALTER TABLE "inbound_messages"
+    ADD COLUMN IF NOT EXISTS "routing_rule_id" uuid;
+
+-- Channel registry: per-bot settings for a relay serving several Kakao bots
+CREATE TABLE IF NOT EXISTS "channels" (
+    "id" text PRIMARY KEY NOT NULL,
+    "display_name" text DEFAULT '' NOT NULL,
+    "signature_secret" text,
+    "signature_secret_encrypted" boolean DEFAULT false NOT NULL,
+    "callback_ttl_seconds" integer,
+    "enabled" boolean DEFAULT true NOT NULL,
+    "default_locale" text,
+    "created_at" timestamp with time zone DEFAULT now() NOT NULL,
+    "updated_at" timestamp with time zone DEFAULT now() NOT NULL
+);
+CREATE INDEX IF NOT EXISTS "conversation_mappings_kakao_channel_id_idx"
+    ON "conversation_mappings" USING btree ("kakao_channel_id");
@@ -38,6 +38,9 @@ const (
 	ErrCodeCallbackExpired ErrorCode = "CALLBACK_EXPIRED"
 	ErrCodeCallbackFailed  ErrorCode = "CALLBACK_FAILED"
 
+	// Feature availability
+	ErrCodeNotConfigured ErrorCode = "NOT_CONFIGURED"
+
 	// Internal
 	ErrCodeInternal ErrorCode = "INTERNAL_ERROR"
 	ErrCodeDatabase ErrorCode = "DATABASE_ERROR"
@@ -156,6 +159,10 @@ func CallbackFailed(reason string) *AppError {
 	return New(ErrCodeCallbackFailed, fmt.Sprintf("Failed to send callback: %s", reason))
 }
 
+func NotConfigured(feature string) *AppError {
+	return New(ErrCodeNotConfigured, fmt.Sprintf("%s is not configured", feature))
+}
+
 func Internal(message string) *AppError {
 	return New(ErrCodeInternal, message)
 }
@@ -55,6 +55,7 @@ func TestErrorConstructors(t *testing.T) {
 		{"RateLimitExceeded", func() *AppError { return RateLimitExceeded() }, ErrCodeRateLimitExceeded},
 		{"CallbackExpired", func() *AppError { return CallbackExpired() }, ErrCodeCallbackExpired},
 		{"CallbackFailed", func() *AppError { return CallbackFailed("timeout") }, ErrCodeCallbackFailed},
+		{"NotConfigured", func() *AppError { return NotConfigured("Event API") }, ErrCodeNotConfigured},
 		{"Internal", func() *AppError { return Internal("test") }, ErrCodeInternal},
 	}
 
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// AccountCommandsHandler lets an OpenClaw agent declare its own slash
+// commands. Invocations are delivered as "command" SSE events.
+type AccountCommandsHandler struct {
+	commandService *service.CommandService
+}
+
+func NewAccountCommandsHandler(commandService *service.CommandService) *AccountCommandsHandler {
+	return &AccountCommandsHandler{commandService: commandService}
+}
+
+// GET /openclaw/commands
+func (h *AccountCommandsHandler) List(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	commands, err := h.commandService.List(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list account commands")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	writeCommands(w, commands)
+}
+
+// PUT /openclaw/commands
+// Replaces the account's whole command set; an empty list removes all.
+func (h *AccountCommandsHandler) Replace(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var req struct {
+		Commands []service.CommandDefinition `json:"commands"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+
+	commands, err := h.commandService.Replace(r.Context(), account.ID, req.Commands)
+	if errors.Is(err, service.ErrInvalidCommand) {
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidCommand.Error()+": ")
+		httputil.WriteError(w, apperrors.InvalidInput("commands", reason))
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to replace account commands")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	log.Info().
+		Str("accountId", account.ID).
+		Int("count", len(commands)).
+		Msg("account commands updated")
+
+	writeCommands(w, commands)
+}
+
+func writeCommands(w http.ResponseWriter, commands []model.AccountCommand) {
+	if commands == nil {
+		commands = []model.AccountCommand{}
+	}
+	for i := range commands {
+		if commands[i].Aliases == nil {
+			commands[i].Aliases = []string{}
+		}
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"commands": commands})
+}
@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+)
+
+type AttachmentHandler struct {
+	attachmentService *service.AttachmentService
+}
+
+func NewAttachmentHandler(attachmentService *service.AttachmentService) *AttachmentHandler {
+	return &AttachmentHandler{attachmentService: attachmentService}
+}
+
+// GET /files/attachments/{id}?expires=...&sig=...
+// The signed query string is the credential, so agents can hand the URL to
+// tools that don't carry the relay token.
+func (h *AttachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	query := r.URL.Query()
+
+	if !h.attachmentService.VerifyDownload(id, query.Get("expires"), query.Get("sig")) {
+		httputil.WriteError(w, apperrors.Forbidden("Download link is invalid or expired"))
+		return
+	}
+
+	ctx := r.Context()
+
+	att, err := h.attachmentService.FindByID(ctx, id)
+	if err != nil {
+		log.Error().Err(err).Str("attachmentId", id).Msg("failed to find attachment")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	if att == nil {
+		httputil.WriteError(w, apperrors.NotFound("Attachment"))
+		return
+	}
+
+	switch att.Status {
+	case model.AttachmentStatusPending:
+		w.Header().Set("Retry-After", "1")
+		httputil.WriteErrorWithStatus(w, http.StatusServiceUnavailable,
+			apperrors.New(apperrors.ErrCodeConflict, "Attachment is still being stored"))
+		return
+	case model.AttachmentStatusFailed:
+		httputil.WriteErrorWithStatus(w, http.StatusGone,
+			apperrors.New(apperrors.ErrCodeNotFound, "Attachment could not be stored"))
+		return
+	}
+
+	body, size, err := h.attachmentService.Open(ctx, att)
+	if errors.Is(err, storage.ErrNotFound) {
+		httputil.WriteError(w, apperrors.NotFound("Attachment"))
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("attachmentId", id).Msg("failed to open attachment blob")
+		httputil.WriteError(w, apperrors.Internal("Failed to read attachment"))
+		return
+	}
+	defer body.Close()
+
+	if att.ContentType != nil {
+		w.Header().Set("Content-Type", *att.ContentType)
+	}
+	if size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	w.Header().Set("Cache-Control", "private, max-age=300")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		log.Debug().Err(err).Str("attachmentId", id).Msg("attachment download interrupted")
+	}
+}
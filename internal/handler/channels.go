@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// ChannelsHandler serves the dashboard API for the channel registry.
+type ChannelsHandler struct {
+	channels      *service.ChannelService
+	dashboardRepo repository.DashboardRepository
+}
+
+func NewChannelsHandler(channels *service.ChannelService, dashboardRepo repository.DashboardRepository) *ChannelsHandler {
+	return &ChannelsHandler{channels: channels, dashboardRepo: dashboardRepo}
+}
+
+// channelView is a channel as shown on the dashboard. Channels that only
+// appear in conversations are listed as unregistered.
+type channelView struct {
+	ID                 string            `json:"id"`
+	DisplayName        string            `json:"displayName"`
+	Registered         bool              `json:"registered"`
+	Enabled            bool              `json:"enabled"`
+	HasSignatureSecret bool              `json:"hasSignatureSecret"`
+	CallbackTTLSeconds *int              `json:"callbackTtlSeconds"`
+	DefaultLocale      *string           `json:"defaultLocale"`
+	WebhookPath        string            `json:"webhookPath"`
+	Stats              *channelStatsView `json:"stats,omitempty"`
+	CreatedAt          *time.Time        `json:"createdAt,omitempty"`
+	UpdatedAt          *time.Time        `json:"updatedAt,omitempty"`
+}
+
+type channelStatsView struct {
+	Conversations      int        `json:"conversations"`
+	ConversationPaired int        `json:"conversationPaired"`
+	InboundTotal       int        `json:"inboundTotal"`
+	Inbound24h         int        `json:"inbound24h"`
+	OutboundTotal      int        `json:"outboundTotal"`
+	OutboundFailed     int        `json:"outboundFailed"`
+	LastSeenAt         *time.Time `json:"lastSeenAt,omitempty"`
+}
+
+func newChannelView(c *model.Channel) channelView {
+	return channelView{
+		ID:                 c.ID,
+		DisplayName:        c.DisplayName,
+		Registered:         true,
+		Enabled:            c.Enabled,
+		HasSignatureSecret: c.HasSignatureSecret(),
+		CallbackTTLSeconds: c.CallbackTTLSeconds,
+		DefaultLocale:      c.DefaultLocale,
+		WebhookPath:        "/kakao/" + url.PathEscape(c.ID) + "/webhook",
+		CreatedAt:          &c.CreatedAt,
+		UpdatedAt:          &c.UpdatedAt,
+	}
+}
+
+// GET /dashboard/api/channels
+func (h *ChannelsHandler) List(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	channels, err := h.channels.List(ctx)
+	if err != nil {
+		writeChannelError(w, err, "Failed to load channels")
+		return
+	}
+	stats, err := h.dashboardRepo.GetChannelStats(ctx)
+	if err != nil {
+		writeChannelError(w, err, "Failed to load channel stats")
+		return
+	}
+
+	views := make([]channelView, 0, len(channels)+len(stats))
+	index := make(map[string]int, len(channels))
+	for i := range channels {
+		index[channels[i].ID] = len(views)
+		view := newChannelView(&channels[i])
+		view.Stats = &channelStatsView{}
+		views = append(views, view)
+	}
+	for _, s := range stats {
+		i, ok := index[s.ChannelID]
+		if !ok {
+			i = len(views)
+			views = append(views, channelView{
+				ID:          s.ChannelID,
+				Enabled:     true,
+				WebhookPath: "/kakao/webhook",
+			})
+		}
+		views[i].Stats = &channelStatsView{
+			Conversations:      s.Conversations,
+			ConversationPaired: s.ConversationPaired,
+			InboundTotal:       s.InboundTotal,
+			Inbound24h:         s.Inbound24h,
+			OutboundTotal:      s.OutboundTotal,
+			OutboundFailed:     s.OutboundFailed,
+			LastSeenAt:         s.LastSeenAt,
+		}
+	}
+
+	writeJSON(w, http.StatusOK, views)
+}
+
+// PUT /dashboard/api/channels/{id}
+// Creates the channel or replaces its settings.
+func (h *ChannelsHandler) Put(w http.ResponseWriter, r *http.Request) {
+	var def service.ChannelDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	channel, err := h.channels.Put(r.Context(), chi.URLParam(r, "id"), def)
+	if err != nil {
+		writeChannelError(w, err, "Failed to save channel")
+		return
+	}
+	writeJSON(w, http.StatusOK, newChannelView(channel))
+}
+
+// DELETE /dashboard/api/channels/{id}
+func (h *ChannelsHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.channels.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeChannelError(w, err, "Failed to delete channel")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeChannelError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidChannel):
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidChannel.Error()+": ")
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": reason})
+	case errors.Is(err, service.ErrChannelNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Channel not found"})
+	default:
+		log.Error().Err(err).Msg("dashboard: " + strings.ToLower(message))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": message})
+	}
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type mockChannelRepo struct {
+	repository.ChannelRepository
+	mock.Mock
+}
+
+func (m *mockChannelRepo) FindAll(ctx context.Context) ([]model.Channel, error) {
+	args := m.Called(ctx)
+	channels, _ := args.Get(0).([]model.Channel)
+	return channels, args.Error(1)
+}
+
+func (m *mockChannelRepo) Upsert(ctx context.Context, params model.UpsertChannelParams) (*model.Channel, error) {
+	args := m.Called(ctx, params)
+	channel, _ := args.Get(0).(*model.Channel)
+	return channel, args.Error(1)
+}
+
+func (m *mockChannelRepo) Delete(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+type mockDashboardRepo struct {
+	repository.DashboardRepository
+	mock.Mock
+}
+
+func (m *mockDashboardRepo) GetChannelStats(ctx context.Context) ([]repository.DashboardChannelStats, error) {
+	args := m.Called(ctx)
+	stats, _ := args.Get(0).([]repository.DashboardChannelStats)
+	return stats, args.Error(1)
+}
+
+func TestChannelsHandler(t *testing.T) {
+	secret := "s3cret"
+	repo := new(mockChannelRepo)
+	repo.On("FindAll", mock.Anything).Return([]model.Channel{{ID: "bot-a", Enabled: true, SignatureSecret: &secret}}, nil)
+	repo.On("Upsert", mock.Anything, mock.Anything).Return(&model.Channel{ID: "bot-a", Enabled: true}, nil)
+	repo.On("Delete", mock.Anything, "bot-a").Return(true, nil)
+	repo.On("Delete", mock.Anything, "bot-z").Return(false, nil)
+	dashboard := new(mockDashboardRepo)
+	dashboard.On("GetChannelStats", mock.Anything).Return([]repository.DashboardChannelStats{{ChannelID: "default", Conversations: 3}}, nil)
+	handler := NewChannelsHandler(service.NewChannelService(repo, i18n.NewCatalog(), ""), dashboard)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		id      string
+		body    string
+		want    int
+		wantIn  []string
+	}{
+		{
+			name:    "lists registered and unregistered channels",
+			handler: handler.List,
+			method:  http.MethodGet,
+			want:    http.StatusOK,
+			wantIn:  []string{`"id":"bot-a"`, `"hasSignatureSecret":true`, `"webhookPath":"/kakao/bot-a/webhook"`, `"id":"default"`, `"registered":false`},
+		},
+		{name: "saves a channel", handler: handler.Put, method: http.MethodPut, id: "bot-a", body: `{"displayName":"A","signatureSecret":"s3cret"}`, want: http.StatusOK, wantIn: []string{`"registered":true`}},
+		{name: "rejects an invalid body", handler: handler.Put, method: http.MethodPut, id: "bot-a", body: `{`, want: http.StatusBadRequest},
+		{name: "rejects an invalid id", handler: handler.Put, method: http.MethodPut, id: "bot/a", body: `{}`, want: http.StatusBadRequest, wantIn: []string{"id must be"}},
+		{name: "rejects a callback TTL above Kakao's limit", handler: handler.Put, method: http.MethodPut, id: "bot-a", body: `{"callbackTtlSeconds":61}`, want: http.StatusBadRequest, wantIn: []string{"callbackTtlSeconds"}},
+		{name: "rejects an unsupported locale", handler: handler.Put, method: http.MethodPut, id: "bot-a", body: `{"defaultLocale":"xx"}`, want: http.StatusBadRequest, wantIn: []string{"unsupported locale"}},
+		{name: "deletes a channel", handler: handler.Delete, method: http.MethodDelete, id: "bot-a", want: http.StatusOK},
+		{name: "reports an unknown channel on delete", handler: handler.Delete, method: http.MethodDelete, id: "bot-z", want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/dashboard/api/channels", strings.NewReader(tc.body))
+			if tc.id != "" {
+				req = req.WithContext(withURLParam(req.Context(), "id", tc.id))
+			}
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+			for _, want := range tc.wantIn {
+				assert.Contains(t, rec.Body.String(), want)
+			}
+		})
+	}
+}
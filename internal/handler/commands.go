@@ -0,0 +1,277 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// Command is a parsed slash command utterance.
+type Command struct {
+	Type string // PAIR, UNPAIR, NEW, STATUS, HELP, or "" when not a built-in
+	// Name is the command as typed, lowercased (e.g. "/연결").
+	Name    string
+	Args    []string
+	RawArgs string
+	// Code is the first argument; the pairing code for /pair.
+	Code string
+}
+
+// CommandContext is passed to built-in command handlers.
+type CommandContext struct {
+	Request         *http.Request
+	Payload         *KakaoWebhookRequest
+	Command         *Command
+	Conversation    *model.ConversationMapping
+	ConversationKey string
+	Localizer       *i18n.Localizer
+}
+
+// CommandSpec declares a built-in command. Usage and Description are
+// catalog keys so help follows the conversation's locale.
+type CommandSpec struct {
+	Type        string
+	Name        string
+	Aliases     []string
+	Usage       i18n.Key
+	Description i18n.Key
+	// MinArgs is the number of arguments required; fewer answers with Usage.
+	MinArgs int
+	// UpperArgs uppercases arguments (pairing codes are case-insensitive).
+	UpperArgs bool
+	Handler   func(h *KakaoHandler, cc *CommandContext) *KakaoResponse
+}
+
+// CommandRegistry resolves slash commands by name or alias and renders /help.
+type CommandRegistry struct {
+	specs  []*CommandSpec
+	byName map[string]*CommandSpec
+}
+
+func NewCommandRegistry() *CommandRegistry {
+	return &CommandRegistry{byName: make(map[string]*CommandSpec)}
+}
+
+func (r *CommandRegistry) Register(spec CommandSpec) error {
+	names := append([]string{spec.Name}, spec.Aliases...)
+	for _, name := range names {
+		name = service.NormalizeCommandName(name)
+		if _, exists := r.byName[name]; exists {
+			return fmt.Errorf("command %s already registered", name)
+		}
+	}
+
+	s := &spec
+	r.specs = append(r.specs, s)
+	for _, name := range names {
+		r.byName[service.NormalizeCommandName(name)] = s
+	}
+	return nil
+}
+
+// MustRegister is Register for static command tables.
+func (r *CommandRegistry) MustRegister(spec CommandSpec) {
+	if err := r.Register(spec); err != nil {
+		panic(err)
+	}
+}
+
+func (r *CommandRegistry) Lookup(name string) *CommandSpec {
+	return r.byName[service.NormalizeCommandName(name)]
+}
+
+// Names returns every registered name and alias.
+func (r *CommandRegistry) Names() []string {
+	names := make([]string, 0, len(r.byName))
+	for name := range r.byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// HelpText lists the built-in commands followed by the account's custom
+// commands, if any. Custom command text is shown as declared.
+func (r *CommandRegistry) HelpText(l *i18n.Localizer, custom []model.AccountCommand) string {
+	var b strings.Builder
+	b.WriteString(l.T(i18n.MsgHelpIntro) + "\n\n")
+	b.WriteString(l.T(i18n.MsgHelpCommands) + "\n")
+	for _, spec := range r.specs {
+		writeHelpLine(&b, spec.usage(l), localizedText(l, spec.Description), spec.Aliases)
+	}
+
+	if len(custom) > 0 {
+		b.WriteString("\n" + l.T(i18n.MsgHelpCustomCommands) + "\n")
+		for _, cmd := range custom {
+			writeHelpLine(&b, commandUsage(cmd.Name, cmd.Usage), cmd.Description, cmd.Aliases)
+		}
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// usage renders the spec's usage line, e.g. "/pair <코드>".
+func (spec *CommandSpec) usage(l *i18n.Localizer) string {
+	return commandUsage(spec.Name, localizedText(l, spec.Usage))
+}
+
+func localizedText(l *i18n.Localizer, key i18n.Key) string {
+	if key == "" {
+		return ""
+	}
+	return l.T(key)
+}
+
+func commandUsage(name, usage string) string {
+	if usage == "" {
+		return name
+	}
+	if strings.HasPrefix(usage, name) {
+		return usage
+	}
+	return name + " " + usage
+}
+
+func writeHelpLine(b *strings.Builder, usage, description string, aliases []string) {
+	b.WriteString("• " + usage)
+	if description != "" {
+		b.WriteString(" - " + description)
+	}
+	if len(aliases) > 0 {
+		b.WriteString(" (" + strings.Join(aliases, ", ") + ")")
+	}
+	b.WriteString("\n")
+}
+
+// Parse splits a slash command utterance. It returns nil for plain text.
+// The returned spec is nil when the name is not a built-in command.
+func (r *CommandRegistry) Parse(utterance string) (*Command, *CommandSpec) {
+	trimmed := strings.TrimSpace(utterance)
+	if !strings.HasPrefix(trimmed, "/") {
+		return nil, nil
+	}
+
+	name, rawArgs, _ := strings.Cut(trimmed, " ")
+	if i := strings.IndexFunc(name, unicode.IsSpace); i >= 0 {
+		name, rawArgs = name[:i], name[i:]+" "+rawArgs
+	}
+	rawArgs = strings.TrimSpace(rawArgs)
+
+	cmd := &Command{
+		Name:    strings.ToLower(name),
+		Args:    splitArgs(rawArgs),
+		RawArgs: rawArgs,
+	}
+
+	spec := r.Lookup(cmd.Name)
+	if spec == nil {
+		return cmd, nil
+	}
+
+	cmd.Type = spec.Type
+	if spec.UpperArgs {
+		for i := range cmd.Args {
+			cmd.Args[i] = strings.ToUpper(cmd.Args[i])
+		}
+	}
+	if len(cmd.Args) > 0 {
+		cmd.Code = cmd.Args[0]
+	}
+	return cmd, spec
+}
+
+// splitArgs splits on whitespace; double quotes group words into one
+// argument.
+func splitArgs(s string) []string {
+	args := []string{}
+	var cur strings.Builder
+	inQuotes, hasArg := false, false
+
+	for _, r := range s {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+			hasArg = true
+		case unicode.IsSpace(r) && !inQuotes:
+			if hasArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				hasArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			hasArg = true
+		}
+	}
+	if hasArg {
+		args = append(args, cur.String())
+	}
+	return args
+}
+
+// builtinCommands is the registry used by KakaoHandler. Built-in names and
+// aliases are reserved and cannot be declared as account commands.
+var builtinCommands = newBuiltinCommandRegistry()
+
+func newBuiltinCommandRegistry() *CommandRegistry {
+	r := NewCommandRegistry()
+	r.MustRegister(CommandSpec{
+		Type:        "PAIR",
+		Name:        "/pair",
+		Aliases:     []string{"/연결"},
+		Usage:       i18n.MsgCmdPairUsage,
+		Description: i18n.MsgCmdPairDescription,
+		MinArgs:     1,
+		UpperArgs:   true,
+		Handler:     (*KakaoHandler).handlePair,
+	})
+	r.MustRegister(CommandSpec{
+		Type:        "UNPAIR",
+		Name:        "/unpair",
+		Aliases:     []string{"/연결해제"},
+		Description: i18n.MsgCmdUnpairDescription,
+		Handler:     (*KakaoHandler).handleUnpair,
+	})
+	r.MustRegister(CommandSpec{
+		Type:        "NEW",
+		Name:        "/new",
+		Aliases:     []string{"/reset", "/새대화"},
+		Description: i18n.MsgCmdNewDescription,
+		Handler:     (*KakaoHandler).handleNew,
+	})
+	r.MustRegister(CommandSpec{
+		Type:        "STATUS",
+		Name:        "/status",
+		Aliases:     []string{"/상태"},
+		Description: i18n.MsgCmdStatusDescription,
+		Handler:     (*KakaoHandler).handleStatus,
+	})
+	r.MustRegister(CommandSpec{
+		Type:        "HELP",
+		Name:        "/help",
+		Aliases:     []string{"/도움말"},
+		Description: i18n.MsgCmdHelpDescription,
+		Handler:     (*KakaoHandler).handleHelp,
+	})
+	return r
+}
+
+// BuiltinCommandNames returns the reserved built-in names and aliases.
+func BuiltinCommandNames() []string {
+	return builtinCommands.Names()
+}
+
+// parseCommand returns the built-in command in utterance, or nil for plain
+// text, unknown commands and commands missing required arguments.
+func parseCommand(utterance string) *Command {
+	cmd, spec := builtinCommands.Parse(utterance)
+	if spec == nil || len(cmd.Args) < spec.MinArgs {
+		return nil
+	}
+	return cmd
+}
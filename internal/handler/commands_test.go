@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type mockCommandRepo struct {
+	mock.Mock
+}
+
+func (m *mockCommandRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.AccountCommand, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AccountCommand), args.Error(1)
+}
+
+func (m *mockCommandRepo) ReplaceForAccount(ctx context.Context, accountID string, params []model.CreateAccountCommandParams) ([]model.AccountCommand, error) {
+	args := m.Called(ctx, accountID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AccountCommand), args.Error(1)
+}
+
+func TestCommandRegistry_Parse(t *testing.T) {
+	t.Run("resolves aliases to the built-in type", func(t *testing.T) {
+		cmd, spec := builtinCommands.Parse("/연결 abcd-1234")
+
+		require.NotNil(t, spec)
+		assert.Equal(t, "PAIR", cmd.Type)
+		assert.Equal(t, "/연결", cmd.Name)
+		assert.Equal(t, "ABCD-1234", cmd.Code)
+	})
+
+	t.Run("keeps unknown commands with parsed args", func(t *testing.T) {
+		cmd, spec := builtinCommands.Parse(`/Order "아이스 아메리카노" 2`)
+
+		assert.Nil(t, spec)
+		assert.Equal(t, "/order", cmd.Name)
+		assert.Equal(t, []string{"아이스 아메리카노", "2"}, cmd.Args)
+		assert.Equal(t, `"아이스 아메리카노" 2`, cmd.RawArgs)
+	})
+
+	t.Run("returns built-in spec even when args are missing", func(t *testing.T) {
+		cmd, spec := builtinCommands.Parse("/pair")
+
+		require.NotNil(t, spec)
+		assert.Empty(t, cmd.Args)
+		assert.Nil(t, parseCommand("/pair"))
+	})
+
+	t.Run("ignores plain text", func(t *testing.T) {
+		cmd, spec := builtinCommands.Parse("안녕하세요 /help")
+
+		assert.Nil(t, cmd)
+		assert.Nil(t, spec)
+	})
+}
+
+func TestCommandRegistry_Register(t *testing.T) {
+	r := NewCommandRegistry()
+	require.NoError(t, r.Register(CommandSpec{Name: "/ping", Aliases: []string{"/핑"}}))
+
+	assert.Error(t, r.Register(CommandSpec{Name: "/pong", Aliases: []string{"/PING"}}))
+	assert.Nil(t, r.Lookup("/pong"), "failed registration must not be partially applied")
+	assert.Equal(t, []string{"/ping", "/핑"}, r.Names())
+}
+
+func TestCommandRegistry_HelpText(t *testing.T) {
+	ko := i18n.NewLocalizer(i18n.NewCatalog(), "ko", nil)
+	help := builtinCommands.HelpText(ko, nil)
+
+	assert.Contains(t, help, "• /pair <코드> - OpenClaw에 연결 (/연결)\n")
+	assert.Contains(t, help, "• /help - 이 도움말 (/도움말)")
+	assert.NotContains(t, help, "OpenClaw 명령어")
+
+	help = builtinCommands.HelpText(ko, []model.AccountCommand{
+		{Name: "/order", Aliases: []string{"/주문"}, Usage: "<메뉴>", Description: "주문하기"},
+	})
+	assert.Contains(t, help, "OpenClaw 명령어:\n• /order <메뉴> - 주문하기 (/주문)")
+
+	en := i18n.NewLocalizer(i18n.NewCatalog(), "en", map[i18n.Key]string{
+		i18n.MsgCmdHelpDescription: "What you are reading",
+	})
+	help = builtinCommands.HelpText(en, nil)
+	assert.Contains(t, help, "• /pair <code> - Connect to OpenClaw (/연결)\n")
+	assert.Contains(t, help, "• /help - What you are reading (/도움말)")
+}
+
+func TestSplitArgs(t *testing.T) {
+	assert.Equal(t, []string{}, splitArgs(""))
+	assert.Equal(t, []string{"a", "b"}, splitArgs("  a \t b "))
+	assert.Equal(t, []string{"a b", "", "c"}, splitArgs(`"a b" "" c`))
+}
+
+func TestAccountCommandsHandler_Replace(t *testing.T) {
+	account := &model.Account{ID: "acc-1"}
+
+	put := func(h *AccountCommandsHandler, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPut, "/openclaw/commands", bytes.NewBufferString(body))
+		req = req.WithContext(withAccount(req.Context(), account))
+		rec := httptest.NewRecorder()
+		h.Replace(rec, req)
+		return rec
+	}
+
+	t.Run("rejects built-in names", func(t *testing.T) {
+		repo := new(mockCommandRepo)
+		h := NewAccountCommandsHandler(service.NewCommandService(repo, BuiltinCommandNames()))
+
+		rec := put(h, `{"commands":[{"name":"/order","aliases":["/상태"]}]}`)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "INVALID_INPUT")
+		assert.Contains(t, rec.Body.String(), "/상태 is a built-in command")
+	})
+
+	t.Run("stores commands", func(t *testing.T) {
+		repo := new(mockCommandRepo)
+		h := NewAccountCommandsHandler(service.NewCommandService(repo, BuiltinCommandNames()))
+		repo.On("ReplaceForAccount", mock.Anything, "acc-1", mock.Anything).
+			Return([]model.AccountCommand{{ID: "c1", Name: "/order", Description: "주문"}}, nil)
+
+		rec := put(h, `{"commands":[{"name":"order","description":"주문"}]}`)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			Commands []model.AccountCommand `json:"commands"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Commands, 1)
+		assert.Equal(t, "/order", resp.Commands[0].Name)
+		assert.Equal(t, []string{}, []string(resp.Commands[0].Aliases))
+	})
+}
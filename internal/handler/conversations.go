@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// ConversationsHandler serves conversation history and state so agents can
+// rebuild context from earlier turns.
+type ConversationsHandler struct {
+	messageService *service.MessageService
+	stateService   *service.ConversationStateService
+}
+
+func NewConversationsHandler(messageService *service.MessageService, stateService *service.ConversationStateService) *ConversationsHandler {
+	return &ConversationsHandler{messageService: messageService, stateService: stateService}
+}
+
+// GET /v1/conversations/{key}/messages?cursor=&limit=&mode=text|full&thread=
+// Only messages of the caller's account are returned, so a conversation
+// that moved to another account shows the part that belonged to this one.
+func (h *ConversationsHandler) Messages(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	key, ok := conversationKeyParam(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	query := r.URL.Query()
+	q := service.HistoryQuery{
+		Cursor:   query.Get("cursor"),
+		Mode:     query.Get("mode"),
+		ThreadID: query.Get("thread"),
+	}
+	if raw := query.Get("limit"); raw != "" {
+		q.Limit, err = strconv.Atoi(raw)
+		if err != nil {
+			httputil.WriteError(w, apperrors.InvalidInput("limit", "must be a number"))
+			return
+		}
+	}
+
+	page, err := h.messageService.History(r.Context(), account.ID, key, q)
+	if errors.Is(err, service.ErrInvalidHistoryQuery) {
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidHistoryQuery.Error()+": ")
+		httputil.WriteError(w, apperrors.ValidationError(reason))
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to load conversation history")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, page)
+}
+
+// GET /v1/conversations/{key}/state
+func (h *ConversationsHandler) ListState(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+	key, ok := conversationKeyParam(w, r)
+	if !ok {
+		return
+	}
+
+	states, err := h.stateService.List(r.Context(), account.ID, key)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list conversation state")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"state": states})
+}
+
+// GET /v1/conversations/{key}/state/{name}
+func (h *ConversationsHandler) GetState(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+	key, ok := conversationKeyParam(w, r)
+	if !ok {
+		return
+	}
+
+	state, err := h.stateService.Get(r.Context(), account.ID, key, chi.URLParam(r, "name"))
+	if err != nil {
+		writeStateError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, state)
+}
+
+// PUT /v1/conversations/{key}/state/{name}
+// Body: {"value": any JSON, "ttlSeconds": 0, "version": 3}. Without version
+// the write is unconditional; a stale version answers 409.
+func (h *ConversationsHandler) PutState(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+	key, ok := conversationKeyParam(w, r)
+	if !ok {
+		return
+	}
+
+	var req service.PutConversationStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+
+	state, err := h.stateService.Put(r.Context(), account.ID, key, chi.URLParam(r, "name"), req)
+	if err != nil {
+		writeStateError(w, err)
+		return
+	}
+	httputil.WriteJSON(w, http.StatusOK, state)
+}
+
+// DELETE /v1/conversations/{key}/state/{name}?version=
+func (h *ConversationsHandler) DeleteState(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+	key, ok := conversationKeyParam(w, r)
+	if !ok {
+		return
+	}
+
+	var version *int64
+	if raw := r.URL.Query().Get("version"); raw != "" {
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			httputil.WriteError(w, apperrors.InvalidInput("version", "must be a number"))
+			return
+		}
+		version = &v
+	}
+
+	if err := h.stateService.Delete(r.Context(), account.ID, key, chi.URLParam(r, "name"), version); err != nil {
+		writeStateError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func conversationKeyParam(w http.ResponseWriter, r *http.Request) (string, bool) {
+	key, err := url.PathUnescape(chi.URLParam(r, "key"))
+	if err != nil || key == "" {
+		httputil.WriteError(w, apperrors.InvalidInput("key", "invalid conversation key"))
+		return "", false
+	}
+	return key, true
+}
+
+func writeStateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidConversationState):
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidConversationState.Error()+": ")
+		httputil.WriteError(w, apperrors.ValidationError(reason))
+	case errors.Is(err, service.ErrStateNotFound):
+		httputil.WriteError(w, apperrors.NotFound("State key"))
+	case errors.Is(err, service.ErrConversationNotFound):
+		httputil.WriteError(w, apperrors.NotFound("Conversation"))
+	case errors.Is(err, service.ErrStateVersionConflict):
+		httputil.WriteError(w, apperrors.New(apperrors.ErrCodeConflict, "State key has a different version"))
+	default:
+		log.Error().Err(err).Msg("failed to access conversation state")
+		httputil.WriteError(w, apperrors.Database(err))
+	}
+}
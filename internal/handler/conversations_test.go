@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+func TestConversationsHandler_Messages(t *testing.T) {
+	now := time.Now()
+	inboundRepo, outboundRepo := new(mockInboundRepo), new(mockOutboundRepo)
+	inboundRepo.On("FindByConversationKey", mock.Anything, "acc-1", "bot:alice", "", (*model.MessageCursor)(nil), 51).
+		Return([]model.InboundMessage{{ID: "in-1", ConversationKey: "bot:alice", KakaoPayload: json.RawMessage(`{}`), CreatedAt: now.Add(-time.Minute)}}, nil)
+	outboundRepo.On("FindByConversationKey", mock.Anything, "acc-1", "bot:alice", "", (*model.MessageCursor)(nil), 51).
+		Return([]model.OutboundMessage{{ID: "out-1", ConversationKey: "bot:alice", ResponsePayload: json.RawMessage(`{}`), CreatedAt: now}}, nil)
+	handler := NewConversationsHandler(service.NewMessageService(inboundRepo, outboundRepo), nil)
+
+	get := func(key, query string, account *model.Account) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/conversations/"+key+"/messages"+query, nil)
+		ctx := withURLParam(req.Context(), "key", key)
+		if account != nil {
+			ctx = withAccount(ctx, account)
+		}
+		rec := httptest.NewRecorder()
+		handler.Messages(rec, req.WithContext(ctx))
+		return rec
+	}
+	account := &model.Account{ID: "acc-1"}
+
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, get("bot%3Aalice", "", nil).Code)
+	})
+
+	t.Run("returns the account's timeline oldest first", func(t *testing.T) {
+		rec := get("bot%3Aalice", "", account)
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var page service.HistoryPage
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &page))
+		require.Len(t, page.Messages, 2)
+		assert.Equal(t, "in-1", page.Messages[0].ID)
+		assert.Equal(t, "out-1", page.Messages[1].ID)
+		assert.Empty(t, page.NextCursor)
+	})
+
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{name: "rejects a non-numeric limit", query: "?limit=ten"},
+		{name: "rejects a limit out of range", query: "?limit=500"},
+		{name: "rejects an unknown mode", query: "?mode=raw"},
+		{name: "rejects an invalid cursor", query: "?cursor=nope"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, http.StatusBadRequest, get("bot%3Aalice", tc.query, account).Code)
+		})
+	}
+}
+
+// memStateRepo keeps conversation state in memory, keyed by
+// "accountID/conversationKey/key".
+type memStateRepo struct {
+	repository.ConversationStateRepository
+	states map[string]model.ConversationState
+}
+
+func (r *memStateRepo) FindByConversation(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error) {
+	var states []model.ConversationState
+	for id, st := range r.states {
+		if strings.HasPrefix(id, accountID+"/"+conversationKey+"/") {
+			states = append(states, st)
+		}
+	}
+	return states, nil
+}
+
+func (r *memStateRepo) Find(ctx context.Context, accountID, conversationKey, key string) (*model.ConversationState, error) {
+	st, ok := r.states[accountID+"/"+conversationKey+"/"+key]
+	if !ok {
+		return nil, nil
+	}
+	return &st, nil
+}
+
+func (r *memStateRepo) Put(ctx context.Context, params model.PutConversationStateParams) (*model.ConversationState, error) {
+	id := params.AccountID + "/" + params.ConversationKey + "/" + params.Key
+	st, exists := r.states[id]
+	if params.IfVersion != nil && *params.IfVersion != st.Version {
+		return nil, nil
+	}
+	if !exists {
+		st = model.ConversationState{AccountID: params.AccountID, ConversationKey: params.ConversationKey, Key: params.Key}
+	}
+	st.Value, st.ExpiresAt = params.Value, params.ExpiresAt
+	st.Version++
+	r.states[id] = st
+	return &st, nil
+}
+
+func (r *memStateRepo) Delete(ctx context.Context, accountID, conversationKey, key string, ifVersion *int64) (bool, error) {
+	id := accountID + "/" + conversationKey + "/" + key
+	st, ok := r.states[id]
+	if !ok || (ifVersion != nil && *ifVersion != st.Version) {
+		return false, nil
+	}
+	delete(r.states, id)
+	return true, nil
+}
+
+func TestConversationsHandler_State(t *testing.T) {
+	accountID, otherAccount := "acc-1", "acc-2"
+	convRepo := new(mockConversationRepo)
+	convRepo.On("FindByKey", mock.Anything, "bot:alice").
+		Return(&model.ConversationMapping{ConversationKey: "bot:alice", AccountID: &accountID, State: model.PairingStatePaired}, nil)
+	convRepo.On("FindByKey", mock.Anything, "bot:bob").
+		Return(&model.ConversationMapping{ConversationKey: "bot:bob", AccountID: &otherAccount, State: model.PairingStatePaired}, nil)
+	states := &memStateRepo{states: map[string]model.ConversationState{
+		"acc-1/bot:alice/step": {Key: "step", Value: json.RawMessage(`2`), Version: 3},
+	}}
+	handler := NewConversationsHandler(nil, service.NewConversationStateService(states, convRepo))
+
+	serve := func(h http.HandlerFunc, method, key, name, query, body string, account *model.Account) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/conversations/state"+query, strings.NewReader(body))
+		ctx := withURLParam(req.Context(), "key", key)
+		if name != "" {
+			ctx = withURLParam(ctx, "name", name)
+		}
+		if account != nil {
+			ctx = withAccount(ctx, account)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req.WithContext(ctx))
+		return rec
+	}
+	account := &model.Account{ID: "acc-1"}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		key     string
+		stateID string
+		query   string
+		body    string
+		account *model.Account
+		want    int
+	}{
+		{name: "list requires an account", handler: handler.ListState, method: http.MethodGet, key: "bot:alice", want: http.StatusUnauthorized},
+		{name: "list returns the keys", handler: handler.ListState, method: http.MethodGet, key: "bot:alice", account: account, want: http.StatusOK},
+		{name: "get requires an account", handler: handler.GetState, method: http.MethodGet, key: "bot:alice", stateID: "step", want: http.StatusUnauthorized},
+		{name: "get returns a key", handler: handler.GetState, method: http.MethodGet, key: "bot:alice", stateID: "step", account: account, want: http.StatusOK},
+		{name: "get reports a missing key", handler: handler.GetState, method: http.MethodGet, key: "bot:alice", stateID: "lang", account: account, want: http.StatusNotFound},
+		{name: "get rejects an invalid key name", handler: handler.GetState, method: http.MethodGet, key: "bot:alice", stateID: "a b", account: account, want: http.StatusBadRequest},
+		{name: "get does not see other accounts' state", handler: handler.GetState, method: http.MethodGet, key: "bot:alice", stateID: "step", account: &model.Account{ID: "acc-2"}, want: http.StatusNotFound},
+		{name: "put requires an account", handler: handler.PutState, method: http.MethodPut, key: "bot:alice", stateID: "lang", body: `{"value":"ko"}`, want: http.StatusUnauthorized},
+		{name: "put writes a key", handler: handler.PutState, method: http.MethodPut, key: "bot:alice", stateID: "lang", body: `{"value":"ko"}`, account: account, want: http.StatusOK},
+		{name: "put rejects a stale version", handler: handler.PutState, method: http.MethodPut, key: "bot:alice", stateID: "step", body: `{"value":3,"version":2}`, account: account, want: http.StatusConflict},
+		{name: "put rejects an invalid body", handler: handler.PutState, method: http.MethodPut, key: "bot:alice", stateID: "lang", body: `{`, account: account, want: http.StatusBadRequest},
+		{name: "put refuses another account's conversation", handler: handler.PutState, method: http.MethodPut, key: "bot:bob", stateID: "lang", body: `{"value":"ko"}`, account: account, want: http.StatusNotFound},
+		{name: "delete requires an account", handler: handler.DeleteState, method: http.MethodDelete, key: "bot:alice", stateID: "step", want: http.StatusUnauthorized},
+		{name: "delete rejects a non-numeric version", handler: handler.DeleteState, method: http.MethodDelete, key: "bot:alice", stateID: "step", query: "?version=x", account: account, want: http.StatusBadRequest},
+		{name: "delete rejects a stale version", handler: handler.DeleteState, method: http.MethodDelete, key: "bot:alice", stateID: "step", query: "?version=1", account: account, want: http.StatusConflict},
+		{name: "delete removes a key", handler: handler.DeleteState, method: http.MethodDelete, key: "bot:alice", stateID: "step", query: "?version=3", account: account, want: http.StatusNoContent},
+		{name: "delete reports a missing key", handler: handler.DeleteState, method: http.MethodDelete, key: "bot:alice", stateID: "step", account: account, want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serve(tc.handler, tc.method, tc.key, tc.stateID, tc.query, tc.body, tc.account)
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 	"time"
@@ -12,7 +13,6 @@ import (
 	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
-	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
 )
 
 type DashboardHandler struct {
@@ -22,9 +22,10 @@ type DashboardHandler struct {
 	inboundRepo    repository.InboundMessageRepository
 	outboundRepo   repository.OutboundMessageRepository
 	sessionService *service.SessionService
+	lifecycle      *service.LifecycleService
 	messageService *service.MessageService
 	broker         *sse.Broker
-	indexHTML       []byte
+	indexHTML      []byte
 }
 
 func NewDashboardHandler(
@@ -34,6 +35,7 @@ func NewDashboardHandler(
 	inboundRepo repository.InboundMessageRepository,
 	outboundRepo repository.OutboundMessageRepository,
 	sessionService *service.SessionService,
+	lifecycle *service.LifecycleService,
 	messageService *service.MessageService,
 	broker *sse.Broker,
 	indexHTML []byte,
@@ -45,9 +47,10 @@ func NewDashboardHandler(
 		inboundRepo:    inboundRepo,
 		outboundRepo:   outboundRepo,
 		sessionService: sessionService,
+		lifecycle:      lifecycle,
 		messageService: messageService,
 		broker:         broker,
-		indexHTML:       indexHTML,
+		indexHTML:      indexHTML,
 	}
 }
 
@@ -65,6 +68,7 @@ func (h *DashboardHandler) Overview(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to get stats"})
 		return
 	}
+	sseStats := h.broker.Stats()
 
 	writeJSON(w, http.StatusOK, map[string]any{
 		"accounts":             stats.AccountCount,
@@ -76,7 +80,12 @@ func (h *DashboardHandler) Overview(w http.ResponseWriter, r *http.Request) {
 		"inboundTotal":         stats.InboundTotal,
 		"outboundTotal":        stats.OutboundTotal,
 		"outboundFailed":       stats.OutboundFailed,
-		"sseClients":           h.broker.TotalClients(),
+		"sseClients":           sseStats.Clients,
+		"sseDelivered":         sseStats.Delivered,
+		"sseDropped":           sseStats.Dropped,
+		"sseOverflows":         sseStats.OverflowDisconnects,
+		"sseMaxLagMs":          sseStats.MaxLag.Milliseconds(),
+		"sseBufferSize":        h.broker.BufferSize(),
 		"timestamp":            time.Now().UnixMilli(),
 	})
 }
@@ -189,9 +198,27 @@ func (h *DashboardHandler) AccountStats(w http.ResponseWriter, r *http.Request)
 		"outboundFailed": stats.OutboundFailed,
 		"conversations":  len(convs),
 		"sseClients":     h.broker.ClientCount(accountID),
+		"sseStreams":     sseStreams(h.broker.ClientStats(accountID)),
 	})
 }
 
+// sseStreams reports each connected stream's delivery lag on this replica.
+func sseStreams(stats []sse.ClientStats) []map[string]any {
+	streams := make([]map[string]any, 0, len(stats))
+	for _, st := range stats {
+		streams = append(streams, map[string]any{
+			"competing":   st.Competing,
+			"connectedAt": st.ConnectedAt.Format(time.RFC3339),
+			"pending":     st.Pending,
+			"delivered":   st.Delivered,
+			"dropped":     st.Dropped,
+			"lagMs":       st.Lag.Milliseconds(),
+			"maxLagMs":    st.MaxLag.Milliseconds(),
+		})
+	}
+	return streams
+}
+
 func (h *DashboardHandler) AccountFailedMessages(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	accountID := chi.URLParam(r, "id")
@@ -249,16 +276,13 @@ func (h *DashboardHandler) RegenerateToken(w http.ResponseWriter, r *http.Reques
 	ctx := r.Context()
 	accountID := chi.URLParam(r, "id")
 
-	token, err := util.GenerateToken()
-	if err != nil {
-		log.Error().Err(err).Msg("dashboard: failed to generate token")
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to generate token"})
+	token, err := h.lifecycle.RotateToken(ctx, accountID, service.LifecycleReasonAdmin)
+	if errors.Is(err, service.ErrAccountNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Account not found"})
 		return
 	}
-
-	tokenHash := util.HashToken(token)
-	if _, err := h.accountRepo.UpdateToken(ctx, accountID, tokenHash); err != nil {
-		log.Error().Err(err).Msg("dashboard: failed to update token")
+	if err != nil {
+		log.Error().Err(err).Msg("dashboard: failed to regenerate token")
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to regenerate token"})
 		return
 	}
@@ -283,7 +307,16 @@ func (h *DashboardHandler) DisconnectSession(w http.ResponseWriter, r *http.Requ
 	ctx := r.Context()
 	sessionID := chi.URLParam(r, "id")
 
-	if err := h.sessionService.Disconnect(ctx, sessionID); err != nil {
+	err := h.lifecycle.DisconnectSession(ctx, sessionID, service.LifecycleReasonAdmin)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found"})
+		return
+	}
+	if errors.Is(err, service.ErrInvalidTransition) {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "Session is already closed"})
+		return
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("dashboard: failed to disconnect session")
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to disconnect session"})
 		return
@@ -296,7 +329,12 @@ func (h *DashboardHandler) DeleteSession(w http.ResponseWriter, r *http.Request)
 	ctx := r.Context()
 	sessionID := chi.URLParam(r, "id")
 
-	if err := h.sessionService.Delete(ctx, sessionID); err != nil {
+	err := h.lifecycle.DeleteSession(ctx, sessionID, service.LifecycleReasonAdmin)
+	if errors.Is(err, service.ErrSessionNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found"})
+		return
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("dashboard: failed to delete session")
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to delete session"})
 		return
@@ -307,9 +345,15 @@ func (h *DashboardHandler) DeleteSession(w http.ResponseWriter, r *http.Request)
 
 func (h *DashboardHandler) DeleteConversation(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
+	accountID := chi.URLParam(r, "id")
 	convID := chi.URLParam(r, "convId")
 
-	if err := h.convRepo.Delete(ctx, convID); err != nil {
+	err := h.lifecycle.DeleteConversation(ctx, accountID, convID, service.LifecycleReasonAdmin)
+	if errors.Is(err, service.ErrConversationNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Conversation not found"})
+		return
+	}
+	if err != nil {
 		log.Error().Err(err).Msg("dashboard: failed to delete conversation")
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Failed to delete conversation"})
 		return
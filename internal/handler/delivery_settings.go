@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// DeliverySettingsHandler lets an OpenClaw client turn per-conversation
+// ordered delivery on or off and set the coalescing window.
+type DeliverySettingsHandler struct {
+	ordering   *service.OrderingService
+	coalescing *service.CoalescingService
+}
+
+func NewDeliverySettingsHandler(ordering *service.OrderingService, coalescing *service.CoalescingService) *DeliverySettingsHandler {
+	return &DeliverySettingsHandler{ordering: ordering, coalescing: coalescing}
+}
+
+// GET /v1/delivery-settings
+func (h *DeliverySettingsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	h.writeSettings(w, account)
+}
+
+// PUT /v1/delivery-settings
+func (h *DeliverySettingsHandler) Update(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var req struct {
+		OrderedDelivery  *bool `json:"orderedDelivery"`
+		CoalesceWindowMs *int  `json:"coalesceWindowMs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+	if req.OrderedDelivery == nil && req.CoalesceWindowMs == nil {
+		httputil.WriteError(w, apperrors.MissingRequired("orderedDelivery or coalesceWindowMs"))
+		return
+	}
+
+	updated := account
+	var err error
+	if req.CoalesceWindowMs != nil {
+		window := time.Duration(*req.CoalesceWindowMs) * time.Millisecond
+		updated, err = h.coalescing.SetWindow(r.Context(), account.ID, window)
+		if errors.Is(err, service.ErrInvalidCoalesceWindow) {
+			httputil.WriteError(w, apperrors.InvalidInput("coalesceWindowMs",
+				fmt.Sprintf("must be between 0 and %d", service.MaxCoalesceWindow.Milliseconds())))
+			return
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("failed to update delivery settings")
+			httputil.WriteError(w, apperrors.Database(err))
+			return
+		}
+	}
+	if req.OrderedDelivery != nil {
+		updated, err = h.ordering.SetOrdered(r.Context(), account.ID, *req.OrderedDelivery)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to update delivery settings")
+			httputil.WriteError(w, apperrors.Database(err))
+			return
+		}
+	}
+
+	log.Info().
+		Str("accountId", account.ID).
+		Bool("orderedDelivery", updated.OrderedDelivery).
+		Int("coalesceWindowMs", updated.CoalesceWindowMs).
+		Msg("delivery settings updated")
+
+	h.writeSettings(w, updated)
+}
+
+func (h *DeliverySettingsHandler) writeSettings(w http.ResponseWriter, account *model.Account) {
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"orderedDelivery":    account.OrderedDelivery,
+		"holdTimeoutSeconds": int(h.ordering.Timeout().Seconds()),
+		"coalesceWindowMs":   account.CoalesceWindowMs,
+	})
+}
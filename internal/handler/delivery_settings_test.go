@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+func TestDeliverySettingsHandler(t *testing.T) {
+	account := &model.Account{ID: "acc-1"}
+	accounts := new(mockAccountRepo)
+	accounts.On("UpdateOrderedDelivery", mock.Anything, "acc-1", true).Return(&model.Account{ID: "acc-1", OrderedDelivery: true}, nil)
+	accounts.On("UpdateOrderedDelivery", mock.Anything, "acc-1", false).Return(&model.Account{ID: "acc-1"}, nil)
+	accounts.On("UpdateCoalesceWindow", mock.Anything, "acc-1", 1500).Return(&model.Account{ID: "acc-1", CoalesceWindowMs: 1500}, nil)
+	inbound := new(mockInboundRepo)
+	inbound.On("ReleaseAllHeld", mock.Anything, "acc-1").Return([]model.InboundMessage{}, nil)
+	handler := NewDeliverySettingsHandler(
+		service.NewOrderingService(accounts, inbound, nopBroker{}, nil, 2*time.Minute),
+		service.NewCoalescingService(accounts, inbound, nopBroker{}, nil, nil, nil),
+	)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		account *model.Account
+		body    string
+		want    int
+		wantIn  string
+	}{
+		{name: "requires a paired session to read", handler: handler.Get, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "reads the settings", handler: handler.Get, method: http.MethodGet, account: account, want: http.StatusOK, wantIn: `"holdTimeoutSeconds":120`},
+		{name: "requires a paired session to update", handler: handler.Update, method: http.MethodPut, body: `{"orderedDelivery":true}`, want: http.StatusUnauthorized},
+		{name: "turns ordered delivery on", handler: handler.Update, method: http.MethodPut, account: account, body: `{"orderedDelivery":true}`, want: http.StatusOK, wantIn: `"orderedDelivery":true`},
+		{name: "turns ordered delivery off", handler: handler.Update, method: http.MethodPut, account: account, body: `{"orderedDelivery":false}`, want: http.StatusOK, wantIn: `"orderedDelivery":false`},
+		{name: "sets the coalescing window", handler: handler.Update, method: http.MethodPut, account: account, body: `{"coalesceWindowMs":1500}`, want: http.StatusOK, wantIn: `"coalesceWindowMs":1500`},
+		{name: "rejects an invalid body", handler: handler.Update, method: http.MethodPut, account: account, body: `{`, want: http.StatusBadRequest},
+		{name: "rejects an empty update", handler: handler.Update, method: http.MethodPut, account: account, body: `{}`, want: http.StatusBadRequest},
+		{name: "rejects a negative window", handler: handler.Update, method: http.MethodPut, account: account, body: `{"coalesceWindowMs":-1}`, want: http.StatusBadRequest, wantIn: "coalesceWindowMs"},
+		{name: "rejects a window above the maximum", handler: handler.Update, method: http.MethodPut, account: account, body: `{"coalesceWindowMs":600000}`, want: http.StatusBadRequest, wantIn: "coalesceWindowMs"},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.account != nil {
+				ctx = withAccount(ctx, tc.account)
+			}
+			req := httptest.NewRequest(tc.method, "/v1/delivery-settings", strings.NewReader(tc.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+			if tc.wantIn != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantIn)
+			}
+		})
+	}
+}
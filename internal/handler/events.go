@@ -5,11 +5,16 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
 )
@@ -17,15 +22,36 @@ import (
 type EventsHandler struct {
 	broker         *sse.Broker
 	messageService *service.MessageService
+	attachments    *service.AttachmentService
+	stateService   *service.ConversationStateService
+	delivery       *service.DeliveryService
 }
 
-func NewEventsHandler(broker *sse.Broker, messageService *service.MessageService) *EventsHandler {
+func NewEventsHandler(
+	broker *sse.Broker,
+	messageService *service.MessageService,
+	attachments *service.AttachmentService,
+	stateService *service.ConversationStateService,
+	delivery *service.DeliveryService,
+) *EventsHandler {
 	return &EventsHandler{
 		broker:         broker,
 		messageService: messageService,
+		attachments:    attachments,
+		stateService:   stateService,
+		delivery:       delivery,
 	}
 }
 
+// Delivery modes selected with the ?delivery= query parameter.
+const (
+	// deliveryBroadcast sends every message to every stream of the account.
+	deliveryBroadcast = "broadcast"
+	// deliveryCompeting sends each message to one of the account's
+	// competing streams, which acks it when done.
+	deliveryCompeting = "competing"
+)
+
 func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	account := middleware.GetAccount(r.Context())
 	session := middleware.GetSession(r.Context())
@@ -47,35 +73,94 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	delivery := r.URL.Query().Get("delivery")
+	switch delivery {
+	case "":
+		delivery = deliveryBroadcast
+	case deliveryBroadcast:
+	case deliveryCompeting:
+		if accountID == "" {
+			httputil.WriteError(w, apperrors.SessionNotPaired())
+			return
+		}
+		if h.delivery == nil {
+			httputil.WriteError(w, apperrors.NotConfigured("Competing delivery"))
+			return
+		}
+	default:
+		httputil.WriteError(w, apperrors.InvalidInput("delivery", "must be broadcast or competing"))
+		return
+	}
+
+	filter := eventFilter(r)
+	if delivery == deliveryCompeting && filter.FiltersMessages() {
+		httputil.WriteError(w, apperrors.InvalidInput("delivery", "competing streams cannot filter messages"))
+		return
+	}
+
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "Streaming not supported"})
 		return
 	}
 
+	ctx := r.Context()
+
+	var consumerID string
+	if delivery == deliveryCompeting {
+		var err error
+		consumerID, err = h.delivery.Join(ctx, accountID)
+		if err != nil {
+			log.Error().Err(err).Str("accountId", accountID).Msg("failed to join competing consumers")
+			httputil.WriteError(w, apperrors.Internal("Failed to join competing delivery"))
+			return
+		}
+		defer func() {
+			// The request context is already done here.
+			if err := h.delivery.Leave(context.Background(), accountID, consumerID); err != nil {
+				log.Warn().Err(err).Str("consumerId", consumerID).Msg("failed to leave competing consumers")
+			}
+		}()
+	}
+
 	w.Header().Set("Content-Type", "text/event-stream")
 	w.Header().Set("Cache-Control", "no-cache")
 	w.Header().Set("Connection", "keep-alive")
 	w.Header().Set("X-Accel-Buffering", "no")
 
-	client := h.broker.Subscribe(subscribeID)
+	// ?include=state adds the conversation state to message and command
+	// events. It needs an account: a pending session has no conversations.
+	stateAccountID := ""
+	if accountID != "" && h.stateService != nil && includes(r, "state") {
+		stateAccountID = accountID
+	}
+
+	var client *sse.Client
+	if consumerID != "" {
+		client = h.broker.SubscribeCompeting(subscribeID, filter)
+	} else {
+		client = h.broker.Subscribe(subscribeID, filter)
+	}
 	defer h.broker.Unsubscribe(client)
 
 	log.Info().
 		Str("subscribeId", subscribeID).
 		Str("accountId", accountID).
+		Str("delivery", delivery).
 		Msg("sse connection established")
 
-	ctx := r.Context()
-
-	// Send queued messages only if we have an account
-	if accountID != "" {
-		if err := h.sendQueuedMessages(ctx, w, flusher, accountID); err != nil {
+	// Send stored messages only if we have an account
+	if consumerID != "" {
+		if err := h.sendClaimedMessages(ctx, w, flusher, accountID, consumerID, stateAccountID); err != nil {
+			log.Error().Err(err).Msg("failed to send claimed messages")
+		}
+	} else if accountID != "" {
+		if err := h.sendQueuedMessages(ctx, w, flusher, accountID, stateAccountID, filter); err != nil {
 			log.Error().Err(err).Msg("failed to send queued messages")
 		}
 	}
 
-	h.sendEvent(w, flusher, "connected", map[string]any{
+	connected := map[string]any{
 		"accountId": accountID,
 		"sessionId": func() string {
 			if session != nil {
@@ -89,11 +174,25 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			}
 			return "paired"
 		}(),
-	})
+		"delivery": delivery,
+	}
+	if consumerID != "" {
+		connected["consumerId"] = consumerID
+	}
+	h.sendEvent(w, flusher, "connected", connected)
 
 	heartbeat := time.NewTicker(sse.HeartbeatInterval)
 	defer heartbeat.Stop()
 
+	// A competing stream also claims on a timer, which picks up leases
+	// that lapsed without a wake-up. Nil for broadcast streams.
+	var poll <-chan time.Time
+	if consumerID != "" {
+		ticker := time.NewTicker(service.ConsumerPollInterval)
+		defer ticker.Stop()
+		poll = ticker.C
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -103,16 +202,48 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			return
 
 		case <-client.Done:
+			if client.Overflowed() {
+				h.sendOverflow(w, flusher, client)
+				log.Warn().
+					Str("subscribeId", subscribeID).
+					Int64("dropped", client.Dropped()).
+					Msg("sse connection closed for falling behind")
+				return
+			}
+			// Flush what was published before the close, such as the
+			// session_disconnected event that explains it.
+			for len(client.Events) > 0 {
+				if err := h.sendRawEvent(w, flusher, h.withState(ctx, stateAccountID, <-client.Events)); err != nil {
+					break
+				}
+				client.Sent()
+			}
 			log.Info().
 				Str("subscribeId", subscribeID).
 				Msg("sse connection closed by broker")
 			return
 
 		case event := <-client.Events:
-			if err := h.sendRawEvent(w, flusher, event); err != nil {
+			if err := h.sendRawEvent(w, flusher, h.withState(ctx, stateAccountID, event)); err != nil {
 				log.Error().Err(err).Msg("failed to send event")
 				return
 			}
+			client.Sent()
+
+		case <-client.Wake:
+			if err := h.sendClaimedMessages(ctx, w, flusher, accountID, consumerID, stateAccountID); err != nil {
+				log.Error().Err(err).Msg("failed to send claimed messages")
+				return
+			}
+
+		case <-poll:
+			if err := h.delivery.Heartbeat(ctx, accountID, consumerID); err != nil {
+				log.Warn().Err(err).Str("consumerId", consumerID).Msg("competing consumer heartbeat failed")
+			}
+			if err := h.sendClaimedMessages(ctx, w, flusher, accountID, consumerID, stateAccountID); err != nil {
+				log.Error().Err(err).Msg("failed to send claimed messages")
+				return
+			}
 
 		case <-heartbeat.C:
 			if _, err := fmt.Fprintf(w, ": ping\n\n"); err != nil {
@@ -126,41 +257,190 @@ func (h *EventsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-func (h *EventsHandler) sendQueuedMessages(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, accountID string) error {
+// sendOverflow ends a stream the broker closed for falling behind. Buffered
+// events without an ID go out first since nothing resends them; messages
+// are skipped, as they stay queued and are sent again on reconnect. The
+// terminal overflow event then asks the client to reconnect.
+func (h *EventsHandler) sendOverflow(w http.ResponseWriter, flusher http.Flusher, client *sse.Client) {
+	for len(client.Events) > 0 {
+		event := <-client.Events
+		if event.ID != "" {
+			continue
+		}
+		if err := h.sendRawEvent(w, flusher, event); err != nil {
+			return
+		}
+		client.Sent()
+	}
+
+	h.sendEvent(w, flusher, sse.OverflowEventType, map[string]any{
+		"reason":     "slow_consumer",
+		"dropped":    client.Dropped(),
+		"bufferSize": cap(client.Events),
+	})
+}
+
+// sendQueuedMessages sends the account's queued messages that pass filter
+// and marks them delivered. Filtered-out messages stay queued for the
+// account's other streams, and so does everything while the account has
+// competing consumers, which only claim queued messages.
+func (h *EventsHandler) sendQueuedMessages(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, accountID, stateAccountID string, filter *sse.Filter) error {
 	messages, err := h.messageService.FindQueuedByAccountID(ctx, accountID)
 	if err != nil {
 		return err
 	}
+	if filter != nil {
+		messages = slices.DeleteFunc(messages, func(msg model.InboundMessage) bool {
+			return !filter.Match(sse.Event{Type: msg.SSEEventType(), Data: msg.ToSSEEventData()})
+		})
+	}
+
+	markDelivered := func(msg *model.InboundMessage) {
+		if err := h.messageService.MarkDelivered(ctx, msg.ID); err != nil {
+			log.Warn().Err(err).Str("messageId", msg.ID).Msg("failed to mark message as delivered")
+		}
+	}
+	if len(messages) > 0 && h.delivery != nil {
+		competing, err := h.delivery.HasConsumers(ctx, accountID)
+		if err != nil {
+			// Leave the messages queued rather than risk taking them
+			// from competing consumers.
+			log.Warn().Err(err).Str("accountId", accountID).Msg("failed to check competing consumers")
+		}
+		if competing || err != nil {
+			markDelivered = nil
+		}
+	}
+
+	err = h.sendMessages(ctx, w, flusher, messages, stateAccountID, markDelivered)
+	if err != nil {
+		return err
+	}
 
-	for _, msg := range messages {
+	if len(messages) > 0 {
+		log.Info().
+			Str("accountId", accountID).
+			Int("count", len(messages)).
+			Msg("sent queued messages")
+	}
+
+	return nil
+}
+
+// sendClaimedMessages sends the messages this competing stream claims. A
+// failed claim is logged and retried on the next wake-up or poll; only a
+// failed write is returned.
+func (h *EventsHandler) sendClaimedMessages(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, accountID, consumerID, stateAccountID string) error {
+	messages, err := h.delivery.Claim(ctx, accountID, consumerID)
+	if err != nil {
+		log.Warn().Err(err).Str("consumerId", consumerID).Msg("failed to claim messages")
+		return nil
+	}
+	return h.sendMessages(ctx, w, flusher, messages, stateAccountID, nil)
+}
+
+// sendMessages sends stored messages as message or command events, calling
+// sent after each one that was written.
+func (h *EventsHandler) sendMessages(ctx context.Context, w http.ResponseWriter, flusher http.Flusher, messages []model.InboundMessage, stateAccountID string, sent func(msg *model.InboundMessage)) error {
+	if h.attachments != nil {
+		if err := h.attachments.AttachLinks(ctx, messages); err != nil {
+			log.Warn().Err(err).Msg("failed to load attachments for stored messages")
+		}
+	}
+
+	for i := range messages {
+		msg := &messages[i]
 		sseData := msg.ToSSEEventData()
 		log.Debug().
 			Str("messageId", msg.ID).
 			RawJSON("sseEventData", sseData).
-			Msg("sending queued sse message event")
+			Msg("sending stored sse message event")
 
-		event := sse.Event{
-			Type: "message",
+		event := h.withState(ctx, stateAccountID, sse.Event{
+			ID:   msg.ID,
+			Type: msg.SSEEventType(),
 			Data: sseData,
-		}
+		})
 
 		if err := h.sendRawEvent(w, flusher, event); err != nil {
 			return err
 		}
 
-		if err := h.messageService.MarkDelivered(ctx, msg.ID); err != nil {
-			log.Warn().Err(err).Str("messageId", msg.ID).Msg("failed to mark message as delivered")
+		if sent != nil {
+			sent(msg)
 		}
 	}
 
-	if len(messages) > 0 {
-		log.Info().
-			Str("accountId", accountID).
-			Int("count", len(messages)).
-			Msg("sent queued messages")
+	return nil
+}
+
+// withState adds a "state" object with the conversation's keys to message
+// and command events. accountID is empty when the stream did not ask for
+// state. If the state cannot be loaded the event goes out without it.
+func (h *EventsHandler) withState(ctx context.Context, accountID string, event sse.Event) sse.Event {
+	if accountID == "" || (event.Type != "message" && event.Type != "command") {
+		return event
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(event.Data, &fields); err != nil {
+		return event
+	}
+	var conversationKey string
+	if err := json.Unmarshal(fields["conversationKey"], &conversationKey); err != nil || conversationKey == "" {
+		return event
 	}
 
-	return nil
+	values, truncated, err := h.stateService.Values(ctx, accountID, conversationKey)
+	if err != nil {
+		log.Warn().Err(err).Str("conversationKey", conversationKey).Msg("failed to load conversation state for event")
+		return event
+	}
+	fields["state"], _ = json.Marshal(values)
+	if truncated {
+		fields["stateTruncated"] = json.RawMessage("true")
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return event
+	}
+	event.Data = data
+	return event
+}
+
+// eventFilter reads the stream's filter from the types, channelIds,
+// conversationKeys, intents and blocks query parameters. Each takes a
+// comma-separated list and may be repeated. Nil when none is given.
+func eventFilter(r *http.Request) *sse.Filter {
+	return sse.NewFilter(
+		queryList(r, "types"),
+		queryList(r, "channelIds"),
+		queryList(r, "conversationKeys"),
+		queryList(r, "intents"),
+		queryList(r, "blocks"),
+	)
+}
+
+func queryList(r *http.Request, name string) []string {
+	var values []string
+	for _, param := range r.URL.Query()[name] {
+		for _, v := range strings.Split(param, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	return values
+}
+
+// includes reports whether the comma-separated include query parameter
+// lists name.
+func includes(r *http.Request, name string) bool {
+	for _, v := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(v) == name {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *EventsHandler) sendEvent(w http.ResponseWriter, flusher http.Flusher, eventType string, data any) error {
@@ -173,6 +453,11 @@ func (h *EventsHandler) sendEvent(w http.ResponseWriter, flusher http.Flusher, e
 }
 
 func (h *EventsHandler) sendRawEvent(w http.ResponseWriter, flusher http.Flusher, event sse.Event) error {
+	if event.ID != "" {
+		if _, err := fmt.Fprintf(w, "id: %s\n", event.ID); err != nil {
+			return err
+		}
+	}
 	if _, err := fmt.Fprintf(w, "event: %s\n", event.Type); err != nil {
 		return err
 	}
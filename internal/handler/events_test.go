@@ -5,13 +5,18 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
 )
 
@@ -23,7 +28,7 @@ func withSession(ctx context.Context, session *model.Session) context.Context {
 func TestEventsHandler_ServeHTTP(t *testing.T) {
 	t.Run("returns 401 when no session or account in context", func(t *testing.T) {
 		// Create handler without dependencies (will fail early)
-		handler := NewEventsHandler(nil, nil)
+		handler := NewEventsHandler(nil, nil, nil, nil, nil)
 
 		req := httptest.NewRequest(http.MethodGet, "/v1/events", nil)
 		rec := httptest.NewRecorder()
@@ -75,6 +80,70 @@ func TestEventsHandler_sendRawEvent(t *testing.T) {
 		assert.Contains(t, body, `data: {"text": "hello"}`)
 		assert.Contains(t, body, "\n\n")
 	})
+
+	t.Run("writes id line for replayable events", func(t *testing.T) {
+		handler := &EventsHandler{}
+		rec := httptest.NewRecorder()
+
+		event := sse.Event{
+			ID:   "msg-1",
+			Type: "message",
+			Data: json.RawMessage(`{}`),
+		}
+
+		err := handler.sendRawEvent(rec, rec, event)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "id: msg-1\nevent: message\ndata: {}\n\n", rec.Body.String())
+	})
+}
+
+// stubStateRepo serves fixed state for one conversation.
+type stubStateRepo struct {
+	repository.ConversationStateRepository
+	states map[string][]model.ConversationState
+}
+
+func (r *stubStateRepo) FindByConversation(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error) {
+	return r.states[accountID+"/"+conversationKey], nil
+}
+
+func TestEventsHandler_withState(t *testing.T) {
+	handler := &EventsHandler{stateService: service.NewConversationStateService(&stubStateRepo{
+		states: map[string][]model.ConversationState{
+			"acc-1/bot:alice": {{Key: "step", Value: json.RawMessage(`2`)}},
+		},
+	}, nil)}
+	ctx := context.Background()
+	message := sse.Event{ID: "msg-1", Type: "message", Data: json.RawMessage(`{"id":"msg-1","conversationKey":"bot:alice"}`)}
+
+	t.Run("adds state to message events", func(t *testing.T) {
+		event := handler.withState(ctx, "acc-1", message)
+
+		var data map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(event.Data, &data))
+		assert.JSONEq(t, `{"step":2}`, string(data["state"]))
+		assert.JSONEq(t, `"msg-1"`, string(data["id"]))
+		assert.Equal(t, "msg-1", event.ID)
+	})
+
+	t.Run("sends an empty object for conversations without state", func(t *testing.T) {
+		event := handler.withState(ctx, "acc-2", message)
+		assert.Contains(t, string(event.Data), `"state":{}`)
+	})
+
+	t.Run("leaves other events and streams without include alone", func(t *testing.T) {
+		assert.Equal(t, message, handler.withState(ctx, "", message))
+		paired := sse.Event{Type: "pairing_complete", Data: json.RawMessage(`{"conversationKey":"bot:alice"}`)}
+		assert.Equal(t, paired, handler.withState(ctx, "acc-1", paired))
+	})
+}
+
+func TestIncludes(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/v1/events?include=attachments,+state", nil)
+	assert.True(t, includes(req, "state"))
+	assert.False(t, includes(req, "history"))
+	assert.False(t, includes(httptest.NewRequest(http.MethodGet, "/v1/events", nil), "state"))
 }
 
 // Override sendRawEvent for testing - this tests the format logic
@@ -192,3 +261,88 @@ func TestSSEHeaders(t *testing.T) {
 		}
 	})
 }
+
+func TestEventsHandler_sendOverflow(t *testing.T) {
+	handler := &EventsHandler{}
+	rec := httptest.NewRecorder()
+
+	client := &sse.Client{Events: make(chan sse.Event, 3)}
+	client.Events <- sse.Event{ID: "msg-1", Type: "message", Data: json.RawMessage(`{}`)}
+	client.Events <- sse.Event{Type: "conversation_unpaired", Data: json.RawMessage(`{"conversationKey":"c"}`)}
+
+	handler.sendOverflow(rec, rec, client)
+
+	body := rec.Body.String()
+	assert.NotContains(t, body, "msg-1", "queued messages are resent on reconnect")
+	assert.Contains(t, body, "event: conversation_unpaired\n")
+	assert.Contains(t, body, "event: overflow\n")
+	assert.Contains(t, body, `"bufferSize":3`)
+	assert.Less(t, strings.Index(body, "conversation_unpaired"), strings.Index(body, "overflow"))
+}
+
+func TestEventFilter(t *testing.T) {
+	t.Run("nil without filter parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/v1/events?include=state", nil)
+		assert.Nil(t, eventFilter(req))
+	})
+
+	t.Run("reads comma-separated and repeated parameters", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet,
+			"/v1/events?types=message,%20command&channelIds=botA&channelIds=botB&intents=&blocks=b-1", nil)
+
+		filter := eventFilter(req)
+
+		require.NotNil(t, filter)
+		assert.Equal(t, map[string]bool{"message": true, "command": true}, filter.Types)
+		assert.Equal(t, map[string]bool{"botA": true, "botB": true}, filter.ChannelIDs)
+		assert.Nil(t, filter.Intents)
+		assert.Equal(t, map[string]bool{"b-1": true}, filter.Blocks)
+	})
+}
+
+// stubConsumers is a ConsumerRegistry with a fixed set of live consumers.
+type stubConsumers struct {
+	service.ConsumerRegistry
+	live []string
+}
+
+func (r stubConsumers) Consumers(ctx context.Context, accountID string) ([]string, error) {
+	return r.live, nil
+}
+
+func TestEventsHandler_sendQueuedMessages(t *testing.T) {
+	queued := []model.InboundMessage{
+		{ID: "msg-1", AccountID: "acc-1", ConversationKey: "bot:alice", KakaoPayload: json.RawMessage(`{}`)},
+	}
+
+	tests := []struct {
+		name          string
+		consumers     []string
+		markDelivered bool
+	}{
+		{name: "marks messages delivered without competing consumers", markDelivered: true},
+		{name: "leaves messages queued for competing consumers", consumers: []string{"c_a"}},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			inboundRepo := new(mockInboundRepo)
+			inboundRepo.On("FindQueuedByAccountID", mock.Anything, "acc-1").Return(queued, nil)
+			inboundRepo.On("MarkDelivered", mock.Anything, "msg-1").Return(nil)
+			handler := &EventsHandler{
+				messageService: service.NewMessageService(inboundRepo, new(mockOutboundRepo)),
+				delivery:       service.NewDeliveryService(inboundRepo, stubConsumers{live: tc.consumers}, nil, time.Minute),
+			}
+			rec := httptest.NewRecorder()
+
+			require.NoError(t, handler.sendQueuedMessages(context.Background(), rec, rec, "acc-1", "", nil))
+
+			assert.Contains(t, rec.Body.String(), "id: msg-1\n", "the replay is sent either way")
+			if tc.markDelivered {
+				inboundRepo.AssertCalled(t, "MarkDelivered", mock.Anything, "msg-1")
+			} else {
+				inboundRepo.AssertNotCalled(t, "MarkDelivered", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/database"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+// mockAccountRepo mocks the AccountRepository methods the handlers use.
+type mockAccountRepo struct {
+	repository.AccountRepository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) FindByID(ctx context.Context, id string) (*model.Account, error) {
+	args := m.Called(ctx, id)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateToken(ctx context.Context, id, tokenHash string) (*model.Account, error) {
+	args := m.Called(ctx, id, tokenHash)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateOrderedDelivery(ctx context.Context, id string, ordered bool) (*model.Account, error) {
+	args := m.Called(ctx, id, ordered)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateCoalesceWindow(ctx context.Context, id string, windowMs int) (*model.Account, error) {
+	args := m.Called(ctx, id, windowMs)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdatePairingApproval(ctx context.Context, id string, required bool) (*model.Account, error) {
+	args := m.Called(ctx, id, required)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) WithTx(tx *sqlx.Tx) repository.AccountRepository {
+	return m
+}
+
+// mockSessionRepo mocks the SessionRepository methods the handlers use.
+type mockSessionRepo struct {
+	repository.SessionRepository
+	mock.Mock
+}
+
+func (m *mockSessionRepo) UpdateTokenHash(ctx context.Context, id, tokenHash string) error {
+	return m.Called(ctx, id, tokenHash).Error(0)
+}
+
+func (m *mockSessionRepo) ClearRelayTokens(ctx context.Context, accountID string) error {
+	return m.Called(ctx, accountID).Error(0)
+}
+
+func (m *mockSessionRepo) CountPairedByAccountID(ctx context.Context, accountID string) (int, error) {
+	args := m.Called(ctx, accountID)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockSessionRepo) WithTx(tx *sqlx.Tx) repository.SessionRepository {
+	return m
+}
+
+// nopBroker drops lifecycle events.
+type nopBroker struct{}
+
+func (nopBroker) Publish(ctx context.Context, accountID string, event sse.Event) error {
+	return nil
+}
+
+func (nopBroker) CloseStreams(ctx context.Context, channel string) error {
+	return nil
+}
+
+// fakeTx runs transactions without a database; the mocks ignore tx.
+type fakeTx struct{}
+
+func (fakeTx) WithTx(ctx context.Context, fn database.TxFunc) error {
+	return fn(nil)
+}
+
+// withURLParam adds a chi route parameter to ctx.
+func withURLParam(ctx context.Context, key, value string) context.Context {
+	rctx := chi.RouteContext(ctx)
+	if rctx == nil {
+		rctx = chi.NewRouteContext()
+		ctx = context.WithValue(ctx, chi.RouteCtxKey, rctx)
+	}
+	rctx.URLParams.Add(key, value)
+	return ctx
+}
@@ -2,69 +2,66 @@ package handler
 
 import (
 	"encoding/json"
-	"fmt"
 	"net/http"
-	"strings"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
 )
 
-type Command struct {
-	Type string // PAIR, UNPAIR, STATUS, HELP
-	Code string
-}
-
-func parseCommand(utterance string) *Command {
-	trimmed := strings.TrimSpace(utterance)
-
-	if strings.HasPrefix(trimmed, "/pair ") {
-		code := strings.ToUpper(strings.TrimSpace(trimmed[6:]))
-		if code != "" {
-			return &Command{Type: "PAIR", Code: code}
-		}
-	}
-
-	if trimmed == "/unpair" {
-		return &Command{Type: "UNPAIR"}
-	}
-
-	if trimmed == "/status" {
-		return &Command{Type: "STATUS"}
-	}
-
-	if trimmed == "/help" {
-		return &Command{Type: "HELP"}
-	}
-
-	return nil
-}
-
 type KakaoHandler struct {
-	convService    *service.ConversationService
-	sessionService *service.SessionService
-	messageService *service.MessageService
-	broker         *sse.Broker
-	callbackTTL    time.Duration
+	convService     *service.ConversationService
+	sessionService  *service.SessionService
+	pairingRequests *service.PairingRequestService
+	lifecycle       *service.LifecycleService
+	messageService  *service.MessageService
+	commandService  *service.CommandService
+	localization    *service.LocalizationService
+	attachments     *service.AttachmentService
+	ordering        *service.OrderingService
+	coalescing      *service.CoalescingService
+	routing         *service.RoutingService
+	broker          *sse.Broker
+	callbackTTL     time.Duration
+	commands        *CommandRegistry
 }
 
 func NewKakaoHandler(
 	convService *service.ConversationService,
 	sessionService *service.SessionService,
+	pairingRequests *service.PairingRequestService,
+	lifecycle *service.LifecycleService,
 	messageService *service.MessageService,
+	commandService *service.CommandService,
+	localization *service.LocalizationService,
+	attachments *service.AttachmentService,
+	ordering *service.OrderingService,
+	coalescing *service.CoalescingService,
+	routing *service.RoutingService,
 	broker *sse.Broker,
 	callbackTTL time.Duration,
 ) *KakaoHandler {
 	return &KakaoHandler{
-		convService:    convService,
-		sessionService: sessionService,
-		messageService: messageService,
-		broker:         broker,
-		callbackTTL:    callbackTTL,
+		convService:     convService,
+		sessionService:  sessionService,
+		pairingRequests: pairingRequests,
+		lifecycle:       lifecycle,
+		messageService:  messageService,
+		commandService:  commandService,
+		localization:    localization,
+		attachments:     attachments,
+		ordering:        ordering,
+		coalescing:      coalescing,
+		routing:         routing,
+		broker:          broker,
+		callbackTTL:     callbackTTL,
+		commands:        builtinCommands,
 	}
 }
 
@@ -76,6 +73,11 @@ func (h *KakaoHandler) Webhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A per-channel webhook path names the bot when the payload does not.
+	if id := chi.URLParam(r, "channel"); id != "" && (req.Bot == nil || req.Bot.ID == "") {
+		req.Bot = &KakaoBot{ID: id}
+	}
+
 	channelID := req.GetChannelID()
 	userKey := req.GetPlusfriendUserKey()
 	utterance := req.UserRequest.Utterance
@@ -93,7 +95,7 @@ func (h *KakaoHandler) Webhook(w http.ResponseWriter, r *http.Request) {
 	var callbackExpiresAt *time.Time
 	if callbackURL != "" {
 		callbackURLPtr = &callbackURL
-		expires := time.Now().Add(h.callbackTTL)
+		expires := time.Now().Add(h.channelCallbackTTL(r))
 		callbackExpiresAt = &expires
 	}
 
@@ -106,37 +108,75 @@ func (h *KakaoHandler) Webhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cmd := parseCommand(utterance)
-	if cmd != nil {
-		response := h.handleCommand(r, cmd, conv, conversationKey)
+	if h.localization != nil {
+		h.localization.ObserveUtterance(ctx, conv, utterance)
+	}
+
+	if notice := h.takePairingNotice(r, conv); notice != nil {
+		writeJSON(w, http.StatusOK, notice)
+		return
+	}
+
+	cmd, spec := h.commands.Parse(utterance)
+	if spec != nil {
+		response := h.runCommand(r, &req, spec, cmd, conv, conversationKey)
 		writeJSON(w, http.StatusOK, response)
 		return
 	}
 
+	if conv.State == model.PairingStatePending {
+		l := h.localizer(r, conv)
+		writeJSON(w, http.StatusOK, NewTextResponse(l.T(i18n.MsgPairAwaitingApproval)))
+		return
+	}
+
 	if conv.State != model.PairingStatePaired || conv.AccountID == nil {
-		writeJSON(w, http.StatusOK, NewTextResponse(
-			"OpenClaw에 연결되지 않았습니다.\n\n"+
-				"연결하려면 페어링 코드를 받은 후:\n"+
-				"/pair <코드>\n\n"+
-				"를 입력해주세요.\n\n"+
-				"도움말: /help",
-		))
+		l := h.localizer(r, conv)
+		writeJSON(w, http.StatusOK, NewTextResponse(l.T(i18n.MsgNotPaired)))
 		return
 	}
 
-	normalizedMsg, _ := json.Marshal(map[string]string{
-		"userId":    userKey,
-		"text":      utterance,
-		"channelId": channelID,
-	})
+	route := h.route(r, &req, *conv.AccountID, conversationKey)
+	accountID := route.AccountID
+
+	normalized := NormalizeKakaoRequest(&req)
+	if cmd != nil {
+		normalized.Command = h.matchAccountCommand(r, accountID, cmd)
+	}
+	normalizedMsg, _ := json.Marshal(normalized)
+
+	var holdTimeout time.Duration
+	if h.ordering != nil {
+		holdTimeout, err = h.ordering.HoldTimeout(ctx, accountID)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to load ordered delivery setting")
+		}
+	}
+
+	var coalesceWindow time.Duration
+	if h.coalescing != nil {
+		if normalized.Command != nil {
+			// Commands are not merged; deliver what the user said before
+			// the command first.
+			if err := h.coalescing.Flush(ctx, accountID, conversationKey, true); err != nil {
+				log.Warn().Err(err).Msg("failed to flush coalesced messages")
+			}
+		} else if coalesceWindow, err = h.coalescing.Window(ctx, accountID); err != nil {
+			log.Warn().Err(err).Msg("failed to load coalescing setting")
+		}
+	}
 
 	msg, err := h.messageService.CreateInbound(ctx, service.CreateInboundParams{
-		AccountID:         *conv.AccountID,
+		AccountID:         accountID,
 		ConversationKey:   conversationKey,
 		KakaoPayload:      req.ToJSON(),
 		NormalizedMessage: normalizedMsg,
 		CallbackURL:       callbackURLPtr,
 		CallbackExpiresAt: callbackExpiresAt,
+		ThreadID:          &conv.ThreadID,
+		HoldTimeout:       holdTimeout,
+		CoalesceWindow:    coalesceWindow,
+		RoutingRuleID:     route.RuleID,
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create inbound message")
@@ -144,14 +184,37 @@ func (h *KakaoHandler) Webhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.attachments != nil && len(normalized.Attachments) > 0 {
+		msg.Attachments = h.attachments.Ingest(ctx, msg, normalized.Attachments)
+	}
+
+	// Every utterance is answered with a callback response right away so
+	// Kakao does not time out; the merged event is published on flush.
+	if msg.CoalesceDueAt != nil {
+		h.coalescing.Schedule(accountID, conversationKey, *msg.CoalesceDueAt)
+		writeJSON(w, http.StatusOK, NewCallbackResponse())
+		return
+	}
+
+	// A held message is published when the ordering service releases it.
+	if msg.Held {
+		log.Debug().
+			Str("messageId", msg.ID).
+			Int("queuePosition", *msg.QueuePosition).
+			Msg("inbound message held for ordered delivery")
+		writeJSON(w, http.StatusOK, NewCallbackResponse())
+		return
+	}
+
 	sseData := msg.ToSSEEventData()
 	log.Debug().
 		Str("messageId", msg.ID).
 		RawJSON("sseEventData", sseData).
 		Msg("publishing sse message event")
 
-	if err := h.broker.Publish(ctx, *conv.AccountID, sse.Event{
-		Type: "message",
+	if err := h.broker.Publish(ctx, accountID, sse.Event{
+		ID:   msg.ID,
+		Type: msg.SSEEventType(),
 		Data: sseData,
 	}); err != nil {
 		log.Warn().Err(err).Msg("failed to publish message event")
@@ -160,108 +223,308 @@ func (h *KakaoHandler) Webhook(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, NewCallbackResponse())
 }
 
-func (h *KakaoHandler) handleCommand(r *http.Request, cmd *Command, conv *model.ConversationMapping, conversationKey string) *KakaoResponse {
-	ctx := r.Context()
+func (h *KakaoHandler) runCommand(r *http.Request, payload *KakaoWebhookRequest, spec *CommandSpec, cmd *Command, conv *model.ConversationMapping, conversationKey string) *KakaoResponse {
+	l := h.localizer(r, conv)
+	if len(cmd.Args) < spec.MinArgs {
+		return NewTextResponse(l.T(i18n.MsgUsage, i18n.Params{"usage": spec.usage(l)}))
+	}
 
-	switch cmd.Type {
-	case "PAIR":
-		if cmd.Code == "" {
-			return NewTextResponse("페어링 코드를 입력해주세요.\n\n예: /pair ABCD-1234")
-		}
+	return spec.Handler(h, &CommandContext{
+		Request:         r,
+		Payload:         payload,
+		Command:         cmd,
+		Conversation:    conv,
+		ConversationKey: conversationKey,
+		Localizer:       l,
+	})
+}
 
-		if conv.State == model.PairingStatePaired {
-			return NewTextResponse(
-				"이미 OpenClaw에 연결되어 있습니다.\n\n" +
-					"다른 봇에 연결하려면 먼저 /unpair 로 연결을 해제하세요.",
-			)
-		}
+// channelCallbackTTL is how long the webhook's callback URL is used: the
+// registered channel's setting, or the global one.
+func (h *KakaoHandler) channelCallbackTTL(r *http.Request) time.Duration {
+	if channel := middleware.GetKakaoChannel(r.Context()); channel != nil && channel.CallbackTTLSeconds != nil {
+		return time.Duration(*channel.CallbackTTLSeconds) * time.Second
+	}
+	return h.callbackTTL
+}
 
-		result := h.sessionService.VerifyPairingCode(ctx, cmd.Code, conversationKey)
-		if !result.Success {
-			errorMessages := map[string]string{
-				"INVALID_CODE":   "❌ 유효하지 않은 코드입니다.\n\n코드를 다시 확인해주세요.",
-				"INTERNAL_ERROR": "❌ 오류가 발생했습니다. 다시 시도해주세요.",
-			}
-			msg := errorMessages[result.Error]
-			if msg == "" {
-				msg = "페어링에 실패했습니다."
-			}
-			return NewTextResponse(msg)
+// pairingNoticeKeys are the messages for pending pairing notices.
+var pairingNoticeKeys = map[model.PairingNotice]i18n.Key{
+	model.PairingNoticeApproved:     i18n.MsgPairApproved,
+	model.PairingNoticeRejected:     i18n.MsgPairRejected,
+	model.PairingNoticeExpired:      i18n.MsgPairRequestExpired,
+	model.PairingNoticeDisconnected: i18n.MsgPairDisconnected,
+}
+
+// takePairingNotice answers with the outcome of the user's pairing request,
+// or a disconnect by OpenClaw, if they have not been told yet. The message that triggered it is not
+// forwarded.
+func (h *KakaoHandler) takePairingNotice(r *http.Request, conv *model.ConversationMapping) *KakaoResponse {
+	if h.pairingRequests == nil {
+		return nil
+	}
+	key, ok := pairingNoticeKeys[h.pairingRequests.TakeNotice(r.Context(), conv)]
+	if !ok {
+		return nil
+	}
+	return NewTextResponse(h.localizer(r, conv).T(key))
+}
+
+// localizer returns the message localizer for conv, or the catalog default
+// when localization is not configured.
+func (h *KakaoHandler) localizer(r *http.Request, conv *model.ConversationMapping) *i18n.Localizer {
+	if h.localization == nil {
+		return i18n.NewLocalizer(i18n.NewCatalog(), i18n.DefaultLocale, nil)
+	}
+	if channel := middleware.GetKakaoChannel(r.Context()); channel != nil && channel.ID == conv.KakaoChannelID {
+		return h.localization.ForConversationInChannel(r.Context(), conv, channel)
+	}
+	return h.localization.ForConversation(r.Context(), conv)
+}
+
+// route applies the routing rules to a message of a conversation paired
+// with accountID. Lookup errors keep the paired account.
+func (h *KakaoHandler) route(r *http.Request, req *KakaoWebhookRequest, accountID, conversationKey string) service.Route {
+	if h.routing == nil {
+		return service.Route{AccountID: accountID}
+	}
+
+	routingReq := service.RoutingRequest{
+		AccountID:       accountID,
+		ConversationKey: conversationKey,
+		ChannelID:       req.GetChannelID(),
+		Utterance:       req.UserRequest.Utterance,
+	}
+	if req.UserRequest.Block != nil {
+		routingReq.BlockName = req.UserRequest.Block.Name
+	}
+	if req.Intent != nil {
+		routingReq.IntentName = req.Intent.Name
+	}
+
+	route, err := h.routing.Resolve(r.Context(), routingReq)
+	if err != nil {
+		log.Error().Err(err).Str("accountId", accountID).Msg("failed to resolve routing rules")
+		return service.Route{AccountID: accountID}
+	}
+	if route.AccountID != accountID {
+		log.Info().
+			Str("conversationKey", conversationKey).
+			Str("ruleId", *route.RuleID).
+			Str("accountId", route.AccountID).
+			Msg("inbound message routed to another account")
+	}
+	return route
+}
+
+// matchAccountCommand resolves cmd against the receiving account's custom
+// commands. Lookup errors degrade to forwarding the utterance as text.
+func (h *KakaoHandler) matchAccountCommand(r *http.Request, accountID string, cmd *Command) *model.NormalizedCommand {
+	if h.commandService == nil {
+		return nil
+	}
+
+	custom, err := h.commandService.Match(r.Context(), accountID, cmd.Name)
+	if err != nil {
+		log.Error().Err(err).Str("accountId", accountID).Msg("failed to match account command")
+		return nil
+	}
+	if custom == nil {
+		return nil
+	}
+
+	normalized := &model.NormalizedCommand{
+		Name:    custom.Name,
+		Args:    cmd.Args,
+		RawArgs: cmd.RawArgs,
+	}
+	if cmd.Name != custom.Name {
+		normalized.Alias = cmd.Name
+	}
+	return normalized
+}
+
+func (h *KakaoHandler) handlePair(cc *CommandContext) *KakaoResponse {
+	ctx := cc.Request.Context()
+	conv := cc.Conversation
+	conversationKey := cc.ConversationKey
+	l := cc.Localizer
+
+	if conv.State == model.PairingStatePaired {
+		return NewTextResponse(l.T(i18n.MsgPairAlreadyPaired))
+	}
+	if conv.State == model.PairingStatePending {
+		return NewTextResponse(l.T(i18n.MsgPairAwaitingApproval))
+	}
+
+	result := h.sessionService.VerifyPairingCode(ctx, cc.Command.Code, conv)
+	if !result.Success {
+		errorMessages := map[string]i18n.Key{
+			"INVALID_CODE":                i18n.MsgPairInvalidCode,
+			"INTERNAL_ERROR":              i18n.MsgPairInternalError,
+			service.PairingErrLockedOut:   i18n.MsgPairLockedOut,
+			service.PairingErrRateLimited: i18n.MsgPairRateLimited,
+			service.PairingErrBlocked:     i18n.MsgPairBlocked,
+			service.PairingErrPending:     i18n.MsgPairAwaitingApproval,
 		}
+		key, ok := errorMessages[result.Error]
+		if !ok {
+			key = i18n.MsgPairFailed
+		}
+		return NewTextResponse(l.T(key, i18n.Params{"minutes": retryMinutes(result.RetryAt)}))
+	}
 
-		// Update conversation state
-		if err := h.convService.UpdateState(ctx, conversationKey, model.PairingStatePaired, &result.AccountID); err != nil {
-			log.Error().Err(err).Msg("failed to update conversation state after session pairing")
+	if result.PairingCodeID != "" && h.pairingRequests != nil {
+		req, err := h.pairingRequests.Submit(ctx, result.AccountID, conversationKey, result.PairingCodeID, pairingProfile(cc.Payload))
+		if err != nil {
+			log.Error().Err(err).Msg("failed to submit pairing request")
+			return NewTextResponse(l.T(i18n.MsgPairInternalError))
 		}
+		if req != nil {
+			conv.AccountID = &result.AccountID
+			return NewTextResponse(h.localizer(cc.Request, conv).T(i18n.MsgPairApprovalRequested))
+		}
+	}
 
-		// Publish pairing_complete event
+	// Update conversation state
+	if err := h.convService.UpdateState(ctx, conversationKey, model.PairingStatePaired, &result.AccountID); err != nil {
+		log.Error().Err(err).Msg("failed to update conversation state after session pairing")
+	}
+
+	// Publish pairing_complete event
+	if result.PairingCodeID != "" {
+		if err := h.sessionService.PublishInvitePairingComplete(ctx, result.AccountID, result.PairingCodeID, conversationKey); err != nil {
+			log.Warn().Err(err).Msg("failed to publish pairing_complete event")
+		}
+	} else {
 		session, err := h.sessionService.FindByID(ctx, result.SessionID)
 		if err == nil && session != nil {
 			if err := h.sessionService.PublishPairingComplete(ctx, session, conversationKey); err != nil {
 				log.Warn().Err(err).Msg("failed to publish pairing_complete event")
 			}
 		}
+	}
 
-		return NewTextResponse("✅ OpenClaw에 연결되었습니다!\n\n이제 자유롭게 대화를 시작하세요.")
+	// The account may set its own locale, so resolve again now it is known.
+	conv.AccountID = &result.AccountID
+	return NewTextResponse(h.localizer(cc.Request, conv).T(i18n.MsgPairSuccess))
+}
 
-	case "UNPAIR":
-		if conv.State != model.PairingStatePaired {
-			return NewTextResponse("연결된 OpenClaw가 없습니다.")
+func (h *KakaoHandler) handleUnpair(cc *CommandContext) *KakaoResponse {
+	l := cc.Localizer
+	if cc.Conversation.State == model.PairingStatePending && h.pairingRequests != nil {
+		if _, err := h.pairingRequests.Cancel(cc.Request.Context(), cc.ConversationKey); err != nil {
+			log.Error().Err(err).Msg("failed to cancel pairing request")
+			return NewTextResponse(l.T(i18n.MsgUnpairFailed))
 		}
+		return NewTextResponse(l.T(i18n.MsgPairRequestCancelled))
+	}
+	if cc.Conversation.State != model.PairingStatePaired {
+		return NewTextResponse(l.T(i18n.MsgUnpairNotPaired))
+	}
 
-		if err := h.convService.UpdateState(ctx, conversationKey, model.PairingStateUnpaired, nil); err != nil {
-			log.Error().Err(err).Msg("failed to unpair")
-			return NewTextResponse("연결 해제에 실패했습니다. 다시 시도해주세요.")
-		}
+	if err := h.lifecycle.UnpairConversation(cc.Request.Context(), cc.Conversation, service.LifecycleReasonUser); err != nil {
+		log.Error().Err(err).Msg("failed to unpair")
+		return NewTextResponse(l.T(i18n.MsgUnpairFailed))
+	}
 
-		return NewTextResponse("연결이 해제되었습니다.\n\n다시 연결하려면 /pair <코드>를 사용하세요.")
+	return NewTextResponse(l.T(i18n.MsgUnpairSuccess))
+}
 
-	case "STATUS":
-		if conv.State == model.PairingStatePaired && conv.AccountID != nil {
-			pairedAt := "알 수 없음"
-			if conv.PairedAt != nil {
-				pairedAt = conv.PairedAt.Format("2006-01-02 15:04:05")
-			}
+func (h *KakaoHandler) handleNew(cc *CommandContext) *KakaoResponse {
+	l := cc.Localizer
+	if cc.Conversation.State == model.PairingStatePending {
+		return NewTextResponse(l.T(i18n.MsgPairAwaitingApproval))
+	}
+	if cc.Conversation.State != model.PairingStatePaired || cc.Conversation.AccountID == nil {
+		return NewTextResponse(l.T(i18n.MsgNotPaired))
+	}
 
-			stats, err := h.messageService.GetQuickStats(ctx, *conv.AccountID)
-			if err != nil {
-				log.Error().Err(err).Msg("failed to get quick stats for status command")
-				return NewTextResponse("✅ 연결됨\n\n연결 시간: " + pairedAt)
-			}
+	if err := h.lifecycle.StartThread(cc.Request.Context(), cc.Conversation, service.LifecycleReasonUser); err != nil {
+		log.Error().Err(err).Msg("failed to start thread")
+		return NewTextResponse(l.T(i18n.MsgNewThreadFailed))
+	}
+
+	return NewTextResponse(l.T(i18n.MsgNewThreadSuccess))
+}
+
+func (h *KakaoHandler) handleStatus(cc *CommandContext) *KakaoResponse {
+	ctx := cc.Request.Context()
+	conv := cc.Conversation
+	l := cc.Localizer
+
+	if conv.State == model.PairingStatePaired && conv.AccountID != nil {
+		pairedAt := l.T(i18n.MsgStatusUnknownTime)
+		if conv.PairedAt != nil {
+			pairedAt = conv.PairedAt.Format("2006-01-02 15:04:05")
+		}
 
-			return NewTextResponse(fmt.Sprintf(
-				"✅ 연결됨\n\n"+
-					"📊 오늘 통계\n"+
-					"• 수신: %d건\n"+
-					"• 발신: %d건 (실패 %d)\n\n"+
-					"📈 전체 통계\n"+
-					"• 총 수신: %d건\n"+
-					"• 총 발신: %d건\n\n"+
-					"연결 시간: %s",
-				stats.InboundToday,
-				stats.OutboundToday,
-				stats.OutboundFailed,
-				stats.InboundTotal,
-				stats.OutboundTotal,
-				pairedAt,
-			))
+		stats, err := h.messageService.GetQuickStats(ctx, *conv.AccountID)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to get quick stats for status command")
+			return NewTextResponse(l.T(i18n.MsgStatusPairedBrief, i18n.Params{"pairedAt": pairedAt}))
 		}
-		return NewTextResponse("❌ 연결되지 않음\n\n/pair <코드>로 연결하세요.")
-
-	case "HELP":
-		return NewTextResponse(
-			"📖 도움말\n\n" +
-				"이 봇은 OpenClaw AI 에이전트와 연결하는 중계 서비스입니다.\n\n" +
-				"명령어:\n" +
-				"• /pair <코드> - OpenClaw에 연결\n" +
-				"• /unpair - 연결 해제\n" +
-				"• /status - 연결 상태 확인\n" +
-				"• /help - 이 도움말",
-		)
-
-	default:
-		return NewTextResponse("알 수 없는 명령어입니다. /help를 입력해 도움말을 확인하세요.")
+
+		return NewTextResponse(l.T(i18n.MsgStatusPaired, i18n.Params{
+			"inboundToday":   stats.InboundToday,
+			"outboundToday":  stats.OutboundToday,
+			"outboundFailed": stats.OutboundFailed,
+			"inboundTotal":   stats.InboundTotal,
+			"outboundTotal":  stats.OutboundTotal,
+			"pairedAt":       pairedAt,
+		}))
+	}
+	if conv.State == model.PairingStatePending {
+		return NewTextResponse(l.T(i18n.MsgPairAwaitingApproval))
+	}
+	return NewTextResponse(l.T(i18n.MsgStatusNotPaired))
+}
+
+func (h *KakaoHandler) handleHelp(cc *CommandContext) *KakaoResponse {
+	var custom []model.AccountCommand
+	conv := cc.Conversation
+	if h.commandService != nil && conv.State == model.PairingStatePaired && conv.AccountID != nil {
+		var err error
+		custom, err = h.commandService.List(cc.Request.Context(), *conv.AccountID)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to load account commands for help")
+		}
+	}
+	return NewTextResponse(h.commands.HelpText(cc.Localizer, custom))
+}
+
+// pairingProfile collects what the webhook says about the user for the
+// account deciding on their pairing request.
+func pairingProfile(req *KakaoWebhookRequest) model.PairingProfile {
+	if req == nil {
+		return model.PairingProfile{}
+	}
+	user := req.UserRequest.User
+	profile := model.PairingProfile{
+		KakaoUserID: req.GetPlusfriendUserKey(),
+		ChannelID:   req.GetChannelID(),
+		Lang:        req.UserRequest.Lang,
+		Timezone:    req.UserRequest.Timezone,
+	}
+	if user.Type == "botUserKey" {
+		profile.BotUserKey = user.ID
+	}
+	if id, ok := user.Properties["appUserId"].(string); ok {
+		profile.AppUserID = id
+	}
+	if friend, ok := user.Properties["isFriend"].(bool); ok {
+		profile.IsFriend = &friend
+	}
+	return profile
+}
+
+// retryMinutes rounds the wait until retryAt up to whole minutes.
+func retryMinutes(retryAt time.Time) int {
+	wait := time.Until(retryAt)
+	if wait <= 0 {
+		return 1
 	}
+	return int((wait + time.Minute - 1) / time.Minute)
 }
 
 func truncate(s string, maxLen int) string {
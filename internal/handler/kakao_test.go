@@ -1,11 +1,14 @@
 package handler
 
 import (
+	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestParseCommand(t *testing.T) {
@@ -44,6 +47,16 @@ func TestParseCommand(t *testing.T) {
 			utterance: "/unpair",
 			expected:  &Command{Type: "UNPAIR"},
 		},
+		{
+			name:      "parse /new command",
+			utterance: "/new",
+			expected:  &Command{Type: "NEW"},
+		},
+		{
+			name:      "parse /reset as /new",
+			utterance: "/reset",
+			expected:  &Command{Type: "NEW"},
+		},
 		{
 			name:      "parse /status command",
 			utterance: "/status",
@@ -146,3 +159,37 @@ func TestWriteJSON(t *testing.T) {
 		assert.Contains(t, rec.Body.String(), "bad request")
 	})
 }
+
+func TestRetryMinutes(t *testing.T) {
+	assert.Equal(t, 1, retryMinutes(time.Now().Add(-time.Second)))
+	assert.Equal(t, 1, retryMinutes(time.Now().Add(30*time.Second)))
+	assert.Equal(t, 10, retryMinutes(time.Now().Add(9*time.Minute+30*time.Second)))
+}
+
+func TestPairingProfile(t *testing.T) {
+	var req KakaoWebhookRequest
+	err := json.Unmarshal([]byte(`{
+		"bot": {"id": "bot-1"},
+		"userRequest": {
+			"utterance": "/pair ABCD-1234",
+			"lang": "ko",
+			"timezone": "Asia/Seoul",
+			"user": {
+				"id": "bot-user-key",
+				"type": "botUserKey",
+				"properties": {"plusfriendUserKey": "pf-key", "appUserId": "123", "isFriend": true}
+			}
+		}
+	}`), &req)
+	require.NoError(t, err)
+
+	profile := pairingProfile(&req)
+	assert.Equal(t, "pf-key", profile.KakaoUserID)
+	assert.Equal(t, "bot-1", profile.ChannelID)
+	assert.Equal(t, "bot-user-key", profile.BotUserKey)
+	assert.Equal(t, "123", profile.AppUserID)
+	require.NotNil(t, profile.IsFriend)
+	assert.True(t, *profile.IsFriend)
+	assert.Equal(t, "ko", profile.Lang)
+	assert.Equal(t, "Asia/Seoul", profile.Timezone)
+}
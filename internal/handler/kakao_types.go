@@ -1,6 +1,10 @@
 package handler
 
-import "encoding/json"
+import (
+	"encoding/json"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
 
 // Kakao Webhook Request Types
 
@@ -16,8 +20,10 @@ type KakaoUserRequest struct {
 	User        KakaoUser              `json:"user"`
 	Utterance   string                 `json:"utterance"`
 	CallbackURL string                 `json:"callbackUrl,omitempty"`
-	Params      map[string]string      `json:"params,omitempty"`
+	Params      map[string]any         `json:"params,omitempty"`
 	Block       *KakaoBlock            `json:"block,omitempty"`
+	Timezone    string                 `json:"timezone,omitempty"`
+	Lang        string                 `json:"lang,omitempty"`
 }
 
 type KakaoUser struct {
@@ -127,7 +133,7 @@ func (r *KakaoWebhookRequest) GetChannelID() string {
 	if r.Bot != nil && r.Bot.ID != "" {
 		return r.Bot.ID
 	}
-	return "default"
+	return service.DefaultChannelID
 }
 
 func (r *KakaoWebhookRequest) ToJSON() json.RawMessage {
@@ -0,0 +1,322 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	redisclient "gitlab.tepseg.com/ai/kakao-relay/internal/redis"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+const (
+	webhookAccountID = "acc-paired"
+	webhookThreadID  = "thread-1"
+	webhookCallback  = `"callbackUrl": "https://bot-api.kakao.com/callback/1",`
+)
+
+// webhookHarness runs the Kakao webhook for a conversation paired with
+// webhookAccountID against mock repositories.
+type webhookHarness struct {
+	accounts *mockAccountRepo
+	sessions *mockSessionRepo
+	rules    *mockRoutingRepo
+	inbound  *mockInboundRepo
+	handler  *KakaoHandler
+
+	// created is what the webhook stored, set by the inbound mock.
+	created model.CreateInboundMessageParams
+	// publishes counts the broker's attempts to reach Redis.
+	publishes atomic.Int32
+}
+
+// newWebhookHarness stores inbound messages as stored. Accounts and rules
+// not set up by the test are looked up as missing.
+func newWebhookHarness(t *testing.T, stored *model.InboundMessage) *webhookHarness {
+	t.Helper()
+	h := &webhookHarness{
+		accounts: new(mockAccountRepo),
+		sessions: new(mockSessionRepo),
+		rules:    new(mockRoutingRepo),
+		inbound:  new(mockInboundRepo),
+	}
+
+	accountID := webhookAccountID
+	conversations := new(mockConversationRepo)
+	conversations.On("Upsert", mock.Anything, mock.Anything).Return(&model.ConversationMapping{
+		ConversationKey: "bot-1:user-1",
+		KakaoChannelID:  "bot-1",
+		State:           model.PairingStatePaired,
+		AccountID:       &accountID,
+		ThreadID:        webhookThreadID,
+	}, nil)
+
+	h.inbound.On("Create", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		h.created = args.Get(1).(model.CreateInboundMessageParams)
+	}).Return(stored, nil)
+
+	// Publishing fails without a connection; the webhook answers
+	// regardless.
+	broker := sse.NewBroker(&redisclient.Client{Client: goredis.NewClient(&goredis.Options{
+		MaxRetries:    -1,
+		DialerRetries: 1,
+		Dialer: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			h.publishes.Add(1)
+			return nil, errors.New("redis unavailable")
+		},
+	})}, sse.Config{})
+	t.Cleanup(func() { broker.Close() })
+
+	h.handler = NewKakaoHandler(
+		service.NewConversationService(conversations),
+		nil, nil, nil,
+		service.NewMessageService(h.inbound, new(mockOutboundRepo)),
+		nil, nil, nil,
+		service.NewOrderingService(h.accounts, h.inbound, nopBroker{}, nil, time.Minute),
+		service.NewCoalescingService(h.accounts, h.inbound, nopBroker{}, nil, nil, nil),
+		service.NewRoutingService(h.rules, h.accounts, h.sessions),
+		broker,
+		55*time.Second,
+	)
+	return h
+}
+
+// account makes account the result of looking up its ID.
+func (h *webhookHarness) account(account *model.Account) {
+	h.accounts.On("FindByID", mock.Anything, account.ID).Return(account, nil)
+}
+
+func (h *webhookHarness) post(t *testing.T, ctx context.Context, utterance string) *httptest.ResponseRecorder {
+	t.Helper()
+	body := `{
+		"bot": {"id": "bot-1"},
+		"userRequest": {
+			"utterance": "` + utterance + `",
+			` + webhookCallback + `
+			"user": {"id": "user-1"}
+		}
+	}`
+	req := httptest.NewRequest(http.MethodPost, "/kakao-talkchannel/webhook", strings.NewReader(body)).WithContext(ctx)
+	rec := httptest.NewRecorder()
+	h.handler.Webhook(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+	return rec
+}
+
+func TestKakaoHandler_WebhookRouting(t *testing.T) {
+	const targetID = "acc-target"
+	target := targetID
+	rule := func(utterancePattern string) model.RoutingRule {
+		return model.RoutingRule{
+			ID:               "rule-1",
+			UtterancePattern: &utterancePattern,
+			Targets:          model.RoutingTargets{{AccountID: &target, Weight: 100}},
+		}
+	}
+
+	tests := []struct {
+		name        string
+		rules       []model.RoutingRule
+		rulesErr    error
+		targetPairs int
+		wantAccount string
+		wantRule    bool
+	}{
+		{
+			name:        "keeps the paired account without rules",
+			wantAccount: webhookAccountID,
+		},
+		{
+			name:        "keeps the paired account when no rule matches",
+			rules:       []model.RoutingRule{rule("^refund")},
+			wantAccount: webhookAccountID,
+		},
+		{
+			name:        "routes a matching message to the target account",
+			rules:       []model.RoutingRule{rule("^hello")},
+			targetPairs: 1,
+			wantAccount: targetID,
+			wantRule:    true,
+		},
+		{
+			name:        "keeps the paired account when the target has no credentials",
+			rules:       []model.RoutingRule{rule("^hello")},
+			wantAccount: webhookAccountID,
+		},
+		{
+			name:        "keeps the paired account when rules cannot be loaded",
+			rulesErr:    errors.New("connection refused"),
+			wantAccount: webhookAccountID,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newWebhookHarness(t, &model.InboundMessage{ID: "msg-1"})
+			h.account(&model.Account{ID: webhookAccountID})
+			h.account(&model.Account{ID: targetID})
+			h.rules.On("FindEnabled", mock.Anything, webhookAccountID).Return(tc.rules, tc.rulesErr)
+			h.sessions.On("CountPairedByAccountID", mock.Anything, targetID).Return(tc.targetPairs, nil)
+
+			rec := h.post(t, context.Background(), "hello there")
+
+			assert.Contains(t, rec.Body.String(), `"useCallback":true`)
+			assert.Positive(t, h.publishes.Load(), "the message is published")
+			assert.Equal(t, tc.wantAccount, h.created.AccountID)
+			assert.Equal(t, webhookThreadID, *h.created.ThreadID)
+			if tc.wantRule {
+				require.NotNil(t, h.created.RoutingRuleID)
+				assert.Equal(t, "rule-1", *h.created.RoutingRuleID)
+			} else {
+				assert.Nil(t, h.created.RoutingRuleID)
+			}
+		})
+	}
+}
+
+func TestKakaoHandler_WebhookOrdering(t *testing.T) {
+	position := 2
+	tests := []struct {
+		name        string
+		account     *model.Account
+		accountErr  error
+		stored      *model.InboundMessage
+		wantTimeout time.Duration
+		wantPublish bool
+	}{
+		{
+			name:        "publishes right away without ordered delivery",
+			account:     &model.Account{ID: webhookAccountID},
+			stored:      &model.InboundMessage{ID: "msg-1"},
+			wantPublish: true,
+		},
+		{
+			name:        "publishes a message released at once",
+			account:     &model.Account{ID: webhookAccountID, OrderedDelivery: true},
+			stored:      &model.InboundMessage{ID: "msg-1"},
+			wantTimeout: time.Minute,
+			wantPublish: true,
+		},
+		{
+			name:        "answers a held message without publishing it",
+			account:     &model.Account{ID: webhookAccountID, OrderedDelivery: true},
+			stored:      &model.InboundMessage{ID: "msg-1", Held: true, QueuePosition: &position},
+			wantTimeout: time.Minute,
+		},
+		{
+			name:        "publishes unordered when the setting cannot be loaded",
+			accountErr:  errors.New("connection refused"),
+			stored:      &model.InboundMessage{ID: "msg-1"},
+			wantPublish: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newWebhookHarness(t, tc.stored)
+			h.accounts.On("FindByID", mock.Anything, webhookAccountID).Return(tc.account, tc.accountErr)
+			h.rules.On("FindEnabled", mock.Anything, webhookAccountID).Return(nil, nil)
+
+			rec := h.post(t, context.Background(), "hello")
+
+			assert.Contains(t, rec.Body.String(), `"useCallback":true`)
+			assert.Equal(t, tc.wantTimeout, h.created.HoldTimeout)
+			assert.Equal(t, tc.wantPublish, h.publishes.Load() > 0)
+		})
+	}
+}
+
+func TestKakaoHandler_WebhookCoalescing(t *testing.T) {
+	// Far enough ahead that the scheduled flush does not run in the test.
+	dueAt := time.Now().Add(time.Hour)
+	tests := []struct {
+		name        string
+		account     *model.Account
+		accountErr  error
+		stored      *model.InboundMessage
+		wantWindow  time.Duration
+		wantPublish bool
+	}{
+		{
+			name:        "publishes right away without a window",
+			account:     &model.Account{ID: webhookAccountID},
+			stored:      &model.InboundMessage{ID: "msg-1"},
+			wantPublish: true,
+		},
+		{
+			name:       "answers a coalescing message and leaves it for the flush",
+			account:    &model.Account{ID: webhookAccountID, CoalesceWindowMs: 1500},
+			stored:     &model.InboundMessage{ID: "msg-1", CoalesceDueAt: &dueAt},
+			wantWindow: 1500 * time.Millisecond,
+		},
+		{
+			name:        "publishes uncoalesced when the setting cannot be loaded",
+			accountErr:  errors.New("connection refused"),
+			stored:      &model.InboundMessage{ID: "msg-1"},
+			wantPublish: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newWebhookHarness(t, tc.stored)
+			h.accounts.On("FindByID", mock.Anything, webhookAccountID).Return(tc.account, tc.accountErr)
+			h.rules.On("FindEnabled", mock.Anything, webhookAccountID).Return(nil, nil)
+
+			rec := h.post(t, context.Background(), "hello")
+
+			assert.Contains(t, rec.Body.String(), `"useCallback":true`)
+			assert.Equal(t, tc.wantWindow, h.created.CoalesceWindow)
+			if tc.wantWindow > 0 {
+				assert.Positive(t, h.created.CoalesceDeadlineMargin, "groups are bounded by the callback deadline")
+			} else {
+				assert.Zero(t, h.created.CoalesceDeadlineMargin)
+			}
+			assert.Equal(t, tc.wantPublish, h.publishes.Load() > 0)
+		})
+	}
+}
+
+func TestKakaoHandler_WebhookCallbackTTL(t *testing.T) {
+	ttl := 30
+	tests := []struct {
+		name    string
+		channel *model.Channel
+		want    time.Duration
+	}{
+		{name: "uses the global TTL for unregistered channels", want: 55 * time.Second},
+		{name: "uses the global TTL when the channel sets none", channel: &model.Channel{ID: "bot-1"}, want: 55 * time.Second},
+		{name: "uses the channel's TTL", channel: &model.Channel{ID: "bot-1", CallbackTTLSeconds: &ttl}, want: 30 * time.Second},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newWebhookHarness(t, &model.InboundMessage{ID: "msg-1"})
+			h.account(&model.Account{ID: webhookAccountID})
+			h.rules.On("FindEnabled", mock.Anything, webhookAccountID).Return(nil, nil)
+
+			ctx := context.Background()
+			if tc.channel != nil {
+				ctx = context.WithValue(ctx, middleware.KakaoChannelContextKey, tc.channel)
+			}
+			h.post(t, ctx, "hello")
+
+			require.NotNil(t, h.created.CallbackExpiresAt)
+			assert.WithinDuration(t, time.Now().Add(tc.want), *h.created.CallbackExpiresAt, 2*time.Second)
+		})
+	}
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// LocalizationHandler serves the dashboard API for per-account locales and
+// message overrides.
+type LocalizationHandler struct {
+	localization *service.LocalizationService
+}
+
+func NewLocalizationHandler(localization *service.LocalizationService) *LocalizationHandler {
+	return &LocalizationHandler{localization: localization}
+}
+
+// GET /dashboard/api/accounts/{id}/localization
+func (h *LocalizationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	result, err := h.localization.GetAccount(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		writeLocalizationError(w, err, "Failed to load localization")
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// PUT /dashboard/api/accounts/{id}/localization/locale
+// An empty or null locale clears the account setting.
+func (h *LocalizationHandler) SetLocale(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Locale *string `json:"locale"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	locale := ""
+	if req.Locale != nil {
+		locale = *req.Locale
+	}
+	account, err := h.localization.SetAccountLocale(r.Context(), chi.URLParam(r, "id"), locale)
+	if err != nil {
+		writeLocalizationError(w, err, "Failed to update locale")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"accountId": account.ID, "locale": account.Locale})
+}
+
+// PUT /dashboard/api/accounts/{id}/localization/messages/{locale}/{key}
+func (h *LocalizationHandler) PutOverride(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	override, err := h.localization.SetOverride(r.Context(),
+		chi.URLParam(r, "id"), chi.URLParam(r, "locale"), chi.URLParam(r, "key"), req.Text)
+	if err != nil {
+		writeLocalizationError(w, err, "Failed to save message override")
+		return
+	}
+	writeJSON(w, http.StatusOK, override)
+}
+
+// DELETE /dashboard/api/accounts/{id}/localization/messages/{locale}/{key}
+func (h *LocalizationHandler) DeleteOverride(w http.ResponseWriter, r *http.Request) {
+	deleted, err := h.localization.DeleteOverride(r.Context(),
+		chi.URLParam(r, "id"), chi.URLParam(r, "locale"), chi.URLParam(r, "key"))
+	if err != nil {
+		writeLocalizationError(w, err, "Failed to delete message override")
+		return
+	}
+	if !deleted {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Override not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeLocalizationError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidLocalization):
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidLocalization.Error()+": ")
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": reason})
+	case errors.Is(err, service.ErrAccountNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Account not found"})
+	default:
+		log.Error().Err(err).Msg("dashboard: " + strings.ToLower(message))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": message})
+	}
+}
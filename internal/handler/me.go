@@ -0,0 +1,209 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+// MeHandler lets an authenticated OpenClaw client inspect its own account
+// and manage its users without dashboard access.
+type MeHandler struct {
+	convService    *service.ConversationService
+	lifecycle      *service.LifecycleService
+	messageService *service.MessageService
+	broker         *sse.Broker
+}
+
+func NewMeHandler(
+	convService *service.ConversationService,
+	lifecycle *service.LifecycleService,
+	messageService *service.MessageService,
+	broker *sse.Broker,
+) *MeHandler {
+	return &MeHandler{
+		convService:    convService,
+		lifecycle:      lifecycle,
+		messageService: messageService,
+		broker:         broker,
+	}
+}
+
+// GET /v1/me
+// Returns the account with its limits, message usage, conversation counts
+// and the SSE clients connected to this relay instance.
+func (h *MeHandler) Get(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	stats, err := h.messageService.GetQuickStats(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to get account usage")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	convs, err := h.convService.ListByAccountID(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list account conversations")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	counts := map[model.PairingState]int{
+		model.PairingStatePaired:  0,
+		model.PairingStatePending: 0,
+		model.PairingStateBlocked: 0,
+	}
+	for _, c := range convs {
+		counts[c.State]++
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"account": account,
+		"limits": map[string]any{
+			"rateLimitPerMinute": account.RateLimitPerMin,
+		},
+		"usage": map[string]int{
+			"inboundToday":   stats.InboundToday,
+			"inboundTotal":   stats.InboundTotal,
+			"outboundToday":  stats.OutboundToday,
+			"outboundTotal":  stats.OutboundTotal,
+			"outboundFailed": stats.OutboundFailed,
+		},
+		"conversations": counts,
+		"sseClients":    h.broker.ClientCount(account.ID),
+	})
+}
+
+// GET /v1/me/conversations
+// Lists paired, pending and blocked conversations, most recently active
+// first. ?state= narrows the list to one state.
+func (h *MeHandler) ListConversations(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	state := model.PairingState(r.URL.Query().Get("state"))
+	switch state {
+	case "", model.PairingStatePaired, model.PairingStatePending, model.PairingStateBlocked:
+	default:
+		httputil.WriteError(w, apperrors.InvalidInput("state", "must be paired, pending or blocked"))
+		return
+	}
+
+	convs, err := h.convService.ListByAccountID(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list account conversations")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	filtered := make([]model.ConversationMapping, 0, len(convs))
+	for _, c := range convs {
+		if state == "" || c.State == state {
+			filtered = append(filtered, c)
+		}
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"conversations": filtered})
+}
+
+// POST /v1/me/conversations/{id}/unpair
+// The user is told they are not connected on their next message.
+func (h *MeHandler) UnpairConversation(w http.ResponseWriter, r *http.Request) {
+	h.changeConversation(w, r, model.PairingStatePaired, h.lifecycle.UnpairConversation)
+}
+
+// POST /v1/me/conversations/{id}/block
+// Blocked users stay listed under the account and cannot pair with it
+// again until unblocked.
+func (h *MeHandler) BlockConversation(w http.ResponseWriter, r *http.Request) {
+	h.changeConversation(w, r, model.PairingStatePaired, h.lifecycle.BlockConversation)
+}
+
+// POST /v1/me/conversations/{id}/unblock
+func (h *MeHandler) UnblockConversation(w http.ResponseWriter, r *http.Request) {
+	h.changeConversation(w, r, model.PairingStateBlocked, h.lifecycle.UnblockConversation)
+}
+
+// changeConversation applies change to one of the account's conversations
+// if it is in the from state. Pending conversations are settled through
+// /v1/pairing-requests instead.
+func (h *MeHandler) changeConversation(
+	w http.ResponseWriter,
+	r *http.Request,
+	from model.PairingState,
+	change func(ctx context.Context, conv *model.ConversationMapping, reason string) error,
+) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	conv, err := h.convService.FindForAccount(r.Context(), account.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find conversation")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	if conv == nil {
+		httputil.WriteError(w, apperrors.NotFound("conversation"))
+		return
+	}
+	if conv.State != from {
+		httputil.WriteError(w, apperrors.New(apperrors.ErrCodeConflict, "Conversation is "+string(conv.State)))
+		return
+	}
+
+	if err := change(r.Context(), conv, service.LifecycleReasonAccount); err != nil {
+		log.Error().Err(err).Msg("failed to update conversation")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, conv)
+}
+
+// POST /v1/me/token/rotate
+// Issues a new relay token and a new token for the calling session; the
+// current ones stop working immediately.
+func (h *MeHandler) RotateToken(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var sessionID string
+	if session := middleware.GetSession(r.Context()); session != nil {
+		sessionID = session.ID
+	}
+
+	tokens, err := h.lifecycle.RotateTokens(r.Context(), account.ID, sessionID, service.LifecycleReasonAccount)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to rotate relay token")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	resp := map[string]string{"relayToken": tokens.RelayToken}
+	if tokens.SessionToken != "" {
+		resp["sessionToken"] = tokens.SessionToken
+	}
+	httputil.WriteJSON(w, http.StatusOK, resp)
+}
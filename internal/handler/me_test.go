@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+func meConversations() []model.ConversationMapping {
+	accountID := "acc-1"
+	return []model.ConversationMapping{
+		{ID: "conv-1", ConversationKey: "bot:alice", AccountID: &accountID, State: model.PairingStatePaired},
+		{ID: "conv-2", ConversationKey: "bot:bob", AccountID: &accountID, State: model.PairingStateBlocked},
+		{ID: "conv-3", ConversationKey: "bot:carol", AccountID: &accountID, State: model.PairingStatePaired},
+	}
+}
+
+func TestMeHandler_Get(t *testing.T) {
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		handler := NewMeHandler(nil, nil, nil, nil)
+
+		rec := httptest.NewRecorder()
+		handler.Get(rec, httptest.NewRequest(http.MethodGet, "/v1/me", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("returns usage and conversation counts", func(t *testing.T) {
+		inboundRepo, outboundRepo, convRepo := new(mockInboundRepo), new(mockOutboundRepo), new(mockConversationRepo)
+		inboundRepo.On("CountByAccountID", mock.Anything, "acc-1").Return(7, nil)
+		inboundRepo.On("CountByAccountIDSince", mock.Anything, "acc-1", mock.Anything).Return(2, nil)
+		outboundRepo.On("CountByAccountID", mock.Anything, "acc-1").Return(5, nil)
+		outboundRepo.On("CountByAccountIDSince", mock.Anything, "acc-1", mock.Anything).Return(1, nil)
+		outboundRepo.On("CountByAccountIDAndStatus", mock.Anything, "acc-1", model.OutboundStatusFailed).Return(0, nil)
+		convRepo.On("FindByAccountID", mock.Anything, "acc-1").Return(meConversations(), nil)
+		handler := NewMeHandler(
+			service.NewConversationService(convRepo), nil,
+			service.NewMessageService(inboundRepo, outboundRepo),
+			sse.NewBroker(nil, sse.Config{}),
+		)
+
+		req := httptest.NewRequest(http.MethodGet, "/v1/me", nil)
+		rec := httptest.NewRecorder()
+		handler.Get(rec, req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"})))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			Usage         map[string]int `json:"usage"`
+			Conversations map[string]int `json:"conversations"`
+			SSEClients    int            `json:"sseClients"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 7, resp.Usage["inboundTotal"])
+		assert.Equal(t, 1, resp.Usage["outboundToday"])
+		assert.Equal(t, map[string]int{"paired": 2, "pending": 0, "blocked": 1}, resp.Conversations)
+		assert.Zero(t, resp.SSEClients)
+	})
+}
+
+func TestMeHandler_ListConversations(t *testing.T) {
+	convRepo := new(mockConversationRepo)
+	convRepo.On("FindByAccountID", mock.Anything, "acc-1").Return(meConversations(), nil)
+	handler := NewMeHandler(service.NewConversationService(convRepo), nil, nil, nil)
+
+	list := func(query string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/v1/me/conversations"+query, nil)
+		rec := httptest.NewRecorder()
+		handler.ListConversations(rec, req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"})))
+		return rec
+	}
+
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ListConversations(rec, httptest.NewRequest(http.MethodGet, "/v1/me/conversations", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("filters by state", func(t *testing.T) {
+		rec := list("?state=paired")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp struct {
+			Conversations []model.ConversationMapping `json:"conversations"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Conversations, 2)
+		assert.Equal(t, "conv-1", resp.Conversations[0].ID)
+		assert.Equal(t, "conv-3", resp.Conversations[1].ID)
+	})
+
+	t.Run("rejects an unknown state", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, list("?state=unpaired").Code)
+	})
+}
+
+func TestMeHandler_BlockConversation(t *testing.T) {
+	otherAccount := "acc-2"
+	convs := meConversations()
+	convRepo := new(mockConversationRepo)
+	convRepo.On("FindByID", mock.Anything, "conv-1").Return(&convs[0], nil)
+	convRepo.On("FindByID", mock.Anything, "conv-2").Return(&convs[1], nil)
+	convRepo.On("FindByID", mock.Anything, "conv-9").Return(&model.ConversationMapping{ID: "conv-9", AccountID: &otherAccount, State: model.PairingStatePaired}, nil)
+	convRepo.On("UpdateState", mock.Anything, "bot:alice", model.PairingStateBlocked, mock.Anything).Return(nil)
+	lifecycle := service.NewLifecycleService(fakeTx{}, convRepo, nil, nil, nil, nopBroker{})
+	handler := NewMeHandler(service.NewConversationService(convRepo), lifecycle, nil, nil)
+
+	block := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/conversations/"+id+"/block", nil)
+		ctx := withURLParam(withAccount(req.Context(), &model.Account{ID: "acc-1"}), "id", id)
+		rec := httptest.NewRecorder()
+		handler.BlockConversation(rec, req.WithContext(ctx))
+		return rec
+	}
+
+	t.Run("blocks a paired conversation", func(t *testing.T) {
+		rec := block("conv-1")
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"state":"blocked"`)
+		convRepo.AssertCalled(t, "UpdateState", mock.Anything, "bot:alice", model.PairingStateBlocked, mock.Anything)
+	})
+
+	t.Run("returns 409 for a conversation in another state", func(t *testing.T) {
+		assert.Equal(t, http.StatusConflict, block("conv-2").Code)
+	})
+
+	t.Run("returns 404 for another account's conversation", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, block("conv-9").Code)
+	})
+}
+
+func TestMeHandler_RotateToken(t *testing.T) {
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		handler := NewMeHandler(nil, nil, nil, nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/token/rotate", nil)
+		rec := httptest.NewRecorder()
+
+		handler.RotateToken(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("rotates the relay token and the caller's session token", func(t *testing.T) {
+		accounts, sessions := new(mockAccountRepo), new(mockSessionRepo)
+		lifecycle := service.NewLifecycleService(fakeTx{}, nil, sessions, accounts, nil, nopBroker{})
+		handler := NewMeHandler(nil, lifecycle, nil, nil)
+
+		var relayHash, sessionHash string
+		accounts.On("UpdateToken", mock.Anything, "acc-1", mock.Anything).
+			Run(func(args mock.Arguments) { relayHash = args.String(2) }).
+			Return(&model.Account{ID: "acc-1"}, nil)
+		sessions.On("ClearRelayTokens", mock.Anything, "acc-1").Return(nil)
+		sessions.On("UpdateTokenHash", mock.Anything, "sess-1", mock.Anything).
+			Run(func(args mock.Arguments) { sessionHash = args.String(2) }).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, "/v1/me/token/rotate", nil)
+		ctx := withAccount(req.Context(), &model.Account{ID: "acc-1"})
+		ctx = withSession(ctx, &model.Session{ID: "sess-1"})
+		rec := httptest.NewRecorder()
+
+		handler.RotateToken(rec, req.WithContext(ctx))
+
+		require.Equal(t, http.StatusOK, rec.Code)
+		var resp map[string]string
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, util.HashToken(resp["relayToken"]), relayHash)
+		assert.Equal(t, util.HashToken(resp["sessionToken"]), sessionHash, "the old session token is replaced")
+		sessions.AssertCalled(t, "ClearRelayTokens", mock.Anything, "acc-1")
+	})
+}
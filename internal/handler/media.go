@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type MediaHandler struct {
+	mediaService *service.MediaService
+}
+
+func NewMediaHandler(mediaService *service.MediaService) *MediaHandler {
+	return &MediaHandler{mediaService: mediaService}
+}
+
+// GET /media/{token}
+// Public, unauthenticated: Kakao fetches template images from here.
+func (h *MediaHandler) Serve(w http.ResponseWriter, r *http.Request) {
+	token := chi.URLParam(r, "token")
+
+	media, body, err := h.mediaService.OpenPublic(r.Context(), token)
+	if errors.Is(err, service.ErrMediaNotFound) {
+		httputil.WriteError(w, apperrors.NotFound("Media"))
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to open media")
+		httputil.WriteError(w, apperrors.Internal("Failed to read media"))
+		return
+	}
+	defer body.Close()
+
+	maxAge := int(time.Until(media.ExpiresAt).Seconds())
+	if maxAge < 0 {
+		maxAge = 0
+	}
+
+	w.Header().Set("Content-Type", media.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(media.SizeBytes, 10))
+	w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(maxAge))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		log.Debug().Err(err).Str("mediaId", media.ID).Msg("media download interrupted")
+	}
+}
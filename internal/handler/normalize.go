@@ -0,0 +1,213 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+var imageExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".webp": true, ".bmp": true, ".heic": true,
+}
+
+// NormalizeKakaoRequest converts a raw skill payload into the versioned
+// normalized schema so OpenClaw agents don't need to parse kakaoPayload.
+func NormalizeKakaoRequest(req *KakaoWebhookRequest) model.NormalizedMessage {
+	msg := model.NormalizedMessage{
+		Version:   model.NormalizedMessageVersion,
+		UserID:    req.GetPlusfriendUserKey(),
+		ChannelID: req.GetChannelID(),
+		Text:      req.UserRequest.Utterance,
+		Timezone:  req.UserRequest.Timezone,
+		Lang:      req.UserRequest.Lang,
+		User:      normalizeUser(req.UserRequest.User),
+	}
+
+	if req.Intent != nil && (req.Intent.ID != "" || req.Intent.Name != "") {
+		msg.Intent = &model.NormalizedRef{ID: req.Intent.ID, Name: req.Intent.Name}
+	}
+
+	if b := req.UserRequest.Block; b != nil && (b.ID != "" || b.Name != "") {
+		msg.Block = &model.NormalizedRef{ID: b.ID, Name: b.Name}
+	}
+
+	if a := req.Action; a != nil {
+		if a.ID != "" || a.Name != "" {
+			msg.Action = &model.NormalizedRef{ID: a.ID, Name: a.Name}
+		}
+		if len(a.Params) > 0 {
+			msg.Params = make(map[string]string, len(a.Params))
+			for k, v := range a.Params {
+				msg.Params[k] = v
+			}
+		}
+		msg.DetailParams = normalizeDetailParams(a.DetailParams)
+		if len(a.ClientExtra) > 0 {
+			msg.ClientExtra = a.ClientExtra
+		}
+	}
+
+	msg.Attachments = extractAttachments(req)
+
+	return msg
+}
+
+func normalizeUser(u KakaoUser) model.NormalizedUser {
+	user := model.NormalizedUser{
+		ID:   u.ID,
+		Type: u.Type,
+	}
+
+	if u.Properties == nil {
+		return user
+	}
+
+	if v, ok := u.Properties["plusfriendUserKey"].(string); ok {
+		user.PlusfriendUserKey = v
+	}
+	if v, ok := u.Properties["appUserId"].(string); ok {
+		user.AppUserID = v
+	}
+	switch v := u.Properties["isFriend"].(type) {
+	case bool:
+		user.IsFriend = &v
+	case string:
+		b := v == "true"
+		user.IsFriend = &b
+	}
+
+	return user
+}
+
+func normalizeDetailParams(raw map[string]any) map[string]model.NormalizedParam {
+	if len(raw) == 0 {
+		return nil
+	}
+
+	result := make(map[string]model.NormalizedParam, len(raw))
+	for name, v := range raw {
+		param := model.NormalizedParam{}
+		if obj, ok := v.(map[string]any); ok {
+			if origin, ok := obj["origin"].(string); ok {
+				param.Origin = origin
+			}
+			if group, ok := obj["groupName"].(string); ok {
+				param.GroupName = group
+			}
+			param.Value = obj["value"]
+		} else {
+			param.Value = v
+		}
+		result[name] = param
+	}
+	return result
+}
+
+// extractAttachments collects media URLs from the places Kakao puts them:
+// userRequest.params.media, secure image plugin params, and bare CDN URLs
+// sent as the utterance.
+func extractAttachments(req *KakaoWebhookRequest) []model.NormalizedAttachment {
+	var attachments []model.NormalizedAttachment
+	seen := make(map[string]bool)
+
+	add := func(a model.NormalizedAttachment) {
+		if a.URL == "" || seen[a.URL] {
+			return
+		}
+		seen[a.URL] = true
+		attachments = append(attachments, a)
+	}
+
+	if media, ok := req.UserRequest.Params["media"].(map[string]any); ok {
+		mediaURL, _ := media["url"].(string)
+		mediaType, _ := media["type"].(string)
+		if mediaType == "" {
+			mediaType = model.AttachmentTypeFile
+		}
+		add(model.NormalizedAttachment{Type: mediaType, URL: mediaURL, Source: "userRequest.params.media"})
+	}
+
+	if req.Action != nil {
+		names := make([]string, 0, len(req.Action.Params))
+		for name := range req.Action.Params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			for _, a := range parseSecureImageParam(req.Action.Params[name]) {
+				a.Source = "action.params." + name
+				add(a)
+			}
+		}
+	}
+
+	if isMediaURL(req.UserRequest.Utterance) {
+		add(model.NormalizedAttachment{
+			Type:   model.AttachmentTypeImage,
+			URL:    strings.TrimSpace(req.UserRequest.Utterance),
+			Source: "utterance",
+		})
+	}
+
+	return attachments
+}
+
+// parseSecureImageParam parses the JSON string the sys.plugin.secureimage
+// plugin stores in action.params, e.g.
+// {"privacyAgreement":"Y","imageQuantity":"2","secureUrls":"List(https://a, https://b)","expire":"..."}
+func parseSecureImageParam(value string) []model.NormalizedAttachment {
+	if !strings.Contains(value, "secureUrls") {
+		return nil
+	}
+
+	var payload map[string]any
+	if err := json.Unmarshal([]byte(value), &payload); err != nil {
+		return nil
+	}
+
+	raw := fmt.Sprint(payload["secureUrls"])
+	raw = strings.TrimSpace(raw)
+	raw = strings.TrimPrefix(raw, "List(")
+	raw = strings.TrimSuffix(raw, ")")
+
+	expire, _ := payload["expire"].(string)
+
+	var attachments []model.NormalizedAttachment
+	for _, u := range strings.Split(raw, ",") {
+		u = strings.TrimSpace(u)
+		if u == "" {
+			continue
+		}
+		attachments = append(attachments, model.NormalizedAttachment{
+			Type:      model.AttachmentTypeSecureImage,
+			URL:       u,
+			ExpiresAt: expire,
+		})
+	}
+	return attachments
+}
+
+func isMediaURL(s string) bool {
+	s = strings.TrimSpace(s)
+	if strings.ContainsAny(s, " \n") {
+		return false
+	}
+
+	parsed, err := url.Parse(s)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+
+	if !service.IsKakaoMediaHost(parsed.Hostname()) {
+		return false
+	}
+
+	return imageExtensions[strings.ToLower(path.Ext(parsed.Path))]
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+func loadKakaoFixture(t *testing.T, name string) *KakaoWebhookRequest {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	var req KakaoWebhookRequest
+	require.NoError(t, json.Unmarshal(data, &req))
+	return &req
+}
+
+func TestNormalizeKakaoRequest(t *testing.T) {
+	boolPtr := func(b bool) *bool { return &b }
+
+	tests := []struct {
+		name    string
+		fixture string
+		check   func(t *testing.T, msg model.NormalizedMessage)
+	}{
+		{
+			name:    "plain text message",
+			fixture: "kakao_text.json",
+			check: func(t *testing.T, msg model.NormalizedMessage) {
+				assert.Equal(t, "내일 날씨 알려줘", msg.Text)
+				assert.Equal(t, "Xy12AbCdEfGh", msg.UserID)
+				assert.Equal(t, "64f0a1b2c3d4e5f6a7b8c9d0", msg.ChannelID)
+				require.NotNil(t, msg.Intent)
+				assert.Equal(t, "폴백 블록", msg.Intent.Name)
+				require.NotNil(t, msg.Block)
+				assert.Equal(t, "5f3c1a2b9e8d7c6b5a4f3e2d", msg.Block.ID)
+				require.NotNil(t, msg.Action)
+				assert.Equal(t, "openclaw_skill", msg.Action.Name)
+				assert.Empty(t, msg.Params)
+				assert.Empty(t, msg.DetailParams)
+				assert.Nil(t, msg.ClientExtra)
+				assert.Empty(t, msg.Attachments)
+				assert.Equal(t, "botUserKey", msg.User.Type)
+				assert.Equal(t, "Xy12AbCdEfGh", msg.User.PlusfriendUserKey)
+				assert.Equal(t, boolPtr(true), msg.User.IsFriend)
+				assert.Equal(t, "Asia/Seoul", msg.Timezone)
+				assert.Equal(t, "ko", msg.Lang)
+			},
+		},
+		{
+			name:    "entity params, detail params and client extra",
+			fixture: "kakao_entity_params.json",
+			check: func(t *testing.T, msg model.NormalizedMessage) {
+				assert.Equal(t, "예약하기", msg.Intent.Name)
+				assert.Equal(t, "2", msg.Params["people"])
+				assert.Contains(t, msg.Params["date"], "2024-05-02")
+				require.Contains(t, msg.DetailParams, "people")
+				assert.Equal(t, "2명", msg.DetailParams["people"].Origin)
+				assert.Equal(t, "2", msg.DetailParams["people"].Value)
+				assert.Equal(t, "quickReply", msg.ClientExtra["source"])
+				assert.Equal(t, "1234567890", msg.User.AppUserID)
+				assert.Equal(t, boolPtr(false), msg.User.IsFriend)
+				assert.Empty(t, msg.Attachments)
+			},
+		},
+		{
+			name:    "image sent by user",
+			fixture: "kakao_image.json",
+			check: func(t *testing.T, msg model.NormalizedMessage) {
+				require.Len(t, msg.Attachments, 1)
+				assert.Equal(t, model.AttachmentTypeImage, msg.Attachments[0].Type)
+				assert.Contains(t, msg.Attachments[0].URL, "talk.kakaocdn.net")
+				assert.Equal(t, "userRequest.params.media", msg.Attachments[0].Source)
+				assert.Empty(t, msg.Lang)
+			},
+		},
+		{
+			name:    "secure image plugin",
+			fixture: "kakao_secureimage.json",
+			check: func(t *testing.T, msg model.NormalizedMessage) {
+				require.Len(t, msg.Attachments, 2)
+				for _, a := range msg.Attachments {
+					assert.Equal(t, model.AttachmentTypeSecureImage, a.Type)
+					assert.Equal(t, "action.params.secureimage", a.Source)
+					assert.Equal(t, "2024-05-02T12:34:56", a.ExpiresAt)
+				}
+				assert.Equal(t, "https://secure.kakaocdn.net/dna/aaa/1.jpg?credential=c1", msg.Attachments[0].URL)
+				assert.Equal(t, "https://secure.kakaocdn.net/dna/bbb/2.jpg?credential=c2", msg.Attachments[1].URL)
+			},
+		},
+		{
+			name:    "minimal legacy payload",
+			fixture: "kakao_minimal.json",
+			check: func(t *testing.T, msg model.NormalizedMessage) {
+				assert.Equal(t, "안녕", msg.Text)
+				assert.Equal(t, "legacy-user-1", msg.UserID)
+				assert.Equal(t, "default", msg.ChannelID)
+				assert.Nil(t, msg.Intent)
+				assert.Nil(t, msg.Block)
+				assert.Nil(t, msg.Action)
+				assert.Nil(t, msg.User.IsFriend)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := loadKakaoFixture(t, tc.fixture)
+			msg := NormalizeKakaoRequest(req)
+			assert.Equal(t, model.NormalizedMessageVersion, msg.Version)
+			tc.check(t, msg)
+		})
+	}
+}
+
+func TestIsMediaURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected bool
+	}{
+		{"kakao cdn image", "https://talk.kakaocdn.net/dna/abc/img.png", true},
+		{"kakao cdn image with query", "http://k.kakaocdn.net/dn/abc/img_l.jpg?x=1", true},
+		{"non-kakao host", "https://example.com/img.jpg", false},
+		{"kakao host without image extension", "https://talk.kakaocdn.net/dna/abc/file.pdf", false},
+		{"plain text", "사진 보내줘", false},
+		{"url inside sentence", "이거 봐 https://talk.kakaocdn.net/a.jpg", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, isMediaURL(tc.input))
+		})
+	}
+}
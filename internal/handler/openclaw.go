@@ -2,7 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
@@ -13,26 +17,42 @@ import (
 	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
 )
 
 type OpenClawHandler struct {
 	messageService *service.MessageService
 	kakaoService   *service.KakaoService
+	convService    *service.ConversationService
+	eventClient    *service.EventAPIClient
+	mediaService   *service.MediaService
+	ordering       *service.OrderingService
 }
 
 func NewOpenClawHandler(
 	messageService *service.MessageService,
 	kakaoService *service.KakaoService,
+	convService *service.ConversationService,
+	eventClient *service.EventAPIClient,
+	mediaService *service.MediaService,
+	ordering *service.OrderingService,
 ) *OpenClawHandler {
 	return &OpenClawHandler{
 		messageService: messageService,
 		kakaoService:   kakaoService,
+		convService:    convService,
+		eventClient:    eventClient,
+		mediaService:   mediaService,
+		ordering:       ordering,
 	}
 }
 
 func (h *OpenClawHandler) Routes() chi.Router {
 	r := chi.NewRouter()
 	r.Post("/reply", h.Reply)
+	r.Post("/send", h.Send)
+	r.Post("/ack", h.Ack)
+	r.Post("/media", h.UploadMedia)
 	return r
 }
 
@@ -73,6 +93,22 @@ func (h *OpenClawHandler) Reply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A reply to any utterance of a coalesced event answers the event,
+	// through the callback of the message it was merged into.
+	if inbound.CoalescedInto != nil {
+		inbound, err = h.messageService.FindInboundByID(ctx, *inbound.CoalescedInto)
+		if err != nil {
+			log.Error().Err(err).Msg("failed to find inbound message")
+			httputil.WriteError(w, apperrors.Database(err))
+			return
+		}
+		if inbound == nil {
+			httputil.WriteError(w, apperrors.NotFound("Message"))
+			return
+		}
+		req.MessageID = inbound.ID
+	}
+
 	hasValidCallback := inbound.CallbackURL != nil &&
 		(inbound.CallbackExpiresAt == nil || inbound.CallbackExpiresAt.After(time.Now()))
 
@@ -85,12 +121,26 @@ func (h *OpenClawHandler) Reply(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.mediaService != nil {
+		resolved, err := h.mediaService.ResolveReferences(ctx, account.ID, req.Response)
+		if errors.Is(err, service.ErrMediaNotFound) {
+			httputil.WriteError(w, apperrors.InvalidInput("response", err.Error()))
+			return
+		}
+		if err != nil {
+			httputil.WriteError(w, apperrors.ValidationError("Invalid response payload"))
+			return
+		}
+		req.Response = resolved
+	}
+
 	outbound, err := h.messageService.CreateOutbound(ctx, model.CreateOutboundMessageParams{
 		AccountID:        account.ID,
 		InboundMessageID: &req.MessageID,
 		ConversationKey:  inbound.ConversationKey,
 		KakaoTarget:      json.RawMessage("{}"),
 		ResponsePayload:  req.Response,
+		ThreadID:         inbound.ThreadID,
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("failed to create outbound message")
@@ -114,6 +164,17 @@ func (h *OpenClawHandler) Reply(w http.ResponseWriter, r *http.Request) {
 
 	h.messageService.MarkOutboundSent(ctx, outbound.ID)
 
+	// A reply settles the message, so a competing stream's lease on it
+	// need not be acked separately.
+	if err := h.messageService.MarkAcked(ctx, req.MessageID); err != nil {
+		log.Warn().Err(err).Str("messageId", req.MessageID).Msg("failed to ack replied message")
+	}
+	if h.ordering != nil && account.OrderedDelivery {
+		if err := h.ordering.ReleaseNext(ctx, account.ID, inbound.ConversationKey); err != nil {
+			log.Warn().Err(err).Str("messageId", req.MessageID).Msg("failed to release next held message")
+		}
+	}
+
 	deliveredAt := time.Now().UnixMilli()
 
 	log.Info().
@@ -127,3 +188,247 @@ func (h *OpenClawHandler) Reply(w http.ResponseWriter, r *http.Request) {
 		"deliveredAt": deliveredAt,
 	})
 }
+
+// maxAckMessageIDs caps the message IDs accepted by one ack request.
+const maxAckMessageIDs = 100
+
+// POST /openclaw/ack
+// Marks delivered messages as handled so competing delivery does not offer
+// them to another stream. Replying to a message acks it already.
+func (h *OpenClawHandler) Ack(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var req struct {
+		MessageIDs []string `json:"messageIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+
+	if len(req.MessageIDs) == 0 {
+		httputil.WriteError(w, apperrors.MissingRequired("messageIds"))
+		return
+	}
+	if len(req.MessageIDs) > maxAckMessageIDs {
+		httputil.WriteError(w, apperrors.InvalidInput("messageIds", fmt.Sprintf("at most %d per request", maxAckMessageIDs)))
+		return
+	}
+	for _, id := range req.MessageIDs {
+		if !util.IsValidUUID(id) {
+			httputil.WriteError(w, apperrors.InvalidInput("messageIds", "must be message IDs"))
+			return
+		}
+	}
+
+	acked, err := h.messageService.AckInbound(r.Context(), account.ID, req.MessageIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to ack messages")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	if h.ordering != nil && account.OrderedDelivery && acked > 0 {
+		if err := h.ordering.ReleaseAfterAck(r.Context(), account.ID, req.MessageIDs); err != nil {
+			log.Warn().Err(err).Msg("failed to release next held messages")
+		}
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"acked": acked,
+	})
+}
+
+// POST /openclaw/send
+// Sends a bot-initiated message through the Kakao Event API, outside any callback window.
+func (h *OpenClawHandler) Send(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	if !h.eventClient.Enabled() {
+		httputil.WriteError(w, apperrors.NotConfigured("Kakao Event API"))
+		return
+	}
+
+	var req struct {
+		ConversationKey string         `json:"conversationKey"`
+		Event           string         `json:"event"`
+		Params          map[string]any `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+
+	if req.ConversationKey == "" {
+		httputil.WriteError(w, apperrors.MissingRequired("conversationKey"))
+		return
+	}
+	if req.Event == "" {
+		httputil.WriteError(w, apperrors.MissingRequired("event"))
+		return
+	}
+
+	ctx := r.Context()
+
+	conv, err := h.convService.FindByKey(ctx, req.ConversationKey)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to find conversation")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	if conv == nil || conv.AccountID == nil || *conv.AccountID != account.ID || conv.State != model.PairingStatePaired {
+		httputil.WriteError(w, apperrors.NotFound("Conversation"))
+		return
+	}
+
+	botID := conv.KakaoChannelID
+	if botID == service.DefaultChannelID {
+		botID = ""
+	}
+
+	// Conversations only store the plusfriend user key, so that is the only
+	// user ID the relay can address.
+	eventReq := service.EventAPIRequest{
+		BotID:     botID,
+		EventName: req.Event,
+		Users:     []service.EventAPIUser{{Type: service.EventUserTypePlusfriendUserKey, ID: conv.PlusfriendUserKey}},
+		Params:    req.Params,
+	}
+
+	target, _ := json.Marshal(map[string]any{
+		"botId": botID,
+		"users": eventReq.Users,
+	})
+	payload, _ := json.Marshal(map[string]any{
+		"event":  req.Event,
+		"params": req.Params,
+	})
+
+	outbound, err := h.messageService.CreateOutbound(ctx, model.CreateOutboundMessageParams{
+		AccountID:       account.ID,
+		ConversationKey: conv.ConversationKey,
+		KakaoTarget:     target,
+		ResponsePayload: payload,
+		DeliveryType:    model.OutboundDeliveryEventAPI,
+		ThreadID:        &conv.ThreadID,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create outbound message")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	result, err := h.eventClient.Send(ctx, eventReq)
+	if err != nil {
+		h.messageService.MarkOutboundFailed(ctx, outbound.ID, err.Error())
+		log.Error().
+			Err(err).
+			Str("outboundId", outbound.ID).
+			Str("conversationKey", conv.ConversationKey).
+			Msg("failed to send kakao event")
+		httputil.WriteError(w, apperrors.External("Kakao Event API", err))
+		return
+	}
+
+	h.messageService.MarkOutboundEventAccepted(ctx, outbound.ID, result.TaskID, result.Status)
+
+	log.Info().
+		Str("outboundId", outbound.ID).
+		Str("taskId", result.TaskID).
+		Str("accountId", account.ID).
+		Msg("event sent to Kakao")
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"success":    true,
+		"outboundId": outbound.ID,
+		"taskId":     result.TaskID,
+		"status":     result.Status,
+	})
+}
+
+// POST /openclaw/media
+// Uploads an image so replies can reference it as "media://<id>".
+// Accepts multipart/form-data (field "file") or a raw request body.
+func (h *OpenClawHandler) UploadMedia(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	if !h.mediaService.Enabled() {
+		httputil.WriteError(w, apperrors.NotConfigured("Media hosting (PUBLIC_BASE_URL)"))
+		return
+	}
+
+	var body io.Reader = r.Body
+	var filename string
+
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/form-data") {
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			if isMaxBytesError(err) {
+				h.writeMediaTooLarge(w)
+				return
+			}
+			httputil.WriteError(w, apperrors.MissingRequired("file"))
+			return
+		}
+		defer file.Close()
+		body = file
+		filename = header.Filename
+	}
+
+	media, err := h.mediaService.Upload(r.Context(), account.ID, body, filename)
+	switch {
+	case errors.Is(err, service.ErrMediaTooLarge), isMaxBytesError(err):
+		h.writeMediaTooLarge(w)
+		return
+	case errors.Is(err, service.ErrMediaTypeNotAllowed):
+		httputil.WriteErrorWithStatus(w, http.StatusUnsupportedMediaType,
+			apperrors.InvalidInput("file", err.Error()))
+		return
+	case errors.Is(err, service.ErrMediaEmpty):
+		httputil.WriteError(w, apperrors.MissingRequired("file"))
+		return
+	case err != nil:
+		log.Error().Err(err).Str("accountId", account.ID).Msg("failed to upload media")
+		httputil.WriteError(w, apperrors.Internal("Failed to store media"))
+		return
+	}
+
+	log.Info().
+		Str("mediaId", media.ID).
+		Str("accountId", account.ID).
+		Str("contentType", media.ContentType).
+		Int64("size", media.SizeBytes).
+		Msg("media uploaded")
+
+	httputil.WriteJSON(w, http.StatusCreated, map[string]any{
+		"id":          media.ID,
+		"ref":         service.MediaRefPrefix + media.ID,
+		"url":         h.mediaService.PublicURL(media),
+		"contentType": media.ContentType,
+		"sizeBytes":   media.SizeBytes,
+		"expiresAt":   media.ExpiresAt,
+	})
+}
+
+func (h *OpenClawHandler) writeMediaTooLarge(w http.ResponseWriter) {
+	httputil.WriteErrorWithStatus(w, http.StatusRequestEntityTooLarge,
+		apperrors.InvalidInput("file", fmt.Sprintf("must be at most %d bytes", h.mediaService.MaxBytes())))
+}
+
+func isMaxBytesError(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -4,17 +4,21 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
+	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
 )
 
 // Mock repositories
@@ -83,8 +87,8 @@ func (m *mockInboundRepo) CountByAccountIDSince(ctx context.Context, accountID s
 	return args.Int(0), args.Error(1)
 }
 
-func (m *mockInboundRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.InboundMessage, error) {
-	args := m.Called(ctx, conversationKey, limit, offset)
+func (m *mockInboundRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, threadID, before, limit)
 	return args.Get(0).([]model.InboundMessage), args.Error(1)
 }
 
@@ -98,6 +102,68 @@ func (m *mockInboundRepo) CountByConversationKeySince(ctx context.Context, conve
 	return args.Int(0), args.Error(1)
 }
 
+func (m *mockInboundRepo) FindClaimable(ctx context.Context, accountID string, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) Claim(ctx context.Context, ids []string, consumerID string, ttl time.Duration) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, ids, consumerID, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseLeases(ctx context.Context, consumerID string) (int64, error) {
+	args := m.Called(ctx, consumerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockInboundRepo) AckMessages(ctx context.Context, accountID string, ids []string) (int64, error) {
+	args := m.Called(ctx, accountID, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseNext(ctx context.Context, accountID, conversationKey string, holdTimeout time.Duration) (*model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, holdTimeout)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseDue(ctx context.Context, holdTimeout, deadlineMargin time.Duration) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, holdTimeout, deadlineMargin)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseAllHeld(ctx context.Context, accountID string) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) FindCoalescing(ctx context.Context, accountID, conversationKey string) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) FindDueCoalescing(ctx context.Context) ([]model.ConversationRef, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.ConversationRef), args.Error(1)
+}
+
+func (m *mockInboundRepo) CompleteCoalescing(ctx context.Context, params model.CompleteCoalescingParams) (*model.InboundMessage, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.InboundMessage), args.Error(1)
+}
+
 type mockOutboundRepo struct{
 	mock.Mock
 }
@@ -143,6 +209,11 @@ func (m *mockOutboundRepo) MarkFailed(ctx context.Context, id string, errorMsg s
 	return args.Error(0)
 }
 
+func (m *mockOutboundRepo) MarkEventAccepted(ctx context.Context, id, taskID, eventStatus string) error {
+	args := m.Called(ctx, id, taskID, eventStatus)
+	return args.Error(0)
+}
+
 func (m *mockOutboundRepo) CountByAccountIDAndStatus(ctx context.Context, accountID string, status model.OutboundMessageStatus) (int, error) {
 	args := m.Called(ctx, accountID, status)
 	return args.Int(0), args.Error(1)
@@ -161,8 +232,8 @@ func (m *mockOutboundRepo) FindRecentFailedByAccountID(ctx context.Context, acco
 	return args.Get(0).([]model.OutboundMessage), args.Error(1)
 }
 
-func (m *mockOutboundRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.OutboundMessage, error) {
-	args := m.Called(ctx, conversationKey, limit, offset)
+func (m *mockOutboundRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.OutboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, threadID, before, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -205,7 +276,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		msgService := service.NewMessageService(inboundRepo, outboundRepo)
 		kakaoService := service.NewKakaoService()
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		body := bytes.NewBufferString(`{"messageId": "msg-1", "response": {"text": "Hello"}}`)
 		req := httptest.NewRequest(http.MethodPost, "/openclaw/reply", body)
@@ -223,7 +294,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		msgService := service.NewMessageService(inboundRepo, outboundRepo)
 		kakaoService := service.NewKakaoService()
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{"response": {"text": "Hello"}}`)
@@ -243,7 +314,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		msgService := service.NewMessageService(inboundRepo, outboundRepo)
 		kakaoService := service.NewKakaoService()
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{invalid json}`)
@@ -265,7 +336,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 
 		inboundRepo.On("FindByID", mock.Anything, "msg-1").Return(nil, nil)
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{"messageId": "msg-1", "response": {"text": "Hello"}}`)
@@ -297,7 +368,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		}
 		inboundRepo.On("FindByID", mock.Anything, "msg-1").Return(inboundMsg, nil)
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{"messageId": "msg-1", "response": {"text": "Hello"}}`)
@@ -326,7 +397,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		}
 		inboundRepo.On("FindByID", mock.Anything, "msg-1").Return(inboundMsg, nil)
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{"messageId": "msg-1", "response": {"text": "Hello"}}`)
@@ -358,7 +429,7 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 		}
 		inboundRepo.On("FindByID", mock.Anything, "msg-1").Return(inboundMsg, nil)
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 
 		account := &model.Account{ID: "acc-1"}
 		body := bytes.NewBufferString(`{"messageId": "msg-1", "response": {"text": "Hello"}}`)
@@ -374,6 +445,55 @@ func TestOpenClawHandler_Reply(t *testing.T) {
 	})
 }
 
+func TestOpenClawHandler_Ack(t *testing.T) {
+	newRequest := func(body string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/ack", bytes.NewBufferString(body))
+		return req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"}))
+	}
+
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		handler := NewOpenClawHandler(service.NewMessageService(new(mockInboundRepo), new(mockOutboundRepo)), nil, nil, nil, nil, nil)
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/ack", bytes.NewBufferString(`{}`))
+		rec := httptest.NewRecorder()
+
+		handler.Ack(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("returns 400 when messageIds is missing", func(t *testing.T) {
+		handler := NewOpenClawHandler(service.NewMessageService(new(mockInboundRepo), new(mockOutboundRepo)), nil, nil, nil, nil, nil)
+		rec := httptest.NewRecorder()
+
+		handler.Ack(rec, newRequest(`{"messageIds": []}`))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("returns 400 for ids that are not message IDs", func(t *testing.T) {
+		handler := NewOpenClawHandler(service.NewMessageService(new(mockInboundRepo), new(mockOutboundRepo)), nil, nil, nil, nil, nil)
+		rec := httptest.NewRecorder()
+
+		handler.Ack(rec, newRequest(`{"messageIds": ["msg-1"]}`))
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	t.Run("acks the account's messages", func(t *testing.T) {
+		inboundRepo := new(mockInboundRepo)
+		handler := NewOpenClawHandler(service.NewMessageService(inboundRepo, new(mockOutboundRepo)), nil, nil, nil, nil, nil)
+		ids := []string{"6f1c7a52-8c1e-4d0a-9b6e-0a6c1f9d2e41", "0b9d2c64-3f5a-4e21-8d7c-5a1e9f3b7c02"}
+		inboundRepo.On("AckMessages", mock.Anything, "acc-1", ids).Return(int64(1), nil)
+		rec := httptest.NewRecorder()
+
+		handler.Ack(rec, newRequest(`{"messageIds": ["`+ids[0]+`", "`+ids[1]+`"]}`))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.JSONEq(t, `{"acked": 1}`, rec.Body.String())
+		inboundRepo.AssertExpectations(t)
+	})
+}
+
 func TestOpenClawHandler_Routes(t *testing.T) {
 	t.Run("registers /reply route", func(t *testing.T) {
 		inboundRepo := new(mockInboundRepo)
@@ -381,7 +501,7 @@ func TestOpenClawHandler_Routes(t *testing.T) {
 		msgService := service.NewMessageService(inboundRepo, outboundRepo)
 		kakaoService := service.NewKakaoService()
 
-		handler := NewOpenClawHandler(msgService, kakaoService)
+		handler := NewOpenClawHandler(msgService, kakaoService, nil, nil, nil, nil)
 		router := handler.Routes()
 
 		// Verify the route is registered by making a request
@@ -451,3 +571,282 @@ func TestReplyRequest_Parsing(t *testing.T) {
 		})
 	}
 }
+
+type mockConversationRepo struct {
+	mock.Mock
+}
+
+func (m *mockConversationRepo) FindByID(ctx context.Context, id string) (*model.ConversationMapping, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) FindByKey(ctx context.Context, key string) (*model.ConversationMapping, error) {
+	args := m.Called(ctx, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.ConversationMapping, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) FindPairedByAccountID(ctx context.Context, accountID string) ([]model.ConversationMapping, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) Upsert(ctx context.Context, params model.UpsertConversationParams) (*model.ConversationMapping, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) UpdateState(ctx context.Context, key string, state model.PairingState, accountID *string) error {
+	args := m.Called(ctx, key, state, accountID)
+	return args.Error(0)
+}
+
+func (m *mockConversationRepo) UpdateCallback(ctx context.Context, key string, callbackURL string, expiresAt time.Time) error {
+	args := m.Called(ctx, key, callbackURL, expiresAt)
+	return args.Error(0)
+}
+
+func (m *mockConversationRepo) SetLocaleIfUnset(ctx context.Context, key, locale string) error {
+	args := m.Called(ctx, key, locale)
+	return args.Error(0)
+}
+
+func (m *mockConversationRepo) ClearPairingNotice(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *mockConversationRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *mockConversationRepo) UnpairByAccountID(ctx context.Context, accountID string, notice model.PairingNotice) ([]model.ConversationMapping, error) {
+	args := m.Called(ctx, accountID, notice)
+	return args.Get(0).([]model.ConversationMapping), args.Error(1)
+}
+
+func (m *mockConversationRepo) StartThread(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockConversationRepo) CountByState(ctx context.Context, state model.PairingState) (int, error) {
+	args := m.Called(ctx, state)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *mockConversationRepo) WithTx(tx *sqlx.Tx) repository.ConversationRepository {
+	return m
+}
+
+func TestOpenClawHandler_Send(t *testing.T) {
+	accountID := "acc-1"
+	pairedConv := &model.ConversationMapping{
+		ConversationKey:   "bot-1:user-1",
+		KakaoChannelID:    "bot-1",
+		PlusfriendUserKey: "user-1",
+		AccountID:         &accountID,
+		State:             model.PairingStatePaired,
+	}
+
+	newHandler := func(convRepo *mockConversationRepo, outboundRepo *mockOutboundRepo, eventClient *service.EventAPIClient) *OpenClawHandler {
+		msgService := service.NewMessageService(new(mockInboundRepo), outboundRepo)
+		return NewOpenClawHandler(msgService, service.NewKakaoService(), service.NewConversationService(convRepo), eventClient, nil, nil)
+	}
+
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		handler := newHandler(new(mockConversationRepo), new(mockOutboundRepo), service.NewEventAPIClient("", "key", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{}`))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("returns 503 when event API is not configured", func(t *testing.T) {
+		handler := newHandler(new(mockConversationRepo), new(mockOutboundRepo), service.NewEventAPIClient("", "", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{}`))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: accountID}))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+		assert.Contains(t, rec.Body.String(), "NOT_CONFIGURED")
+	})
+
+	t.Run("returns 400 when event is missing", func(t *testing.T) {
+		handler := newHandler(new(mockConversationRepo), new(mockOutboundRepo), service.NewEventAPIClient("", "key", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{"conversationKey": "bot-1:user-1"}`))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: accountID}))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Contains(t, rec.Body.String(), "MISSING_REQUIRED")
+	})
+
+	t.Run("returns 404 when conversation belongs to different account", func(t *testing.T) {
+		convRepo := new(mockConversationRepo)
+		convRepo.On("FindByKey", mock.Anything, "bot-1:user-1").Return(pairedConv, nil)
+		handler := newHandler(convRepo, new(mockOutboundRepo), service.NewEventAPIClient("", "key", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{"conversationKey": "bot-1:user-1", "event": "reminder"}`))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-other"}))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+		convRepo.AssertExpectations(t)
+	})
+
+	t.Run("sends event and records outbound message", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/v2/bots/bot-1/talk", r.URL.Path)
+			var body struct {
+				User []service.EventAPIUser `json:"user"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, []service.EventAPIUser{{Type: service.EventUserTypePlusfriendUserKey, ID: "user-1"}}, body.User)
+			w.Write([]byte(`{"taskId":"task-1","status":"SUCCESS"}`))
+		}))
+		defer stub.Close()
+
+		convRepo := new(mockConversationRepo)
+		convRepo.On("FindByKey", mock.Anything, "bot-1:user-1").Return(pairedConv, nil)
+
+		outboundRepo := new(mockOutboundRepo)
+		outboundRepo.On("Create", mock.Anything, mock.MatchedBy(func(p model.CreateOutboundMessageParams) bool {
+			return p.DeliveryType == model.OutboundDeliveryEventAPI && p.InboundMessageID == nil
+		})).Return(&model.OutboundMessage{ID: "out-1"}, nil)
+		outboundRepo.On("MarkEventAccepted", mock.Anything, "out-1", "task-1", "SUCCESS").Return(nil)
+
+		handler := newHandler(convRepo, outboundRepo, service.NewEventAPIClient(stub.URL, "key", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{"conversationKey": "bot-1:user-1", "event": "reminder", "userType": "appUserId", "params": {"text": "hi"}}`))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: accountID}))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), "task-1")
+		outboundRepo.AssertExpectations(t)
+	})
+
+	t.Run("marks outbound failed when event API rejects", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer stub.Close()
+
+		convRepo := new(mockConversationRepo)
+		convRepo.On("FindByKey", mock.Anything, "bot-1:user-1").Return(pairedConv, nil)
+
+		outboundRepo := new(mockOutboundRepo)
+		outboundRepo.On("Create", mock.Anything, mock.Anything).Return(&model.OutboundMessage{ID: "out-1"}, nil)
+		outboundRepo.On("MarkFailed", mock.Anything, "out-1", mock.Anything).Return(nil)
+
+		handler := newHandler(convRepo, outboundRepo, service.NewEventAPIClient(stub.URL, "key", ""))
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/send", bytes.NewBufferString(`{"conversationKey": "bot-1:user-1", "event": "reminder"}`))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: accountID}))
+		rec := httptest.NewRecorder()
+
+		handler.Send(rec, req)
+
+		assert.Equal(t, http.StatusBadGateway, rec.Code)
+		outboundRepo.AssertExpectations(t)
+	})
+}
+
+func TestOpenClawHandler_UploadMedia(t *testing.T) {
+	newHandler := func(t *testing.T, publicBaseURL string) *OpenClawHandler {
+		store, err := storage.NewLocalStore(t.TempDir())
+		if err != nil {
+			t.Fatal(err)
+		}
+		mediaService := service.NewMediaService(nil, store, service.MediaConfig{
+			MaxBytes:      16,
+			TTL:           time.Hour,
+			PublicBaseURL: publicBaseURL,
+		})
+		return NewOpenClawHandler(nil, nil, nil, nil, mediaService, nil)
+	}
+
+	t.Run("returns 401 when no account in context", func(t *testing.T) {
+		handler := newHandler(t, "https://relay.example.com")
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/media", bytes.NewBufferString("x"))
+		rec := httptest.NewRecorder()
+
+		handler.UploadMedia(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("returns 503 without public base URL", func(t *testing.T) {
+		handler := newHandler(t, "")
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/media", bytes.NewBufferString("x"))
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"}))
+		rec := httptest.NewRecorder()
+
+		handler.UploadMedia(rec, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	})
+
+	t.Run("returns 415 for non-image content", func(t *testing.T) {
+		handler := newHandler(t, "https://relay.example.com")
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/media", bytes.NewBufferString("plain text"))
+		req.Header.Set("Content-Type", "image/png")
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"}))
+		rec := httptest.NewRecorder()
+
+		handler.UploadMedia(rec, req)
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	})
+
+	t.Run("returns 413 for oversized multipart upload", func(t *testing.T) {
+		handler := newHandler(t, "https://relay.example.com")
+
+		var body bytes.Buffer
+		mw := multipart.NewWriter(&body)
+		part, _ := mw.CreateFormFile("file", "big.png")
+		part.Write(bytes.Repeat([]byte("x"), 64))
+		mw.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/openclaw/media", &body)
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+		req = req.WithContext(withAccount(req.Context(), &model.Account{ID: "acc-1"}))
+		rec := httptest.NewRecorder()
+
+		handler.UploadMedia(rec, req)
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	})
+}
@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// PairingCodesHandler lets an authenticated OpenClaw client mint invite
+// codes that attach further Kakao users to its own account.
+type PairingCodesHandler struct {
+	pairingCodeService *service.PairingCodeService
+}
+
+func NewPairingCodesHandler(pairingCodeService *service.PairingCodeService) *PairingCodesHandler {
+	return &PairingCodesHandler{pairingCodeService: pairingCodeService}
+}
+
+// POST /v1/pairing-codes
+func (h *PairingCodesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var req service.CreatePairingCodeRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+			return
+		}
+	}
+
+	code, err := h.pairingCodeService.Create(r.Context(), account.ID, req)
+	if errors.Is(err, service.ErrInvalidPairingCode) {
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidPairingCode.Error()+": ")
+		httputil.WriteError(w, apperrors.ValidationError(reason))
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("failed to create pairing code")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusCreated, code)
+}
+
+// GET /v1/pairing-codes
+// Lists codes that can still be redeemed.
+func (h *PairingCodesHandler) List(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	codes, err := h.pairingCodeService.List(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list pairing codes")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	if codes == nil {
+		codes = []model.PairingCode{}
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"pairingCodes": codes})
+}
+
+// DELETE /v1/pairing-codes/{id}
+// Revoking a code does not unpair conversations that already used it.
+func (h *PairingCodesHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	revoked, err := h.pairingCodeService.Revoke(r.Context(), account.ID, chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Err(err).Msg("failed to revoke pairing code")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	if !revoked {
+		httputil.WriteError(w, apperrors.NotFound("pairing code"))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
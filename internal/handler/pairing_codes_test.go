@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type mockPairingCodeRepo struct {
+	repository.PairingCodeRepository
+	mock.Mock
+}
+
+func (m *mockPairingCodeRepo) Create(ctx context.Context, params model.CreatePairingCodeParams) (*model.PairingCode, error) {
+	args := m.Called(ctx, params)
+	code, _ := args.Get(0).(*model.PairingCode)
+	return code, args.Error(1)
+}
+
+func (m *mockPairingCodeRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.PairingCode, error) {
+	args := m.Called(ctx, accountID)
+	codes, _ := args.Get(0).([]model.PairingCode)
+	return codes, args.Error(1)
+}
+
+func (m *mockPairingCodeRepo) Revoke(ctx context.Context, accountID, id string) (bool, error) {
+	args := m.Called(ctx, accountID, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestPairingCodesHandler(t *testing.T) {
+	owner := &model.Account{ID: "acc-1"}
+	other := &model.Account{ID: "acc-2"}
+	live := model.PairingCode{ID: "code-1", AccountID: "acc-1", Code: "ABCD-1234", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	repo := new(mockPairingCodeRepo)
+	repo.On("FindByAccountID", mock.Anything, "acc-1").Return([]model.PairingCode{live}, nil)
+	repo.On("FindByAccountID", mock.Anything, "acc-2").Return(nil, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(&live, nil)
+	repo.On("Revoke", mock.Anything, "acc-1", "code-1").Return(true, nil)
+	repo.On("Revoke", mock.Anything, "acc-2", "code-1").Return(false, nil)
+	handler := NewPairingCodesHandler(service.NewPairingCodeService(repo))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		account *model.Account
+		id      string
+		body    string
+		want    int
+		wantIn  string
+	}{
+		{name: "requires a paired session to create", handler: handler.Create, method: http.MethodPost, want: http.StatusUnauthorized},
+		{name: "creates a code with defaults", handler: handler.Create, method: http.MethodPost, account: owner, want: http.StatusCreated, wantIn: "ABCD-1234"},
+		{name: "creates a code with options", handler: handler.Create, method: http.MethodPost, account: owner, body: `{"maxUses":5,"expiresInSeconds":3600}`, want: http.StatusCreated},
+		{name: "rejects an invalid body", handler: handler.Create, method: http.MethodPost, account: owner, body: `{`, want: http.StatusBadRequest},
+		{name: "rejects too many uses", handler: handler.Create, method: http.MethodPost, account: owner, body: `{"maxUses":101}`, want: http.StatusBadRequest, wantIn: "maxUses"},
+		{name: "rejects a too short expiry", handler: handler.Create, method: http.MethodPost, account: owner, body: `{"expiresInSeconds":30}`, want: http.StatusBadRequest, wantIn: "expiresInSeconds"},
+		{name: "requires a paired session to list", handler: handler.List, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "lists the account's live codes", handler: handler.List, method: http.MethodGet, account: owner, want: http.StatusOK, wantIn: "code-1"},
+		{name: "lists no codes as an empty array", handler: handler.List, method: http.MethodGet, account: other, want: http.StatusOK, wantIn: `"pairingCodes":[]`},
+		{name: "requires a paired session to revoke", handler: handler.Revoke, method: http.MethodDelete, id: "code-1", want: http.StatusUnauthorized},
+		{name: "revokes the account's code", handler: handler.Revoke, method: http.MethodDelete, account: owner, id: "code-1", want: http.StatusNoContent},
+		{name: "does not revoke another account's code", handler: handler.Revoke, method: http.MethodDelete, account: other, id: "code-1", want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.account != nil {
+				ctx = withAccount(ctx, tc.account)
+			}
+			if tc.id != "" {
+				ctx = withURLParam(ctx, "id", tc.id)
+			}
+			req := httptest.NewRequest(tc.method, "/v1/pairing-codes", strings.NewReader(tc.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+			if tc.wantIn != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantIn)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	apperrors "gitlab.tepseg.com/ai/kakao-relay/internal/errors"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/httputil"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/middleware"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// PairingRequestsHandler lets an OpenClaw client require approval for
+// users joining through its pairing codes, and approve or reject them.
+type PairingRequestsHandler struct {
+	pairingRequests *service.PairingRequestService
+}
+
+func NewPairingRequestsHandler(pairingRequests *service.PairingRequestService) *PairingRequestsHandler {
+	return &PairingRequestsHandler{pairingRequests: pairingRequests}
+}
+
+// GET /v1/pairing-settings
+func (h *PairingRequestsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	writePairingSettings(w, account)
+}
+
+// PUT /v1/pairing-settings
+func (h *PairingRequestsHandler) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	var req struct {
+		RequireApproval *bool `json:"requireApproval"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httputil.WriteError(w, apperrors.ValidationError("Invalid request body"))
+		return
+	}
+	if req.RequireApproval == nil {
+		httputil.WriteError(w, apperrors.MissingRequired("requireApproval"))
+		return
+	}
+
+	updated, err := h.pairingRequests.SetApprovalRequired(r.Context(), account.ID, *req.RequireApproval)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to update pairing settings")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	log.Info().
+		Str("accountId", account.ID).
+		Bool("requireApproval", updated.RequirePairingApproval).
+		Msg("pairing settings updated")
+
+	writePairingSettings(w, updated)
+}
+
+// GET /v1/pairing-requests
+// Lists requests still waiting for a decision, oldest first.
+func (h *PairingRequestsHandler) List(w http.ResponseWriter, r *http.Request) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	reqs, err := h.pairingRequests.ListPending(r.Context(), account.ID)
+	if err != nil {
+		log.Error().Err(err).Msg("failed to list pairing requests")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+	if reqs == nil {
+		reqs = []model.PairingRequest{}
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{"pairingRequests": reqs})
+}
+
+// POST /v1/pairing-requests/{id}/approve
+func (h *PairingRequestsHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, true)
+}
+
+// POST /v1/pairing-requests/{id}/reject
+func (h *PairingRequestsHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, false)
+}
+
+func (h *PairingRequestsHandler) decide(w http.ResponseWriter, r *http.Request, approve bool) {
+	account := middleware.GetAccount(r.Context())
+	if account == nil {
+		httputil.WriteError(w, apperrors.SessionNotPaired())
+		return
+	}
+
+	req, err := h.pairingRequests.Decide(r.Context(), account.ID, chi.URLParam(r, "id"), approve)
+	switch {
+	case errors.Is(err, service.ErrPairingRequestNotFound):
+		httputil.WriteError(w, apperrors.NotFound("pairing request"))
+		return
+	case errors.Is(err, service.ErrPairingRequestClosed):
+		httputil.WriteError(w, apperrors.New(apperrors.ErrCodeConflict, "Pairing request is no longer pending"))
+		return
+	case err != nil:
+		log.Error().Err(err).Msg("failed to decide pairing request")
+		httputil.WriteError(w, apperrors.Database(err))
+		return
+	}
+
+	httputil.WriteJSON(w, http.StatusOK, req)
+}
+
+func writePairingSettings(w http.ResponseWriter, account *model.Account) {
+	httputil.WriteJSON(w, http.StatusOK, map[string]any{
+		"requireApproval": account.RequirePairingApproval,
+	})
+}
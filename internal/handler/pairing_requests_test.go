@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type mockPairingRequestRepo struct {
+	repository.PairingRequestRepository
+	mock.Mock
+}
+
+func (m *mockPairingRequestRepo) FindByID(ctx context.Context, accountID, id string) (*model.PairingRequest, error) {
+	args := m.Called(ctx, accountID, id)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) FindPendingByAccountID(ctx context.Context, accountID string) ([]model.PairingRequest, error) {
+	args := m.Called(ctx, accountID)
+	reqs, _ := args.Get(0).([]model.PairingRequest)
+	return reqs, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) Decide(ctx context.Context, accountID, id string, status model.PairingRequestStatus) (*model.PairingRequest, error) {
+	args := m.Called(ctx, accountID, id, status)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func TestPairingRequestsHandler(t *testing.T) {
+	owner := &model.Account{ID: "acc-1"}
+	other := &model.Account{ID: "acc-2"}
+	accounts := new(mockAccountRepo)
+	accounts.On("UpdatePairingApproval", mock.Anything, "acc-1", true).Return(&model.Account{ID: "acc-1", RequirePairingApproval: true}, nil)
+	repo := new(mockPairingRequestRepo)
+	repo.On("FindPendingByAccountID", mock.Anything, "acc-1").Return([]model.PairingRequest{{ID: "req-1", ConversationKey: "bot-1:user-1"}}, nil)
+	repo.On("FindPendingByAccountID", mock.Anything, "acc-2").Return(nil, nil)
+	repo.On("Decide", mock.Anything, "acc-1", "req-1", model.PairingRequestApproved).
+		Return(&model.PairingRequest{ID: "req-1", ConversationKey: "bot-1:user-1", Status: model.PairingRequestApproved}, nil)
+	repo.On("Decide", mock.Anything, "acc-1", "req-1", model.PairingRequestRejected).
+		Return(&model.PairingRequest{ID: "req-1", ConversationKey: "bot-1:user-1", Status: model.PairingRequestRejected}, nil)
+	repo.On("Decide", mock.Anything, "acc-1", "req-closed", mock.Anything).Return(nil, nil)
+	repo.On("FindByID", mock.Anything, "acc-1", "req-closed").Return(&model.PairingRequest{ID: "req-closed"}, nil)
+	repo.On("Decide", mock.Anything, "acc-2", "req-1", mock.Anything).Return(nil, nil)
+	repo.On("FindByID", mock.Anything, "acc-2", "req-1").Return(nil, nil)
+	handler := NewPairingRequestsHandler(service.NewPairingRequestService(repo, accounts, nil, nopBroker{}, nil, service.PairingApprovalConfig{}))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		account *model.Account
+		id      string
+		body    string
+		want    int
+		wantIn  string
+	}{
+		{name: "requires a paired session to read settings", handler: handler.GetSettings, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "reads the settings", handler: handler.GetSettings, method: http.MethodGet, account: owner, want: http.StatusOK, wantIn: `"requireApproval":false`},
+		{name: "requires a paired session to update settings", handler: handler.UpdateSettings, method: http.MethodPut, body: `{"requireApproval":true}`, want: http.StatusUnauthorized},
+		{name: "requires approval", handler: handler.UpdateSettings, method: http.MethodPut, account: owner, body: `{"requireApproval":true}`, want: http.StatusOK, wantIn: `"requireApproval":true`},
+		{name: "rejects an invalid body", handler: handler.UpdateSettings, method: http.MethodPut, account: owner, body: `{`, want: http.StatusBadRequest},
+		{name: "rejects an empty update", handler: handler.UpdateSettings, method: http.MethodPut, account: owner, body: `{}`, want: http.StatusBadRequest},
+		{name: "requires a paired session to list", handler: handler.List, method: http.MethodGet, want: http.StatusUnauthorized},
+		{name: "lists the account's pending requests", handler: handler.List, method: http.MethodGet, account: owner, want: http.StatusOK, wantIn: "req-1"},
+		{name: "lists no requests as an empty array", handler: handler.List, method: http.MethodGet, account: other, want: http.StatusOK, wantIn: `"pairingRequests":[]`},
+		{name: "requires a paired session to decide", handler: handler.Approve, method: http.MethodPost, id: "req-1", want: http.StatusUnauthorized},
+		{name: "approves a request", handler: handler.Approve, method: http.MethodPost, account: owner, id: "req-1", want: http.StatusOK, wantIn: `"approved"`},
+		{name: "rejects a request", handler: handler.Reject, method: http.MethodPost, account: owner, id: "req-1", want: http.StatusOK, wantIn: `"rejected"`},
+		{name: "reports a decided request", handler: handler.Approve, method: http.MethodPost, account: owner, id: "req-closed", want: http.StatusConflict},
+		{name: "does not decide another account's request", handler: handler.Approve, method: http.MethodPost, account: other, id: "req-1", want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tc.account != nil {
+				ctx = withAccount(ctx, tc.account)
+			}
+			if tc.id != "" {
+				ctx = withURLParam(ctx, "id", tc.id)
+			}
+			req := httptest.NewRequest(tc.method, "/v1/pairing-requests", strings.NewReader(tc.body)).WithContext(ctx)
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+			if tc.wantIn != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantIn)
+			}
+		})
+	}
+}
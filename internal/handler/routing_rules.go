@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+// RoutingRulesHandler serves the dashboard API for routing rules.
+type RoutingRulesHandler struct {
+	routing *service.RoutingService
+}
+
+func NewRoutingRulesHandler(routing *service.RoutingService) *RoutingRulesHandler {
+	return &RoutingRulesHandler{routing: routing}
+}
+
+// GET /dashboard/api/routing-rules
+func (h *RoutingRulesHandler) List(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.routing.List(r.Context())
+	if err != nil {
+		writeRoutingError(w, err, "Failed to load routing rules")
+		return
+	}
+	if rules == nil {
+		rules = []model.RoutingRule{}
+	}
+	writeJSON(w, http.StatusOK, rules)
+}
+
+// POST /dashboard/api/routing-rules
+func (h *RoutingRulesHandler) Create(w http.ResponseWriter, r *http.Request) {
+	var def service.RoutingRuleDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	rule, err := h.routing.Create(r.Context(), def)
+	if err != nil {
+		writeRoutingError(w, err, "Failed to create routing rule")
+		return
+	}
+	writeJSON(w, http.StatusCreated, rule)
+}
+
+// PUT /dashboard/api/routing-rules/{id}
+// The body replaces the whole rule.
+func (h *RoutingRulesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	var def service.RoutingRuleDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "Invalid request body"})
+		return
+	}
+
+	rule, err := h.routing.Update(r.Context(), chi.URLParam(r, "id"), def)
+	if err != nil {
+		writeRoutingError(w, err, "Failed to update routing rule")
+		return
+	}
+	writeJSON(w, http.StatusOK, rule)
+}
+
+// DELETE /dashboard/api/routing-rules/{id}
+func (h *RoutingRulesHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	if err := h.routing.Delete(r.Context(), chi.URLParam(r, "id")); err != nil {
+		writeRoutingError(w, err, "Failed to delete routing rule")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func writeRoutingError(w http.ResponseWriter, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRoutingRule):
+		reason := strings.TrimPrefix(err.Error(), service.ErrInvalidRoutingRule.Error()+": ")
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": reason})
+	case errors.Is(err, service.ErrRoutingRuleNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Routing rule not found"})
+	default:
+		log.Error().Err(err).Msg("dashboard: " + strings.ToLower(message))
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": message})
+	}
+}
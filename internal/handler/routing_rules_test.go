@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/service"
+)
+
+type mockRoutingRepo struct {
+	repository.RoutingRuleRepository
+	mock.Mock
+}
+
+func (m *mockRoutingRepo) FindAll(ctx context.Context) ([]model.RoutingRule, error) {
+	args := m.Called(ctx)
+	rules, _ := args.Get(0).([]model.RoutingRule)
+	return rules, args.Error(1)
+}
+
+func (m *mockRoutingRepo) FindEnabled(ctx context.Context, accountID string) ([]model.RoutingRule, error) {
+	args := m.Called(ctx, accountID)
+	rules, _ := args.Get(0).([]model.RoutingRule)
+	return rules, args.Error(1)
+}
+
+func (m *mockRoutingRepo) Create(ctx context.Context, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	args := m.Called(ctx, params)
+	rule, _ := args.Get(0).(*model.RoutingRule)
+	return rule, args.Error(1)
+}
+
+func (m *mockRoutingRepo) Update(ctx context.Context, id string, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	args := m.Called(ctx, id, params)
+	rule, _ := args.Get(0).(*model.RoutingRule)
+	return rule, args.Error(1)
+}
+
+func (m *mockRoutingRepo) Delete(ctx context.Context, id string) (bool, error) {
+	args := m.Called(ctx, id)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestRoutingRulesHandler(t *testing.T) {
+	const (
+		ruleID    = "11111111-1111-1111-1111-111111111111"
+		missingID = "22222222-2222-2222-2222-222222222222"
+		validRule = `{"name":"faq","channelId":"bot-a","targets":[{"weight":100}]}`
+	)
+	repo := new(mockRoutingRepo)
+	repo.On("FindAll", mock.Anything).Return(nil, nil)
+	repo.On("Create", mock.Anything, mock.Anything).Return(&model.RoutingRule{ID: ruleID, Name: "faq"}, nil)
+	repo.On("Update", mock.Anything, ruleID, mock.Anything).Return(&model.RoutingRule{ID: ruleID, Name: "faq"}, nil)
+	repo.On("Update", mock.Anything, missingID, mock.Anything).Return(nil, nil)
+	repo.On("Delete", mock.Anything, ruleID).Return(true, nil)
+	repo.On("Delete", mock.Anything, missingID).Return(false, nil)
+	handler := NewRoutingRulesHandler(service.NewRoutingService(repo, nil, nil))
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		id      string
+		body    string
+		want    int
+		wantIn  string
+	}{
+		{name: "lists no rules as an empty array", handler: handler.List, method: http.MethodGet, want: http.StatusOK, wantIn: "[]"},
+		{name: "creates a rule", handler: handler.Create, method: http.MethodPost, body: validRule, want: http.StatusCreated, wantIn: ruleID},
+		{name: "rejects an invalid body", handler: handler.Create, method: http.MethodPost, body: `{`, want: http.StatusBadRequest},
+		{name: "rejects a rule without a name", handler: handler.Create, method: http.MethodPost, body: `{"channelId":"bot-a","targets":[{"weight":100}]}`, want: http.StatusBadRequest, wantIn: "name is required"},
+		{name: "rejects a rule without conditions", handler: handler.Create, method: http.MethodPost, body: `{"name":"all","targets":[{"weight":100}]}`, want: http.StatusBadRequest},
+		{name: "rejects an invalid pattern", handler: handler.Create, method: http.MethodPost, body: `{"name":"re","utterancePattern":"(","targets":[{"weight":100}]}`, want: http.StatusBadRequest},
+		{name: "updates a rule", handler: handler.Update, method: http.MethodPut, id: ruleID, body: validRule, want: http.StatusOK},
+		{name: "reports an unknown rule on update", handler: handler.Update, method: http.MethodPut, id: missingID, body: validRule, want: http.StatusNotFound},
+		{name: "reports a malformed id on update", handler: handler.Update, method: http.MethodPut, id: "rule-1", body: validRule, want: http.StatusNotFound},
+		{name: "deletes a rule", handler: handler.Delete, method: http.MethodDelete, id: ruleID, want: http.StatusOK},
+		{name: "reports an unknown rule on delete", handler: handler.Delete, method: http.MethodDelete, id: missingID, want: http.StatusNotFound},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, "/dashboard/api/routing-rules", strings.NewReader(tc.body))
+			if tc.id != "" {
+				req = req.WithContext(withURLParam(req.Context(), "id", tc.id))
+			}
+			rec := httptest.NewRecorder()
+
+			tc.handler(rec, req)
+
+			assert.Equal(t, tc.want, rec.Code, rec.Body.String())
+			if tc.wantIn != "" {
+				assert.Contains(t, rec.Body.String(), tc.wantIn)
+			}
+		})
+	}
+}
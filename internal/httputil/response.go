@@ -90,6 +90,10 @@ func statusFromCode(code apperrors.ErrorCode) int {
 		apperrors.ErrCodeExternal:
 		return http.StatusBadGateway
 
+	// 503 Service Unavailable
+	case apperrors.ErrCodeNotConfigured:
+		return http.StatusServiceUnavailable
+
 	// 500 Internal Server Error
 	case apperrors.ErrCodeInternal,
 		apperrors.ErrCodeDatabase:
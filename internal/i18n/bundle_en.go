@@ -0,0 +1,60 @@
+package i18n
+
+var bundleEN = map[Key]string{
+	MsgNotPaired: "You are not connected to OpenClaw.\n\n" +
+		"To connect, get a pairing code and enter:\n" +
+		"/pair <code>\n\n" +
+		"Help: /help",
+
+	MsgUsage: "Usage: {usage}\n\nHelp: /help",
+
+	MsgPairAlreadyPaired: "You are already connected to OpenClaw.\n\n" +
+		"To connect to another bot, disconnect first with /unpair.",
+	MsgPairInvalidCode:   "❌ Invalid code.\n\nPlease check the code and try again.",
+	MsgPairInternalError: "❌ Something went wrong. Please try again.",
+	MsgPairFailed:        "Pairing failed.",
+	MsgPairLockedOut:     "🔒 Too many code attempts.\n\nPlease try again in {minutes} min.",
+	MsgPairRateLimited:   "⏳ Too many pairing requests right now.\n\nPlease try again in {minutes} min.",
+	MsgPairSuccess:       "✅ Connected to OpenClaw!\n\nYou can start chatting now.",
+
+	MsgPairApprovalRequested: "📨 Your request was sent.\n\nWe'll let you know once it's approved.",
+	MsgPairAwaitingApproval:  "⏳ Waiting for your connection to be approved.\n\nTo cancel the request, send /unpair.",
+	MsgPairApproved:          "✅ Your connection was approved!\n\nYou can start chatting now.",
+	MsgPairRejected:          "❌ Your connection request was declined.",
+	MsgPairRequestExpired:    "⌛ Your connection request expired.\n\nTo try again, use /pair <code>.",
+	MsgPairRequestCancelled:  "Connection request cancelled.",
+	MsgPairDisconnected:      "🔌 You were disconnected from OpenClaw.\n\nTo connect again, use /pair <code>.",
+	MsgPairBlocked:           "🚫 This OpenClaw is not accepting your connection.",
+
+	MsgUnpairNotPaired: "You are not connected to OpenClaw.",
+	MsgUnpairFailed:    "Failed to disconnect. Please try again.",
+	MsgUnpairSuccess:   "Disconnected.\n\nTo connect again, use /pair <code>.",
+
+	MsgNewThreadFailed:  "Failed to start a new conversation. Please try again.",
+	MsgNewThreadSuccess: "🆕 Starting a new conversation.\n\nEarlier messages won't carry over.",
+
+	MsgCoalesced: "I'll answer this together with your next message.",
+
+	MsgStatusPaired: "✅ Connected\n\n" +
+		"📊 Today\n" +
+		"• Received: {inboundToday}\n" +
+		"• Sent: {outboundToday} (failed {outboundFailed})\n\n" +
+		"📈 All time\n" +
+		"• Total received: {inboundTotal}\n" +
+		"• Total sent: {outboundTotal}\n\n" +
+		"Connected at: {pairedAt}",
+	MsgStatusPairedBrief: "✅ Connected\n\nConnected at: {pairedAt}",
+	MsgStatusNotPaired:   "❌ Not connected\n\nConnect with /pair <code>.",
+	MsgStatusUnknownTime: "unknown",
+
+	MsgHelpIntro:          "📖 Help\n\nThis bot relays your messages to an OpenClaw AI agent.",
+	MsgHelpCommands:       "Commands:",
+	MsgHelpCustomCommands: "OpenClaw commands:",
+
+	MsgCmdPairUsage:         "<code>",
+	MsgCmdPairDescription:   "Connect to OpenClaw",
+	MsgCmdUnpairDescription: "Disconnect",
+	MsgCmdNewDescription:    "Start a new conversation",
+	MsgCmdStatusDescription: "Show connection status",
+	MsgCmdHelpDescription:   "Show this help",
+}
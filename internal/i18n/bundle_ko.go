@@ -0,0 +1,61 @@
+package i18n
+
+var bundleKO = map[Key]string{
+	MsgNotPaired: "OpenClaw에 연결되지 않았습니다.\n\n" +
+		"연결하려면 페어링 코드를 받은 후:\n" +
+		"/pair <코드>\n\n" +
+		"를 입력해주세요.\n\n" +
+		"도움말: /help",
+
+	MsgUsage: "사용법: {usage}\n\n도움말: /help",
+
+	MsgPairAlreadyPaired: "이미 OpenClaw에 연결되어 있습니다.\n\n" +
+		"다른 봇에 연결하려면 먼저 /unpair 로 연결을 해제하세요.",
+	MsgPairInvalidCode:   "❌ 유효하지 않은 코드입니다.\n\n코드를 다시 확인해주세요.",
+	MsgPairInternalError: "❌ 오류가 발생했습니다. 다시 시도해주세요.",
+	MsgPairFailed:        "페어링에 실패했습니다.",
+	MsgPairLockedOut:     "🔒 코드 입력 시도가 너무 많습니다.\n\n{minutes}분 후에 다시 시도해주세요.",
+	MsgPairRateLimited:   "⏳ 지금은 연결 요청이 많습니다.\n\n{minutes}분 후에 다시 시도해주세요.",
+	MsgPairSuccess:       "✅ OpenClaw에 연결되었습니다!\n\n이제 자유롭게 대화를 시작하세요.",
+
+	MsgPairApprovalRequested: "📨 연결 요청을 보냈습니다.\n\n승인되면 알려드릴게요.",
+	MsgPairAwaitingApproval:  "⏳ 연결 승인을 기다리고 있습니다.\n\n요청을 취소하려면 /unpair 를 입력하세요.",
+	MsgPairApproved:          "✅ 연결 요청이 승인되었습니다!\n\n이제 자유롭게 대화를 시작하세요.",
+	MsgPairRejected:          "❌ 연결 요청이 거절되었습니다.",
+	MsgPairRequestExpired:    "⌛ 연결 요청이 만료되었습니다.\n\n다시 연결하려면 /pair <코드>를 사용하세요.",
+	MsgPairRequestCancelled:  "연결 요청을 취소했습니다.",
+	MsgPairDisconnected:      "🔌 OpenClaw와의 연결이 해제되었습니다.\n\n다시 연결하려면 /pair <코드>를 사용하세요.",
+	MsgPairBlocked:           "🚫 이 OpenClaw는 연결을 받지 않고 있습니다.",
+
+	MsgUnpairNotPaired: "연결된 OpenClaw가 없습니다.",
+	MsgUnpairFailed:    "연결 해제에 실패했습니다. 다시 시도해주세요.",
+	MsgUnpairSuccess:   "연결이 해제되었습니다.\n\n다시 연결하려면 /pair <코드>를 사용하세요.",
+
+	MsgNewThreadFailed:  "새 대화를 시작하지 못했습니다. 다시 시도해주세요.",
+	MsgNewThreadSuccess: "🆕 새 대화를 시작합니다.\n\n이전 대화 내용은 이어지지 않습니다.",
+
+	MsgCoalesced: "이어서 보내신 메시지와 함께 답변드릴게요.",
+
+	MsgStatusPaired: "✅ 연결됨\n\n" +
+		"📊 오늘 통계\n" +
+		"• 수신: {inboundToday}건\n" +
+		"• 발신: {outboundToday}건 (실패 {outboundFailed})\n\n" +
+		"📈 전체 통계\n" +
+		"• 총 수신: {inboundTotal}건\n" +
+		"• 총 발신: {outboundTotal}건\n\n" +
+		"연결 시간: {pairedAt}",
+	MsgStatusPairedBrief: "✅ 연결됨\n\n연결 시간: {pairedAt}",
+	MsgStatusNotPaired:   "❌ 연결되지 않음\n\n/pair <코드>로 연결하세요.",
+	MsgStatusUnknownTime: "알 수 없음",
+
+	MsgHelpIntro:          "📖 도움말\n\n이 봇은 OpenClaw AI 에이전트와 연결하는 중계 서비스입니다.",
+	MsgHelpCommands:       "명령어:",
+	MsgHelpCustomCommands: "OpenClaw 명령어:",
+
+	MsgCmdPairUsage:         "<코드>",
+	MsgCmdPairDescription:   "OpenClaw에 연결",
+	MsgCmdUnpairDescription: "연결 해제",
+	MsgCmdNewDescription:    "새 대화 시작",
+	MsgCmdStatusDescription: "연결 상태 확인",
+	MsgCmdHelpDescription:   "이 도움말",
+}
@@ -0,0 +1,138 @@
+// Package i18n holds the catalog of user-facing bot messages.
+//
+// Messages are templates with named {placeholders}. Each supported locale has
+// a bundle; keys missing from a bundle fall back to DefaultLocale.
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultLocale is used when no locale can be resolved.
+const DefaultLocale = "ko"
+
+// Key identifies a catalog message.
+type Key string
+
+// Params are the values substituted for {name} placeholders.
+type Params map[string]any
+
+var placeholderRe = regexp.MustCompile(`\{([a-zA-Z][a-zA-Z0-9_]*)\}`)
+
+type Catalog struct {
+	bundles map[string]map[Key]string
+}
+
+// NewCatalog returns the built-in Korean and English catalog.
+func NewCatalog() *Catalog {
+	return &Catalog{bundles: map[string]map[Key]string{
+		"ko": bundleKO,
+		"en": bundleEN,
+	}}
+}
+
+// Locales returns the supported locales, sorted.
+func (c *Catalog) Locales() []string {
+	locales := make([]string, 0, len(c.bundles))
+	for locale := range c.bundles {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+func (c *Catalog) Supports(locale string) bool {
+	_, ok := c.bundles[locale]
+	return ok
+}
+
+// Keys returns every key of the default bundle, sorted.
+func (c *Catalog) Keys() []Key {
+	keys := make([]Key, 0, len(c.bundles[DefaultLocale]))
+	for key := range c.bundles[DefaultLocale] {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+func (c *Catalog) Has(key Key) bool {
+	_, ok := c.bundles[DefaultLocale][key]
+	return ok
+}
+
+// Template returns the raw template for key, falling back to DefaultLocale.
+// Unknown keys return the key itself so a missing entry is visible rather
+// than blank.
+func (c *Catalog) Template(locale string, key Key) string {
+	if tmpl, ok := c.bundles[locale][key]; ok {
+		return tmpl
+	}
+	if tmpl, ok := c.bundles[DefaultLocale][key]; ok {
+		return tmpl
+	}
+	return string(key)
+}
+
+// NormalizeLocale maps tags like "en-US" or "ko_KR" to a supported locale,
+// or returns "" if the language is not supported.
+func (c *Catalog) NormalizeLocale(tag string) string {
+	lang := strings.ToLower(strings.TrimSpace(tag))
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if c.Supports(lang) {
+		return lang
+	}
+	return ""
+}
+
+// Placeholders returns the placeholder names used by tmpl.
+func Placeholders(tmpl string) []string {
+	var names []string
+	for _, m := range placeholderRe.FindAllStringSubmatch(tmpl, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// Format substitutes params into tmpl. Placeholders without a value are
+// left as-is.
+func Format(tmpl string, params Params) string {
+	if len(params) == 0 {
+		return tmpl
+	}
+	return placeholderRe.ReplaceAllStringFunc(tmpl, func(m string) string {
+		if v, ok := params[m[1:len(m)-1]]; ok {
+			return toString(v)
+		}
+		return m
+	})
+}
+
+// DetectLocale guesses the locale of a user utterance: any Hangul means
+// Korean, otherwise Latin letters mean English. It returns "" when unsure,
+// e.g. for emoji, digits or slash commands.
+func DetectLocale(text string) string {
+	text = strings.TrimSpace(text)
+	if text == "" || strings.HasPrefix(text, "/") {
+		return ""
+	}
+
+	latin := 0
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Hangul, r):
+			return "ko"
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+	if latin >= 2 {
+		return "en"
+	}
+	return ""
+}
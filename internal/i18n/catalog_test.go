@@ -0,0 +1,71 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCatalog_BundlesAreComplete(t *testing.T) {
+	c := NewCatalog()
+	for _, locale := range c.Locales() {
+		for _, key := range c.Keys() {
+			tmpl, ok := c.bundles[locale][key]
+			if !assert.True(t, ok, "%s missing %s", locale, key) {
+				continue
+			}
+			assert.ElementsMatch(t, Placeholders(c.bundles[DefaultLocale][key]), Placeholders(tmpl),
+				"%s %s placeholders differ from %s", locale, key, DefaultLocale)
+		}
+		assert.Len(t, c.bundles[locale], len(c.Keys()), "%s has keys not in %s", locale, DefaultLocale)
+	}
+}
+
+func TestCatalog_NormalizeLocale(t *testing.T) {
+	c := NewCatalog()
+	assert.Equal(t, "en", c.NormalizeLocale("en-US"))
+	assert.Equal(t, "ko", c.NormalizeLocale(" KO_kr "))
+	assert.Equal(t, "", c.NormalizeLocale("ja"))
+	assert.Equal(t, "", c.NormalizeLocale(""))
+}
+
+func TestLocalizer(t *testing.T) {
+	c := NewCatalog()
+
+	en := NewLocalizer(c, "en", nil)
+	assert.Equal(t, "Usage: /pair <code>\n\nHelp: /help", en.T(MsgUsage, Params{"usage": "/pair <code>"}))
+
+	t.Run("falls back to the default locale", func(t *testing.T) {
+		l := NewLocalizer(c, "ja", nil)
+		assert.Equal(t, "ko", l.Locale())
+		assert.Equal(t, c.Template("ko", MsgPairFailed), l.T(MsgPairFailed))
+	})
+
+	t.Run("prefers overrides", func(t *testing.T) {
+		l := NewLocalizer(c, "en", map[Key]string{MsgStatusPairedBrief: "Linked since {pairedAt} {missing}"})
+		assert.Equal(t, "Linked since today {missing}", l.T(MsgStatusPairedBrief, Params{"pairedAt": "today"}))
+	})
+
+	t.Run("formats numbers", func(t *testing.T) {
+		assert.Contains(t, en.T(MsgStatusPaired, Params{"inboundToday": 3}), "Received: 3\n")
+	})
+}
+
+func TestDetectLocale(t *testing.T) {
+	tests := []struct {
+		text string
+		want string
+	}{
+		{"안녕하세요", "ko"},
+		{"hello 안녕", "ko"},
+		{"What's the weather?", "en"},
+		{"/pair ABCD-1234", ""},
+		{"ㅋ", "ko"},
+		{"👍", ""},
+		{"123", ""},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, DetectLocale(tt.text), tt.text)
+	}
+}
@@ -0,0 +1,50 @@
+package i18n
+
+// Catalog keys. Placeholders each message accepts are noted alongside.
+const (
+	MsgNotPaired Key = "conversation.not_paired"
+
+	MsgUsage Key = "command.usage" // {usage}
+
+	MsgPairAlreadyPaired Key = "pair.already_paired"
+	MsgPairInvalidCode   Key = "pair.invalid_code"
+	MsgPairInternalError Key = "pair.internal_error"
+	MsgPairFailed        Key = "pair.failed"
+	MsgPairLockedOut     Key = "pair.locked_out"   // {minutes}
+	MsgPairRateLimited   Key = "pair.rate_limited" // {minutes}
+	MsgPairSuccess       Key = "pair.success"
+
+	MsgPairApprovalRequested Key = "pair.approval_requested"
+	MsgPairAwaitingApproval  Key = "pair.awaiting_approval"
+	MsgPairApproved          Key = "pair.approved"
+	MsgPairRejected          Key = "pair.rejected"
+	MsgPairRequestExpired    Key = "pair.request_expired"
+	MsgPairRequestCancelled  Key = "pair.request_cancelled"
+	MsgPairDisconnected      Key = "pair.disconnected"
+	MsgPairBlocked           Key = "pair.blocked"
+
+	MsgUnpairNotPaired Key = "unpair.not_paired"
+	MsgUnpairFailed    Key = "unpair.failed"
+	MsgUnpairSuccess   Key = "unpair.success"
+
+	MsgNewThreadFailed  Key = "new_thread.failed"
+	MsgNewThreadSuccess Key = "new_thread.success"
+
+	MsgCoalesced Key = "coalesce.merged"
+
+	MsgStatusPaired      Key = "status.paired"       // {inboundToday} {outboundToday} {outboundFailed} {inboundTotal} {outboundTotal} {pairedAt}
+	MsgStatusPairedBrief Key = "status.paired_brief" // {pairedAt}
+	MsgStatusNotPaired   Key = "status.not_paired"
+	MsgStatusUnknownTime Key = "status.unknown_time"
+
+	MsgHelpIntro          Key = "help.intro"
+	MsgHelpCommands       Key = "help.commands"
+	MsgHelpCustomCommands Key = "help.custom_commands"
+
+	MsgCmdPairUsage         Key = "command.pair.usage"
+	MsgCmdPairDescription   Key = "command.pair.description"
+	MsgCmdUnpairDescription Key = "command.unpair.description"
+	MsgCmdNewDescription    Key = "command.new.description"
+	MsgCmdStatusDescription Key = "command.status.description"
+	MsgCmdHelpDescription   Key = "command.help.description"
+)
@@ -0,0 +1,45 @@
+package i18n
+
+import "fmt"
+
+// Localizer renders catalog messages for one locale, preferring
+// per-account overrides.
+type Localizer struct {
+	catalog   *Catalog
+	locale    string
+	overrides map[Key]string
+}
+
+func NewLocalizer(catalog *Catalog, locale string, overrides map[Key]string) *Localizer {
+	if !catalog.Supports(locale) {
+		locale = DefaultLocale
+	}
+	return &Localizer{catalog: catalog, locale: locale, overrides: overrides}
+}
+
+func (l *Localizer) Locale() string {
+	return l.locale
+}
+
+// T renders key with the given params.
+func (l *Localizer) T(key Key, params ...Params) string {
+	tmpl, ok := l.overrides[key]
+	if !ok {
+		tmpl = l.catalog.Template(l.locale, key)
+	}
+	if len(params) == 0 {
+		return tmpl
+	}
+	return Format(tmpl, params[0])
+}
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprint(v)
+	}
+}
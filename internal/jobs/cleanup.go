@@ -12,10 +12,16 @@ import (
 type CleanupJob struct {
 	inboundMsgRepo repository.InboundMessageRepository
 	sessionRepo    repository.SessionRepository
+	tasks          []cleanupTask
 	interval       time.Duration
 	done           chan struct{}
 }
 
+type cleanupTask struct {
+	name string
+	fn   func(context.Context) (int64, error)
+}
+
 func NewCleanupJob(
 	inboundMsgRepo repository.InboundMessageRepository,
 	sessionRepo repository.SessionRepository,
@@ -29,6 +35,12 @@ func NewCleanupJob(
 	}
 }
 
+// AddTask registers an extra cleanup step that runs after the built-in ones.
+// Must be called before Start.
+func (j *CleanupJob) AddTask(name string, fn func(context.Context) (int64, error)) {
+	j.tasks = append(j.tasks, cleanupTask{name: name, fn: fn})
+}
+
 func (j *CleanupJob) Start() {
 	go j.run()
 	log.Info().Dur("interval", j.interval).Msg("cleanup job started")
@@ -63,6 +75,9 @@ func (j *CleanupJob) cleanup() {
 	if j.sessionRepo != nil {
 		j.runCleanup(ctx, "sessions", j.sessionRepo.DeleteExpired)
 	}
+	for _, task := range j.tasks {
+		j.runCleanup(ctx, task.name, task.fn)
+	}
 }
 
 func (j *CleanupJob) runCleanup(ctx context.Context, name string, fn func(context.Context) (int64, error)) {
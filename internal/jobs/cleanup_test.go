@@ -61,7 +61,7 @@ func (m *mockInboundMsgRepo) CountByAccountIDSince(ctx context.Context, accountI
 	return 0, nil
 }
 
-func (m *mockInboundMsgRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.InboundMessage, error) {
+func (m *mockInboundMsgRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.InboundMessage, error) {
 	return nil, nil
 }
 
@@ -73,6 +73,46 @@ func (m *mockInboundMsgRepo) CountByConversationKeySince(ctx context.Context, co
 	return 0, nil
 }
 
+func (m *mockInboundMsgRepo) FindClaimable(ctx context.Context, accountID string, limit int) ([]model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) Claim(ctx context.Context, ids []string, consumerID string, ttl time.Duration) ([]model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) ReleaseLeases(ctx context.Context, consumerID string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockInboundMsgRepo) AckMessages(ctx context.Context, accountID string, ids []string) (int64, error) {
+	return 0, nil
+}
+
+func (m *mockInboundMsgRepo) ReleaseNext(ctx context.Context, accountID, conversationKey string, holdTimeout time.Duration) (*model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) ReleaseDue(ctx context.Context, holdTimeout, deadlineMargin time.Duration) ([]model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) ReleaseAllHeld(ctx context.Context, accountID string) ([]model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) FindCoalescing(ctx context.Context, accountID, conversationKey string) ([]model.InboundMessage, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) FindDueCoalescing(ctx context.Context) ([]model.ConversationRef, error) {
+	return nil, nil
+}
+
+func (m *mockInboundMsgRepo) CompleteCoalescing(ctx context.Context, params model.CompleteCoalescingParams) (*model.InboundMessage, error) {
+	return nil, nil
+}
+
 type mockSessionRepo struct {
 	deleteExpiredCount int64
 }
@@ -117,6 +157,10 @@ func (m *mockSessionRepo) FindRecent(ctx context.Context, limit int) ([]model.Se
 	return nil, nil
 }
 
+func (m *mockSessionRepo) CountPairedByAccountID(ctx context.Context, accountID string) (int, error) {
+	return 0, nil
+}
+
 func (m *mockSessionRepo) CountByStatus(ctx context.Context, status model.SessionStatus) (int, error) {
 	return 0, nil
 }
@@ -125,6 +169,14 @@ func (m *mockSessionRepo) UpdateMetadata(ctx context.Context, id string, metadat
 	return nil
 }
 
+func (m *mockSessionRepo) UpdateTokenHash(ctx context.Context, id, tokenHash string) error {
+	return nil
+}
+
+func (m *mockSessionRepo) ClearRelayTokens(ctx context.Context, accountID string) error {
+	return nil
+}
+
 func (m *mockSessionRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DeliveryJob runs the delivery steps that wait on time rather than on a
+// request: releasing messages held for ordered delivery and flushing
+// coalesced utterances. It runs far more often than CleanupJob because
+// users wait on it.
+type DeliveryJob struct {
+	tasks    []cleanupTask
+	interval time.Duration
+	done     chan struct{}
+}
+
+func NewDeliveryJob(interval time.Duration) *DeliveryJob {
+	return &DeliveryJob{
+		interval: interval,
+		done:     make(chan struct{}),
+	}
+}
+
+// AddTask registers a step that returns how many messages it delivered.
+// Must be called before Start.
+func (j *DeliveryJob) AddTask(name string, fn func(context.Context) (int64, error)) {
+	j.tasks = append(j.tasks, cleanupTask{name: name, fn: fn})
+}
+
+func (j *DeliveryJob) Start() {
+	go j.run()
+	log.Info().Dur("interval", j.interval).Msg("delivery job started")
+}
+
+func (j *DeliveryJob) Stop() {
+	close(j.done)
+	log.Info().Msg("delivery job stopped")
+}
+
+func (j *DeliveryJob) run() {
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-j.done:
+			return
+		case <-ticker.C:
+			j.tick()
+		}
+	}
+}
+
+func (j *DeliveryJob) tick() {
+	ctx, cancel := context.WithTimeout(context.Background(), j.interval*5)
+	defer cancel()
+
+	for _, task := range j.tasks {
+		count, err := task.fn(ctx)
+		if err != nil {
+			log.Error().Err(err).Msgf("failed to deliver %s", task.name)
+		} else if count > 0 {
+			log.Info().Int64("count", count).Msgf("delivered %s", task.name)
+		}
+	}
+}
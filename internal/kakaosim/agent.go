@@ -0,0 +1,239 @@
+package kakaosim
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const agentReconnectDelay = 500 * time.Millisecond
+
+// InboundEvent is a relay "message" SSE event as seen by the agent.
+type InboundEvent struct {
+	ID              string
+	ConversationKey string
+	Text            string
+}
+
+// Agent is a minimal OpenClaw stand-in: it creates a pairing session,
+// listens on /v1/events and answers through /openclaw/reply.
+type Agent struct {
+	relayURL string
+	client   *http.Client
+
+	mu       sync.Mutex
+	token    string
+	messages chan InboundEvent
+	paired   chan struct{}
+	cancel   context.CancelFunc
+}
+
+func NewAgent(relayURL string) *Agent {
+	return &Agent{
+		relayURL: strings.TrimRight(relayURL, "/"),
+		client:   &http.Client{Timeout: requestTimeout},
+		messages: make(chan InboundEvent, 100),
+		paired:   make(chan struct{}, 1),
+	}
+}
+
+// CreateSession creates a pending session and starts listening for events.
+// It returns the pairing code to send with /pair.
+func (a *Agent) CreateSession(ctx context.Context) (string, error) {
+	var result struct {
+		SessionToken string `json:"sessionToken"`
+		PairingCode  string `json:"pairingCode"`
+	}
+	if err := a.postJSON(ctx, "/v1/sessions/create", "", nil, &result); err != nil {
+		return "", err
+	}
+
+	a.mu.Lock()
+	a.token = result.SessionToken
+	if a.cancel != nil {
+		a.cancel()
+	}
+	listenCtx, cancel := context.WithCancel(context.Background())
+	a.cancel = cancel
+	a.mu.Unlock()
+
+	go a.listen(listenCtx)
+	return result.PairingCode, nil
+}
+
+func (a *Agent) Close() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.cancel != nil {
+		a.cancel()
+	}
+}
+
+// NextMessage waits for the next inbound message event.
+func (a *Agent) NextMessage(ctx context.Context) (InboundEvent, error) {
+	select {
+	case msg := <-a.messages:
+		return msg, nil
+	case <-ctx.Done():
+		return InboundEvent{}, ctx.Err()
+	}
+}
+
+// WaitPaired blocks until a pairing_complete event arrives.
+func (a *Agent) WaitPaired(ctx context.Context) error {
+	select {
+	case <-a.paired:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Reply answers an inbound message with a simpleText template.
+func (a *Agent) Reply(ctx context.Context, messageID, text string) error {
+	a.mu.Lock()
+	token := a.token
+	a.mu.Unlock()
+
+	body := map[string]any{
+		"messageId": messageID,
+		"response": map[string]any{
+			"version": "2.0",
+			"template": map[string]any{
+				"outputs": []any{map[string]any{"simpleText": map[string]any{"text": text}}},
+			},
+		},
+	}
+	return a.postJSON(ctx, "/openclaw/reply", token, body, nil)
+}
+
+// listen keeps an SSE connection open. The relay moves a session from the
+// session channel to the account channel on pairing, so it reconnects after
+// pairing_complete like a real client.
+func (a *Agent) listen(ctx context.Context) {
+	for ctx.Err() == nil {
+		if err := a.stream(ctx); err != nil && ctx.Err() == nil {
+			time.Sleep(agentReconnectDelay)
+		}
+	}
+}
+
+func (a *Agent) stream(ctx context.Context) error {
+	a.mu.Lock()
+	token := a.token
+	a.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.relayURL+"/v1/events", nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "text/event-stream")
+
+	// No client timeout: the stream is long-lived.
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("events stream returned %d", resp.StatusCode)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var eventType string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if eventType != "" {
+				if a.dispatch(eventType, []byte(data.String())) {
+					return nil
+				}
+			}
+			eventType = ""
+			data.Reset()
+		case strings.HasPrefix(line, "event:"):
+			eventType = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data.WriteString(strings.TrimSpace(strings.TrimPrefix(line, "data:")))
+		}
+	}
+	return scanner.Err()
+}
+
+// dispatch handles one event and reports whether the stream should be
+// reopened.
+func (a *Agent) dispatch(eventType string, data []byte) bool {
+	switch eventType {
+	case "message":
+		var ev struct {
+			ID              string `json:"id"`
+			ConversationKey string `json:"conversationKey"`
+			Normalized      *struct {
+				Text string `json:"text"`
+			} `json:"normalized"`
+		}
+		if json.Unmarshal(data, &ev) != nil {
+			return false
+		}
+		msg := InboundEvent{ID: ev.ID, ConversationKey: ev.ConversationKey}
+		if ev.Normalized != nil {
+			msg.Text = ev.Normalized.Text
+		}
+		a.messages <- msg
+	case "pairing_complete":
+		select {
+		case a.paired <- struct{}{}:
+		default:
+		}
+		return true
+	}
+	return false
+}
+
+func (a *Agent) postJSON(ctx context.Context, path, token string, body any, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.relayURL+path, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s request failed: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s returned %d: %s", path, resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	if out != nil {
+		return json.Unmarshal(respBody, out)
+	}
+	return nil
+}
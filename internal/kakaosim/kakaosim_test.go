@@ -0,0 +1,236 @@
+package kakaosim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+// fakeRelay implements just enough of the relay for the simulator: /pair
+// answers synchronously, other messages are forwarded to the agent over
+// SSE and answered through /openclaw/reply via the stored callback URL.
+type fakeRelay struct {
+	t      *testing.T
+	secret string
+
+	mu        sync.Mutex
+	callbacks map[string]string
+	events    chan string
+	seq       int
+}
+
+func newFakeRelay(t *testing.T, secret string) *httptest.Server {
+	fr := &fakeRelay{t: t, secret: secret, callbacks: make(map[string]string), events: make(chan string, 10)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/kakao/webhook", fr.webhook)
+	mux.HandleFunc("/v1/sessions/create", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"sessionToken": "tok", "pairingCode": "ABCD-2345"})
+	})
+	mux.HandleFunc("/v1/events", fr.sse)
+	mux.HandleFunc("/openclaw/reply", fr.reply)
+	return httptest.NewServer(mux)
+}
+
+func (fr *fakeRelay) webhook(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	if fr.secret != "" && r.Header.Get("X-Kakao-Signature") != util.HmacSHA256(fr.secret, string(body)) {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req struct {
+		UserRequest struct {
+			Utterance   string `json:"utterance"`
+			CallbackURL string `json:"callbackUrl"`
+		} `json:"userRequest"`
+	}
+	json.Unmarshal(body, &req)
+
+	if req.UserRequest.Utterance == "/pair ABCD-2345" {
+		json.NewEncoder(w).Encode(map[string]any{"version": "2.0", "template": map[string]any{
+			"outputs": []any{map[string]any{"simpleText": map[string]any{"text": "OpenClaw에 연결되었습니다"}}},
+		}})
+		fr.events <- "event: pairing_complete\ndata: {}\n\n"
+		return
+	}
+
+	fr.mu.Lock()
+	fr.seq++
+	id := fmt.Sprintf("msg-%d", fr.seq)
+	fr.callbacks[id] = req.UserRequest.CallbackURL
+	fr.mu.Unlock()
+
+	data, _ := json.Marshal(map[string]any{"id": id, "conversationKey": "sim-bot:u1", "normalized": map[string]any{"text": req.UserRequest.Utterance}})
+	fr.events <- "event: message\ndata: " + string(data) + "\n\n"
+	json.NewEncoder(w).Encode(map[string]any{"version": "2.0", "useCallback": true})
+}
+
+func (fr *fakeRelay) sse(w http.ResponseWriter, r *http.Request) {
+	assert.Equal(fr.t, "Bearer tok", r.Header.Get("Authorization"))
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.(http.Flusher).Flush()
+	for {
+		select {
+		case ev := <-fr.events:
+			io.WriteString(w, ev)
+			w.(http.Flusher).Flush()
+			if strings.Contains(ev, "pairing_complete") {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func (fr *fakeRelay) reply(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		MessageID string          `json:"messageId"`
+		Response  json.RawMessage `json:"response"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+
+	fr.mu.Lock()
+	cbURL := fr.callbacks[req.MessageID]
+	fr.mu.Unlock()
+
+	resp, err := http.Post(cbURL, "application/json", bytes.NewReader(req.Response))
+	if err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		return
+	}
+	resp.Body.Close()
+	json.NewEncoder(w).Encode(map[string]any{"success": true})
+}
+
+func newTestRunner(t *testing.T, secret string) (*Runner, *bytes.Buffer) {
+	relay := newFakeRelay(t, secret)
+	t.Cleanup(relay.Close)
+
+	sim := New(Config{RelayURL: relay.URL, Secret: secret, BotID: "sim-bot", UserKey: "u1"})
+	receiver := httptest.NewServer(sim.Receiver)
+	t.Cleanup(receiver.Close)
+	sim.cfg.CallbackBaseURL = receiver.URL
+
+	agent := NewAgent(relay.URL)
+	t.Cleanup(agent.Close)
+
+	var out bytes.Buffer
+	return &Runner{Sim: sim, Agent: agent, Out: &out}, &out
+}
+
+const pairAndReplyScenario = `
+name: pair and reply
+steps:
+  - createSession: true
+  - send: "/pair {{pairingCode}}"
+    expect:
+      status: 200
+      reply: { contains: "연결되었습니다" }
+  - send: "안녕"
+    expect: { useCallback: true }
+  - agentReply: "반가워요"
+    expect:
+      inbound: { equals: "안녕" }
+      callback: { contains: "반가워요", within: 2s }
+  - send: "답 없는 메시지"
+    expect:
+      callback: { none: true, within: 200ms }
+`
+
+func TestRunner_PairAndReply(t *testing.T) {
+	runner, out := newTestRunner(t, "s3cret")
+
+	sc, err := ParseScenario([]byte(pairAndReplyScenario))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	require.NoError(t, runner.Run(ctx, sc), out.String())
+	assert.Contains(t, out.String(), "✓ 5.")
+}
+
+func TestRunner_FailsOnMismatch(t *testing.T) {
+	runner, out := newTestRunner(t, "")
+
+	sc, err := ParseScenario([]byte(`
+name: wrong reply
+steps:
+  - createSession: true
+  - send: "/pair {{pairingCode}}"
+    expect:
+      reply: { contains: "실패" }
+`))
+	require.NoError(t, err)
+
+	err = runner.Run(context.Background(), sc)
+	assert.ErrorContains(t, err, "step 2")
+	assert.Contains(t, out.String(), "✗ 2.")
+}
+
+func TestParseScenario(t *testing.T) {
+	t.Run("rejects unknown fields", func(t *testing.T) {
+		_, err := ParseScenario([]byte("name: x\nsteps:\n  - sned: hi\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects empty scenario", func(t *testing.T) {
+		_, err := ParseScenario([]byte("name: x\n"))
+		assert.Error(t, err)
+	})
+
+	t.Run("parses durations", func(t *testing.T) {
+		sc, err := ParseScenario([]byte("name: x\nsteps:\n  - sleep: 1500ms\n"))
+		require.NoError(t, err)
+		assert.Equal(t, 1500*time.Millisecond, sc.Steps[0].Sleep)
+	})
+}
+
+func TestBuildPayload(t *testing.T) {
+	t.Run("image message", func(t *testing.T) {
+		p := BuildPayload("bot-1", "user-1", Message{ImageURL: "https://talk.kakaocdn.net/a.jpg", CallbackURL: "http://cb"})
+		ur := p["userRequest"].(map[string]any)
+
+		assert.Equal(t, "https://talk.kakaocdn.net/a.jpg", ur["utterance"])
+		assert.Equal(t, "http://cb", ur["callbackUrl"])
+		assert.Equal(t, "image", ur["params"].(map[string]any)["media"].(map[string]any)["type"])
+		assert.Equal(t, "user-1", ur["user"].(map[string]any)["properties"].(map[string]any)["plusfriendUserKey"])
+		assert.Equal(t, "bot-1", p["bot"].(map[string]any)["id"])
+	})
+
+	t.Run("quick reply click", func(t *testing.T) {
+		p := BuildPayload("bot-1", "user-1", Message{QuickReply: &QuickReply{
+			Label: "예약하기", BlockID: "blk-1", ClientExtra: map[string]any{"slot": "3pm"},
+		}})
+		ur := p["userRequest"].(map[string]any)
+
+		assert.Equal(t, "예약하기", ur["utterance"])
+		assert.NotContains(t, ur, "callbackUrl")
+		assert.Equal(t, "blk-1", ur["block"].(map[string]any)["id"])
+		assert.Equal(t, "3pm", p["action"].(map[string]any)["clientExtra"].(map[string]any)["slot"])
+	})
+}
+
+func TestResponseText(t *testing.T) {
+	body := []byte(`{"version":"2.0","template":{"outputs":[
+		{"simpleText":{"text":"hello"}},
+		{"simpleImage":{"imageUrl":"https://x/y.png"}}
+	],"quickReplies":[{"label":"예"},{"label":"아니오"}]}}`)
+
+	assert.Equal(t, "hello\n[image] https://x/y.png\n[예] [아니오]", ResponseText(body))
+	assert.Equal(t, "", ResponseText([]byte(`{"version":"2.0","useCallback":true}`)))
+}
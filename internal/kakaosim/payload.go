@@ -0,0 +1,83 @@
+// Package kakaosim simulates the Kakao open builder side of the relay:
+// it sends skill webhooks, receives callbacks, and drives a minimal
+// OpenClaw agent so whole conversations can be tested without Kakao.
+package kakaosim
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+)
+
+// Message is one user action in the simulated chat.
+type Message struct {
+	Utterance   string
+	ImageURL    string
+	QuickReply  *QuickReply
+	CallbackURL string
+}
+
+// QuickReply simulates the user tapping a quick reply button that is
+// connected to a block.
+type QuickReply struct {
+	Label       string         `yaml:"label"`
+	BlockID     string         `yaml:"blockId"`
+	ClientExtra map[string]any `yaml:"extra"`
+}
+
+// BuildPayload returns a skill payload shaped like what the open builder
+// sends for the given message.
+func BuildPayload(botID, userKey string, msg Message) map[string]any {
+	sum := sha256.Sum256([]byte(botID + ":" + userKey))
+	botUserKey := hex.EncodeToString(sum[:])
+
+	params := map[string]any{"surface": "Kakaotalk.plusfriend"}
+	utterance := msg.Utterance
+	if msg.ImageURL != "" {
+		params["media"] = map[string]any{"type": "image", "url": msg.ImageURL}
+		utterance = msg.ImageURL
+	}
+
+	block := map[string]any{"id": "sim-fallback-block", "name": "폴백 블록"}
+	var clientExtra map[string]any
+	if qr := msg.QuickReply; qr != nil {
+		utterance = qr.Label
+		if qr.BlockID != "" {
+			block = map[string]any{"id": qr.BlockID, "name": qr.Label}
+		}
+		clientExtra = qr.ClientExtra
+	}
+
+	userRequest := map[string]any{
+		"timezone":  "Asia/Seoul",
+		"lang":      "ko",
+		"utterance": utterance,
+		"params":    params,
+		"block":     block,
+		"user": map[string]any{
+			"id":   botUserKey,
+			"type": "botUserKey",
+			"properties": map[string]any{
+				"plusfriendUserKey": userKey,
+				"botUserKey":        botUserKey,
+				"isFriend":          true,
+			},
+		},
+	}
+	if msg.CallbackURL != "" {
+		userRequest["callbackUrl"] = msg.CallbackURL
+	}
+
+	return map[string]any{
+		"intent":      map[string]any{"id": block["id"], "name": block["name"]},
+		"userRequest": userRequest,
+		"bot":         map[string]any{"id": botID, "name": "kakao-sim"},
+		"action": map[string]any{
+			"id":           "sim-action",
+			"name":         "openclaw_skill",
+			"params":       map[string]any{},
+			"detailParams": map[string]any{},
+			"clientExtra":  clientExtra,
+		},
+		"contexts": []any{},
+	}
+}
@@ -0,0 +1,99 @@
+package kakaosim
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Callback is a request the relay posted to a simulated callback URL.
+type Callback struct {
+	ID         string
+	Body       json.RawMessage
+	ReceivedAt time.Time
+}
+
+// Receiver records callbacks the relay sends back, keyed by the ID in the
+// callback path (/callback/{id}).
+type Receiver struct {
+	mu       sync.Mutex
+	received map[string][]Callback
+	waiters  map[string][]chan Callback
+}
+
+func NewReceiver() *Receiver {
+	return &Receiver{
+		received: make(map[string][]Callback),
+		waiters:  make(map[string][]chan Callback),
+	}
+}
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, ok := strings.CutPrefix(r.URL.Path, "/callback/")
+	if !ok || id == "" || r.Method != http.MethodPost {
+		http.NotFound(w, r)
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	cb := Callback{ID: id, Body: body, ReceivedAt: time.Now()}
+
+	rc.mu.Lock()
+	rc.received[id] = append(rc.received[id], cb)
+	waiters := rc.waiters[id]
+	delete(rc.waiters, id)
+	rc.mu.Unlock()
+
+	for _, ch := range waiters {
+		ch <- cb
+	}
+
+	// Same shape as the real callback API's acknowledgement.
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"taskId": "sim-" + id, "status": "SUCCESS"})
+}
+
+// Wait returns the first callback for id, blocking until it arrives or ctx
+// is done.
+func (rc *Receiver) Wait(ctx context.Context, id string) (Callback, error) {
+	rc.mu.Lock()
+	if cbs := rc.received[id]; len(cbs) > 0 {
+		rc.mu.Unlock()
+		return cbs[0], nil
+	}
+	ch := make(chan Callback, 1)
+	rc.waiters[id] = append(rc.waiters[id], ch)
+	rc.mu.Unlock()
+
+	select {
+	case cb := <-ch:
+		return cb, nil
+	case <-ctx.Done():
+		return Callback{}, ctx.Err()
+	}
+}
+
+// All returns every recorded callback in arrival order.
+func (rc *Receiver) All() []Callback {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	var all []Callback
+	for _, cbs := range rc.received {
+		all = append(all, cbs...)
+	}
+	sort.SliceStable(all, func(i, j int) bool {
+		return all[i].ReceivedAt.Before(all[j].ReceivedAt)
+	})
+	return all
+}
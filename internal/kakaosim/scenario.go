@@ -0,0 +1,303 @@
+package kakaosim
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultCallbackWait = 10 * time.Second
+	defaultAgentWait    = 10 * time.Second
+)
+
+// Scenario is a scripted conversation loaded from YAML.
+//
+//	name: pair and reply
+//	steps:
+//	  - createSession: true
+//	  - send: "/pair {{pairingCode}}"
+//	    expect:
+//	      reply: { contains: "연결" }
+//	  - send: "안녕"
+//	    expect: { useCallback: true }
+//	  - agentReply: "반가워요"
+//	    expect:
+//	      callback: { contains: "반가워요", within: 5s }
+type Scenario struct {
+	Name  string `yaml:"name"`
+	User  string `yaml:"user"`
+	Steps []Step `yaml:"steps"`
+}
+
+type Step struct {
+	Name          string        `yaml:"name"`
+	User          string        `yaml:"user"`
+	Send          string        `yaml:"send"`
+	Image         string        `yaml:"image"`
+	QuickReply    *QuickReply   `yaml:"quickReply"`
+	NoCallback    bool          `yaml:"noCallback"`
+	CreateSession bool          `yaml:"createSession"`
+	AgentReply    string        `yaml:"agentReply"`
+	Sleep         time.Duration `yaml:"sleep"`
+	Expect        *Expect       `yaml:"expect"`
+}
+
+type Expect struct {
+	Status      int             `yaml:"status"`
+	Reply       *TextMatch      `yaml:"reply"`
+	UseCallback *bool           `yaml:"useCallback"`
+	Callback    *CallbackExpect `yaml:"callback"`
+	Inbound     *TextMatch      `yaml:"inbound"`
+}
+
+type TextMatch struct {
+	Contains string `yaml:"contains"`
+	Equals   string `yaml:"equals"`
+	Matches  string `yaml:"matches"`
+}
+
+// CallbackExpect checks the callback for the most recently sent message.
+// With none set, the step passes only if no callback arrives within the wait.
+type CallbackExpect struct {
+	TextMatch `yaml:",inline"`
+	Within    time.Duration `yaml:"within"`
+	None      bool          `yaml:"none"`
+}
+
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseScenario(data)
+}
+
+func ParseScenario(data []byte) (*Scenario, error) {
+	var sc Scenario
+	dec := yaml.NewDecoder(strings.NewReader(string(data)))
+	dec.KnownFields(true)
+	if err := dec.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("parse scenario: %w", err)
+	}
+	if len(sc.Steps) == 0 {
+		return nil, errors.New("scenario has no steps")
+	}
+	return &sc, nil
+}
+
+func (m *TextMatch) Check(actual string) error {
+	if m == nil {
+		return nil
+	}
+	if m.Equals != "" && actual != m.Equals {
+		return fmt.Errorf("expected %q, got %q", m.Equals, actual)
+	}
+	if m.Contains != "" && !strings.Contains(actual, m.Contains) {
+		return fmt.Errorf("expected text containing %q, got %q", m.Contains, actual)
+	}
+	if m.Matches != "" {
+		re, err := regexp.Compile(m.Matches)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", m.Matches, err)
+		}
+		if !re.MatchString(actual) {
+			return fmt.Errorf("expected text matching %q, got %q", m.Matches, actual)
+		}
+	}
+	return nil
+}
+
+// Runner executes scenarios against a relay.
+type Runner struct {
+	Sim   *Simulator
+	Agent *Agent
+	Out   io.Writer
+
+	vars           map[string]string
+	lastCallbackID string
+}
+
+func (r *Runner) Run(ctx context.Context, sc *Scenario) error {
+	r.vars = make(map[string]string)
+	r.lastCallbackID = ""
+	if sc.User != "" {
+		r.Sim.SetUser(sc.User)
+	}
+
+	fmt.Fprintf(r.Out, "▶ %s\n", sc.Name)
+	for i, step := range sc.Steps {
+		label := step.Name
+		if label == "" {
+			label = describeStep(step)
+		}
+		if err := r.runStep(ctx, step); err != nil {
+			fmt.Fprintf(r.Out, "  ✗ %d. %s\n      %v\n", i+1, label, err)
+			return fmt.Errorf("%s: step %d (%s): %w", sc.Name, i+1, label, err)
+		}
+		fmt.Fprintf(r.Out, "  ✓ %d. %s\n", i+1, label)
+	}
+	return nil
+}
+
+func (r *Runner) runStep(ctx context.Context, step Step) error {
+	if step.User != "" {
+		r.Sim.SetUser(step.User)
+	}
+
+	if step.Sleep > 0 {
+		select {
+		case <-time.After(step.Sleep):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	if step.CreateSession {
+		code, err := r.Agent.CreateSession(ctx)
+		if err != nil {
+			return err
+		}
+		r.vars["pairingCode"] = code
+	}
+
+	var inbound *InboundEvent
+	if step.AgentReply != "" {
+		waitCtx, cancel := context.WithTimeout(ctx, defaultAgentWait)
+		msg, err := r.Agent.NextMessage(waitCtx)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("agent did not receive a message: %w", err)
+		}
+		inbound = &msg
+		if err := r.Agent.Reply(ctx, msg.ID, r.expand(step.AgentReply)); err != nil {
+			return err
+		}
+	}
+
+	var reply *Reply
+	msg, hasMessage := r.message(step)
+	if hasMessage {
+		var err error
+		reply, err = r.Sim.Send(ctx, msg, step.NoCallback)
+		if err != nil {
+			return err
+		}
+		r.lastCallbackID = reply.CallbackID
+	}
+
+	if step.Expect == nil {
+		return nil
+	}
+	return r.check(ctx, step.Expect, reply, inbound)
+}
+
+func (r *Runner) message(step Step) (Message, bool) {
+	switch {
+	case step.Send != "":
+		return Message{Utterance: r.expand(step.Send)}, true
+	case step.Image != "":
+		return Message{ImageURL: step.Image}, true
+	case step.QuickReply != nil:
+		return Message{QuickReply: step.QuickReply}, true
+	}
+	return Message{}, false
+}
+
+func (r *Runner) check(ctx context.Context, exp *Expect, reply *Reply, inbound *InboundEvent) error {
+	if exp.Status != 0 || exp.Reply != nil || exp.UseCallback != nil {
+		if reply == nil {
+			return errors.New("expectation on webhook reply but step sent no message")
+		}
+		if exp.Status != 0 && reply.StatusCode != exp.Status {
+			return fmt.Errorf("expected status %d, got %d", exp.Status, reply.StatusCode)
+		}
+		if exp.UseCallback != nil && reply.UseCallback != *exp.UseCallback {
+			return fmt.Errorf("expected useCallback=%v, got %v (%s)", *exp.UseCallback, reply.UseCallback, reply.Text)
+		}
+		if err := exp.Reply.Check(reply.Text); err != nil {
+			return fmt.Errorf("reply: %w", err)
+		}
+	}
+
+	if exp.Inbound != nil {
+		if inbound == nil {
+			return errors.New("inbound expectation requires agentReply")
+		}
+		if err := exp.Inbound.Check(inbound.Text); err != nil {
+			return fmt.Errorf("inbound: %w", err)
+		}
+	}
+
+	if exp.Callback != nil {
+		return r.checkCallback(ctx, exp.Callback)
+	}
+	return nil
+}
+
+func (r *Runner) checkCallback(ctx context.Context, exp *CallbackExpect) error {
+	if r.lastCallbackID == "" {
+		return errors.New("callback expectation but no message with a callback URL was sent")
+	}
+
+	wait := exp.Within
+	if wait == 0 {
+		wait = defaultCallbackWait
+	}
+	waitCtx, cancel := context.WithTimeout(ctx, wait)
+	defer cancel()
+
+	cb, err := r.Sim.Receiver.Wait(waitCtx, r.lastCallbackID)
+	if exp.None {
+		if err == nil {
+			return fmt.Errorf("expected no callback, got %s", ResponseText(cb.Body))
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("no callback within %s", wait)
+	}
+	if err := exp.TextMatch.Check(ResponseText(cb.Body)); err != nil {
+		return fmt.Errorf("callback: %w", err)
+	}
+	return nil
+}
+
+func (r *Runner) expand(s string) string {
+	for k, v := range r.vars {
+		s = strings.ReplaceAll(s, "{{"+k+"}}", v)
+	}
+	return s
+}
+
+func describeStep(step Step) string {
+	var parts []string
+	if step.CreateSession {
+		parts = append(parts, "create session")
+	}
+	if step.Sleep > 0 {
+		parts = append(parts, "sleep "+step.Sleep.String())
+	}
+	if step.AgentReply != "" {
+		parts = append(parts, fmt.Sprintf("agent replies %q", step.AgentReply))
+	}
+	switch {
+	case step.Send != "":
+		parts = append(parts, fmt.Sprintf("send %q", step.Send))
+	case step.Image != "":
+		parts = append(parts, "send image")
+	case step.QuickReply != nil:
+		parts = append(parts, fmt.Sprintf("tap quick reply %q", step.QuickReply.Label))
+	}
+	if len(parts) == 0 {
+		return "expect"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,176 @@
+package kakaosim
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const requestTimeout = 10 * time.Second
+
+type Config struct {
+	RelayURL string
+	// Secret signs webhooks with X-Kakao-Signature when set.
+	Secret  string
+	BotID   string
+	UserKey string
+	// CallbackBaseURL is where the Receiver is reachable from the relay,
+	// e.g. http://127.0.0.1:9090. Empty disables callback URLs.
+	CallbackBaseURL string
+}
+
+// Reply is the relay's synchronous answer to a webhook.
+type Reply struct {
+	CallbackID  string
+	StatusCode  int
+	Body        json.RawMessage
+	UseCallback bool
+	Text        string
+}
+
+type Simulator struct {
+	Receiver *Receiver
+
+	mu     sync.Mutex
+	cfg    Config
+	seq    int
+	client *http.Client
+}
+
+func New(cfg Config) *Simulator {
+	return &Simulator{
+		Receiver: NewReceiver(),
+		cfg:      cfg,
+		client:   &http.Client{Timeout: requestTimeout},
+	}
+}
+
+func (s *Simulator) SetUser(userKey string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cfg.UserKey = userKey
+}
+
+func (s *Simulator) User() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.cfg.UserKey
+}
+
+// Send posts one webhook to the relay. Unless noCallback is set, the payload
+// carries a callback URL pointing at the Receiver.
+func (s *Simulator) Send(ctx context.Context, msg Message, noCallback bool) (*Reply, error) {
+	s.mu.Lock()
+	s.seq++
+	callbackID := strconv.Itoa(s.seq)
+	cfg := s.cfg
+	s.mu.Unlock()
+
+	if !noCallback && cfg.CallbackBaseURL != "" {
+		msg.CallbackURL = strings.TrimRight(cfg.CallbackBaseURL, "/") + "/callback/" + callbackID
+	}
+
+	body, err := json.Marshal(BuildPayload(cfg.BotID, cfg.UserKey, msg))
+	if err != nil {
+		return nil, fmt.Errorf("marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost,
+		strings.TrimRight(cfg.RelayURL, "/")+"/kakao/webhook", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cfg.Secret != "" {
+		req.Header.Set("X-Kakao-Signature", util.HmacSHA256(cfg.Secret, string(body)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read webhook response: %w", err)
+	}
+
+	reply := &Reply{
+		CallbackID: callbackID,
+		StatusCode: resp.StatusCode,
+		Body:       respBody,
+		Text:       ResponseText(respBody),
+	}
+	if msg.CallbackURL == "" {
+		reply.CallbackID = ""
+	}
+
+	var parsed struct {
+		UseCallback bool `json:"useCallback"`
+	}
+	if json.Unmarshal(respBody, &parsed) == nil {
+		reply.UseCallback = parsed.UseCallback
+	}
+
+	return reply, nil
+}
+
+// ResponseText flattens a skill response template into readable text:
+// simpleText outputs verbatim, images as "[image] url", and quick replies
+// as "[label]" buttons.
+func ResponseText(body []byte) string {
+	var resp struct {
+		Template *struct {
+			Outputs []struct {
+				SimpleText *struct {
+					Text string `json:"text"`
+				} `json:"simpleText"`
+				SimpleImage *struct {
+					ImageURL string `json:"imageUrl"`
+				} `json:"simpleImage"`
+				BasicCard *struct {
+					Title       string `json:"title"`
+					Description string `json:"description"`
+				} `json:"basicCard"`
+			} `json:"outputs"`
+			QuickReplies []struct {
+				Label string `json:"label"`
+			} `json:"quickReplies"`
+		} `json:"template"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Template == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, out := range resp.Template.Outputs {
+		switch {
+		case out.SimpleText != nil:
+			parts = append(parts, out.SimpleText.Text)
+		case out.SimpleImage != nil:
+			parts = append(parts, "[image] "+out.SimpleImage.ImageURL)
+		case out.BasicCard != nil:
+			parts = append(parts, strings.TrimSpace(out.BasicCard.Title+"\n"+out.BasicCard.Description))
+		}
+	}
+
+	if len(resp.Template.QuickReplies) > 0 {
+		labels := make([]string, len(resp.Template.QuickReplies))
+		for i, qr := range resp.Template.QuickReplies {
+			labels[i] = "[" + qr.Label + "]"
+		}
+		parts = append(parts, strings.Join(labels, " "))
+	}
+
+	return strings.Join(parts, "\n")
+}
@@ -76,6 +76,13 @@ func (m *AuthMiddleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
+		if session.Status == model.SessionStatusDisconnected {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": "Session disconnected",
+			})
+			return
+		}
+
 		ctx = context.WithValue(ctx, SessionContextKey, session)
 
 		// If session is paired, also add the linked account
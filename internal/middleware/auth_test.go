@@ -84,6 +84,10 @@ func (m *mockSessionRepo) FindRecent(ctx context.Context, limit int) ([]model.Se
 	return nil, nil
 }
 
+func (m *mockSessionRepo) CountPairedByAccountID(ctx context.Context, accountID string) (int, error) {
+	return 0, nil
+}
+
 func (m *mockSessionRepo) CountByStatus(ctx context.Context, status model.SessionStatus) (int, error) {
 	return 0, nil
 }
@@ -92,6 +96,14 @@ func (m *mockSessionRepo) UpdateMetadata(ctx context.Context, id string, metadat
 	return nil
 }
 
+func (m *mockSessionRepo) UpdateTokenHash(ctx context.Context, id, tokenHash string) error {
+	return nil
+}
+
+func (m *mockSessionRepo) ClearRelayTokens(ctx context.Context, accountID string) error {
+	return nil
+}
+
 func (m *mockSessionRepo) Delete(ctx context.Context, id string) error {
 	return nil
 }
@@ -124,6 +136,26 @@ func (m *mockAccountRepo) UpdateToken(ctx context.Context, id, tokenHash string)
 	return nil, nil
 }
 
+func (m *mockAccountRepo) RevokeToken(ctx context.Context, id string) error {
+	return nil
+}
+
+func (m *mockAccountRepo) UpdateLocale(ctx context.Context, id string, locale *string) (*model.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) UpdatePairingApproval(ctx context.Context, id string, required bool) (*model.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) UpdateOrderedDelivery(ctx context.Context, id string, ordered bool) (*model.Account, error) {
+	return nil, nil
+}
+
+func (m *mockAccountRepo) UpdateCoalesceWindow(ctx context.Context, id string, windowMs int) (*model.Account, error) {
+	return nil, nil
+}
+
 func (m *mockAccountRepo) WithTx(tx *sqlx.Tx) repository.AccountRepository {
 	return m
 }
@@ -268,6 +300,62 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, rec.Code)
 	})
 
+	t.Run("rejects a relay token without a session", func(t *testing.T) {
+		accountRepo := &mockAccountRepo{
+			findByTokenHashFunc: func(ctx context.Context, tokenHash string) (*model.Account, error) {
+				if tokenHash == validTokenHash {
+					return testAccount, nil
+				}
+				return nil, nil
+			},
+		}
+		sessionRepo := &mockSessionRepo{}
+
+		middleware := NewAuthMiddleware(accountRepo, sessionRepo)
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be called")
+		}))
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+validToken)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("rejects disconnected session", func(t *testing.T) {
+		disconnected := &model.Session{
+			ID:        "sess-123",
+			Status:    model.SessionStatusDisconnected,
+			AccountID: &accountID,
+		}
+		accountRepo := &mockAccountRepo{
+			findByIDFunc: func(ctx context.Context, id string) (*model.Account, error) {
+				return testAccount, nil
+			},
+		}
+		sessionRepo := &mockSessionRepo{
+			findByTokenHashFunc: func(ctx context.Context, tokenHash string) (*model.Session, error) {
+				return disconnected, nil
+			},
+		}
+
+		middleware := NewAuthMiddleware(accountRepo, sessionRepo)
+		handler := middleware.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			t.Fatal("handler should not be called")
+		}))
+
+		req := httptest.NewRequest("GET", "/test", nil)
+		req.Header.Set("Authorization", "Bearer "+validToken)
+		rec := httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
 	t.Run("allows pending session without account", func(t *testing.T) {
 		pendingSession := &model.Session{
 			ID:     "sess-pending",
@@ -9,26 +9,39 @@ const (
 )
 
 type BodyLimitMiddleware struct {
-	maxSize int64
+	maxSize   int64
+	overrides map[string]int64
 }
 
 func NewBodyLimitMiddleware(maxSize int64) *BodyLimitMiddleware {
 	if maxSize <= 0 {
 		maxSize = DefaultMaxBodySize
 	}
-	return &BodyLimitMiddleware{maxSize: maxSize}
+	return &BodyLimitMiddleware{maxSize: maxSize, overrides: make(map[string]int64)}
+}
+
+// Override sets a different limit for requests to an exact path, e.g. upload
+// endpoints that need more than the global default.
+func (m *BodyLimitMiddleware) Override(path string, maxSize int64) *BodyLimitMiddleware {
+	m.overrides[path] = maxSize
+	return m
 }
 
 func (m *BodyLimitMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if r.Body != nil && r.ContentLength > m.maxSize {
+		maxSize := m.maxSize
+		if override, ok := m.overrides[r.URL.Path]; ok {
+			maxSize = override
+		}
+
+		if r.Body != nil && r.ContentLength > maxSize {
 			writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
 				"error": "Request body too large",
 			})
 			return
 		}
 
-		r.Body = http.MaxBytesReader(w, r.Body, m.maxSize)
+		r.Body = http.MaxBytesReader(w, r.Body, maxSize)
 		next.ServeHTTP(w, r)
 	})
 }
@@ -7,30 +7,110 @@ import (
 	"io"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
 
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
 )
 
-const KakaoBodyContextKey contextKey = "kakaoBody"
+const (
+	KakaoBodyContextKey    contextKey = "kakaoBody"
+	KakaoChannelContextKey contextKey = "kakaoChannel"
+)
 
 func GetKakaoBody(ctx context.Context) any {
 	return ctx.Value(KakaoBodyContextKey)
 }
 
+// GetKakaoChannel returns the registered channel the webhook is for, or nil
+// if the bot is not registered.
+func GetKakaoChannel(ctx context.Context) *model.Channel {
+	if channel, ok := ctx.Value(KakaoChannelContextKey).(*model.Channel); ok {
+		return channel
+	}
+	return nil
+}
+
+// ChannelLookup finds registered channels and their signature secrets.
+type ChannelLookup interface {
+	Get(ctx context.Context, id string) (*model.Channel, error)
+	SignatureSecret(channel *model.Channel) (string, error)
+}
+
 type KakaoSignatureMiddleware struct {
-	secret string
+	secret        string
+	channels      ChannelLookup
+	allowUnsigned bool
 }
 
+// NewKakaoSignatureMiddleware verifies webhooks with secret, the global
+// KAKAO_SIGNATURE_SECRET.
 func NewKakaoSignatureMiddleware(secret string) *KakaoSignatureMiddleware {
 	return &KakaoSignatureMiddleware{secret: secret}
 }
 
+// WithChannels resolves the channel of each webhook from the {channel} path
+// parameter or bot.id, rejects disabled channels and verifies with the
+// channel's own secret when it has one.
+func (m *KakaoSignatureMiddleware) WithChannels(channels ChannelLookup) *KakaoSignatureMiddleware {
+	m.channels = channels
+	return m
+}
+
+// AllowUnsignedChannels lets registered channels be served without
+// verification when neither they nor the global setting have a secret.
+// Otherwise their webhooks are refused.
+func (m *KakaoSignatureMiddleware) AllowUnsignedChannels(allow bool) *KakaoSignatureMiddleware {
+	m.allowUnsigned = allow
+	return m
+}
+
 func (m *KakaoSignatureMiddleware) Handler(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		if m.secret == "" {
-			log.Warn().Msg("kakao signature verification bypassed: KAKAO_SIGNATURE_SECRET is not configured")
-			next.ServeHTTP(w, r)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			log.Error().Err(err).Msg("kakao signature middleware: failed to read body")
+			writeJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "Failed to read request body",
+			})
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		ctx := r.Context()
+		secret := m.secret
+		if m.channels != nil {
+			channel, ok := m.resolveChannel(w, r, body)
+			if !ok {
+				return
+			}
+			if channel != nil {
+				channelSecret, err := m.channels.SignatureSecret(channel)
+				if err != nil {
+					log.Error().Err(err).Msg("kakao signature middleware: failed to load channel secret")
+					writeJSON(w, http.StatusInternalServerError, map[string]string{
+						"error": "Failed to verify signature",
+					})
+					return
+				}
+				if channelSecret != "" {
+					secret = channelSecret
+				}
+				if secret == "" && !m.allowUnsigned {
+					log.Error().Str("channel", channel.ID).Msg("kakao signature middleware: channel has no signature secret")
+					writeJSON(w, http.StatusForbidden, map[string]string{
+						"error": "Channel signature secret not configured",
+					})
+					return
+				}
+				ctx = context.WithValue(ctx, KakaoChannelContextKey, channel)
+			}
+		}
+
+		if secret == "" {
+			log.Warn().Msg("kakao signature verification bypassed: no signature secret is configured for the channel")
+			next.ServeHTTP(w, r.WithContext(ctx))
 			return
 		}
 
@@ -43,17 +123,7 @@ func (m *KakaoSignatureMiddleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
-		body, err := io.ReadAll(r.Body)
-		if err != nil {
-			log.Error().Err(err).Msg("kakao signature middleware: failed to read body")
-			writeJSON(w, http.StatusInternalServerError, map[string]string{
-				"error": "Failed to read request body",
-			})
-			return
-		}
-		r.Body = io.NopCloser(bytes.NewReader(body))
-
-		computed := util.HmacSHA256(m.secret, string(body))
+		computed := util.HmacSHA256(secret, string(body))
 		if !util.ConstantTimeEqual(computed, signature) {
 			log.Warn().Msg("kakao signature middleware: invalid signature")
 			writeJSON(w, http.StatusUnauthorized, map[string]string{
@@ -71,7 +141,64 @@ func (m *KakaoSignatureMiddleware) Handler(next http.Handler) http.Handler {
 			return
 		}
 
-		ctx := context.WithValue(r.Context(), KakaoBodyContextKey, parsed)
+		ctx = context.WithValue(ctx, KakaoBodyContextKey, parsed)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// resolveChannel finds the registered channel of the webhook. The path
+// parameter must name a registered channel and agree with bot.id; a bot.id
+// alone may be unregistered and then uses the global settings. It writes
+// the error response and returns false when the webhook is rejected.
+func (m *KakaoSignatureMiddleware) resolveChannel(w http.ResponseWriter, r *http.Request, body []byte) (*model.Channel, bool) {
+	var payload struct {
+		Bot *struct {
+			ID string `json:"id"`
+		} `json:"bot"`
+	}
+	// Undecodable bodies are rejected after the signature check.
+	_ = json.Unmarshal(body, &payload)
+	botID := ""
+	if payload.Bot != nil {
+		botID = payload.Bot.ID
+	}
+
+	pathID := chi.URLParam(r, "channel")
+	if pathID != "" && botID != "" && pathID != botID {
+		log.Warn().Str("channel", pathID).Str("botId", botID).Msg("kakao signature middleware: bot id does not match webhook path")
+		writeJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Bot ID does not match channel",
+		})
+		return nil, false
+	}
+	id := pathID
+	if id == "" {
+		id = botID
+	}
+	if id == "" {
+		return nil, true
+	}
+
+	channel, err := m.channels.Get(r.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("channel", id).Msg("kakao signature middleware: failed to load channel")
+		writeJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "Failed to load channel",
+		})
+		return nil, false
+	}
+	if channel == nil && pathID != "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{
+			"error": "Unknown channel",
+		})
+		return nil, false
+	}
+	if channel != nil && !channel.Enabled {
+		log.Warn().Str("channel", id).Msg("kakao signature middleware: channel is disabled")
+		writeJSON(w, http.StatusForbidden, map[string]string{
+			"error": "Channel disabled",
+		})
+		return nil, false
+	}
+	return channel, true
+}
@@ -2,12 +2,15 @@ package middleware
 
 import (
 	"bytes"
+	"context"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/stretchr/testify/assert"
 
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
 )
 
@@ -113,3 +116,121 @@ func TestKakaoSignatureMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusBadRequest, rec.Code)
 	})
 }
+
+type fakeChannels map[string]*model.Channel
+
+func (f fakeChannels) Get(ctx context.Context, id string) (*model.Channel, error) {
+	return f[id], nil
+}
+
+func (f fakeChannels) SignatureSecret(channel *model.Channel) (string, error) {
+	if channel.SignatureSecret == nil {
+		return "", nil
+	}
+	return *channel.SignatureSecret, nil
+}
+
+func TestKakaoSignatureMiddleware_Channels(t *testing.T) {
+	globalSecret := "global-secret"
+	botSecret := "bot-secret"
+	channels := fakeChannels{
+		"bot-a":   {ID: "bot-a", Enabled: true, SignatureSecret: &botSecret},
+		"bot-b":   {ID: "bot-b", Enabled: true},
+		"bot-off": {ID: "bot-off", Enabled: false},
+	}
+
+	newRouter := func(reached *string) http.Handler {
+		m := NewKakaoSignatureMiddleware(globalSecret).WithChannels(channels)
+		r := chi.NewRouter()
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*reached = "unregistered"
+			if channel := GetKakaoChannel(r.Context()); channel != nil {
+				*reached = channel.ID
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		r.With(m.Handler).Post("/kakao/webhook", handler)
+		r.With(m.Handler).Post("/kakao/{channel}/webhook", handler)
+		return r
+	}
+
+	send := func(path, body, secret string) (int, string) {
+		var reached string
+		req := httptest.NewRequest("POST", path, bytes.NewBufferString(body))
+		req.Header.Set("X-Kakao-Signature", util.HmacSHA256(secret, body))
+		rec := httptest.NewRecorder()
+		newRouter(&reached).ServeHTTP(rec, req)
+		return rec.Code, reached
+	}
+
+	t.Run("verifies with the channel secret", func(t *testing.T) {
+		body := `{"bot":{"id":"bot-a"}}`
+
+		code, reached := send("/kakao/webhook", body, botSecret)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "bot-a", reached)
+
+		code, _ = send("/kakao/webhook", body, globalSecret)
+		assert.Equal(t, http.StatusUnauthorized, code)
+	})
+
+	t.Run("falls back to the global secret", func(t *testing.T) {
+		code, reached := send("/kakao/webhook", `{"bot":{"id":"bot-b"}}`, globalSecret)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "bot-b", reached)
+
+		code, reached = send("/kakao/webhook", `{"bot":{"id":"bot-new"}}`, globalSecret)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "unregistered", reached)
+	})
+
+	t.Run("resolves the channel from the path", func(t *testing.T) {
+		code, reached := send("/kakao/bot-a/webhook", `{"userRequest":{}}`, botSecret)
+		assert.Equal(t, http.StatusOK, code)
+		assert.Equal(t, "bot-a", reached)
+	})
+
+	t.Run("rejects a bot id that does not match the path", func(t *testing.T) {
+		code, _ := send("/kakao/bot-b/webhook", `{"bot":{"id":"bot-a"}}`, botSecret)
+		assert.Equal(t, http.StatusBadRequest, code)
+	})
+
+	t.Run("rejects unknown channel paths", func(t *testing.T) {
+		code, _ := send("/kakao/bot-new/webhook", `{}`, globalSecret)
+		assert.Equal(t, http.StatusNotFound, code)
+	})
+
+	t.Run("rejects disabled channels", func(t *testing.T) {
+		code, _ := send("/kakao/webhook", `{"bot":{"id":"bot-off"}}`, globalSecret)
+		assert.Equal(t, http.StatusForbidden, code)
+	})
+}
+
+func TestKakaoSignatureMiddleware_UnsignedChannels(t *testing.T) {
+	channels := fakeChannels{"bot-b": {ID: "bot-b", Enabled: true}}
+
+	send := func(m *KakaoSignatureMiddleware, body string) int {
+		handler := m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		req := httptest.NewRequest("POST", "/kakao/webhook", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Run("refuses a registered channel without any secret", func(t *testing.T) {
+		m := NewKakaoSignatureMiddleware("").WithChannels(channels)
+		assert.Equal(t, http.StatusForbidden, send(m, `{"bot":{"id":"bot-b"}}`))
+	})
+
+	t.Run("serves it when unsigned channels are allowed", func(t *testing.T) {
+		m := NewKakaoSignatureMiddleware("").WithChannels(channels).AllowUnsignedChannels(true)
+		assert.Equal(t, http.StatusOK, send(m, `{"bot":{"id":"bot-b"}}`))
+	})
+
+	t.Run("leaves unregistered bots to the global setting", func(t *testing.T) {
+		m := NewKakaoSignatureMiddleware("").WithChannels(channels)
+		assert.Equal(t, http.StatusOK, send(m, `{"bot":{"id":"bot-new"}}`))
+	})
+}
@@ -8,8 +8,19 @@ type Account struct {
 	ID              string  `db:"id" json:"id"`
 	RelayTokenHash  *string `db:"relay_token_hash" json:"-"`
 	RateLimitPerMin int     `db:"rate_limit_per_minute" json:"rateLimitPerMinute"`
-	CreatedAt       time.Time `db:"created_at" json:"createdAt"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updatedAt"`
+	Locale          *string `db:"locale" json:"locale,omitempty"`
+	// RequirePairingApproval makes /pair with the account's pairing codes
+	// wait for the account to approve the user.
+	RequirePairingApproval bool `db:"require_pairing_approval" json:"requirePairingApproval"`
+	// OrderedDelivery holds a conversation's later messages until the
+	// earlier one is settled.
+	OrderedDelivery bool `db:"ordered_delivery" json:"orderedDelivery"`
+	// CoalesceWindowMs merges a conversation's utterances that arrive
+	// within this many milliseconds of each other into one event. Zero
+	// turns coalescing off.
+	CoalesceWindowMs int       `db:"coalesce_window_ms" json:"coalesceWindowMs"`
+	CreatedAt        time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updatedAt"`
 }
 
 type CreateAccountParams struct {
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+// Attachment is a user-sent file copied from Kakao's CDN into the blob store
+// so it stays available after the original URL expires.
+type Attachment struct {
+	ID               string           `db:"id" json:"id"`
+	InboundMessageID string           `db:"inbound_message_id" json:"inboundMessageId"`
+	AccountID        string           `db:"account_id" json:"accountId"`
+	Type             string           `db:"type" json:"type"`
+	SourceURL        string           `db:"source_url" json:"-"`
+	StorageKey       *string          `db:"storage_key" json:"-"`
+	ContentType      *string          `db:"content_type" json:"contentType,omitempty"`
+	SizeBytes        *int64           `db:"size_bytes" json:"sizeBytes,omitempty"`
+	Status           AttachmentStatus `db:"status" json:"status"`
+	ErrorMessage     *string          `db:"error_message" json:"errorMessage,omitempty"`
+	CreatedAt        time.Time        `db:"created_at" json:"createdAt"`
+	StoredAt         *time.Time       `db:"stored_at" json:"storedAt,omitempty"`
+}
+
+type CreateAttachmentParams struct {
+	InboundMessageID string
+	AccountID        string
+	Type             string
+	SourceURL        string
+}
+
+// AttachmentLink is the attachment entry included in SSE message events.
+type AttachmentLink struct {
+	ID          string           `json:"id"`
+	Type        string           `json:"type"`
+	Status      AttachmentStatus `json:"status"`
+	ContentType string           `json:"contentType,omitempty"`
+	SizeBytes   int64            `json:"sizeBytes,omitempty"`
+	URL         string           `json:"url"`
+	ExpiresAt   time.Time        `json:"expiresAt"`
+}
@@ -0,0 +1,39 @@
+package model
+
+import "time"
+
+// Channel is a registered Kakao bot. Unset fields fall back to the relay's
+// global configuration.
+type Channel struct {
+	// ID is the Kakao bot ID, as sent in bot.id of skill requests.
+	ID          string `db:"id" json:"id"`
+	DisplayName string `db:"display_name" json:"displayName"`
+	// SignatureSecret verifies X-Kakao-Signature for the bot. It is stored
+	// encrypted when ENCRYPTION_KEY is set.
+	SignatureSecret          *string   `db:"signature_secret" json:"-"`
+	SignatureSecretEncrypted bool      `db:"signature_secret_encrypted" json:"-"`
+	CallbackTTLSeconds       *int      `db:"callback_ttl_seconds" json:"callbackTtlSeconds"`
+	Enabled                  bool      `db:"enabled" json:"enabled"`
+	DefaultLocale            *string   `db:"default_locale" json:"defaultLocale"`
+	CreatedAt                time.Time `db:"created_at" json:"createdAt"`
+	UpdatedAt                time.Time `db:"updated_at" json:"updatedAt"`
+}
+
+// HasSignatureSecret reports whether the channel verifies signatures with
+// its own secret.
+func (c *Channel) HasSignatureSecret() bool {
+	return c.SignatureSecret != nil && *c.SignatureSecret != ""
+}
+
+type UpsertChannelParams struct {
+	ID          string
+	DisplayName string
+	// KeepSignatureSecret leaves the stored secret unchanged; otherwise
+	// SignatureSecret replaces it and nil clears it.
+	KeepSignatureSecret      bool
+	SignatureSecret          *string
+	SignatureSecretEncrypted bool
+	CallbackTTLSeconds       *int
+	Enabled                  bool
+	DefaultLocale            *string
+}
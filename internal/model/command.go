@@ -0,0 +1,26 @@
+package model
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// AccountCommand is a slash command declared by an OpenClaw agent. Matching
+// utterances are forwarded as "command" SSE events instead of plain messages.
+type AccountCommand struct {
+	ID          string         `db:"id" json:"id"`
+	AccountID   string         `db:"account_id" json:"-"`
+	Name        string         `db:"name" json:"name"`
+	Aliases     pq.StringArray `db:"aliases" json:"aliases"`
+	Usage       string         `db:"usage" json:"usage,omitempty"`
+	Description string         `db:"description" json:"description"`
+	CreatedAt   time.Time      `db:"created_at" json:"createdAt"`
+}
+
+type CreateAccountCommandParams struct {
+	Name        string
+	Aliases     []string
+	Usage       string
+	Description string
+}
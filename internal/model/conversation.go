@@ -16,6 +16,14 @@ type ConversationMapping struct {
 	FirstSeenAt           time.Time    `db:"first_seen_at" json:"firstSeenAt"`
 	LastSeenAt            time.Time    `db:"last_seen_at" json:"lastSeenAt"`
 	PairedAt              *time.Time   `db:"paired_at" json:"pairedAt,omitempty"`
+	// Locale is detected from the user's first utterance, if enabled.
+	Locale *string `db:"locale" json:"locale,omitempty"`
+	// PairingNotice is the outcome of a pairing request, or a disconnect by
+	// OpenClaw, that the user has not been told about yet.
+	PairingNotice *PairingNotice `db:"pairing_notice" json:"-"`
+	// ThreadID is the current topic of the conversation. /new replaces it;
+	// messages record the thread they were sent in.
+	ThreadID string `db:"thread_id" json:"threadId"`
 }
 
 type UpsertConversationParams struct {
@@ -0,0 +1,34 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ConversationState is one key of an account's per-conversation key-value
+// store. Version starts at 1 and grows with every write so clients can
+// update it optimistically.
+type ConversationState struct {
+	AccountID       string          `db:"account_id" json:"-"`
+	ConversationKey string          `db:"conversation_key" json:"-"`
+	Key             string          `db:"key" json:"key"`
+	Value           json.RawMessage `db:"value" json:"value"`
+	Version         int64           `db:"version" json:"version"`
+	ExpiresAt       *time.Time      `db:"expires_at" json:"expiresAt,omitempty"`
+	CreatedAt       time.Time       `db:"created_at" json:"createdAt"`
+	UpdatedAt       time.Time       `db:"updated_at" json:"updatedAt"`
+}
+
+type PutConversationStateParams struct {
+	AccountID       string
+	ConversationKey string
+	Key             string
+	Value           json.RawMessage
+	ExpiresAt       *time.Time
+	// IfVersion, when set, makes the write conditional: 0 requires that the
+	// key does not exist, any other value that it is at that version.
+	IfVersion *int64
+	// MaxKeys, when positive, refuses to create a key once the
+	// conversation has that many live keys.
+	MaxKeys int
+}
@@ -9,6 +9,28 @@ const (
 	PairingStateBlocked  PairingState = "blocked"
 )
 
+// PairingRequestStatus is the outcome of an approval-mode pairing request.
+type PairingRequestStatus string
+
+const (
+	PairingRequestPending   PairingRequestStatus = "pending"
+	PairingRequestApproved  PairingRequestStatus = "approved"
+	PairingRequestRejected  PairingRequestStatus = "rejected"
+	PairingRequestExpired   PairingRequestStatus = "expired"
+	PairingRequestCancelled PairingRequestStatus = "cancelled"
+)
+
+// PairingNotice is a change to a conversation's pairing that the Kakao user
+// has not been told about yet. Request outcomes share their status values.
+type PairingNotice string
+
+const (
+	PairingNoticeApproved     PairingNotice = "approved"
+	PairingNoticeRejected     PairingNotice = "rejected"
+	PairingNoticeExpired      PairingNotice = "expired"
+	PairingNoticeDisconnected PairingNotice = "disconnected"
+)
+
 type InboundMessageStatus string
 
 const (
@@ -26,6 +48,13 @@ const (
 	OutboundStatusFailed  OutboundMessageStatus = "failed"
 )
 
+type OutboundDeliveryType string
+
+const (
+	OutboundDeliveryCallback OutboundDeliveryType = "callback"
+	OutboundDeliveryEventAPI OutboundDeliveryType = "event_api"
+)
+
 type SessionStatus string
 
 const (
@@ -34,3 +63,11 @@ const (
 	SessionStatusExpired        SessionStatus = "expired"
 	SessionStatusDisconnected   SessionStatus = "disconnected"
 )
+
+type AttachmentStatus string
+
+const (
+	AttachmentStatusPending AttachmentStatus = "pending"
+	AttachmentStatusStored  AttachmentStatus = "stored"
+	AttachmentStatusFailed  AttachmentStatus = "failed"
+)
@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// MessageOverride replaces one catalog message for an account and locale.
+type MessageOverride struct {
+	AccountID string    `db:"account_id" json:"-"`
+	Locale    string    `db:"locale" json:"locale"`
+	Key       string    `db:"key" json:"key"`
+	Text      string    `db:"text" json:"text"`
+	UpdatedAt time.Time `db:"updated_at" json:"updatedAt"`
+}
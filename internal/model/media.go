@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// Media is a file uploaded by an OpenClaw agent and served at a public,
+// unguessable URL so it can be used in Kakao templates (e.g. simpleImage).
+type Media struct {
+	ID          string    `db:"id" json:"id"`
+	AccountID   string    `db:"account_id" json:"accountId"`
+	PublicToken string    `db:"public_token" json:"-"`
+	StorageKey  string    `db:"storage_key" json:"-"`
+	ContentType string    `db:"content_type" json:"contentType"`
+	SizeBytes   int64     `db:"size_bytes" json:"sizeBytes"`
+	Filename    *string   `db:"filename" json:"filename,omitempty"`
+	ExpiresAt   time.Time `db:"expires_at" json:"expiresAt"`
+	CreatedAt   time.Time `db:"created_at" json:"createdAt"`
+}
+
+type CreateMediaParams struct {
+	AccountID   string
+	PublicToken string
+	StorageKey  string
+	ContentType string
+	SizeBytes   int64
+	Filename    *string
+	ExpiresAt   time.Time
+}
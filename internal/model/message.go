@@ -3,6 +3,8 @@ package model
 import (
 	"encoding/json"
 	"time"
+
+	"github.com/lib/pq"
 )
 
 type InboundMessage struct {
@@ -15,23 +17,102 @@ type InboundMessage struct {
 	CallbackExpiresAt *time.Time           `db:"callback_expires_at" json:"-"`
 	Status            InboundMessageStatus `db:"status" json:"status"`
 	SourceEventID     *string              `db:"source_event_id" json:"sourceEventId,omitempty"`
+	ThreadID          *string              `db:"thread_id" json:"threadId,omitempty"`
 	CreatedAt         time.Time            `db:"created_at" json:"createdAt"`
 	DeliveredAt       *time.Time           `db:"delivered_at" json:"deliveredAt,omitempty"`
 	AckedAt           *time.Time           `db:"acked_at" json:"ackedAt,omitempty"`
+	LeaseOwner        *string              `db:"lease_owner" json:"-"`
+	LeaseExpiresAt    *time.Time           `db:"lease_expires_at" json:"-"`
+	DeliveryAttempts  int                  `db:"delivery_attempts" json:"deliveryAttempts"`
+	// Held messages wait behind an earlier message of the conversation
+	// (ordered delivery) and are not sent to streams yet.
+	Held bool `db:"held" json:"held"`
+	// QueuePosition is how many messages of the conversation were ahead
+	// when this one arrived. Nil unless the account orders delivery.
+	QueuePosition *int       `db:"queue_position" json:"queuePosition,omitempty"`
+	ReleasedAt    *time.Time `db:"released_at" json:"-"`
+	// CoalesceDueAt is set while the message waits for follow-up
+	// utterances to merge with; it is not delivered until then.
+	CoalesceDueAt *time.Time `db:"coalesce_due_at" json:"-"`
+	// CoalescedInto is the message this one was merged into.
+	CoalescedInto *string `db:"coalesced_into" json:"coalescedInto,omitempty"`
+	// CoalescedIDs lists, oldest first, the messages merged into this one,
+	// itself included. CoalescedMessage is their merged normalized message.
+	CoalescedIDs     pq.StringArray   `db:"coalesced_ids" json:"coalescedIds,omitempty"`
+	CoalescedMessage *json.RawMessage `db:"coalesced_message" json:"-"`
+	// RoutingRuleID is the routing rule that sent the message to this
+	// account instead of the paired one.
+	RoutingRuleID *string `db:"routing_rule_id" json:"routingRuleId,omitempty"`
+
+	// Attachments is filled in by the caller before building SSE events.
+	Attachments []AttachmentLink `db:"-" json:"attachments,omitempty"`
 }
 
 // ToSSEEventData returns JSON data for SSE message events
 func (m *InboundMessage) ToSSEEventData() json.RawMessage {
-	data, _ := json.Marshal(map[string]any{
+	fields := map[string]any{
 		"id":              m.ID,
 		"conversationKey": m.ConversationKey,
+		"threadId":        m.ThreadID,
 		"kakaoPayload":    m.KakaoPayload,
 		"normalized":      m.NormalizedMessage,
 		"createdAt":       m.CreatedAt,
-	})
+		"attachments":     m.attachmentLinks(),
+	}
+	if m.CoalescedMessage != nil {
+		fields["normalized"] = m.CoalescedMessage
+		fields["messageIds"] = m.SourceMessageIDs()
+	}
+	if m.QueuePosition != nil {
+		fields["queuePosition"] = *m.QueuePosition
+	}
+	if cmd := m.Command(); cmd != nil {
+		fields["command"] = cmd
+	}
+	data, _ := json.Marshal(fields)
 	return data
 }
 
+// Command returns the custom command recorded in the normalized message, if
+// the utterance invoked one.
+func (m *InboundMessage) Command() *NormalizedCommand {
+	if m.NormalizedMessage == nil {
+		return nil
+	}
+	var normalized struct {
+		Command *NormalizedCommand `json:"command"`
+	}
+	if err := json.Unmarshal(*m.NormalizedMessage, &normalized); err != nil {
+		return nil
+	}
+	return normalized.Command
+}
+
+// SSEEventType is "command" for custom command invocations and "message"
+// otherwise.
+func (m *InboundMessage) SSEEventType() string {
+	if m.Command() != nil {
+		return "command"
+	}
+	return "message"
+}
+
+// SourceMessageIDs returns the IDs of the utterances the message carries:
+// all merged messages when it was coalesced, otherwise its own ID.
+func (m *InboundMessage) SourceMessageIDs() []string {
+	if len(m.CoalescedIDs) > 0 {
+		return m.CoalescedIDs
+	}
+	return []string{m.ID}
+}
+
+func (m *InboundMessage) attachmentLinks() []AttachmentLink {
+	if m.Attachments == nil {
+		return []AttachmentLink{}
+	}
+	return m.Attachments
+}
+
 type CreateInboundMessageParams struct {
 	AccountID         string
 	ConversationKey   string
@@ -40,6 +121,31 @@ type CreateInboundMessageParams struct {
 	CallbackURL       *string
 	CallbackExpiresAt *time.Time
 	SourceEventID     *string
+	ThreadID          *string
+	// HoldTimeout, when set, orders the message behind the conversation's
+	// unsettled messages. Messages in flight longer than this no longer
+	// hold it back.
+	HoldTimeout time.Duration
+	// CoalesceWindow, when set, keeps the message back for follow-up
+	// utterances to merge with.
+	CoalesceWindow time.Duration
+	// CoalesceDeadlineMargin delivers a coalescing group at least this long
+	// before the earliest callback in it expires.
+	CoalesceDeadlineMargin time.Duration
+	RoutingRuleID          *string
+}
+
+// CompleteCoalescingParams merges a conversation's waiting utterances into
+// PrimaryID, the one whose callback is used for the reply.
+type CompleteCoalescingParams struct {
+	AccountID       string
+	ConversationKey string
+	// IDs are all waiting messages, oldest first, PrimaryID included.
+	IDs       []string
+	PrimaryID string
+	// Merged is the combined normalized message; nil when IDs holds only
+	// the primary.
+	Merged json.RawMessage
 }
 
 type OutboundMessage struct {
@@ -51,6 +157,10 @@ type OutboundMessage struct {
 	ResponsePayload  json.RawMessage       `db:"response_payload" json:"responsePayload"`
 	Status           OutboundMessageStatus `db:"status" json:"status"`
 	ErrorMessage     *string               `db:"error_message" json:"errorMessage,omitempty"`
+	DeliveryType     OutboundDeliveryType  `db:"delivery_type" json:"deliveryType"`
+	EventTaskID      *string               `db:"event_task_id" json:"eventTaskId,omitempty"`
+	EventStatus      *string               `db:"event_status" json:"eventStatus,omitempty"`
+	ThreadID         *string               `db:"thread_id" json:"threadId,omitempty"`
 	CreatedAt        time.Time             `db:"created_at" json:"createdAt"`
 	SentAt           *time.Time            `db:"sent_at" json:"sentAt,omitempty"`
 }
@@ -61,4 +171,19 @@ type CreateOutboundMessageParams struct {
 	ConversationKey  string
 	KakaoTarget      json.RawMessage
 	ResponsePayload  json.RawMessage
+	DeliveryType     OutboundDeliveryType
+	ThreadID         *string
+}
+
+// MessageCursor is a position in a conversation's message history. Inbound
+// and outbound messages share one order: creation time, then ID.
+type MessageCursor struct {
+	CreatedAt time.Time
+	ID        string
+}
+
+// ConversationRef identifies one conversation of an account.
+type ConversationRef struct {
+	AccountID       string `db:"account_id"`
+	ConversationKey string `db:"conversation_key"`
 }
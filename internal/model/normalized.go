@@ -0,0 +1,70 @@
+package model
+
+// NormalizedMessageVersion is bumped whenever the normalized schema changes
+// in a way that OpenClaw agents need to detect.
+const NormalizedMessageVersion = 1
+
+// Attachment types found in Kakao skill payloads
+const (
+	AttachmentTypeImage       = "image"
+	AttachmentTypeSecureImage = "secure_image"
+	AttachmentTypeVideo       = "video"
+	AttachmentTypeAudio       = "audio"
+	AttachmentTypeFile        = "file"
+)
+
+// NormalizedMessage is the channel-independent view of a Kakao skill request
+// that is stored alongside the raw payload and forwarded to OpenClaw.
+type NormalizedMessage struct {
+	Version      int                        `json:"version"`
+	UserID       string                     `json:"userId"`
+	ChannelID    string                     `json:"channelId"`
+	Text         string                     `json:"text"`
+	Intent       *NormalizedRef             `json:"intent,omitempty"`
+	Block        *NormalizedRef             `json:"block,omitempty"`
+	Action       *NormalizedRef             `json:"action,omitempty"`
+	Params       map[string]string          `json:"params,omitempty"`
+	DetailParams map[string]NormalizedParam `json:"detailParams,omitempty"`
+	ClientExtra  map[string]any             `json:"clientExtra,omitempty"`
+	Attachments  []NormalizedAttachment     `json:"attachments,omitempty"`
+	Command      *NormalizedCommand         `json:"command,omitempty"`
+	User         NormalizedUser             `json:"user"`
+	Timezone     string                     `json:"timezone,omitempty"`
+	Lang         string                     `json:"lang,omitempty"`
+}
+
+type NormalizedRef struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type NormalizedParam struct {
+	Origin    string `json:"origin,omitempty"`
+	Value     any    `json:"value"`
+	GroupName string `json:"groupName,omitempty"`
+}
+
+type NormalizedAttachment struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Source    string `json:"source,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+// NormalizedCommand is set when the utterance invoked a custom command of
+// the paired account.
+type NormalizedCommand struct {
+	// Name is the canonical command name, even when invoked via an alias.
+	Name    string   `json:"name"`
+	Alias   string   `json:"alias,omitempty"`
+	Args    []string `json:"args"`
+	RawArgs string   `json:"rawArgs"`
+}
+
+type NormalizedUser struct {
+	ID                string `json:"id"`
+	Type              string `json:"type,omitempty"`
+	PlusfriendUserKey string `json:"plusfriendUserKey,omitempty"`
+	AppUserID         string `json:"appUserId,omitempty"`
+	IsFriend          *bool  `json:"isFriend,omitempty"`
+}
@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// PairingCode is an account-scoped invite: redeeming it with /pair attaches
+// the Kakao conversation to the existing account instead of creating one.
+type PairingCode struct {
+	ID         string     `db:"id" json:"id"`
+	AccountID  string     `db:"account_id" json:"-"`
+	Code       string     `db:"code" json:"code"`
+	MaxUses    int        `db:"max_uses" json:"maxUses"`
+	UseCount   int        `db:"use_count" json:"useCount"`
+	ExpiresAt  time.Time  `db:"expires_at" json:"expiresAt"`
+	RevokedAt  *time.Time `db:"revoked_at" json:"revokedAt,omitempty"`
+	LastUsedAt *time.Time `db:"last_used_at" json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `db:"created_at" json:"createdAt"`
+}
+
+type CreatePairingCodeParams struct {
+	AccountID string
+	Code      string
+	MaxUses   int
+	ExpiresAt time.Time
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// PairingRequest is a Kakao user's request to join an account that requires
+// approval. The conversation stays pending until the account decides.
+type PairingRequest struct {
+	ID              string               `db:"id" json:"id"`
+	AccountID       string               `db:"account_id" json:"-"`
+	ConversationKey string               `db:"conversation_key" json:"conversationKey"`
+	PairingCodeID   *string              `db:"pairing_code_id" json:"pairingCodeId,omitempty"`
+	Profile         json.RawMessage      `db:"profile" json:"profile"`
+	Status          PairingRequestStatus `db:"status" json:"status"`
+	ExpiresAt       time.Time            `db:"expires_at" json:"expiresAt"`
+	DecidedAt       *time.Time           `db:"decided_at" json:"decidedAt,omitempty"`
+	CreatedAt       time.Time            `db:"created_at" json:"createdAt"`
+}
+
+type CreatePairingRequestParams struct {
+	AccountID       string
+	ConversationKey string
+	PairingCodeID   *string
+	Profile         json.RawMessage
+	ExpiresAt       time.Time
+}
+
+// PairingProfile is what the relay knows about the Kakao user asking to
+// pair, taken from the webhook that carried the /pair command.
+type PairingProfile struct {
+	KakaoUserID string `json:"kakaoUserId"`
+	ChannelID   string `json:"channelId"`
+	BotUserKey  string `json:"botUserKey,omitempty"`
+	AppUserID   string `json:"appUserId,omitempty"`
+	IsFriend    *bool  `json:"isFriend,omitempty"`
+	Lang        string `json:"lang,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+}
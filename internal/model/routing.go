@@ -0,0 +1,73 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// RoutingRule sends the messages of paired conversations to another
+// account. Every condition that is set must match; rules are evaluated by
+// ascending priority and the first match wins.
+type RoutingRule struct {
+	ID       string `db:"id" json:"id"`
+	Name     string `db:"name" json:"name"`
+	Priority int    `db:"priority" json:"priority"`
+	Enabled  bool   `db:"enabled" json:"enabled"`
+	// SourceAccountID limits the rule to conversations paired with this
+	// account. Nil applies it to every conversation.
+	SourceAccountID  *string        `db:"source_account_id" json:"sourceAccountId"`
+	ChannelID        *string        `db:"channel_id" json:"channelId"`
+	BlockName        *string        `db:"block_name" json:"blockName"`
+	IntentName       *string        `db:"intent_name" json:"intentName"`
+	UtterancePattern *string        `db:"utterance_pattern" json:"utterancePattern"`
+	Targets          RoutingTargets `db:"targets" json:"targets"`
+	CreatedAt        time.Time      `db:"created_at" json:"createdAt"`
+	UpdatedAt        time.Time      `db:"updated_at" json:"updatedAt"`
+}
+
+// RoutingTarget is one destination of a rule. Weight is the percentage of
+// conversations it receives; a nil AccountID keeps the paired account.
+type RoutingTarget struct {
+	AccountID *string `json:"accountId"`
+	Weight    int     `json:"weight"`
+}
+
+// RoutingTargets is stored as a jsonb array.
+type RoutingTargets []RoutingTarget
+
+func (t RoutingTargets) Value() (driver.Value, error) {
+	if t == nil {
+		t = RoutingTargets{}
+	}
+	return json.Marshal(t)
+}
+
+func (t *RoutingTargets) Scan(src any) error {
+	var data []byte
+	switch v := src.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	case nil:
+		*t = nil
+		return nil
+	default:
+		return errors.New("routing targets: unsupported type")
+	}
+	return json.Unmarshal(data, t)
+}
+
+type RoutingRuleParams struct {
+	Name             string
+	Priority         int
+	Enabled          bool
+	SourceAccountID  *string
+	ChannelID        *string
+	BlockName        *string
+	IntentName       *string
+	UtterancePattern *string
+	Targets          RoutingTargets
+}
@@ -17,6 +17,15 @@ type AccountRepository interface {
 	Create(ctx context.Context, params model.CreateAccountParams) (*model.Account, error)
 	Update(ctx context.Context, id string, params model.UpdateAccountParams) (*model.Account, error)
 	UpdateToken(ctx context.Context, id, tokenHash string) (*model.Account, error)
+	// RevokeToken clears the relay token so it no longer authenticates.
+	RevokeToken(ctx context.Context, id string) error
+	// UpdateLocale sets the account's locale; nil clears it.
+	UpdateLocale(ctx context.Context, id string, locale *string) (*model.Account, error)
+	UpdatePairingApproval(ctx context.Context, id string, required bool) (*model.Account, error)
+	// UpdateOrderedDelivery turns per-conversation ordered delivery on or off.
+	UpdateOrderedDelivery(ctx context.Context, id string, ordered bool) (*model.Account, error)
+	// UpdateCoalesceWindow sets the coalescing window; zero turns it off.
+	UpdateCoalesceWindow(ctx context.Context, id string, windowMs int) (*model.Account, error)
 	Delete(ctx context.Context, id string) error
 	Count(ctx context.Context) (int, error)
 	// WithTx returns a new repository that uses the given transaction
@@ -119,3 +128,61 @@ func (r *accountRepo) UpdateToken(ctx context.Context, id, tokenHash string) (*m
 	`, id, tokenHash, time.Now())
 	return HandleNotFound(&account, err)
 }
+
+func (r *accountRepo) RevokeToken(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE accounts SET
+			relay_token_hash = NULL,
+			updated_at = $2
+		WHERE id = $1
+	`, id, time.Now())
+	return err
+}
+
+func (r *accountRepo) UpdateLocale(ctx context.Context, id string, locale *string) (*model.Account, error) {
+	var account model.Account
+	err := r.db.GetContext(ctx, &account, `
+		UPDATE accounts SET
+			locale = $2,
+			updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	`, id, locale, time.Now())
+	return HandleNotFound(&account, err)
+}
+
+func (r *accountRepo) UpdatePairingApproval(ctx context.Context, id string, required bool) (*model.Account, error) {
+	var account model.Account
+	err := r.db.GetContext(ctx, &account, `
+		UPDATE accounts SET
+			require_pairing_approval = $2,
+			updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	`, id, required, time.Now())
+	return HandleNotFound(&account, err)
+}
+
+func (r *accountRepo) UpdateOrderedDelivery(ctx context.Context, id string, ordered bool) (*model.Account, error) {
+	var account model.Account
+	err := r.db.GetContext(ctx, &account, `
+		UPDATE accounts SET
+			ordered_delivery = $2,
+			updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	`, id, ordered, time.Now())
+	return HandleNotFound(&account, err)
+}
+
+func (r *accountRepo) UpdateCoalesceWindow(ctx context.Context, id string, windowMs int) (*model.Account, error) {
+	var account model.Account
+	err := r.db.GetContext(ctx, &account, `
+		UPDATE accounts SET
+			coalesce_window_ms = $2,
+			updated_at = $3
+		WHERE id = $1
+		RETURNING *
+	`, id, windowMs, time.Now())
+	return HandleNotFound(&account, err)
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type AttachmentRepository interface {
+	FindByID(ctx context.Context, id string) (*model.Attachment, error)
+	FindByInboundMessageIDs(ctx context.Context, inboundMessageIDs []string) ([]model.Attachment, error)
+	FindCreatedBefore(ctx context.Context, before time.Time, limit int) ([]model.Attachment, error)
+	Create(ctx context.Context, params model.CreateAttachmentParams) (*model.Attachment, error)
+	MarkStored(ctx context.Context, id, storageKey, contentType string, sizeBytes int64) error
+	MarkFailed(ctx context.Context, id string, errorMsg string) error
+	Delete(ctx context.Context, id string) error
+}
+
+type attachmentRepo struct {
+	db *sqlx.DB
+}
+
+func NewAttachmentRepository(db *sqlx.DB) AttachmentRepository {
+	return &attachmentRepo{db: db}
+}
+
+func (r *attachmentRepo) FindByID(ctx context.Context, id string) (*model.Attachment, error) {
+	var att model.Attachment
+	err := r.db.GetContext(ctx, &att, `SELECT * FROM attachments WHERE id = $1`, id)
+	return HandleNotFound(&att, err)
+}
+
+func (r *attachmentRepo) FindByInboundMessageIDs(ctx context.Context, inboundMessageIDs []string) ([]model.Attachment, error) {
+	var atts []model.Attachment
+	if len(inboundMessageIDs) == 0 {
+		return atts, nil
+	}
+	err := r.db.SelectContext(ctx, &atts, `
+		SELECT * FROM attachments
+		WHERE inbound_message_id = ANY($1)
+		ORDER BY created_at ASC
+	`, pq.Array(inboundMessageIDs))
+	return atts, err
+}
+
+func (r *attachmentRepo) FindCreatedBefore(ctx context.Context, before time.Time, limit int) ([]model.Attachment, error) {
+	var atts []model.Attachment
+	err := r.db.SelectContext(ctx, &atts, `
+		SELECT * FROM attachments
+		WHERE created_at < $1
+		ORDER BY created_at ASC
+		LIMIT $2
+	`, before, limit)
+	return atts, err
+}
+
+func (r *attachmentRepo) Create(ctx context.Context, params model.CreateAttachmentParams) (*model.Attachment, error) {
+	var att model.Attachment
+	err := r.db.GetContext(ctx, &att, `
+		INSERT INTO attachments (inbound_message_id, account_id, type, source_url)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`, params.InboundMessageID, params.AccountID, params.Type, params.SourceURL)
+	if err != nil {
+		return nil, err
+	}
+	return &att, nil
+}
+
+func (r *attachmentRepo) MarkStored(ctx context.Context, id, storageKey, contentType string, sizeBytes int64) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE attachments SET
+			status = 'stored',
+			storage_key = $2,
+			content_type = $3,
+			size_bytes = $4,
+			stored_at = $5
+		WHERE id = $1
+	`, id, storageKey, contentType, sizeBytes, time.Now())
+	return err
+}
+
+func (r *attachmentRepo) MarkFailed(ctx context.Context, id string, errorMsg string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE attachments SET
+			status = 'failed',
+			error_message = $2
+		WHERE id = $1
+	`, id, errorMsg)
+	return err
+}
+
+func (r *attachmentRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = $1`, id)
+	return err
+}
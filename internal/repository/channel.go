@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type ChannelRepository interface {
+	FindAll(ctx context.Context) ([]model.Channel, error)
+	FindByID(ctx context.Context, id string) (*model.Channel, error)
+	// Upsert creates the channel or replaces its settings.
+	Upsert(ctx context.Context, params model.UpsertChannelParams) (*model.Channel, error)
+	// Delete reports whether the channel existed.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type channelRepo struct {
+	db *sqlx.DB
+}
+
+func NewChannelRepository(db *sqlx.DB) ChannelRepository {
+	return &channelRepo{db: db}
+}
+
+func (r *channelRepo) FindAll(ctx context.Context) ([]model.Channel, error) {
+	var channels []model.Channel
+	err := r.db.SelectContext(ctx, &channels, `SELECT * FROM channels ORDER BY id ASC`)
+	return channels, err
+}
+
+func (r *channelRepo) FindByID(ctx context.Context, id string) (*model.Channel, error) {
+	var channel model.Channel
+	err := r.db.GetContext(ctx, &channel, `SELECT * FROM channels WHERE id = $1`, id)
+	return HandleNotFound(&channel, err)
+}
+
+func (r *channelRepo) Upsert(ctx context.Context, params model.UpsertChannelParams) (*model.Channel, error) {
+	var channel model.Channel
+	err := r.db.GetContext(ctx, &channel, `
+		INSERT INTO channels
+			(id, display_name, signature_secret, signature_secret_encrypted,
+			 callback_ttl_seconds, enabled, default_locale)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (id) DO UPDATE SET
+			display_name = EXCLUDED.display_name,
+			signature_secret = CASE WHEN $8 THEN channels.signature_secret ELSE EXCLUDED.signature_secret END,
+			signature_secret_encrypted = CASE WHEN $8 THEN channels.signature_secret_encrypted ELSE EXCLUDED.signature_secret_encrypted END,
+			callback_ttl_seconds = EXCLUDED.callback_ttl_seconds,
+			enabled = EXCLUDED.enabled,
+			default_locale = EXCLUDED.default_locale,
+			updated_at = NOW()
+		RETURNING *
+	`, params.ID, params.DisplayName, params.SignatureSecret, params.SignatureSecretEncrypted,
+		params.CallbackTTLSeconds, params.Enabled, params.DefaultLocale, params.KeepSignatureSecret)
+	if err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+func (r *channelRepo) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM channels WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
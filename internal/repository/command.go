@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type CommandRepository interface {
+	FindByAccountID(ctx context.Context, accountID string) ([]model.AccountCommand, error)
+	// ReplaceForAccount atomically replaces the account's command set.
+	ReplaceForAccount(ctx context.Context, accountID string, params []model.CreateAccountCommandParams) ([]model.AccountCommand, error)
+}
+
+type commandRepo struct {
+	db *sqlx.DB
+}
+
+func NewCommandRepository(db *sqlx.DB) CommandRepository {
+	return &commandRepo{db: db}
+}
+
+func (r *commandRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.AccountCommand, error) {
+	var commands []model.AccountCommand
+	err := r.db.SelectContext(ctx, &commands, `
+		SELECT * FROM account_commands
+		WHERE account_id = $1
+		ORDER BY name ASC
+	`, accountID)
+	return commands, err
+}
+
+func (r *commandRepo) ReplaceForAccount(ctx context.Context, accountID string, params []model.CreateAccountCommandParams) ([]model.AccountCommand, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM account_commands WHERE account_id = $1`, accountID); err != nil {
+		return nil, err
+	}
+
+	commands := make([]model.AccountCommand, 0, len(params))
+	for _, p := range params {
+		var cmd model.AccountCommand
+		err := tx.GetContext(ctx, &cmd, `
+			INSERT INTO account_commands (account_id, name, aliases, usage, description)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *
+		`, accountID, p.Name, pq.Array(p.Aliases), p.Usage, p.Description)
+		if err != nil {
+			return nil, err
+		}
+		commands = append(commands, cmd)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return commands, nil
+}
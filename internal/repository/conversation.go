@@ -10,24 +10,48 @@ import (
 )
 
 type ConversationRepository interface {
+	FindByID(ctx context.Context, id string) (*model.ConversationMapping, error)
 	FindByKey(ctx context.Context, key string) (*model.ConversationMapping, error)
 	FindByAccountID(ctx context.Context, accountID string) ([]model.ConversationMapping, error)
 	FindPairedByAccountID(ctx context.Context, accountID string) ([]model.ConversationMapping, error)
 	Upsert(ctx context.Context, params model.UpsertConversationParams) (*model.ConversationMapping, error)
 	UpdateState(ctx context.Context, key string, state model.PairingState, accountID *string) error
 	UpdateCallback(ctx context.Context, key string, callbackURL string, expiresAt time.Time) error
+	// SetLocaleIfUnset records a detected locale unless one is already set.
+	SetLocaleIfUnset(ctx context.Context, key, locale string) error
+	// ClearPairingNotice marks the pending pairing outcome as delivered.
+	ClearPairingNotice(ctx context.Context, key string) error
+	// UnpairByAccountID unpairs every paired conversation of the account,
+	// leaving notice for each user, and returns them as they were.
+	UnpairByAccountID(ctx context.Context, accountID string, notice model.PairingNotice) ([]model.ConversationMapping, error)
+	// StartThread gives the conversation a new thread ID and returns it.
+	StartThread(ctx context.Context, key string) (string, error)
 	Delete(ctx context.Context, id string) error
 	CountByState(ctx context.Context, state model.PairingState) (int, error)
+	// WithTx returns a new repository that uses the given transaction
+	WithTx(tx *sqlx.Tx) ConversationRepository
 }
 
 type conversationRepo struct {
-	db *sqlx.DB
+	db sqlxDB
 }
 
 func NewConversationRepository(db *sqlx.DB) ConversationRepository {
 	return &conversationRepo{db: db}
 }
 
+func (r *conversationRepo) WithTx(tx *sqlx.Tx) ConversationRepository {
+	return &conversationRepo{db: tx}
+}
+
+func (r *conversationRepo) FindByID(ctx context.Context, id string) (*model.ConversationMapping, error) {
+	var conv model.ConversationMapping
+	err := r.db.GetContext(ctx, &conv, `
+		SELECT * FROM conversation_mappings WHERE id = $1
+	`, id)
+	return HandleNotFound(&conv, err)
+}
+
 func (r *conversationRepo) FindByKey(ctx context.Context, key string) (*model.ConversationMapping, error) {
 	var conv model.ConversationMapping
 	err := r.db.GetContext(ctx, &conv, `
@@ -102,6 +126,51 @@ func (r *conversationRepo) UpdateCallback(ctx context.Context, key string, callb
 	return err
 }
 
+func (r *conversationRepo) SetLocaleIfUnset(ctx context.Context, key, locale string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE conversation_mappings SET locale = $2
+		WHERE conversation_key = $1 AND locale IS NULL
+	`, key, locale)
+	return err
+}
+
+func (r *conversationRepo) ClearPairingNotice(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE conversation_mappings SET pairing_notice = NULL
+		WHERE conversation_key = $1
+	`, key)
+	return err
+}
+
+func (r *conversationRepo) UnpairByAccountID(ctx context.Context, accountID string, notice model.PairingNotice) ([]model.ConversationMapping, error) {
+	var convs []model.ConversationMapping
+	err := r.db.SelectContext(ctx, &convs, `
+		WITH paired AS (
+			SELECT * FROM conversation_mappings
+			WHERE account_id = $1 AND state = 'paired'
+			FOR UPDATE
+		)
+		UPDATE conversation_mappings c SET
+			state = 'unpaired',
+			account_id = NULL,
+			pairing_notice = $2
+		FROM paired
+		WHERE c.id = paired.id
+		RETURNING paired.*
+	`, accountID, notice)
+	return convs, err
+}
+
+func (r *conversationRepo) StartThread(ctx context.Context, key string) (string, error) {
+	var threadID string
+	err := r.db.GetContext(ctx, &threadID, `
+		UPDATE conversation_mappings SET thread_id = gen_random_uuid()
+		WHERE conversation_key = $1
+		RETURNING thread_id
+	`, key)
+	return threadID, err
+}
+
 func (r *conversationRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM conversation_mappings WHERE id = $1`, id)
 	return err
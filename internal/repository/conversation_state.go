@@ -0,0 +1,159 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+// ConversationStateRepository stores the per-conversation key-value state
+// of accounts. Keys past their expires_at read as absent until
+// DeleteExpired removes them.
+type ConversationStateRepository interface {
+	FindByConversation(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error)
+	Find(ctx context.Context, accountID, conversationKey, key string) (*model.ConversationState, error)
+	// Put writes a key and bumps its version. It returns nil when
+	// params.IfVersion does not match or a new key would exceed
+	// params.MaxKeys.
+	Put(ctx context.Context, params model.PutConversationStateParams) (*model.ConversationState, error)
+	// Delete reports whether a key was deleted; with ifVersion set, only if
+	// it is at that version.
+	Delete(ctx context.Context, accountID, conversationKey, key string, ifVersion *int64) (bool, error)
+	DeleteByConversation(ctx context.Context, accountID, conversationKey string) (int64, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+	// WithTx returns a new repository that uses the given transaction
+	WithTx(tx *sqlx.Tx) ConversationStateRepository
+}
+
+type conversationStateRepo struct {
+	db sqlxDB
+}
+
+func NewConversationStateRepository(db *sqlx.DB) ConversationStateRepository {
+	return &conversationStateRepo{db: db}
+}
+
+func (r *conversationStateRepo) WithTx(tx *sqlx.Tx) ConversationStateRepository {
+	return &conversationStateRepo{db: tx}
+}
+
+func (r *conversationStateRepo) FindByConversation(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error) {
+	var states []model.ConversationState
+	err := r.db.SelectContext(ctx, &states, `
+		SELECT * FROM conversation_state
+		WHERE account_id = $1 AND conversation_key = $2
+			AND (expires_at IS NULL OR expires_at > NOW())
+		ORDER BY key
+	`, accountID, conversationKey)
+	return states, err
+}
+
+func (r *conversationStateRepo) Find(ctx context.Context, accountID, conversationKey, key string) (*model.ConversationState, error) {
+	var state model.ConversationState
+	err := r.db.GetContext(ctx, &state, `
+		SELECT * FROM conversation_state
+		WHERE account_id = $1 AND conversation_key = $2 AND key = $3
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`, accountID, conversationKey, key)
+	return HandleNotFound(&state, err)
+}
+
+// stateInsertWithinLimit selects the row to insert unless it would add a
+// live key beyond the $6 the conversation may have. Concurrent writes of
+// new keys can still overshoot the limit slightly.
+const stateInsertWithinLimit = `
+	SELECT $1::uuid, $2::text, $3::text, $4::jsonb, $5::timestamptz
+	WHERE $6::int <= 0
+		OR EXISTS (
+			SELECT 1 FROM conversation_state
+			WHERE account_id = $1::uuid AND conversation_key = $2::text AND key = $3::text
+				AND (expires_at IS NULL OR expires_at > NOW())
+		)
+		OR (
+			SELECT COUNT(*) FROM conversation_state
+			WHERE account_id = $1::uuid AND conversation_key = $2::text
+				AND (expires_at IS NULL OR expires_at > NOW())
+		) < $6::int`
+
+func (r *conversationStateRepo) Put(ctx context.Context, params model.PutConversationStateParams) (*model.ConversationState, error) {
+	var state model.ConversationState
+	args := []any{params.AccountID, params.ConversationKey, params.Key, params.Value, params.ExpiresAt}
+
+	var err error
+	switch {
+	case params.IfVersion == nil:
+		err = r.db.GetContext(ctx, &state, `
+			INSERT INTO conversation_state (account_id, conversation_key, key, value, expires_at)
+			`+stateInsertWithinLimit+`
+			ON CONFLICT (account_id, conversation_key, key) DO UPDATE SET
+				value = EXCLUDED.value,
+				expires_at = EXCLUDED.expires_at,
+				version = conversation_state.version + 1,
+				updated_at = NOW()
+			RETURNING *
+		`, append(args, params.MaxKeys)...)
+	case *params.IfVersion == 0:
+		// An expired key counts as absent; it keeps counting versions so a
+		// stale writer cannot match it again.
+		err = r.db.GetContext(ctx, &state, `
+			INSERT INTO conversation_state (account_id, conversation_key, key, value, expires_at)
+			`+stateInsertWithinLimit+`
+			ON CONFLICT (account_id, conversation_key, key) DO UPDATE SET
+				value = EXCLUDED.value,
+				expires_at = EXCLUDED.expires_at,
+				version = conversation_state.version + 1,
+				updated_at = NOW()
+			WHERE conversation_state.expires_at <= NOW()
+			RETURNING *
+		`, append(args, params.MaxKeys)...)
+	default:
+		err = r.db.GetContext(ctx, &state, `
+			UPDATE conversation_state SET
+				value = $4,
+				expires_at = $5,
+				version = version + 1,
+				updated_at = NOW()
+			WHERE account_id = $1 AND conversation_key = $2 AND key = $3
+				AND version = $6
+				AND (expires_at IS NULL OR expires_at > NOW())
+			RETURNING *
+		`, append(args, *params.IfVersion)...)
+	}
+	return HandleNotFound(&state, err)
+}
+
+func (r *conversationStateRepo) Delete(ctx context.Context, accountID, conversationKey, key string, ifVersion *int64) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM conversation_state
+		WHERE account_id = $1 AND conversation_key = $2 AND key = $3
+			AND ($4::bigint IS NULL OR version = $4)
+			AND (expires_at IS NULL OR expires_at > NOW())
+	`, accountID, conversationKey, key, ifVersion)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *conversationStateRepo) DeleteByConversation(ctx context.Context, accountID, conversationKey string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM conversation_state WHERE account_id = $1 AND conversation_key = $2
+	`, accountID, conversationKey)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *conversationStateRepo) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM conversation_state WHERE expires_at <= NOW()
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
@@ -18,8 +19,23 @@ type DashboardOverview struct {
 	OutboundFailed       int `db:"outbound_failed"`
 }
 
+// DashboardChannelStats is the traffic of one Kakao bot, keyed by the
+// channel part of conversation keys.
+type DashboardChannelStats struct {
+	ChannelID          string     `db:"channel_id"`
+	Conversations      int        `db:"conversations"`
+	ConversationPaired int        `db:"conversation_paired"`
+	InboundTotal       int        `db:"inbound_total"`
+	Inbound24h         int        `db:"inbound_24h"`
+	OutboundTotal      int        `db:"outbound_total"`
+	OutboundFailed     int        `db:"outbound_failed"`
+	LastSeenAt         *time.Time `db:"last_seen_at"`
+}
+
 type DashboardRepository interface {
 	GetOverviewStats(ctx context.Context) (*DashboardOverview, error)
+	// GetChannelStats returns stats for every channel that has conversations.
+	GetChannelStats(ctx context.Context) ([]DashboardChannelStats, error)
 }
 
 type dashboardRepo struct {
@@ -61,3 +77,46 @@ func (r *dashboardRepo) GetOverviewStats(ctx context.Context) (*DashboardOvervie
 	}
 	return &stats, nil
 }
+
+func (r *dashboardRepo) GetChannelStats(ctx context.Context) ([]DashboardChannelStats, error) {
+	var stats []DashboardChannelStats
+	err := r.db.SelectContext(ctx, &stats, `
+		WITH
+			conv AS (
+				SELECT kakao_channel_id AS channel_id,
+					COUNT(*) AS conversations,
+					COUNT(*) FILTER (WHERE state = 'paired') AS paired,
+					MAX(last_seen_at) AS last_seen_at
+				FROM conversation_mappings
+				GROUP BY kakao_channel_id
+			),
+			inbound AS (
+				SELECT split_part(conversation_key, ':', 1) AS channel_id,
+					COUNT(*) AS total,
+					COUNT(*) FILTER (WHERE created_at > NOW() - INTERVAL '24 hours') AS last_24h
+				FROM inbound_messages
+				GROUP BY 1
+			),
+			outbound AS (
+				SELECT split_part(conversation_key, ':', 1) AS channel_id,
+					COUNT(*) AS total,
+					COUNT(*) FILTER (WHERE status = 'failed') AS failed
+				FROM outbound_messages
+				GROUP BY 1
+			)
+		SELECT
+			conv.channel_id,
+			conv.conversations,
+			conv.paired AS conversation_paired,
+			COALESCE(inbound.total, 0) AS inbound_total,
+			COALESCE(inbound.last_24h, 0) AS inbound_24h,
+			COALESCE(outbound.total, 0) AS outbound_total,
+			COALESCE(outbound.failed, 0) AS outbound_failed,
+			conv.last_seen_at
+		FROM conv
+		LEFT JOIN inbound USING (channel_id)
+		LEFT JOIN outbound USING (channel_id)
+		ORDER BY conv.channel_id ASC
+	`)
+	return stats, err
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type MessageOverrideRepository interface {
+	FindByAccountID(ctx context.Context, accountID string) ([]model.MessageOverride, error)
+	FindByAccountAndLocale(ctx context.Context, accountID, locale string) ([]model.MessageOverride, error)
+	Upsert(ctx context.Context, accountID, locale, key, text string) (*model.MessageOverride, error)
+	// Delete reports whether an override existed.
+	Delete(ctx context.Context, accountID, locale, key string) (bool, error)
+}
+
+type messageOverrideRepo struct {
+	db *sqlx.DB
+}
+
+func NewMessageOverrideRepository(db *sqlx.DB) MessageOverrideRepository {
+	return &messageOverrideRepo{db: db}
+}
+
+func (r *messageOverrideRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.MessageOverride, error) {
+	var overrides []model.MessageOverride
+	err := r.db.SelectContext(ctx, &overrides, `
+		SELECT * FROM account_message_overrides
+		WHERE account_id = $1
+		ORDER BY locale ASC, key ASC
+	`, accountID)
+	return overrides, err
+}
+
+func (r *messageOverrideRepo) FindByAccountAndLocale(ctx context.Context, accountID, locale string) ([]model.MessageOverride, error) {
+	var overrides []model.MessageOverride
+	err := r.db.SelectContext(ctx, &overrides, `
+		SELECT * FROM account_message_overrides
+		WHERE account_id = $1 AND locale = $2
+	`, accountID, locale)
+	return overrides, err
+}
+
+func (r *messageOverrideRepo) Upsert(ctx context.Context, accountID, locale, key, text string) (*model.MessageOverride, error) {
+	var override model.MessageOverride
+	err := r.db.GetContext(ctx, &override, `
+		INSERT INTO account_message_overrides (account_id, locale, key, text)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (account_id, locale, key) DO UPDATE SET
+			text = EXCLUDED.text,
+			updated_at = NOW()
+		RETURNING *
+	`, accountID, locale, key, text)
+	if err != nil {
+		return nil, err
+	}
+	return &override, nil
+}
+
+func (r *messageOverrideRepo) Delete(ctx context.Context, accountID, locale, key string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM account_message_overrides
+		WHERE account_id = $1 AND locale = $2 AND key = $3
+	`, accountID, locale, key)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
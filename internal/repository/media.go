@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type MediaRepository interface {
+	FindByID(ctx context.Context, id string) (*model.Media, error)
+	FindByPublicToken(ctx context.Context, token string) (*model.Media, error)
+	FindExpired(ctx context.Context, now time.Time, limit int) ([]model.Media, error)
+	Create(ctx context.Context, params model.CreateMediaParams) (*model.Media, error)
+	Delete(ctx context.Context, id string) error
+}
+
+type mediaRepo struct {
+	db *sqlx.DB
+}
+
+func NewMediaRepository(db *sqlx.DB) MediaRepository {
+	return &mediaRepo{db: db}
+}
+
+func (r *mediaRepo) FindByID(ctx context.Context, id string) (*model.Media, error) {
+	var m model.Media
+	err := r.db.GetContext(ctx, &m, `SELECT * FROM media WHERE id = $1`, id)
+	return HandleNotFound(&m, err)
+}
+
+func (r *mediaRepo) FindByPublicToken(ctx context.Context, token string) (*model.Media, error) {
+	var m model.Media
+	err := r.db.GetContext(ctx, &m, `SELECT * FROM media WHERE public_token = $1`, token)
+	return HandleNotFound(&m, err)
+}
+
+func (r *mediaRepo) FindExpired(ctx context.Context, now time.Time, limit int) ([]model.Media, error) {
+	var items []model.Media
+	err := r.db.SelectContext(ctx, &items, `
+		SELECT * FROM media
+		WHERE expires_at < $1
+		ORDER BY expires_at ASC
+		LIMIT $2
+	`, now, limit)
+	return items, err
+}
+
+func (r *mediaRepo) Create(ctx context.Context, params model.CreateMediaParams) (*model.Media, error) {
+	var m model.Media
+	err := r.db.GetContext(ctx, &m, `
+		INSERT INTO media
+			(account_id, public_token, storage_key, content_type, size_bytes, filename, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING *
+	`, params.AccountID, params.PublicToken, params.StorageKey, params.ContentType,
+		params.SizeBytes, params.Filename, params.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (r *mediaRepo) Delete(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `DELETE FROM media WHERE id = $1`, id)
+	return err
+}
@@ -2,9 +2,13 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 
 	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
 )
@@ -13,7 +17,10 @@ type InboundMessageRepository interface {
 	FindByID(ctx context.Context, id string) (*model.InboundMessage, error)
 	FindQueuedByAccountID(ctx context.Context, accountID string) ([]model.InboundMessage, error)
 	FindByAccountID(ctx context.Context, accountID string, limit, offset int) ([]model.InboundMessage, error)
-	FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.InboundMessage, error)
+	// FindByConversationKey pages backwards through the account's messages
+	// in a conversation: up to limit messages older than before (all when
+	// nil), newest first. A non-empty threadID keeps only that thread.
+	FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.InboundMessage, error)
 	CountByAccountID(ctx context.Context, accountID string) (int, error)
 	CountByConversationKey(ctx context.Context, conversationKey string) (int, error)
 	CountByConversationKeySince(ctx context.Context, conversationKey string, since time.Time) (int, error)
@@ -21,6 +28,42 @@ type InboundMessageRepository interface {
 	MarkDelivered(ctx context.Context, id string) error
 	MarkAcked(ctx context.Context, id string) error
 	MarkExpired(ctx context.Context) (int64, error)
+	// FindClaimable returns up to limit messages a competing consumer may
+	// take: queued ones and delivered ones whose lease has lapsed, oldest
+	// first. Messages whose callback window has closed or that used up
+	// MaxDeliveryAttempts are skipped.
+	FindClaimable(ctx context.Context, accountID string, limit int) ([]model.InboundMessage, error)
+	// Claim leases the given messages to consumerID for ttl. Only messages
+	// that are still claimable are taken; the claimed rows are returned.
+	Claim(ctx context.Context, ids []string, consumerID string, ttl time.Duration) ([]model.InboundMessage, error)
+	// ReleaseLeases ends every lease held by consumerID so its unacked
+	// messages can be claimed by another consumer.
+	ReleaseLeases(ctx context.Context, consumerID string) (int64, error)
+	// AckMessages marks the account's pending messages among ids acked and
+	// returns how many changed. The ID of an utterance that was coalesced
+	// into another message acks that message.
+	AckMessages(ctx context.Context, accountID string, ids []string) (int64, error)
+	// ReleaseNext releases the conversation's oldest held message once no
+	// message released within holdTimeout is still unsettled. Nil when
+	// nothing was released.
+	ReleaseNext(ctx context.Context, accountID, conversationKey string, holdTimeout time.Duration) (*model.InboundMessage, error)
+	// ReleaseDue releases, across all accounts, the next held message of
+	// every conversation whose earlier messages are settled or timed out,
+	// and every held message whose callback expires within deadlineMargin.
+	ReleaseDue(ctx context.Context, holdTimeout, deadlineMargin time.Duration) ([]model.InboundMessage, error)
+	// ReleaseAllHeld releases every held message of the account, oldest
+	// first, for when it stops ordering delivery.
+	ReleaseAllHeld(ctx context.Context, accountID string) ([]model.InboundMessage, error)
+	// FindCoalescing returns the conversation's messages still waiting to
+	// be coalesced, oldest first.
+	FindCoalescing(ctx context.Context, accountID, conversationKey string) ([]model.InboundMessage, error)
+	// FindDueCoalescing lists the conversations whose coalescing window has
+	// closed.
+	FindDueCoalescing(ctx context.Context) ([]model.ConversationRef, error)
+	// CompleteCoalescing merges the waiting messages into the primary one
+	// and makes it deliverable. Nil when the waiting messages are no longer
+	// exactly params.IDs.
+	CompleteCoalescing(ctx context.Context, params model.CompleteCoalescingParams) (*model.InboundMessage, error)
 	CountByStatus(ctx context.Context, status model.InboundMessageStatus) (int, error)
 	CountByAccountIDAndStatus(ctx context.Context, accountID string, status model.InboundMessageStatus) (int, error)
 	CountByAccountIDSince(ctx context.Context, accountID string, since time.Time) (int, error)
@@ -44,7 +87,7 @@ func (r *inboundMessageRepo) FindQueuedByAccountID(ctx context.Context, accountI
 	var msgs []model.InboundMessage
 	err := r.db.SelectContext(ctx, &msgs, `
 		SELECT * FROM inbound_messages
-		WHERE account_id = $1 AND status = 'queued'
+		WHERE account_id = $1 AND status = 'queued' AND NOT held AND coalesce_due_at IS NULL
 		ORDER BY created_at ASC
 	`, accountID)
 	return msgs, err
@@ -61,14 +104,22 @@ func (r *inboundMessageRepo) FindByAccountID(ctx context.Context, accountID stri
 	return msgs, err
 }
 
-func (r *inboundMessageRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.InboundMessage, error) {
+func (r *inboundMessageRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.InboundMessage, error) {
+	var beforeAt *time.Time
+	var beforeID *string
+	if before != nil {
+		beforeAt, beforeID = &before.CreatedAt, &before.ID
+	}
+
 	var msgs []model.InboundMessage
 	err := r.db.SelectContext(ctx, &msgs, `
 		SELECT * FROM inbound_messages
-		WHERE conversation_key = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`, conversationKey, limit, offset)
+		WHERE account_id = $1 AND conversation_key = $2
+		AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4::uuid))
+		AND ($6 = '' OR thread_id::text = $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`, accountID, conversationKey, beforeAt, beforeID, limit, threadID)
 	return msgs, err
 }
 
@@ -98,22 +149,318 @@ func (r *inboundMessageRepo) CountByConversationKeySince(ctx context.Context, co
 }
 
 func (r *inboundMessageRepo) Create(ctx context.Context, params model.CreateInboundMessageParams) (*model.InboundMessage, error) {
+	if params.HoldTimeout > 0 || params.CoalesceWindow > 0 {
+		return r.createShaped(ctx, params)
+	}
+
 	var msg model.InboundMessage
 	err := r.db.GetContext(ctx, &msg, `
 		INSERT INTO inbound_messages
 			(account_id, conversation_key, kakao_payload, normalized_message,
-			 callback_url, callback_expires_at, source_event_id)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+			 callback_url, callback_expires_at, source_event_id, thread_id, routing_rule_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING *
 	`, params.AccountID, params.ConversationKey, params.KakaoPayload,
 		params.NormalizedMessage, params.CallbackURL, params.CallbackExpiresAt,
-		params.SourceEventID)
+		params.SourceEventID, params.ThreadID, params.RoutingRuleID)
+	if err != nil {
+		return nil, err
+	}
+	return &msg, nil
+}
+
+// lockConversation serializes ordered-delivery changes to one conversation
+// until tx ends.
+func lockConversation(ctx context.Context, tx *sqlx.Tx, accountID, conversationKey string) error {
+	_, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`, accountID, conversationKey)
+	return err
+}
+
+// unsettledPredicate matches a conversation's messages that hold later ones
+// back: held messages, and released ones still waiting for a reply or ack
+// that were released after $3.
+const unsettledPredicate = `
+	account_id = $1 AND conversation_key = $2 AND status IN ('queued', 'delivered')
+	AND (held OR released_at > $3)`
+
+// coalesceMaxDelayFactor caps how long utterances may keep extending a
+// coalescing group: the group is delivered at most this many windows after
+// its first utterance.
+const coalesceMaxDelayFactor = 3
+
+// createShaped inserts a message of an account that orders or coalesces
+// delivery. Both look at the conversation's other messages, so the insert
+// runs under the conversation lock.
+func (r *inboundMessageRepo) createShaped(ctx context.Context, params model.CreateInboundMessageParams) (*model.InboundMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockConversation(ctx, tx, params.AccountID, params.ConversationKey); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var held bool
+	var queuePosition *int
+	var releasedAt *time.Time
+	if params.HoldTimeout > 0 {
+		var ahead int
+		err = tx.GetContext(ctx, &ahead, `SELECT COUNT(*) FROM inbound_messages WHERE `+unsettledPredicate,
+			params.AccountID, params.ConversationKey, now.Add(-params.HoldTimeout))
+		if err != nil {
+			return nil, err
+		}
+		held, queuePosition = ahead > 0, &ahead
+		if !held {
+			releasedAt = &now
+		}
+	}
+
+	var coalesceDueAt *time.Time
+	if params.CoalesceWindow > 0 {
+		var groupStart, groupExpiry *time.Time
+		err = tx.QueryRowxContext(ctx, `
+			SELECT MIN(created_at), MIN(callback_expires_at) FROM inbound_messages
+			WHERE account_id = $1 AND conversation_key = $2
+			AND coalesce_due_at IS NOT NULL AND status = 'queued'
+		`, params.AccountID, params.ConversationKey).Scan(&groupStart, &groupExpiry)
+		if err != nil {
+			return nil, err
+		}
+		due := now.Add(params.CoalesceWindow)
+		if groupStart != nil {
+			if limit := groupStart.Add(coalesceMaxDelayFactor * params.CoalesceWindow); limit.Before(due) {
+				due = limit
+			}
+		}
+		// The group must be delivered while its callbacks can still be
+		// answered, however short the channel's callback TTL.
+		for _, expiry := range []*time.Time{groupExpiry, params.CallbackExpiresAt} {
+			if expiry == nil {
+				continue
+			}
+			if limit := expiry.Add(-params.CoalesceDeadlineMargin); limit.Before(due) {
+				due = limit
+			}
+		}
+		if due.Before(now) {
+			due = now
+		}
+		coalesceDueAt = &due
+	}
+
+	var msg model.InboundMessage
+	err = tx.GetContext(ctx, &msg, `
+		INSERT INTO inbound_messages
+			(account_id, conversation_key, kakao_payload, normalized_message,
+			 callback_url, callback_expires_at, source_event_id, thread_id,
+			 held, queue_position, released_at, coalesce_due_at, routing_rule_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING *
+	`, params.AccountID, params.ConversationKey, params.KakaoPayload,
+		params.NormalizedMessage, params.CallbackURL, params.CallbackExpiresAt,
+		params.SourceEventID, params.ThreadID, held, queuePosition, releasedAt, coalesceDueAt,
+		params.RoutingRuleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return &msg, nil
+}
+
+func (r *inboundMessageRepo) FindCoalescing(ctx context.Context, accountID, conversationKey string) ([]model.InboundMessage, error) {
+	var msgs []model.InboundMessage
+	err := r.db.SelectContext(ctx, &msgs, `
+		SELECT * FROM inbound_messages
+		WHERE account_id = $1 AND conversation_key = $2
+		AND coalesce_due_at IS NOT NULL AND status = 'queued'
+		ORDER BY created_at ASC, id ASC
+	`, accountID, conversationKey)
+	return msgs, err
+}
+
+func (r *inboundMessageRepo) FindDueCoalescing(ctx context.Context) ([]model.ConversationRef, error) {
+	var refs []model.ConversationRef
+	err := r.db.SelectContext(ctx, &refs, `
+		SELECT account_id, conversation_key FROM inbound_messages
+		WHERE coalesce_due_at IS NOT NULL AND status = 'queued'
+		GROUP BY account_id, conversation_key
+		HAVING MAX(coalesce_due_at) <= NOW()
+	`)
+	return refs, err
+}
+
+func (r *inboundMessageRepo) CompleteCoalescing(ctx context.Context, params model.CompleteCoalescingParams) (*model.InboundMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockConversation(ctx, tx, params.AccountID, params.ConversationKey); err != nil {
+		return nil, err
+	}
+
+	// Give up if the group changed since it was read: another utterance
+	// joined (its own flush covers it) or a flush already ran.
+	var waiting []string
+	err = tx.SelectContext(ctx, &waiting, `
+		SELECT id FROM inbound_messages
+		WHERE account_id = $1 AND conversation_key = $2
+		AND coalesce_due_at IS NOT NULL AND status = 'queued'
+		ORDER BY created_at ASC, id ASC
+	`, params.AccountID, params.ConversationKey)
 	if err != nil {
 		return nil, err
 	}
+	if !slices.Equal(waiting, params.IDs) {
+		return nil, nil
+	}
+
+	// The merged message takes the place of the group's first utterance
+	// in ordered delivery.
+	var first model.InboundMessage
+	if err := tx.GetContext(ctx, &first, `SELECT * FROM inbound_messages WHERE id = $1`, params.IDs[0]); err != nil {
+		return nil, err
+	}
+
+	var coalescedIDs pq.StringArray
+	var merged *json.RawMessage
+	if len(params.IDs) > 1 {
+		coalescedIDs = params.IDs
+		merged = &params.Merged
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE inbound_messages SET
+				status = 'acked', acked_at = NOW(), coalesce_due_at = NULL,
+				held = false, coalesced_into = $2
+			WHERE id = ANY($1) AND id <> $2
+		`, pq.Array(params.IDs), params.PrimaryID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var msg model.InboundMessage
+	err = tx.GetContext(ctx, &msg, `
+		UPDATE inbound_messages SET
+			coalesce_due_at = NULL,
+			coalesced_ids = $2,
+			coalesced_message = $3,
+			held = $4,
+			queue_position = $5,
+			released_at = $6
+		WHERE id = $1
+		RETURNING *
+	`, params.PrimaryID, coalescedIDs, merged, first.Held, first.QueuePosition, first.ReleasedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
 	return &msg, nil
 }
 
+func (r *inboundMessageRepo) ReleaseNext(ctx context.Context, accountID, conversationKey string, holdTimeout time.Duration) (*model.InboundMessage, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := lockConversation(ctx, tx, accountID, conversationKey); err != nil {
+		return nil, err
+	}
+
+	var msg model.InboundMessage
+	err = tx.GetContext(ctx, &msg, `
+		UPDATE inbound_messages SET held = false, released_at = NOW()
+		WHERE id = (
+			SELECT id FROM inbound_messages
+			WHERE account_id = $1 AND conversation_key = $2 AND held AND status = 'queued'
+			AND coalesce_due_at IS NULL
+			ORDER BY created_at ASC
+			LIMIT 1
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM inbound_messages
+			WHERE account_id = $1 AND conversation_key = $2 AND status IN ('queued', 'delivered')
+			AND NOT held AND released_at > $3
+		)
+		RETURNING *
+	`, accountID, conversationKey, time.Now().Add(-holdTimeout))
+	released, err := HandleNotFound(&msg, err)
+	if err != nil || released == nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+	return released, nil
+}
+
+func (r *inboundMessageRepo) ReleaseDue(ctx context.Context, holdTimeout, deadlineMargin time.Duration) ([]model.InboundMessage, error) {
+	now := time.Now()
+	// pg_try_advisory_xact_lock skips conversations a webhook or reply is
+	// changing right now, in both queries; the next run picks them up.
+	var msgs []model.InboundMessage
+	err := r.db.SelectContext(ctx, &msgs, `
+		UPDATE inbound_messages m SET held = false, released_at = NOW()
+		FROM (
+			SELECT DISTINCT ON (h.account_id, h.conversation_key) h.id
+			FROM inbound_messages h
+			WHERE h.held AND h.status = 'queued' AND h.coalesce_due_at IS NULL
+			AND NOT EXISTS (
+				SELECT 1 FROM inbound_messages f
+				WHERE f.account_id = h.account_id AND f.conversation_key = h.conversation_key
+				AND f.status IN ('queued', 'delivered') AND NOT f.held AND f.released_at > $1
+			)
+			ORDER BY h.account_id, h.conversation_key, h.created_at ASC
+		) next
+		WHERE m.id = next.id
+		AND pg_try_advisory_xact_lock(hashtext(m.account_id || ':' || m.conversation_key))
+		RETURNING m.*
+	`, now.Add(-holdTimeout))
+	if err != nil {
+		return nil, err
+	}
+
+	var urgent []model.InboundMessage
+	err = r.db.SelectContext(ctx, &urgent, `
+		UPDATE inbound_messages SET held = false, released_at = NOW()
+		WHERE held AND status = 'queued' AND coalesce_due_at IS NULL
+		AND callback_expires_at IS NOT NULL AND callback_expires_at <= $1
+		AND pg_try_advisory_xact_lock(hashtext(account_id || ':' || conversation_key))
+		RETURNING *
+	`, now.Add(deadlineMargin))
+	if err != nil {
+		return msgs, err
+	}
+	return append(msgs, urgent...), nil
+}
+
+func (r *inboundMessageRepo) ReleaseAllHeld(ctx context.Context, accountID string) ([]model.InboundMessage, error) {
+	var msgs []model.InboundMessage
+	err := r.db.SelectContext(ctx, &msgs, `
+		WITH released AS (
+			UPDATE inbound_messages SET held = false, released_at = NOW()
+			WHERE account_id = $1 AND held AND status = 'queued'
+			RETURNING *
+		)
+		SELECT * FROM released ORDER BY created_at ASC
+	`, accountID)
+	return msgs, err
+}
+
 func (r *inboundMessageRepo) MarkDelivered(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE inbound_messages SET
@@ -128,7 +475,9 @@ func (r *inboundMessageRepo) MarkAcked(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE inbound_messages SET
 			status = 'acked',
-			acked_at = $2
+			acked_at = $2,
+			lease_owner = NULL,
+			lease_expires_at = NULL
 		WHERE id = $1
 	`, id, time.Now())
 	return err
@@ -136,11 +485,91 @@ func (r *inboundMessageRepo) MarkAcked(ctx context.Context, id string) error {
 
 func (r *inboundMessageRepo) MarkExpired(ctx context.Context) (int64, error) {
 	result, err := r.db.ExecContext(ctx, `
-		UPDATE inbound_messages SET status = 'expired'
-		WHERE status = 'queued'
-		AND callback_expires_at IS NOT NULL
-		AND callback_expires_at < NOW()
-	`)
+		UPDATE inbound_messages SET status = 'expired', lease_owner = NULL, lease_expires_at = NULL
+		WHERE (
+			(status = 'queued' OR (status = 'delivered' AND lease_owner IS NOT NULL))
+			AND callback_expires_at IS NOT NULL
+			AND callback_expires_at < NOW()
+		) OR (
+			status = 'delivered' AND lease_owner IS NOT NULL
+			AND lease_expires_at <= NOW()
+			AND delivery_attempts >= $1
+		)
+	`, MaxDeliveryAttempts)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// MaxDeliveryAttempts caps how often a leased message is offered to
+// competing consumers. MarkExpired retires it after the last lease lapses.
+const MaxDeliveryAttempts = 5
+
+// claimablePredicate matches messages no live lease holds.
+var claimablePredicate = fmt.Sprintf(`
+	(status = 'queued' OR (status = 'delivered' AND lease_owner IS NOT NULL AND lease_expires_at <= NOW()))
+	AND (callback_expires_at IS NULL OR callback_expires_at > NOW())
+	AND NOT held AND coalesce_due_at IS NULL
+	AND delivery_attempts < %d`, MaxDeliveryAttempts)
+
+func (r *inboundMessageRepo) FindClaimable(ctx context.Context, accountID string, limit int) ([]model.InboundMessage, error) {
+	var msgs []model.InboundMessage
+	err := r.db.SelectContext(ctx, &msgs, `
+		SELECT * FROM inbound_messages
+		WHERE account_id = $1 AND `+claimablePredicate+`
+		ORDER BY created_at ASC
+		LIMIT $2
+	`, accountID, limit)
+	return msgs, err
+}
+
+func (r *inboundMessageRepo) Claim(ctx context.Context, ids []string, consumerID string, ttl time.Duration) ([]model.InboundMessage, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	now := time.Now()
+	var msgs []model.InboundMessage
+	err := r.db.SelectContext(ctx, &msgs, `
+		UPDATE inbound_messages SET
+			status = 'delivered',
+			delivered_at = $3,
+			lease_owner = $2,
+			lease_expires_at = $4,
+			delivery_attempts = delivery_attempts + 1
+		WHERE id = ANY($1) AND `+claimablePredicate+`
+		RETURNING *
+	`, pq.Array(ids), consumerID, now, now.Add(ttl))
+	return msgs, err
+}
+
+func (r *inboundMessageRepo) ReleaseLeases(ctx context.Context, consumerID string) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE inbound_messages SET lease_expires_at = NOW()
+		WHERE lease_owner = $1 AND status = 'delivered'
+	`, consumerID)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func (r *inboundMessageRepo) AckMessages(ctx context.Context, accountID string, ids []string) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE inbound_messages SET
+			status = 'acked',
+			acked_at = $3,
+			lease_owner = NULL,
+			lease_expires_at = NULL
+		WHERE account_id = $1 AND status IN ('queued', 'delivered')
+		AND (id = ANY($2) OR id IN (
+			SELECT coalesced_into FROM inbound_messages
+			WHERE account_id = $1 AND id = ANY($2) AND coalesced_into IS NOT NULL
+		))
+	`, accountID, pq.Array(ids), time.Now())
 	if err != nil {
 		return 0, err
 	}
@@ -177,7 +606,8 @@ type OutboundMessageRepository interface {
 	FindByID(ctx context.Context, id string) (*model.OutboundMessage, error)
 	FindPendingByAccountID(ctx context.Context, accountID string) ([]model.OutboundMessage, error)
 	FindByAccountID(ctx context.Context, accountID string, limit, offset int) ([]model.OutboundMessage, error)
-	FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.OutboundMessage, error)
+	// FindByConversationKey pages backwards like its inbound counterpart.
+	FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.OutboundMessage, error)
 	CountByAccountID(ctx context.Context, accountID string) (int, error)
 	CountByConversationKey(ctx context.Context, conversationKey string) (int, error)
 	CountByConversationKeySince(ctx context.Context, conversationKey string, since time.Time) (int, error)
@@ -185,6 +615,7 @@ type OutboundMessageRepository interface {
 	Create(ctx context.Context, params model.CreateOutboundMessageParams) (*model.OutboundMessage, error)
 	MarkSent(ctx context.Context, id string) error
 	MarkFailed(ctx context.Context, id string, errorMsg string) error
+	MarkEventAccepted(ctx context.Context, id, taskID, eventStatus string) error
 	CountByAccountIDAndStatus(ctx context.Context, accountID string, status model.OutboundMessageStatus) (int, error)
 	CountByAccountIDSince(ctx context.Context, accountID string, since time.Time) (int, error)
 	FindRecentFailedByAccountID(ctx context.Context, accountID string, limit int) ([]model.OutboundMessage, error)
@@ -225,14 +656,22 @@ func (r *outboundMessageRepo) FindByAccountID(ctx context.Context, accountID str
 	return msgs, err
 }
 
-func (r *outboundMessageRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.OutboundMessage, error) {
+func (r *outboundMessageRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.OutboundMessage, error) {
+	var beforeAt *time.Time
+	var beforeID *string
+	if before != nil {
+		beforeAt, beforeID = &before.CreatedAt, &before.ID
+	}
+
 	var msgs []model.OutboundMessage
 	err := r.db.SelectContext(ctx, &msgs, `
 		SELECT * FROM outbound_messages
-		WHERE conversation_key = $1
-		ORDER BY created_at DESC
-		LIMIT $2 OFFSET $3
-	`, conversationKey, limit, offset)
+		WHERE account_id = $1 AND conversation_key = $2
+		AND ($3::timestamptz IS NULL OR (created_at, id) < ($3, $4::uuid))
+		AND ($6 = '' OR thread_id::text = $6)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $5
+	`, accountID, conversationKey, beforeAt, beforeID, limit, threadID)
 	return msgs, err
 }
 
@@ -271,14 +710,19 @@ func (r *outboundMessageRepo) CountByConversationKeyAndStatus(ctx context.Contex
 }
 
 func (r *outboundMessageRepo) Create(ctx context.Context, params model.CreateOutboundMessageParams) (*model.OutboundMessage, error) {
+	deliveryType := params.DeliveryType
+	if deliveryType == "" {
+		deliveryType = model.OutboundDeliveryCallback
+	}
+
 	var msg model.OutboundMessage
 	err := r.db.GetContext(ctx, &msg, `
 		INSERT INTO outbound_messages
-			(account_id, inbound_message_id, conversation_key, kakao_target, response_payload)
-		VALUES ($1, $2, $3, $4, $5)
+			(account_id, inbound_message_id, conversation_key, kakao_target, response_payload, delivery_type, thread_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING *
 	`, params.AccountID, params.InboundMessageID, params.ConversationKey,
-		params.KakaoTarget, params.ResponsePayload)
+		params.KakaoTarget, params.ResponsePayload, deliveryType, params.ThreadID)
 	if err != nil {
 		return nil, err
 	}
@@ -305,6 +749,18 @@ func (r *outboundMessageRepo) MarkFailed(ctx context.Context, id string, errorMs
 	return err
 }
 
+func (r *outboundMessageRepo) MarkEventAccepted(ctx context.Context, id, taskID, eventStatus string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE outbound_messages SET
+			status = 'sent',
+			sent_at = $2,
+			event_task_id = $3,
+			event_status = $4
+		WHERE id = $1
+	`, id, time.Now(), taskID, eventStatus)
+	return err
+}
+
 func (r *outboundMessageRepo) CountByAccountIDAndStatus(ctx context.Context, accountID string, status model.OutboundMessageStatus) (int, error) {
 	var count int
 	err := r.db.GetContext(ctx, &count, `
@@ -0,0 +1,103 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type PairingCodeRepository interface {
+	Create(ctx context.Context, params model.CreatePairingCodeParams) (*model.PairingCode, error)
+	FindByAccountID(ctx context.Context, accountID string) ([]model.PairingCode, error)
+	// Redeem consumes one use of a live code. It returns nil when the code
+	// does not exist, is revoked, expired or used up.
+	Redeem(ctx context.Context, code string) (*model.PairingCode, error)
+	// FindLive returns the code if Redeem would accept it, without using it.
+	FindLive(ctx context.Context, code string) (*model.PairingCode, error)
+	// Revoke reports whether a live code of the account was revoked.
+	Revoke(ctx context.Context, accountID, id string) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+type pairingCodeRepo struct {
+	db *sqlx.DB
+}
+
+func NewPairingCodeRepository(db *sqlx.DB) PairingCodeRepository {
+	return &pairingCodeRepo{db: db}
+}
+
+func (r *pairingCodeRepo) Create(ctx context.Context, params model.CreatePairingCodeParams) (*model.PairingCode, error) {
+	var code model.PairingCode
+	err := r.db.GetContext(ctx, &code, `
+		INSERT INTO pairing_codes (account_id, code, max_uses, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING *
+	`, params.AccountID, params.Code, params.MaxUses, params.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+func (r *pairingCodeRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.PairingCode, error) {
+	var codes []model.PairingCode
+	err := r.db.SelectContext(ctx, &codes, `
+		SELECT * FROM pairing_codes
+		WHERE account_id = $1
+		ORDER BY created_at DESC
+	`, accountID)
+	return codes, err
+}
+
+func (r *pairingCodeRepo) Redeem(ctx context.Context, code string) (*model.PairingCode, error) {
+	var pc model.PairingCode
+	err := r.db.GetContext(ctx, &pc, `
+		UPDATE pairing_codes SET
+			use_count = use_count + 1,
+			last_used_at = NOW()
+		WHERE code = $1
+			AND revoked_at IS NULL
+			AND expires_at > NOW()
+			AND use_count < max_uses
+		RETURNING *
+	`, code)
+	return HandleNotFound(&pc, err)
+}
+
+func (r *pairingCodeRepo) FindLive(ctx context.Context, code string) (*model.PairingCode, error) {
+	var pc model.PairingCode
+	err := r.db.GetContext(ctx, &pc, `
+		SELECT * FROM pairing_codes
+		WHERE code = $1
+			AND revoked_at IS NULL
+			AND expires_at > NOW()
+			AND use_count < max_uses
+	`, code)
+	return HandleNotFound(&pc, err)
+}
+
+func (r *pairingCodeRepo) Revoke(ctx context.Context, accountID, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE pairing_codes SET revoked_at = NOW()
+		WHERE id::text = $1 AND account_id = $2 AND revoked_at IS NULL
+	`, id, accountID)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (r *pairingCodeRepo) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM pairing_codes WHERE expires_at < $1
+	`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+// PairingRequestRepository stores approval-mode pairing requests. Each state
+// change updates the request and its conversation in one statement, so a
+// conversation is pending exactly while its request is.
+type PairingRequestRepository interface {
+	// Create records a pending request and moves the conversation to pending
+	// for the account.
+	Create(ctx context.Context, params model.CreatePairingRequestParams) (*model.PairingRequest, error)
+	FindByID(ctx context.Context, accountID, id string) (*model.PairingRequest, error)
+	FindPendingByAccountID(ctx context.Context, accountID string) ([]model.PairingRequest, error)
+	// Decide approves or rejects a live pending request of the account and
+	// pairs or releases its conversation. It returns nil if none matched.
+	Decide(ctx context.Context, accountID, id string, status model.PairingRequestStatus) (*model.PairingRequest, error)
+	// Cancel withdraws the conversation's pending request.
+	Cancel(ctx context.Context, conversationKey string) (*model.PairingRequest, error)
+	// ExpirePending expires overdue requests and releases their conversations.
+	ExpirePending(ctx context.Context) ([]model.PairingRequest, error)
+}
+
+type pairingRequestRepo struct {
+	db *sqlx.DB
+}
+
+func NewPairingRequestRepository(db *sqlx.DB) PairingRequestRepository {
+	return &pairingRequestRepo{db: db}
+}
+
+func (r *pairingRequestRepo) Create(ctx context.Context, params model.CreatePairingRequestParams) (*model.PairingRequest, error) {
+	var req model.PairingRequest
+	err := r.db.GetContext(ctx, &req, `
+		WITH req AS (
+			INSERT INTO pairing_requests (account_id, conversation_key, pairing_code_id, profile, expires_at)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING *
+		), conv AS (
+			UPDATE conversation_mappings c SET
+				state = 'pending',
+				account_id = req.account_id,
+				pairing_notice = NULL
+			FROM req
+			WHERE c.conversation_key = req.conversation_key
+		)
+		SELECT * FROM req
+	`, params.AccountID, params.ConversationKey, params.PairingCodeID, params.Profile, params.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+func (r *pairingRequestRepo) FindByID(ctx context.Context, accountID, id string) (*model.PairingRequest, error) {
+	var req model.PairingRequest
+	err := r.db.GetContext(ctx, &req, `
+		SELECT * FROM pairing_requests WHERE id::text = $1 AND account_id = $2
+	`, id, accountID)
+	return HandleNotFound(&req, err)
+}
+
+func (r *pairingRequestRepo) FindPendingByAccountID(ctx context.Context, accountID string) ([]model.PairingRequest, error) {
+	var reqs []model.PairingRequest
+	err := r.db.SelectContext(ctx, &reqs, `
+		SELECT * FROM pairing_requests
+		WHERE account_id = $1 AND status = 'pending' AND expires_at > NOW()
+		ORDER BY created_at
+	`, accountID)
+	return reqs, err
+}
+
+func (r *pairingRequestRepo) Decide(ctx context.Context, accountID, id string, status model.PairingRequestStatus) (*model.PairingRequest, error) {
+	var req model.PairingRequest
+	err := r.db.GetContext(ctx, &req, `
+		WITH req AS (
+			UPDATE pairing_requests SET
+				status = $3,
+				decided_at = NOW()
+			WHERE id::text = $1 AND account_id = $2
+				AND status = 'pending'
+				AND expires_at > NOW()
+			RETURNING *
+		), conv AS (
+			UPDATE conversation_mappings c SET
+				state = CASE WHEN req.status = 'approved' THEN 'paired' ELSE 'unpaired' END::pairing_state,
+				account_id = CASE WHEN req.status = 'approved' THEN req.account_id END,
+				paired_at = CASE WHEN req.status = 'approved' THEN NOW() ELSE c.paired_at END,
+				pairing_notice = req.status
+			FROM req
+			WHERE c.conversation_key = req.conversation_key
+				AND c.state = 'pending'
+				AND c.account_id = req.account_id
+		)
+		SELECT * FROM req
+	`, id, accountID, status)
+	return HandleNotFound(&req, err)
+}
+
+func (r *pairingRequestRepo) Cancel(ctx context.Context, conversationKey string) (*model.PairingRequest, error) {
+	var req model.PairingRequest
+	err := r.db.GetContext(ctx, &req, `
+		WITH req AS (
+			UPDATE pairing_requests SET
+				status = 'cancelled',
+				decided_at = NOW()
+			WHERE conversation_key = $1 AND status = 'pending'
+			RETURNING *
+		), conv AS (
+			UPDATE conversation_mappings c SET
+				state = 'unpaired',
+				account_id = NULL
+			FROM req
+			WHERE c.conversation_key = req.conversation_key
+				AND c.state = 'pending'
+		)
+		SELECT * FROM req
+	`, conversationKey)
+	return HandleNotFound(&req, err)
+}
+
+func (r *pairingRequestRepo) ExpirePending(ctx context.Context) ([]model.PairingRequest, error) {
+	var reqs []model.PairingRequest
+	err := r.db.SelectContext(ctx, &reqs, `
+		WITH req AS (
+			UPDATE pairing_requests SET
+				status = 'expired',
+				decided_at = NOW()
+			WHERE status = 'pending' AND expires_at <= NOW()
+			RETURNING *
+		), conv AS (
+			UPDATE conversation_mappings c SET
+				state = 'unpaired',
+				account_id = NULL,
+				pairing_notice = 'expired'
+			FROM req
+			WHERE c.conversation_key = req.conversation_key
+				AND c.state = 'pending'
+				AND c.account_id = req.account_id
+		)
+		SELECT * FROM req
+	`)
+	return reqs, err
+}
@@ -0,0 +1,93 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type RoutingRuleRepository interface {
+	FindAll(ctx context.Context) ([]model.RoutingRule, error)
+	FindByID(ctx context.Context, id string) (*model.RoutingRule, error)
+	// FindEnabled returns, in evaluation order, the enabled rules that
+	// apply to conversations paired with accountID.
+	FindEnabled(ctx context.Context, accountID string) ([]model.RoutingRule, error)
+	Create(ctx context.Context, params model.RoutingRuleParams) (*model.RoutingRule, error)
+	Update(ctx context.Context, id string, params model.RoutingRuleParams) (*model.RoutingRule, error)
+	// Delete reports whether the rule existed.
+	Delete(ctx context.Context, id string) (bool, error)
+}
+
+type routingRuleRepo struct {
+	db *sqlx.DB
+}
+
+func NewRoutingRuleRepository(db *sqlx.DB) RoutingRuleRepository {
+	return &routingRuleRepo{db: db}
+}
+
+func (r *routingRuleRepo) FindAll(ctx context.Context) ([]model.RoutingRule, error) {
+	var rules []model.RoutingRule
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT * FROM routing_rules
+		ORDER BY priority ASC, created_at ASC
+	`)
+	return rules, err
+}
+
+func (r *routingRuleRepo) FindByID(ctx context.Context, id string) (*model.RoutingRule, error) {
+	var rule model.RoutingRule
+	err := r.db.GetContext(ctx, &rule, `SELECT * FROM routing_rules WHERE id = $1`, id)
+	return HandleNotFound(&rule, err)
+}
+
+func (r *routingRuleRepo) FindEnabled(ctx context.Context, accountID string) ([]model.RoutingRule, error) {
+	var rules []model.RoutingRule
+	err := r.db.SelectContext(ctx, &rules, `
+		SELECT * FROM routing_rules
+		WHERE enabled AND (source_account_id IS NULL OR source_account_id = $1)
+		ORDER BY priority ASC, created_at ASC
+	`, accountID)
+	return rules, err
+}
+
+func (r *routingRuleRepo) Create(ctx context.Context, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	var rule model.RoutingRule
+	err := r.db.GetContext(ctx, &rule, `
+		INSERT INTO routing_rules
+			(name, priority, enabled, source_account_id, channel_id,
+			 block_name, intent_name, utterance_pattern, targets)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING *
+	`, params.Name, params.Priority, params.Enabled, params.SourceAccountID, params.ChannelID,
+		params.BlockName, params.IntentName, params.UtterancePattern, params.Targets)
+	if err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+func (r *routingRuleRepo) Update(ctx context.Context, id string, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	var rule model.RoutingRule
+	err := r.db.GetContext(ctx, &rule, `
+		UPDATE routing_rules SET
+			name = $2, priority = $3, enabled = $4, source_account_id = $5, channel_id = $6,
+			block_name = $7, intent_name = $8, utterance_pattern = $9, targets = $10,
+			updated_at = NOW()
+		WHERE id = $1
+		RETURNING *
+	`, id, params.Name, params.Priority, params.Enabled, params.SourceAccountID, params.ChannelID,
+		params.BlockName, params.IntentName, params.UtterancePattern, params.Targets)
+	return HandleNotFound(&rule, err)
+}
+
+func (r *routingRuleRepo) Delete(ctx context.Context, id string) (bool, error) {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM routing_rules WHERE id = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
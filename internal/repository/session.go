@@ -23,7 +23,15 @@ type SessionRepository interface {
 	CountPendingByIP(ctx context.Context, ip string, since time.Time) (int, error)
 	FindRecent(ctx context.Context, limit int) ([]model.Session, error)
 	CountByStatus(ctx context.Context, status model.SessionStatus) (int, error)
+	// CountPairedByAccountID counts the paired sessions whose tokens
+	// authenticate the account.
+	CountPairedByAccountID(ctx context.Context, accountID string) (int, error)
 	UpdateMetadata(ctx context.Context, id string, metadata json.RawMessage) error
+	// UpdateTokenHash replaces the session token of a live session.
+	UpdateTokenHash(ctx context.Context, id, tokenHash string) error
+	// ClearRelayTokens removes the relay token stored in the metadata of
+	// the account's sessions.
+	ClearRelayTokens(ctx context.Context, accountID string) error
 	Delete(ctx context.Context, id string) error
 	// WithTx returns a new repository that uses the given transaction
 	WithTx(tx *sqlx.Tx) SessionRepository
@@ -163,6 +171,14 @@ func (r *sessionRepo) CountByStatus(ctx context.Context, status model.SessionSta
 	return count, err
 }
 
+func (r *sessionRepo) CountPairedByAccountID(ctx context.Context, accountID string) (int, error) {
+	var count int
+	err := r.db.GetContext(ctx, &count, `
+		SELECT COUNT(*) FROM sessions WHERE account_id = $1 AND status = 'paired'
+	`, accountID)
+	return count, err
+}
+
 func (r *sessionRepo) UpdateMetadata(ctx context.Context, id string, metadata json.RawMessage) error {
 	_, err := r.db.ExecContext(ctx, `
 		UPDATE sessions SET metadata = $2, updated_at = NOW()
@@ -171,6 +187,22 @@ func (r *sessionRepo) UpdateMetadata(ctx context.Context, id string, metadata js
 	return err
 }
 
+func (r *sessionRepo) UpdateTokenHash(ctx context.Context, id, tokenHash string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET session_token_hash = $2, updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending_pairing', 'paired')
+	`, id, tokenHash)
+	return err
+}
+
+func (r *sessionRepo) ClearRelayTokens(ctx context.Context, accountID string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE sessions SET metadata = metadata - 'relayToken', updated_at = NOW()
+		WHERE account_id = $1 AND metadata ? 'relayToken'
+	`, accountID)
+	return err
+}
+
 func (r *sessionRepo) Delete(ctx context.Context, id string) error {
 	_, err := r.db.ExecContext(ctx, `DELETE FROM sessions WHERE id = $1`, id)
 	return err
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const (
+	attachmentFetchTimeout = 30 * time.Second
+	attachmentMaxRedirects = 3
+	attachmentPurgeBatch   = 100
+	sniffLen               = 512
+)
+
+var allowedMediaHosts = []string{
+	".kakaocdn.net",
+	".kakao.com",
+}
+
+var ErrAttachmentTooLarge = errors.New("attachment exceeds size limit")
+
+// IsKakaoMediaHost reports whether host serves Kakao user media. Only these
+// hosts are fetched so a crafted payload can't make the relay request
+// arbitrary URLs.
+func IsKakaoMediaHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, suffix := range allowedMediaHosts {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type AttachmentConfig struct {
+	MaxBytes      int64
+	URLTTL        time.Duration
+	Retention     time.Duration
+	PublicBaseURL string
+	SigningSecret string
+}
+
+type AttachmentService struct {
+	repo   repository.AttachmentRepository
+	store  storage.BlobStore
+	client *http.Client
+	cfg    AttachmentConfig
+	now    func() time.Time
+}
+
+func NewAttachmentService(repo repository.AttachmentRepository, store storage.BlobStore, cfg AttachmentConfig) *AttachmentService {
+	return &AttachmentService{
+		repo:  repo,
+		store: store,
+		client: &http.Client{
+			Timeout: attachmentFetchTimeout,
+			// Redirects must stay on Kakao media hosts too, or an open
+			// redirect would let a payload reach internal addresses.
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if len(via) >= attachmentMaxRedirects {
+					return fmt.Errorf("too many redirects")
+				}
+				if !isFetchableMediaURL(req.URL.String()) {
+					return fmt.Errorf("redirect to disallowed host %s", req.URL.Hostname())
+				}
+				return nil
+			},
+		},
+		cfg: cfg,
+		now: time.Now,
+	}
+}
+
+// Ingest records the attachments of an inbound message and copies them into
+// the blob store in the background. The returned links are valid right away;
+// downloads answer 503 until the copy finishes.
+func (s *AttachmentService) Ingest(ctx context.Context, msg *model.InboundMessage, attachments []model.NormalizedAttachment) []model.AttachmentLink {
+	links := make([]model.AttachmentLink, 0, len(attachments))
+
+	for _, a := range attachments {
+		if !isFetchableMediaURL(a.URL) {
+			log.Warn().Str("messageId", msg.ID).Str("url", a.URL).Msg("skipping attachment from non-kakao host")
+			continue
+		}
+
+		att, err := s.repo.Create(ctx, model.CreateAttachmentParams{
+			InboundMessageID: msg.ID,
+			AccountID:        msg.AccountID,
+			Type:             a.Type,
+			SourceURL:        a.URL,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("messageId", msg.ID).Msg("failed to create attachment")
+			continue
+		}
+
+		links = append(links, s.Link(att))
+
+		go func(att *model.Attachment) {
+			fetchCtx, cancel := context.WithTimeout(context.Background(), attachmentFetchTimeout)
+			defer cancel()
+			if err := s.Fetch(fetchCtx, att); err != nil {
+				log.Warn().Err(err).Str("attachmentId", att.ID).Msg("failed to store attachment")
+			}
+		}(att)
+	}
+
+	return links
+}
+
+// Fetch downloads the attachment from Kakao and writes it to the blob store.
+func (s *AttachmentService) Fetch(ctx context.Context, att *model.Attachment) error {
+	err := s.fetch(ctx, att)
+	if err != nil {
+		if markErr := s.repo.MarkFailed(ctx, att.ID, err.Error()); markErr != nil {
+			log.Error().Err(markErr).Str("attachmentId", att.ID).Msg("failed to mark attachment failed")
+		}
+	}
+	return err
+}
+
+func (s *AttachmentService) fetch(ctx context.Context, att *model.Attachment) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, att.SourceURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("download failed with status %d", resp.StatusCode)
+	}
+	if s.cfg.MaxBytes > 0 && resp.ContentLength > s.cfg.MaxBytes {
+		return ErrAttachmentTooLarge
+	}
+
+	body := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, _ := body.Peek(sniffLen)
+	contentType := http.DetectContentType(head)
+	if header := resp.Header.Get("Content-Type"); contentType == "application/octet-stream" && header != "" {
+		contentType = header
+	}
+
+	counter := &countingReader{r: body, limit: s.cfg.MaxBytes}
+	key := fmt.Sprintf("attachments/%s/%s", att.AccountID, att.ID)
+	if err := s.store.Put(ctx, key, counter, resp.ContentLength, contentType); err != nil {
+		if counter.exceeded {
+			return ErrAttachmentTooLarge
+		}
+		return fmt.Errorf("store blob: %w", err)
+	}
+
+	return s.repo.MarkStored(ctx, att.ID, key, contentType, counter.n)
+}
+
+// LinksByMessage returns the attachment links for each of the given inbound
+// message IDs, keyed by message ID.
+func (s *AttachmentService) LinksByMessage(ctx context.Context, inboundMessageIDs []string) (map[string][]model.AttachmentLink, error) {
+	atts, err := s.repo.FindByInboundMessageIDs(ctx, inboundMessageIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]model.AttachmentLink)
+	for i := range atts {
+		result[atts[i].InboundMessageID] = append(result[atts[i].InboundMessageID], s.Link(&atts[i]))
+	}
+	return result, nil
+}
+
+// AttachLinks fills in the attachment links of stored messages. A
+// coalesced message gets the attachments of every utterance merged into it.
+func (s *AttachmentService) AttachLinks(ctx context.Context, messages []model.InboundMessage) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	var ids []string
+	for i := range messages {
+		ids = append(ids, messages[i].SourceMessageIDs()...)
+	}
+	links, err := s.LinksByMessage(ctx, ids)
+	if err != nil {
+		return err
+	}
+	for i := range messages {
+		var merged []model.AttachmentLink
+		for _, id := range messages[i].SourceMessageIDs() {
+			merged = append(merged, links[id]...)
+		}
+		messages[i].Attachments = merged
+	}
+	return nil
+}
+
+// Link builds the signed, expiring download link for an attachment.
+func (s *AttachmentService) Link(att *model.Attachment) model.AttachmentLink {
+	expiresAt := s.now().Add(s.cfg.URLTTL)
+	sig := util.SignExpiring(s.cfg.SigningSecret, att.ID, expiresAt.Unix())
+
+	link := model.AttachmentLink{
+		ID:     att.ID,
+		Type:   att.Type,
+		Status: att.Status,
+		URL: fmt.Sprintf("%s/files/attachments/%s?expires=%d&sig=%s",
+			strings.TrimRight(s.cfg.PublicBaseURL, "/"), att.ID, expiresAt.Unix(), sig),
+		ExpiresAt: expiresAt.UTC(),
+	}
+	if att.ContentType != nil {
+		link.ContentType = *att.ContentType
+	}
+	if att.SizeBytes != nil {
+		link.SizeBytes = *att.SizeBytes
+	}
+	return link
+}
+
+// VerifyDownload checks the expires/sig query parameters of a download link.
+func (s *AttachmentService) VerifyDownload(id, expires, sig string) bool {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || sig == "" {
+		return false
+	}
+	return util.VerifyExpiring(s.cfg.SigningSecret, id, expiresAt, sig, s.now())
+}
+
+func (s *AttachmentService) FindByID(ctx context.Context, id string) (*model.Attachment, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
+// Open returns a reader for a stored attachment's bytes.
+func (s *AttachmentService) Open(ctx context.Context, att *model.Attachment) (io.ReadCloser, int64, error) {
+	if att.StorageKey == nil {
+		return nil, 0, storage.ErrNotFound
+	}
+	return s.store.Get(ctx, *att.StorageKey)
+}
+
+// PurgeExpired deletes attachments older than the retention period from both
+// the blob store and the database.
+func (s *AttachmentService) PurgeExpired(ctx context.Context) (int64, error) {
+	if s.cfg.Retention <= 0 {
+		return 0, nil
+	}
+
+	atts, err := s.repo.FindCreatedBefore(ctx, s.now().Add(-s.cfg.Retention), attachmentPurgeBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, att := range atts {
+		if att.StorageKey != nil {
+			if err := s.store.Delete(ctx, *att.StorageKey); err != nil {
+				log.Warn().Err(err).Str("attachmentId", att.ID).Msg("failed to delete attachment blob")
+				continue
+			}
+		}
+		if err := s.repo.Delete(ctx, att.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+func isFetchableMediaURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return false
+	}
+	return IsKakaoMediaHost(parsed.Hostname())
+}
+
+// countingReader counts bytes read and fails once limit is exceeded.
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	limit    int64
+	exceeded bool
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.limit > 0 && c.n > c.limit {
+		c.exceeded = true
+		return n, ErrAttachmentTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+)
+
+type mockAttachmentRepo struct {
+	mock.Mock
+}
+
+func (m *mockAttachmentRepo) FindByID(ctx context.Context, id string) (*model.Attachment, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Attachment), args.Error(1)
+}
+
+func (m *mockAttachmentRepo) FindByInboundMessageIDs(ctx context.Context, ids []string) ([]model.Attachment, error) {
+	args := m.Called(ctx, ids)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Attachment), args.Error(1)
+}
+
+func (m *mockAttachmentRepo) FindCreatedBefore(ctx context.Context, before time.Time, limit int) ([]model.Attachment, error) {
+	args := m.Called(ctx, before, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Attachment), args.Error(1)
+}
+
+func (m *mockAttachmentRepo) Create(ctx context.Context, params model.CreateAttachmentParams) (*model.Attachment, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Attachment), args.Error(1)
+}
+
+func (m *mockAttachmentRepo) MarkStored(ctx context.Context, id, storageKey, contentType string, sizeBytes int64) error {
+	args := m.Called(ctx, id, storageKey, contentType, sizeBytes)
+	return args.Error(0)
+}
+
+func (m *mockAttachmentRepo) MarkFailed(ctx context.Context, id string, errorMsg string) error {
+	args := m.Called(ctx, id, errorMsg)
+	return args.Error(0)
+}
+
+func (m *mockAttachmentRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+var pngHeader = "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 32)
+
+func newTestAttachmentService(t *testing.T, repo *mockAttachmentRepo, maxBytes int64) (*AttachmentService, *storage.LocalStore) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	svc := NewAttachmentService(repo, store, AttachmentConfig{
+		MaxBytes:      maxBytes,
+		URLTTL:        time.Hour,
+		Retention:     24 * time.Hour,
+		PublicBaseURL: "https://relay.example.com/",
+		SigningSecret: "secret",
+	})
+	return svc, store
+}
+
+func TestAttachmentService_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, "/img", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/octet-stream")
+		io.WriteString(w, pngHeader)
+	}))
+	defer server.Close()
+
+	t.Run("stores blob with sniffed content type", func(t *testing.T) {
+		repo := new(mockAttachmentRepo)
+		svc, store := newTestAttachmentService(t, repo, 1024)
+		att := &model.Attachment{ID: "att-1", AccountID: "acc-1", SourceURL: server.URL + "/img"}
+
+		repo.On("MarkStored", mock.Anything, "att-1", "attachments/acc-1/att-1", "image/png", int64(len(pngHeader))).Return(nil)
+
+		require.NoError(t, svc.Fetch(context.Background(), att))
+		repo.AssertExpectations(t)
+
+		body, _, err := store.Get(context.Background(), "attachments/acc-1/att-1")
+		require.NoError(t, err)
+		defer body.Close()
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, pngHeader, string(data))
+	})
+
+	t.Run("marks failed on download error", func(t *testing.T) {
+		repo := new(mockAttachmentRepo)
+		svc, _ := newTestAttachmentService(t, repo, 1024)
+		att := &model.Attachment{ID: "att-2", AccountID: "acc-1", SourceURL: server.URL + "/missing"}
+
+		repo.On("MarkFailed", mock.Anything, "att-2", "download failed with status 404").Return(nil)
+
+		assert.Error(t, svc.Fetch(context.Background(), att))
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("does not follow redirects off kakao media hosts", func(t *testing.T) {
+		repo := new(mockAttachmentRepo)
+		svc, _ := newTestAttachmentService(t, repo, 1024)
+		att := &model.Attachment{ID: "att-4", AccountID: "acc-1", SourceURL: server.URL + "/redirect"}
+
+		repo.On("MarkFailed", mock.Anything, "att-4", mock.MatchedBy(func(msg string) bool {
+			return strings.Contains(msg, "redirect to disallowed host")
+		})).Return(nil)
+
+		assert.Error(t, svc.Fetch(context.Background(), att))
+		repo.AssertExpectations(t)
+		repo.AssertNotCalled(t, "MarkStored", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects attachments over the size limit", func(t *testing.T) {
+		repo := new(mockAttachmentRepo)
+		svc, _ := newTestAttachmentService(t, repo, 8)
+		att := &model.Attachment{ID: "att-3", AccountID: "acc-1", SourceURL: server.URL + "/img"}
+
+		repo.On("MarkFailed", mock.Anything, "att-3", ErrAttachmentTooLarge.Error()).Return(nil)
+
+		assert.ErrorIs(t, svc.Fetch(context.Background(), att), ErrAttachmentTooLarge)
+		repo.AssertExpectations(t)
+	})
+}
+
+func TestAttachmentService_Ingest(t *testing.T) {
+	t.Run("skips non-kakao hosts", func(t *testing.T) {
+		repo := new(mockAttachmentRepo)
+		svc, _ := newTestAttachmentService(t, repo, 1024)
+		msg := &model.InboundMessage{ID: "msg-1", AccountID: "acc-1"}
+
+		links := svc.Ingest(context.Background(), msg, []model.NormalizedAttachment{
+			{Type: model.AttachmentTypeImage, URL: "http://169.254.169.254/latest/meta-data"},
+		})
+
+		assert.Empty(t, links)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+}
+
+func TestAttachmentService_Link(t *testing.T) {
+	repo := new(mockAttachmentRepo)
+	svc, _ := newTestAttachmentService(t, repo, 1024)
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	contentType := "image/png"
+	link := svc.Link(&model.Attachment{
+		ID:          "att-1",
+		Type:        model.AttachmentTypeImage,
+		Status:      model.AttachmentStatusStored,
+		ContentType: &contentType,
+	})
+
+	assert.Equal(t, "image/png", link.ContentType)
+	assert.Equal(t, now.Add(time.Hour), link.ExpiresAt)
+	assert.True(t, strings.HasPrefix(link.URL, "https://relay.example.com/files/attachments/att-1?"))
+
+	parsed, err := url.Parse(link.URL)
+	require.NoError(t, err)
+	q := parsed.Query()
+
+	assert.True(t, svc.VerifyDownload("att-1", q.Get("expires"), q.Get("sig")))
+	assert.False(t, svc.VerifyDownload("att-2", q.Get("expires"), q.Get("sig")))
+	assert.False(t, svc.VerifyDownload("att-1", "not-a-number", q.Get("sig")))
+
+	svc.now = func() time.Time { return now.Add(2 * time.Hour) }
+	assert.False(t, svc.VerifyDownload("att-1", q.Get("expires"), q.Get("sig")))
+}
+
+func TestAttachmentService_PurgeExpired(t *testing.T) {
+	repo := new(mockAttachmentRepo)
+	svc, store := newTestAttachmentService(t, repo, 1024)
+	ctx := context.Background()
+
+	key := "attachments/acc-1/old"
+	require.NoError(t, store.Put(ctx, key, strings.NewReader("x"), 1, ""))
+
+	repo.On("FindCreatedBefore", ctx, mock.Anything, attachmentPurgeBatch).Return([]model.Attachment{
+		{ID: "old", StorageKey: &key},
+		{ID: "never-stored"},
+	}, nil)
+	repo.On("Delete", ctx, "old").Return(nil)
+	repo.On("Delete", ctx, "never-stored").Return(nil)
+
+	count, err := svc.PurgeExpired(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	repo.AssertExpectations(t)
+
+	_, _, err = store.Get(ctx, key)
+	assert.ErrorIs(t, err, storage.ErrNotFound)
+}
+
+func TestIsKakaoMediaHost(t *testing.T) {
+	for host, want := range map[string]bool{
+		"k.kakaocdn.net":        true,
+		"talk.kakao.com":        true,
+		"KAKAOCDN.NET.evil.com": false,
+		"evil-kakaocdn.net":     false,
+		"localhost":             false,
+	} {
+		assert.Equal(t, want, IsKakaoMediaHost(host), fmt.Sprintf("host %q", host))
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"os"
+	"strings"
+)
+
+type callbackRuleKind int
+
+const (
+	callbackRuleExact callbackRuleKind = iota
+	callbackRuleSuffix
+	callbackRuleCIDR
+)
+
+type callbackRule struct {
+	kind   callbackRuleKind
+	host   string
+	prefix netip.Prefix
+}
+
+// CallbackPolicy decides which callback URLs the relay may POST to.
+//
+// Rules:
+//   - "api.kakao.com"  exact host
+//   - ".kakao.com" or "*.kakao.com"  any subdomain
+//   - "10.20.0.0/16"  IP-literal hosts inside the range
+type CallbackPolicy struct {
+	rules                  []callbackRule
+	allowInsecureLocalhost bool
+}
+
+func NewCallbackPolicy(specs []string, allowInsecureLocalhost bool) (*CallbackPolicy, error) {
+	p := &CallbackPolicy{allowInsecureLocalhost: allowInsecureLocalhost}
+	for _, spec := range specs {
+		spec = strings.ToLower(strings.TrimSpace(spec))
+		if spec == "" {
+			continue
+		}
+
+		switch {
+		case strings.Contains(spec, "/"):
+			prefix, err := netip.ParsePrefix(spec)
+			if err != nil {
+				return nil, fmt.Errorf("invalid callback CIDR rule %q: %w", spec, err)
+			}
+			p.rules = append(p.rules, callbackRule{kind: callbackRuleCIDR, prefix: prefix.Masked()})
+		case strings.HasPrefix(spec, "*."):
+			p.rules = append(p.rules, callbackRule{kind: callbackRuleSuffix, host: spec[1:]})
+		case strings.HasPrefix(spec, "."):
+			p.rules = append(p.rules, callbackRule{kind: callbackRuleSuffix, host: spec})
+		default:
+			p.rules = append(p.rules, callbackRule{kind: callbackRuleExact, host: spec})
+		}
+	}
+	return p, nil
+}
+
+// LoadCallbackRules reads one rule per line from path; blank lines and
+// lines starting with # are ignored.
+func LoadCallbackRules(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open callback allowlist: %w", err)
+	}
+	defer f.Close()
+
+	var specs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		specs = append(specs, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read callback allowlist: %w", err)
+	}
+	return specs, nil
+}
+
+func (p *CallbackPolicy) Allows(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	hostname := strings.ToLower(parsed.Hostname())
+	if hostname == "" {
+		return false
+	}
+
+	switch parsed.Scheme {
+	case "https":
+	case "http":
+		// Dev-only escape hatch for pointing callbacks at a local Kakao stub.
+		return p.allowInsecureLocalhost && isLoopbackHost(hostname)
+	default:
+		return false
+	}
+
+	if p.allowInsecureLocalhost && isLoopbackHost(hostname) {
+		return true
+	}
+
+	addr, addrErr := netip.ParseAddr(hostname)
+	for _, rule := range p.rules {
+		switch rule.kind {
+		case callbackRuleExact:
+			if hostname == rule.host {
+				return true
+			}
+		case callbackRuleSuffix:
+			if strings.HasSuffix(hostname, rule.host) {
+				return true
+			}
+		case callbackRuleCIDR:
+			if addrErr == nil && rule.prefix.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isLoopbackHost(hostname string) bool {
+	if hostname == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(hostname)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const (
+	maxChannelDisplayNameLen = 100
+	// MaxChannelCallbackTTLSeconds is Kakao's limit for answering through a
+	// callback URL.
+	MaxChannelCallbackTTLSeconds = 60
+)
+
+var (
+	ErrInvalidChannel  = errors.New("invalid channel")
+	ErrChannelNotFound = errors.New("channel not found")
+)
+
+// channelIDPattern keeps bot IDs usable in conversation keys and webhook
+// paths.
+var channelIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+// ChannelDefinition is a channel as written through the dashboard. A nil
+// SignatureSecret keeps the stored one and an empty one clears it; empty
+// CallbackTTLSeconds and DefaultLocale use the global configuration.
+type ChannelDefinition struct {
+	DisplayName        string  `json:"displayName"`
+	SignatureSecret    *string `json:"signatureSecret"`
+	CallbackTTLSeconds *int    `json:"callbackTtlSeconds"`
+	Enabled            *bool   `json:"enabled"`
+	DefaultLocale      *string `json:"defaultLocale"`
+}
+
+// ChannelService manages the registry of Kakao bots served by the relay.
+type ChannelService struct {
+	repo          repository.ChannelRepository
+	catalog       *i18n.Catalog
+	encryptionKey string
+}
+
+// NewChannelService creates the service. Signature secrets are encrypted at
+// rest when encryptionKey is set.
+func NewChannelService(repo repository.ChannelRepository, catalog *i18n.Catalog, encryptionKey string) *ChannelService {
+	return &ChannelService{repo: repo, catalog: catalog, encryptionKey: encryptionKey}
+}
+
+func (s *ChannelService) List(ctx context.Context) ([]model.Channel, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Get returns the registered channel, or nil if the bot is not registered.
+func (s *ChannelService) Get(ctx context.Context, id string) (*model.Channel, error) {
+	if id == "" {
+		return nil, nil
+	}
+	return s.repo.FindByID(ctx, id)
+}
+
+// Put creates the channel or replaces its settings. Validation failures wrap
+// ErrInvalidChannel.
+func (s *ChannelService) Put(ctx context.Context, id string, def ChannelDefinition) (*model.Channel, error) {
+	if !channelIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("%w: id must be 1-100 letters, digits, '.', '_' or '-'", ErrInvalidChannel)
+	}
+
+	params := model.UpsertChannelParams{
+		ID:                  id,
+		DisplayName:         strings.TrimSpace(def.DisplayName),
+		KeepSignatureSecret: def.SignatureSecret == nil,
+		CallbackTTLSeconds:  def.CallbackTTLSeconds,
+		Enabled:             def.Enabled == nil || *def.Enabled,
+	}
+	if utf8.RuneCountInString(params.DisplayName) > maxChannelDisplayNameLen {
+		return nil, fmt.Errorf("%w: displayName must be at most %d characters", ErrInvalidChannel, maxChannelDisplayNameLen)
+	}
+	if ttl := params.CallbackTTLSeconds; ttl != nil && (*ttl < 1 || *ttl > MaxChannelCallbackTTLSeconds) {
+		return nil, fmt.Errorf("%w: callbackTtlSeconds must be between 1 and %d", ErrInvalidChannel, MaxChannelCallbackTTLSeconds)
+	}
+	if def.DefaultLocale != nil && *def.DefaultLocale != "" {
+		locale := s.catalog.NormalizeLocale(*def.DefaultLocale)
+		if locale == "" {
+			return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidChannel, *def.DefaultLocale)
+		}
+		params.DefaultLocale = &locale
+	}
+
+	if secret := def.SignatureSecret; secret != nil && *secret != "" {
+		stored := *secret
+		if s.encryptionKey != "" {
+			encrypted, err := util.Encrypt(s.encryptionKey, stored)
+			if err != nil {
+				return nil, fmt.Errorf("encrypt signature secret: %w", err)
+			}
+			stored = encrypted
+			params.SignatureSecretEncrypted = true
+		}
+		params.SignatureSecret = &stored
+	}
+
+	return s.repo.Upsert(ctx, params)
+}
+
+func (s *ChannelService) Delete(ctx context.Context, id string) error {
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrChannelNotFound
+	}
+	return nil
+}
+
+// SignatureSecret returns the channel's own webhook signature secret, or ""
+// if it uses the global one.
+func (s *ChannelService) SignatureSecret(channel *model.Channel) (string, error) {
+	if !channel.HasSignatureSecret() {
+		return "", nil
+	}
+	if !channel.SignatureSecretEncrypted {
+		return *channel.SignatureSecret, nil
+	}
+	if s.encryptionKey == "" {
+		return "", fmt.Errorf("channel %s: signature secret is encrypted but ENCRYPTION_KEY is not set", channel.ID)
+	}
+	secret, err := util.Decrypt(s.encryptionKey, *channel.SignatureSecret)
+	if err != nil {
+		return "", fmt.Errorf("channel %s: decrypt signature secret: %w", channel.ID, err)
+	}
+	return secret, nil
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+const testEncryptionKey = "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f"
+
+type mockChannelRepo struct {
+	repository.ChannelRepository
+	mock.Mock
+}
+
+func (m *mockChannelRepo) Upsert(ctx context.Context, params model.UpsertChannelParams) (*model.Channel, error) {
+	args := m.Called(ctx, params)
+	channel, _ := args.Get(0).(*model.Channel)
+	return channel, args.Error(1)
+}
+
+func (m *mockChannelRepo) FindByID(ctx context.Context, id string) (*model.Channel, error) {
+	args := m.Called(ctx, id)
+	channel, _ := args.Get(0).(*model.Channel)
+	return channel, args.Error(1)
+}
+
+func TestChannelService_Put(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("encrypts the secret and normalizes the locale", func(t *testing.T) {
+		repo := new(mockChannelRepo)
+		svc := NewChannelService(repo, i18n.NewCatalog(), testEncryptionKey)
+
+		var params model.UpsertChannelParams
+		repo.On("Upsert", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			params = args.Get(1).(model.UpsertChannelParams)
+		}).Return(&model.Channel{ID: "bot-a"}, nil)
+
+		ttl := 30
+		_, err := svc.Put(ctx, "bot-a", ChannelDefinition{
+			DisplayName:        " 상담봇 ",
+			SignatureSecret:    strPtr("s3cret"),
+			CallbackTTLSeconds: &ttl,
+			DefaultLocale:      strPtr("en-US"),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "상담봇", params.DisplayName)
+		assert.True(t, params.Enabled)
+		assert.False(t, params.KeepSignatureSecret)
+		assert.True(t, params.SignatureSecretEncrypted)
+		assert.NotEqual(t, "s3cret", *params.SignatureSecret)
+		assert.Equal(t, "en", *params.DefaultLocale)
+
+		secret, err := svc.SignatureSecret(&model.Channel{
+			SignatureSecret:          params.SignatureSecret,
+			SignatureSecretEncrypted: true,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "s3cret", secret)
+	})
+
+	t.Run("keeps the stored secret when none is given", func(t *testing.T) {
+		repo := new(mockChannelRepo)
+		svc := NewChannelService(repo, i18n.NewCatalog(), "")
+		repo.On("Upsert", ctx, model.UpsertChannelParams{
+			ID:                  "bot-a",
+			KeepSignatureSecret: true,
+			Enabled:             true,
+		}).Return(&model.Channel{ID: "bot-a"}, nil)
+
+		_, err := svc.Put(ctx, "bot-a", ChannelDefinition{})
+
+		require.NoError(t, err)
+		repo.AssertExpectations(t)
+	})
+
+	tooLongTTL := MaxChannelCallbackTTLSeconds + 1
+	invalid := map[string]struct {
+		id  string
+		def ChannelDefinition
+	}{
+		"id with a separator":               {id: "bot:a"},
+		"long display name":                 {id: "bot-a", def: ChannelDefinition{DisplayName: strings.Repeat("가", 101)}},
+		"callback ttl over the Kakao limit": {id: "bot-a", def: ChannelDefinition{CallbackTTLSeconds: &tooLongTTL}},
+		"unsupported locale":                {id: "bot-a", def: ChannelDefinition{DefaultLocale: strPtr("xx")}},
+	}
+	for name, tc := range invalid {
+		t.Run("rejects "+name, func(t *testing.T) {
+			repo := new(mockChannelRepo)
+			svc := NewChannelService(repo, i18n.NewCatalog(), "")
+
+			_, err := svc.Put(ctx, tc.id, tc.def)
+
+			assert.ErrorIs(t, err, ErrInvalidChannel)
+			repo.AssertNotCalled(t, "Upsert", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestChannelService_SignatureSecret(t *testing.T) {
+	svc := NewChannelService(new(mockChannelRepo), i18n.NewCatalog(), "")
+
+	secret, err := svc.SignatureSecret(&model.Channel{})
+	require.NoError(t, err)
+	assert.Empty(t, secret, "channels without a secret use the global one")
+
+	secret, err = svc.SignatureSecret(&model.Channel{SignatureSecret: strPtr("plain")})
+	require.NoError(t, err)
+	assert.Equal(t, "plain", secret)
+
+	_, err = svc.SignatureSecret(&model.Channel{ID: "bot-a", SignatureSecret: strPtr("ciphertext"), SignatureSecretEncrypted: true})
+	assert.Error(t, err, "encrypted secrets need ENCRYPTION_KEY")
+}
+
+func TestLocalizationService_ChannelDefaultLocale(t *testing.T) {
+	ctx := context.Background()
+	channels := new(mockChannelRepo)
+	svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), new(mockConversationRepo), channels,
+		LocalizationConfig{DefaultLocale: "ko", ChannelLocales: map[string]string{"bot-a": "ko"}})
+	channels.On("FindByID", ctx, "bot-a").Return(&model.Channel{ID: "bot-a", DefaultLocale: strPtr("en")}, nil)
+
+	l := svc.ForConversation(ctx, &model.ConversationMapping{KakaoChannelID: "bot-a"})
+
+	assert.Equal(t, "en", l.Locale(), "the registry wins over CHANNEL_LOCALES")
+}
+
+func TestLocalizationService_ChannelLookups(t *testing.T) {
+	ctx := context.Background()
+	channels := new(mockChannelRepo)
+	svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), new(mockConversationRepo), channels,
+		LocalizationConfig{DefaultLocale: "ko"})
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+	conv := &model.ConversationMapping{KakaoChannelID: "bot-a"}
+
+	l := svc.ForConversationInChannel(ctx, conv, &model.Channel{ID: "bot-a", DefaultLocale: strPtr("en")})
+	assert.Equal(t, "en", l.Locale())
+	channels.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+
+	channels.On("FindByID", ctx, "bot-a").Return(&model.Channel{ID: "bot-a", DefaultLocale: strPtr("en")}, nil)
+	for range 3 {
+		assert.Equal(t, "en", svc.ForConversation(ctx, conv).Locale())
+	}
+	channels.AssertNumberOfCalls(t, "FindByID", 1)
+
+	now = now.Add(channelCacheTTL)
+	svc.ForConversation(ctx, conv)
+	channels.AssertNumberOfCalls(t, "FindByID", 2)
+}
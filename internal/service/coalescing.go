@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+// MaxCoalesceWindow caps the per-account coalescing window. Utterances
+// wait up to three windows, but never past coalesceDeadlineMargin before
+// the earliest callback in the group expires.
+const MaxCoalesceWindow = 10 * time.Second
+
+// coalesceDeadlineMargin is how long before the earliest callback of a
+// coalescing group expires the group is delivered at the latest, so the
+// client still has time to answer through the callback. Channels with a
+// callback TTL shorter than this deliver each utterance right away.
+const coalesceDeadlineMargin = heldDeadlineMargin
+
+// coalesceFlushTimeout bounds one scheduled flush.
+const coalesceFlushTimeout = 10 * time.Second
+
+var ErrInvalidCoalesceWindow = errors.New("coalesce window out of range")
+
+// CallbackSender posts a response to a Kakao callback URL. *KakaoService
+// implements it.
+type CallbackSender interface {
+	SendCallback(ctx context.Context, callbackURL string, payload any) error
+}
+
+// ConversationLocalizer picks the localizer for bot messages in a
+// conversation. *LocalizationService implements it.
+type ConversationLocalizer interface {
+	ForConversationKey(ctx context.Context, conversationKey string) *i18n.Localizer
+}
+
+var (
+	_ CallbackSender        = (*KakaoService)(nil)
+	_ ConversationLocalizer = (*LocalizationService)(nil)
+)
+
+// CoalescingService merges utterances a user sends in quick succession
+// into one message event. Each utterance is stored and answered on its own
+// webhook as usual, but is kept back until no further utterance arrives
+// for the account's window. The waiting utterances are then merged into
+// the latest one that still has a callback, and the event lists all their
+// IDs; the others are settled as coalesced into it and their still-open
+// callbacks get a short notice, since the reply only goes to the primary.
+type CoalescingService struct {
+	accounts    repository.AccountRepository
+	inboundRepo repository.InboundMessageRepository
+	publisher   EventPublisher
+	attachments AttachmentLinker
+	callbacks   CallbackSender
+	localizer   ConversationLocalizer
+	now         func() time.Time
+}
+
+func NewCoalescingService(
+	accounts repository.AccountRepository,
+	inboundRepo repository.InboundMessageRepository,
+	publisher EventPublisher,
+	attachments AttachmentLinker,
+	callbacks CallbackSender,
+	localizer ConversationLocalizer,
+) *CoalescingService {
+	return &CoalescingService{
+		accounts:    accounts,
+		inboundRepo: inboundRepo,
+		publisher:   publisher,
+		attachments: attachments,
+		callbacks:   callbacks,
+		localizer:   localizer,
+		now:         time.Now,
+	}
+}
+
+// Window is the account's coalescing window, or zero when it does not
+// coalesce.
+func (s *CoalescingService) Window(ctx context.Context, accountID string) (time.Duration, error) {
+	account, err := s.accounts.FindByID(ctx, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("find account: %w", err)
+	}
+	if account == nil {
+		return 0, nil
+	}
+	return time.Duration(account.CoalesceWindowMs) * time.Millisecond, nil
+}
+
+// SetWindow changes the account's coalescing window. Zero turns
+// coalescing off; utterances already waiting are still merged.
+func (s *CoalescingService) SetWindow(ctx context.Context, accountID string, window time.Duration) (*model.Account, error) {
+	if window < 0 || window > MaxCoalesceWindow {
+		return nil, ErrInvalidCoalesceWindow
+	}
+
+	account, err := s.accounts.UpdateCoalesceWindow(ctx, accountID, int(window.Milliseconds()))
+	if err != nil {
+		return nil, fmt.Errorf("update coalesce window: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// Schedule flushes the conversation once dueAt passes. The flush does
+// nothing if a later utterance extended the window; that utterance's own
+// schedule covers it. FlushDue catches flushes lost with a replica.
+func (s *CoalescingService) Schedule(accountID, conversationKey string, dueAt time.Time) {
+	time.AfterFunc(time.Until(dueAt), func() {
+		ctx, cancel := context.WithTimeout(context.Background(), coalesceFlushTimeout)
+		defer cancel()
+
+		if err := s.Flush(ctx, accountID, conversationKey, false); err != nil {
+			log.Warn().Err(err).
+				Str("accountId", accountID).
+				Str("conversationKey", conversationKey).
+				Msg("failed to flush coalesced messages")
+		}
+	})
+}
+
+// Flush merges and delivers the conversation's waiting utterances once
+// their window has closed, or right away when force is set.
+func (s *CoalescingService) Flush(ctx context.Context, accountID, conversationKey string, force bool) error {
+	waiting, err := s.inboundRepo.FindCoalescing(ctx, accountID, conversationKey)
+	if err != nil {
+		return fmt.Errorf("find coalescing messages: %w", err)
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+
+	now := s.now()
+	if !force {
+		for i := range waiting {
+			if waiting[i].CoalesceDueAt.After(now) {
+				return nil
+			}
+		}
+	}
+
+	ids := make([]string, len(waiting))
+	for i := range waiting {
+		ids[i] = waiting[i].ID
+	}
+	params := model.CompleteCoalescingParams{
+		AccountID:       accountID,
+		ConversationKey: conversationKey,
+		IDs:             ids,
+		PrimaryID:       coalescePrimary(waiting, now).ID,
+	}
+	if len(waiting) > 1 {
+		params.Merged, err = mergeNormalized(waiting)
+		if err != nil {
+			return fmt.Errorf("merge messages: %w", err)
+		}
+	}
+
+	msg, err := s.inboundRepo.CompleteCoalescing(ctx, params)
+	if err != nil {
+		return fmt.Errorf("complete coalescing: %w", err)
+	}
+	if msg == nil {
+		return nil
+	}
+
+	log.Info().
+		Str("messageId", msg.ID).
+		Str("accountId", accountID).
+		Str("conversationKey", conversationKey).
+		Int("count", len(ids)).
+		Msg("utterances coalesced")
+
+	// A held message is published when the ordering service releases it.
+	if !msg.Held {
+		publishInbound(ctx, s.publisher, s.attachments, []model.InboundMessage{*msg})
+	}
+	s.answerMerged(ctx, conversationKey, waiting, params.PrimaryID, now)
+	return nil
+}
+
+// answerMerged answers the open callbacks of the utterances merged into
+// the primary one. Their webhooks were answered with useCallback, and the
+// reply to the merged event only uses the primary's callback.
+func (s *CoalescingService) answerMerged(ctx context.Context, conversationKey string, waiting []model.InboundMessage, primaryID string, now time.Time) {
+	if s.callbacks == nil || s.localizer == nil {
+		return
+	}
+
+	var payload map[string]any
+	for i := range waiting {
+		msg := &waiting[i]
+		if msg.ID == primaryID || !callbackOpen(msg, now) {
+			continue
+		}
+		if payload == nil {
+			text := s.localizer.ForConversationKey(ctx, conversationKey).T(i18n.MsgCoalesced)
+			payload = map[string]any{
+				"version": "2.0",
+				"template": map[string]any{
+					"outputs": []any{map[string]any{"simpleText": map[string]any{"text": text}}},
+				},
+			}
+		}
+		if err := s.callbacks.SendCallback(ctx, *msg.CallbackURL, payload); err != nil {
+			log.Warn().Err(err).Str("messageId", msg.ID).Msg("failed to answer coalesced message callback")
+		}
+	}
+}
+
+// FlushDue flushes every conversation whose window has closed. It runs
+// periodically as a backstop for Schedule.
+func (s *CoalescingService) FlushDue(ctx context.Context) (int64, error) {
+	refs, err := s.inboundRepo.FindDueCoalescing(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("find due coalescing: %w", err)
+	}
+
+	var flushed int64
+	for _, ref := range refs {
+		if err := s.Flush(ctx, ref.AccountID, ref.ConversationKey, false); err != nil {
+			log.Warn().Err(err).Str("conversationKey", ref.ConversationKey).Msg("failed to flush coalesced messages")
+			continue
+		}
+		flushed++
+	}
+	return flushed, nil
+}
+
+// coalescePrimary picks the message the merged event is delivered as: the
+// latest one whose callback is still open, so the reply uses the freshest
+// callback, or the latest one when none has a callback.
+func coalescePrimary(waiting []model.InboundMessage, now time.Time) *model.InboundMessage {
+	for i := len(waiting) - 1; i >= 0; i-- {
+		if callbackOpen(&waiting[i], now) {
+			return &waiting[i]
+		}
+	}
+	return &waiting[len(waiting)-1]
+}
+
+func callbackOpen(msg *model.InboundMessage, now time.Time) bool {
+	return msg.CallbackURL != nil && (msg.CallbackExpiresAt == nil || msg.CallbackExpiresAt.After(now))
+}
+
+// mergeNormalized combines the normalized messages of the waiting
+// utterances: the last one's fields, with the texts joined by newlines and
+// all attachments in order.
+func mergeNormalized(waiting []model.InboundMessage) (json.RawMessage, error) {
+	var merged model.NormalizedMessage
+	var texts []string
+	var attachments []model.NormalizedAttachment
+	for i := range waiting {
+		if waiting[i].NormalizedMessage == nil {
+			continue
+		}
+		var n model.NormalizedMessage
+		if err := json.Unmarshal(*waiting[i].NormalizedMessage, &n); err != nil {
+			return nil, err
+		}
+		if n.Text != "" {
+			texts = append(texts, n.Text)
+		}
+		attachments = append(attachments, n.Attachments...)
+		merged = n
+	}
+
+	merged.Text = strings.Join(texts, "\n")
+	merged.Attachments = attachments
+	return json.Marshal(merged)
+}
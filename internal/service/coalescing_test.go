@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type recordingCallbacks struct {
+	urls     []string
+	payloads []any
+}
+
+func (c *recordingCallbacks) SendCallback(ctx context.Context, callbackURL string, payload any) error {
+	c.urls = append(c.urls, callbackURL)
+	c.payloads = append(c.payloads, payload)
+	return nil
+}
+
+type englishLocalizer struct{}
+
+func (englishLocalizer) ForConversationKey(ctx context.Context, conversationKey string) *i18n.Localizer {
+	return i18n.NewLocalizer(i18n.NewCatalog(), "en", nil)
+}
+
+func waitingUtterance(id, text string, dueAt time.Time, callback bool) model.InboundMessage {
+	normalized := json.RawMessage(`{"version":1,"text":"` + text + `","attachments":[{"type":"image","url":"https://example.com/` + id + `"}]}`)
+	msg := model.InboundMessage{
+		ID:                id,
+		AccountID:         "acc-1",
+		ConversationKey:   "conv-1",
+		Status:            model.InboundStatusQueued,
+		NormalizedMessage: &normalized,
+		CoalesceDueAt:     &dueAt,
+	}
+	if callback {
+		url := "https://callback.example.com/" + id
+		expires := dueAt.Add(time.Minute)
+		msg.CallbackURL, msg.CallbackExpiresAt = &url, &expires
+	}
+	return msg
+}
+
+func TestCoalescingService_Flush(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+
+	t.Run("waits while the window is open", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewCoalescingService(new(mockAccountRepo), repo, publisher, nil, nil, nil)
+		svc.now = func() time.Time { return now }
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			waitingUtterance("m1", "안녕", now.Add(-time.Second), true),
+			waitingUtterance("m2", "질문이 있어요", now.Add(time.Second), true),
+		}, nil)
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+
+		repo.AssertNotCalled(t, "CompleteCoalescing", mock.Anything, mock.Anything)
+		assert.Empty(t, publisher.events)
+	})
+
+	t.Run("merges into the latest utterance with a callback", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewCoalescingService(new(mockAccountRepo), repo, publisher, nil, nil, nil)
+		svc.now = func() time.Time { return now }
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			waitingUtterance("m1", "안녕", now.Add(-3*time.Second), true),
+			waitingUtterance("m2", "질문이 있어요", now.Add(-2*time.Second), true),
+			waitingUtterance("m3", "", now.Add(-time.Second), false),
+		}, nil)
+
+		var params model.CompleteCoalescingParams
+		merged := json.RawMessage(`{"text":"안녕\n질문이 있어요"}`)
+		repo.On("CompleteCoalescing", ctx, mock.Anything).Run(func(args mock.Arguments) {
+			params = args.Get(1).(model.CompleteCoalescingParams)
+		}).Return(&model.InboundMessage{
+			ID:               "m2",
+			AccountID:        "acc-1",
+			ConversationKey:  "conv-1",
+			CoalescedIDs:     []string{"m1", "m2", "m3"},
+			CoalescedMessage: &merged,
+		}, nil)
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+
+		assert.Equal(t, []string{"m1", "m2", "m3"}, params.IDs)
+		assert.Equal(t, "m2", params.PrimaryID, "m3 has no callback")
+
+		var normalized model.NormalizedMessage
+		require.NoError(t, json.Unmarshal(params.Merged, &normalized))
+		assert.Equal(t, "안녕\n질문이 있어요", normalized.Text)
+		assert.Len(t, normalized.Attachments, 3)
+
+		events := publisher.events["acc-1"]
+		require.Len(t, events, 1)
+		assert.Equal(t, "m2", events[0].ID)
+		var data map[string]any
+		require.NoError(t, json.Unmarshal(events[0].Data, &data))
+		assert.Equal(t, []any{"m1", "m2", "m3"}, data["messageIds"])
+	})
+
+	t.Run("answers the open callbacks of the merged utterances", func(t *testing.T) {
+		repo, callbacks := new(mockInboundRepo), &recordingCallbacks{}
+		svc := NewCoalescingService(new(mockAccountRepo), repo, &recordingPublisher{}, nil, callbacks, englishLocalizer{})
+		svc.now = func() time.Time { return now }
+		expired := waitingUtterance("m1", "안녕", now.Add(-3*time.Second), true)
+		past := now.Add(-time.Second)
+		expired.CallbackExpiresAt = &past
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			expired,
+			waitingUtterance("m2", "질문이 있어요", now.Add(-2*time.Second), true),
+			waitingUtterance("m3", "", now.Add(-time.Second), false),
+			waitingUtterance("m4", "급해요", now.Add(-time.Second), true),
+		}, nil)
+		repo.On("CompleteCoalescing", ctx, mock.Anything).Return(&model.InboundMessage{ID: "m4", AccountID: "acc-1"}, nil)
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+
+		assert.Equal(t, []string{"https://callback.example.com/m2"}, callbacks.urls,
+			"the primary is answered by the reply, m1's callback expired and m3 has none")
+		body, err := json.Marshal(callbacks.payloads[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(body), "together with your next message")
+	})
+
+	t.Run("delivers a single utterance unmerged", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewCoalescingService(new(mockAccountRepo), repo, publisher, nil, nil, nil)
+		svc.now = func() time.Time { return now }
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			waitingUtterance("m1", "안녕", now.Add(-time.Second), true),
+		}, nil)
+		repo.On("CompleteCoalescing", ctx, model.CompleteCoalescingParams{
+			AccountID:       "acc-1",
+			ConversationKey: "conv-1",
+			IDs:             []string{"m1"},
+			PrimaryID:       "m1",
+		}).Return(&model.InboundMessage{ID: "m1", AccountID: "acc-1"}, nil)
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+
+		assert.Len(t, publisher.events["acc-1"], 1)
+	})
+
+	t.Run("force flushes an open window", func(t *testing.T) {
+		repo := new(mockInboundRepo)
+		svc := NewCoalescingService(new(mockAccountRepo), repo, &recordingPublisher{}, nil, nil, nil)
+		svc.now = func() time.Time { return now }
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			waitingUtterance("m1", "안녕", now.Add(time.Second), true),
+		}, nil)
+		repo.On("CompleteCoalescing", ctx, mock.Anything).Return(&model.InboundMessage{ID: "m1", AccountID: "acc-1"}, nil)
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", true))
+
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("publishes nothing when the group changed or is held", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewCoalescingService(new(mockAccountRepo), repo, publisher, nil, nil, nil)
+		svc.now = func() time.Time { return now }
+		repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+			waitingUtterance("m1", "안녕", now.Add(-time.Second), true),
+		}, nil)
+		repo.On("CompleteCoalescing", ctx, mock.Anything).Return(nil, nil).Once()
+		repo.On("CompleteCoalescing", ctx, mock.Anything).Return(&model.InboundMessage{ID: "m1", Held: true}, nil).Once()
+
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+		require.NoError(t, svc.Flush(ctx, "acc-1", "conv-1", false))
+
+		assert.Empty(t, publisher.events)
+	})
+}
+
+func TestCoalescingService_FlushDue(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+	svc := NewCoalescingService(new(mockAccountRepo), repo, publisher, nil, nil, nil)
+	svc.now = func() time.Time { return now }
+
+	repo.On("FindDueCoalescing", ctx).Return([]model.ConversationRef{{AccountID: "acc-1", ConversationKey: "conv-1"}}, nil)
+	repo.On("FindCoalescing", ctx, "acc-1", "conv-1").Return([]model.InboundMessage{
+		waitingUtterance("m1", "안녕", now.Add(-time.Second), true),
+	}, nil)
+	repo.On("CompleteCoalescing", ctx, mock.Anything).Return(&model.InboundMessage{ID: "m1", AccountID: "acc-1"}, nil)
+
+	flushed, err := svc.FlushDue(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), flushed)
+	assert.Len(t, publisher.events["acc-1"], 1)
+}
+
+func TestCoalescingService_SetWindow(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores the window in milliseconds", func(t *testing.T) {
+		accounts := new(mockAccountRepo)
+		svc := NewCoalescingService(accounts, new(mockInboundRepo), &recordingPublisher{}, nil, nil, nil)
+		accounts.On("UpdateCoalesceWindow", ctx, "acc-1", 1500).
+			Return(&model.Account{ID: "acc-1", CoalesceWindowMs: 1500}, nil)
+
+		account, err := svc.SetWindow(ctx, "acc-1", 1500*time.Millisecond)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1500, account.CoalesceWindowMs)
+	})
+
+	t.Run("rejects windows out of range", func(t *testing.T) {
+		accounts := new(mockAccountRepo)
+		svc := NewCoalescingService(accounts, new(mockInboundRepo), &recordingPublisher{}, nil, nil, nil)
+
+		_, err := svc.SetWindow(ctx, "acc-1", MaxCoalesceWindow+time.Millisecond)
+		assert.ErrorIs(t, err, ErrInvalidCoalesceWindow)
+		_, err = svc.SetWindow(ctx, "acc-1", -time.Second)
+		assert.ErrorIs(t, err, ErrInvalidCoalesceWindow)
+		accounts.AssertNotCalled(t, "UpdateCoalesceWindow", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
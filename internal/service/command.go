@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+const (
+	MaxAccountCommands   = 30
+	maxCommandNameLen    = 32
+	maxCommandUsageLen   = 100
+	maxCommandDescLen    = 200
+	maxCommandAliasCount = 5
+)
+
+var ErrInvalidCommand = errors.New("invalid command")
+
+// CommandDefinition is a custom command as declared by an OpenClaw agent.
+type CommandDefinition struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Usage       string   `json:"usage,omitempty"`
+	Description string   `json:"description"`
+}
+
+type CommandService struct {
+	repo     repository.CommandRepository
+	reserved map[string]bool
+}
+
+// NewCommandService creates the service. reserved lists the built-in command
+// names and aliases that accounts may not override.
+func NewCommandService(repo repository.CommandRepository, reserved []string) *CommandService {
+	r := make(map[string]bool, len(reserved))
+	for _, name := range reserved {
+		r[NormalizeCommandName(name)] = true
+	}
+	return &CommandService{repo: repo, reserved: r}
+}
+
+// NormalizeCommandName lowercases a command name and adds the leading slash.
+func NormalizeCommandName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name != "" && !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name
+}
+
+func (s *CommandService) List(ctx context.Context, accountID string) ([]model.AccountCommand, error) {
+	return s.repo.FindByAccountID(ctx, accountID)
+}
+
+// Replace validates defs and replaces the account's whole command set.
+// Validation failures wrap ErrInvalidCommand.
+func (s *CommandService) Replace(ctx context.Context, accountID string, defs []CommandDefinition) ([]model.AccountCommand, error) {
+	if len(defs) > MaxAccountCommands {
+		return nil, fmt.Errorf("%w: at most %d commands allowed", ErrInvalidCommand, MaxAccountCommands)
+	}
+
+	seen := make(map[string]bool)
+	params := make([]model.CreateAccountCommandParams, 0, len(defs))
+	for _, def := range defs {
+		name := NormalizeCommandName(def.Name)
+		if err := s.validateName(name, seen); err != nil {
+			return nil, err
+		}
+		if len(def.Aliases) > maxCommandAliasCount {
+			return nil, fmt.Errorf("%w: %s has more than %d aliases", ErrInvalidCommand, name, maxCommandAliasCount)
+		}
+
+		aliases := make([]string, 0, len(def.Aliases))
+		for _, alias := range def.Aliases {
+			alias = NormalizeCommandName(alias)
+			if err := s.validateName(alias, seen); err != nil {
+				return nil, err
+			}
+			aliases = append(aliases, alias)
+		}
+
+		usage := strings.TrimSpace(def.Usage)
+		description := strings.TrimSpace(def.Description)
+		if utf8.RuneCountInString(usage) > maxCommandUsageLen {
+			return nil, fmt.Errorf("%w: usage of %s is too long", ErrInvalidCommand, name)
+		}
+		if utf8.RuneCountInString(description) > maxCommandDescLen {
+			return nil, fmt.Errorf("%w: description of %s is too long", ErrInvalidCommand, name)
+		}
+
+		params = append(params, model.CreateAccountCommandParams{
+			Name:        name,
+			Aliases:     aliases,
+			Usage:       usage,
+			Description: description,
+		})
+	}
+
+	commands, err := s.repo.ReplaceForAccount(ctx, accountID, params)
+	if err != nil {
+		return nil, fmt.Errorf("replace commands: %w", err)
+	}
+	return commands, nil
+}
+
+func (s *CommandService) validateName(name string, seen map[string]bool) error {
+	n := utf8.RuneCountInString(name)
+	if n < 2 || n > maxCommandNameLen {
+		return fmt.Errorf("%w: %q must be 1-%d characters after the slash", ErrInvalidCommand, name, maxCommandNameLen-1)
+	}
+	if strings.ContainsFunc(name, unicode.IsSpace) || strings.Contains(name[1:], "/") {
+		return fmt.Errorf("%w: %q must be a single word", ErrInvalidCommand, name)
+	}
+	if s.reserved[name] {
+		return fmt.Errorf("%w: %s is a built-in command", ErrInvalidCommand, name)
+	}
+	if seen[name] {
+		return fmt.Errorf("%w: %s is declared more than once", ErrInvalidCommand, name)
+	}
+	seen[name] = true
+	return nil
+}
+
+// Match returns the account command named or aliased by name, or nil.
+func (s *CommandService) Match(ctx context.Context, accountID, name string) (*model.AccountCommand, error) {
+	name = NormalizeCommandName(name)
+	commands, err := s.repo.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range commands {
+		if commands[i].Name == name {
+			return &commands[i], nil
+		}
+		for _, alias := range commands[i].Aliases {
+			if alias == name {
+				return &commands[i], nil
+			}
+		}
+	}
+	return nil, nil
+}
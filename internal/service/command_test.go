@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type mockCommandRepo struct {
+	mock.Mock
+}
+
+func (m *mockCommandRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.AccountCommand, error) {
+	args := m.Called(ctx, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AccountCommand), args.Error(1)
+}
+
+func (m *mockCommandRepo) ReplaceForAccount(ctx context.Context, accountID string, params []model.CreateAccountCommandParams) ([]model.AccountCommand, error) {
+	args := m.Called(ctx, accountID, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.AccountCommand), args.Error(1)
+}
+
+func TestCommandService_Replace(t *testing.T) {
+	ctx := context.Background()
+	reserved := []string{"/pair", "/연결", "/help"}
+
+	t.Run("normalizes and stores definitions", func(t *testing.T) {
+		repo := new(mockCommandRepo)
+		svc := NewCommandService(repo, reserved)
+
+		want := []model.CreateAccountCommandParams{{
+			Name:        "/order",
+			Aliases:     []string{"/주문"},
+			Usage:       "<메뉴> [수량]",
+			Description: "주문하기",
+		}}
+		repo.On("ReplaceForAccount", ctx, "acc-1", want).
+			Return([]model.AccountCommand{{Name: "/order"}}, nil)
+
+		commands, err := svc.Replace(ctx, "acc-1", []CommandDefinition{{
+			Name:        "ORDER",
+			Aliases:     []string{" /주문 "},
+			Usage:       "<메뉴> [수량]",
+			Description: " 주문하기 ",
+		}})
+
+		require.NoError(t, err)
+		assert.Len(t, commands, 1)
+		repo.AssertExpectations(t)
+	})
+
+	invalid := []struct {
+		name string
+		defs []CommandDefinition
+	}{
+		{"built-in name", []CommandDefinition{{Name: "/pair"}}},
+		{"built-in alias", []CommandDefinition{{Name: "/link", Aliases: []string{"/연결"}}}},
+		{"duplicate across commands", []CommandDefinition{{Name: "/a"}, {Name: "/b", Aliases: []string{"/a"}}}},
+		{"whitespace in name", []CommandDefinition{{Name: "/two words"}}},
+		{"nested slash", []CommandDefinition{{Name: "/a/b"}}},
+		{"empty name", []CommandDefinition{{Name: "/"}}},
+	}
+	for _, tc := range invalid {
+		t.Run("rejects "+tc.name, func(t *testing.T) {
+			repo := new(mockCommandRepo)
+			svc := NewCommandService(repo, reserved)
+
+			_, err := svc.Replace(ctx, "acc-1", tc.defs)
+
+			assert.ErrorIs(t, err, ErrInvalidCommand)
+			repo.AssertNotCalled(t, "ReplaceForAccount", mock.Anything, mock.Anything, mock.Anything)
+		})
+	}
+
+	t.Run("rejects too many commands", func(t *testing.T) {
+		svc := NewCommandService(new(mockCommandRepo), reserved)
+		defs := make([]CommandDefinition, MaxAccountCommands+1)
+
+		_, err := svc.Replace(ctx, "acc-1", defs)
+
+		assert.ErrorIs(t, err, ErrInvalidCommand)
+	})
+}
+
+func TestCommandService_Match(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockCommandRepo)
+	svc := NewCommandService(repo, nil)
+	repo.On("FindByAccountID", ctx, "acc-1").Return([]model.AccountCommand{
+		{Name: "/order", Aliases: []string{"/주문"}},
+		{Name: "/menu"},
+	}, nil)
+
+	cmd, err := svc.Match(ctx, "acc-1", "/주문")
+	require.NoError(t, err)
+	assert.Equal(t, "/order", cmd.Name)
+
+	cmd, err = svc.Match(ctx, "acc-1", "/MENU")
+	require.NoError(t, err)
+	assert.Equal(t, "/menu", cmd.Name)
+
+	cmd, err = svc.Match(ctx, "acc-1", "/unknown")
+	require.NoError(t, err)
+	assert.Nil(t, cmd)
+}
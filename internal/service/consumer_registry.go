@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ConsumerRegistry tracks the live competing consumers of each account and
+// which consumer owns each conversation.
+type ConsumerRegistry interface {
+	// Heartbeat marks consumerID live until ttl elapses.
+	Heartbeat(ctx context.Context, accountID, consumerID string, ttl time.Duration) error
+	// Leave removes consumerID. Conversations it owned move to other
+	// consumers on their next Assign.
+	Leave(ctx context.Context, accountID, consumerID string) error
+	// Consumers lists the account's live consumers.
+	Consumers(ctx context.Context, accountID string) ([]string, error)
+	// Assign returns the consumer that owns the conversation. A live owner
+	// keeps it; otherwise candidate becomes the owner.
+	Assign(ctx context.Context, accountID, conversationKey, candidate string) (string, error)
+}
+
+// conversationAffinityTTL is how long an idle conversation stays bound to
+// its consumer. Each Assign extends it.
+const conversationAffinityTTL = time.Hour
+
+// assignScript keeps a conversation with its owner while the owner is live
+// and hands it to the candidate otherwise. Consumer liveness is the expiry
+// time stored as the member's score in the account's consumer set.
+var assignScript = redis.NewScript(`
+local consumers = KEYS[1]
+local affinity = KEYS[2]
+local candidate = ARGV[1]
+local now = tonumber(ARGV[2])
+local ttl = tonumber(ARGV[3])
+
+local owner = redis.call('GET', affinity)
+if owner then
+    local expiresAt = redis.call('ZSCORE', consumers, owner)
+    if expiresAt and tonumber(expiresAt) > now then
+        redis.call('PEXPIRE', affinity, ttl)
+        return owner
+    end
+end
+
+redis.call('SET', affinity, candidate, 'PX', ttl)
+return candidate
+`)
+
+// RedisConsumerRegistry is a ConsumerRegistry shared by all replicas.
+type RedisConsumerRegistry struct {
+	client *redis.Client
+}
+
+var _ ConsumerRegistry = (*RedisConsumerRegistry)(nil)
+
+func NewRedisConsumerRegistry(client *redis.Client) *RedisConsumerRegistry {
+	return &RedisConsumerRegistry{client: client}
+}
+
+func consumersKey(accountID string) string {
+	return fmt.Sprintf("consumers:%s", accountID)
+}
+
+func affinityKey(accountID, conversationKey string) string {
+	return fmt.Sprintf("affinity:%s:%s", accountID, conversationKey)
+}
+
+func (r *RedisConsumerRegistry) Heartbeat(ctx context.Context, accountID, consumerID string, ttl time.Duration) error {
+	key := consumersKey(accountID)
+	expiresAt := time.Now().Add(ttl).UnixMilli()
+
+	pipe := r.client.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(expiresAt), Member: consumerID})
+	pipe.Expire(ctx, key, ttl+conversationAffinityTTL)
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+func (r *RedisConsumerRegistry) Leave(ctx context.Context, accountID, consumerID string) error {
+	return r.client.ZRem(ctx, consumersKey(accountID), consumerID).Err()
+}
+
+func (r *RedisConsumerRegistry) Consumers(ctx context.Context, accountID string) ([]string, error) {
+	key := consumersKey(accountID)
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	if err := r.client.ZRemRangeByScore(ctx, key, "-inf", now).Err(); err != nil {
+		return nil, err
+	}
+	return r.client.ZRangeByScore(ctx, key, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+}
+
+func (r *RedisConsumerRegistry) Assign(ctx context.Context, accountID, conversationKey, candidate string) (string, error) {
+	return assignScript.Run(
+		ctx,
+		r.client,
+		[]string{consumersKey(accountID), affinityKey(accountID, conversationKey)},
+		candidate,
+		time.Now().UnixMilli(),
+		conversationAffinityTTL.Milliseconds(),
+	).Text()
+}
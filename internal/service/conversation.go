@@ -19,6 +19,9 @@ func NewConversationService(repo repository.ConversationRepository) *Conversatio
 	return &ConversationService{repo: repo}
 }
 
+// DefaultChannelID is used when a webhook does not carry a bot ID.
+const DefaultChannelID = "default"
+
 func BuildConversationKey(channelID, userKey string) string {
 	return fmt.Sprintf("%s:%s", channelID, userKey)
 }
@@ -71,6 +74,21 @@ func (s *ConversationService) Unpair(ctx context.Context, key string) error {
 	return s.repo.UpdateState(ctx, key, model.PairingStateUnpaired, nil)
 }
 
+// FindForAccount returns conversation id if it is attached to accountID,
+// in any state, or nil otherwise.
+func (s *ConversationService) FindForAccount(ctx context.Context, accountID, id string) (*model.ConversationMapping, error) {
+	conv, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if conv == nil || conv.AccountID == nil || *conv.AccountID != accountID {
+		return nil, nil
+	}
+	return conv, nil
+}
+
+// ListByAccountID returns every conversation attached to the account:
+// paired, awaiting approval or blocked.
 func (s *ConversationService) ListByAccountID(ctx context.Context, accountID string) ([]model.ConversationMapping, error) {
-	return s.repo.FindPairedByAccountID(ctx, accountID)
+	return s.repo.FindByAccountID(ctx, accountID)
 }
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+const (
+	MaxStateKeyLength  = 64
+	MaxStateValueBytes = 16 << 10
+	MaxStateTTL        = 90 * 24 * time.Hour
+	// MaxStateKeys caps the live keys an account keeps per conversation.
+	MaxStateKeys = 100
+	// MaxStateEventBytes caps the keys and values attached to one event.
+	MaxStateEventBytes = 64 << 10
+)
+
+var stateKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_.:-]+$`)
+
+var (
+	ErrInvalidConversationState = errors.New("invalid conversation state")
+	ErrStateNotFound            = errors.New("conversation state not found")
+	ErrStateVersionConflict     = errors.New("conversation state version conflict")
+)
+
+type PutConversationStateRequest struct {
+	Value json.RawMessage `json:"value"`
+	// TTLSeconds expires the key; 0 keeps it until deleted or the
+	// conversation leaves the account.
+	TTLSeconds int `json:"ttlSeconds"`
+	// Version makes the write conditional: 0 creates the key only if it
+	// does not exist, any other value replaces it only at that version.
+	Version *int64 `json:"version"`
+}
+
+// ConversationStateService is a key-value store each account keeps per
+// conversation, for agents that hold no state of their own. Writes need the
+// conversation to be paired with the account; LifecycleService deletes the
+// keys when it leaves.
+type ConversationStateService struct {
+	repo  repository.ConversationStateRepository
+	convs repository.ConversationRepository
+	now   func() time.Time
+}
+
+func NewConversationStateService(repo repository.ConversationStateRepository, convs repository.ConversationRepository) *ConversationStateService {
+	return &ConversationStateService{repo: repo, convs: convs, now: time.Now}
+}
+
+func (s *ConversationStateService) List(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error) {
+	states, err := s.repo.FindByConversation(ctx, accountID, conversationKey)
+	if err != nil {
+		return nil, fmt.Errorf("find conversation state: %w", err)
+	}
+	if states == nil {
+		states = []model.ConversationState{}
+	}
+	return states, nil
+}
+
+// Values returns the conversation's keys and values, as included in
+// message events for streams that ask for them. Keys are taken in order
+// until MaxStateEventBytes; truncated reports whether any were left out.
+func (s *ConversationStateService) Values(ctx context.Context, accountID, conversationKey string) (values map[string]json.RawMessage, truncated bool, err error) {
+	states, err := s.repo.FindByConversation(ctx, accountID, conversationKey)
+	if err != nil {
+		return nil, false, fmt.Errorf("find conversation state: %w", err)
+	}
+	values = make(map[string]json.RawMessage, len(states))
+	size := 0
+	for _, st := range states {
+		size += len(st.Key) + len(st.Value)
+		if size > MaxStateEventBytes {
+			return values, true, nil
+		}
+		values[st.Key] = st.Value
+	}
+	return values, false, nil
+}
+
+func (s *ConversationStateService) Get(ctx context.Context, accountID, conversationKey, key string) (*model.ConversationState, error) {
+	if err := validateStateKey(key); err != nil {
+		return nil, err
+	}
+	state, err := s.repo.Find(ctx, accountID, conversationKey, key)
+	if err != nil {
+		return nil, fmt.Errorf("find conversation state: %w", err)
+	}
+	if state == nil {
+		return nil, ErrStateNotFound
+	}
+	return state, nil
+}
+
+// Put writes a key of a conversation paired with the account. A
+// conditional write that lost a race fails with ErrStateVersionConflict, and
+// a new key beyond MaxStateKeys with ErrInvalidConversationState.
+func (s *ConversationStateService) Put(ctx context.Context, accountID, conversationKey, key string, req PutConversationStateRequest) (*model.ConversationState, error) {
+	if err := validateStateKey(key); err != nil {
+		return nil, err
+	}
+	value := bytes.TrimSpace(req.Value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil, fmt.Errorf("%w: value is required", ErrInvalidConversationState)
+	}
+	if !json.Valid(value) {
+		return nil, fmt.Errorf("%w: value must be JSON", ErrInvalidConversationState)
+	}
+	if len(value) > MaxStateValueBytes {
+		return nil, fmt.Errorf("%w: value must be at most %d bytes", ErrInvalidConversationState, MaxStateValueBytes)
+	}
+	if req.TTLSeconds < 0 || time.Duration(req.TTLSeconds)*time.Second > MaxStateTTL {
+		return nil, fmt.Errorf("%w: ttlSeconds must be between 0 and %d", ErrInvalidConversationState, int(MaxStateTTL.Seconds()))
+	}
+	if req.Version != nil && *req.Version < 0 {
+		return nil, fmt.Errorf("%w: version must not be negative", ErrInvalidConversationState)
+	}
+	if err := s.requirePaired(ctx, accountID, conversationKey); err != nil {
+		return nil, err
+	}
+
+	var expiresAt *time.Time
+	if req.TTLSeconds > 0 {
+		t := s.now().Add(time.Duration(req.TTLSeconds) * time.Second)
+		expiresAt = &t
+	}
+	state, err := s.repo.Put(ctx, model.PutConversationStateParams{
+		AccountID:       accountID,
+		ConversationKey: conversationKey,
+		Key:             key,
+		Value:           value,
+		ExpiresAt:       expiresAt,
+		IfVersion:       req.Version,
+		MaxKeys:         MaxStateKeys,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("put conversation state: %w", err)
+	}
+	if state == nil {
+		return nil, s.putRefused(ctx, accountID, conversationKey, key, req.Version)
+	}
+	return state, nil
+}
+
+// putRefused tells a version mismatch from a full conversation after the
+// repository refused a write.
+func (s *ConversationStateService) putRefused(ctx context.Context, accountID, conversationKey, key string, version *int64) error {
+	if version != nil && *version > 0 {
+		return ErrStateVersionConflict
+	}
+	if version != nil {
+		existing, err := s.repo.Find(ctx, accountID, conversationKey, key)
+		if err != nil {
+			return fmt.Errorf("find conversation state: %w", err)
+		}
+		if existing != nil {
+			return ErrStateVersionConflict
+		}
+	}
+	return fmt.Errorf("%w: conversation already has %d keys", ErrInvalidConversationState, MaxStateKeys)
+}
+
+// Delete removes a key; with version set, only if it is at that version.
+func (s *ConversationStateService) Delete(ctx context.Context, accountID, conversationKey, key string, version *int64) error {
+	if err := validateStateKey(key); err != nil {
+		return err
+	}
+	deleted, err := s.repo.Delete(ctx, accountID, conversationKey, key, version)
+	if err != nil {
+		return fmt.Errorf("delete conversation state: %w", err)
+	}
+	if deleted {
+		return nil
+	}
+	if version != nil {
+		existing, err := s.repo.Find(ctx, accountID, conversationKey, key)
+		if err != nil {
+			return fmt.Errorf("find conversation state: %w", err)
+		}
+		if existing != nil {
+			return ErrStateVersionConflict
+		}
+	}
+	return ErrStateNotFound
+}
+
+// PurgeExpired deletes keys past their TTL.
+func (s *ConversationStateService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx)
+}
+
+func (s *ConversationStateService) requirePaired(ctx context.Context, accountID, conversationKey string) error {
+	conv, err := s.convs.FindByKey(ctx, conversationKey)
+	if err != nil {
+		return fmt.Errorf("find conversation: %w", err)
+	}
+	if conv == nil || conv.State != model.PairingStatePaired || conv.AccountID == nil || *conv.AccountID != accountID {
+		return ErrConversationNotFound
+	}
+	return nil
+}
+
+func validateStateKey(key string) error {
+	if key == "" || len(key) > MaxStateKeyLength || !stateKeyPattern.MatchString(key) {
+		return fmt.Errorf("%w: key must be 1-%d letters, digits, '_', '-', '.' or ':'", ErrInvalidConversationState, MaxStateKeyLength)
+	}
+	return nil
+}
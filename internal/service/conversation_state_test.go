@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+type mockStateRepo struct {
+	repository.ConversationStateRepository
+	mock.Mock
+}
+
+func (m *mockStateRepo) Find(ctx context.Context, accountID, conversationKey, key string) (*model.ConversationState, error) {
+	args := m.Called(ctx, accountID, conversationKey, key)
+	state, _ := args.Get(0).(*model.ConversationState)
+	return state, args.Error(1)
+}
+
+func (m *mockStateRepo) FindByConversation(ctx context.Context, accountID, conversationKey string) ([]model.ConversationState, error) {
+	args := m.Called(ctx, accountID, conversationKey)
+	states, _ := args.Get(0).([]model.ConversationState)
+	return states, args.Error(1)
+}
+
+func (m *mockStateRepo) Put(ctx context.Context, params model.PutConversationStateParams) (*model.ConversationState, error) {
+	args := m.Called(ctx, params)
+	state, _ := args.Get(0).(*model.ConversationState)
+	return state, args.Error(1)
+}
+
+func (m *mockStateRepo) Delete(ctx context.Context, accountID, conversationKey, key string, ifVersion *int64) (bool, error) {
+	args := m.Called(ctx, accountID, conversationKey, key, ifVersion)
+	return args.Bool(0), args.Error(1)
+}
+
+var stateTestNow = time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+
+func int64Ptr(v int64) *int64 { return &v }
+
+func TestConversationStateService_Put(t *testing.T) {
+	repo, convs := new(mockStateRepo), new(mockConversationRepo)
+	svc := NewConversationStateService(repo, convs)
+	svc.now = func() time.Time { return stateTestNow }
+	ctx := context.Background()
+	convs.On("FindByKey", ctx, "bot:alice").Return(&model.ConversationMapping{
+		ConversationKey: "bot:alice",
+		AccountID:       strPtr("acc-1"),
+		State:           model.PairingStatePaired,
+	}, nil)
+
+	expiresAt := svc.now().Add(time.Hour)
+	repo.On("Put", ctx, model.PutConversationStateParams{
+		AccountID:       "acc-1",
+		ConversationKey: "bot:alice",
+		Key:             "form.step",
+		Value:           json.RawMessage(`{"step":2}`),
+		ExpiresAt:       &expiresAt,
+		IfVersion:       int64Ptr(1),
+		MaxKeys:         MaxStateKeys,
+	}).Return(&model.ConversationState{Key: "form.step", Value: json.RawMessage(`{"step":2}`), Version: 2}, nil).Once()
+
+	state, err := svc.Put(ctx, "acc-1", "bot:alice", "form.step", PutConversationStateRequest{
+		Value:      json.RawMessage(` {"step":2} `),
+		TTLSeconds: 3600,
+		Version:    int64Ptr(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), state.Version)
+
+	repo.On("Put", ctx, mock.Anything).Return(nil, nil).Once()
+	_, err = svc.Put(ctx, "acc-1", "bot:alice", "form.step", PutConversationStateRequest{
+		Value:   json.RawMessage(`{"step":3}`),
+		Version: int64Ptr(1),
+	})
+	assert.ErrorIs(t, err, ErrStateVersionConflict)
+
+	_, err = svc.Put(ctx, "acc-2", "bot:alice", "form.step", PutConversationStateRequest{Value: json.RawMessage(`1`)})
+	assert.ErrorIs(t, err, ErrConversationNotFound, "only the paired account may write")
+	repo.AssertNumberOfCalls(t, "Put", 2)
+}
+
+func TestConversationStateService_PutKeyLimit(t *testing.T) {
+	repo, convs := new(mockStateRepo), new(mockConversationRepo)
+	svc := NewConversationStateService(repo, convs)
+	ctx := context.Background()
+	convs.On("FindByKey", ctx, "bot:alice").Return(&model.ConversationMapping{
+		ConversationKey: "bot:alice",
+		AccountID:       strPtr("acc-1"),
+		State:           model.PairingStatePaired,
+	}, nil)
+	repo.On("Put", ctx, mock.MatchedBy(func(p model.PutConversationStateParams) bool {
+		return p.MaxKeys == MaxStateKeys
+	})).Return(nil, nil)
+
+	_, err := svc.Put(ctx, "acc-1", "bot:alice", "k101", PutConversationStateRequest{Value: json.RawMessage(`1`)})
+	assert.ErrorIs(t, err, ErrInvalidConversationState)
+
+	repo.On("Find", ctx, "acc-1", "bot:alice", "k101").Return(nil, nil).Once()
+	_, err = svc.Put(ctx, "acc-1", "bot:alice", "k101", PutConversationStateRequest{Value: json.RawMessage(`1`), Version: int64Ptr(0)})
+	assert.ErrorIs(t, err, ErrInvalidConversationState, "a new key is refused for the limit, not a version")
+
+	repo.On("Find", ctx, "acc-1", "bot:alice", "k1").Return(&model.ConversationState{Key: "k1", Version: 1}, nil).Once()
+	_, err = svc.Put(ctx, "acc-1", "bot:alice", "k1", PutConversationStateRequest{Value: json.RawMessage(`1`), Version: int64Ptr(0)})
+	assert.ErrorIs(t, err, ErrStateVersionConflict)
+}
+
+func TestConversationStateService_PutRejectsInvalidRequest(t *testing.T) {
+	svc := NewConversationStateService(new(mockStateRepo), new(mockConversationRepo))
+	svc.now = func() time.Time { return stateTestNow }
+	ctx := context.Background()
+	big := json.RawMessage(`"` + strings.Repeat("a", MaxStateValueBytes) + `"`)
+
+	for name, tc := range map[string]struct {
+		key string
+		req PutConversationStateRequest
+	}{
+		"empty key":        {"", PutConversationStateRequest{Value: json.RawMessage(`1`)}},
+		"key with slash":   {"a/b", PutConversationStateRequest{Value: json.RawMessage(`1`)}},
+		"missing value":    {"k", PutConversationStateRequest{}},
+		"null value":       {"k", PutConversationStateRequest{Value: json.RawMessage(`null`)}},
+		"value too large":  {"k", PutConversationStateRequest{Value: big}},
+		"negative ttl":     {"k", PutConversationStateRequest{Value: json.RawMessage(`1`), TTLSeconds: -1}},
+		"negative version": {"k", PutConversationStateRequest{Value: json.RawMessage(`1`), Version: int64Ptr(-1)}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.Put(ctx, "acc-1", "bot:alice", tc.key, tc.req)
+			assert.ErrorIs(t, err, ErrInvalidConversationState)
+		})
+	}
+}
+
+func TestConversationStateService_Delete(t *testing.T) {
+	repo := new(mockStateRepo)
+	svc := NewConversationStateService(repo, new(mockConversationRepo))
+	svc.now = func() time.Time { return stateTestNow }
+	ctx := context.Background()
+
+	repo.On("Delete", ctx, "acc-1", "bot:alice", "a", (*int64)(nil)).Return(true, nil)
+	assert.NoError(t, svc.Delete(ctx, "acc-1", "bot:alice", "a", nil))
+
+	repo.On("Delete", ctx, "acc-1", "bot:alice", "b", int64Ptr(3)).Return(false, nil)
+	repo.On("Find", ctx, "acc-1", "bot:alice", "b").Return(&model.ConversationState{Key: "b", Version: 4}, nil)
+	assert.ErrorIs(t, svc.Delete(ctx, "acc-1", "bot:alice", "b", int64Ptr(3)), ErrStateVersionConflict)
+
+	repo.On("Delete", ctx, "acc-1", "bot:alice", "c", (*int64)(nil)).Return(false, nil)
+	assert.ErrorIs(t, svc.Delete(ctx, "acc-1", "bot:alice", "c", nil), ErrStateNotFound)
+}
+
+func TestConversationStateService_Values(t *testing.T) {
+	repo := new(mockStateRepo)
+	svc := NewConversationStateService(repo, new(mockConversationRepo))
+	svc.now = func() time.Time { return stateTestNow }
+	ctx := context.Background()
+	repo.On("FindByConversation", ctx, "acc-1", "bot:alice").Return([]model.ConversationState{
+		{Key: "lang", Value: json.RawMessage(`"ko"`)},
+		{Key: "cart", Value: json.RawMessage(`[1,2]`)},
+	}, nil)
+
+	values, truncated, err := svc.Values(ctx, "acc-1", "bot:alice")
+	require.NoError(t, err)
+	assert.False(t, truncated)
+	assert.Equal(t, map[string]json.RawMessage{
+		"lang": json.RawMessage(`"ko"`),
+		"cart": json.RawMessage(`[1,2]`),
+	}, values)
+}
+
+func TestConversationStateService_ValuesAreCapped(t *testing.T) {
+	repo := new(mockStateRepo)
+	svc := NewConversationStateService(repo, new(mockConversationRepo))
+	ctx := context.Background()
+	value := json.RawMessage(`"` + strings.Repeat("a", MaxStateValueBytes-10) + `"`)
+	var states []model.ConversationState
+	for i := range 8 {
+		states = append(states, model.ConversationState{Key: fmt.Sprintf("k%d", i), Value: value})
+	}
+	repo.On("FindByConversation", ctx, "acc-1", "bot:alice").Return(states, nil)
+
+	values, truncated, err := svc.Values(ctx, "acc-1", "bot:alice")
+	require.NoError(t, err)
+	assert.True(t, truncated)
+	assert.Len(t, values, MaxStateEventBytes/MaxStateValueBytes)
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+const (
+	// ConsumerPollInterval is how often a competing stream heartbeats and
+	// looks for messages it was not woken for, such as lapsed leases.
+	ConsumerPollInterval = 5 * time.Second
+
+	// consumerTTL is how long a consumer counts as live without a
+	// heartbeat. A crashed stream's conversations move after this.
+	consumerTTL = 3 * ConsumerPollInterval
+
+	// claimBatchSize caps the messages considered per claim.
+	claimBatchSize = 100
+)
+
+// ConsumerWaker asks the competing streams of an account to claim again.
+// *sse.Broker implements it.
+type ConsumerWaker interface {
+	Wake(ctx context.Context, accountID string) error
+}
+
+var _ ConsumerWaker = (*sse.Broker)(nil)
+
+// DeliveryService hands each inbound message to exactly one of an
+// account's competing streams. A conversation stays with the stream that
+// first took it while that stream is connected; new conversations are
+// spread over the live streams by rendezvous hashing, so streams that join
+// pick up new work and the conversations of a stream that leaves move to
+// the others. A claimed message is leased to its stream and offered again
+// if it is not acked before the lease expires.
+type DeliveryService struct {
+	inboundRepo repository.InboundMessageRepository
+	registry    ConsumerRegistry
+	waker       ConsumerWaker
+	leaseTTL    time.Duration
+}
+
+func NewDeliveryService(
+	inboundRepo repository.InboundMessageRepository,
+	registry ConsumerRegistry,
+	waker ConsumerWaker,
+	leaseTTL time.Duration,
+) *DeliveryService {
+	return &DeliveryService{
+		inboundRepo: inboundRepo,
+		registry:    registry,
+		waker:       waker,
+		leaseTTL:    leaseTTL,
+	}
+}
+
+// Join registers a new competing consumer for the account and returns its
+// ID.
+func (s *DeliveryService) Join(ctx context.Context, accountID string) (string, error) {
+	var b [8]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("generate consumer id: %w", err)
+	}
+	consumerID := "c_" + hex.EncodeToString(b[:])
+
+	if err := s.Heartbeat(ctx, accountID, consumerID); err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("accountId", accountID).
+		Str("consumerId", consumerID).
+		Msg("competing consumer joined")
+
+	return consumerID, nil
+}
+
+// Heartbeat keeps the consumer live. Streams call it every
+// ConsumerPollInterval.
+func (s *DeliveryService) Heartbeat(ctx context.Context, accountID, consumerID string) error {
+	if err := s.registry.Heartbeat(ctx, accountID, consumerID, consumerTTL); err != nil {
+		return fmt.Errorf("consumer heartbeat: %w", err)
+	}
+	return nil
+}
+
+// Leave removes the consumer, releases its unacked messages and wakes the
+// remaining consumers so they take over its conversations.
+func (s *DeliveryService) Leave(ctx context.Context, accountID, consumerID string) error {
+	if err := s.registry.Leave(ctx, accountID, consumerID); err != nil {
+		return fmt.Errorf("leave consumer registry: %w", err)
+	}
+
+	released, err := s.inboundRepo.ReleaseLeases(ctx, consumerID)
+	if err != nil {
+		return fmt.Errorf("release leases: %w", err)
+	}
+
+	if err := s.waker.Wake(ctx, accountID); err != nil {
+		log.Warn().Err(err).Str("accountId", accountID).Msg("failed to wake competing consumers")
+	}
+
+	log.Info().
+		Str("accountId", accountID).
+		Str("consumerId", consumerID).
+		Int64("released", released).
+		Msg("competing consumer left")
+
+	return nil
+}
+
+// HasConsumers reports whether the account has live competing consumers.
+func (s *DeliveryService) HasConsumers(ctx context.Context, accountID string) (bool, error) {
+	consumers, err := s.registry.Consumers(ctx, accountID)
+	if err != nil {
+		return false, fmt.Errorf("list consumers: %w", err)
+	}
+	return len(consumers) > 0, nil
+}
+
+// Claim leases the account's waiting messages that belong to the
+// consumer's conversations and returns them oldest first.
+func (s *DeliveryService) Claim(ctx context.Context, accountID, consumerID string) ([]model.InboundMessage, error) {
+	pending, err := s.inboundRepo.FindClaimable(ctx, accountID, claimBatchSize)
+	if err != nil {
+		return nil, fmt.Errorf("find claimable messages: %w", err)
+	}
+	if len(pending) == 0 {
+		return nil, nil
+	}
+
+	consumers, err := s.registry.Consumers(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("list consumers: %w", err)
+	}
+	if !containsString(consumers, consumerID) {
+		consumers = append(consumers, consumerID)
+	}
+
+	owners := make(map[string]string)
+	var ids []string
+	for _, msg := range pending {
+		owner, ok := owners[msg.ConversationKey]
+		if !ok {
+			candidate := rendezvousOwner(consumers, msg.ConversationKey)
+			owner, err = s.registry.Assign(ctx, accountID, msg.ConversationKey, candidate)
+			if err != nil {
+				return nil, fmt.Errorf("assign conversation: %w", err)
+			}
+			owners[msg.ConversationKey] = owner
+		}
+		if owner == consumerID {
+			ids = append(ids, msg.ID)
+		}
+	}
+
+	claimed, err := s.inboundRepo.Claim(ctx, ids, consumerID, s.leaseTTL)
+	if err != nil {
+		return nil, fmt.Errorf("claim messages: %w", err)
+	}
+	sort.SliceStable(claimed, func(i, j int) bool {
+		return claimed[i].CreatedAt.Before(claimed[j].CreatedAt)
+	})
+
+	if len(claimed) > 0 {
+		log.Debug().
+			Str("accountId", accountID).
+			Str("consumerId", consumerID).
+			Int("count", len(claimed)).
+			Msg("messages claimed")
+	}
+
+	return claimed, nil
+}
+
+// rendezvousOwner picks the consumer with the highest hash for the
+// conversation. Adding or removing a consumer only moves the conversations
+// that consumer wins or held.
+func rendezvousOwner(consumers []string, conversationKey string) string {
+	var best string
+	var bestScore uint64
+	for _, c := range consumers {
+		h := fnv.New64a()
+		h.Write([]byte(c))
+		h.Write([]byte{0})
+		h.Write([]byte(conversationKey))
+		if score := mix64(h.Sum64()); best == "" || score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
+
+// mix64 is the murmur3 finalizer. FNV alone barely separates consumer IDs
+// that differ in their last bytes, which would skew the spread.
+func mix64(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
+
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
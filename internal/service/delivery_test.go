@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+// stubRegistry is an in-memory ConsumerRegistry for one account.
+type stubRegistry struct {
+	mu     sync.Mutex
+	live   map[string]bool
+	owners map[string]string
+}
+
+func newStubRegistry(consumers ...string) *stubRegistry {
+	r := &stubRegistry{live: make(map[string]bool), owners: make(map[string]string)}
+	for _, c := range consumers {
+		r.live[c] = true
+	}
+	return r
+}
+
+func (r *stubRegistry) Heartbeat(ctx context.Context, accountID, consumerID string, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.live[consumerID] = true
+	return nil
+}
+
+func (r *stubRegistry) Leave(ctx context.Context, accountID, consumerID string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.live, consumerID)
+	return nil
+}
+
+func (r *stubRegistry) Consumers(ctx context.Context, accountID string) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []string
+	for c := range r.live {
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+func (r *stubRegistry) Assign(ctx context.Context, accountID, conversationKey, candidate string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if owner, ok := r.owners[conversationKey]; ok && r.live[owner] {
+		return owner, nil
+	}
+	r.owners[conversationKey] = candidate
+	return candidate, nil
+}
+
+type countingWaker struct {
+	wakes []string
+}
+
+func (w *countingWaker) Wake(ctx context.Context, accountID string) error {
+	w.wakes = append(w.wakes, accountID)
+	return nil
+}
+
+func queuedMessage(id, conversationKey string, createdAt time.Time) model.InboundMessage {
+	return model.InboundMessage{
+		ID:              id,
+		AccountID:       "acc-1",
+		ConversationKey: conversationKey,
+		Status:          model.InboundStatusQueued,
+		CreatedAt:       createdAt,
+	}
+}
+
+func TestRendezvousOwner(t *testing.T) {
+	consumers := []string{"c_a", "c_b", "c_c"}
+
+	t.Run("is deterministic regardless of order", func(t *testing.T) {
+		for i := 0; i < 20; i++ {
+			key := fmt.Sprintf("conv-%d", i)
+			assert.Equal(t,
+				rendezvousOwner(consumers, key),
+				rendezvousOwner([]string{"c_c", "c_a", "c_b"}, key))
+		}
+	})
+
+	t.Run("spreads conversations over consumers", func(t *testing.T) {
+		seen := make(map[string]int)
+		for i := 0; i < 300; i++ {
+			seen[rendezvousOwner(consumers, fmt.Sprintf("conv-%d", i))]++
+		}
+		assert.Len(t, seen, 3)
+	})
+
+	t.Run("removing a consumer only moves its conversations", func(t *testing.T) {
+		remaining := []string{"c_a", "c_c"}
+		for i := 0; i < 100; i++ {
+			key := fmt.Sprintf("conv-%d", i)
+			before := rendezvousOwner(consumers, key)
+			if before != "c_b" {
+				assert.Equal(t, before, rendezvousOwner(remaining, key))
+			}
+		}
+	})
+
+	t.Run("returns empty without consumers", func(t *testing.T) {
+		assert.Empty(t, rendezvousOwner(nil, "conv-1"))
+	})
+}
+
+func TestDeliveryService_Claim(t *testing.T) {
+	ctx := context.Background()
+	ttl := 30 * time.Second
+	now := time.Now()
+
+	t.Run("claims only conversations the consumer owns", func(t *testing.T) {
+		repo := new(mockInboundRepo)
+		registry := newStubRegistry("c_a", "c_b")
+		registry.owners["conv-1"] = "c_a"
+		registry.owners["conv-2"] = "c_b"
+		svc := NewDeliveryService(repo, registry, &countingWaker{}, ttl)
+
+		pending := []model.InboundMessage{
+			queuedMessage("m1", "conv-1", now),
+			queuedMessage("m2", "conv-2", now.Add(time.Second)),
+			queuedMessage("m3", "conv-1", now.Add(2*time.Second)),
+		}
+		repo.On("FindClaimable", ctx, "acc-1", claimBatchSize).Return(pending, nil)
+		repo.On("Claim", ctx, []string{"m1", "m3"}, "c_a", ttl).
+			Return([]model.InboundMessage{pending[2], pending[0]}, nil)
+
+		claimed, err := svc.Claim(ctx, "acc-1", "c_a")
+
+		require.NoError(t, err)
+		require.Len(t, claimed, 2)
+		assert.Equal(t, "m1", claimed[0].ID, "claimed messages are returned oldest first")
+		assert.Equal(t, "m3", claimed[1].ID)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("takes over conversations of a consumer that left", func(t *testing.T) {
+		repo := new(mockInboundRepo)
+		registry := newStubRegistry("c_a")
+		registry.owners["conv-1"] = "c_gone"
+		svc := NewDeliveryService(repo, registry, &countingWaker{}, ttl)
+
+		pending := []model.InboundMessage{queuedMessage("m1", "conv-1", now)}
+		repo.On("FindClaimable", ctx, "acc-1", claimBatchSize).Return(pending, nil)
+		repo.On("Claim", ctx, []string{"m1"}, "c_a", ttl).Return(pending, nil)
+
+		claimed, err := svc.Claim(ctx, "acc-1", "c_a")
+
+		require.NoError(t, err)
+		assert.Len(t, claimed, 1)
+		assert.Equal(t, "c_a", registry.owners["conv-1"])
+	})
+
+	t.Run("keeps a conversation with its owner after another consumer joins", func(t *testing.T) {
+		repo := new(mockInboundRepo)
+		registry := newStubRegistry("c_a")
+		svc := NewDeliveryService(repo, registry, &countingWaker{}, ttl)
+
+		first := []model.InboundMessage{queuedMessage("m1", "conv-1", now)}
+		repo.On("FindClaimable", ctx, "acc-1", claimBatchSize).Return(first, nil).Once()
+		repo.On("Claim", ctx, []string{"m1"}, "c_a", ttl).Return(first, nil).Once()
+		_, err := svc.Claim(ctx, "acc-1", "c_a")
+		require.NoError(t, err)
+
+		_, err = svc.Join(ctx, "acc-1")
+		require.NoError(t, err)
+
+		second := []model.InboundMessage{queuedMessage("m2", "conv-1", now.Add(time.Second))}
+		repo.On("FindClaimable", ctx, "acc-1", claimBatchSize).Return(second, nil)
+		repo.On("Claim", ctx, []string{"m2"}, "c_a", ttl).Return(second, nil)
+
+		claimed, err := svc.Claim(ctx, "acc-1", "c_a")
+
+		require.NoError(t, err)
+		require.Len(t, claimed, 1)
+		assert.Equal(t, "m2", claimed[0].ID)
+	})
+
+	t.Run("does nothing when no messages wait", func(t *testing.T) {
+		repo := new(mockInboundRepo)
+		svc := NewDeliveryService(repo, newStubRegistry("c_a"), &countingWaker{}, ttl)
+		repo.On("FindClaimable", ctx, "acc-1", claimBatchSize).Return([]model.InboundMessage{}, nil)
+
+		claimed, err := svc.Claim(ctx, "acc-1", "c_a")
+
+		require.NoError(t, err)
+		assert.Empty(t, claimed)
+		repo.AssertNotCalled(t, "Claim", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestDeliveryService_Leave(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockInboundRepo)
+	registry := newStubRegistry("c_a", "c_b")
+	waker := &countingWaker{}
+	svc := NewDeliveryService(repo, registry, waker, 30*time.Second)
+
+	repo.On("ReleaseLeases", ctx, "c_a").Return(int64(2), nil)
+
+	require.NoError(t, svc.Leave(ctx, "acc-1", "c_a"))
+
+	consumers, _ := registry.Consumers(ctx, "acc-1")
+	assert.Equal(t, []string{"c_b"}, consumers)
+	assert.Equal(t, []string{"acc-1"}, waker.wakes)
+	repo.AssertExpectations(t)
+}
+
+func TestRedisConsumerRegistry(t *testing.T) {
+	redisClient := newTestRedisClient(t)
+	defer redisClient.Close()
+
+	ctx := context.Background()
+	registry := NewRedisConsumerRegistry(redisClient)
+
+	require.NoError(t, registry.Heartbeat(ctx, "acc-1", "c_a", time.Minute))
+	require.NoError(t, registry.Heartbeat(ctx, "acc-1", "c_b", time.Minute))
+
+	consumers, err := registry.Consumers(ctx, "acc-1")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"c_a", "c_b"}, consumers)
+
+	owner, err := registry.Assign(ctx, "acc-1", "conv-1", "c_a")
+	require.NoError(t, err)
+	assert.Equal(t, "c_a", owner)
+
+	owner, err = registry.Assign(ctx, "acc-1", "conv-1", "c_b")
+	require.NoError(t, err)
+	assert.Equal(t, "c_a", owner, "a live owner keeps the conversation")
+
+	require.NoError(t, registry.Leave(ctx, "acc-1", "c_a"))
+
+	owner, err = registry.Assign(ctx, "acc-1", "conv-1", "c_b")
+	require.NoError(t, err)
+	assert.Equal(t, "c_b", owner, "the conversation moves once its owner leaves")
+
+	require.NoError(t, registry.Heartbeat(ctx, "acc-1", "c_c", time.Millisecond))
+	time.Sleep(5 * time.Millisecond)
+	consumers, err = registry.Consumers(ctx, "acc-1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"c_b"}, consumers, "expired consumers are dropped")
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	eventAPITimeout        = 10 * time.Second
+	DefaultEventAPIBaseURL = "https://bot-api.kakao.com"
+)
+
+// Event API user identifier types accepted by Kakao
+const (
+	EventUserTypeBotUserKey        = "botUserKey"
+	EventUserTypePlusfriendUserKey = "plusfriendUserKey"
+	EventUserTypeAppUserID         = "appUserId"
+)
+
+type EventAPIUser struct {
+	Type       string         `json:"type"`
+	ID         string         `json:"id"`
+	Properties map[string]any `json:"properties,omitempty"`
+}
+
+type EventAPIRequest struct {
+	BotID     string
+	EventName string
+	Users     []EventAPIUser
+	Params    map[string]any
+}
+
+type EventAPIResult struct {
+	TaskID string `json:"taskId"`
+	Status string `json:"status"`
+}
+
+// EventAPIClient sends bot-initiated messages through the Kakao i open builder Event API.
+type EventAPIClient struct {
+	client       *http.Client
+	baseURL      string
+	restAPIKey   string
+	defaultBotID string
+}
+
+// NewEventAPIClient creates a client for the Event API. defaultBotID is used
+// when a request does not name a bot (e.g. conversations on the "default" channel).
+func NewEventAPIClient(baseURL, restAPIKey, defaultBotID string) *EventAPIClient {
+	if baseURL == "" {
+		baseURL = DefaultEventAPIBaseURL
+	}
+	return &EventAPIClient{
+		client: &http.Client{
+			Timeout: eventAPITimeout,
+		},
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		restAPIKey:   restAPIKey,
+		defaultBotID: defaultBotID,
+	}
+}
+
+// Enabled reports whether a REST API key is configured.
+func (c *EventAPIClient) Enabled() bool {
+	return c != nil && c.restAPIKey != ""
+}
+
+func (c *EventAPIClient) Send(ctx context.Context, req EventAPIRequest) (*EventAPIResult, error) {
+	if !c.Enabled() {
+		return nil, fmt.Errorf("event api not configured")
+	}
+	if req.BotID == "" {
+		req.BotID = c.defaultBotID
+	}
+	if req.BotID == "" || req.EventName == "" || len(req.Users) == 0 {
+		return nil, fmt.Errorf("bot id, event name and users are required")
+	}
+
+	event := map[string]any{"name": req.EventName}
+	if len(req.Params) > 0 {
+		event["data"] = map[string]any{"params": req.Params}
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"event": event,
+		"user":  req.Users,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("marshal event request: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("%s/v2/bots/%s/talk", c.baseURL, url.PathEscape(req.BotID))
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "KakaoAK "+c.restAPIKey)
+
+	start := time.Now()
+	resp, err := c.client.Do(httpReq)
+	elapsed := time.Since(start)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("botId", req.BotID).
+			Str("event", req.EventName).
+			Dur("elapsed", elapsed).
+			Msg("kakao event api error")
+		return nil, fmt.Errorf("event api request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		log.Error().
+			Str("botId", req.BotID).
+			Str("event", req.EventName).
+			Int("status", resp.StatusCode).
+			Dur("elapsed", elapsed).
+			Msg("kakao event api failed")
+		return nil, fmt.Errorf("event api failed with status %d", resp.StatusCode)
+	}
+
+	var result EventAPIResult
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return nil, fmt.Errorf("decode event api response: %w", err)
+	}
+
+	log.Info().
+		Str("botId", req.BotID).
+		Str("event", req.EventName).
+		Str("taskId", result.TaskID).
+		Str("taskStatus", result.Status).
+		Int("users", len(req.Users)).
+		Dur("elapsed", elapsed).
+		Msg("kakao event api accepted")
+
+	return &result, nil
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventAPIClient_Enabled(t *testing.T) {
+	t.Run("disabled without REST API key", func(t *testing.T) {
+		assert.False(t, NewEventAPIClient("", "", "").Enabled())
+	})
+
+	t.Run("disabled for nil client", func(t *testing.T) {
+		var c *EventAPIClient
+		assert.False(t, c.Enabled())
+	})
+
+	t.Run("enabled with REST API key", func(t *testing.T) {
+		assert.True(t, NewEventAPIClient("", "rest-key", "").Enabled())
+	})
+}
+
+func TestEventAPIClient_Send(t *testing.T) {
+	t.Run("posts event to bot talk endpoint", func(t *testing.T) {
+		var gotPath, gotAuth string
+		var gotBody map[string]any
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			gotAuth = r.Header.Get("Authorization")
+			json.NewDecoder(r.Body).Decode(&gotBody)
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"taskId":"task-1","status":"SUCCESS"}`))
+		}))
+		defer server.Close()
+
+		client := NewEventAPIClient(server.URL+"/", "rest-key", "")
+		result, err := client.Send(context.Background(), EventAPIRequest{
+			BotID:     "bot-1",
+			EventName: "reminder",
+			Users:     []EventAPIUser{{Type: EventUserTypePlusfriendUserKey, ID: "user-1"}},
+			Params:    map[string]any{"text": "hello"},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "task-1", result.TaskID)
+		assert.Equal(t, "SUCCESS", result.Status)
+		assert.Equal(t, "/v2/bots/bot-1/talk", gotPath)
+		assert.Equal(t, "KakaoAK rest-key", gotAuth)
+
+		event := gotBody["event"].(map[string]any)
+		assert.Equal(t, "reminder", event["name"])
+		assert.Equal(t, "hello", event["data"].(map[string]any)["params"].(map[string]any)["text"])
+
+		users := gotBody["user"].([]any)
+		require.Len(t, users, 1)
+		assert.Equal(t, "plusfriendUserKey", users[0].(map[string]any)["type"])
+		assert.Equal(t, "user-1", users[0].(map[string]any)["id"])
+	})
+
+	t.Run("falls back to default bot ID", func(t *testing.T) {
+		var gotPath string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			gotPath = r.URL.Path
+			w.Write([]byte(`{"taskId":"task-2","status":"SUCCESS"}`))
+		}))
+		defer server.Close()
+
+		client := NewEventAPIClient(server.URL, "rest-key", "default-bot")
+		_, err := client.Send(context.Background(), EventAPIRequest{
+			EventName: "reminder",
+			Users:     []EventAPIUser{{Type: EventUserTypeBotUserKey, ID: "user-1"}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "/v2/bots/default-bot/talk", gotPath)
+	})
+
+	t.Run("returns error on non-2xx status", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}))
+		defer server.Close()
+
+		client := NewEventAPIClient(server.URL, "bad-key", "bot-1")
+		_, err := client.Send(context.Background(), EventAPIRequest{
+			EventName: "reminder",
+			Users:     []EventAPIUser{{Type: EventUserTypeBotUserKey, ID: "user-1"}},
+		})
+
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "status 401")
+	})
+
+	t.Run("rejects request without users", func(t *testing.T) {
+		client := NewEventAPIClient("http://127.0.0.1:1", "rest-key", "bot-1")
+		_, err := client.Send(context.Background(), EventAPIRequest{EventName: "reminder"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects request when not configured", func(t *testing.T) {
+		client := NewEventAPIClient("http://127.0.0.1:1", "", "bot-1")
+		_, err := client.Send(context.Background(), EventAPIRequest{
+			EventName: "reminder",
+			Users:     []EventAPIUser{{Type: EventUserTypeBotUserKey, ID: "user-1"}},
+		})
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "not configured")
+	})
+}
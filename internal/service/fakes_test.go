@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/mock"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/database"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+// Fakes shared by the service tests.
+
+func strPtr(s string) *string { return &s }
+
+// mockAccountRepo mocks the AccountRepository methods the services use;
+// anything else panics through the nil embedded interface.
+type mockAccountRepo struct {
+	repository.AccountRepository
+	mock.Mock
+}
+
+func (m *mockAccountRepo) FindByID(ctx context.Context, id string) (*model.Account, error) {
+	args := m.Called(ctx, id)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateLocale(ctx context.Context, id string, locale *string) (*model.Account, error) {
+	args := m.Called(ctx, id, locale)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateToken(ctx context.Context, id, tokenHash string) (*model.Account, error) {
+	args := m.Called(ctx, id, tokenHash)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) RevokeToken(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockAccountRepo) UpdateOrderedDelivery(ctx context.Context, id string, ordered bool) (*model.Account, error) {
+	args := m.Called(ctx, id, ordered)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) UpdateCoalesceWindow(ctx context.Context, id string, windowMs int) (*model.Account, error) {
+	args := m.Called(ctx, id, windowMs)
+	account, _ := args.Get(0).(*model.Account)
+	return account, args.Error(1)
+}
+
+func (m *mockAccountRepo) WithTx(tx *sqlx.Tx) repository.AccountRepository {
+	return m
+}
+
+// mockConversationRepo mocks the ConversationRepository methods the
+// services use.
+type mockConversationRepo struct {
+	repository.ConversationRepository
+	mock.Mock
+}
+
+func (m *mockConversationRepo) FindByID(ctx context.Context, id string) (*model.ConversationMapping, error) {
+	args := m.Called(ctx, id)
+	conv, _ := args.Get(0).(*model.ConversationMapping)
+	return conv, args.Error(1)
+}
+
+func (m *mockConversationRepo) FindByKey(ctx context.Context, key string) (*model.ConversationMapping, error) {
+	args := m.Called(ctx, key)
+	conv, _ := args.Get(0).(*model.ConversationMapping)
+	return conv, args.Error(1)
+}
+
+func (m *mockConversationRepo) UpdateState(ctx context.Context, key string, state model.PairingState, accountID *string) error {
+	return m.Called(ctx, key, state, accountID).Error(0)
+}
+
+func (m *mockConversationRepo) UnpairByAccountID(ctx context.Context, accountID string, notice model.PairingNotice) ([]model.ConversationMapping, error) {
+	args := m.Called(ctx, accountID, notice)
+	convs, _ := args.Get(0).([]model.ConversationMapping)
+	return convs, args.Error(1)
+}
+
+func (m *mockConversationRepo) ClearPairingNotice(ctx context.Context, key string) error {
+	return m.Called(ctx, key).Error(0)
+}
+
+func (m *mockConversationRepo) SetLocaleIfUnset(ctx context.Context, key, locale string) error {
+	return m.Called(ctx, key, locale).Error(0)
+}
+
+func (m *mockConversationRepo) StartThread(ctx context.Context, key string) (string, error) {
+	args := m.Called(ctx, key)
+	return args.String(0), args.Error(1)
+}
+
+func (m *mockConversationRepo) Delete(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *mockConversationRepo) WithTx(tx *sqlx.Tx) repository.ConversationRepository {
+	return m
+}
+
+// recordingPublisher keeps published events per account and the channels
+// whose streams were closed.
+type recordingPublisher struct {
+	events map[string][]sse.Event
+	closed []string
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, accountID string, event sse.Event) error {
+	if p.events == nil {
+		p.events = make(map[string][]sse.Event)
+	}
+	p.events[accountID] = append(p.events[accountID], event)
+	return nil
+}
+
+func (p *recordingPublisher) CloseStreams(ctx context.Context, channel string) error {
+	p.closed = append(p.closed, channel)
+	return nil
+}
+
+// fakeTx runs transactions without a database; the mocks ignore tx.
+type fakeTx struct {
+	calls int
+}
+
+func (f *fakeTx) WithTx(ctx context.Context, fn database.TxFunc) error {
+	f.calls++
+	return fn(nil)
+}
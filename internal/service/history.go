@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const (
+	DefaultHistoryLimit = 50
+	MaxHistoryLimit     = 200
+)
+
+// History modes: text entries carry only the extracted text, full entries
+// add the stored Kakao payloads.
+const (
+	HistoryModeText = "text"
+	HistoryModeFull = "full"
+)
+
+// Message directions in a history timeline.
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+var ErrInvalidHistoryQuery = errors.New("invalid history query")
+
+type HistoryQuery struct {
+	// Cursor is the NextCursor of the previous page; empty starts from the
+	// newest message.
+	Cursor string
+	// Limit defaults to DefaultHistoryLimit.
+	Limit int
+	// Mode defaults to HistoryModeText.
+	Mode string
+	// ThreadID keeps only messages of one thread; empty returns them all.
+	ThreadID string
+}
+
+// HistoryEntry is one inbound or outbound message of a conversation.
+type HistoryEntry struct {
+	ID        string                   `json:"id"`
+	Direction string                   `json:"direction"`
+	Text      string                   `json:"text"`
+	Status    string                   `json:"status"`
+	Command   *model.NormalizedCommand `json:"command,omitempty"`
+	// ThreadID is unset for messages stored before threads existed.
+	ThreadID *string `json:"threadId,omitempty"`
+	// InReplyTo is the inbound message an outbound callback reply answered.
+	InReplyTo *string `json:"inReplyTo,omitempty"`
+	// DeliveryType is set on outbound messages.
+	DeliveryType string    `json:"deliveryType,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	// Full mode only.
+	KakaoPayload    json.RawMessage  `json:"kakaoPayload,omitempty"`
+	Normalized      *json.RawMessage `json:"normalized,omitempty"`
+	ResponsePayload json.RawMessage  `json:"responsePayload,omitempty"`
+}
+
+// HistoryPage lists messages oldest first. NextCursor fetches the page of
+// older messages and is empty at the start of the conversation.
+type HistoryPage struct {
+	Messages   []HistoryEntry `json:"messages"`
+	NextCursor string         `json:"nextCursor,omitempty"`
+}
+
+// History returns one page of the account's conversation as a single
+// timeline of inbound and outbound messages, walking back from the newest.
+func (s *MessageService) History(ctx context.Context, accountID, conversationKey string, q HistoryQuery) (*HistoryPage, error) {
+	limit := q.Limit
+	if limit == 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit < 1 || limit > MaxHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidHistoryQuery, MaxHistoryLimit)
+	}
+	mode := q.Mode
+	if mode == "" {
+		mode = HistoryModeText
+	}
+	if mode != HistoryModeText && mode != HistoryModeFull {
+		return nil, fmt.Errorf("%w: mode must be text or full", ErrInvalidHistoryQuery)
+	}
+	if q.ThreadID != "" && !util.IsValidUUID(q.ThreadID) {
+		return nil, fmt.Errorf("%w: thread must be a UUID", ErrInvalidHistoryQuery)
+	}
+	var before *model.MessageCursor
+	if q.Cursor != "" {
+		cursor, err := decodeMessageCursor(q.Cursor)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid cursor", ErrInvalidHistoryQuery)
+		}
+		before = cursor
+	}
+
+	// Each side is fetched one past the page so we know whether older
+	// messages remain once the two are merged.
+	inbound, err := s.inboundRepo.FindByConversationKey(ctx, accountID, conversationKey, q.ThreadID, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("find inbound messages: %w", err)
+	}
+	outbound, err := s.outboundRepo.FindByConversationKey(ctx, accountID, conversationKey, q.ThreadID, before, limit+1)
+	if err != nil {
+		return nil, fmt.Errorf("find outbound messages: %w", err)
+	}
+
+	full := mode == HistoryModeFull
+	entries := make([]HistoryEntry, 0, limit+1)
+	i, j := 0, 0
+	for len(entries) <= limit && (i < len(inbound) || j < len(outbound)) {
+		if j >= len(outbound) || (i < len(inbound) && newerThan(inbound[i].CreatedAt, inbound[i].ID, outbound[j].CreatedAt, outbound[j].ID)) {
+			entries = append(entries, inboundHistoryEntry(&inbound[i], full))
+			i++
+		} else {
+			entries = append(entries, outboundHistoryEntry(&outbound[j], full))
+			j++
+		}
+	}
+
+	page := &HistoryPage{}
+	if len(entries) > limit {
+		entries = entries[:limit]
+		last := entries[limit-1]
+		page.NextCursor = encodeMessageCursor(model.MessageCursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+	for l, r := 0, len(entries)-1; l < r; l, r = l+1, r-1 {
+		entries[l], entries[r] = entries[r], entries[l]
+	}
+	page.Messages = entries
+	return page, nil
+}
+
+// newerThan orders messages as the repositories do: by creation time, then
+// ID, descending.
+func newerThan(at time.Time, id string, otherAt time.Time, otherID string) bool {
+	if !at.Equal(otherAt) {
+		return at.After(otherAt)
+	}
+	return id > otherID
+}
+
+func inboundHistoryEntry(m *model.InboundMessage, full bool) HistoryEntry {
+	entry := HistoryEntry{
+		ID:        m.ID,
+		Direction: DirectionInbound,
+		Text:      inboundText(m),
+		Status:    string(m.Status),
+		Command:   m.Command(),
+		ThreadID:  m.ThreadID,
+		CreatedAt: m.CreatedAt,
+	}
+	if full {
+		entry.KakaoPayload = m.KakaoPayload
+		entry.Normalized = m.NormalizedMessage
+	}
+	return entry
+}
+
+func outboundHistoryEntry(m *model.OutboundMessage, full bool) HistoryEntry {
+	entry := HistoryEntry{
+		ID:           m.ID,
+		Direction:    DirectionOutbound,
+		Text:         outboundText(m),
+		Status:       string(m.Status),
+		InReplyTo:    m.InboundMessageID,
+		DeliveryType: string(m.DeliveryType),
+		ThreadID:     m.ThreadID,
+		CreatedAt:    m.CreatedAt,
+	}
+	if full {
+		entry.ResponsePayload = m.ResponsePayload
+	}
+	return entry
+}
+
+// inboundText is the normalized text, falling back to the raw utterance for
+// messages stored before normalization existed.
+func inboundText(m *model.InboundMessage) string {
+	if m.NormalizedMessage != nil {
+		var normalized model.NormalizedMessage
+		if err := json.Unmarshal(*m.NormalizedMessage, &normalized); err == nil && normalized.Text != "" {
+			return normalized.Text
+		}
+	}
+	var payload struct {
+		UserRequest struct {
+			Utterance string `json:"utterance"`
+		} `json:"userRequest"`
+	}
+	if err := json.Unmarshal(m.KakaoPayload, &payload); err != nil {
+		return ""
+	}
+	return payload.UserRequest.Utterance
+}
+
+// outboundText joins the text of a skill response's outputs. Event API
+// sends have no text of their own and yield "".
+func outboundText(m *model.OutboundMessage) string {
+	var payload struct {
+		Template struct {
+			Outputs []struct {
+				SimpleText *struct {
+					Text string `json:"text"`
+				} `json:"simpleText"`
+				TextCard *struct {
+					Title       string `json:"title"`
+					Description string `json:"description"`
+				} `json:"textCard"`
+				BasicCard *struct {
+					Title       string `json:"title"`
+					Description string `json:"description"`
+				} `json:"basicCard"`
+			} `json:"outputs"`
+		} `json:"template"`
+	}
+	if err := json.Unmarshal(m.ResponsePayload, &payload); err != nil {
+		return ""
+	}
+
+	var parts []string
+	add := func(s string) {
+		if s != "" {
+			parts = append(parts, s)
+		}
+	}
+	for _, out := range payload.Template.Outputs {
+		switch {
+		case out.SimpleText != nil:
+			add(out.SimpleText.Text)
+		case out.TextCard != nil:
+			add(out.TextCard.Title)
+			add(out.TextCard.Description)
+		case out.BasicCard != nil:
+			add(out.BasicCard.Title)
+			add(out.BasicCard.Description)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func encodeMessageCursor(c model.MessageCursor) string {
+	raw := c.CreatedAt.UTC().Format(time.RFC3339Nano) + "|" + c.ID
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeMessageCursor(s string) (*model.MessageCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	at, id, ok := strings.Cut(string(raw), "|")
+	if !ok || !util.IsValidUUID(id) {
+		return nil, errors.New("malformed cursor")
+	}
+	createdAt, err := time.Parse(time.RFC3339Nano, at)
+	if err != nil {
+		return nil, err
+	}
+	return &model.MessageCursor{CreatedAt: createdAt, ID: id}, nil
+}
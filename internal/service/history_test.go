@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+func historyIDs(entries []HistoryEntry) []string {
+	ids := make([]string, len(entries))
+	for i, e := range entries {
+		ids[i] = e.ID
+	}
+	return ids
+}
+
+func noCursor(c *model.MessageCursor) bool { return c == nil }
+
+func TestMessageService_HistoryMergesAndPages(t *testing.T) {
+	inboundRepo := new(mockInboundRepo)
+	outboundRepo := new(mockOutboundRepo)
+	svc := NewMessageService(inboundRepo, outboundRepo)
+	ctx := context.Background()
+
+	base := time.Date(2025, 1, 31, 12, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return base.Add(time.Duration(minutes) * time.Minute) }
+	normalized := json.RawMessage(`{"version":1,"text":"hello"}`)
+	in := func(id string, minute int) model.InboundMessage {
+		return model.InboundMessage{ID: id, CreatedAt: at(minute), KakaoPayload: json.RawMessage(`{}`), NormalizedMessage: &normalized}
+	}
+	out := func(id string, minute int) model.OutboundMessage {
+		return model.OutboundMessage{ID: id, CreatedAt: at(minute), ResponsePayload: json.RawMessage(
+			`{"version":"2.0","template":{"outputs":[{"simpleText":{"text":"hi"}}]}}`)}
+	}
+
+	inboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", "", mock.MatchedBy(noCursor), 4).
+		Return([]model.InboundMessage{in("00000000-0000-0000-0000-000000000005", 5), in("00000000-0000-0000-0000-000000000003", 3), in("00000000-0000-0000-0000-000000000001", 1)}, nil)
+	outboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", "", mock.MatchedBy(noCursor), 4).
+		Return([]model.OutboundMessage{out("00000000-0000-0000-0000-000000000004", 4), out("00000000-0000-0000-0000-000000000002", 2)}, nil)
+
+	page, err := svc.History(ctx, "acc-1", "bot:alice", HistoryQuery{Limit: 3})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"00000000-0000-0000-0000-000000000003",
+		"00000000-0000-0000-0000-000000000004",
+		"00000000-0000-0000-0000-000000000005",
+	}, historyIDs(page.Messages), "oldest first within the page")
+	assert.Equal(t, DirectionOutbound, page.Messages[1].Direction)
+	assert.Equal(t, "hi", page.Messages[1].Text)
+	assert.Equal(t, "hello", page.Messages[0].Text)
+	assert.Nil(t, page.Messages[0].KakaoPayload, "text mode omits payloads")
+	require.NotEmpty(t, page.NextCursor)
+
+	cursor, err := decodeMessageCursor(page.NextCursor)
+	require.NoError(t, err)
+	assert.Equal(t, "00000000-0000-0000-0000-000000000003", cursor.ID)
+	assert.True(t, cursor.CreatedAt.Equal(at(3)))
+
+	isPageCursor := func(c *model.MessageCursor) bool { return c != nil && c.ID == cursor.ID }
+	inboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", "", mock.MatchedBy(isPageCursor), 4).
+		Return([]model.InboundMessage{in("00000000-0000-0000-0000-000000000001", 1)}, nil)
+	outboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", "", mock.MatchedBy(isPageCursor), 4).
+		Return([]model.OutboundMessage{out("00000000-0000-0000-0000-000000000002", 2)}, nil)
+
+	page, err = svc.History(ctx, "acc-1", "bot:alice", HistoryQuery{Limit: 3, Cursor: page.NextCursor, Mode: HistoryModeFull})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"00000000-0000-0000-0000-000000000001",
+		"00000000-0000-0000-0000-000000000002",
+	}, historyIDs(page.Messages))
+	assert.Empty(t, page.NextCursor, "no older messages")
+	assert.NotNil(t, page.Messages[0].Normalized)
+	assert.NotNil(t, page.Messages[1].ResponsePayload)
+}
+
+func TestMessageService_HistoryFiltersByThread(t *testing.T) {
+	inboundRepo := new(mockInboundRepo)
+	outboundRepo := new(mockOutboundRepo)
+	svc := NewMessageService(inboundRepo, outboundRepo)
+	ctx := context.Background()
+	thread := "11111111-1111-1111-1111-111111111111"
+
+	inboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", thread, mock.MatchedBy(noCursor), DefaultHistoryLimit+1).
+		Return([]model.InboundMessage{{ID: "00000000-0000-0000-0000-000000000001", ThreadID: &thread, KakaoPayload: json.RawMessage(`{}`)}}, nil)
+	outboundRepo.On("FindByConversationKey", ctx, "acc-1", "bot:alice", thread, mock.MatchedBy(noCursor), DefaultHistoryLimit+1).
+		Return([]model.OutboundMessage{}, nil)
+
+	page, err := svc.History(ctx, "acc-1", "bot:alice", HistoryQuery{ThreadID: thread})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 1)
+	assert.Equal(t, thread, *page.Messages[0].ThreadID)
+}
+
+func TestMessageService_HistoryRejectsInvalidQuery(t *testing.T) {
+	svc := NewMessageService(new(mockInboundRepo), new(mockOutboundRepo))
+	ctx := context.Background()
+
+	for name, q := range map[string]HistoryQuery{
+		"limit too large": {Limit: MaxHistoryLimit + 1},
+		"negative limit":  {Limit: -1},
+		"unknown mode":    {Mode: "html"},
+		"bad cursor":      {Cursor: "not-a-cursor"},
+		"cursor bad id":   {Cursor: encodeMessageCursor(model.MessageCursor{CreatedAt: time.Now(), ID: "1; DROP"})},
+		"bad thread":      {ThreadID: "thread-1"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			_, err := svc.History(ctx, "acc-1", "bot:alice", q)
+			assert.ErrorIs(t, err, ErrInvalidHistoryQuery)
+		})
+	}
+}
+
+func TestInboundText_FallsBackToUtterance(t *testing.T) {
+	msg := &model.InboundMessage{KakaoPayload: json.RawMessage(`{"userRequest":{"utterance":"안녕"}}`)}
+	assert.Equal(t, "안녕", inboundText(msg))
+}
+
+func TestOutboundText(t *testing.T) {
+	msg := &model.OutboundMessage{ResponsePayload: json.RawMessage(`{"template":{"outputs":[
+		{"simpleText":{"text":"first"}},
+		{"simpleImage":{"imageUrl":"https://example.com/a.png"}},
+		{"textCard":{"title":"Card","description":"details"}}
+	]}}`)}
+	assert.Equal(t, "first\nCard\ndetails", outboundText(msg))
+
+	event := &model.OutboundMessage{ResponsePayload: json.RawMessage(`{"event":"reminder","params":{}}`)}
+	assert.Equal(t, "", outboundText(event))
+}
@@ -3,18 +3,21 @@ package service
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/url"
-	"strings"
+	"os"
 	"time"
 
 	"github.com/rs/zerolog/log"
 )
 
 const (
-	callbackTimeout = 5 * time.Second
+	callbackTimeout      = 5 * time.Second
+	callbackMaxRedirects = 3
 )
 
 var allowedCallbackHosts = []string{
@@ -23,8 +26,24 @@ var allowedCallbackHosts = []string{
 	".kakaoenterprise.com",
 }
 
+var defaultCallbackPolicy, _ = NewCallbackPolicy(allowedCallbackHosts, false)
+
+// CallbackClientConfig customizes where and how callbacks are sent.
+type CallbackClientConfig struct {
+	// AllowedHosts replaces the built-in Kakao allowlist when non-empty.
+	AllowedHosts []string
+	// AllowInsecureLocalhost permits http://localhost callbacks (dev only).
+	AllowInsecureLocalhost bool
+	// ProxyURL routes callbacks through an HTTP(S) proxy. When empty the
+	// standard HTTPS_PROXY/NO_PROXY environment variables apply.
+	ProxyURL string
+	// CAFile is a PEM bundle trusted in addition to the system roots.
+	CAFile string
+}
+
 type KakaoService struct {
 	client *http.Client
+	policy *CallbackPolicy
 }
 
 func NewKakaoService() *KakaoService {
@@ -32,11 +51,65 @@ func NewKakaoService() *KakaoService {
 		client: &http.Client{
 			Timeout: callbackTimeout,
 		},
+		policy: defaultCallbackPolicy,
 	}
 }
 
+func NewKakaoServiceFromConfig(cfg CallbackClientConfig) (*KakaoService, error) {
+	hosts := allowedCallbackHosts
+	if len(cfg.AllowedHosts) > 0 {
+		hosts = cfg.AllowedHosts
+	}
+	policy, err := NewCallbackPolicy(hosts, cfg.AllowInsecureLocalhost)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = 10
+
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil || (proxyURL.Scheme != "http" && proxyURL.Scheme != "https") {
+			return nil, fmt.Errorf("invalid callback proxy URL %q", cfg.ProxyURL)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read callback CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	}
+
+	s := &KakaoService{policy: policy}
+	s.client = &http.Client{
+		Timeout:   callbackTimeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= callbackMaxRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			if !s.policy.Allows(req.URL.String()) {
+				return fmt.Errorf("redirect to disallowed host %s", req.URL.Hostname())
+			}
+			return nil
+		},
+	}
+	return s, nil
+}
+
 func (s *KakaoService) SendCallback(ctx context.Context, callbackURL string, payload any) error {
-	if !isValidCallbackURL(callbackURL) {
+	if !s.policy.Allows(callbackURL) {
 		log.Warn().Str("url", callbackURL).Msg("invalid callback URL rejected")
 		return fmt.Errorf("invalid callback URL")
 	}
@@ -90,21 +163,5 @@ func (s *KakaoService) SendCallback(ctx context.Context, callbackURL string, pay
 }
 
 func isValidCallbackURL(rawURL string) bool {
-	parsed, err := url.Parse(rawURL)
-	if err != nil {
-		return false
-	}
-
-	if parsed.Scheme != "https" {
-		return false
-	}
-
-	hostname := strings.ToLower(parsed.Hostname())
-	for _, suffix := range allowedCallbackHosts {
-		if strings.HasSuffix(hostname, suffix) {
-			return true
-		}
-	}
-
-	return false
+	return defaultCallbackPolicy.Allows(rawURL)
 }
@@ -1,9 +1,16 @@
 package service
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestIsValidCallbackURL(t *testing.T) {
@@ -71,3 +78,99 @@ func TestIsValidCallbackURL(t *testing.T) {
 		})
 	}
 }
+
+func TestCallbackPolicy(t *testing.T) {
+	policy, err := NewCallbackPolicy([]string{
+		"stub.internal.example",
+		"*.kakao.com",
+		".kakaocdn.net",
+		"10.20.0.0/16",
+	}, false)
+	require.NoError(t, err)
+
+	devPolicy, err := NewCallbackPolicy(nil, true)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		policy   *CallbackPolicy
+		url      string
+		expected bool
+	}{
+		{"exact host", policy, "https://stub.internal.example/cb", true},
+		{"exact host does not match subdomain", policy, "https://a.stub.internal.example/cb", false},
+		{"wildcard suffix", policy, "https://bot-api.kakao.com/cb", true},
+		{"dot suffix", policy, "https://t1.kakaocdn.net/cb", true},
+		{"cidr match", policy, "https://10.20.3.4:8443/cb", true},
+		{"cidr miss", policy, "https://10.21.0.1/cb", false},
+		{"cidr does not match hostnames", policy, "https://ten.example.com/cb", false},
+		{"http rejected without dev flag", policy, "http://localhost:8080/cb", false},
+		{"dev flag allows http localhost", devPolicy, "http://localhost:8080/cb", true},
+		{"dev flag allows http loopback ip", devPolicy, "http://127.0.0.1:9000/cb", true},
+		{"dev flag does not allow other http hosts", devPolicy, "http://api.kakao.com/cb", false},
+		{"dev flag does not widen https allowlist", devPolicy, "https://evil.com/cb", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, tc.policy.Allows(tc.url))
+		})
+	}
+
+	t.Run("rejects invalid cidr", func(t *testing.T) {
+		_, err := NewCallbackPolicy([]string{"10.0.0.0/99"}, false)
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadCallbackRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "allowlist.txt")
+	require.NoError(t, os.WriteFile(path, []byte("# kakao\n.kakao.com\n\n  10.0.0.0/8  \n"), 0o600))
+
+	rules, err := LoadCallbackRules(path)
+	require.NoError(t, err)
+	assert.Equal(t, []string{".kakao.com", "10.0.0.0/8"}, rules)
+}
+
+func TestNewKakaoServiceFromConfig(t *testing.T) {
+	t.Run("rejects invalid proxy URL", func(t *testing.T) {
+		_, err := NewKakaoServiceFromConfig(CallbackClientConfig{ProxyURL: "socks5://proxy:1080"})
+		assert.Error(t, err)
+	})
+
+	t.Run("rejects CA file without certificates", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a cert"), 0o600))
+
+		_, err := NewKakaoServiceFromConfig(CallbackClientConfig{CAFile: path})
+		assert.Error(t, err)
+	})
+
+	t.Run("sends to local stub in dev mode", func(t *testing.T) {
+		var received map[string]any
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&received)
+		}))
+		defer stub.Close()
+
+		svc, err := NewKakaoServiceFromConfig(CallbackClientConfig{AllowInsecureLocalhost: true})
+		require.NoError(t, err)
+
+		err = svc.SendCallback(context.Background(), stub.URL+"/callback", map[string]any{"version": "2.0"})
+		require.NoError(t, err)
+		assert.Equal(t, "2.0", received["version"])
+	})
+
+	t.Run("refuses redirects to disallowed hosts", func(t *testing.T) {
+		stub := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "https://evil.com/steal", http.StatusTemporaryRedirect)
+		}))
+		defer stub.Close()
+
+		svc, err := NewKakaoServiceFromConfig(CallbackClientConfig{AllowInsecureLocalhost: true})
+		require.NoError(t, err)
+
+		err = svc.SendCallback(context.Background(), stub.URL+"/callback", map[string]any{})
+		assert.ErrorContains(t, err, "disallowed host")
+	})
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/audit"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/database"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+// Lifecycle SSE event types, sent so OpenClaw can drop per-user state.
+const (
+	EventConversationUnpaired = "conversation_unpaired"
+	EventConversationBlocked  = "conversation_blocked"
+	EventConversationDeleted  = "conversation_deleted"
+	EventSessionDisconnected  = "session_disconnected"
+	EventAccountTokenRotated  = "account_token_rotated"
+	EventPairingExpired       = "pairing_expired"
+	EventThreadStarted        = "thread_started"
+)
+
+// Reasons carried in lifecycle events.
+const (
+	LifecycleReasonUser    = "user"
+	LifecycleReasonAdmin   = "admin"
+	LifecycleReasonExpired = "expired"
+	// LifecycleReasonAccount is an OpenClaw client acting on its own
+	// account through /v1/me.
+	LifecycleReasonAccount = "account"
+)
+
+var (
+	ErrInvalidTransition    = errors.New("invalid state transition")
+	ErrConversationNotFound = errors.New("conversation not found")
+	ErrSessionNotFound      = errors.New("session not found")
+)
+
+// TxRunner runs fn in a database transaction. *database.DB implements it.
+type TxRunner interface {
+	WithTx(ctx context.Context, fn database.TxFunc) error
+}
+
+var _ TxRunner = (*database.DB)(nil)
+
+// LifecycleBroker publishes lifecycle events and ends live streams on every
+// replica. *sse.Broker implements it.
+type LifecycleBroker interface {
+	EventPublisher
+	CloseStreams(ctx context.Context, channel string) error
+}
+
+var _ LifecycleBroker = (*sse.Broker)(nil)
+
+var conversationTransitions = map[model.PairingState][]model.PairingState{
+	model.PairingStateUnpaired: {model.PairingStatePending, model.PairingStatePaired, model.PairingStateBlocked},
+	model.PairingStatePending:  {model.PairingStatePaired, model.PairingStateUnpaired, model.PairingStateBlocked},
+	model.PairingStatePaired:   {model.PairingStateUnpaired, model.PairingStateBlocked},
+	model.PairingStateBlocked:  {model.PairingStateUnpaired},
+}
+
+var sessionTransitions = map[model.SessionStatus][]model.SessionStatus{
+	model.SessionStatusPendingPairing: {model.SessionStatusPaired, model.SessionStatusExpired, model.SessionStatusDisconnected},
+	model.SessionStatusPaired:         {model.SessionStatusDisconnected},
+}
+
+// CanTransitionConversation reports whether a conversation may move from
+// one pairing state to another.
+func CanTransitionConversation(from, to model.PairingState) bool {
+	for _, s := range conversationTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CanTransitionSession reports whether a session may move from one status
+// to another. Expired and disconnected sessions are final.
+func CanTransitionSession(from, to model.SessionStatus) bool {
+	for _, s := range sessionTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// LifecycleService moves conversations, sessions and account tokens
+// through their states and tells the affected account's streams about it.
+// A conversation leaving its account also loses the account's
+// conversation state.
+type LifecycleService struct {
+	db       TxRunner
+	convs    repository.ConversationRepository
+	sessions repository.SessionRepository
+	accounts repository.AccountRepository
+	states   repository.ConversationStateRepository
+	broker   LifecycleBroker
+}
+
+func NewLifecycleService(
+	db TxRunner,
+	convs repository.ConversationRepository,
+	sessions repository.SessionRepository,
+	accounts repository.AccountRepository,
+	states repository.ConversationStateRepository,
+	broker LifecycleBroker,
+) *LifecycleService {
+	return &LifecycleService{
+		db:       db,
+		convs:    convs,
+		sessions: sessions,
+		accounts: accounts,
+		states:   states,
+		broker:   broker,
+	}
+}
+
+// UnpairConversation detaches conv from its account and emits
+// conversation_unpaired to that account.
+func (s *LifecycleService) UnpairConversation(ctx context.Context, conv *model.ConversationMapping, reason string) error {
+	if !CanTransitionConversation(conv.State, model.PairingStateUnpaired) {
+		return fmt.Errorf("%w: conversation %s -> %s", ErrInvalidTransition, conv.State, model.PairingStateUnpaired)
+	}
+	if err := s.convs.UpdateState(ctx, conv.ConversationKey, model.PairingStateUnpaired, nil); err != nil {
+		return fmt.Errorf("update state: %w", err)
+	}
+
+	accountID := conv.AccountID
+	conv.State = model.PairingStateUnpaired
+	conv.AccountID = nil
+
+	log.Info().
+		Str("conversationKey", conv.ConversationKey).
+		Str("reason", reason).
+		Msg("conversation unpaired")
+
+	if accountID != nil {
+		s.clearState(ctx, *accountID, conv.ConversationKey)
+		s.publish(ctx, *accountID, EventConversationUnpaired, conversationEventData(conv, reason, "unpairedAt"))
+	}
+	return nil
+}
+
+// BlockConversation keeps conv attached to its account but stops its
+// messages and replies, and emits conversation_blocked. The user cannot
+// pair with the account again until UnblockConversation.
+func (s *LifecycleService) BlockConversation(ctx context.Context, conv *model.ConversationMapping, reason string) error {
+	if conv.AccountID == nil || !CanTransitionConversation(conv.State, model.PairingStateBlocked) {
+		return fmt.Errorf("%w: conversation %s -> %s", ErrInvalidTransition, conv.State, model.PairingStateBlocked)
+	}
+	if err := s.convs.UpdateState(ctx, conv.ConversationKey, model.PairingStateBlocked, conv.AccountID); err != nil {
+		return fmt.Errorf("update state: %w", err)
+	}
+	conv.State = model.PairingStateBlocked
+
+	log.Info().
+		Str("conversationKey", conv.ConversationKey).
+		Str("accountId", *conv.AccountID).
+		Str("reason", reason).
+		Msg("conversation blocked")
+
+	s.publish(ctx, *conv.AccountID, EventConversationBlocked, conversationEventData(conv, reason, "blockedAt"))
+	return nil
+}
+
+// UnblockConversation releases a blocked conversation from its account so
+// the user may pair again.
+func (s *LifecycleService) UnblockConversation(ctx context.Context, conv *model.ConversationMapping, reason string) error {
+	if conv.State != model.PairingStateBlocked {
+		return fmt.Errorf("%w: conversation %s is not blocked", ErrInvalidTransition, conv.State)
+	}
+	if err := s.convs.UpdateState(ctx, conv.ConversationKey, model.PairingStateUnpaired, nil); err != nil {
+		return fmt.Errorf("update state: %w", err)
+	}
+	if conv.AccountID != nil {
+		s.clearState(ctx, *conv.AccountID, conv.ConversationKey)
+	}
+	conv.State = model.PairingStateUnpaired
+	conv.AccountID = nil
+
+	log.Info().
+		Str("conversationKey", conv.ConversationKey).
+		Str("reason", reason).
+		Msg("conversation unblocked")
+	return nil
+}
+
+// StartThread moves a paired conversation to a new thread and emits
+// thread_started, so OpenClaw can drop the context of the previous topic.
+// Messages keep the thread they were sent in.
+func (s *LifecycleService) StartThread(ctx context.Context, conv *model.ConversationMapping, reason string) error {
+	if conv.State != model.PairingStatePaired || conv.AccountID == nil {
+		return fmt.Errorf("%w: conversation %s cannot start a thread", ErrInvalidTransition, conv.State)
+	}
+	threadID, err := s.convs.StartThread(ctx, conv.ConversationKey)
+	if err != nil {
+		return fmt.Errorf("start thread: %w", err)
+	}
+	previous := conv.ThreadID
+	conv.ThreadID = threadID
+
+	log.Info().
+		Str("conversationKey", conv.ConversationKey).
+		Str("threadId", threadID).
+		Str("reason", reason).
+		Msg("conversation thread started")
+
+	data := conversationEventData(conv, reason, "startedAt")
+	data["previousThreadId"] = previous
+	s.publish(ctx, *conv.AccountID, EventThreadStarted, data)
+	return nil
+}
+
+// DeleteConversation removes a conversation of accountID and emits
+// conversation_deleted if it was attached to the account.
+func (s *LifecycleService) DeleteConversation(ctx context.Context, accountID, id, reason string) error {
+	conv, err := s.convs.FindByID(ctx, id)
+	if err != nil {
+		return fmt.Errorf("find conversation: %w", err)
+	}
+	if conv == nil || conv.AccountID == nil || *conv.AccountID != accountID {
+		return ErrConversationNotFound
+	}
+	if err := s.convs.Delete(ctx, id); err != nil {
+		return fmt.Errorf("delete conversation: %w", err)
+	}
+	s.clearState(ctx, accountID, conv.ConversationKey)
+
+	log.Info().
+		Str("conversationKey", conv.ConversationKey).
+		Str("accountId", accountID).
+		Str("reason", reason).
+		Msg("conversation deleted")
+
+	s.publish(ctx, accountID, EventConversationDeleted, conversationEventData(conv, reason, "deletedAt"))
+	return nil
+}
+
+// DisconnectSession ends a pending or paired session and revokes the
+// access it granted (see revokeSession).
+func (s *LifecycleService) DisconnectSession(ctx context.Context, id, reason string) error {
+	session, err := s.findSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !CanTransitionSession(session.Status, model.SessionStatusDisconnected) {
+		return fmt.Errorf("%w: session %s -> %s", ErrInvalidTransition, session.Status, model.SessionStatusDisconnected)
+	}
+	return s.revokeSession(ctx, session, reason, func(sessions repository.SessionRepository) error {
+		if err := sessions.MarkDisconnected(ctx, id); err != nil {
+			return fmt.Errorf("mark disconnected: %w", err)
+		}
+		return nil
+	})
+}
+
+// DeleteSession removes a session. A live session is disconnected first,
+// as for DisconnectSession.
+func (s *LifecycleService) DeleteSession(ctx context.Context, id, reason string) error {
+	session, err := s.findSession(ctx, id)
+	if err != nil {
+		return err
+	}
+	remove := func(sessions repository.SessionRepository) error {
+		if err := sessions.Delete(ctx, id); err != nil {
+			return fmt.Errorf("delete session: %w", err)
+		}
+		return nil
+	}
+	if !CanTransitionSession(session.Status, model.SessionStatusDisconnected) {
+		if err := remove(s.sessions); err != nil {
+			return err
+		}
+		log.Info().Str("sessionId", id).Str("reason", reason).Msg("session deleted")
+		return nil
+	}
+	return s.revokeSession(ctx, session, reason, remove)
+}
+
+// revokeSession closes session with update and, in the same transaction,
+// revokes the account's relay token and unpairs its conversations, leaving
+// each user a notice for their next message. Afterwards it emits
+// session_disconnected and conversation_unpaired, then ends the live
+// streams of the session and account on every replica.
+func (s *LifecycleService) revokeSession(
+	ctx context.Context,
+	session *model.Session,
+	reason string,
+	update func(repository.SessionRepository) error,
+) error {
+	var unpaired []model.ConversationMapping
+	err := s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		if err := update(s.sessions.WithTx(tx)); err != nil {
+			return err
+		}
+		if session.AccountID == nil {
+			return nil
+		}
+		if err := s.accounts.WithTx(tx).RevokeToken(ctx, *session.AccountID); err != nil {
+			return fmt.Errorf("revoke token: %w", err)
+		}
+		var err error
+		unpaired, err = s.convs.WithTx(tx).UnpairByAccountID(ctx, *session.AccountID, model.PairingNoticeDisconnected)
+		if err != nil {
+			return fmt.Errorf("unpair conversations: %w", err)
+		}
+		states := s.states.WithTx(tx)
+		for i := range unpaired {
+			if _, err := states.DeleteByConversation(ctx, *session.AccountID, unpaired[i].ConversationKey); err != nil {
+				return fmt.Errorf("delete conversation state: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Info().
+		Str("sessionId", session.ID).
+		Int("unpaired", len(unpaired)).
+		Str("reason", reason).
+		Msg("session disconnected")
+
+	s.publishSessionDisconnected(ctx, session, reason)
+	channels := []string{"session:" + session.ID}
+	if session.AccountID != nil {
+		for i := range unpaired {
+			s.publish(ctx, *session.AccountID, EventConversationUnpaired, conversationEventData(&unpaired[i], reason, "unpairedAt"))
+		}
+		channels = append(channels, *session.AccountID)
+	}
+	for _, channel := range channels {
+		if err := s.broker.CloseStreams(ctx, channel); err != nil {
+			log.Warn().Err(err).Str("channel", channel).Msg("failed to close sse streams")
+		}
+	}
+	return nil
+}
+
+// ExpireSession marks a pending session expired and emits pairing_expired
+// to the session stream waiting for it.
+func (s *LifecycleService) ExpireSession(ctx context.Context, session *model.Session) error {
+	if !CanTransitionSession(session.Status, model.SessionStatusExpired) {
+		return fmt.Errorf("%w: session %s -> %s", ErrInvalidTransition, session.Status, model.SessionStatusExpired)
+	}
+	if err := s.sessions.MarkExpired(ctx, session.ID); err != nil {
+		return fmt.Errorf("mark expired: %w", err)
+	}
+	session.Status = model.SessionStatusExpired
+
+	s.publish(ctx, "session:"+session.ID, EventPairingExpired, map[string]string{
+		"sessionId": session.ID,
+		"reason":    LifecycleReasonExpired,
+	})
+	return nil
+}
+
+// RotatedTokens are the credentials issued by a rotation. SessionToken is
+// empty when no session was rotated.
+type RotatedTokens struct {
+	RelayToken   string
+	SessionToken string
+}
+
+// RotateToken issues a new relay token for the account and emits
+// account_token_rotated so clients know to fetch it. The old token stops
+// working immediately and is removed from the account's session metadata.
+func (s *LifecycleService) RotateToken(ctx context.Context, accountID, reason string) (string, error) {
+	tokens, err := s.RotateTokens(ctx, accountID, "", reason)
+	return tokens.RelayToken, err
+}
+
+// RotateTokens is RotateToken that also replaces the token of the session
+// the request came with, so its old bearer token stops working too.
+func (s *LifecycleService) RotateTokens(ctx context.Context, accountID, sessionID, reason string) (RotatedTokens, error) {
+	var tokens RotatedTokens
+	relayToken, err := util.GenerateToken()
+	if err != nil {
+		return tokens, fmt.Errorf("generate token: %w", err)
+	}
+	var sessionToken string
+	if sessionID != "" {
+		if sessionToken, err = util.GenerateToken(); err != nil {
+			return tokens, fmt.Errorf("generate session token: %w", err)
+		}
+	}
+
+	err = s.db.WithTx(ctx, func(tx *sqlx.Tx) error {
+		account, err := s.accounts.WithTx(tx).UpdateToken(ctx, accountID, util.HashToken(relayToken))
+		if err != nil {
+			return fmt.Errorf("update token: %w", err)
+		}
+		if account == nil {
+			return ErrAccountNotFound
+		}
+		sessions := s.sessions.WithTx(tx)
+		if err := sessions.ClearRelayTokens(ctx, accountID); err != nil {
+			return fmt.Errorf("clear stored relay tokens: %w", err)
+		}
+		if sessionID != "" {
+			if err := sessions.UpdateTokenHash(ctx, sessionID, util.HashToken(sessionToken)); err != nil {
+				return fmt.Errorf("update session token: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return tokens, err
+	}
+	tokens = RotatedTokens{RelayToken: relayToken, SessionToken: sessionToken}
+
+	log.Info().Str("accountId", accountID).Str("reason", reason).Msg("relay token rotated")
+	audit.Log(ctx, audit.Event{
+		Type:      audit.EventTokenRegenerate,
+		AccountID: accountID,
+		Details:   map[string]interface{}{"reason": reason},
+	})
+	s.publish(ctx, accountID, EventAccountTokenRotated, map[string]string{
+		"accountId": accountID,
+		"reason":    reason,
+		"rotatedAt": time.Now().Format(time.RFC3339),
+	})
+	return tokens, nil
+}
+
+func (s *LifecycleService) findSession(ctx context.Context, id string) (*model.Session, error) {
+	session, err := s.sessions.FindByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("find session: %w", err)
+	}
+	if session == nil {
+		return nil, ErrSessionNotFound
+	}
+	return session, nil
+}
+
+func (s *LifecycleService) publishSessionDisconnected(ctx context.Context, session *model.Session, reason string) {
+	data := map[string]string{
+		"sessionId":      session.ID,
+		"reason":         reason,
+		"disconnectedAt": time.Now().Format(time.RFC3339),
+	}
+	if session.AccountID != nil {
+		data["accountId"] = *session.AccountID
+	}
+
+	s.publish(ctx, "session:"+session.ID, EventSessionDisconnected, data)
+	if session.AccountID != nil {
+		s.publish(ctx, *session.AccountID, EventSessionDisconnected, data)
+	}
+}
+
+// clearState drops the account's state for a conversation it no longer
+// holds. Like publish it only logs failures, as the conversation has
+// already changed state.
+func (s *LifecycleService) clearState(ctx context.Context, accountID, conversationKey string) {
+	if _, err := s.states.DeleteByConversation(ctx, accountID, conversationKey); err != nil {
+		log.Warn().Err(err).Str("conversationKey", conversationKey).Msg("failed to delete conversation state")
+	}
+}
+
+// publish logs rather than returns failures: the state change has already
+// been committed and clients resync on reconnect.
+func (s *LifecycleService) publish(ctx context.Context, channel, eventType string, data any) {
+	raw, err := json.Marshal(data)
+	if err == nil {
+		err = s.broker.Publish(ctx, channel, sse.Event{Type: eventType, Data: raw})
+	}
+	if err != nil {
+		log.Warn().Err(err).Str("channel", channel).Str("event", eventType).Msg("failed to publish lifecycle event")
+	}
+}
+
+func conversationEventData(conv *model.ConversationMapping, reason, atField string) map[string]string {
+	return map[string]string{
+		"conversationKey": conv.ConversationKey,
+		"kakaoUserId":     conv.PlusfriendUserKey,
+		"threadId":        conv.ThreadID,
+		"reason":          reason,
+		atField:           time.Now().Format(time.RFC3339),
+	}
+}
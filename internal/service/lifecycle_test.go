@@ -0,0 +1,379 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+// lifecycleSessionRepo mocks the SessionRepository methods lifecycle uses.
+type lifecycleSessionRepo struct {
+	repository.SessionRepository
+	mock.Mock
+}
+
+func (m *lifecycleSessionRepo) FindByID(ctx context.Context, id string) (*model.Session, error) {
+	args := m.Called(ctx, id)
+	session, _ := args.Get(0).(*model.Session)
+	return session, args.Error(1)
+}
+
+func (m *lifecycleSessionRepo) MarkDisconnected(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *lifecycleSessionRepo) MarkExpired(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *lifecycleSessionRepo) Delete(ctx context.Context, id string) error {
+	return m.Called(ctx, id).Error(0)
+}
+
+func (m *lifecycleSessionRepo) UpdateTokenHash(ctx context.Context, id, tokenHash string) error {
+	return m.Called(ctx, id, tokenHash).Error(0)
+}
+
+func (m *lifecycleSessionRepo) ClearRelayTokens(ctx context.Context, accountID string) error {
+	return m.Called(ctx, accountID).Error(0)
+}
+
+func (m *lifecycleSessionRepo) WithTx(tx *sqlx.Tx) repository.SessionRepository {
+	return m
+}
+
+// recordingStateRepo records the conversations whose state was deleted, as
+// "accountID/conversationKey".
+type recordingStateRepo struct {
+	repository.ConversationStateRepository
+	deleted []string
+}
+
+func (r *recordingStateRepo) DeleteByConversation(ctx context.Context, accountID, conversationKey string) (int64, error) {
+	r.deleted = append(r.deleted, accountID+"/"+conversationKey)
+	return 1, nil
+}
+
+func (r *recordingStateRepo) WithTx(tx *sqlx.Tx) repository.ConversationStateRepository {
+	return r
+}
+
+func deletedState(svc *LifecycleService) []string {
+	return svc.states.(*recordingStateRepo).deleted
+}
+
+func eventData(t *testing.T, raw []byte) map[string]string {
+	t.Helper()
+	var data map[string]string
+	require.NoError(t, json.Unmarshal(raw, &data))
+	return data
+}
+
+func TestCanTransitionConversation(t *testing.T) {
+	assert.True(t, CanTransitionConversation(model.PairingStatePaired, model.PairingStateUnpaired))
+	assert.True(t, CanTransitionConversation(model.PairingStatePending, model.PairingStatePaired))
+	assert.True(t, CanTransitionConversation(model.PairingStateBlocked, model.PairingStateUnpaired))
+	assert.False(t, CanTransitionConversation(model.PairingStateUnpaired, model.PairingStateUnpaired))
+	assert.False(t, CanTransitionConversation(model.PairingStateBlocked, model.PairingStatePaired))
+	assert.False(t, CanTransitionConversation(model.PairingStatePaired, model.PairingStatePending))
+}
+
+func TestCanTransitionSession(t *testing.T) {
+	assert.True(t, CanTransitionSession(model.SessionStatusPendingPairing, model.SessionStatusExpired))
+	assert.True(t, CanTransitionSession(model.SessionStatusPaired, model.SessionStatusDisconnected))
+	assert.False(t, CanTransitionSession(model.SessionStatusPaired, model.SessionStatusExpired))
+	assert.False(t, CanTransitionSession(model.SessionStatusExpired, model.SessionStatusDisconnected))
+	assert.False(t, CanTransitionSession(model.SessionStatusDisconnected, model.SessionStatusDisconnected))
+}
+
+func TestLifecycleService_UnpairConversation(t *testing.T) {
+	convs, publisher := new(mockConversationRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	conv := &model.ConversationMapping{
+		ConversationKey:   "bot:alice",
+		PlusfriendUserKey: "alice",
+		AccountID:         strPtr("acc-1"),
+		State:             model.PairingStatePaired,
+	}
+	convs.On("UpdateState", ctx, "bot:alice", model.PairingStateUnpaired, (*string)(nil)).Return(nil)
+
+	require.NoError(t, svc.UnpairConversation(ctx, conv, LifecycleReasonUser))
+	assert.Equal(t, model.PairingStateUnpaired, conv.State)
+	assert.Nil(t, conv.AccountID)
+
+	require.Len(t, publisher.events["acc-1"], 1)
+	event := publisher.events["acc-1"][0]
+	assert.Equal(t, EventConversationUnpaired, event.Type)
+	data := eventData(t, event.Data)
+	assert.Equal(t, "bot:alice", data["conversationKey"])
+	assert.Equal(t, "alice", data["kakaoUserId"])
+	assert.Equal(t, LifecycleReasonUser, data["reason"])
+	assert.NotEmpty(t, data["unpairedAt"])
+	assert.Equal(t, []string{"acc-1/bot:alice"}, deletedState(svc))
+}
+
+func TestLifecycleService_UnpairConversationRejectsInvalidTransition(t *testing.T) {
+	convs, publisher := new(mockConversationRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	conv := &model.ConversationMapping{ConversationKey: "bot:alice", State: model.PairingStateUnpaired}
+
+	err := svc.UnpairConversation(context.Background(), conv, LifecycleReasonUser)
+	assert.ErrorIs(t, err, ErrInvalidTransition)
+	convs.AssertNotCalled(t, "UpdateState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.events)
+}
+
+func TestLifecycleService_BlockAndUnblockConversation(t *testing.T) {
+	convs, publisher := new(mockConversationRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	conv := &model.ConversationMapping{
+		ConversationKey:   "bot:alice",
+		PlusfriendUserKey: "alice",
+		AccountID:         strPtr("acc-1"),
+		State:             model.PairingStatePaired,
+	}
+	convs.On("UpdateState", ctx, "bot:alice", model.PairingStateBlocked, conv.AccountID).Return(nil)
+	convs.On("UpdateState", ctx, "bot:alice", model.PairingStateUnpaired, (*string)(nil)).Return(nil)
+
+	require.NoError(t, svc.BlockConversation(ctx, conv, LifecycleReasonAccount))
+	assert.Equal(t, model.PairingStateBlocked, conv.State)
+	assert.Equal(t, "acc-1", *conv.AccountID, "a blocked conversation stays with its account")
+	assert.Empty(t, deletedState(svc), "blocking keeps the conversation state")
+	require.Len(t, publisher.events["acc-1"], 1)
+	assert.Equal(t, EventConversationBlocked, publisher.events["acc-1"][0].Type)
+	data := eventData(t, publisher.events["acc-1"][0].Data)
+	assert.Equal(t, LifecycleReasonAccount, data["reason"])
+	assert.NotEmpty(t, data["blockedAt"])
+
+	assert.ErrorIs(t, svc.BlockConversation(ctx, conv, LifecycleReasonAccount), ErrInvalidTransition)
+
+	require.NoError(t, svc.UnblockConversation(ctx, conv, LifecycleReasonAccount))
+	assert.Equal(t, model.PairingStateUnpaired, conv.State)
+	assert.Nil(t, conv.AccountID)
+	assert.Len(t, publisher.events["acc-1"], 1, "unblocking emits no event")
+	assert.Equal(t, []string{"acc-1/bot:alice"}, deletedState(svc))
+
+	assert.ErrorIs(t, svc.UnblockConversation(ctx, conv, LifecycleReasonAccount), ErrInvalidTransition)
+}
+
+func TestLifecycleService_BlockRequiresAccount(t *testing.T) {
+	convs := new(mockConversationRepo)
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, &recordingPublisher{})
+	conv := &model.ConversationMapping{ConversationKey: "bot:alice", State: model.PairingStateUnpaired}
+
+	assert.ErrorIs(t, svc.BlockConversation(context.Background(), conv, LifecycleReasonAccount), ErrInvalidTransition)
+	convs.AssertNotCalled(t, "UpdateState", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestLifecycleService_StartThread(t *testing.T) {
+	convs, publisher := new(mockConversationRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	conv := &model.ConversationMapping{
+		ConversationKey:   "bot:alice",
+		PlusfriendUserKey: "alice",
+		AccountID:         strPtr("acc-1"),
+		State:             model.PairingStatePaired,
+		ThreadID:          "thread-1",
+	}
+	convs.On("StartThread", ctx, "bot:alice").Return("thread-2", nil)
+
+	require.NoError(t, svc.StartThread(ctx, conv, LifecycleReasonUser))
+	assert.Equal(t, "thread-2", conv.ThreadID)
+	assert.Empty(t, deletedState(svc), "a new thread keeps the conversation state")
+
+	require.Len(t, publisher.events["acc-1"], 1)
+	event := publisher.events["acc-1"][0]
+	assert.Equal(t, EventThreadStarted, event.Type)
+	data := eventData(t, event.Data)
+	assert.Equal(t, "bot:alice", data["conversationKey"])
+	assert.Equal(t, "thread-2", data["threadId"])
+	assert.Equal(t, "thread-1", data["previousThreadId"])
+	assert.NotEmpty(t, data["startedAt"])
+
+	conv.State = model.PairingStateBlocked
+	assert.ErrorIs(t, svc.StartThread(ctx, conv, LifecycleReasonUser), ErrInvalidTransition)
+	convs.AssertNumberOfCalls(t, "StartThread", 1)
+}
+
+func TestLifecycleService_DeleteConversation(t *testing.T) {
+	convs, publisher := new(mockConversationRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, new(lifecycleSessionRepo), new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	convs.On("FindByID", ctx, "conv-1").Return(&model.ConversationMapping{
+		ID:                "conv-1",
+		ConversationKey:   "bot:alice",
+		PlusfriendUserKey: "alice",
+		AccountID:         strPtr("acc-1"),
+		State:             model.PairingStatePaired,
+	}, nil)
+	convs.On("Delete", ctx, "conv-1").Return(nil)
+
+	assert.ErrorIs(t, svc.DeleteConversation(ctx, "acc-2", "conv-1", LifecycleReasonAdmin), ErrConversationNotFound,
+		"conversations of other accounts are not visible")
+	convs.AssertNotCalled(t, "Delete", mock.Anything, mock.Anything)
+
+	require.NoError(t, svc.DeleteConversation(ctx, "acc-1", "conv-1", LifecycleReasonAdmin))
+	require.Len(t, publisher.events["acc-1"], 1)
+	assert.Equal(t, EventConversationDeleted, publisher.events["acc-1"][0].Type)
+	assert.Equal(t, LifecycleReasonAdmin, eventData(t, publisher.events["acc-1"][0].Data)["reason"])
+	assert.Equal(t, []string{"acc-1/bot:alice"}, deletedState(svc))
+}
+
+func TestLifecycleService_DisconnectSession(t *testing.T) {
+	convs, sessions, accounts, publisher := new(mockConversationRepo), new(lifecycleSessionRepo), new(mockAccountRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, sessions, accounts, &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	sessions.On("FindByID", ctx, "sess-1").Return(&model.Session{
+		ID:        "sess-1",
+		Status:    model.SessionStatusPaired,
+		AccountID: strPtr("acc-1"),
+	}, nil)
+	sessions.On("MarkDisconnected", ctx, "sess-1").Return(nil)
+	accounts.On("RevokeToken", ctx, "acc-1").Return(nil)
+	convs.On("UnpairByAccountID", ctx, "acc-1", model.PairingNoticeDisconnected).Return([]model.ConversationMapping{
+		{ConversationKey: "bot:alice", PlusfriendUserKey: "alice"},
+		{ConversationKey: "bot:bob", PlusfriendUserKey: "bob"},
+	}, nil)
+
+	require.NoError(t, svc.DisconnectSession(ctx, "sess-1", LifecycleReasonAdmin))
+	accounts.AssertExpectations(t)
+
+	require.Len(t, publisher.events["session:sess-1"], 1)
+	require.Len(t, publisher.events["acc-1"], 3)
+	for _, event := range []sse.Event{publisher.events["session:sess-1"][0], publisher.events["acc-1"][0]} {
+		assert.Equal(t, EventSessionDisconnected, event.Type)
+		data := eventData(t, event.Data)
+		assert.Equal(t, "sess-1", data["sessionId"])
+		assert.Equal(t, "acc-1", data["accountId"])
+	}
+	for i, user := range []string{"alice", "bob"} {
+		event := publisher.events["acc-1"][i+1]
+		assert.Equal(t, EventConversationUnpaired, event.Type)
+		assert.Equal(t, user, eventData(t, event.Data)["kakaoUserId"])
+	}
+	assert.Equal(t, []string{"session:sess-1", "acc-1"}, publisher.closed, "streams close after the events are sent")
+	assert.Equal(t, []string{"acc-1/bot:alice", "acc-1/bot:bob"}, deletedState(svc))
+}
+
+func TestLifecycleService_DisconnectRollsBackOnFailure(t *testing.T) {
+	convs, sessions, accounts, publisher := new(mockConversationRepo), new(lifecycleSessionRepo), new(mockAccountRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, convs, sessions, accounts, &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	sessions.On("FindByID", ctx, "sess-1").Return(&model.Session{
+		ID:        "sess-1",
+		Status:    model.SessionStatusPaired,
+		AccountID: strPtr("acc-1"),
+	}, nil)
+	sessions.On("MarkDisconnected", ctx, "sess-1").Return(nil)
+	accounts.On("RevokeToken", ctx, "acc-1").Return(nil)
+	convs.On("UnpairByAccountID", ctx, "acc-1", model.PairingNoticeDisconnected).Return(nil, assert.AnError)
+
+	err := svc.DisconnectSession(ctx, "sess-1", LifecycleReasonAdmin)
+	assert.ErrorIs(t, err, assert.AnError)
+	assert.Empty(t, publisher.events)
+	assert.Empty(t, publisher.closed)
+}
+
+func TestLifecycleService_DisconnectClosedSession(t *testing.T) {
+	sessions, publisher := new(lifecycleSessionRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, new(mockConversationRepo), sessions, new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	sessions.On("FindByID", ctx, "sess-1").Return(&model.Session{ID: "sess-1", Status: model.SessionStatusExpired}, nil)
+	sessions.On("FindByID", ctx, "sess-2").Return(nil, nil)
+
+	assert.ErrorIs(t, svc.DisconnectSession(ctx, "sess-1", LifecycleReasonAdmin), ErrInvalidTransition)
+	assert.ErrorIs(t, svc.DisconnectSession(ctx, "sess-2", LifecycleReasonAdmin), ErrSessionNotFound)
+	sessions.AssertNotCalled(t, "MarkDisconnected", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.events)
+}
+
+func TestLifecycleService_DeleteSession(t *testing.T) {
+	sessions, publisher := new(lifecycleSessionRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, new(mockConversationRepo), sessions, new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	sessions.On("FindByID", ctx, "live").Return(&model.Session{ID: "live", Status: model.SessionStatusPendingPairing}, nil)
+	sessions.On("FindByID", ctx, "done").Return(&model.Session{ID: "done", Status: model.SessionStatusDisconnected}, nil)
+	sessions.On("Delete", ctx, mock.Anything).Return(nil)
+
+	require.NoError(t, svc.DeleteSession(ctx, "live", LifecycleReasonAdmin))
+	require.NoError(t, svc.DeleteSession(ctx, "done", LifecycleReasonAdmin))
+
+	require.Len(t, publisher.events["session:live"], 1)
+	assert.Equal(t, EventSessionDisconnected, publisher.events["session:live"][0].Type)
+	assert.Empty(t, publisher.events["session:done"], "closed sessions were already disconnected")
+	assert.Equal(t, []string{"session:live"}, publisher.closed)
+	assert.Equal(t, 1, svc.db.(*fakeTx).calls)
+}
+
+func TestLifecycleService_ExpireSession(t *testing.T) {
+	sessions, publisher := new(lifecycleSessionRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, new(mockConversationRepo), sessions, new(mockAccountRepo), &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	session := &model.Session{ID: "sess-1", Status: model.SessionStatusPendingPairing, ExpiresAt: time.Now().Add(-time.Minute)}
+	sessions.On("MarkExpired", ctx, "sess-1").Return(nil)
+
+	require.NoError(t, svc.ExpireSession(ctx, session))
+	assert.Equal(t, model.SessionStatusExpired, session.Status)
+	require.Len(t, publisher.events["session:sess-1"], 1)
+	assert.Equal(t, EventPairingExpired, publisher.events["session:sess-1"][0].Type)
+
+	assert.ErrorIs(t, svc.ExpireSession(ctx, session), ErrInvalidTransition)
+	sessions.AssertNumberOfCalls(t, "MarkExpired", 1)
+}
+
+func TestLifecycleService_RotateToken(t *testing.T) {
+	accounts, sessions, publisher := new(mockAccountRepo), new(lifecycleSessionRepo), &recordingPublisher{}
+	svc := NewLifecycleService(&fakeTx{}, new(mockConversationRepo), sessions, accounts, &recordingStateRepo{}, publisher)
+	ctx := context.Background()
+	var storedHash string
+	accounts.On("UpdateToken", ctx, "acc-1", mock.Anything).
+		Run(func(args mock.Arguments) { storedHash = args.String(2) }).
+		Return(&model.Account{ID: "acc-1"}, nil)
+	accounts.On("UpdateToken", ctx, "missing", mock.Anything).Return(nil, nil)
+	sessions.On("ClearRelayTokens", ctx, "acc-1").Return(nil)
+
+	token, err := svc.RotateToken(ctx, "acc-1", LifecycleReasonAdmin)
+	require.NoError(t, err)
+	assert.Equal(t, util.HashToken(token), storedHash)
+	require.Len(t, publisher.events["acc-1"], 1)
+	assert.Equal(t, EventAccountTokenRotated, publisher.events["acc-1"][0].Type)
+	assert.NotContains(t, string(publisher.events["acc-1"][0].Data), token, "the new token is never broadcast")
+
+	sessions.AssertCalled(t, "ClearRelayTokens", ctx, "acc-1")
+	sessions.AssertNotCalled(t, "UpdateTokenHash", mock.Anything, mock.Anything, mock.Anything)
+
+	_, err = svc.RotateToken(ctx, "missing", LifecycleReasonAdmin)
+	assert.ErrorIs(t, err, ErrAccountNotFound)
+}
+
+func TestLifecycleService_RotateTokens(t *testing.T) {
+	accounts, sessions := new(mockAccountRepo), new(lifecycleSessionRepo)
+	svc := NewLifecycleService(&fakeTx{}, new(mockConversationRepo), sessions, accounts, &recordingStateRepo{}, &recordingPublisher{})
+	ctx := context.Background()
+	var sessionHash string
+	accounts.On("UpdateToken", ctx, "acc-1", mock.Anything).Return(&model.Account{ID: "acc-1"}, nil)
+	sessions.On("ClearRelayTokens", ctx, "acc-1").Return(nil)
+	sessions.On("UpdateTokenHash", ctx, "sess-1", mock.Anything).
+		Run(func(args mock.Arguments) { sessionHash = args.String(2) }).
+		Return(nil)
+
+	tokens, err := svc.RotateTokens(ctx, "acc-1", "sess-1", LifecycleReasonAdmin)
+	require.NoError(t, err)
+	require.NotEmpty(t, tokens.SessionToken)
+	assert.NotEqual(t, tokens.RelayToken, tokens.SessionToken)
+	assert.Equal(t, util.HashToken(tokens.SessionToken), sessionHash)
+}
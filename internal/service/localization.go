@@ -0,0 +1,390 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+const maxMessageOverrideLen = 1000
+
+// channelCacheTTL is how long a looked-up channel, or its absence, is
+// reused. Registry changes reach localization within this time.
+const channelCacheTTL = 30 * time.Second
+
+// maxCachedChannels bounds the channel cache; it is emptied when full.
+const maxCachedChannels = 1000
+
+var (
+	ErrInvalidLocalization = errors.New("invalid localization")
+	ErrAccountNotFound     = errors.New("account not found")
+)
+
+type LocalizationConfig struct {
+	DefaultLocale string
+	// ChannelLocales maps Kakao bot IDs to locales.
+	ChannelLocales map[string]string
+	// DetectFromUtterance records the locale of a user's first recognisable
+	// utterance and uses it when neither the account nor the channel sets one.
+	DetectFromUtterance bool
+}
+
+// LocalizationService resolves which locale and account overrides apply to
+// a conversation and manages overrides for the dashboard.
+type LocalizationService struct {
+	catalog   *i18n.Catalog
+	overrides repository.MessageOverrideRepository
+	accounts  repository.AccountRepository
+	convs     repository.ConversationRepository
+	channels  repository.ChannelRepository
+	cfg       LocalizationConfig
+	now       func() time.Time
+
+	mu           sync.Mutex
+	channelCache map[string]cachedChannel
+}
+
+type cachedChannel struct {
+	channel   *model.Channel
+	expiresAt time.Time
+}
+
+func NewLocalizationService(
+	catalog *i18n.Catalog,
+	overrides repository.MessageOverrideRepository,
+	accounts repository.AccountRepository,
+	convs repository.ConversationRepository,
+	channels repository.ChannelRepository,
+	cfg LocalizationConfig,
+) *LocalizationService {
+	defaultLocale := catalog.NormalizeLocale(cfg.DefaultLocale)
+	if defaultLocale == "" {
+		if cfg.DefaultLocale != "" {
+			log.Warn().Str("locale", cfg.DefaultLocale).Msg("unsupported default locale, using " + i18n.DefaultLocale)
+		}
+		defaultLocale = i18n.DefaultLocale
+	}
+	cfg.DefaultLocale = defaultLocale
+
+	channelLocales := make(map[string]string, len(cfg.ChannelLocales))
+	for channelID, tag := range cfg.ChannelLocales {
+		locale := catalog.NormalizeLocale(tag)
+		if locale == "" {
+			log.Warn().Str("channelId", channelID).Str("locale", tag).Msg("ignoring unsupported channel locale")
+			continue
+		}
+		channelLocales[channelID] = locale
+	}
+	cfg.ChannelLocales = channelLocales
+
+	return &LocalizationService{
+		catalog:   catalog,
+		overrides: overrides,
+		accounts:  accounts,
+		convs:     convs,
+		channels:  channels,
+		cfg:       cfg,
+		now:       time.Now,
+
+		channelCache: make(map[string]cachedChannel),
+	}
+}
+
+func (s *LocalizationService) Catalog() *i18n.Catalog {
+	return s.catalog
+}
+
+// Default returns a localizer for the configured default locale.
+func (s *LocalizationService) Default() *i18n.Localizer {
+	return i18n.NewLocalizer(s.catalog, s.cfg.DefaultLocale, nil)
+}
+
+// ResolveLocale picks the account locale, then the channel locale, then the
+// detected conversation locale, then the default. account may be nil.
+func (s *LocalizationService) ResolveLocale(account *model.Account, conv *model.ConversationMapping) string {
+	return s.resolveLocale(account, conv, nil)
+}
+
+// resolveLocale is ResolveLocale with the conversation's registered
+// channel, whose default locale takes precedence over CHANNEL_LOCALES.
+func (s *LocalizationService) resolveLocale(account *model.Account, conv *model.ConversationMapping, channel *model.Channel) string {
+	if account != nil && account.Locale != nil && s.catalog.Supports(*account.Locale) {
+		return *account.Locale
+	}
+	if conv != nil {
+		if channel != nil && channel.DefaultLocale != nil && s.catalog.Supports(*channel.DefaultLocale) {
+			return *channel.DefaultLocale
+		}
+		if locale, ok := s.cfg.ChannelLocales[conv.KakaoChannelID]; ok {
+			return locale
+		}
+		if s.cfg.DetectFromUtterance && conv.Locale != nil && s.catalog.Supports(*conv.Locale) {
+			return *conv.Locale
+		}
+	}
+	return s.cfg.DefaultLocale
+}
+
+// ForConversation returns the localizer for replies in conv, including the
+// paired account's overrides. Lookup failures fall back to catalog text so
+// the bot keeps answering.
+func (s *LocalizationService) ForConversation(ctx context.Context, conv *model.ConversationMapping) *i18n.Localizer {
+	return s.ForConversationInChannel(ctx, conv, s.findChannel(ctx, conv.KakaoChannelID))
+}
+
+// ForConversationInChannel is ForConversation with the conversation's
+// registered channel already resolved, as webhooks have it from the
+// signature middleware. channel is nil for unregistered bots.
+func (s *LocalizationService) ForConversationInChannel(ctx context.Context, conv *model.ConversationMapping, channel *model.Channel) *i18n.Localizer {
+	var account *model.Account
+	if conv.AccountID != nil {
+		var err error
+		account, err = s.accounts.FindByID(ctx, *conv.AccountID)
+		if err != nil {
+			log.Warn().Err(err).Str("accountId", *conv.AccountID).Msg("failed to load account for localization")
+		}
+	}
+
+	locale := s.resolveLocale(account, conv, channel)
+	if account == nil {
+		return i18n.NewLocalizer(s.catalog, locale, nil)
+	}
+
+	rows, err := s.overrides.FindByAccountAndLocale(ctx, account.ID, locale)
+	if err != nil {
+		log.Warn().Err(err).Str("accountId", account.ID).Msg("failed to load message overrides")
+	}
+	overrides := make(map[i18n.Key]string, len(rows))
+	for _, o := range rows {
+		overrides[i18n.Key(o.Key)] = o.Text
+	}
+	return i18n.NewLocalizer(s.catalog, locale, overrides)
+}
+
+// findChannel returns the registered channel, cached for channelCacheTTL.
+// Lookup failures are not cached.
+func (s *LocalizationService) findChannel(ctx context.Context, id string) *model.Channel {
+	if s.channels == nil {
+		return nil
+	}
+
+	now := s.now()
+	s.mu.Lock()
+	cached, ok := s.channelCache[id]
+	s.mu.Unlock()
+	if ok && cached.expiresAt.After(now) {
+		return cached.channel
+	}
+
+	channel, err := s.channels.FindByID(ctx, id)
+	if err != nil {
+		log.Warn().Err(err).Str("channelId", id).Msg("failed to load channel for localization")
+		return nil
+	}
+
+	s.mu.Lock()
+	if len(s.channelCache) >= maxCachedChannels {
+		clear(s.channelCache)
+	}
+	s.channelCache[id] = cachedChannel{channel: channel, expiresAt: now.Add(channelCacheTTL)}
+	s.mu.Unlock()
+	return channel
+}
+
+// ForConversationKey is ForConversation for a conversation known only by
+// key. An unknown conversation gets the default locale.
+func (s *LocalizationService) ForConversationKey(ctx context.Context, conversationKey string) *i18n.Localizer {
+	conv, err := s.convs.FindByKey(ctx, conversationKey)
+	if err != nil {
+		log.Warn().Err(err).Str("conversationKey", conversationKey).Msg("failed to load conversation for localization")
+	}
+	if conv == nil {
+		return s.Default()
+	}
+	return s.ForConversation(ctx, conv)
+}
+
+// ObserveUtterance records the conversation locale from the user's first
+// utterance whose language can be detected. It is a no-op unless detection
+// is enabled.
+func (s *LocalizationService) ObserveUtterance(ctx context.Context, conv *model.ConversationMapping, utterance string) {
+	if !s.cfg.DetectFromUtterance || conv.Locale != nil {
+		return
+	}
+	locale := i18n.DetectLocale(utterance)
+	if locale == "" || !s.catalog.Supports(locale) {
+		return
+	}
+	if err := s.convs.SetLocaleIfUnset(ctx, conv.ConversationKey, locale); err != nil {
+		log.Warn().Err(err).Str("conversationKey", conv.ConversationKey).Msg("failed to record conversation locale")
+		return
+	}
+	conv.Locale = &locale
+}
+
+// AccountLocalization is the dashboard view of an account's messages.
+type AccountLocalization struct {
+	AccountID string `json:"accountId"`
+	// Locale is the account's explicit locale, or nil to resolve per
+	// channel/conversation.
+	Locale        *string            `json:"locale"`
+	DefaultLocale string             `json:"defaultLocale"`
+	Locales       []string           `json:"locales"`
+	Messages      []LocalizedMessage `json:"messages"`
+}
+
+type LocalizedMessage struct {
+	Key          string   `json:"key"`
+	Placeholders []string `json:"placeholders"`
+	// Defaults and Overrides are keyed by locale.
+	Defaults  map[string]string `json:"defaults"`
+	Overrides map[string]string `json:"overrides"`
+}
+
+func (s *LocalizationService) GetAccount(ctx context.Context, accountID string) (*AccountLocalization, error) {
+	account, err := s.findAccount(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.overrides.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("find message overrides: %w", err)
+	}
+	byKey := make(map[string]map[string]string)
+	for _, o := range rows {
+		if byKey[o.Key] == nil {
+			byKey[o.Key] = make(map[string]string)
+		}
+		byKey[o.Key][o.Locale] = o.Text
+	}
+
+	locales := s.catalog.Locales()
+	result := &AccountLocalization{
+		AccountID:     account.ID,
+		Locale:        account.Locale,
+		DefaultLocale: s.cfg.DefaultLocale,
+		Locales:       locales,
+	}
+	for _, key := range s.catalog.Keys() {
+		msg := LocalizedMessage{
+			Key:          string(key),
+			Placeholders: i18n.Placeholders(s.catalog.Template(i18n.DefaultLocale, key)),
+			Defaults:     make(map[string]string, len(locales)),
+			Overrides:    byKey[string(key)],
+		}
+		if msg.Placeholders == nil {
+			msg.Placeholders = []string{}
+		}
+		if msg.Overrides == nil {
+			msg.Overrides = map[string]string{}
+		}
+		for _, locale := range locales {
+			msg.Defaults[locale] = s.catalog.Template(locale, key)
+		}
+		result.Messages = append(result.Messages, msg)
+	}
+	return result, nil
+}
+
+// SetAccountLocale sets the account's locale; an empty locale clears it.
+func (s *LocalizationService) SetAccountLocale(ctx context.Context, accountID, locale string) (*model.Account, error) {
+	var value *string
+	if locale != "" {
+		normalized := s.catalog.NormalizeLocale(locale)
+		if normalized == "" {
+			return nil, fmt.Errorf("%w: unsupported locale %q", ErrInvalidLocalization, locale)
+		}
+		value = &normalized
+	}
+
+	account, err := s.accounts.UpdateLocale(ctx, accountID, value)
+	if err != nil {
+		return nil, fmt.Errorf("update account locale: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// SetOverride stores text in place of the catalog message key for one
+// account and locale. text may only use the placeholders of the original.
+func (s *LocalizationService) SetOverride(ctx context.Context, accountID, locale, key, text string) (*model.MessageOverride, error) {
+	locale, err := s.validateOverride(locale, key)
+	if err != nil {
+		return nil, err
+	}
+
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("%w: text is required", ErrInvalidLocalization)
+	}
+	if utf8.RuneCountInString(text) > maxMessageOverrideLen {
+		return nil, fmt.Errorf("%w: text exceeds %d characters", ErrInvalidLocalization, maxMessageOverrideLen)
+	}
+	allowed := make(map[string]bool)
+	for _, name := range i18n.Placeholders(s.catalog.Template(i18n.DefaultLocale, i18n.Key(key))) {
+		allowed[name] = true
+	}
+	for _, name := range i18n.Placeholders(text) {
+		if !allowed[name] {
+			return nil, fmt.Errorf("%w: unknown placeholder {%s} for %s", ErrInvalidLocalization, name, key)
+		}
+	}
+
+	if _, err := s.findAccount(ctx, accountID); err != nil {
+		return nil, err
+	}
+	override, err := s.overrides.Upsert(ctx, accountID, locale, key, text)
+	if err != nil {
+		return nil, fmt.Errorf("upsert message override: %w", err)
+	}
+	return override, nil
+}
+
+// DeleteOverride restores the catalog text; it reports whether an override
+// existed.
+func (s *LocalizationService) DeleteOverride(ctx context.Context, accountID, locale, key string) (bool, error) {
+	locale, err := s.validateOverride(locale, key)
+	if err != nil {
+		return false, err
+	}
+	deleted, err := s.overrides.Delete(ctx, accountID, locale, key)
+	if err != nil {
+		return false, fmt.Errorf("delete message override: %w", err)
+	}
+	return deleted, nil
+}
+
+func (s *LocalizationService) validateOverride(locale, key string) (string, error) {
+	normalized := s.catalog.NormalizeLocale(locale)
+	if normalized == "" {
+		return "", fmt.Errorf("%w: unsupported locale %q", ErrInvalidLocalization, locale)
+	}
+	if !s.catalog.Has(i18n.Key(key)) {
+		return "", fmt.Errorf("%w: unknown message key %q", ErrInvalidLocalization, key)
+	}
+	return normalized, nil
+}
+
+func (s *LocalizationService) findAccount(ctx context.Context, accountID string) (*model.Account, error) {
+	account, err := s.accounts.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("find account: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
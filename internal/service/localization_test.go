@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/i18n"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type mockMessageOverrideRepo struct {
+	mock.Mock
+}
+
+func (m *mockMessageOverrideRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.MessageOverride, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.MessageOverride), args.Error(1)
+}
+
+func (m *mockMessageOverrideRepo) FindByAccountAndLocale(ctx context.Context, accountID, locale string) ([]model.MessageOverride, error) {
+	args := m.Called(ctx, accountID, locale)
+	return args.Get(0).([]model.MessageOverride), args.Error(1)
+}
+
+func (m *mockMessageOverrideRepo) Upsert(ctx context.Context, accountID, locale, key, text string) (*model.MessageOverride, error) {
+	args := m.Called(ctx, accountID, locale, key, text)
+	return args.Get(0).(*model.MessageOverride), args.Error(1)
+}
+
+func (m *mockMessageOverrideRepo) Delete(ctx context.Context, accountID, locale, key string) (bool, error) {
+	args := m.Called(ctx, accountID, locale, key)
+	return args.Bool(0), args.Error(1)
+}
+
+func TestLocalizationService_ResolveLocale(t *testing.T) {
+	svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), new(mockConversationRepo), nil, LocalizationConfig{
+		DefaultLocale:       "en-US",
+		ChannelLocales:      map[string]string{"bot-ko": "ko", "bot-xx": "xx"},
+		DetectFromUtterance: true,
+	})
+	conv := &model.ConversationMapping{KakaoChannelID: "bot-ko", Locale: strPtr("en")}
+
+	assert.Equal(t, "en", svc.ResolveLocale(&model.Account{Locale: strPtr("en")}, conv), "account wins")
+	assert.Equal(t, "ko", svc.ResolveLocale(&model.Account{}, conv), "then channel")
+
+	conv.KakaoChannelID = "bot-xx"
+	conv.Locale = strPtr("ko")
+	assert.Equal(t, "ko", svc.ResolveLocale(nil, conv), "unsupported channel locales are ignored")
+
+	conv.Locale = nil
+	assert.Equal(t, "en", svc.ResolveLocale(nil, conv), "then default")
+}
+
+func TestLocalizationService_ForConversation(t *testing.T) {
+	ctx := context.Background()
+	overrides, accounts := new(mockMessageOverrideRepo), new(mockAccountRepo)
+	svc := NewLocalizationService(i18n.NewCatalog(), overrides, accounts, new(mockConversationRepo), nil, LocalizationConfig{})
+
+	accounts.On("FindByID", ctx, "acc-1").Return(&model.Account{ID: "acc-1", Locale: strPtr("en")}, nil)
+	overrides.On("FindByAccountAndLocale", ctx, "acc-1", "en").
+		Return([]model.MessageOverride{{Key: string(i18n.MsgPairSuccess), Text: "Welcome aboard!"}}, nil)
+
+	l := svc.ForConversation(ctx, &model.ConversationMapping{AccountID: strPtr("acc-1")})
+
+	assert.Equal(t, "en", l.Locale())
+	assert.Equal(t, "Welcome aboard!", l.T(i18n.MsgPairSuccess))
+	assert.Equal(t, "Pairing failed.", l.T(i18n.MsgPairFailed))
+}
+
+func TestLocalizationService_ObserveUtterance(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("records the first detectable locale", func(t *testing.T) {
+		convs := new(mockConversationRepo)
+		svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), convs, nil, LocalizationConfig{DetectFromUtterance: true})
+		convs.On("SetLocaleIfUnset", ctx, "ch:u", "en").Return(nil).Once()
+		conv := &model.ConversationMapping{ConversationKey: "ch:u"}
+
+		svc.ObserveUtterance(ctx, conv, "/help")
+		assert.Nil(t, conv.Locale)
+
+		svc.ObserveUtterance(ctx, conv, "hello there")
+		require.NotNil(t, conv.Locale)
+		assert.Equal(t, "en", *conv.Locale)
+
+		svc.ObserveUtterance(ctx, conv, "안녕하세요")
+		assert.Equal(t, "en", *conv.Locale)
+		convs.AssertExpectations(t)
+	})
+
+	t.Run("disabled by default", func(t *testing.T) {
+		convs := new(mockConversationRepo)
+		svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), convs, nil, LocalizationConfig{})
+		svc.ObserveUtterance(ctx, &model.ConversationMapping{ConversationKey: "ch:u"}, "hello")
+		convs.AssertNotCalled(t, "SetLocaleIfUnset", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestLocalizationService_SetOverride(t *testing.T) {
+	ctx := context.Background()
+	key := string(i18n.MsgStatusPairedBrief)
+
+	invalid := []struct {
+		name, locale, key, text string
+	}{
+		{"unsupported locale", "ja", key, "x"},
+		{"unknown key", "en", "nope", "x"},
+		{"empty text", "en", key, "  "},
+		{"unknown placeholder", "en", key, "since {when}"},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), new(mockAccountRepo), new(mockConversationRepo), nil, LocalizationConfig{})
+			_, err := svc.SetOverride(ctx, "acc-1", tt.locale, tt.key, tt.text)
+			assert.ErrorIs(t, err, ErrInvalidLocalization)
+		})
+	}
+
+	t.Run("stores normalized override", func(t *testing.T) {
+		overrides, accounts := new(mockMessageOverrideRepo), new(mockAccountRepo)
+		svc := NewLocalizationService(i18n.NewCatalog(), overrides, accounts, new(mockConversationRepo), nil, LocalizationConfig{})
+		accounts.On("FindByID", ctx, "acc-1").Return(&model.Account{ID: "acc-1"}, nil)
+		overrides.On("Upsert", ctx, "acc-1", "en", key, "Linked {pairedAt}").
+			Return(&model.MessageOverride{Locale: "en", Key: key, Text: "Linked {pairedAt}"}, nil)
+
+		override, err := svc.SetOverride(ctx, "acc-1", "en-GB", key, " Linked {pairedAt} ")
+
+		require.NoError(t, err)
+		assert.Equal(t, "Linked {pairedAt}", override.Text)
+	})
+
+	t.Run("unknown account", func(t *testing.T) {
+		accounts := new(mockAccountRepo)
+		svc := NewLocalizationService(i18n.NewCatalog(), new(mockMessageOverrideRepo), accounts, new(mockConversationRepo), nil, LocalizationConfig{})
+		accounts.On("FindByID", ctx, "missing").Return(nil, nil)
+
+		_, err := svc.SetOverride(ctx, "missing", "en", key, "x")
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+// MediaRefPrefix marks a string in a reply template as an uploaded media
+// reference, e.g. "imageUrl": "media://<id>".
+const MediaRefPrefix = "media://"
+
+const mediaPurgeBatch = 100
+
+var DefaultMediaTypes = []string{"image/jpeg", "image/png", "image/gif", "image/webp"}
+
+var (
+	ErrMediaTooLarge       = errors.New("media exceeds size limit")
+	ErrMediaTypeNotAllowed = errors.New("media type not allowed")
+	ErrMediaEmpty          = errors.New("media is empty")
+	ErrMediaNotFound       = errors.New("media not found")
+)
+
+type MediaConfig struct {
+	MaxBytes      int64
+	AllowedTypes  []string
+	TTL           time.Duration
+	PublicBaseURL string
+}
+
+type MediaService struct {
+	repo  repository.MediaRepository
+	store storage.BlobStore
+	cfg   MediaConfig
+	now   func() time.Time
+}
+
+func NewMediaService(repo repository.MediaRepository, store storage.BlobStore, cfg MediaConfig) *MediaService {
+	if len(cfg.AllowedTypes) == 0 {
+		cfg.AllowedTypes = DefaultMediaTypes
+	}
+	return &MediaService{
+		repo:  repo,
+		store: store,
+		cfg:   cfg,
+		now:   time.Now,
+	}
+}
+
+// Enabled reports whether uploaded media can be given public URLs.
+func (s *MediaService) Enabled() bool {
+	return s != nil && s.cfg.PublicBaseURL != ""
+}
+
+func (s *MediaService) MaxBytes() int64 {
+	return s.cfg.MaxBytes
+}
+
+// Upload stores an agent-provided file. The content type is sniffed from the
+// bytes rather than trusted from the client.
+func (s *MediaService) Upload(ctx context.Context, accountID string, body io.Reader, filename string) (*model.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(body, s.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("read upload: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, ErrMediaEmpty
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return nil, ErrMediaTooLarge
+	}
+
+	contentType := http.DetectContentType(data)
+	if !util.IsValidEnum(contentType, s.cfg.AllowedTypes) {
+		return nil, fmt.Errorf("%w: %s", ErrMediaTypeNotAllowed, contentType)
+	}
+
+	token, err := util.GenerateToken()
+	if err != nil {
+		return nil, fmt.Errorf("generate token: %w", err)
+	}
+
+	key := fmt.Sprintf("media/%s/%s", accountID, token)
+	if err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType); err != nil {
+		return nil, fmt.Errorf("store blob: %w", err)
+	}
+
+	var filenamePtr *string
+	if filename != "" {
+		filenamePtr = &filename
+	}
+
+	media, err := s.repo.Create(ctx, model.CreateMediaParams{
+		AccountID:   accountID,
+		PublicToken: token,
+		StorageKey:  key,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		Filename:    filenamePtr,
+		ExpiresAt:   s.now().Add(s.cfg.TTL),
+	})
+	if err != nil {
+		if delErr := s.store.Delete(ctx, key); delErr != nil {
+			log.Warn().Err(delErr).Str("key", key).Msg("failed to remove orphaned media blob")
+		}
+		return nil, err
+	}
+	return media, nil
+}
+
+func (s *MediaService) PublicURL(media *model.Media) string {
+	return strings.TrimRight(s.cfg.PublicBaseURL, "/") + "/media/" + media.PublicToken
+}
+
+// OpenPublic returns the media for a public token, or ErrMediaNotFound when
+// the token is unknown or expired.
+func (s *MediaService) OpenPublic(ctx context.Context, token string) (*model.Media, io.ReadCloser, error) {
+	media, err := s.repo.FindByPublicToken(ctx, token)
+	if err != nil {
+		return nil, nil, err
+	}
+	if media == nil || s.now().After(media.ExpiresAt) {
+		return nil, nil, ErrMediaNotFound
+	}
+
+	body, _, err := s.store.Get(ctx, media.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrMediaNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return media, body, nil
+}
+
+// ResolveReferences rewrites every "media://<id>" string in a Kakao template
+// into the public URL of that upload. The media must belong to accountID
+// and must not be expired.
+func (s *MediaService) ResolveReferences(ctx context.Context, accountID string, payload json.RawMessage) (json.RawMessage, error) {
+	if !bytes.Contains(payload, []byte(MediaRefPrefix)) {
+		return payload, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(payload))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode payload: %w", err)
+	}
+
+	resolved := make(map[string]string)
+	var resolveErr error
+
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch t := v.(type) {
+		case map[string]any:
+			for k, child := range t {
+				t[k] = walk(child)
+			}
+		case []any:
+			for i, child := range t {
+				t[i] = walk(child)
+			}
+		case string:
+			if !strings.HasPrefix(t, MediaRefPrefix) || resolveErr != nil {
+				return t
+			}
+			id := strings.TrimPrefix(t, MediaRefPrefix)
+			if u, ok := resolved[id]; ok {
+				return u
+			}
+			u, err := s.resolve(ctx, accountID, id)
+			if err != nil {
+				resolveErr = err
+				return t
+			}
+			resolved[id] = u
+			return u
+		}
+		return v
+	}
+
+	doc = walk(doc)
+	if resolveErr != nil {
+		return nil, resolveErr
+	}
+
+	return json.Marshal(doc)
+}
+
+func (s *MediaService) resolve(ctx context.Context, accountID, id string) (string, error) {
+	if !util.IsValidUUID(id) {
+		return "", fmt.Errorf("%w: %s", ErrMediaNotFound, id)
+	}
+
+	media, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+	if media == nil || media.AccountID != accountID || s.now().After(media.ExpiresAt) {
+		return "", fmt.Errorf("%w: %s", ErrMediaNotFound, id)
+	}
+	return s.PublicURL(media), nil
+}
+
+// PurgeExpired deletes expired uploads from the blob store and database.
+func (s *MediaService) PurgeExpired(ctx context.Context) (int64, error) {
+	items, err := s.repo.FindExpired(ctx, s.now(), mediaPurgeBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, m := range items {
+		if err := s.store.Delete(ctx, m.StorageKey); err != nil {
+			log.Warn().Err(err).Str("mediaId", m.ID).Msg("failed to delete media blob")
+			continue
+		}
+		if err := s.repo.Delete(ctx, m.ID); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
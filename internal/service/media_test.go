@@ -0,0 +1,204 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/storage"
+)
+
+type mockMediaRepo struct {
+	mock.Mock
+}
+
+func (m *mockMediaRepo) FindByID(ctx context.Context, id string) (*model.Media, error) {
+	args := m.Called(ctx, id)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Media), args.Error(1)
+}
+
+func (m *mockMediaRepo) FindByPublicToken(ctx context.Context, token string) (*model.Media, error) {
+	args := m.Called(ctx, token)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Media), args.Error(1)
+}
+
+func (m *mockMediaRepo) FindExpired(ctx context.Context, now time.Time, limit int) ([]model.Media, error) {
+	args := m.Called(ctx, now, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.Media), args.Error(1)
+}
+
+func (m *mockMediaRepo) Create(ctx context.Context, params model.CreateMediaParams) (*model.Media, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.Media), args.Error(1)
+}
+
+func (m *mockMediaRepo) Delete(ctx context.Context, id string) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+const testMediaID = "11111111-2222-3333-4444-555555555555"
+
+func newTestMediaService(t *testing.T, repo *mockMediaRepo) (*MediaService, *storage.LocalStore) {
+	store, err := storage.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	svc := NewMediaService(repo, store, MediaConfig{
+		MaxBytes:      64,
+		TTL:           time.Hour,
+		PublicBaseURL: "https://relay.example.com",
+	})
+	return svc, store
+}
+
+func TestMediaService_Upload(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores sniffed image", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, store := newTestMediaService(t, repo)
+
+		repo.On("Create", ctx, mock.MatchedBy(func(p model.CreateMediaParams) bool {
+			return p.AccountID == "acc-1" && p.ContentType == "image/png" &&
+				p.SizeBytes == int64(len(pngHeader)) && len(p.PublicToken) == 64 &&
+				p.StorageKey == "media/acc-1/"+p.PublicToken && *p.Filename == "chart.png"
+		})).Return(&model.Media{ID: testMediaID, StorageKey: "k"}, nil)
+
+		media, err := svc.Upload(ctx, "acc-1", strings.NewReader(pngHeader), "chart.png")
+		require.NoError(t, err)
+		assert.Equal(t, testMediaID, media.ID)
+		repo.AssertExpectations(t)
+
+		params := repo.Calls[0].Arguments.Get(1).(model.CreateMediaParams)
+		_, _, err = store.Get(ctx, params.StorageKey)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects disallowed type regardless of filename", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+
+		_, err := svc.Upload(ctx, "acc-1", strings.NewReader("<html><script>alert(1)</script>"), "x.png")
+		assert.ErrorIs(t, err, ErrMediaTypeNotAllowed)
+		repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("rejects oversized upload", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+
+		_, err := svc.Upload(ctx, "acc-1", strings.NewReader(pngHeader+strings.Repeat("x", 64)), "")
+		assert.ErrorIs(t, err, ErrMediaTooLarge)
+	})
+
+	t.Run("rejects empty upload", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+
+		_, err := svc.Upload(ctx, "acc-1", bytes.NewReader(nil), "")
+		assert.ErrorIs(t, err, ErrMediaEmpty)
+	})
+}
+
+func TestMediaService_ResolveReferences(t *testing.T) {
+	ctx := context.Background()
+	media := &model.Media{
+		ID:          testMediaID,
+		AccountID:   "acc-1",
+		PublicToken: "tok",
+		ExpiresAt:   time.Now().Add(time.Hour),
+	}
+
+	t.Run("rewrites nested references", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+		repo.On("FindByID", ctx, testMediaID).Return(media, nil).Once()
+
+		payload := json.RawMessage(`{"version":"2.0","template":{"outputs":[
+			{"simpleImage":{"imageUrl":"media://` + testMediaID + `","altText":"chart"}},
+			{"basicCard":{"thumbnail":{"imageUrl":"media://` + testMediaID + `"},"buttons":[{"messageText":"1234567890123"}]}}
+		]}}`)
+
+		out, err := svc.ResolveReferences(ctx, "acc-1", payload)
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(out), "media://")
+		assert.Equal(t, 2, strings.Count(string(out), "https://relay.example.com/media/tok"))
+		assert.Contains(t, string(out), `"messageText":"1234567890123"`)
+		repo.AssertExpectations(t)
+	})
+
+	t.Run("passes through payload without references", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+		payload := json.RawMessage(`{"version":"2.0"}`)
+
+		out, err := svc.ResolveReferences(ctx, "acc-1", payload)
+		require.NoError(t, err)
+		assert.Equal(t, payload, out)
+	})
+
+	t.Run("rejects media of another account", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+		repo.On("FindByID", ctx, testMediaID).Return(media, nil)
+
+		_, err := svc.ResolveReferences(ctx, "acc-2", json.RawMessage(`{"imageUrl":"media://`+testMediaID+`"}`))
+		assert.ErrorIs(t, err, ErrMediaNotFound)
+	})
+
+	t.Run("rejects malformed id without lookup", func(t *testing.T) {
+		repo := new(mockMediaRepo)
+		svc, _ := newTestMediaService(t, repo)
+
+		_, err := svc.ResolveReferences(ctx, "acc-1", json.RawMessage(`{"imageUrl":"media://nope"}`))
+		assert.ErrorIs(t, err, ErrMediaNotFound)
+		repo.AssertNotCalled(t, "FindByID", mock.Anything, mock.Anything)
+	})
+}
+
+func TestMediaService_OpenPublic(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockMediaRepo)
+	svc, store := newTestMediaService(t, repo)
+	require.NoError(t, store.Put(ctx, "media/acc-1/tok", strings.NewReader(pngHeader), -1, "image/png"))
+
+	live := &model.Media{ID: testMediaID, StorageKey: "media/acc-1/tok", ExpiresAt: time.Now().Add(time.Hour)}
+	expired := &model.Media{ID: testMediaID, StorageKey: "media/acc-1/tok", ExpiresAt: time.Now().Add(-time.Minute)}
+
+	repo.On("FindByPublicToken", ctx, "live").Return(live, nil)
+	repo.On("FindByPublicToken", ctx, "expired").Return(expired, nil)
+	repo.On("FindByPublicToken", ctx, "unknown").Return(nil, nil)
+
+	_, body, err := svc.OpenPublic(ctx, "live")
+	require.NoError(t, err)
+	data, _ := io.ReadAll(body)
+	body.Close()
+	assert.Equal(t, pngHeader, string(data))
+
+	_, _, err = svc.OpenPublic(ctx, "expired")
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+
+	_, _, err = svc.OpenPublic(ctx, "unknown")
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+}
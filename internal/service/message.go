@@ -20,6 +20,16 @@ type CreateInboundParams struct {
 	CallbackURL       *string
 	CallbackExpiresAt *time.Time
 	SourceEventID     *string
+	ThreadID          *string
+	// HoldTimeout enables ordered delivery for the message; see
+	// OrderingService.
+	HoldTimeout time.Duration
+	// CoalesceWindow keeps the message back for follow-up utterances; see
+	// CoalescingService.
+	CoalesceWindow time.Duration
+	// RoutingRuleID records the routing rule that chose AccountID; see
+	// RoutingService.
+	RoutingRuleID *string
 }
 
 type MessageService struct {
@@ -38,7 +48,7 @@ func NewMessageService(
 }
 
 func (s *MessageService) CreateInbound(ctx context.Context, params CreateInboundParams) (*model.InboundMessage, error) {
-	msg, err := s.inboundRepo.Create(ctx, model.CreateInboundMessageParams{
+	create := model.CreateInboundMessageParams{
 		AccountID:         params.AccountID,
 		ConversationKey:   params.ConversationKey,
 		KakaoPayload:      params.KakaoPayload,
@@ -46,7 +56,15 @@ func (s *MessageService) CreateInbound(ctx context.Context, params CreateInbound
 		CallbackURL:       params.CallbackURL,
 		CallbackExpiresAt: params.CallbackExpiresAt,
 		SourceEventID:     params.SourceEventID,
-	})
+		ThreadID:          params.ThreadID,
+		HoldTimeout:       params.HoldTimeout,
+		CoalesceWindow:    params.CoalesceWindow,
+		RoutingRuleID:     params.RoutingRuleID,
+	}
+	if params.CoalesceWindow > 0 {
+		create.CoalesceDeadlineMargin = coalesceDeadlineMargin
+	}
+	msg, err := s.inboundRepo.Create(ctx, create)
 	if err != nil {
 		return nil, fmt.Errorf("create inbound message: %w", err)
 	}
@@ -55,6 +73,7 @@ func (s *MessageService) CreateInbound(ctx context.Context, params CreateInbound
 		Str("messageId", msg.ID).
 		Str("accountId", params.AccountID).
 		Str("conversationKey", params.ConversationKey).
+		Bool("held", msg.Held).
 		Msg("inbound message created")
 
 	return msg, nil
@@ -84,6 +103,17 @@ func (s *MessageService) MarkAcked(ctx context.Context, id string) error {
 	return nil
 }
 
+// AckInbound marks the account's delivered messages among ids as handled
+// so they are not offered again, and returns how many were acked.
+func (s *MessageService) AckInbound(ctx context.Context, accountID string, ids []string) (int64, error) {
+	acked, err := s.inboundRepo.AckMessages(ctx, accountID, ids)
+	if err != nil {
+		return 0, fmt.Errorf("ack messages: %w", err)
+	}
+	log.Debug().Str("accountId", accountID).Int64("count", acked).Msg("messages acked")
+	return acked, nil
+}
+
 func (s *MessageService) CreateOutbound(ctx context.Context, params model.CreateOutboundMessageParams) (*model.OutboundMessage, error) {
 	msg, err := s.outboundRepo.Create(ctx, params)
 	if err != nil {
@@ -107,6 +137,10 @@ func (s *MessageService) MarkOutboundFailed(ctx context.Context, id, errorMsg st
 	return s.outboundRepo.MarkFailed(ctx, id, errorMsg)
 }
 
+func (s *MessageService) MarkOutboundEventAccepted(ctx context.Context, id, taskID, eventStatus string) error {
+	return s.outboundRepo.MarkEventAccepted(ctx, id, taskID, eventStatus)
+}
+
 // QuickStats represents basic message statistics for display in chat
 type QuickStats struct {
 	InboundToday   int
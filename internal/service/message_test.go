@@ -84,8 +84,8 @@ func (m *mockInboundRepo) CountByAccountIDSince(ctx context.Context, accountID s
 	return args.Int(0), args.Error(1)
 }
 
-func (m *mockInboundRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.InboundMessage, error) {
-	args := m.Called(ctx, conversationKey, limit, offset)
+func (m *mockInboundRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, threadID, before, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -102,6 +102,68 @@ func (m *mockInboundRepo) CountByConversationKeySince(ctx context.Context, conve
 	return args.Int(0), args.Error(1)
 }
 
+func (m *mockInboundRepo) FindClaimable(ctx context.Context, accountID string, limit int) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, limit)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) Claim(ctx context.Context, ids []string, consumerID string, ttl time.Duration) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, ids, consumerID, ttl)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseLeases(ctx context.Context, consumerID string) (int64, error) {
+	args := m.Called(ctx, consumerID)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockInboundRepo) AckMessages(ctx context.Context, accountID string, ids []string) (int64, error) {
+	args := m.Called(ctx, accountID, ids)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseNext(ctx context.Context, accountID, conversationKey string, holdTimeout time.Duration) (*model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, holdTimeout)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseDue(ctx context.Context, holdTimeout, deadlineMargin time.Duration) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, holdTimeout, deadlineMargin)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) ReleaseAllHeld(ctx context.Context, accountID string) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) FindCoalescing(ctx context.Context, accountID, conversationKey string) ([]model.InboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey)
+	return args.Get(0).([]model.InboundMessage), args.Error(1)
+}
+
+func (m *mockInboundRepo) FindDueCoalescing(ctx context.Context) ([]model.ConversationRef, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.ConversationRef), args.Error(1)
+}
+
+func (m *mockInboundRepo) CompleteCoalescing(ctx context.Context, params model.CompleteCoalescingParams) (*model.InboundMessage, error) {
+	args := m.Called(ctx, params)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*model.InboundMessage), args.Error(1)
+}
+
 type mockOutboundRepo struct {
 	mock.Mock
 }
@@ -153,6 +215,11 @@ func (m *mockOutboundRepo) MarkFailed(ctx context.Context, id string, errorMsg s
 	return args.Error(0)
 }
 
+func (m *mockOutboundRepo) MarkEventAccepted(ctx context.Context, id, taskID, eventStatus string) error {
+	args := m.Called(ctx, id, taskID, eventStatus)
+	return args.Error(0)
+}
+
 func (m *mockOutboundRepo) CountByAccountIDAndStatus(ctx context.Context, accountID string, status model.OutboundMessageStatus) (int, error) {
 	args := m.Called(ctx, accountID, status)
 	return args.Int(0), args.Error(1)
@@ -171,8 +238,8 @@ func (m *mockOutboundRepo) FindRecentFailedByAccountID(ctx context.Context, acco
 	return args.Get(0).([]model.OutboundMessage), args.Error(1)
 }
 
-func (m *mockOutboundRepo) FindByConversationKey(ctx context.Context, conversationKey string, limit, offset int) ([]model.OutboundMessage, error) {
-	args := m.Called(ctx, conversationKey, limit, offset)
+func (m *mockOutboundRepo) FindByConversationKey(ctx context.Context, accountID, conversationKey, threadID string, before *model.MessageCursor, limit int) ([]model.OutboundMessage, error) {
+	args := m.Called(ctx, accountID, conversationKey, threadID, before, limit)
 	if args.Get(0) == nil {
 		return nil, args.Error(1)
 	}
@@ -248,6 +315,25 @@ func TestMessageService_CreateInbound(t *testing.T) {
 		assert.Contains(t, err.Error(), "create inbound message")
 		inboundRepo.AssertExpectations(t)
 	})
+
+	t.Run("bounds coalescing by the callback deadline", func(t *testing.T) {
+		inboundRepo := new(mockInboundRepo)
+		svc := NewMessageService(inboundRepo, new(mockOutboundRepo))
+		ctx := context.Background()
+
+		inboundRepo.On("Create", ctx, mock.MatchedBy(func(p model.CreateInboundMessageParams) bool {
+			return p.CoalesceWindow == 5*time.Second && p.CoalesceDeadlineMargin == coalesceDeadlineMargin
+		})).Return(&model.InboundMessage{ID: "msg-1"}, nil).Once()
+		inboundRepo.On("Create", ctx, mock.MatchedBy(func(p model.CreateInboundMessageParams) bool {
+			return p.CoalesceWindow == 0 && p.CoalesceDeadlineMargin == 0
+		})).Return(&model.InboundMessage{ID: "msg-2"}, nil).Once()
+
+		_, err := svc.CreateInbound(ctx, CreateInboundParams{AccountID: "acc-1", CoalesceWindow: 5 * time.Second})
+		assert.NoError(t, err)
+		_, err = svc.CreateInbound(ctx, CreateInboundParams{AccountID: "acc-1"})
+		assert.NoError(t, err)
+		inboundRepo.AssertExpectations(t)
+	})
 }
 
 func TestMessageService_FindInboundByID(t *testing.T) {
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+// heldDeadlineMargin is how long before its callback expires a held
+// message is released regardless of order, so the client still has time
+// to answer through the callback.
+const heldDeadlineMargin = 20 * time.Second
+
+// AttachmentLinker fills in the attachment links of stored messages.
+// *AttachmentService implements it.
+type AttachmentLinker interface {
+	AttachLinks(ctx context.Context, messages []model.InboundMessage) error
+}
+
+var _ AttachmentLinker = (*AttachmentService)(nil)
+
+// OrderingService serializes delivery per conversation for accounts that
+// opt in. While a conversation has a message out for handling, later
+// messages are stored as held and not delivered. The next one is released
+// when the previous one is replied to or acked, when the hold timeout
+// passes, or when its own callback is about to expire.
+type OrderingService struct {
+	accounts    repository.AccountRepository
+	inboundRepo repository.InboundMessageRepository
+	publisher   EventPublisher
+	attachments AttachmentLinker
+	holdTimeout time.Duration
+}
+
+func NewOrderingService(
+	accounts repository.AccountRepository,
+	inboundRepo repository.InboundMessageRepository,
+	publisher EventPublisher,
+	attachments AttachmentLinker,
+	holdTimeout time.Duration,
+) *OrderingService {
+	return &OrderingService{
+		accounts:    accounts,
+		inboundRepo: inboundRepo,
+		publisher:   publisher,
+		attachments: attachments,
+		holdTimeout: holdTimeout,
+	}
+}
+
+// Timeout is how long a conversation waits for a reply or ack before its
+// next held message is released anyway.
+func (s *OrderingService) Timeout() time.Duration {
+	return s.holdTimeout
+}
+
+// HoldTimeout is the timeout new messages of the account are ordered
+// with, or zero when the account does not use ordered delivery.
+func (s *OrderingService) HoldTimeout(ctx context.Context, accountID string) (time.Duration, error) {
+	account, err := s.accounts.FindByID(ctx, accountID)
+	if err != nil {
+		return 0, fmt.Errorf("find account: %w", err)
+	}
+	if account == nil || !account.OrderedDelivery {
+		return 0, nil
+	}
+	return s.holdTimeout, nil
+}
+
+// SetOrdered turns ordered delivery on or off for the account. Turning it
+// off releases every message still held, since replies and acks no longer
+// release them one by one. Held utterances still waiting to be coalesced
+// are published when their group is flushed.
+func (s *OrderingService) SetOrdered(ctx context.Context, accountID string, ordered bool) (*model.Account, error) {
+	account, err := s.accounts.UpdateOrderedDelivery(ctx, accountID, ordered)
+	if err != nil {
+		return nil, fmt.Errorf("update ordered delivery: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	if ordered {
+		return account, nil
+	}
+
+	released, err := s.inboundRepo.ReleaseAllHeld(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("release held messages: %w", err)
+	}
+	deliverable := released[:0]
+	for _, msg := range released {
+		if msg.CoalesceDueAt == nil {
+			deliverable = append(deliverable, msg)
+		}
+	}
+	s.publish(ctx, deliverable)
+	return account, nil
+}
+
+// ReleaseNext delivers the conversation's next held message if nothing
+// ahead of it is still being handled.
+func (s *OrderingService) ReleaseNext(ctx context.Context, accountID, conversationKey string) error {
+	msg, err := s.inboundRepo.ReleaseNext(ctx, accountID, conversationKey, s.holdTimeout)
+	if err != nil {
+		return fmt.Errorf("release held message: %w", err)
+	}
+	if msg == nil {
+		return nil
+	}
+
+	s.publish(ctx, []model.InboundMessage{*msg})
+	return nil
+}
+
+// ReleaseAfterAck releases the next held message of each conversation
+// the acked messages belong to.
+func (s *OrderingService) ReleaseAfterAck(ctx context.Context, accountID string, messageIDs []string) error {
+	seen := make(map[string]bool)
+	for _, id := range messageIDs {
+		msg, err := s.inboundRepo.FindByID(ctx, id)
+		if err != nil {
+			return fmt.Errorf("find acked message: %w", err)
+		}
+		if msg == nil || msg.AccountID != accountID || seen[msg.ConversationKey] {
+			continue
+		}
+		seen[msg.ConversationKey] = true
+
+		if err := s.ReleaseNext(ctx, accountID, msg.ConversationKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReleaseDue releases held messages whose conversation timed out waiting
+// for a reply or whose callback is about to expire. It runs periodically.
+func (s *OrderingService) ReleaseDue(ctx context.Context) (int64, error) {
+	released, err := s.inboundRepo.ReleaseDue(ctx, s.holdTimeout, heldDeadlineMargin)
+	if err != nil {
+		return 0, fmt.Errorf("release due messages: %w", err)
+	}
+
+	s.publish(ctx, released)
+	return int64(len(released)), nil
+}
+
+func (s *OrderingService) publish(ctx context.Context, messages []model.InboundMessage) {
+	publishInbound(ctx, s.publisher, s.attachments, messages)
+}
+
+// publishInbound sends stored messages that became deliverable to the
+// account's streams as message or command events.
+func publishInbound(ctx context.Context, publisher EventPublisher, attachments AttachmentLinker, messages []model.InboundMessage) {
+	if len(messages) == 0 {
+		return
+	}
+
+	if attachments != nil {
+		if err := attachments.AttachLinks(ctx, messages); err != nil {
+			log.Warn().Err(err).Msg("failed to load attachments for released messages")
+		}
+	}
+
+	for i := range messages {
+		msg := &messages[i]
+		if err := publisher.Publish(ctx, msg.AccountID, sse.Event{
+			ID:   msg.ID,
+			Type: msg.SSEEventType(),
+			Data: msg.ToSSEEventData(),
+		}); err != nil {
+			log.Warn().Err(err).Str("messageId", msg.ID).Msg("failed to publish released message")
+			continue
+		}
+
+		log.Debug().
+			Str("messageId", msg.ID).
+			Str("accountId", msg.AccountID).
+			Str("conversationKey", msg.ConversationKey).
+			Msg("stored message published")
+	}
+}
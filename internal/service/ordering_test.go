@@ -0,0 +1,157 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+func heldMessage(id, conversationKey string, position int) *model.InboundMessage {
+	normalized := json.RawMessage(`{"text":"hi"}`)
+	return &model.InboundMessage{
+		ID:                id,
+		AccountID:         "acc-1",
+		ConversationKey:   conversationKey,
+		Status:            model.InboundStatusQueued,
+		NormalizedMessage: &normalized,
+		QueuePosition:     &position,
+	}
+}
+
+func TestOrderingService_HoldTimeout(t *testing.T) {
+	ctx := context.Background()
+	accounts := new(mockAccountRepo)
+	svc := NewOrderingService(accounts, new(mockInboundRepo), &recordingPublisher{}, nil, time.Minute)
+
+	accounts.On("FindByID", ctx, "acc-ordered").Return(&model.Account{ID: "acc-ordered", OrderedDelivery: true}, nil)
+	accounts.On("FindByID", ctx, "acc-plain").Return(&model.Account{ID: "acc-plain"}, nil)
+
+	timeout, err := svc.HoldTimeout(ctx, "acc-ordered")
+	require.NoError(t, err)
+	assert.Equal(t, time.Minute, timeout)
+
+	timeout, err = svc.HoldTimeout(ctx, "acc-plain")
+	require.NoError(t, err)
+	assert.Zero(t, timeout)
+}
+
+func TestOrderingService_SetOrdered(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("updates the account", func(t *testing.T) {
+		accounts := new(mockAccountRepo)
+		svc := NewOrderingService(accounts, new(mockInboundRepo), &recordingPublisher{}, nil, time.Minute)
+		accounts.On("UpdateOrderedDelivery", ctx, "acc-1", true).
+			Return(&model.Account{ID: "acc-1", OrderedDelivery: true}, nil)
+
+		account, err := svc.SetOrdered(ctx, "acc-1", true)
+
+		require.NoError(t, err)
+		assert.True(t, account.OrderedDelivery)
+	})
+
+	t.Run("releases every held message when turned off", func(t *testing.T) {
+		accounts, repo, publisher := new(mockAccountRepo), new(mockInboundRepo), &recordingPublisher{}
+		svc := NewOrderingService(accounts, repo, publisher, nil, time.Minute)
+		accounts.On("UpdateOrderedDelivery", ctx, "acc-1", false).
+			Return(&model.Account{ID: "acc-1"}, nil)
+		coalescing := heldMessage("m3", "conv-2", 0)
+		coalescing.CoalesceDueAt = &time.Time{}
+		repo.On("ReleaseAllHeld", ctx, "acc-1").Return([]model.InboundMessage{
+			*heldMessage("m1", "conv-1", 1),
+			*heldMessage("m2", "conv-1", 2),
+			*coalescing,
+		}, nil)
+
+		_, err := svc.SetOrdered(ctx, "acc-1", false)
+
+		require.NoError(t, err)
+		events := publisher.events["acc-1"]
+		require.Len(t, events, 2, "utterances still being coalesced wait for their flush")
+		assert.Equal(t, "m1", events[0].ID)
+		assert.Equal(t, "m2", events[1].ID)
+	})
+
+	t.Run("reports a missing account", func(t *testing.T) {
+		accounts := new(mockAccountRepo)
+		svc := NewOrderingService(accounts, new(mockInboundRepo), &recordingPublisher{}, nil, time.Minute)
+		accounts.On("UpdateOrderedDelivery", ctx, "acc-1", true).Return(nil, nil)
+
+		_, err := svc.SetOrdered(ctx, "acc-1", true)
+
+		assert.ErrorIs(t, err, ErrAccountNotFound)
+	})
+}
+
+func TestOrderingService_ReleaseNext(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("publishes the released message with its queue position", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewOrderingService(new(mockAccountRepo), repo, publisher, nil, time.Minute)
+		repo.On("ReleaseNext", ctx, "acc-1", "conv-1", time.Minute).Return(heldMessage("m2", "conv-1", 1), nil)
+
+		require.NoError(t, svc.ReleaseNext(ctx, "acc-1", "conv-1"))
+
+		events := publisher.events["acc-1"]
+		require.Len(t, events, 1)
+		assert.Equal(t, "m2", events[0].ID)
+		assert.Equal(t, "message", events[0].Type)
+
+		var data map[string]any
+		require.NoError(t, json.Unmarshal(events[0].Data, &data))
+		assert.EqualValues(t, 1, data["queuePosition"])
+	})
+
+	t.Run("publishes nothing while the conversation is busy", func(t *testing.T) {
+		repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+		svc := NewOrderingService(new(mockAccountRepo), repo, publisher, nil, time.Minute)
+		repo.On("ReleaseNext", ctx, "acc-1", "conv-1", time.Minute).Return(nil, nil)
+
+		require.NoError(t, svc.ReleaseNext(ctx, "acc-1", "conv-1"))
+
+		assert.Empty(t, publisher.events)
+	})
+}
+
+func TestOrderingService_ReleaseAfterAck(t *testing.T) {
+	ctx := context.Background()
+	repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+	svc := NewOrderingService(new(mockAccountRepo), repo, publisher, nil, time.Minute)
+
+	repo.On("FindByID", ctx, "m1").Return(heldMessage("m1", "conv-1", 0), nil)
+	repo.On("FindByID", ctx, "m2").Return(heldMessage("m2", "conv-1", 1), nil)
+	other := heldMessage("m9", "conv-9", 0)
+	other.AccountID = "acc-other"
+	repo.On("FindByID", ctx, "m9").Return(other, nil)
+	repo.On("ReleaseNext", ctx, "acc-1", "conv-1", time.Minute).Return(heldMessage("m3", "conv-1", 2), nil).Once()
+
+	require.NoError(t, svc.ReleaseAfterAck(ctx, "acc-1", []string{"m1", "m2", "m9"}))
+
+	require.Len(t, publisher.events["acc-1"], 1)
+	assert.Equal(t, "m3", publisher.events["acc-1"][0].ID)
+	repo.AssertNumberOfCalls(t, "ReleaseNext", 1)
+}
+
+func TestOrderingService_ReleaseDue(t *testing.T) {
+	ctx := context.Background()
+	repo, publisher := new(mockInboundRepo), &recordingPublisher{}
+	svc := NewOrderingService(new(mockAccountRepo), repo, publisher, nil, time.Minute)
+
+	released := []model.InboundMessage{*heldMessage("m2", "conv-1", 1), *heldMessage("m5", "conv-2", 3)}
+	repo.On("ReleaseDue", ctx, time.Minute, heldDeadlineMargin).Return(released, nil)
+
+	count, err := svc.ReleaseDue(ctx)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+	assert.Len(t, publisher.events["acc-1"], 2)
+	repo.AssertCalled(t, "ReleaseDue", mock.Anything, time.Minute, heldDeadlineMargin)
+}
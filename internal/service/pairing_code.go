@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const (
+	DefaultPairingCodeTTL = 24 * time.Hour
+	MaxPairingCodeTTL     = 7 * 24 * time.Hour
+	MaxPairingCodeUses    = 100
+	// maxLivePairingCodes caps unexpired codes per account.
+	maxLivePairingCodes = 50
+)
+
+var ErrInvalidPairingCode = errors.New("invalid pairing code request")
+
+type CreatePairingCodeRequest struct {
+	// MaxUses defaults to 1.
+	MaxUses int `json:"maxUses"`
+	// ExpiresInSeconds defaults to DefaultPairingCodeTTL.
+	ExpiresInSeconds int `json:"expiresInSeconds"`
+}
+
+// PairingCodeService manages account-scoped invite codes. Unlike session
+// codes, redeeming one attaches the conversation to the issuing account.
+type PairingCodeService struct {
+	repo repository.PairingCodeRepository
+	now  func() time.Time
+}
+
+func NewPairingCodeService(repo repository.PairingCodeRepository) *PairingCodeService {
+	return &PairingCodeService{repo: repo, now: time.Now}
+}
+
+func (s *PairingCodeService) Create(ctx context.Context, accountID string, req CreatePairingCodeRequest) (*model.PairingCode, error) {
+	maxUses := req.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+	if maxUses < 1 || maxUses > MaxPairingCodeUses {
+		return nil, fmt.Errorf("%w: maxUses must be between 1 and %d", ErrInvalidPairingCode, MaxPairingCodeUses)
+	}
+
+	ttl := DefaultPairingCodeTTL
+	if req.ExpiresInSeconds != 0 {
+		ttl = time.Duration(req.ExpiresInSeconds) * time.Second
+	}
+	if ttl < time.Minute || ttl > MaxPairingCodeTTL {
+		return nil, fmt.Errorf("%w: expiresInSeconds must be between 60 and %d", ErrInvalidPairingCode, int(MaxPairingCodeTTL.Seconds()))
+	}
+
+	existing, err := s.repo.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("find pairing codes: %w", err)
+	}
+	live := 0
+	for _, c := range existing {
+		if s.isLive(&c) {
+			live++
+		}
+	}
+	if live >= maxLivePairingCodes {
+		return nil, fmt.Errorf("%w: at most %d active codes per account", ErrInvalidPairingCode, maxLivePairingCodes)
+	}
+
+	code, err := s.repo.Create(ctx, model.CreatePairingCodeParams{
+		AccountID: accountID,
+		Code:      generateSessionPairingCode(),
+		MaxUses:   maxUses,
+		ExpiresAt: s.now().Add(ttl),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("create pairing code: %w", err)
+	}
+
+	log.Info().
+		Str("accountId", accountID).
+		Str("pairingCode", util.MaskCode(code.Code)).
+		Int("maxUses", maxUses).
+		Time("expiresAt", code.ExpiresAt).
+		Msg("pairing code created")
+
+	return code, nil
+}
+
+// List returns the account's codes that can still be redeemed.
+func (s *PairingCodeService) List(ctx context.Context, accountID string) ([]model.PairingCode, error) {
+	codes, err := s.repo.FindByAccountID(ctx, accountID)
+	if err != nil {
+		return nil, err
+	}
+	live := make([]model.PairingCode, 0, len(codes))
+	for _, c := range codes {
+		if s.isLive(&c) {
+			live = append(live, c)
+		}
+	}
+	return live, nil
+}
+
+func (s *PairingCodeService) Revoke(ctx context.Context, accountID, id string) (bool, error) {
+	return s.repo.Revoke(ctx, accountID, id)
+}
+
+// Redeem consumes one use of code, returning nil if it cannot be used.
+func (s *PairingCodeService) Redeem(ctx context.Context, code string) (*model.PairingCode, error) {
+	return s.repo.Redeem(ctx, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// FindLive returns the live code without using it, or nil.
+func (s *PairingCodeService) FindLive(ctx context.Context, code string) (*model.PairingCode, error) {
+	return s.repo.FindLive(ctx, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// PurgeExpired deletes codes that expired more than a day ago.
+func (s *PairingCodeService) PurgeExpired(ctx context.Context) (int64, error) {
+	return s.repo.DeleteExpired(ctx, s.now().Add(-24*time.Hour))
+}
+
+func (s *PairingCodeService) isLive(c *model.PairingCode) bool {
+	return c.RevokedAt == nil && c.UseCount < c.MaxUses && c.ExpiresAt.After(s.now())
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+// memPairingCodeRepo keeps codes in memory with the same redeem rules as
+// the SQL implementation.
+type memPairingCodeRepo struct {
+	repository.PairingCodeRepository
+	now   func() time.Time
+	codes []*model.PairingCode
+}
+
+func (r *memPairingCodeRepo) Create(ctx context.Context, p model.CreatePairingCodeParams) (*model.PairingCode, error) {
+	c := &model.PairingCode{
+		ID:        "pc-" + p.Code,
+		AccountID: p.AccountID,
+		Code:      p.Code,
+		MaxUses:   p.MaxUses,
+		ExpiresAt: p.ExpiresAt,
+		CreatedAt: r.now(),
+	}
+	r.codes = append(r.codes, c)
+	return c, nil
+}
+
+func (r *memPairingCodeRepo) FindByAccountID(ctx context.Context, accountID string) ([]model.PairingCode, error) {
+	var out []model.PairingCode
+	for _, c := range r.codes {
+		if c.AccountID == accountID {
+			out = append(out, *c)
+		}
+	}
+	return out, nil
+}
+
+func (r *memPairingCodeRepo) Redeem(ctx context.Context, code string) (*model.PairingCode, error) {
+	for _, c := range r.codes {
+		if c.Code == code && c.RevokedAt == nil && c.ExpiresAt.After(r.now()) && c.UseCount < c.MaxUses {
+			c.UseCount++
+			redeemed := *c
+			return &redeemed, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPairingCodeRepo) FindLive(ctx context.Context, code string) (*model.PairingCode, error) {
+	for _, c := range r.codes {
+		if c.Code == code && c.RevokedAt == nil && c.ExpiresAt.After(r.now()) && c.UseCount < c.MaxUses {
+			found := *c
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memPairingCodeRepo) Revoke(ctx context.Context, accountID, id string) (bool, error) {
+	for _, c := range r.codes {
+		if c.ID == id && c.AccountID == accountID && c.RevokedAt == nil {
+			now := r.now()
+			c.RevokedAt = &now
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func newTestPairingCodeService() (*PairingCodeService, *memPairingCodeRepo, *time.Time) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	repo := &memPairingCodeRepo{now: func() time.Time { return now }}
+	svc := NewPairingCodeService(repo)
+	svc.now = repo.now
+	return svc, repo, &now
+}
+
+func TestPairingCodeService_CreateDefaults(t *testing.T) {
+	svc, _, now := newTestPairingCodeService()
+
+	code, err := svc.Create(context.Background(), "acc-1", CreatePairingCodeRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, "acc-1", code.AccountID)
+	assert.Equal(t, 1, code.MaxUses)
+	assert.Equal(t, now.Add(DefaultPairingCodeTTL), code.ExpiresAt)
+	assert.Regexp(t, `^[A-Z0-9]{4}-[A-Z0-9]{4}$`, code.Code)
+}
+
+func TestPairingCodeService_CreateValidates(t *testing.T) {
+	svc, _, _ := newTestPairingCodeService()
+	ctx := context.Background()
+
+	for name, req := range map[string]CreatePairingCodeRequest{
+		"negative uses": {MaxUses: -1},
+		"too many uses": {MaxUses: MaxPairingCodeUses + 1},
+		"ttl too short": {ExpiresInSeconds: 30},
+		"ttl too long":  {ExpiresInSeconds: int(MaxPairingCodeTTL.Seconds()) + 1},
+		"negative ttl":  {ExpiresInSeconds: -60},
+	} {
+		_, err := svc.Create(ctx, "acc-1", req)
+		assert.ErrorIs(t, err, ErrInvalidPairingCode, name)
+	}
+}
+
+func TestPairingCodeService_ListHidesUnusableCodes(t *testing.T) {
+	svc, _, now := newTestPairingCodeService()
+	ctx := context.Background()
+
+	used, err := svc.Create(ctx, "acc-1", CreatePairingCodeRequest{})
+	require.NoError(t, err)
+	_, err = svc.Redeem(ctx, used.Code)
+	require.NoError(t, err)
+
+	revoked, err := svc.Create(ctx, "acc-1", CreatePairingCodeRequest{MaxUses: 5})
+	require.NoError(t, err)
+	ok, err := svc.Revoke(ctx, "acc-1", revoked.ID)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	live, err := svc.Create(ctx, "acc-1", CreatePairingCodeRequest{MaxUses: 5, ExpiresInSeconds: 3600})
+	require.NoError(t, err)
+
+	codes, err := svc.List(ctx, "acc-1")
+	require.NoError(t, err)
+	require.Len(t, codes, 1)
+	assert.Equal(t, live.ID, codes[0].ID)
+
+	*now = now.Add(2 * time.Hour)
+	codes, err = svc.List(ctx, "acc-1")
+	require.NoError(t, err)
+	assert.Empty(t, codes)
+}
+
+func TestSessionService_VerifyPairingCodeRedeemsInvite(t *testing.T) {
+	ctx := context.Background()
+	invites, repo, _ := newTestPairingCodeService()
+	sessions := &pairingSessionRepo{}
+	limiter := newStubLimiter()
+	svc := NewSessionService(nil, sessions, nil, nil, NewPairingGuard(limiter, testGuardConfig()), invites, nil)
+
+	code, err := invites.Create(ctx, "acc-1", CreatePairingCodeRequest{MaxUses: 2})
+	require.NoError(t, err)
+
+	for _, user := range []string{"bot:alice", "bot:bob"} {
+		result := svc.VerifyPairingCode(ctx, " "+code.Code+" ", &model.ConversationMapping{ConversationKey: user})
+		require.True(t, result.Success, user)
+		assert.Equal(t, "acc-1", result.AccountID)
+		assert.Equal(t, code.ID, result.PairingCodeID)
+		assert.Empty(t, result.SessionID)
+	}
+	assert.Equal(t, 2, repo.codes[0].UseCount)
+
+	result := svc.VerifyPairingCode(ctx, code.Code, &model.ConversationMapping{ConversationKey: "bot:carol"})
+	assert.False(t, result.Success)
+	assert.Equal(t, "INVALID_CODE", result.Error, "used-up codes are rejected")
+	assert.Equal(t, 1, limiter.count("pairing:failures"))
+}
+
+func TestSessionService_VerifyPairingCodeRefusesBeforeRedeeming(t *testing.T) {
+	ctx := context.Background()
+	invites, repo, _ := newTestPairingCodeService()
+	svc := NewSessionService(nil, &pairingSessionRepo{}, nil, nil, nil, invites, nil)
+
+	code, err := invites.Create(ctx, "acc-1", CreatePairingCodeRequest{MaxUses: 1})
+	require.NoError(t, err)
+
+	blocked := &model.ConversationMapping{ConversationKey: "bot:alice", State: model.PairingStateBlocked, AccountID: strPtr("acc-1")}
+	for range 3 {
+		result := svc.VerifyPairingCode(ctx, code.Code, blocked)
+		assert.Equal(t, PairingErrBlocked, result.Error)
+	}
+	pending := &model.ConversationMapping{ConversationKey: "bot:bob", State: model.PairingStatePending, AccountID: strPtr("acc-2")}
+	assert.Equal(t, PairingErrPending, svc.VerifyPairingCode(ctx, code.Code, pending).Error)
+	assert.Equal(t, 0, repo.codes[0].UseCount, "refused attempts keep the code's uses")
+
+	blockedElsewhere := &model.ConversationMapping{ConversationKey: "bot:carol", State: model.PairingStateBlocked, AccountID: strPtr("acc-2")}
+	result := svc.VerifyPairingCode(ctx, code.Code, blockedElsewhere)
+	assert.True(t, result.Success, "a block by another account does not stop the invite")
+	assert.Equal(t, 1, repo.codes[0].UseCount)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/audit"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+// AttemptLimiter is the sliding-window check PairingGuard relies on.
+// *RateLimiter implements it; each allowed call counts as one hit.
+type AttemptLimiter interface {
+	CheckLimit(ctx context.Context, key string, limit int, window time.Duration) (allowed bool, resetAt time.Time)
+}
+
+var _ AttemptLimiter = (*RateLimiter)(nil)
+
+// PairingLimit allows Limit hits per sliding Window.
+type PairingLimit struct {
+	Limit  int
+	Window time.Duration
+}
+
+type PairingGuardConfig struct {
+	// ConversationTiers are checked together on every attempt. Longer
+	// windows trip only after repeated lockouts, so a conversation that keeps
+	// guessing is locked out for progressively longer.
+	ConversationTiers []PairingLimit
+	// Channel caps attempts across all users of one Kakao bot, which slows
+	// guessing spread over many user keys.
+	Channel PairingLimit
+	// AnomalyThreshold is the relay-wide failure rate that raises an alert.
+	// It never blocks pairing by itself.
+	AnomalyThreshold PairingLimit
+}
+
+func DefaultPairingGuardConfig() PairingGuardConfig {
+	return PairingGuardConfig{
+		ConversationTiers: []PairingLimit{
+			{Limit: 5, Window: 10 * time.Minute},
+			{Limit: 10, Window: time.Hour},
+			{Limit: 20, Window: 24 * time.Hour},
+		},
+		Channel:          PairingLimit{Limit: 100, Window: 10 * time.Minute},
+		AnomalyThreshold: PairingLimit{Limit: 50, Window: 5 * time.Minute},
+	}
+}
+
+// Pairing attempt denial reasons, also used as SessionPairResult.Error.
+const (
+	PairingErrLockedOut   = "LOCKED_OUT"
+	PairingErrRateLimited = "RATE_LIMITED"
+)
+
+type PairingDecision struct {
+	Allowed bool
+	// Reason is PairingErrLockedOut (this conversation) or
+	// PairingErrRateLimited (the whole channel).
+	Reason  string
+	RetryAt time.Time
+}
+
+// PairingGuard rate limits pairing code attempts from Kakao conversations
+// and records failures for auditing.
+type PairingGuard struct {
+	limiter AttemptLimiter
+	cfg     PairingGuardConfig
+}
+
+func NewPairingGuard(limiter AttemptLimiter, cfg PairingGuardConfig) *PairingGuard {
+	return &PairingGuard{limiter: limiter, cfg: cfg}
+}
+
+// Allow counts one attempt from conversationKey and reports whether it may
+// proceed.
+func (g *PairingGuard) Allow(ctx context.Context, conversationKey string) PairingDecision {
+	channelID := channelFromConversationKey(conversationKey)
+
+	decision := PairingDecision{Allowed: true}
+	// Check every tier, even after one denies, so attempts made while
+	// locked out still count toward the longer tiers.
+	for _, tier := range g.cfg.ConversationTiers {
+		key := "pairing:conv:" + tier.Window.String() + ":" + conversationKey
+		if allowed, resetAt := g.limiter.CheckLimit(ctx, key, tier.Limit, tier.Window); !allowed {
+			decision = deny(decision, PairingErrLockedOut, resetAt)
+		}
+	}
+
+	if decision.Allowed && g.cfg.Channel.Limit > 0 {
+		key := "pairing:channel:" + channelID
+		if allowed, resetAt := g.limiter.CheckLimit(ctx, key, g.cfg.Channel.Limit, g.cfg.Channel.Window); !allowed {
+			decision = deny(decision, PairingErrRateLimited, resetAt)
+		}
+	}
+
+	if !decision.Allowed {
+		audit.Log(ctx, audit.Event{
+			Type: audit.EventPairingLockout,
+			Details: map[string]interface{}{
+				"conversation_key": conversationKey,
+				"channel_id":       channelID,
+				"reason":           decision.Reason,
+				"retry_at":         decision.RetryAt.Format(time.RFC3339),
+			},
+		})
+	}
+	return decision
+}
+
+// deny keeps the latest retry time; a channel limit never downgrades a
+// conversation lockout.
+func deny(d PairingDecision, reason string, retryAt time.Time) PairingDecision {
+	if d.Allowed || retryAt.After(d.RetryAt) {
+		d.RetryAt = retryAt
+	}
+	if d.Allowed {
+		d.Reason = reason
+	}
+	d.Allowed = false
+	return d
+}
+
+// RecordFailure audits a rejected code and raises an anomaly alert when the
+// relay-wide failure rate crosses the threshold (once per window).
+func (g *PairingGuard) RecordFailure(ctx context.Context, conversationKey, code, reason string) {
+	audit.Log(ctx, audit.Event{
+		Type: audit.EventPairingFailure,
+		Details: map[string]interface{}{
+			"conversation_key": conversationKey,
+			"channel_id":       channelFromConversationKey(conversationKey),
+			"code":             util.MaskCode(code),
+			"reason":           reason,
+		},
+	})
+
+	t := g.cfg.AnomalyThreshold
+	if t.Limit <= 0 {
+		return
+	}
+	if allowed, _ := g.limiter.CheckLimit(ctx, "pairing:failures", t.Limit, t.Window); allowed {
+		return
+	}
+	if alert, _ := g.limiter.CheckLimit(ctx, "pairing:anomaly_alert", 1, t.Window); !alert {
+		return
+	}
+
+	log.Error().
+		Int("threshold", t.Limit).
+		Dur("window", t.Window).
+		Msg("pairing failure rate exceeded threshold: possible brute-force attempt")
+	audit.Log(ctx, audit.Event{
+		Type: audit.EventPairingAnomaly,
+		Details: map[string]interface{}{
+			"threshold":      t.Limit,
+			"window_seconds": int(t.Window.Seconds()),
+			"last_channel":   channelFromConversationKey(conversationKey),
+		},
+	})
+}
+
+func (g *PairingGuard) RecordSuccess(ctx context.Context, conversationKey, accountID string) {
+	audit.Log(ctx, audit.Event{
+		Type:      audit.EventPairingSuccess,
+		AccountID: accountID,
+		Details: map[string]interface{}{
+			"conversation_key": conversationKey,
+		},
+	})
+}
+
+// channelFromConversationKey returns the channel part of a key built by
+// BuildConversationKey.
+func channelFromConversationKey(key string) string {
+	channelID, _, _ := strings.Cut(key, ":")
+	return channelID
+}
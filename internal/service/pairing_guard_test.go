@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+// stubLimiter is an in-memory sliding window with a controllable clock.
+type stubLimiter struct {
+	mu   sync.Mutex
+	now  time.Time
+	hits map[string][]time.Time
+}
+
+func newStubLimiter() *stubLimiter {
+	return &stubLimiter{now: time.Now(), hits: make(map[string][]time.Time)}
+}
+
+func (l *stubLimiter) CheckLimit(ctx context.Context, key string, limit int, window time.Duration) (bool, time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var live []time.Time
+	for _, t := range l.hits[key] {
+		if t.After(l.now.Add(-window)) {
+			live = append(live, t)
+		}
+	}
+	l.hits[key] = live
+	if len(live) >= limit {
+		return false, live[0].Add(window)
+	}
+	l.hits[key] = append(live, l.now)
+	return true, l.now.Add(window)
+}
+
+func (l *stubLimiter) advance(d time.Duration) {
+	l.mu.Lock()
+	l.now = l.now.Add(d)
+	l.mu.Unlock()
+}
+
+func (l *stubLimiter) count(key string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.hits[key])
+}
+
+func testGuardConfig() PairingGuardConfig {
+	return PairingGuardConfig{
+		ConversationTiers: []PairingLimit{
+			{Limit: 3, Window: 10 * time.Minute},
+			{Limit: 5, Window: time.Hour},
+		},
+		Channel:          PairingLimit{Limit: 10, Window: 10 * time.Minute},
+		AnomalyThreshold: PairingLimit{Limit: 4, Window: 5 * time.Minute},
+	}
+}
+
+func TestPairingGuard_ConversationLockout(t *testing.T) {
+	ctx := context.Background()
+	limiter := newStubLimiter()
+	guard := NewPairingGuard(limiter, testGuardConfig())
+
+	for i := 0; i < 3; i++ {
+		require.True(t, guard.Allow(ctx, "bot:alice").Allowed, "attempt %d", i+1)
+	}
+
+	decision := guard.Allow(ctx, "bot:alice")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, PairingErrLockedOut, decision.Reason)
+	assert.Equal(t, limiter.now.Add(10*time.Minute), decision.RetryAt)
+
+	assert.True(t, guard.Allow(ctx, "bot:bob").Allowed, "other conversations are unaffected")
+
+	t.Run("lockouts get longer", func(t *testing.T) {
+		limiter.advance(11 * time.Minute)
+		// The denied attempt above already counted toward the hourly tier.
+		require.True(t, guard.Allow(ctx, "bot:alice").Allowed)
+
+		decision := guard.Allow(ctx, "bot:alice")
+		assert.False(t, decision.Allowed)
+		assert.True(t, decision.RetryAt.After(limiter.now.Add(45*time.Minute)),
+			"expected hourly lockout, retry at %s", decision.RetryAt.Sub(limiter.now))
+	})
+}
+
+func TestPairingGuard_ChannelLimit(t *testing.T) {
+	ctx := context.Background()
+	limiter := newStubLimiter()
+	guard := NewPairingGuard(limiter, testGuardConfig())
+
+	for i := 0; i < 10; i++ {
+		require.True(t, guard.Allow(ctx, "bot:user"+string(rune('a'+i))).Allowed)
+	}
+
+	decision := guard.Allow(ctx, "bot:zed")
+	assert.False(t, decision.Allowed)
+	assert.Equal(t, PairingErrRateLimited, decision.Reason)
+
+	assert.True(t, guard.Allow(ctx, "otherbot:zed").Allowed, "limits are per channel")
+}
+
+func TestPairingGuard_AnomalyAlertsOncePerWindow(t *testing.T) {
+	ctx := context.Background()
+	limiter := newStubLimiter()
+	guard := NewPairingGuard(limiter, testGuardConfig())
+
+	for i := 0; i < 4; i++ {
+		guard.RecordFailure(ctx, "bot:u", "ABCD-1234", "INVALID_CODE")
+	}
+	assert.Equal(t, 0, limiter.count("pairing:anomaly_alert"), "below threshold")
+
+	guard.RecordFailure(ctx, "bot:u", "ABCD-1234", "INVALID_CODE")
+	guard.RecordFailure(ctx, "bot:u", "ABCD-1234", "INVALID_CODE")
+	assert.Equal(t, 1, limiter.count("pairing:anomaly_alert"), "alerts once per window")
+
+	limiter.advance(6 * time.Minute)
+	for i := 0; i < 5; i++ {
+		guard.RecordFailure(ctx, "bot:u", "ABCD-1234", "INVALID_CODE")
+	}
+	require.Equal(t, 1, limiter.count("pairing:anomaly_alert"))
+	assert.Equal(t, limiter.now, limiter.hits["pairing:anomaly_alert"][0], "alerts again in the next window")
+}
+
+// pairingSessionRepo stubs the lookup VerifyPairingCode does before pairing.
+type pairingSessionRepo struct {
+	repository.SessionRepository
+	lookups int
+}
+
+func (r *pairingSessionRepo) FindByPairingCode(ctx context.Context, code string) (*model.Session, error) {
+	r.lookups++
+	return nil, nil
+}
+
+func TestSessionService_VerifyPairingCodeIsLimited(t *testing.T) {
+	ctx := context.Background()
+	limiter := newStubLimiter()
+	repo := &pairingSessionRepo{}
+	svc := NewSessionService(nil, repo, nil, nil, NewPairingGuard(limiter, testGuardConfig()), nil, nil)
+
+	for i := 0; i < 3; i++ {
+		result := svc.VerifyPairingCode(ctx, "wxyz-2345", &model.ConversationMapping{ConversationKey: "bot:mallory"})
+		assert.Equal(t, "INVALID_CODE", result.Error)
+	}
+	assert.Equal(t, 3, limiter.count("pairing:failures"))
+
+	result := svc.VerifyPairingCode(ctx, "wxyz-2346", &model.ConversationMapping{ConversationKey: "bot:mallory"})
+	assert.False(t, result.Success)
+	assert.Equal(t, PairingErrLockedOut, result.Error)
+	assert.False(t, result.RetryAt.IsZero())
+	assert.Equal(t, 3, repo.lookups, "locked out attempts never reach the database")
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/audit"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/sse"
+)
+
+const DefaultPairingRequestTTL = 24 * time.Hour
+
+var (
+	ErrPairingRequestNotFound = errors.New("pairing request not found")
+	// ErrPairingRequestClosed means the request was already decided,
+	// cancelled or has expired.
+	ErrPairingRequestClosed = errors.New("pairing request is no longer pending")
+)
+
+// EventPublisher delivers SSE events to an account's streams.
+// *sse.Broker implements it.
+type EventPublisher interface {
+	Publish(ctx context.Context, accountID string, event sse.Event) error
+}
+
+var _ EventPublisher = (*sse.Broker)(nil)
+
+type PairingApprovalConfig struct {
+	// RequestTTL is how long a request waits for a decision.
+	RequestTTL time.Duration
+	// NotifyEvent is the Open Builder event sent through the Event API when
+	// a request is decided or expires. Without it (or without an Event API
+	// key) the user is told on their next message.
+	NotifyEvent string
+}
+
+// PairingRequestService runs the approval workflow for accounts that
+// require approval before a Kakao user joins through one of their codes.
+type PairingRequestService struct {
+	repo      repository.PairingRequestRepository
+	accounts  repository.AccountRepository
+	convs     repository.ConversationRepository
+	publisher EventPublisher
+	events    *EventAPIClient
+	cfg       PairingApprovalConfig
+}
+
+// NewPairingRequestService creates the service. events may be nil to
+// notify users only on their next message.
+func NewPairingRequestService(
+	repo repository.PairingRequestRepository,
+	accounts repository.AccountRepository,
+	convs repository.ConversationRepository,
+	publisher EventPublisher,
+	events *EventAPIClient,
+	cfg PairingApprovalConfig,
+) *PairingRequestService {
+	if cfg.RequestTTL <= 0 {
+		cfg.RequestTTL = DefaultPairingRequestTTL
+	}
+	return &PairingRequestService{
+		repo:      repo,
+		accounts:  accounts,
+		convs:     convs,
+		publisher: publisher,
+		events:    events,
+		cfg:       cfg,
+	}
+}
+
+// SetApprovalRequired turns approval mode on or off for the account.
+// Requests already pending are unaffected.
+func (s *PairingRequestService) SetApprovalRequired(ctx context.Context, accountID string, required bool) (*model.Account, error) {
+	account, err := s.accounts.UpdatePairingApproval(ctx, accountID, required)
+	if err != nil {
+		return nil, fmt.Errorf("update pairing approval: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	return account, nil
+}
+
+// Submit opens a pending request for conversationKey if the account
+// requires approval, and notifies the account with a pairing_request
+// event. It returns nil when the conversation can be paired right away.
+func (s *PairingRequestService) Submit(
+	ctx context.Context,
+	accountID, conversationKey, pairingCodeID string,
+	profile model.PairingProfile,
+) (*model.PairingRequest, error) {
+	account, err := s.accounts.FindByID(ctx, accountID)
+	if err != nil {
+		return nil, fmt.Errorf("find account: %w", err)
+	}
+	if account == nil {
+		return nil, ErrAccountNotFound
+	}
+	if !account.RequirePairingApproval {
+		return nil, nil
+	}
+
+	profileJSON, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("marshal profile: %w", err)
+	}
+	params := model.CreatePairingRequestParams{
+		AccountID:       accountID,
+		ConversationKey: conversationKey,
+		Profile:         profileJSON,
+		ExpiresAt:       time.Now().Add(s.cfg.RequestTTL),
+	}
+	if pairingCodeID != "" {
+		params.PairingCodeID = &pairingCodeID
+	}
+
+	req, err := s.repo.Create(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("create pairing request: %w", err)
+	}
+
+	log.Info().
+		Str("requestId", req.ID).
+		Str("accountId", accountID).
+		Str("conversationKey", conversationKey).
+		Msg("pairing request awaiting approval")
+
+	if err := s.publish(ctx, accountID, "pairing_request", pairingRequestEventData(req, profile)); err != nil {
+		log.Warn().Err(err).Str("requestId", req.ID).Msg("failed to publish pairing_request event")
+	}
+	return req, nil
+}
+
+func (s *PairingRequestService) ListPending(ctx context.Context, accountID string) ([]model.PairingRequest, error) {
+	return s.repo.FindPendingByAccountID(ctx, accountID)
+}
+
+// Decide approves or rejects a pending request. Approval pairs the
+// conversation and emits pairing_complete on the account stream.
+func (s *PairingRequestService) Decide(ctx context.Context, accountID, requestID string, approve bool) (*model.PairingRequest, error) {
+	status := model.PairingRequestRejected
+	if approve {
+		status = model.PairingRequestApproved
+	}
+
+	req, err := s.repo.Decide(ctx, accountID, requestID, status)
+	if err != nil {
+		return nil, fmt.Errorf("decide pairing request: %w", err)
+	}
+	if req == nil {
+		existing, err := s.repo.FindByID(ctx, accountID, requestID)
+		if err != nil {
+			return nil, fmt.Errorf("find pairing request: %w", err)
+		}
+		if existing == nil {
+			return nil, ErrPairingRequestNotFound
+		}
+		return nil, ErrPairingRequestClosed
+	}
+
+	log.Info().
+		Str("requestId", req.ID).
+		Str("accountId", accountID).
+		Str("conversationKey", req.ConversationKey).
+		Str("status", string(req.Status)).
+		Msg("pairing request decided")
+	audit.Log(ctx, audit.Event{
+		Type:      audit.EventPairingDecision,
+		AccountID: accountID,
+		Details: map[string]interface{}{
+			"request_id":       req.ID,
+			"conversation_key": req.ConversationKey,
+			"status":           string(req.Status),
+		},
+	})
+
+	if approve {
+		codeID := ""
+		if req.PairingCodeID != nil {
+			codeID = *req.PairingCodeID
+		}
+		event, err := pairingCompleteEvent(accountID, req.ConversationKey, codeID)
+		if err == nil {
+			err = s.publisher.Publish(ctx, accountID, event)
+		}
+		if err != nil {
+			log.Warn().Err(err).Str("requestId", req.ID).Msg("failed to publish pairing_complete event")
+		}
+	}
+
+	s.notify(ctx, req)
+	return req, nil
+}
+
+// Cancel withdraws the conversation's pending request, as when the user
+// sends /unpair while waiting. It reports whether one was pending.
+func (s *PairingRequestService) Cancel(ctx context.Context, conversationKey string) (bool, error) {
+	req, err := s.repo.Cancel(ctx, conversationKey)
+	if err != nil {
+		return false, fmt.Errorf("cancel pairing request: %w", err)
+	}
+	return req != nil, nil
+}
+
+// ExpirePending expires requests past their deadline, for the cleanup job.
+func (s *PairingRequestService) ExpirePending(ctx context.Context) (int64, error) {
+	reqs, err := s.repo.ExpirePending(ctx)
+	if err != nil {
+		return 0, err
+	}
+	for i := range reqs {
+		s.notify(ctx, &reqs[i])
+	}
+	return int64(len(reqs)), nil
+}
+
+// TakeNotice returns the pairing outcome conv's user has not seen yet and
+// marks it delivered, or "" if there is none.
+func (s *PairingRequestService) TakeNotice(ctx context.Context, conv *model.ConversationMapping) model.PairingNotice {
+	if conv.PairingNotice == nil {
+		return ""
+	}
+	notice := *conv.PairingNotice
+	if err := s.convs.ClearPairingNotice(ctx, conv.ConversationKey); err != nil {
+		log.Warn().Err(err).Str("conversationKey", conv.ConversationKey).Msg("failed to clear pairing notice")
+	}
+	conv.PairingNotice = nil
+	return notice
+}
+
+// notify tells the user about a decided request through the Event API when
+// configured; otherwise the notice waits for their next message.
+func (s *PairingRequestService) notify(ctx context.Context, req *model.PairingRequest) {
+	if s.cfg.NotifyEvent == "" || !s.events.Enabled() {
+		return
+	}
+
+	channelID, userKey, _ := strings.Cut(req.ConversationKey, ":")
+	botID := channelID
+	if botID == DefaultChannelID {
+		botID = ""
+	}
+
+	_, err := s.events.Send(ctx, EventAPIRequest{
+		BotID:     botID,
+		EventName: s.cfg.NotifyEvent,
+		Users:     []EventAPIUser{{Type: EventUserTypePlusfriendUserKey, ID: userKey}},
+		Params:    map[string]any{"result": string(req.Status)},
+	})
+	if err != nil {
+		log.Warn().Err(err).Str("requestId", req.ID).Msg("failed to notify pairing decision, will tell on next message")
+		return
+	}
+	if err := s.convs.ClearPairingNotice(ctx, req.ConversationKey); err != nil {
+		log.Warn().Err(err).Str("conversationKey", req.ConversationKey).Msg("failed to clear pairing notice")
+	}
+}
+
+func (s *PairingRequestService) publish(ctx context.Context, accountID, eventType string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("marshal %s event: %w", eventType, err)
+	}
+	return s.publisher.Publish(ctx, accountID, sse.Event{Type: eventType, Data: raw})
+}
+
+func pairingRequestEventData(req *model.PairingRequest, profile model.PairingProfile) map[string]any {
+	data := map[string]any{
+		"requestId":       req.ID,
+		"conversationKey": req.ConversationKey,
+		"kakaoUserId":     profile.KakaoUserID,
+		"profile":         profile,
+		"requestedAt":     req.CreatedAt.Format(time.RFC3339),
+		"expiresAt":       req.ExpiresAt.Format(time.RFC3339),
+	}
+	if req.PairingCodeID != nil {
+		data["pairingCodeId"] = *req.PairingCodeID
+	}
+	return data
+}
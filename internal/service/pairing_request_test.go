@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+)
+
+type mockPairingRequestRepo struct {
+	mock.Mock
+}
+
+func (m *mockPairingRequestRepo) Create(ctx context.Context, params model.CreatePairingRequestParams) (*model.PairingRequest, error) {
+	args := m.Called(ctx, params)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) FindByID(ctx context.Context, accountID, id string) (*model.PairingRequest, error) {
+	args := m.Called(ctx, accountID, id)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) FindPendingByAccountID(ctx context.Context, accountID string) ([]model.PairingRequest, error) {
+	args := m.Called(ctx, accountID)
+	return args.Get(0).([]model.PairingRequest), args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) Decide(ctx context.Context, accountID, id string, status model.PairingRequestStatus) (*model.PairingRequest, error) {
+	args := m.Called(ctx, accountID, id, status)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) Cancel(ctx context.Context, conversationKey string) (*model.PairingRequest, error) {
+	args := m.Called(ctx, conversationKey)
+	req, _ := args.Get(0).(*model.PairingRequest)
+	return req, args.Error(1)
+}
+
+func (m *mockPairingRequestRepo) ExpirePending(ctx context.Context) ([]model.PairingRequest, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]model.PairingRequest), args.Error(1)
+}
+
+var testPairingApproval = PairingApprovalConfig{
+	RequestTTL:  time.Hour,
+	NotifyEvent: "pairing_result",
+}
+
+func TestPairingRequestService_SubmitWithoutApproval(t *testing.T) {
+	repo, accounts, publisher := new(mockPairingRequestRepo), new(mockAccountRepo), &recordingPublisher{}
+	svc := NewPairingRequestService(repo, accounts, new(mockConversationRepo), publisher, nil, testPairingApproval)
+	ctx := context.Background()
+	accounts.On("FindByID", ctx, "acc-1").Return(&model.Account{ID: "acc-1"}, nil)
+
+	req, err := svc.Submit(ctx, "acc-1", "bot:alice", "pc-1", model.PairingProfile{KakaoUserID: "alice"})
+	require.NoError(t, err)
+	assert.Nil(t, req, "accounts without approval mode pair immediately")
+	repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	assert.Empty(t, publisher.events)
+}
+
+func TestPairingRequestService_SubmitPublishesRequest(t *testing.T) {
+	repo, accounts, publisher := new(mockPairingRequestRepo), new(mockAccountRepo), &recordingPublisher{}
+	svc := NewPairingRequestService(repo, accounts, new(mockConversationRepo), publisher, nil, testPairingApproval)
+	ctx := context.Background()
+	accounts.On("FindByID", ctx, "acc-1").Return(&model.Account{ID: "acc-1", RequirePairingApproval: true}, nil)
+	repo.On("Create", ctx, mock.MatchedBy(func(p model.CreatePairingRequestParams) bool {
+		return p.AccountID == "acc-1" && p.ConversationKey == "bot:alice" &&
+			p.PairingCodeID != nil && *p.PairingCodeID == "pc-1" &&
+			time.Until(p.ExpiresAt) > 59*time.Minute
+	})).Return(&model.PairingRequest{
+		ID:              "req-1",
+		AccountID:       "acc-1",
+		ConversationKey: "bot:alice",
+		PairingCodeID:   strPtr("pc-1"),
+		Status:          model.PairingRequestPending,
+		ExpiresAt:       time.Now().Add(time.Hour),
+		CreatedAt:       time.Now(),
+	}, nil)
+
+	friend := true
+	req, err := svc.Submit(ctx, "acc-1", "bot:alice", "pc-1", model.PairingProfile{KakaoUserID: "alice", ChannelID: "bot", IsFriend: &friend})
+	require.NoError(t, err)
+	require.NotNil(t, req)
+
+	require.Len(t, publisher.events["acc-1"], 1)
+	event := publisher.events["acc-1"][0]
+	assert.Equal(t, "pairing_request", event.Type)
+
+	var data struct {
+		RequestID     string               `json:"requestId"`
+		KakaoUserID   string               `json:"kakaoUserId"`
+		PairingCodeID string               `json:"pairingCodeId"`
+		Profile       model.PairingProfile `json:"profile"`
+	}
+	require.NoError(t, json.Unmarshal(event.Data, &data))
+	assert.Equal(t, "req-1", data.RequestID)
+	assert.Equal(t, "alice", data.KakaoUserID)
+	assert.Equal(t, "pc-1", data.PairingCodeID)
+	require.NotNil(t, data.Profile.IsFriend)
+	assert.True(t, *data.Profile.IsFriend)
+}
+
+func TestPairingRequestService_DecideErrors(t *testing.T) {
+	repo := new(mockPairingRequestRepo)
+	svc := NewPairingRequestService(repo, new(mockAccountRepo), new(mockConversationRepo), &recordingPublisher{}, nil, testPairingApproval)
+	ctx := context.Background()
+	repo.On("Decide", ctx, "acc-1", mock.Anything, model.PairingRequestApproved).Return(nil, nil)
+	repo.On("FindByID", ctx, "acc-1", "missing").Return(nil, nil)
+	repo.On("FindByID", ctx, "acc-1", "done").Return(&model.PairingRequest{ID: "done", Status: model.PairingRequestRejected}, nil)
+
+	_, err := svc.Decide(ctx, "acc-1", "missing", true)
+	assert.ErrorIs(t, err, ErrPairingRequestNotFound)
+
+	_, err = svc.Decide(ctx, "acc-1", "done", true)
+	assert.ErrorIs(t, err, ErrPairingRequestClosed)
+}
+
+func TestPairingRequestService_ApproveNotifiesAccountAndUser(t *testing.T) {
+	var sent map[string]any
+	kakao := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/bots/bot-1/talk", r.URL.Path)
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"taskId":"t-1","status":"SUCCESS"}`))
+	}))
+	defer kakao.Close()
+
+	repo, convs, publisher := new(mockPairingRequestRepo), new(mockConversationRepo), &recordingPublisher{}
+	svc := NewPairingRequestService(repo, new(mockAccountRepo), convs, publisher, NewEventAPIClient(kakao.URL, "rest-key", ""), testPairingApproval)
+	ctx := context.Background()
+	repo.On("Decide", ctx, "acc-1", "req-1", model.PairingRequestApproved).Return(&model.PairingRequest{
+		ID:              "req-1",
+		AccountID:       "acc-1",
+		ConversationKey: "bot-1:alice",
+		PairingCodeID:   strPtr("pc-1"),
+		Status:          model.PairingRequestApproved,
+	}, nil)
+	convs.On("ClearPairingNotice", ctx, "bot-1:alice").Return(nil)
+
+	req, err := svc.Decide(ctx, "acc-1", "req-1", true)
+	require.NoError(t, err)
+	assert.Equal(t, model.PairingRequestApproved, req.Status)
+
+	require.Len(t, publisher.events["acc-1"], 1)
+	assert.Equal(t, "pairing_complete", publisher.events["acc-1"][0].Type)
+	assert.Contains(t, string(publisher.events["acc-1"][0].Data), `"pairingCodeId":"pc-1"`)
+
+	require.NotNil(t, sent)
+	assert.Equal(t, map[string]any{"name": "pairing_result", "data": map[string]any{"params": map[string]any{"result": "approved"}}}, sent["event"])
+	convs.AssertCalled(t, "ClearPairingNotice", ctx, "bot-1:alice")
+}
+
+func TestPairingRequestService_TakeNotice(t *testing.T) {
+	convs := new(mockConversationRepo)
+	svc := NewPairingRequestService(new(mockPairingRequestRepo), new(mockAccountRepo), convs, &recordingPublisher{}, nil, testPairingApproval)
+	ctx := context.Background()
+	convs.On("ClearPairingNotice", ctx, "bot:alice").Return(nil)
+
+	rejected := model.PairingNoticeRejected
+	conv := &model.ConversationMapping{ConversationKey: "bot:alice", PairingNotice: &rejected}
+
+	assert.Equal(t, model.PairingNoticeRejected, svc.TakeNotice(ctx, conv))
+	assert.Nil(t, conv.PairingNotice)
+	assert.Equal(t, model.PairingNotice(""), svc.TakeNotice(ctx, conv))
+	convs.AssertNumberOfCalls(t, "ClearPairingNotice", 1)
+}
@@ -0,0 +1,370 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/rs/zerolog/log"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/util"
+)
+
+const (
+	maxRoutingRuleNameLen  = 100
+	maxRoutingTargets      = 10
+	maxUtterancePatternLen = 500
+)
+
+var (
+	ErrInvalidRoutingRule  = errors.New("invalid routing rule")
+	ErrRoutingRuleNotFound = errors.New("routing rule not found")
+)
+
+// RoutingRuleDefinition is a routing rule as written through the dashboard.
+// Empty conditions are unset.
+type RoutingRuleDefinition struct {
+	Name             string                `json:"name"`
+	Priority         int                   `json:"priority"`
+	Enabled          *bool                 `json:"enabled"`
+	SourceAccountID  *string               `json:"sourceAccountId"`
+	ChannelID        *string               `json:"channelId"`
+	BlockName        *string               `json:"blockName"`
+	IntentName       *string               `json:"intentName"`
+	UtterancePattern *string               `json:"utterancePattern"`
+	Targets          []model.RoutingTarget `json:"targets"`
+}
+
+// RoutingRequest is what routing rules look at in an inbound message.
+type RoutingRequest struct {
+	// AccountID is the account the conversation is paired with.
+	AccountID       string
+	ConversationKey string
+	ChannelID       string
+	BlockName       string
+	IntentName      string
+	Utterance       string
+}
+
+// Route is where an inbound message goes. RuleID is nil when no rule
+// matched and the message stays with the paired account.
+type Route struct {
+	AccountID string
+	RuleID    *string
+}
+
+// RoutingService evaluates routing rules for inbound messages and manages
+// them for the dashboard.
+type RoutingService struct {
+	repo     repository.RoutingRuleRepository
+	accounts repository.AccountRepository
+	sessions repository.SessionRepository
+
+	// patterns caches compiled utterance patterns by rule ID. An entry is
+	// replaced when the rule's updated_at changes.
+	mu       sync.Mutex
+	patterns map[string]compiledPattern
+}
+
+type compiledPattern struct {
+	updatedAt time.Time
+	re        *regexp.Regexp
+	err       error
+}
+
+func NewRoutingService(
+	repo repository.RoutingRuleRepository,
+	accounts repository.AccountRepository,
+	sessions repository.SessionRepository,
+) *RoutingService {
+	return &RoutingService{repo: repo, accounts: accounts, sessions: sessions, patterns: make(map[string]compiledPattern)}
+}
+
+func (s *RoutingService) List(ctx context.Context) ([]model.RoutingRule, error) {
+	return s.repo.FindAll(ctx)
+}
+
+// Create validates def and stores it as a new rule. Validation failures wrap
+// ErrInvalidRoutingRule.
+func (s *RoutingService) Create(ctx context.Context, def RoutingRuleDefinition) (*model.RoutingRule, error) {
+	params, err := s.validate(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	return s.repo.Create(ctx, params)
+}
+
+// Update replaces the rule with def.
+func (s *RoutingService) Update(ctx context.Context, id string, def RoutingRuleDefinition) (*model.RoutingRule, error) {
+	if !util.IsValidUUID(id) {
+		return nil, ErrRoutingRuleNotFound
+	}
+	params, err := s.validate(ctx, def)
+	if err != nil {
+		return nil, err
+	}
+	rule, err := s.repo.Update(ctx, id, params)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrRoutingRuleNotFound
+	}
+	return rule, nil
+}
+
+func (s *RoutingService) Delete(ctx context.Context, id string) error {
+	if !util.IsValidUUID(id) {
+		return ErrRoutingRuleNotFound
+	}
+	deleted, err := s.repo.Delete(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrRoutingRuleNotFound
+	}
+
+	s.mu.Lock()
+	delete(s.patterns, id)
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *RoutingService) validate(ctx context.Context, def RoutingRuleDefinition) (model.RoutingRuleParams, error) {
+	params := model.RoutingRuleParams{
+		Name:             strings.TrimSpace(def.Name),
+		Priority:         def.Priority,
+		Enabled:          def.Enabled == nil || *def.Enabled,
+		SourceAccountID:  optionalString(def.SourceAccountID),
+		ChannelID:        optionalString(def.ChannelID),
+		BlockName:        optionalString(def.BlockName),
+		IntentName:       optionalString(def.IntentName),
+		UtterancePattern: def.UtterancePattern,
+	}
+	if p := params.UtterancePattern; p != nil && *p == "" {
+		params.UtterancePattern = nil
+	}
+
+	if params.Name == "" {
+		return params, fmt.Errorf("%w: name is required", ErrInvalidRoutingRule)
+	}
+	if utf8.RuneCountInString(params.Name) > maxRoutingRuleNameLen {
+		return params, fmt.Errorf("%w: name must be at most %d characters", ErrInvalidRoutingRule, maxRoutingRuleNameLen)
+	}
+	if params.ChannelID == nil && params.BlockName == nil && params.IntentName == nil && params.UtterancePattern == nil {
+		return params, fmt.Errorf("%w: at least one of channelId, blockName, intentName or utterancePattern is required", ErrInvalidRoutingRule)
+	}
+	if p := params.UtterancePattern; p != nil {
+		if len(*p) > maxUtterancePatternLen {
+			return params, fmt.Errorf("%w: utterancePattern must be at most %d bytes", ErrInvalidRoutingRule, maxUtterancePatternLen)
+		}
+		if _, err := regexp.Compile(*p); err != nil {
+			return params, fmt.Errorf("%w: utterancePattern: %v", ErrInvalidRoutingRule, err)
+		}
+	}
+	if params.SourceAccountID != nil {
+		if err := s.checkAccount(ctx, *params.SourceAccountID); err != nil {
+			return params, err
+		}
+	}
+
+	targets, err := s.validateTargets(ctx, def.Targets)
+	if err != nil {
+		return params, err
+	}
+	params.Targets = targets
+	return params, nil
+}
+
+// validateTargets requires weights that add up to 100; a lone target
+// without a weight gets all of it.
+func (s *RoutingService) validateTargets(ctx context.Context, targets []model.RoutingTarget) (model.RoutingTargets, error) {
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("%w: at least one target is required", ErrInvalidRoutingRule)
+	}
+	if len(targets) > maxRoutingTargets {
+		return nil, fmt.Errorf("%w: at most %d targets allowed", ErrInvalidRoutingRule, maxRoutingTargets)
+	}
+
+	result := make(model.RoutingTargets, 0, len(targets))
+	seen := make(map[string]bool)
+	total := 0
+	for _, t := range targets {
+		target := model.RoutingTarget{AccountID: optionalString(t.AccountID), Weight: t.Weight}
+		if len(targets) == 1 && target.Weight == 0 {
+			target.Weight = 100
+		}
+		if target.Weight < 1 || target.Weight > 100 {
+			return nil, fmt.Errorf("%w: target weights must be between 1 and 100", ErrInvalidRoutingRule)
+		}
+
+		key := ""
+		if target.AccountID != nil {
+			key = *target.AccountID
+			if err := s.checkAccount(ctx, key); err != nil {
+				return nil, err
+			}
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: duplicate target", ErrInvalidRoutingRule)
+		}
+		seen[key] = true
+
+		total += target.Weight
+		result = append(result, target)
+	}
+	if total != 100 {
+		return nil, fmt.Errorf("%w: target weights must add up to 100, got %d", ErrInvalidRoutingRule, total)
+	}
+	return result, nil
+}
+
+func (s *RoutingService) checkAccount(ctx context.Context, id string) error {
+	if !util.IsValidUUID(id) {
+		return fmt.Errorf("%w: unknown account %s", ErrInvalidRoutingRule, id)
+	}
+	account, err := s.accounts.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if account == nil {
+		return fmt.Errorf("%w: unknown account %s", ErrInvalidRoutingRule, id)
+	}
+	return nil
+}
+
+// Resolve picks the account for an inbound message: the first enabled rule
+// that matches sends it to one of its targets, otherwise it stays with the
+// paired account. A weighted split always sends the same conversation to
+// the same target. A target account that no longer exists or has no
+// credential left to consume its events with falls back to the paired
+// account.
+func (s *RoutingService) Resolve(ctx context.Context, req RoutingRequest) (Route, error) {
+	route := Route{AccountID: req.AccountID}
+
+	rules, err := s.repo.FindEnabled(ctx, req.AccountID)
+	if err != nil {
+		return route, fmt.Errorf("find routing rules: %w", err)
+	}
+	for i := range rules {
+		rule := &rules[i]
+		if !s.ruleMatches(rule, req) {
+			continue
+		}
+
+		target := pickTarget(rule, req.ConversationKey)
+		if target == nil || target.AccountID == nil || *target.AccountID == req.AccountID {
+			route.RuleID = &rule.ID
+			return route, nil
+		}
+
+		account, err := s.accounts.FindByID(ctx, *target.AccountID)
+		if err != nil {
+			return route, fmt.Errorf("find routing target: %w", err)
+		}
+		if account == nil {
+			log.Warn().Str("ruleId", rule.ID).Str("accountId", *target.AccountID).Msg("routing target account not found, keeping paired account")
+			return route, nil
+		}
+		usable, err := s.hasCredential(ctx, account)
+		if err != nil {
+			return route, fmt.Errorf("check routing target credentials: %w", err)
+		}
+		if !usable {
+			log.Warn().Str("ruleId", rule.ID).Str("accountId", account.ID).Msg("routing target account has no credentials, keeping paired account")
+			return route, nil
+		}
+		return Route{AccountID: account.ID, RuleID: &rule.ID}, nil
+	}
+	return route, nil
+}
+
+// hasCredential reports whether anything can still authenticate as the
+// account, which only a paired session's token does.
+func (s *RoutingService) hasCredential(ctx context.Context, account *model.Account) (bool, error) {
+	count, err := s.sessions.CountPairedByAccountID(ctx, account.ID)
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (s *RoutingService) ruleMatches(rule *model.RoutingRule, req RoutingRequest) bool {
+	if rule.ChannelID != nil && *rule.ChannelID != req.ChannelID {
+		return false
+	}
+	if rule.BlockName != nil && *rule.BlockName != req.BlockName {
+		return false
+	}
+	if rule.IntentName != nil && *rule.IntentName != req.IntentName {
+		return false
+	}
+	if rule.UtterancePattern != nil {
+		re, err := s.pattern(rule)
+		if err != nil {
+			log.Warn().Err(err).Str("ruleId", rule.ID).Msg("skipping routing rule with invalid utterance pattern")
+			return false
+		}
+		if !re.MatchString(req.Utterance) {
+			return false
+		}
+	}
+	return true
+}
+
+// pattern returns the rule's compiled utterance pattern, compiling it on
+// first use after the rule was created or updated. Compile errors are
+// cached too, for rows stored before validation or edited by hand.
+func (s *RoutingService) pattern(rule *model.RoutingRule) (*regexp.Regexp, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if cached, ok := s.patterns[rule.ID]; ok && cached.updatedAt.Equal(rule.UpdatedAt) {
+		return cached.re, cached.err
+	}
+	re, err := regexp.Compile(*rule.UtterancePattern)
+	s.patterns[rule.ID] = compiledPattern{updatedAt: rule.UpdatedAt, re: re, err: err}
+	return re, err
+}
+
+// pickTarget buckets the conversation into 0-99 by a hash of the rule and
+// conversation, so splits are stable per conversation and independent
+// between rules.
+func pickTarget(rule *model.RoutingRule, conversationKey string) *model.RoutingTarget {
+	h := fnv.New32a()
+	h.Write([]byte(rule.ID))
+	h.Write([]byte{0})
+	h.Write([]byte(conversationKey))
+	bucket := int(h.Sum32() % 100)
+
+	for i := range rule.Targets {
+		bucket -= rule.Targets[i].Weight
+		if bucket < 0 {
+			return &rule.Targets[i]
+		}
+	}
+	if len(rule.Targets) > 0 {
+		return &rule.Targets[len(rule.Targets)-1]
+	}
+	return nil
+}
+
+func optionalString(s *string) *string {
+	if s == nil {
+		return nil
+	}
+	v := strings.TrimSpace(*s)
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,306 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/internal/model"
+	"gitlab.tepseg.com/ai/kakao-relay/internal/repository"
+)
+
+const (
+	pairedAccountID = "00000000-0000-0000-0000-00000000000a"
+	canaryAccountID = "00000000-0000-0000-0000-00000000000b"
+	routingRuleID   = "00000000-0000-0000-0000-0000000000f1"
+)
+
+type mockRoutingRuleRepo struct {
+	repository.RoutingRuleRepository
+	mock.Mock
+}
+
+func (m *mockRoutingRuleRepo) FindEnabled(ctx context.Context, accountID string) ([]model.RoutingRule, error) {
+	args := m.Called(ctx, accountID)
+	rules, _ := args.Get(0).([]model.RoutingRule)
+	return rules, args.Error(1)
+}
+
+func (m *mockRoutingRuleRepo) Create(ctx context.Context, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	args := m.Called(ctx, params)
+	rule, _ := args.Get(0).(*model.RoutingRule)
+	return rule, args.Error(1)
+}
+
+func (m *mockRoutingRuleRepo) Update(ctx context.Context, id string, params model.RoutingRuleParams) (*model.RoutingRule, error) {
+	args := m.Called(ctx, id, params)
+	rule, _ := args.Get(0).(*model.RoutingRule)
+	return rule, args.Error(1)
+}
+
+type routingSessionRepo struct {
+	repository.SessionRepository
+	paired map[string]int
+}
+
+func (r *routingSessionRepo) CountPairedByAccountID(ctx context.Context, accountID string) (int, error) {
+	return r.paired[accountID], nil
+}
+
+func TestRoutingService_Resolve(t *testing.T) {
+	ctx := context.Background()
+	req := RoutingRequest{
+		AccountID:       pairedAccountID,
+		ConversationKey: "bot-1:user-1",
+		ChannelID:       "bot-1",
+		BlockName:       "상담",
+		IntentName:      "refund",
+		Utterance:       "환불하고 싶어요",
+	}
+	canary := &model.Account{ID: canaryAccountID}
+	pairedSessions := &routingSessionRepo{paired: map[string]int{canaryAccountID: 1}}
+
+	t.Run("keeps the paired account without a matching rule", func(t *testing.T) {
+		repo := new(mockRoutingRuleRepo)
+		svc := NewRoutingService(repo, new(mockAccountRepo), pairedSessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{{
+			ID:        routingRuleID,
+			BlockName: strPtr("다른 블록"),
+			Targets:   model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+		}}, nil)
+
+		route, err := svc.Resolve(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, Route{AccountID: pairedAccountID}, route)
+	})
+
+	t.Run("routes when every condition matches", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, pairedSessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{
+			{
+				ID:               "rule-0",
+				IntentName:       strPtr("refund"),
+				UtterancePattern: strPtr(`^취소`),
+				Targets:          model.RoutingTargets{{AccountID: strPtr("other"), Weight: 100}},
+			},
+			{
+				ID:               routingRuleID,
+				ChannelID:        strPtr("bot-1"),
+				IntentName:       strPtr("refund"),
+				UtterancePattern: strPtr(`환불`),
+				Targets:          model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+			},
+		}, nil)
+		accounts.On("FindByID", ctx, canaryAccountID).Return(canary, nil)
+
+		route, err := svc.Resolve(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, canaryAccountID, route.AccountID)
+		assert.Equal(t, routingRuleID, *route.RuleID)
+	})
+
+	t.Run("falls back when the target account is gone", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, pairedSessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{{
+			ID:        routingRuleID,
+			ChannelID: strPtr("bot-1"),
+			Targets:   model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+		}}, nil)
+		accounts.On("FindByID", ctx, canaryAccountID).Return(nil, nil)
+
+		route, err := svc.Resolve(ctx, req)
+
+		require.NoError(t, err)
+		assert.Equal(t, Route{AccountID: pairedAccountID}, route)
+	})
+
+	t.Run("falls back only when the target account has no credentials", func(t *testing.T) {
+		repo, accounts, sessions := new(mockRoutingRuleRepo), new(mockAccountRepo), new(routingSessionRepo)
+		svc := NewRoutingService(repo, accounts, sessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{{
+			ID:        routingRuleID,
+			ChannelID: strPtr("bot-1"),
+			Targets:   model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+		}}, nil)
+		accounts.On("FindByID", ctx, canaryAccountID).Return(&model.Account{ID: canaryAccountID, RelayTokenHash: strPtr("hash")}, nil)
+
+		route, err := svc.Resolve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, Route{AccountID: pairedAccountID}, route, "a relay token alone does not authenticate")
+
+		sessions.paired = map[string]int{canaryAccountID: 1}
+		route, err = svc.Resolve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, canaryAccountID, route.AccountID, "its paired session can still consume the events")
+	})
+
+	t.Run("recompiles a pattern when the rule is updated", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, pairedSessions)
+		rule := model.RoutingRule{
+			ID:               routingRuleID,
+			UtterancePattern: strPtr(`환불`),
+			Targets:          model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+			UpdatedAt:        time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		}
+		updated := rule
+		updated.UtterancePattern = strPtr(`^취소`)
+		updated.UpdatedAt = rule.UpdatedAt.Add(time.Minute)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{rule}, nil).Twice()
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{updated}, nil).Once()
+		accounts.On("FindByID", ctx, canaryAccountID).Return(canary, nil)
+
+		for range 2 {
+			route, err := svc.Resolve(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, canaryAccountID, route.AccountID)
+		}
+		route, err := svc.Resolve(ctx, req)
+		require.NoError(t, err)
+		assert.Equal(t, pairedAccountID, route.AccountID, "the updated pattern no longer matches")
+	})
+
+	t.Run("skips a rule whose stored pattern does not compile", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, pairedSessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{
+			{
+				ID:               "rule-0",
+				UtterancePattern: strPtr(`(unclosed`),
+				Targets:          model.RoutingTargets{{AccountID: strPtr("other"), Weight: 100}},
+			},
+			{
+				ID:        routingRuleID,
+				ChannelID: strPtr("bot-1"),
+				Targets:   model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+			},
+		}, nil)
+		accounts.On("FindByID", ctx, canaryAccountID).Return(canary, nil)
+
+		for range 2 {
+			route, err := svc.Resolve(ctx, req)
+			require.NoError(t, err)
+			assert.Equal(t, canaryAccountID, route.AccountID)
+		}
+	})
+
+	t.Run("splits conversations by weight and keeps each one sticky", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, pairedSessions)
+		repo.On("FindEnabled", ctx, pairedAccountID).Return([]model.RoutingRule{{
+			ID:        routingRuleID,
+			ChannelID: strPtr("bot-1"),
+			Targets: model.RoutingTargets{
+				{Weight: 80},
+				{AccountID: strPtr(canaryAccountID), Weight: 20},
+			},
+		}}, nil)
+		accounts.On("FindByID", ctx, canaryAccountID).Return(canary, nil)
+
+		routed := 0
+		for i := range 1000 {
+			r := req
+			r.ConversationKey = fmt.Sprintf("bot-1:user-%d", i)
+			first, err := svc.Resolve(ctx, r)
+			require.NoError(t, err)
+			again, err := svc.Resolve(ctx, r)
+			require.NoError(t, err)
+			require.Equal(t, first.AccountID, again.AccountID)
+			if first.AccountID == canaryAccountID {
+				routed++
+			}
+		}
+		assert.InDelta(t, 200, routed, 50)
+	})
+}
+
+func TestRoutingService_Create(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("stores a valid rule", func(t *testing.T) {
+		repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+		svc := NewRoutingService(repo, accounts, new(routingSessionRepo))
+		accounts.On("FindByID", ctx, canaryAccountID).Return(&model.Account{ID: canaryAccountID}, nil)
+		repo.On("Create", ctx, model.RoutingRuleParams{
+			Name:      "canary",
+			Enabled:   true,
+			BlockName: strPtr("상담"),
+			Targets:   model.RoutingTargets{{AccountID: strPtr(canaryAccountID), Weight: 100}},
+		}).Return(&model.RoutingRule{ID: routingRuleID}, nil)
+
+		rule, err := svc.Create(ctx, RoutingRuleDefinition{
+			Name:       " canary ",
+			BlockName:  strPtr("상담"),
+			IntentName: strPtr(""),
+			Targets:    []model.RoutingTarget{{AccountID: strPtr(canaryAccountID)}},
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, routingRuleID, rule.ID)
+	})
+
+	invalid := map[string]RoutingRuleDefinition{
+		"no name": {
+			ChannelID: strPtr("bot-1"),
+			Targets:   []model.RoutingTarget{{Weight: 100}},
+		},
+		"no condition": {
+			Name:    "r",
+			Targets: []model.RoutingTarget{{Weight: 100}},
+		},
+		"bad pattern": {
+			Name:             "r",
+			UtterancePattern: strPtr("(unclosed"),
+			Targets:          []model.RoutingTarget{{Weight: 100}},
+		},
+		"no targets": {
+			Name:      "r",
+			ChannelID: strPtr("bot-1"),
+		},
+		"weights not adding up": {
+			Name:      "r",
+			ChannelID: strPtr("bot-1"),
+			Targets:   []model.RoutingTarget{{Weight: 50}, {AccountID: strPtr(canaryAccountID), Weight: 30}},
+		},
+		"unknown account": {
+			Name:      "r",
+			ChannelID: strPtr("bot-1"),
+			Targets:   []model.RoutingTarget{{AccountID: strPtr("not-a-uuid")}},
+		},
+	}
+	for name, def := range invalid {
+		t.Run("rejects "+name, func(t *testing.T) {
+			repo, accounts := new(mockRoutingRuleRepo), new(mockAccountRepo)
+			svc := NewRoutingService(repo, accounts, new(routingSessionRepo))
+			accounts.On("FindByID", ctx, canaryAccountID).Return(&model.Account{ID: canaryAccountID}, nil)
+
+			_, err := svc.Create(ctx, def)
+
+			assert.ErrorIs(t, err, ErrInvalidRoutingRule)
+			repo.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+		})
+	}
+}
+
+func TestRoutingService_Update(t *testing.T) {
+	ctx := context.Background()
+	repo := new(mockRoutingRuleRepo)
+	svc := NewRoutingService(repo, new(mockAccountRepo), new(routingSessionRepo))
+	def := RoutingRuleDefinition{Name: "r", ChannelID: strPtr("bot-1"), Targets: []model.RoutingTarget{{}}}
+
+	_, err := svc.Update(ctx, "not-a-uuid", def)
+	assert.ErrorIs(t, err, ErrRoutingRuleNotFound)
+
+	repo.On("Update", ctx, routingRuleID, mock.Anything).Return(nil, nil)
+	_, err = svc.Update(ctx, routingRuleID, def)
+	assert.ErrorIs(t, err, ErrRoutingRuleNotFound)
+}
@@ -24,6 +24,13 @@ const (
 	sessionPairingExpiryMins = 5
 )
 
+// Pairing errors for invite codes the conversation may not use. The code
+// keeps its remaining uses.
+const (
+	PairingErrBlocked = "BLOCKED"
+	PairingErrPending = "PENDING"
+)
+
 type CreateSessionResult struct {
 	SessionToken string    `json:"sessionToken"`
 	PairingCode  string    `json:"pairingCode"`
@@ -46,6 +53,11 @@ type SessionPairResult struct {
 	AccountID  string
 	RelayToken string
 	Error      string
+	// PairingCodeID is set when an account invite code was redeemed; the
+	// conversation joins that existing account and SessionID is empty.
+	PairingCodeID string
+	// RetryAt is set when Error is PairingErrLockedOut or PairingErrRateLimited.
+	RetryAt time.Time
 }
 
 type SessionService struct {
@@ -53,19 +65,30 @@ type SessionService struct {
 	sessionRepo repository.SessionRepository
 	accountRepo repository.AccountRepository
 	broker      *sse.Broker
+	guard       *PairingGuard
+	invites     *PairingCodeService
+	lifecycle   *LifecycleService
 }
 
+// NewSessionService creates the service. guard may be nil to disable
+// pairing attempt limits, and invites nil to accept session codes only.
 func NewSessionService(
 	db *database.DB,
 	sessionRepo repository.SessionRepository,
 	accountRepo repository.AccountRepository,
 	broker *sse.Broker,
+	guard *PairingGuard,
+	invites *PairingCodeService,
+	lifecycle *LifecycleService,
 ) *SessionService {
 	return &SessionService{
 		db:          db,
 		sessionRepo: sessionRepo,
 		accountRepo: accountRepo,
 		broker:      broker,
+		guard:       guard,
+		invites:     invites,
+		lifecycle:   lifecycle,
 	}
 }
 
@@ -115,7 +138,9 @@ func (s *SessionService) GetStatus(ctx context.Context, tokenHash string) (*Sess
 
 	// Check if pending session has expired
 	if session.Status == model.SessionStatusPendingPairing && time.Now().After(session.ExpiresAt) {
-		s.sessionRepo.MarkExpired(ctx, session.ID)
+		if err := s.lifecycle.ExpireSession(ctx, session); err != nil {
+			log.Warn().Err(err).Str("sessionId", session.ID).Msg("failed to expire session")
+		}
 		return &SessionStatusResult{
 			Status: model.SessionStatusExpired,
 		}, nil
@@ -160,16 +185,24 @@ func (s *SessionService) FindRecent(ctx context.Context, limit int) ([]model.Ses
 	return s.sessionRepo.FindRecent(ctx, limit)
 }
 
-func (s *SessionService) Disconnect(ctx context.Context, id string) error {
-	return s.sessionRepo.MarkDisconnected(ctx, id)
-}
-
-func (s *SessionService) Delete(ctx context.Context, id string) error {
-	return s.sessionRepo.Delete(ctx, id)
-}
-
-func (s *SessionService) VerifyPairingCode(ctx context.Context, code, conversationKey string) SessionPairResult {
+// VerifyPairingCode pairs conv with a session code, or attaches it to an
+// existing account with an invite code. Invites of the account that blocked
+// the conversation, or while it awaits approval, are refused before they
+// use up the code.
+func (s *SessionService) VerifyPairingCode(ctx context.Context, code string, conv *model.ConversationMapping) SessionPairResult {
 	normalizedCode := strings.ToUpper(strings.TrimSpace(code))
+	conversationKey := conv.ConversationKey
+
+	if s.guard != nil {
+		if decision := s.guard.Allow(ctx, conversationKey); !decision.Allowed {
+			log.Warn().
+				Str("conversationKey", conversationKey).
+				Str("reason", decision.Reason).
+				Time("retryAt", decision.RetryAt).
+				Msg("pairing attempt blocked")
+			return SessionPairResult{Success: false, Error: decision.Reason, RetryAt: decision.RetryAt}
+		}
+	}
 
 	// First, find the session (outside transaction for quick validation)
 	session, err := s.sessionRepo.FindByPairingCode(ctx, normalizedCode)
@@ -178,8 +211,33 @@ func (s *SessionService) VerifyPairingCode(ctx context.Context, code, conversati
 		return SessionPairResult{Success: false, Error: "INVALID_CODE"}
 	}
 
+	if session == nil && s.invites != nil {
+		if refused, ok := s.refuseInvite(ctx, normalizedCode, conv); ok {
+			return refused
+		}
+		invite, err := s.invites.Redeem(ctx, normalizedCode)
+		if err != nil {
+			log.Error().Err(err).Msg("verify pairing code: redeem invite failed")
+			return SessionPairResult{Success: false, Error: "INTERNAL_ERROR"}
+		}
+		if invite != nil {
+			log.Info().
+				Str("pairingCodeId", invite.ID).
+				Str("accountId", invite.AccountID).
+				Str("conversationKey", conversationKey).
+				Msg("conversation joined account via pairing code")
+			if s.guard != nil {
+				s.guard.RecordSuccess(ctx, conversationKey, invite.AccountID)
+			}
+			return SessionPairResult{Success: true, AccountID: invite.AccountID, PairingCodeID: invite.ID}
+		}
+	}
+
 	if session == nil {
 		log.Warn().Str("code", util.MaskCode(normalizedCode)).Msg("invalid session pairing code")
+		if s.guard != nil {
+			s.guard.RecordFailure(ctx, conversationKey, normalizedCode, "INVALID_CODE")
+		}
 		return SessionPairResult{Success: false, Error: "INVALID_CODE"}
 	}
 
@@ -222,6 +280,9 @@ func (s *SessionService) VerifyPairingCode(ctx context.Context, code, conversati
 		Str("accountId", account.ID).
 		Str("conversationKey", conversationKey).
 		Msg("session paired successfully")
+	if s.guard != nil {
+		s.guard.RecordSuccess(ctx, conversationKey, account.ID)
+	}
 
 	return SessionPairResult{
 		Success:    true,
@@ -231,6 +292,34 @@ func (s *SessionService) VerifyPairingCode(ctx context.Context, code, conversati
 	}
 }
 
+// refuseInvite checks a live invite against the conversation's state before
+// it is redeemed.
+func (s *SessionService) refuseInvite(ctx context.Context, code string, conv *model.ConversationMapping) (SessionPairResult, bool) {
+	if conv.State != model.PairingStateBlocked && conv.State != model.PairingStatePending {
+		return SessionPairResult{}, false
+	}
+	invite, err := s.invites.FindLive(ctx, code)
+	if err != nil {
+		log.Error().Err(err).Msg("verify pairing code: find invite failed")
+		return SessionPairResult{Success: false, Error: "INTERNAL_ERROR"}, true
+	}
+	if invite == nil {
+		return SessionPairResult{}, false
+	}
+
+	if conv.State == model.PairingStatePending {
+		return SessionPairResult{Success: false, Error: PairingErrPending, AccountID: invite.AccountID}, true
+	}
+	if conv.AccountID != nil && *conv.AccountID == invite.AccountID {
+		log.Info().
+			Str("conversationKey", conv.ConversationKey).
+			Str("accountId", invite.AccountID).
+			Msg("blocked user tried to pair")
+		return SessionPairResult{Success: false, Error: PairingErrBlocked, AccountID: invite.AccountID}, true
+	}
+	return SessionPairResult{}, false
+}
+
 func (s *SessionService) createAccountForSession(ctx context.Context, sessionID string) (*model.Account, string, error) {
 	return s.createAccountForSessionTx(ctx, s.accountRepo, sessionID)
 }
@@ -259,25 +348,9 @@ func (s *SessionService) PublishPairingComplete(ctx context.Context, session *mo
 		return fmt.Errorf("session not paired")
 	}
 
-	// Extract kakaoUserId from conversation key
-	var kakaoUserID string
-	parts := strings.Split(conversationKey, ":")
-	if len(parts) >= 2 {
-		kakaoUserID = parts[1]
-	}
-
-	eventDataBytes, err := json.Marshal(map[string]string{
-		"kakaoUserId": kakaoUserID,
-		"pairedAt":    time.Now().Format(time.RFC3339),
-		"accountId":   *session.AccountID,
-	})
+	event, err := pairingCompleteEvent(*session.AccountID, conversationKey, "")
 	if err != nil {
-		return fmt.Errorf("marshal pairing complete event: %w", err)
-	}
-
-	event := sse.Event{
-		Type: "pairing_complete",
-		Data: eventDataBytes,
+		return err
 	}
 
 	// Publish to session channel (for pending SSE connections)
@@ -290,27 +363,41 @@ func (s *SessionService) PublishPairingComplete(ctx context.Context, session *mo
 	return s.broker.Publish(ctx, *session.AccountID, event)
 }
 
-func (s *SessionService) PublishPairingExpired(ctx context.Context, sessionID string, reason string) error {
-	session, err := s.sessionRepo.FindByID(ctx, sessionID)
-	if err != nil || session == nil {
-		return fmt.Errorf("session not found")
+// PublishInvitePairingComplete notifies the account's stream that a
+// conversation joined it through an invite code.
+func (s *SessionService) PublishInvitePairingComplete(ctx context.Context, accountID, pairingCodeID, conversationKey string) error {
+	event, err := pairingCompleteEvent(accountID, conversationKey, pairingCodeID)
+	if err != nil {
+		return err
 	}
+	return s.broker.Publish(ctx, accountID, event)
+}
 
-	if session.AccountID == nil {
-		return nil // No account to notify
+func pairingCompleteEvent(accountID, conversationKey, pairingCodeID string) (sse.Event, error) {
+	// Extract kakaoUserId from conversation key
+	var kakaoUserID string
+	parts := strings.Split(conversationKey, ":")
+	if len(parts) >= 2 {
+		kakaoUserID = parts[1]
 	}
 
-	eventDataBytes, err := json.Marshal(map[string]string{
-		"reason": reason,
-	})
+	data := map[string]string{
+		"kakaoUserId": kakaoUserID,
+		"pairedAt":    time.Now().Format(time.RFC3339),
+		"accountId":   accountID,
+	}
+	if pairingCodeID != "" {
+		data["pairingCodeId"] = pairingCodeID
+	}
+	eventDataBytes, err := json.Marshal(data)
 	if err != nil {
-		return fmt.Errorf("marshal pairing expired event: %w", err)
+		return sse.Event{}, fmt.Errorf("marshal pairing complete event: %w", err)
 	}
 
-	return s.broker.Publish(ctx, *session.AccountID, sse.Event{
-		Type: "pairing_expired",
+	return sse.Event{
+		Type: "pairing_complete",
 		Data: eventDataBytes,
-	})
+	}, nil
 }
 
 func generateSessionPairingCode() string {
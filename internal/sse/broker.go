@@ -3,9 +3,12 @@ package sse
 import (
 	"context"
 	"encoding/json"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	goredis "github.com/redis/go-redis/v9"
 	"github.com/rs/zerolog/log"
 
 	redisclient "gitlab.tepseg.com/ai/kakao-relay/internal/redis"
@@ -13,48 +16,273 @@ import (
 
 const (
 	HeartbeatInterval = 30 * time.Second
+
+	// DefaultBufferSize is the number of events a client may have waiting
+	// before its overflow policy applies.
+	DefaultBufferSize = 100
+
+	// OverflowEventType is the terminal event sent to a client disconnected
+	// for falling behind. It asks the client to reconnect, which resends
+	// its queued messages.
+	OverflowEventType = "overflow"
+
+	// closeEventType is the control event behind CloseStreams. It is never
+	// sent to clients.
+	closeEventType = "_close"
+
+	// wakeEventType is the control event behind Wake. It is never sent to
+	// clients.
+	wakeEventType = "_wake"
 )
 
 type Event struct {
+	// ID is sent as the SSE "id:" field so clients can resume with
+	// Last-Event-ID. Empty for events that are not replayable.
+	ID   string          `json:"id,omitempty"`
 	Type string          `json:"type"`
 	Data json.RawMessage `json:"data"`
 }
 
+// OverflowPolicy decides what happens when a client's event buffer is
+// full.
+type OverflowPolicy string
+
+const (
+	// OverflowDisconnect closes the client's stream with an overflow event.
+	// Messages it missed are still queued and resent when it reconnects.
+	OverflowDisconnect OverflowPolicy = "disconnect"
+	// OverflowDrop drops the event and keeps the client connected.
+	OverflowDrop OverflowPolicy = "drop"
+)
+
+// Valid reports whether p is a known policy.
+func (p OverflowPolicy) Valid() bool {
+	return p == OverflowDisconnect || p == OverflowDrop
+}
+
+// Config tunes how the broker treats slow clients.
+type Config struct {
+	// BufferSize is each client's event buffer. Zero means
+	// DefaultBufferSize.
+	BufferSize int
+	// OverflowPolicy applies when a buffer is full. Empty means
+	// OverflowDisconnect.
+	OverflowPolicy OverflowPolicy
+}
+
+// Stats are the broker's slow-client counters since start, on this
+// replica.
+type Stats struct {
+	Clients int
+	// Delivered counts events written to clients; Dropped counts events
+	// lost to full buffers.
+	Delivered int64
+	Dropped   int64
+	// OverflowDisconnects counts clients closed by OverflowDisconnect.
+	OverflowDisconnects int64
+	// MaxLag is the highest current lag of a connected client.
+	MaxLag time.Duration
+}
+
+// ClientStats describe one connected client.
+type ClientStats struct {
+	Competing   bool
+	ConnectedAt time.Time
+	// Pending is the number of events waiting in the buffer.
+	Pending   int
+	Delivered int64
+	Dropped   int64
+	// Lag is how long the last delivered event waited in the buffer;
+	// MaxLag is the longest wait so far.
+	Lag    time.Duration
+	MaxLag time.Duration
+}
+
 type Client struct {
 	AccountID string
 	Events    chan Event
 	Done      chan struct{}
+
+	// Competing clients share the account's messages with its other
+	// competing clients instead of each receiving every one. Replayable
+	// events are not put on Events for them; Wake is signalled instead and
+	// the client claims its share from the database.
+	Competing bool
+	Wake      chan struct{}
+
+	// Filter drops events before they are buffered. Nil passes all.
+	Filter *Filter
+
+	connectedAt time.Time
+	overflowed  atomic.Bool
+	delivered   atomic.Int64
+	dropped     atomic.Int64
+	lag         atomic.Int64 // nanoseconds
+	maxLag      atomic.Int64 // nanoseconds
+
+	// enqueued holds when each buffered event was put on Events, in
+	// order, for lag tracking.
+	mu       sync.Mutex
+	enqueued []time.Time
+}
+
+// Overflowed reports whether the broker closed the client because its
+// buffer was full. The stream should then end with an overflow event.
+func (c *Client) Overflowed() bool {
+	return c.overflowed.Load()
+}
+
+// Dropped is the number of events the client lost to a full buffer.
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Sent records that the oldest buffered event was written to the client.
+// Call it once per event taken from Events.
+func (c *Client) Sent() {
+	c.mu.Lock()
+	var at time.Time
+	if len(c.enqueued) > 0 {
+		at = c.enqueued[0]
+		c.enqueued = c.enqueued[1:]
+	}
+	c.mu.Unlock()
+
+	c.delivered.Add(1)
+	if at.IsZero() {
+		return
+	}
+	lag := int64(time.Since(at))
+	c.lag.Store(lag)
+	for {
+		prev := c.maxLag.Load()
+		if lag <= prev || c.maxLag.CompareAndSwap(prev, lag) {
+			return
+		}
+	}
+}
+
+// Stats returns the client's delivery counters.
+func (c *Client) Stats() ClientStats {
+	return ClientStats{
+		Competing:   c.Competing,
+		ConnectedAt: c.connectedAt,
+		Pending:     len(c.Events),
+		Delivered:   c.delivered.Load(),
+		Dropped:     c.dropped.Load(),
+		Lag:         c.currentLag(),
+		MaxLag:      time.Duration(c.maxLag.Load()),
+	}
+}
+
+// currentLag is the wait of the oldest buffered event, or the lag of the
+// last delivered one when nothing waits longer.
+func (c *Client) currentLag() time.Duration {
+	lag := time.Duration(c.lag.Load())
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.enqueued) > 0 {
+		lag = max(lag, time.Since(c.enqueued[0]))
+	}
+	return lag
+}
+
+// offer puts event on the buffer without blocking and reports whether it
+// fit.
+func (c *Client) offer(event Event) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	select {
+	case c.Events <- event:
+		c.enqueued = append(c.enqueued, time.Now())
+		return true
+	default:
+		return false
+	}
+}
+
+// subscription is the pubsub listener of one account, running while the
+// account has clients on this replica.
+type subscription struct {
+	cancel context.CancelFunc
 }
 
 type Broker struct {
 	redis   *redisclient.Client
 	clients map[string]map[*Client]bool // accountID -> set of clients
+	subs    map[string]*subscription    // accountID -> its listener
 	mu      sync.RWMutex
 	ctx     context.Context
 	cancel  context.CancelFunc
+
+	// listen opens the pubsub channel of an account. Tests replace it.
+	listen func(ctx context.Context, channel string) (<-chan *goredis.Message, func() error)
+
+	bufferSize int
+	policy     OverflowPolicy
+
+	delivered           atomic.Int64 // by clients that have left
+	dropped             atomic.Int64
+	overflowDisconnects atomic.Int64
 }
 
-func NewBroker(redisClient *redisclient.Client) *Broker {
+func NewBroker(redisClient *redisclient.Client, cfg Config) *Broker {
+	if cfg.BufferSize <= 0 {
+		cfg.BufferSize = DefaultBufferSize
+	}
+	if cfg.OverflowPolicy == "" {
+		cfg.OverflowPolicy = OverflowDisconnect
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
-	return &Broker{
-		redis:   redisClient,
-		clients: make(map[string]map[*Client]bool),
-		ctx:     ctx,
-		cancel:  cancel,
+	b := &Broker{
+		redis:      redisClient,
+		clients:    make(map[string]map[*Client]bool),
+		subs:       make(map[string]*subscription),
+		ctx:        ctx,
+		cancel:     cancel,
+		bufferSize: cfg.BufferSize,
+		policy:     cfg.OverflowPolicy,
 	}
+	b.listen = b.listenRedis
+	return b
 }
 
-func (b *Broker) Subscribe(accountID string) *Client {
-	client := &Client{
+// Subscribe subscribes a client to accountID. filter may be nil.
+func (b *Broker) Subscribe(accountID string, filter *Filter) *Client {
+	return b.subscribe(&Client{
 		AccountID: accountID,
-		Events:    make(chan Event, 100),
+		Events:    make(chan Event, b.bufferSize),
 		Done:      make(chan struct{}),
-	}
+		Filter:    filter,
+	})
+}
+
+// SubscribeCompeting subscribes a competing client to accountID. See
+// Client.Competing. The filter only sees the events put on Events, so it
+// must not exclude messages (see Filter.FiltersMessages).
+func (b *Broker) SubscribeCompeting(accountID string, filter *Filter) *Client {
+	return b.subscribe(&Client{
+		AccountID: accountID,
+		Events:    make(chan Event, b.bufferSize),
+		Done:      make(chan struct{}),
+		Competing: true,
+		Wake:      make(chan struct{}, 1),
+		Filter:    filter,
+	})
+}
+
+func (b *Broker) subscribe(client *Client) *Client {
+	accountID := client.AccountID
+	client.connectedAt = time.Now()
 
 	b.mu.Lock()
 	if b.clients[accountID] == nil {
 		b.clients[accountID] = make(map[*Client]bool)
-		go b.subscribeToRedis(accountID)
+		ctx, cancel := context.WithCancel(b.ctx)
+		sub := &subscription{cancel: cancel}
+		b.subs[accountID] = sub
+		go b.subscribeToRedis(ctx, accountID, sub)
 	}
 	b.clients[accountID][client] = true
 	clientCount := len(b.clients[accountID])
@@ -63,6 +291,8 @@ func (b *Broker) Subscribe(accountID string) *Client {
 	log.Info().
 		Str("accountId", accountID).
 		Int("clientCount", clientCount).
+		Bool("competing", client.Competing).
+		Bool("filtered", client.Filter != nil).
 		Msg("sse client subscribed")
 
 	return client
@@ -73,12 +303,10 @@ func (b *Broker) Unsubscribe(client *Client) {
 	defer b.mu.Unlock()
 
 	if clients, ok := b.clients[client.AccountID]; ok {
-		delete(clients, client)
-		close(client.Done)
-
-		if len(clients) == 0 {
-			delete(b.clients, client.AccountID)
+		if !clients[client] {
+			return // already closed by CloseStreams
 		}
+		b.removeLocked(client)
 
 		log.Info().
 			Str("accountId", client.AccountID).
@@ -87,6 +315,24 @@ func (b *Broker) Unsubscribe(client *Client) {
 	}
 }
 
+// removeLocked drops a subscribed client and closes its Done channel. The
+// account's pubsub listener stops with its last client. The caller holds
+// b.mu.
+func (b *Broker) removeLocked(client *Client) {
+	clients := b.clients[client.AccountID]
+	delete(clients, client)
+	close(client.Done)
+	b.delivered.Add(client.delivered.Load())
+
+	if len(clients) == 0 {
+		delete(b.clients, client.AccountID)
+		if sub := b.subs[client.AccountID]; sub != nil {
+			sub.cancel()
+			delete(b.subs, client.AccountID)
+		}
+	}
+}
+
 func (b *Broker) Publish(ctx context.Context, accountID string, event Event) error {
 	data, err := json.Marshal(event)
 	if err != nil {
@@ -97,21 +343,45 @@ func (b *Broker) Publish(ctx context.Context, accountID string, event Event) err
 	return b.redis.Publish(ctx, channel, data).Err()
 }
 
-func (b *Broker) subscribeToRedis(accountID string) {
+// CloseStreams ends every live stream subscribed to accountID on all
+// replicas. Events published before it are still delivered.
+func (b *Broker) CloseStreams(ctx context.Context, accountID string) error {
+	return b.Publish(ctx, accountID, Event{Type: closeEventType})
+}
+
+// Wake tells every competing client of accountID on all replicas to claim
+// messages again, for example after a consumer left and its leases were
+// released.
+func (b *Broker) Wake(ctx context.Context, accountID string) error {
+	return b.Publish(ctx, accountID, Event{Type: wakeEventType})
+}
+
+func (b *Broker) listenRedis(ctx context.Context, channel string) (<-chan *goredis.Message, func() error) {
+	pubsub := b.redis.Subscribe(ctx, channel)
+	return pubsub.Channel(), pubsub.Close
+}
+
+// subscribeToRedis relays the account's pubsub events to its clients until
+// ctx is cancelled, when its last client leaves. Events reach clients only
+// while sub is the account's current listener, so a listener that has not
+// exited yet cannot deliver them a second time after a reconnect.
+func (b *Broker) subscribeToRedis(ctx context.Context, accountID string, sub *subscription) {
 	channel := redisclient.MessageChannel(accountID)
-	pubsub := b.redis.Subscribe(b.ctx, channel)
-	defer pubsub.Close()
+	ch, closePubSub := b.listen(ctx, channel)
+	defer closePubSub()
 
 	log.Debug().
 		Str("accountId", accountID).
 		Str("channel", channel).
 		Msg("redis pubsub subscribed")
 
-	ch := pubsub.Channel()
-
 	for {
 		select {
-		case <-b.ctx.Done():
+		case <-ctx.Done():
+			log.Debug().
+				Str("accountId", accountID).
+				Str("channel", channel).
+				Msg("redis pubsub unsubscribed")
 			return
 
 		case msg, ok := <-ch:
@@ -125,24 +395,89 @@ func (b *Broker) subscribeToRedis(accountID string) {
 				continue
 			}
 
-			b.broadcast(accountID, event)
+			if event.Type == closeEventType {
+				b.closeClients(accountID, sub)
+				continue
+			}
+			b.broadcast(accountID, sub, event)
 		}
 	}
 }
 
-func (b *Broker) broadcast(accountID string, event Event) {
+// broadcast hands event to the account's clients if sub is still its
+// listener. A nil sub skips the check.
+func (b *Broker) broadcast(accountID string, sub *subscription, event Event) {
 	b.mu.RLock()
-	clients := b.clients[accountID]
+	if sub != nil && b.subs[accountID] != sub {
+		b.mu.RUnlock()
+		return
+	}
+	clients := make([]*Client, 0, len(b.clients[accountID]))
+	for client := range b.clients[accountID] {
+		clients = append(clients, client)
+	}
 	b.mu.RUnlock()
 
-	for client := range clients {
-		select {
-		case client.Events <- event:
-		default:
-			log.Warn().
-				Str("accountId", accountID).
-				Msg("client event buffer full, dropping event")
+	for _, client := range clients {
+		if client.Competing && (event.ID != "" || event.Type == wakeEventType) {
+			select {
+			case client.Wake <- struct{}{}:
+			default: // a wake-up is already pending
+			}
+			continue
+		}
+		if event.Type == wakeEventType || !client.Filter.Match(event) {
+			continue
 		}
+		if !client.offer(event) {
+			b.overflow(client, event)
+		}
+	}
+}
+
+// overflow applies the overflow policy to a client whose buffer is full.
+func (b *Broker) overflow(client *Client, event Event) {
+	client.dropped.Add(1)
+	b.dropped.Add(1)
+
+	if b.policy == OverflowDrop {
+		log.Warn().
+			Str("accountId", client.AccountID).
+			Str("eventType", event.Type).
+			Msg("client event buffer full, dropping event")
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.clients[client.AccountID][client] {
+		return // already closed
+	}
+	client.overflowed.Store(true)
+	b.removeLocked(client)
+	b.overflowDisconnects.Add(1)
+
+	log.Warn().
+		Str("accountId", client.AccountID).
+		Str("eventType", event.Type).
+		Int("bufferSize", cap(client.Events)).
+		Msg("client event buffer full, disconnecting slow client")
+}
+
+func (b *Broker) closeClients(accountID string, sub *subscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs[accountID] != sub {
+		return
+	}
+
+	clients := b.clients[accountID]
+	count := len(clients)
+	for client := range clients {
+		b.removeLocked(client)
+	}
+	if count > 0 {
+		log.Info().Str("accountId", accountID).Int("clientCount", count).Msg("sse streams closed")
 	}
 }
 
@@ -158,6 +493,7 @@ func (b *Broker) Close() {
 		}
 	}
 	b.clients = make(map[string]map[*Client]bool)
+	b.subs = make(map[string]*subscription)
 }
 
 func (b *Broker) ClientCount(accountID string) int {
@@ -176,3 +512,44 @@ func (b *Broker) TotalClients() int {
 	}
 	return total
 }
+
+// BufferSize is the event buffer of each client.
+func (b *Broker) BufferSize() int {
+	return b.bufferSize
+}
+
+// Stats returns the broker's slow-client counters.
+func (b *Broker) Stats() Stats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	stats := Stats{
+		Delivered:           b.delivered.Load(),
+		Dropped:             b.dropped.Load(),
+		OverflowDisconnects: b.overflowDisconnects.Load(),
+	}
+	for _, clients := range b.clients {
+		for client := range clients {
+			stats.Clients++
+			stats.Delivered += client.delivered.Load()
+			stats.MaxLag = max(stats.MaxLag, client.currentLag())
+		}
+	}
+	return stats
+}
+
+// ClientStats returns the stats of accountID's connected clients, oldest
+// first.
+func (b *Broker) ClientStats(accountID string) []ClientStats {
+	b.mu.RLock()
+	stats := make([]ClientStats, 0, len(b.clients[accountID]))
+	for client := range b.clients[accountID] {
+		stats = append(stats, client.Stats())
+	}
+	b.mu.RUnlock()
+
+	slices.SortFunc(stats, func(a, b ClientStats) int {
+		return a.ConnectedAt.Compare(b.ConnectedAt)
+	})
+	return stats
+}
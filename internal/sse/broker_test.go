@@ -0,0 +1,266 @@
+package sse
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// attach subscribes a client without the Redis subscription, so broadcast
+// can be driven directly.
+func attach(b *Broker, client *Client) *Client {
+	client.connectedAt = time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.clients[client.AccountID] == nil {
+		b.clients[client.AccountID] = make(map[*Client]bool)
+	}
+	b.clients[client.AccountID][client] = true
+	return client
+}
+
+func newTestClient(b *Broker, accountID string) *Client {
+	return attach(b, &Client{
+		AccountID: accountID,
+		Events:    make(chan Event, b.bufferSize),
+		Done:      make(chan struct{}),
+	})
+}
+
+func testEvent(id string) Event {
+	return Event{ID: id, Type: "message", Data: json.RawMessage(`{}`)}
+}
+
+func TestNewBroker_Defaults(t *testing.T) {
+	b := NewBroker(nil, Config{})
+
+	assert.Equal(t, DefaultBufferSize, b.BufferSize())
+	assert.Equal(t, OverflowDisconnect, b.policy)
+}
+
+func TestBroker_SlowReaderDisconnected(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 2})
+	slow := newTestClient(b, "acc-1")
+	fast := newTestClient(b, "acc-1")
+
+	for _, id := range []string{"m1", "m2", "m3"} {
+		b.broadcast("acc-1", nil, testEvent(id))
+		// The fast reader keeps up.
+		<-fast.Events
+		fast.Sent()
+	}
+
+	select {
+	case <-slow.Done:
+	default:
+		t.Fatal("slow client was not closed")
+	}
+	assert.True(t, slow.Overflowed())
+	assert.Equal(t, int64(1), slow.Dropped())
+	assert.Len(t, slow.Events, 2, "buffered events are left for the handler")
+
+	assert.False(t, fast.Overflowed())
+	assert.Equal(t, 1, b.ClientCount("acc-1"))
+
+	stats := b.Stats()
+	assert.Equal(t, 1, stats.Clients)
+	assert.Equal(t, int64(1), stats.Dropped)
+	assert.Equal(t, int64(1), stats.OverflowDisconnects)
+	assert.Equal(t, int64(3), stats.Delivered)
+
+	// The handler's deferred Unsubscribe is a no-op after an overflow.
+	b.Unsubscribe(slow)
+	assert.Equal(t, 1, b.ClientCount("acc-1"))
+}
+
+func TestBroker_SlowReaderDropPolicy(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 1, OverflowPolicy: OverflowDrop})
+	slow := newTestClient(b, "acc-1")
+
+	b.broadcast("acc-1", nil, testEvent("m1"))
+	b.broadcast("acc-1", nil, testEvent("m2"))
+	b.broadcast("acc-1", nil, testEvent("m3"))
+
+	assert.False(t, slow.Overflowed())
+	assert.Equal(t, 1, b.ClientCount("acc-1"))
+	assert.Equal(t, int64(2), slow.Dropped())
+	assert.Equal(t, "m1", (<-slow.Events).ID)
+
+	stats := b.Stats()
+	assert.Equal(t, int64(2), stats.Dropped)
+	assert.Zero(t, stats.OverflowDisconnects)
+}
+
+func TestBroker_CompetingWakeDoesNotOverflow(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 1})
+	client := attach(b, &Client{
+		AccountID: "acc-1",
+		Events:    make(chan Event, 1),
+		Done:      make(chan struct{}),
+		Competing: true,
+		Wake:      make(chan struct{}, 1),
+	})
+
+	// Messages only wake competing clients; a pending wake-up absorbs
+	// the rest.
+	for _, id := range []string{"m1", "m2", "m3"} {
+		b.broadcast("acc-1", nil, testEvent(id))
+	}
+
+	assert.False(t, client.Overflowed())
+	assert.Len(t, client.Wake, 1)
+	assert.Empty(t, client.Events)
+}
+
+func TestClient_LagTracking(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 4})
+	client := newTestClient(b, "acc-1")
+
+	b.broadcast("acc-1", nil, testEvent("m1"))
+	b.broadcast("acc-1", nil, testEvent("m2"))
+	time.Sleep(20 * time.Millisecond)
+
+	stats := client.Stats()
+	assert.Equal(t, 2, stats.Pending)
+	assert.GreaterOrEqual(t, stats.Lag, 20*time.Millisecond, "lag includes the oldest waiting event")
+	assert.GreaterOrEqual(t, b.Stats().MaxLag, 20*time.Millisecond)
+
+	<-client.Events
+	client.Sent()
+	<-client.Events
+	client.Sent()
+
+	stats = client.Stats()
+	assert.Zero(t, stats.Pending)
+	assert.Equal(t, int64(2), stats.Delivered)
+	assert.GreaterOrEqual(t, stats.Lag, 20*time.Millisecond)
+	assert.GreaterOrEqual(t, stats.MaxLag, stats.Lag)
+
+	all := b.ClientStats("acc-1")
+	require.Len(t, all, 1)
+	assert.Equal(t, int64(2), all[0].Delivered)
+}
+
+func TestOverflowPolicy_Valid(t *testing.T) {
+	assert.True(t, OverflowDisconnect.Valid())
+	assert.True(t, OverflowDrop.Valid())
+	assert.False(t, OverflowPolicy("block").Valid())
+}
+
+func TestBroker_FilterBeforeBuffering(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 1})
+	client := attach(b, &Client{
+		AccountID: "acc-1",
+		Events:    make(chan Event, 1),
+		Done:      make(chan struct{}),
+		Filter:    NewFilter([]string{"thread_started"}, nil, nil, nil, nil),
+	})
+
+	// Filtered events neither take buffer space nor count as dropped.
+	b.broadcast("acc-1", nil, testEvent("m1"))
+	b.broadcast("acc-1", nil, testEvent("m2"))
+	b.broadcast("acc-1", nil, Event{Type: "thread_started", Data: json.RawMessage(`{}`)})
+
+	assert.False(t, client.Overflowed())
+	assert.Zero(t, client.Dropped())
+	require.Len(t, client.Events, 1)
+	assert.Equal(t, "thread_started", (<-client.Events).Type)
+}
+
+// fakePubSub stands in for Redis pubsub, fanning each payload out to every
+// open listener of a channel.
+type fakePubSub struct {
+	mu        sync.Mutex
+	listeners map[chan *goredis.Message]string
+}
+
+func newFakePubSub(b *Broker) *fakePubSub {
+	ps := &fakePubSub{listeners: make(map[chan *goredis.Message]string)}
+	b.listen = ps.listen
+	return ps
+}
+
+func (ps *fakePubSub) listen(ctx context.Context, channel string) (<-chan *goredis.Message, func() error) {
+	ch := make(chan *goredis.Message, 16)
+	ps.mu.Lock()
+	ps.listeners[ch] = channel
+	ps.mu.Unlock()
+	return ch, func() error {
+		ps.mu.Lock()
+		delete(ps.listeners, ch)
+		ps.mu.Unlock()
+		return nil
+	}
+}
+
+func (ps *fakePubSub) open() int {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	return len(ps.listeners)
+}
+
+func (ps *fakePubSub) publish(t *testing.T, accountID string, event Event) {
+	t.Helper()
+	data, err := json.Marshal(event)
+	require.NoError(t, err)
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	for ch, channel := range ps.listeners {
+		if channel == "messages:"+accountID {
+			ch <- &goredis.Message{Channel: channel, Payload: string(data)}
+		}
+	}
+}
+
+func TestBroker_ReconnectAfterOverflowDeliversOnce(t *testing.T) {
+	b := NewBroker(nil, Config{BufferSize: 1})
+	defer b.Close()
+	ps := newFakePubSub(b)
+
+	slow := b.Subscribe("acc-1", nil)
+	require.Eventually(t, func() bool { return ps.open() == 1 }, time.Second, time.Millisecond)
+	ps.publish(t, "acc-1", testEvent("m1"))
+	ps.publish(t, "acc-1", testEvent("m2"))
+	<-slow.Done
+	require.True(t, slow.Overflowed())
+
+	// The listener stops with the account's last client.
+	require.Eventually(t, func() bool { return ps.open() == 0 }, time.Second, time.Millisecond)
+
+	client := b.Subscribe("acc-1", nil)
+	defer b.Unsubscribe(client)
+	require.Eventually(t, func() bool { return ps.open() == 1 }, time.Second, time.Millisecond)
+
+	ps.publish(t, "acc-1", testEvent("m3"))
+	select {
+	case event := <-client.Events:
+		assert.Equal(t, "m3", event.ID)
+	case <-time.After(time.Second):
+		t.Fatal("event was not delivered")
+	}
+	select {
+	case event := <-client.Events:
+		t.Fatalf("event %s delivered twice", event.ID)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBroker_CloseStreamsStopsListener(t *testing.T) {
+	b := NewBroker(nil, Config{})
+	defer b.Close()
+	ps := newFakePubSub(b)
+
+	client := b.Subscribe("acc-1", nil)
+	require.Eventually(t, func() bool { return ps.open() == 1 }, time.Second, time.Millisecond)
+
+	ps.publish(t, "acc-1", Event{Type: closeEventType})
+	<-client.Done
+	assert.Eventually(t, func() bool { return ps.open() == 0 }, time.Second, time.Millisecond)
+	assert.Zero(t, b.ClientCount("acc-1"))
+}
@@ -0,0 +1,130 @@
+package sse
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Filter narrows the events a client receives. Each non-empty set must
+// match; an empty set matches everything.
+//
+// Types applies to every event. ChannelIDs and ConversationKeys apply to
+// events about a conversation; events without one, such as
+// account_token_rotated, pass them. Intents and Blocks apply to message
+// and command events only, matching the normalized intent or block by
+// name or ID.
+type Filter struct {
+	Types            map[string]bool
+	ChannelIDs       map[string]bool
+	ConversationKeys map[string]bool
+	Intents          map[string]bool
+	Blocks           map[string]bool
+}
+
+// NewFilter builds a filter from value lists, or returns nil when every
+// list is empty.
+func NewFilter(types, channelIDs, conversationKeys, intents, blocks []string) *Filter {
+	f := &Filter{
+		Types:            toSet(types),
+		ChannelIDs:       toSet(channelIDs),
+		ConversationKeys: toSet(conversationKeys),
+		Intents:          toSet(intents),
+		Blocks:           toSet(blocks),
+	}
+	if f.Types == nil && f.ChannelIDs == nil && f.ConversationKeys == nil && f.Intents == nil && f.Blocks == nil {
+		return nil
+	}
+	return f
+}
+
+func toSet(values []string) map[string]bool {
+	var set map[string]bool
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]bool)
+		}
+		set[v] = true
+	}
+	return set
+}
+
+// filterFields are the parts of an event's data a filter looks at.
+type filterFields struct {
+	ConversationKey string `json:"conversationKey"`
+	Normalized      *struct {
+		ChannelID string     `json:"channelId"`
+		Intent    *filterRef `json:"intent"`
+		Block     *filterRef `json:"block"`
+	} `json:"normalized"`
+}
+
+type filterRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func (r *filterRef) in(set map[string]bool) bool {
+	return r != nil && (set[r.Name] || (r.ID != "" && set[r.ID]))
+}
+
+// Match reports whether the client should receive event. A nil filter
+// matches everything.
+func (f *Filter) Match(event Event) bool {
+	if f == nil {
+		return true
+	}
+	if f.Types != nil && !f.Types[event.Type] {
+		return false
+	}
+	if f.ChannelIDs == nil && f.ConversationKeys == nil && f.Intents == nil && f.Blocks == nil {
+		return true
+	}
+
+	var fields filterFields
+	if len(event.Data) > 0 {
+		// Undecodable data is treated as carrying none of the fields.
+		_ = json.Unmarshal(event.Data, &fields)
+	}
+
+	if fields.ConversationKey != "" {
+		if f.ConversationKeys != nil && !f.ConversationKeys[fields.ConversationKey] {
+			return false
+		}
+		if f.ChannelIDs != nil {
+			channelID, _, _ := strings.Cut(fields.ConversationKey, ":")
+			if fields.Normalized != nil && fields.Normalized.ChannelID != "" {
+				channelID = fields.Normalized.ChannelID
+			}
+			if !f.ChannelIDs[channelID] {
+				return false
+			}
+		}
+	}
+
+	if event.Type == "message" || event.Type == "command" {
+		var intent, block *filterRef
+		if fields.Normalized != nil {
+			intent, block = fields.Normalized.Intent, fields.Normalized.Block
+		}
+		if f.Intents != nil && !intent.in(f.Intents) {
+			return false
+		}
+		if f.Blocks != nil && !block.in(f.Blocks) {
+			return false
+		}
+	}
+	return true
+}
+
+// FiltersMessages reports whether the filter can exclude message or
+// command events, which competing delivery cannot honor.
+func (f *Filter) FiltersMessages() bool {
+	if f == nil {
+		return false
+	}
+	return f.ChannelIDs != nil || f.ConversationKeys != nil || f.Intents != nil || f.Blocks != nil ||
+		(f.Types != nil && (!f.Types["message"] || !f.Types["command"]))
+}
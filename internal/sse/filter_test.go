@@ -0,0 +1,96 @@
+package sse
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func messageEvent(eventType, conversationKey, normalized string) Event {
+	data, _ := json.Marshal(map[string]any{
+		"id":              "m1",
+		"conversationKey": conversationKey,
+		"normalized":      json.RawMessage(normalized),
+	})
+	return Event{ID: "m1", Type: eventType, Data: data}
+}
+
+func TestNewFilter_EmptyIsNil(t *testing.T) {
+	assert.Nil(t, NewFilter(nil, nil, []string{""}, nil, nil))
+
+	var f *Filter
+	assert.True(t, f.Match(Event{Type: "message"}))
+	assert.False(t, f.FiltersMessages())
+}
+
+func TestFilter_Match(t *testing.T) {
+	booking := messageEvent("message", "botA:user1",
+		`{"channelId":"botA","intent":{"id":"i-1","name":"예약"},"block":{"id":"b-1","name":"예약 블록"}}`)
+	smalltalk := messageEvent("message", "botB:user2",
+		`{"channelId":"botB","intent":{"id":"i-2","name":"잡담"}}`)
+	unpaired := Event{Type: "conversation_unpaired", Data: json.RawMessage(`{"conversationKey":"botB:user2"}`)}
+	rotated := Event{Type: "account_token_rotated", Data: json.RawMessage(`{"accountId":"acc-1"}`)}
+
+	tests := []struct {
+		name   string
+		filter *Filter
+		want   []bool // booking, smalltalk, unpaired, rotated
+	}{
+		{
+			name:   "event types",
+			filter: NewFilter([]string{"message"}, nil, nil, nil, nil),
+			want:   []bool{true, true, false, false},
+		},
+		{
+			name:   "channel IDs pass events without a conversation",
+			filter: NewFilter(nil, []string{"botA"}, nil, nil, nil),
+			want:   []bool{true, false, false, true},
+		},
+		{
+			name:   "conversation keys",
+			filter: NewFilter(nil, nil, []string{"botB:user2"}, nil, nil),
+			want:   []bool{false, true, true, true},
+		},
+		{
+			name:   "intent by name or ID applies to messages only",
+			filter: NewFilter(nil, nil, nil, []string{"예약", "i-2"}, nil),
+			want:   []bool{true, true, true, true},
+		},
+		{
+			name:   "block",
+			filter: NewFilter(nil, nil, nil, nil, []string{"b-1"}),
+			want:   []bool{true, false, true, true},
+		},
+		{
+			name:   "every set must match",
+			filter: NewFilter([]string{"message", "conversation_unpaired"}, []string{"botB"}, nil, nil, nil),
+			want:   []bool{false, true, true, false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []bool{
+				tt.filter.Match(booking),
+				tt.filter.Match(smalltalk),
+				tt.filter.Match(unpaired),
+				tt.filter.Match(rotated),
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestFilter_MessageWithoutIntent(t *testing.T) {
+	f := NewFilter(nil, nil, nil, []string{"예약"}, nil)
+
+	assert.False(t, f.Match(messageEvent("message", "botA:user1", `{"channelId":"botA"}`)))
+	assert.False(t, f.Match(messageEvent("command", "botA:user1", `null`)))
+}
+
+func TestFilter_FiltersMessages(t *testing.T) {
+	assert.False(t, NewFilter([]string{"message", "command", "thread_started"}, nil, nil, nil, nil).FiltersMessages())
+	assert.True(t, NewFilter([]string{"message"}, nil, nil, nil, nil).FiltersMessages())
+	assert.True(t, NewFilter(nil, []string{"botA"}, nil, nil, nil).FiltersMessages())
+}
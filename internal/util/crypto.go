@@ -6,6 +6,8 @@ import (
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
+	"fmt"
+	"time"
 )
 
 const tokenBytes = 32
@@ -33,6 +35,19 @@ func ConstantTimeEqual(a, b string) bool {
 	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
 }
 
+// SignExpiring returns a signature that binds resource to an expiry time,
+// used for download URLs that must stop working after expiresAt.
+func SignExpiring(secret, resource string, expiresAt int64) string {
+	return HmacSHA256(secret, fmt.Sprintf("%s:%d", resource, expiresAt))
+}
+
+func VerifyExpiring(secret, resource string, expiresAt int64, signature string, now time.Time) bool {
+	if now.Unix() > expiresAt {
+		return false
+	}
+	return ConstantTimeEqual(SignExpiring(secret, resource, expiresAt), signature)
+}
+
 func MaskCode(code string) string {
 	if len(code) <= 4 {
 		return "****"
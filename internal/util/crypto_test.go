@@ -2,6 +2,7 @@ package util
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -95,3 +96,25 @@ func TestConstantTimeEqual(t *testing.T) {
 		assert.True(t, ConstantTimeEqual("", ""))
 	})
 }
+
+func TestSignExpiring(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	expires := now.Add(time.Hour).Unix()
+	sig := SignExpiring("secret", "att-1", expires)
+
+	t.Run("accepts valid signature before expiry", func(t *testing.T) {
+		assert.True(t, VerifyExpiring("secret", "att-1", expires, sig, now))
+	})
+
+	t.Run("rejects after expiry", func(t *testing.T) {
+		assert.False(t, VerifyExpiring("secret", "att-1", expires, sig, now.Add(2*time.Hour)))
+	})
+
+	t.Run("rejects other resource", func(t *testing.T) {
+		assert.False(t, VerifyExpiring("secret", "att-2", expires, sig, now))
+	})
+
+	t.Run("rejects tampered expiry", func(t *testing.T) {
+		assert.False(t, VerifyExpiring("secret", "att-1", expires+3600, sig, now))
+	})
+}
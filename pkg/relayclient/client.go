@@ -0,0 +1,166 @@
+// Package relayclient is a Go client for the kakao-relay OpenClaw API.
+//
+// It wraps session pairing (/v1/sessions), the SSE event stream
+// (/v1/events) with automatic reconnect, and the reply endpoints under
+// /openclaw. A typical integration:
+//
+//	c := relayclient.New("https://relay.example.com", relayclient.WithToken(token))
+//	stream := c.Events(ctx, nil)
+//	defer stream.Close()
+//	for {
+//		ev, err := stream.Next(ctx)
+//		if err != nil {
+//			return err
+//		}
+//		if msg, ok := ev.(*relayclient.MessageEvent); ok {
+//			c.Reply(ctx, msg.ID, relayclient.NewTextResponse("echo: "+msg.Text()))
+//		}
+//	}
+//
+// Package relaytest provides an in-process fake relay for unit tests.
+package relayclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultTimeout = 30 * time.Second
+
+// Client talks to a relay instance. It is safe for concurrent use.
+type Client struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+	// streamClient has no timeout; SSE connections are long-lived.
+	streamClient *http.Client
+}
+
+type Option func(*Client)
+
+// WithToken sets the bearer token: the session token, before and after
+// pairing.
+func WithToken(token string) Option {
+	return func(c *Client) { c.token = token }
+}
+
+// WithHTTPClient replaces the client used for both API calls and the event
+// stream. Its Timeout applies to API calls only.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+		stream := *hc
+		stream.Timeout = 0
+		c.streamClient = &stream
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		httpClient:   &http.Client{Timeout: defaultTimeout},
+		streamClient: &http.Client{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithToken returns a copy of the client that authenticates with token.
+func (c *Client) WithToken(token string) *Client {
+	clone := *c
+	clone.token = token
+	return &clone
+}
+
+// Error is a non-2xx response from the relay.
+type Error struct {
+	StatusCode int
+	// Code is the relay error code (e.g. "CALLBACK_EXPIRED"), empty for
+	// endpoints that only return a message.
+	Code    string
+	Message string
+	Details json.RawMessage
+}
+
+func (e *Error) Error() string {
+	if e.Code != "" {
+		return fmt.Sprintf("relay: %d %s: %s", e.StatusCode, e.Code, e.Message)
+	}
+	return fmt.Sprintf("relay: %d: %s", e.StatusCode, e.Message)
+}
+
+// IsCode reports whether err is a relay Error with the given code.
+func IsCode(err error, code string) bool {
+	var relayErr *Error
+	if !errors.As(err, &relayErr) {
+		return false
+	}
+	return relayErr.Code == code
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, body, out any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("marshal request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	return c.do(ctx, method, path, "application/json", reader, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("relay: %s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("relay: read response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return parseError(resp.StatusCode, respBody)
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("relay: decode response: %w", err)
+		}
+	}
+	return nil
+}
+
+func parseError(status int, body []byte) *Error {
+	var payload struct {
+		Error   string          `json:"error"`
+		Code    string          `json:"code"`
+		Details json.RawMessage `json:"details"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil || payload.Error == "" {
+		return &Error{StatusCode: status, Message: strings.TrimSpace(string(body))}
+	}
+	return &Error{StatusCode: status, Code: payload.Code, Message: payload.Error, Details: payload.Details}
+}
@@ -0,0 +1,57 @@
+package relayclient
+
+import (
+	"context"
+	"time"
+)
+
+// DeliverySettings control how the relay hands messages to the account.
+type DeliverySettings struct {
+	// OrderedDelivery holds a conversation's next message until the
+	// previous one is replied to or acked, so each conversation has at
+	// most one message in flight.
+	OrderedDelivery bool `json:"orderedDelivery"`
+	// HoldTimeoutSeconds is how long a conversation waits for a reply or
+	// ack before its next message is delivered anyway. Messages whose
+	// callback is about to expire are delivered regardless.
+	HoldTimeoutSeconds int `json:"holdTimeoutSeconds"`
+	// CoalesceWindowMs merges utterances a user sends in quick succession
+	// into one message event: each is kept back until none follows for
+	// this long. Zero turns coalescing off.
+	CoalesceWindowMs int `json:"coalesceWindowMs"`
+}
+
+func (c *Client) DeliverySettings(ctx context.Context) (*DeliverySettings, error) {
+	var settings DeliverySettings
+	if err := c.doJSON(ctx, "GET", "/v1/delivery-settings", nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SetOrderedDelivery turns ordered delivery on or off. Messages already
+// held when it is turned off are delivered as their hold timeout passes.
+func (c *Client) SetOrderedDelivery(ctx context.Context, ordered bool) (*DeliverySettings, error) {
+	var settings DeliverySettings
+	body := map[string]bool{"orderedDelivery": ordered}
+	if err := c.doJSON(ctx, "PUT", "/v1/delivery-settings", body, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// MaxCoalesceWindow is the longest coalescing window the relay accepts.
+const MaxCoalesceWindow = 10 * time.Second
+
+// SetCoalesceWindow sets how long the relay waits for a further utterance
+// before delivering the ones waiting as one merged MessageEvent (see
+// MessageEvent.MessageIDs). Zero turns coalescing off. It is rounded to
+// milliseconds and must not exceed MaxCoalesceWindow.
+func (c *Client) SetCoalesceWindow(ctx context.Context, window time.Duration) (*DeliverySettings, error) {
+	var settings DeliverySettings
+	body := map[string]int64{"coalesceWindowMs": window.Milliseconds()}
+	if err := c.doJSON(ctx, "PUT", "/v1/delivery-settings", body, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
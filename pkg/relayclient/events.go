@@ -0,0 +1,333 @@
+package relayclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// SSE event types sent on /v1/events.
+const (
+	EventConnected       = "connected"
+	EventMessage         = "message"
+	EventCommand         = "command"
+	EventPairingComplete = "pairing_complete"
+	EventPairingExpired  = "pairing_expired"
+	EventPairingRequest  = "pairing_request"
+
+	EventConversationUnpaired = "conversation_unpaired"
+	EventConversationBlocked  = "conversation_blocked"
+	EventConversationDeleted  = "conversation_deleted"
+	EventSessionDisconnected  = "session_disconnected"
+	EventAccountTokenRotated  = "account_token_rotated"
+	EventThreadStarted        = "thread_started"
+	EventOverflow             = "overflow"
+)
+
+// Reasons carried by lifecycle events.
+const (
+	ReasonUser    = "user"
+	ReasonAdmin   = "admin"
+	ReasonExpired = "expired"
+	// ReasonAccount marks changes the integration made through /v1/me.
+	ReasonAccount = "account"
+)
+
+// Event is one event from the relay stream. Use a type switch on
+// *ConnectedEvent, *MessageEvent, *CommandEvent, *PairingCompleteEvent,
+// *PairingExpiredEvent, *PairingRequestEvent, the lifecycle events
+// (*ConversationUnpairedEvent, *ConversationBlockedEvent,
+// *ConversationDeletedEvent, *SessionDisconnectedEvent,
+// *AccountTokenRotatedEvent), *ThreadStartedEvent, *OverflowEvent and
+// *UnknownEvent.
+type Event interface {
+	EventType() string
+}
+
+type ConnectedEvent struct {
+	AccountID string `json:"accountId"`
+	SessionID string `json:"sessionId"`
+	// Status is the session status, or "paired" for relay tokens.
+	Status string `json:"status"`
+	// Delivery is the stream's delivery mode; ConsumerID identifies a
+	// competing stream.
+	Delivery   string `json:"delivery,omitempty"`
+	ConsumerID string `json:"consumerId,omitempty"`
+}
+
+// MessageEvent is an inbound KakaoTalk message awaiting a reply.
+type MessageEvent struct {
+	ID              string             `json:"id"`
+	ConversationKey string             `json:"conversationKey"`
+	KakaoPayload    json.RawMessage    `json:"kakaoPayload"`
+	Normalized      *NormalizedMessage `json:"normalized"`
+	CreatedAt       time.Time          `json:"createdAt"`
+	Attachments     []Attachment       `json:"attachments"`
+	// ThreadID is the conversation thread the message belongs to. It is
+	// empty for messages stored before threads existed.
+	ThreadID string `json:"threadId"`
+	// State holds the conversation's state values by key when the stream
+	// was opened with StreamOptions.IncludeState.
+	State map[string]json.RawMessage `json:"state,omitempty"`
+	// QueuePosition is how many earlier messages of the conversation were
+	// still waiting when this one arrived, with ordered delivery on (see
+	// Client.SetOrderedDelivery). It is zero otherwise.
+	QueuePosition int `json:"queuePosition,omitempty"`
+	// MessageIDs lists the utterances merged into this event, oldest
+	// first, when coalescing is on (see Client.SetCoalesceWindow). ID is
+	// one of them; replying to or acking any of them settles the event.
+	// It is empty for events that were not merged.
+	MessageIDs []string `json:"messageIds,omitempty"`
+}
+
+// Text returns the normalized utterance, or "" for messages stored before
+// normalization existed.
+func (m *MessageEvent) Text() string {
+	if m.Normalized == nil {
+		return ""
+	}
+	return m.Normalized.Text
+}
+
+// CommandEvent is an invocation of one of the account's custom commands
+// (see Client.SetCommands). Reply to it like a MessageEvent.
+type CommandEvent struct {
+	MessageEvent
+	Command CommandInvocation `json:"command"`
+}
+
+type CommandInvocation struct {
+	// Name is the canonical command name, even when invoked via an alias.
+	Name    string   `json:"name"`
+	Alias   string   `json:"alias,omitempty"`
+	Args    []string `json:"args"`
+	RawArgs string   `json:"rawArgs"`
+}
+
+type PairingCompleteEvent struct {
+	KakaoUserID string    `json:"kakaoUserId"`
+	AccountID   string    `json:"accountId"`
+	PairedAt    time.Time `json:"pairedAt"`
+	// PairingCodeID is set when the user joined through an account
+	// PairingCode rather than a session code.
+	PairingCodeID string `json:"pairingCodeId,omitempty"`
+}
+
+// PairingRequestEvent asks the account to approve a Kakao user joining
+// through one of its pairing codes (see Client.SetPairingApproval). Answer
+// with ApprovePairingRequest or RejectPairingRequest before ExpiresAt.
+type PairingRequestEvent struct {
+	RequestID       string         `json:"requestId"`
+	ConversationKey string         `json:"conversationKey"`
+	KakaoUserID     string         `json:"kakaoUserId"`
+	PairingCodeID   string         `json:"pairingCodeId,omitempty"`
+	Profile         PairingProfile `json:"profile"`
+	RequestedAt     time.Time      `json:"requestedAt"`
+	ExpiresAt       time.Time      `json:"expiresAt"`
+}
+
+// PairingProfile is what Kakao reports about a user asking to pair.
+type PairingProfile struct {
+	KakaoUserID string `json:"kakaoUserId"`
+	ChannelID   string `json:"channelId"`
+	BotUserKey  string `json:"botUserKey,omitempty"`
+	AppUserID   string `json:"appUserId,omitempty"`
+	IsFriend    *bool  `json:"isFriend,omitempty"`
+	Lang        string `json:"lang,omitempty"`
+	Timezone    string `json:"timezone,omitempty"`
+}
+
+type PairingExpiredEvent struct {
+	SessionID string `json:"sessionId,omitempty"`
+	Reason    string `json:"reason"`
+}
+
+// ConversationUnpairedEvent means a Kakao user left the account, with
+// /unpair (ReasonUser), from the dashboard (ReasonAdmin) or through
+// UnpairConversation (ReasonAccount). Drop any state kept for the
+// conversation; replies to it will fail.
+type ConversationUnpairedEvent struct {
+	ConversationKey string    `json:"conversationKey"`
+	KakaoUserID     string    `json:"kakaoUserId"`
+	ThreadID        string    `json:"threadId"`
+	Reason          string    `json:"reason"`
+	UnpairedAt      time.Time `json:"unpairedAt"`
+}
+
+// ConversationBlockedEvent means the user was blocked with
+// BlockConversation. Their messages are no longer delivered.
+type ConversationBlockedEvent struct {
+	ConversationKey string    `json:"conversationKey"`
+	KakaoUserID     string    `json:"kakaoUserId"`
+	ThreadID        string    `json:"threadId"`
+	Reason          string    `json:"reason"`
+	BlockedAt       time.Time `json:"blockedAt"`
+}
+
+// ConversationDeletedEvent means an operator deleted the conversation and
+// its history from the relay.
+type ConversationDeletedEvent struct {
+	ConversationKey string    `json:"conversationKey"`
+	KakaoUserID     string    `json:"kakaoUserId"`
+	ThreadID        string    `json:"threadId"`
+	Reason          string    `json:"reason"`
+	DeletedAt       time.Time `json:"deletedAt"`
+}
+
+// ThreadStartedEvent means the user started a new topic with /new (or
+// /reset). Later messages carry ThreadID; drop any LLM context kept for
+// PreviousThreadID. Conversation state is kept.
+type ThreadStartedEvent struct {
+	ConversationKey  string    `json:"conversationKey"`
+	KakaoUserID      string    `json:"kakaoUserId"`
+	ThreadID         string    `json:"threadId"`
+	PreviousThreadID string    `json:"previousThreadId"`
+	Reason           string    `json:"reason"`
+	StartedAt        time.Time `json:"startedAt"`
+}
+
+// SessionDisconnectedEvent means the session was disconnected or deleted.
+// It is sent on both the session and the account streams.
+type SessionDisconnectedEvent struct {
+	SessionID      string    `json:"sessionId"`
+	AccountID      string    `json:"accountId,omitempty"`
+	Reason         string    `json:"reason"`
+	DisconnectedAt time.Time `json:"disconnectedAt"`
+}
+
+// AccountTokenRotatedEvent means the relay token was replaced. The old
+// token no longer authenticates; the new one is not included.
+type AccountTokenRotatedEvent struct {
+	AccountID string    `json:"accountId"`
+	Reason    string    `json:"reason"`
+	RotatedAt time.Time `json:"rotatedAt"`
+}
+
+// OverflowEvent is the last event of a connection the relay closed because
+// the client fell behind reading it. Stream reconnects right away; queued
+// messages missed in the meantime are sent again on connect.
+type OverflowEvent struct {
+	Reason string `json:"reason"`
+	// Dropped is how many events did not fit the relay's buffer.
+	Dropped    int `json:"dropped"`
+	BufferSize int `json:"bufferSize"`
+}
+
+// UnknownEvent carries event types this client version does not know.
+type UnknownEvent struct {
+	Type string
+	Data json.RawMessage
+}
+
+func (*ConnectedEvent) EventType() string       { return EventConnected }
+func (*MessageEvent) EventType() string         { return EventMessage }
+func (*CommandEvent) EventType() string         { return EventCommand }
+func (*PairingCompleteEvent) EventType() string { return EventPairingComplete }
+func (*PairingExpiredEvent) EventType() string  { return EventPairingExpired }
+func (*PairingRequestEvent) EventType() string  { return EventPairingRequest }
+func (e *UnknownEvent) EventType() string       { return e.Type }
+
+func (*ConversationUnpairedEvent) EventType() string { return EventConversationUnpaired }
+func (*ConversationBlockedEvent) EventType() string  { return EventConversationBlocked }
+func (*ConversationDeletedEvent) EventType() string  { return EventConversationDeleted }
+func (*SessionDisconnectedEvent) EventType() string  { return EventSessionDisconnected }
+func (*AccountTokenRotatedEvent) EventType() string  { return EventAccountTokenRotated }
+func (*ThreadStartedEvent) EventType() string        { return EventThreadStarted }
+func (*OverflowEvent) EventType() string             { return EventOverflow }
+
+// NormalizedMessage mirrors the relay's versioned normalized schema.
+type NormalizedMessage struct {
+	Version      int                        `json:"version"`
+	UserID       string                     `json:"userId"`
+	ChannelID    string                     `json:"channelId"`
+	Text         string                     `json:"text"`
+	Intent       *NormalizedRef             `json:"intent,omitempty"`
+	Block        *NormalizedRef             `json:"block,omitempty"`
+	Action       *NormalizedRef             `json:"action,omitempty"`
+	Params       map[string]string          `json:"params,omitempty"`
+	DetailParams map[string]NormalizedParam `json:"detailParams,omitempty"`
+	ClientExtra  map[string]any             `json:"clientExtra,omitempty"`
+	Attachments  []NormalizedAttachment     `json:"attachments,omitempty"`
+	Command      *CommandInvocation         `json:"command,omitempty"`
+	User         NormalizedUser             `json:"user"`
+	Timezone     string                     `json:"timezone,omitempty"`
+	Lang         string                     `json:"lang,omitempty"`
+}
+
+type NormalizedRef struct {
+	ID   string `json:"id,omitempty"`
+	Name string `json:"name"`
+}
+
+type NormalizedParam struct {
+	Origin    string `json:"origin,omitempty"`
+	Value     any    `json:"value"`
+	GroupName string `json:"groupName,omitempty"`
+}
+
+type NormalizedAttachment struct {
+	Type      string `json:"type"`
+	URL       string `json:"url"`
+	Source    string `json:"source,omitempty"`
+	ExpiresAt string `json:"expiresAt,omitempty"`
+}
+
+type NormalizedUser struct {
+	ID                string `json:"id"`
+	Type              string `json:"type,omitempty"`
+	PlusfriendUserKey string `json:"plusfriendUserKey,omitempty"`
+	AppUserID         string `json:"appUserId,omitempty"`
+	IsFriend          *bool  `json:"isFriend,omitempty"`
+}
+
+// Attachment is a relay-hosted copy of a Kakao media attachment. URL is a
+// signed download link; it answers 503 while Status is "pending".
+type Attachment struct {
+	ID          string    `json:"id"`
+	Type        string    `json:"type"`
+	Status      string    `json:"status"`
+	ContentType string    `json:"contentType,omitempty"`
+	SizeBytes   int64     `json:"sizeBytes,omitempty"`
+	URL         string    `json:"url"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// ParseEvent decodes the data of an SSE event of the given type.
+func ParseEvent(eventType string, data []byte) (Event, error) {
+	var ev Event
+	switch eventType {
+	case EventConnected:
+		ev = &ConnectedEvent{}
+	case EventMessage:
+		ev = &MessageEvent{}
+	case EventCommand:
+		ev = &CommandEvent{}
+	case EventPairingComplete:
+		ev = &PairingCompleteEvent{}
+	case EventPairingExpired:
+		ev = &PairingExpiredEvent{}
+	case EventPairingRequest:
+		ev = &PairingRequestEvent{}
+	case EventConversationUnpaired:
+		ev = &ConversationUnpairedEvent{}
+	case EventConversationBlocked:
+		ev = &ConversationBlockedEvent{}
+	case EventConversationDeleted:
+		ev = &ConversationDeletedEvent{}
+	case EventSessionDisconnected:
+		ev = &SessionDisconnectedEvent{}
+	case EventAccountTokenRotated:
+		ev = &AccountTokenRotatedEvent{}
+	case EventThreadStarted:
+		ev = &ThreadStartedEvent{}
+	case EventOverflow:
+		ev = &OverflowEvent{}
+	default:
+		return &UnknownEvent{Type: eventType, Data: append(json.RawMessage(nil), data...)}, nil
+	}
+
+	if err := json.Unmarshal(data, ev); err != nil {
+		return nil, fmt.Errorf("relay: decode %s event: %w", eventType, err)
+	}
+	return ev, nil
+}
@@ -0,0 +1,90 @@
+package relayclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// History modes.
+const (
+	HistoryText = "text"
+	HistoryFull = "full"
+)
+
+// Message directions in a conversation history.
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+// HistoryOptions selects a page of conversation history. Zero values use
+// the relay defaults: the newest 50 messages, text only.
+type HistoryOptions struct {
+	// Cursor is the NextCursor of the previous page.
+	Cursor string
+	// Limit is at most 200.
+	Limit int
+	// Mode is HistoryText or HistoryFull.
+	Mode string
+	// ThreadID keeps only messages of one conversation thread.
+	ThreadID string
+}
+
+// HistoryMessage is one turn of a conversation: a Kakao user message
+// (DirectionInbound) or a reply or Event API send (DirectionOutbound).
+type HistoryMessage struct {
+	ID        string             `json:"id"`
+	Direction string             `json:"direction"`
+	Text      string             `json:"text"`
+	Status    string             `json:"status"`
+	Command   *CommandInvocation `json:"command,omitempty"`
+	ThreadID  string             `json:"threadId,omitempty"`
+	// InReplyTo is the inbound message ID a callback reply answered.
+	InReplyTo    string    `json:"inReplyTo,omitempty"`
+	DeliveryType string    `json:"deliveryType,omitempty"`
+	CreatedAt    time.Time `json:"createdAt"`
+
+	// Set in HistoryFull mode.
+	KakaoPayload    json.RawMessage    `json:"kakaoPayload,omitempty"`
+	Normalized      *NormalizedMessage `json:"normalized,omitempty"`
+	ResponsePayload json.RawMessage    `json:"responsePayload,omitempty"`
+}
+
+// HistoryPage lists messages oldest first. Pass NextCursor to fetch the
+// page before it; it is empty once the start of the conversation is
+// reached.
+type HistoryPage struct {
+	Messages   []HistoryMessage `json:"messages"`
+	NextCursor string           `json:"nextCursor,omitempty"`
+}
+
+// History returns a page of the account's messages in a conversation,
+// walking back from the newest.
+func (c *Client) History(ctx context.Context, conversationKey string, opts HistoryOptions) (*HistoryPage, error) {
+	query := url.Values{}
+	if opts.Cursor != "" {
+		query.Set("cursor", opts.Cursor)
+	}
+	if opts.Limit != 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Mode != "" {
+		query.Set("mode", opts.Mode)
+	}
+	if opts.ThreadID != "" {
+		query.Set("thread", opts.ThreadID)
+	}
+	path := "/v1/conversations/" + url.PathEscape(conversationKey) + "/messages"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var page HistoryPage
+	if err := c.doJSON(ctx, "GET", path, nil, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
@@ -0,0 +1,132 @@
+package relayclient
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// Conversation states reported by Conversations.
+const (
+	ConversationPaired  = "paired"
+	ConversationPending = "pending"
+	ConversationBlocked = "blocked"
+)
+
+type Account struct {
+	ID                     string    `json:"id"`
+	RateLimitPerMinute     int       `json:"rateLimitPerMinute"`
+	Locale                 string    `json:"locale,omitempty"`
+	RequirePairingApproval bool      `json:"requirePairingApproval"`
+	OrderedDelivery        bool      `json:"orderedDelivery"`
+	CoalesceWindowMs       int       `json:"coalesceWindowMs"`
+	CreatedAt              time.Time `json:"createdAt"`
+}
+
+type Limits struct {
+	RateLimitPerMinute int `json:"rateLimitPerMinute"`
+}
+
+// Usage counts the account's messages. "Today" is the relay's local day.
+type Usage struct {
+	InboundToday   int `json:"inboundToday"`
+	InboundTotal   int `json:"inboundTotal"`
+	OutboundToday  int `json:"outboundToday"`
+	OutboundTotal  int `json:"outboundTotal"`
+	OutboundFailed int `json:"outboundFailed"`
+}
+
+// Me describes the authenticated account.
+type Me struct {
+	Account Account `json:"account"`
+	Limits  Limits  `json:"limits"`
+	Usage   Usage   `json:"usage"`
+	// Conversations counts conversations by state.
+	Conversations map[string]int `json:"conversations"`
+	// SSEClients counts the event streams open on the relay instance that
+	// answered, not across replicas.
+	SSEClients int `json:"sseClients"`
+}
+
+// Conversation is a Kakao user attached to the account.
+type Conversation struct {
+	ID              string     `json:"id"`
+	ConversationKey string     `json:"conversationKey"`
+	KakaoChannelID  string     `json:"kakaoChannelId"`
+	KakaoUserID     string     `json:"plusfriendUserKey"`
+	State           string     `json:"state"`
+	Locale          string     `json:"locale,omitempty"`
+	ThreadID        string     `json:"threadId"`
+	FirstSeenAt     time.Time  `json:"firstSeenAt"`
+	LastSeenAt      time.Time  `json:"lastSeenAt"`
+	PairedAt        *time.Time `json:"pairedAt,omitempty"`
+}
+
+func (c *Client) Me(ctx context.Context) (*Me, error) {
+	var me Me
+	if err := c.doJSON(ctx, "GET", "/v1/me", nil, &me); err != nil {
+		return nil, err
+	}
+	return &me, nil
+}
+
+// Conversations lists the account's conversations, most recently active
+// first. An empty state lists paired, pending and blocked ones.
+func (c *Client) Conversations(ctx context.Context, state string) ([]Conversation, error) {
+	path := "/v1/me/conversations"
+	if state != "" {
+		path += "?state=" + url.QueryEscape(state)
+	}
+	var result struct {
+		Conversations []Conversation `json:"conversations"`
+	}
+	if err := c.doJSON(ctx, "GET", path, nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Conversations, nil
+}
+
+// UnpairConversation detaches a paired user from the account; the stream
+// receives a ConversationUnpairedEvent with ReasonAccount. Conversations
+// that are not paired fail with code CONFLICT.
+func (c *Client) UnpairConversation(ctx context.Context, id string) (*Conversation, error) {
+	return c.changeConversation(ctx, id, "unpair")
+}
+
+// BlockConversation stops a paired user's messages and keeps them from
+// pairing with the account again; the stream receives a
+// ConversationBlockedEvent.
+func (c *Client) BlockConversation(ctx context.Context, id string) (*Conversation, error) {
+	return c.changeConversation(ctx, id, "block")
+}
+
+// UnblockConversation releases a blocked user, who may then pair again.
+func (c *Client) UnblockConversation(ctx context.Context, id string) (*Conversation, error) {
+	return c.changeConversation(ctx, id, "unblock")
+}
+
+func (c *Client) changeConversation(ctx context.Context, id, action string) (*Conversation, error) {
+	var conv Conversation
+	path := "/v1/me/conversations/" + url.PathEscape(id) + "/" + action
+	if err := c.doJSON(ctx, "POST", path, nil, &conv); err != nil {
+		return nil, err
+	}
+	return &conv, nil
+}
+
+// RotateToken reissues the account's credentials and returns the token
+// to continue with. The token the client was using stops working at once;
+// continue with c.WithToken(newToken).
+func (c *Client) RotateToken(ctx context.Context) (string, error) {
+	var result struct {
+		RelayToken   string `json:"relayToken"`
+		SessionToken string `json:"sessionToken"`
+	}
+	if err := c.doJSON(ctx, "POST", "/v1/me/token/rotate", nil, &result); err != nil {
+		return "", err
+	}
+	if result.SessionToken != "" {
+		return result.SessionToken, nil
+	}
+	return result.RelayToken, nil
+}
@@ -0,0 +1,162 @@
+package relayclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"time"
+)
+
+// Relay error codes worth handling explicitly.
+const (
+	CodeCallbackExpired  = "CALLBACK_EXPIRED"
+	CodeCallbackFailed   = "CALLBACK_FAILED"
+	CodeSessionNotPaired = "SESSION_NOT_PAIRED"
+	CodeNotFound         = "NOT_FOUND"
+	CodeNotConfigured    = "NOT_CONFIGURED"
+	// CodeConflict: a state write lost to another version, or an account
+	// action the current state does not allow.
+	CodeConflict = "CONFLICT"
+)
+
+type ReplyResult struct {
+	Success bool `json:"success"`
+	// DeliveredAt is Unix milliseconds.
+	DeliveredAt int64 `json:"deliveredAt"`
+}
+
+// Reply answers an inbound message through its Kakao callback URL. The
+// callback is valid for about a minute after the message arrives; later
+// replies fail with CodeCallbackExpired and should go through Send.
+func (c *Client) Reply(ctx context.Context, messageID string, resp *Response) (*ReplyResult, error) {
+	var result ReplyResult
+	body := map[string]any{"messageId": messageID, "response": resp}
+	if err := c.doJSON(ctx, "POST", "/openclaw/reply", body, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Ack marks messages as handled so a competing stream's lease on them ends
+// and they are not offered again. Replying already acks a message. It
+// returns how many of the messages were still waiting for an ack.
+func (c *Client) Ack(ctx context.Context, messageIDs ...string) (int, error) {
+	var result struct {
+		Acked int `json:"acked"`
+	}
+	body := map[string]any{"messageIds": messageIDs}
+	if err := c.doJSON(ctx, "POST", "/openclaw/ack", body, &result); err != nil {
+		return 0, err
+	}
+	return result.Acked, nil
+}
+
+// SendRequest triggers a Kakao Event API block for a conversation.
+type SendRequest struct {
+	ConversationKey string         `json:"conversationKey"`
+	Event           string         `json:"event"`
+	Params          map[string]any `json:"params,omitempty"`
+}
+
+type SendResult struct {
+	Success    bool   `json:"success"`
+	OutboundID string `json:"outboundId"`
+	TaskID     string `json:"taskId"`
+	Status     string `json:"status"`
+}
+
+// Send pushes a bot-initiated message via the Kakao Event API. It fails with
+// CodeNotConfigured when the relay has no Event API credentials.
+func (c *Client) Send(ctx context.Context, req SendRequest) (*SendResult, error) {
+	var result SendResult
+	if err := c.doJSON(ctx, "POST", "/openclaw/send", req, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+type Media struct {
+	ID string `json:"id"`
+	// Ref is the "media://<id>" reference to use as a SimpleImage URL.
+	Ref         string    `json:"ref"`
+	URL         string    `json:"url"`
+	ContentType string    `json:"contentType"`
+	SizeBytes   int64     `json:"sizeBytes"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+}
+
+// UploadMedia uploads an image for use in replies. contentType may be empty;
+// the relay sniffs the body either way.
+func (c *Client) UploadMedia(ctx context.Context, filename, contentType string, r io.Reader) (*Media, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="file"; filename=%q`, filename))
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	part, err := mw.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, r); err != nil {
+		return nil, fmt.Errorf("relay: read media: %w", err)
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+
+	var media Media
+	if err := c.do(ctx, "POST", "/openclaw/media", mw.FormDataContentType(), &buf, &media); err != nil {
+		return nil, err
+	}
+	return &media, nil
+}
+
+// CommandDefinition declares a custom slash command. Name and aliases may
+// omit the leading slash; built-in commands (/pair, /help, ...) are reserved.
+type CommandDefinition struct {
+	Name        string   `json:"name"`
+	Aliases     []string `json:"aliases,omitempty"`
+	Usage       string   `json:"usage,omitempty"`
+	Description string   `json:"description"`
+}
+
+type Command struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Aliases     []string  `json:"aliases"`
+	Usage       string    `json:"usage,omitempty"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+func (c *Client) Commands(ctx context.Context) ([]Command, error) {
+	var result struct {
+		Commands []Command `json:"commands"`
+	}
+	if err := c.doJSON(ctx, "GET", "/openclaw/commands", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.Commands, nil
+}
+
+// SetCommands replaces the account's custom commands. Users invoking them
+// produce CommandEvents and see them under /help.
+func (c *Client) SetCommands(ctx context.Context, defs []CommandDefinition) ([]Command, error) {
+	if defs == nil {
+		defs = []CommandDefinition{}
+	}
+	var result struct {
+		Commands []Command `json:"commands"`
+	}
+	body := map[string]any{"commands": defs}
+	if err := c.doJSON(ctx, "PUT", "/openclaw/commands", body, &result); err != nil {
+		return nil, err
+	}
+	return result.Commands, nil
+}
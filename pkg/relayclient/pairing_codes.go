@@ -0,0 +1,111 @@
+package relayclient
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// PairingCodeOptions configures an account invite code. Zero values use
+// the relay defaults: one use, valid for 24 hours.
+type PairingCodeOptions struct {
+	MaxUses          int `json:"maxUses,omitempty"`
+	ExpiresInSeconds int `json:"expiresInSeconds,omitempty"`
+}
+
+// PairingCode attaches the Kakao users who send "/pair <Code>" to the
+// account that created it. Each pairing emits a PairingCompleteEvent with
+// PairingCodeID set.
+type PairingCode struct {
+	ID         string     `json:"id"`
+	Code       string     `json:"code"`
+	MaxUses    int        `json:"maxUses"`
+	UseCount   int        `json:"useCount"`
+	ExpiresAt  time.Time  `json:"expiresAt"`
+	LastUsedAt *time.Time `json:"lastUsedAt,omitempty"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+func (c *Client) CreatePairingCode(ctx context.Context, opts PairingCodeOptions) (*PairingCode, error) {
+	var code PairingCode
+	if err := c.doJSON(ctx, "POST", "/v1/pairing-codes", opts, &code); err != nil {
+		return nil, err
+	}
+	return &code, nil
+}
+
+// PairingCodes lists the account's codes that can still be redeemed.
+func (c *Client) PairingCodes(ctx context.Context) ([]PairingCode, error) {
+	var result struct {
+		PairingCodes []PairingCode `json:"pairingCodes"`
+	}
+	if err := c.doJSON(ctx, "GET", "/v1/pairing-codes", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.PairingCodes, nil
+}
+
+// RevokePairingCode stops a code from being redeemed. Users already paired
+// with it stay paired.
+func (c *Client) RevokePairingCode(ctx context.Context, id string) error {
+	return c.doJSON(ctx, "DELETE", "/v1/pairing-codes/"+url.PathEscape(id), nil, nil)
+}
+
+// Pairing request statuses.
+const (
+	PairingRequestPending   = "pending"
+	PairingRequestApproved  = "approved"
+	PairingRequestRejected  = "rejected"
+	PairingRequestExpired   = "expired"
+	PairingRequestCancelled = "cancelled"
+)
+
+type PairingRequest struct {
+	ID              string         `json:"id"`
+	ConversationKey string         `json:"conversationKey"`
+	PairingCodeID   string         `json:"pairingCodeId,omitempty"`
+	Profile         PairingProfile `json:"profile"`
+	Status          string         `json:"status"`
+	ExpiresAt       time.Time      `json:"expiresAt"`
+	DecidedAt       *time.Time     `json:"decidedAt,omitempty"`
+	CreatedAt       time.Time      `json:"createdAt"`
+}
+
+// SetPairingApproval turns approval mode on or off. While on, users who
+// send "/pair" with one of the account's pairing codes wait for a decision
+// and the stream receives a PairingRequestEvent.
+func (c *Client) SetPairingApproval(ctx context.Context, required bool) error {
+	body := map[string]bool{"requireApproval": required}
+	return c.doJSON(ctx, "PUT", "/v1/pairing-settings", body, nil)
+}
+
+// PairingRequests lists requests waiting for a decision, oldest first.
+func (c *Client) PairingRequests(ctx context.Context) ([]PairingRequest, error) {
+	var result struct {
+		PairingRequests []PairingRequest `json:"pairingRequests"`
+	}
+	if err := c.doJSON(ctx, "GET", "/v1/pairing-requests", nil, &result); err != nil {
+		return nil, err
+	}
+	return result.PairingRequests, nil
+}
+
+// ApprovePairingRequest pairs the user; the stream then receives a
+// PairingCompleteEvent. Requests that are no longer pending fail with
+// code CONFLICT.
+func (c *Client) ApprovePairingRequest(ctx context.Context, id string) (*PairingRequest, error) {
+	return c.decidePairingRequest(ctx, id, "approve")
+}
+
+func (c *Client) RejectPairingRequest(ctx context.Context, id string) (*PairingRequest, error) {
+	return c.decidePairingRequest(ctx, id, "reject")
+}
+
+func (c *Client) decidePairingRequest(ctx context.Context, id, action string) (*PairingRequest, error) {
+	var req PairingRequest
+	path := "/v1/pairing-requests/" + url.PathEscape(id) + "/" + action
+	if err := c.doJSON(ctx, "POST", path, nil, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
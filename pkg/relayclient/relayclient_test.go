@@ -0,0 +1,801 @@
+package relayclient_test
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"gitlab.tepseg.com/ai/kakao-relay/pkg/relayclient"
+	"gitlab.tepseg.com/ai/kakao-relay/pkg/relayclient/relaytest"
+)
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func nextEvent[T relayclient.Event](t *testing.T, ctx context.Context, stream *relayclient.Stream) T {
+	t.Helper()
+	for {
+		ev, err := stream.Next(ctx)
+		require.NoError(t, err)
+		if typed, ok := ev.(T); ok {
+			return typed
+		}
+	}
+}
+
+func TestStream_PairingFlow(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	session, err := relayclient.New(srv.URL).CreateSession(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, relayclient.SessionPendingPairing, session.Status)
+
+	c := relayclient.New(srv.URL, relayclient.WithToken(session.SessionToken))
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+
+	connected := nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+	assert.Equal(t, relayclient.SessionPendingPairing, connected.Status)
+
+	require.NoError(t, srv.CompletePairing(session.PairingCode))
+	paired := nextEvent[*relayclient.PairingCompleteEvent](t, ctx, stream)
+	assert.Equal(t, "relaytest-user", paired.KakaoUserID)
+
+	// The stream reconnects onto the account channel by itself.
+	connected = nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+	assert.Equal(t, relayclient.SessionPaired, connected.Status)
+
+	sent := srv.SendMessage("안녕")
+	msg := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, sent.ID, msg.ID)
+	assert.Equal(t, "안녕", msg.Text())
+
+	result, err := c.Reply(ctx, msg.ID, relayclient.NewTextResponse("반가워요").
+		WithQuickReplies(relayclient.MessageQuickReply("도움말", "")))
+	require.NoError(t, err)
+	assert.True(t, result.Success)
+
+	reply, err := srv.WaitReply(ctx, msg.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "반가워요", reply.Response.Template.Outputs[0].SimpleText.Text)
+	assert.Equal(t, "도움말", reply.Response.Template.QuickReplies[0].MessageText)
+}
+
+func TestStream_ReconnectsWithLastEventID(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	var reconnects atomic.Int32
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+	stream := c.Events(ctx, &relayclient.StreamOptions{
+		MinBackoff: 10 * time.Millisecond,
+		MaxBackoff: 20 * time.Millisecond,
+		OnReconnect: func(err error, delay time.Duration) {
+			reconnects.Add(1)
+		},
+	})
+	defer stream.Close()
+
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+	first := srv.SendMessage("first")
+	assert.Equal(t, first.ID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ID)
+
+	srv.DisconnectAll()
+	// Queued while offline, delivered on reconnect.
+	second := srv.SendMessage("second")
+
+	// Depending on timing it arrives as backlog (before "connected") or live.
+	msg := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, second.ID, msg.ID)
+
+	assert.Equal(t, int32(1), reconnects.Load())
+	assert.Equal(t, []string{"", first.ID}, srv.LastEventIDs())
+	assert.Equal(t, second.ID, stream.LastEventID())
+}
+
+func TestStream_ReconnectsAfterOverflow(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	var reconnects atomic.Int32
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+	stream := c.Events(ctx, &relayclient.StreamOptions{
+		MinBackoff: time.Hour,
+		MaxBackoff: time.Hour,
+		OnReconnect: func(err error, delay time.Duration) {
+			reconnects.Add(1)
+		},
+	})
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	srv.Overflow()
+	overflow := nextEvent[*relayclient.OverflowEvent](t, ctx, stream)
+	assert.Equal(t, "slow_consumer", overflow.Reason)
+	assert.Positive(t, overflow.BufferSize)
+
+	// Reconnected without the hour-long backoff.
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+	msg := srv.SendMessage("after overflow")
+	assert.Equal(t, msg.ID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ID)
+	assert.Zero(t, reconnects.Load())
+}
+
+func TestStream_DropsDuplicateMessages(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	stream := relayclient.New(srv.URL, relayclient.WithToken(srv.Token)).Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	msg := srv.SendMessage("once")
+	srv.PushMessage(msg)
+	srv.Publish("custom", map[string]string{"k": "v"})
+
+	assert.Equal(t, msg.ID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ID)
+	// The duplicate is skipped; the next event is the custom one.
+	ev, err := stream.Next(ctx)
+	require.NoError(t, err)
+	unknown, ok := ev.(*relayclient.UnknownEvent)
+	require.True(t, ok, "got %T", ev)
+	assert.Equal(t, "custom", unknown.EventType())
+	assert.JSONEq(t, `{"k":"v"}`, string(unknown.Data))
+}
+
+func TestStream_StopsOnUnauthorized(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	stream := relayclient.New(srv.URL, relayclient.WithToken("wrong")).Events(ctx, nil)
+
+	_, err := stream.Next(ctx)
+	var relayErr *relayclient.Error
+	require.ErrorAs(t, err, &relayErr)
+	assert.Equal(t, 401, relayErr.StatusCode)
+	assert.True(t, relayclient.IsCode(err, "UNAUTHORIZED"))
+}
+
+func TestStream_Close(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	stream := relayclient.New(srv.URL, relayclient.WithToken(srv.Token)).Events(ctx, nil)
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	stream.Close()
+	_, err := stream.Next(ctx)
+	assert.ErrorIs(t, err, relayclient.ErrStreamClosed)
+}
+
+func TestClient_WaitForPairing(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	c := relayclient.New(srv.URL)
+	session, err := c.CreateSession(ctx)
+	require.NoError(t, err)
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		srv.CompletePairing(session.PairingCode)
+	}()
+
+	status, err := c.WaitForPairing(ctx, session.SessionToken, 10*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, relayclient.SessionPaired, status.Status)
+	assert.Equal(t, srv.Token, status.RelayToken)
+}
+
+func TestClient_ReplyErrors(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	_, err := c.Reply(ctx, "missing", relayclient.NewTextResponse("x"))
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeNotFound))
+
+	msg := srv.SendMessage("late")
+	srv.ExpireCallback(msg.ID)
+	_, err = c.Reply(ctx, msg.ID, relayclient.NewTextResponse("x"))
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeCallbackExpired))
+
+	session, err := c.CreateSession(ctx)
+	require.NoError(t, err)
+	_, err = c.WithToken(session.SessionToken).Reply(ctx, msg.ID, relayclient.NewTextResponse("x"))
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeSessionNotPaired))
+}
+
+func TestClient_SendAndUploadMedia(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	result, err := c.Send(ctx, relayclient.SendRequest{
+		ConversationKey: relaytest.DefaultConversationKey,
+		Event:           "reminder",
+		Params:          map[string]any{"when": "3pm"},
+	})
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.TaskID)
+	require.Len(t, srv.Sends(), 1)
+	assert.Equal(t, "reminder", srv.Sends()[0].Event)
+
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+	media, err := c.UploadMedia(ctx, "chart.png", "", strings.NewReader(png))
+	require.NoError(t, err)
+	assert.Equal(t, "image/png", media.ContentType)
+	assert.Equal(t, "media://"+media.ID, media.Ref)
+
+	stored, ok := srv.Media(media.ID)
+	require.True(t, ok)
+	assert.Equal(t, png, string(stored))
+}
+
+func TestResponseBuilders(t *testing.T) {
+	resp := relayclient.NewResponse(
+		relayclient.Text("결과입니다"),
+		relayclient.Image("media://abc", "chart"),
+	).WithQuickReplies(relayclient.MessageQuickReply("다시", "/retry")).
+		WithContext("order", 3, map[string]string{"id": "42"})
+
+	data, err := json.Marshal(resp)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"version": "2.0",
+		"template": {
+			"outputs": [
+				{"simpleText": {"text": "결과입니다"}},
+				{"simpleImage": {"imageUrl": "media://abc", "altText": "chart"}}
+			],
+			"quickReplies": [{"label": "다시", "action": "message", "messageText": "/retry"}]
+		},
+		"context": {"values": [{"name": "order", "lifeSpan": 3, "params": {"id": "42"}}]}
+	}`, string(data))
+}
+
+func TestParseEvent(t *testing.T) {
+	ev, err := relayclient.ParseEvent("message", []byte(`{
+		"id": "m1",
+		"conversationKey": "ch:u",
+		"normalized": {"version": 1, "text": "hi", "user": {"id": "u"}},
+		"createdAt": "2026-01-02T03:04:05Z",
+		"attachments": [{"id": "a1", "type": "image", "status": "pending", "url": "https://relay/files/attachments/a1", "expiresAt": "2026-01-02T04:04:05Z"}]
+	}`))
+	require.NoError(t, err)
+
+	msg := ev.(*relayclient.MessageEvent)
+	assert.Equal(t, "hi", msg.Text())
+	assert.Equal(t, "pending", msg.Attachments[0].Status)
+
+	_, err = relayclient.ParseEvent("message", []byte(`not json`))
+	assert.Error(t, err)
+
+	ev, err = relayclient.ParseEvent("session_disconnected", []byte(`{
+		"sessionId": "s1", "accountId": "acc", "reason": "admin", "disconnectedAt": "2026-01-02T03:04:05Z"
+	}`))
+	require.NoError(t, err)
+	disconnected := ev.(*relayclient.SessionDisconnectedEvent)
+	assert.Equal(t, "s1", disconnected.SessionID)
+	assert.Equal(t, relayclient.ReasonAdmin, disconnected.Reason)
+}
+
+func TestStream_LifecycleEvents(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	srv.Unpair("alice")
+	unpaired := nextEvent[*relayclient.ConversationUnpairedEvent](t, ctx, stream)
+	assert.Equal(t, "alice", unpaired.KakaoUserID)
+	assert.Equal(t, relayclient.ReasonUser, unpaired.Reason)
+	assert.False(t, unpaired.UnpairedAt.IsZero())
+
+	token := srv.RotateToken()
+	rotated := nextEvent[*relayclient.AccountTokenRotatedEvent](t, ctx, stream)
+	assert.Equal(t, relayclient.ReasonAdmin, rotated.Reason)
+
+	_, err := c.PairingCodes(ctx)
+	assert.Error(t, err, "the old token stops working")
+	_, err = relayclient.New(srv.URL, relayclient.WithToken(token)).PairingCodes(ctx)
+	assert.NoError(t, err)
+}
+
+func TestStream_SessionDisconnected(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+
+	stream := relayclient.New(srv.URL, relayclient.WithToken(srv.Token)).Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	srv.DisconnectSession()
+	disconnected := nextEvent[*relayclient.SessionDisconnectedEvent](t, ctx, stream)
+	assert.Equal(t, relayclient.ReasonAdmin, disconnected.Reason)
+
+	// The token is revoked, so the stream stops instead of reconnecting.
+	_, err := stream.Next(ctx)
+	assert.True(t, relayclient.IsCode(err, "UNAUTHORIZED"))
+}
+
+func TestClient_PairingCodes(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	code, err := c.CreatePairingCode(ctx, relayclient.PairingCodeOptions{MaxUses: 2})
+	require.NoError(t, err)
+	assert.Equal(t, 2, code.MaxUses)
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	require.NoError(t, srv.JoinWithCode(code.Code, "alice"))
+	joined := nextEvent[*relayclient.PairingCompleteEvent](t, ctx, stream)
+	assert.Equal(t, "alice", joined.KakaoUserID)
+	assert.Equal(t, code.ID, joined.PairingCodeID)
+
+	require.NoError(t, c.RevokePairingCode(ctx, code.ID))
+	assert.Error(t, srv.JoinWithCode(code.Code, "bob"))
+
+	codes, err := c.PairingCodes(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, codes)
+
+	err = c.RevokePairingCode(ctx, code.ID)
+	assert.True(t, relayclient.IsCode(err, "NOT_FOUND"))
+}
+
+func TestClient_PairingApproval(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	require.NoError(t, c.SetPairingApproval(ctx, true))
+	code, err := c.CreatePairingCode(ctx, relayclient.PairingCodeOptions{MaxUses: 5})
+	require.NoError(t, err)
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	require.NoError(t, srv.JoinWithCode(code.Code, "alice"))
+	asked := nextEvent[*relayclient.PairingRequestEvent](t, ctx, stream)
+	assert.Equal(t, "alice", asked.KakaoUserID)
+	assert.Equal(t, code.ID, asked.PairingCodeID)
+
+	pending, err := c.PairingRequests(ctx)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	assert.Equal(t, asked.RequestID, pending[0].ID)
+
+	approved, err := c.ApprovePairingRequest(ctx, asked.RequestID)
+	require.NoError(t, err)
+	assert.Equal(t, relayclient.PairingRequestApproved, approved.Status)
+	joined := nextEvent[*relayclient.PairingCompleteEvent](t, ctx, stream)
+	assert.Equal(t, "alice", joined.KakaoUserID)
+
+	_, err = c.RejectPairingRequest(ctx, asked.RequestID)
+	assert.True(t, relayclient.IsCode(err, "CONFLICT"))
+}
+
+func TestClient_CustomCommands(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	commands, err := c.SetCommands(ctx, []relayclient.CommandDefinition{
+		{Name: "order", Aliases: []string{"/주문"}, Usage: "<메뉴>", Description: "주문하기"},
+	})
+	require.NoError(t, err)
+	require.Len(t, commands, 1)
+	assert.Equal(t, "/order", commands[0].Name)
+
+	_, err = c.SetCommands(ctx, []relayclient.CommandDefinition{{Name: "/help"}})
+	assert.True(t, relayclient.IsCode(err, "INVALID_INPUT"))
+
+	listed, err := c.Commands(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, commands, listed)
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+
+	sent := srv.SendCommand("/order", "라떼", "2")
+	cmd := nextEvent[*relayclient.CommandEvent](t, ctx, stream)
+	assert.Equal(t, sent.ID, cmd.ID)
+	assert.Equal(t, []string{"라떼", "2"}, cmd.Command.Args)
+	assert.Equal(t, "/order 라떼 2", cmd.Text())
+
+	_, err = c.Reply(ctx, cmd.ID, relayclient.NewTextResponse("주문 완료"))
+	require.NoError(t, err)
+}
+
+func TestClient_Me(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	code, err := c.CreatePairingCode(ctx, relayclient.PairingCodeOptions{MaxUses: 5})
+	require.NoError(t, err)
+	require.NoError(t, srv.JoinWithCode(code.Code, "alice"))
+	require.NoError(t, srv.JoinWithCode(code.Code, "bob"))
+
+	me, err := c.Me(ctx)
+	require.NoError(t, err)
+	assert.NotEmpty(t, me.Account.ID)
+	assert.Equal(t, 2, me.Conversations[relayclient.ConversationPaired])
+
+	convs, err := c.Conversations(ctx, relayclient.ConversationPaired)
+	require.NoError(t, err)
+	require.Len(t, convs, 2)
+	assert.Equal(t, "bob", convs[0].KakaoUserID, "most recently active first")
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	_, err = c.UnpairConversation(ctx, convs[1].ID)
+	require.NoError(t, err)
+	unpaired := nextEvent[*relayclient.ConversationUnpairedEvent](t, ctx, stream)
+	assert.Equal(t, "alice", unpaired.KakaoUserID)
+	assert.Equal(t, relayclient.ReasonAccount, unpaired.Reason)
+
+	blocked, err := c.BlockConversation(ctx, convs[0].ID)
+	require.NoError(t, err)
+	assert.Equal(t, relayclient.ConversationBlocked, blocked.State)
+	nextEvent[*relayclient.ConversationBlockedEvent](t, ctx, stream)
+	assert.Error(t, srv.JoinWithCode(code.Code, "bob"), "blocked users cannot pair again")
+
+	_, err = c.UnpairConversation(ctx, convs[0].ID)
+	assert.True(t, relayclient.IsCode(err, "CONFLICT"))
+	_, err = c.UnblockConversation(ctx, convs[0].ID)
+	require.NoError(t, err)
+	require.NoError(t, srv.JoinWithCode(code.Code, "bob"))
+}
+
+func TestClient_RotateToken(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	token, err := c.RotateToken(ctx)
+	require.NoError(t, err)
+	assert.NotEqual(t, "relaytest-token", token)
+
+	_, err = c.Me(ctx)
+	assert.True(t, relayclient.IsCode(err, "UNAUTHORIZED"), "the old token stops working")
+	_, err = c.WithToken(token).Me(ctx)
+	assert.NoError(t, err)
+}
+
+func TestClient_History(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	first := srv.SendMessage("안녕")
+	_, err := c.Reply(ctx, first.ID, relayclient.NewTextResponse("반가워요"))
+	require.NoError(t, err)
+	srv.SendMessage("예약할래요")
+
+	page, err := c.History(ctx, relaytest.DefaultConversationKey, relayclient.HistoryOptions{Limit: 2})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 2)
+	assert.Equal(t, relayclient.DirectionOutbound, page.Messages[0].Direction)
+	assert.Equal(t, "반가워요", page.Messages[0].Text)
+	assert.Equal(t, first.ID, page.Messages[0].InReplyTo)
+	assert.Equal(t, "예약할래요", page.Messages[1].Text)
+	assert.Nil(t, page.Messages[1].Normalized, "text mode omits payloads")
+	require.NotEmpty(t, page.NextCursor)
+
+	older, err := c.History(ctx, relaytest.DefaultConversationKey, relayclient.HistoryOptions{
+		Cursor: page.NextCursor,
+		Mode:   relayclient.HistoryFull,
+	})
+	require.NoError(t, err)
+	require.Len(t, older.Messages, 1)
+	assert.Equal(t, first.ID, older.Messages[0].ID)
+	assert.NotNil(t, older.Messages[0].Normalized)
+	assert.Empty(t, older.NextCursor)
+}
+
+func TestStream_Threads(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	first := srv.SendMessage("예약할래요")
+	assert.Equal(t, first.ThreadID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ThreadID)
+	require.NotEmpty(t, first.ThreadID)
+
+	_, userID, _ := strings.Cut(relaytest.DefaultConversationKey, ":")
+	threadID := srv.NewThread(userID)
+	started := nextEvent[*relayclient.ThreadStartedEvent](t, ctx, stream)
+	assert.Equal(t, relaytest.DefaultConversationKey, started.ConversationKey)
+	assert.Equal(t, threadID, started.ThreadID)
+	assert.Equal(t, first.ThreadID, started.PreviousThreadID)
+	assert.Equal(t, relayclient.ReasonUser, started.Reason)
+
+	second := srv.SendMessage("날씨 알려줘")
+	assert.Equal(t, threadID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ThreadID)
+	_, err := c.Reply(ctx, second.ID, relayclient.NewTextResponse("맑아요"))
+	require.NoError(t, err)
+
+	page, err := c.History(ctx, relaytest.DefaultConversationKey, relayclient.HistoryOptions{ThreadID: threadID})
+	require.NoError(t, err)
+	require.Len(t, page.Messages, 2)
+	assert.Equal(t, second.ID, page.Messages[0].ID)
+	assert.Equal(t, threadID, page.Messages[1].ThreadID, "replies stay in the thread they answer")
+}
+
+func TestClient_ConversationState(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+	key := relaytest.DefaultConversationKey
+
+	entry, err := c.SetState(ctx, key, "cart", []string{"coffee"}, &relayclient.SetStateOptions{IfVersion: relayclient.Version(0)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), entry.Version)
+
+	_, err = c.SetState(ctx, key, "cart", []string{"tea"}, &relayclient.SetStateOptions{IfVersion: relayclient.Version(0)})
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeConflict), "the key already exists")
+
+	entry, err = c.SetState(ctx, key, "cart", []string{"coffee", "tea"}, &relayclient.SetStateOptions{
+		IfVersion: relayclient.Version(1),
+		TTL:       time.Hour,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), entry.Version)
+	assert.NotNil(t, entry.ExpiresAt)
+
+	got, err := c.GetState(ctx, key, "cart")
+	require.NoError(t, err)
+	var cart []string
+	require.NoError(t, got.Decode(&cart))
+	assert.Equal(t, []string{"coffee", "tea"}, cart)
+
+	stream := c.Events(ctx, &relayclient.StreamOptions{IncludeState: true})
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+	srv.SendMessage("주문할게요")
+	msg := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.JSONEq(t, `["coffee","tea"]`, string(msg.State["cart"]))
+
+	err = c.DeleteState(ctx, key, "cart", relayclient.Version(1))
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeConflict))
+	require.NoError(t, c.DeleteState(ctx, key, "cart", relayclient.Version(2)))
+	_, err = c.GetState(ctx, key, "cart")
+	assert.True(t, relayclient.IsCode(err, relayclient.CodeNotFound))
+
+	_, err = c.SetState(ctx, key, "lang", "ko", nil)
+	require.NoError(t, err)
+	_, userID, _ := strings.Cut(key, ":")
+	srv.Unpair(userID)
+	entries, err := c.State(ctx, key)
+	require.NoError(t, err)
+	assert.Empty(t, entries, "unpairing clears the state")
+}
+
+func TestStream_CompetingDelivery(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+	opts := &relayclient.StreamOptions{Delivery: relayclient.DeliveryCompeting}
+
+	first := c.Events(ctx, opts)
+	defer first.Close()
+	connected := nextEvent[*relayclient.ConnectedEvent](t, ctx, first)
+	assert.Equal(t, relayclient.DeliveryCompeting, connected.Delivery)
+	assert.NotEmpty(t, connected.ConsumerID)
+
+	second := c.Events(ctx, opts)
+	defer second.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, second)
+
+	// The first stream owns the fewest conversations, so it gets the
+	// default conversation and keeps it for later turns.
+	msg1 := srv.SendMessage("첫 번째")
+	assert.Equal(t, msg1.ID, nextEvent[*relayclient.MessageEvent](t, ctx, first).ID)
+	msg2 := srv.SendMessage("두 번째")
+	assert.Equal(t, msg2.ID, nextEvent[*relayclient.MessageEvent](t, ctx, first).ID)
+
+	// A new conversation goes to the less busy stream.
+	other := relayclient.MessageEvent{ID: "other-1", ConversationKey: "relaytest-channel:other-user"}
+	srv.PushMessage(other)
+	assert.Equal(t, other.ID, nextEvent[*relayclient.MessageEvent](t, ctx, second).ID)
+
+	acked, err := c.Ack(ctx, msg1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, acked)
+
+	// msg2 was neither acked nor answered, so it moves to the remaining
+	// stream together with the conversation.
+	first.Close()
+	assert.Equal(t, msg2.ID, nextEvent[*relayclient.MessageEvent](t, ctx, second).ID)
+
+	msg3 := srv.SendMessage("세 번째")
+	assert.Equal(t, msg3.ID, nextEvent[*relayclient.MessageEvent](t, ctx, second).ID)
+	_, err = c.Reply(ctx, msg3.ID, relayclient.NewTextResponse("네"))
+	require.NoError(t, err)
+
+	acked, err = c.Ack(ctx, msg2.ID, msg3.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, acked, "replying already acked msg3")
+}
+
+func TestStream_OrderedDelivery(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	settings, err := c.SetOrderedDelivery(ctx, true)
+	require.NoError(t, err)
+	assert.True(t, settings.OrderedDelivery)
+	assert.Positive(t, settings.HoldTimeoutSeconds)
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	msg1 := srv.SendMessage("첫 번째")
+	first := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, msg1.ID, first.ID)
+	assert.Zero(t, first.QueuePosition)
+
+	// Later messages wait until the one before them is answered.
+	msg2 := srv.SendMessage("두 번째")
+	msg3 := srv.SendMessage("세 번째")
+	other := relayclient.MessageEvent{ID: "other-1", ConversationKey: "relaytest-channel:other-user"}
+	srv.PushMessage(other)
+	assert.Equal(t, other.ID, nextEvent[*relayclient.MessageEvent](t, ctx, stream).ID,
+		"other conversations are not held")
+
+	_, err = c.Reply(ctx, msg1.ID, relayclient.NewTextResponse("네"))
+	require.NoError(t, err)
+	second := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, msg2.ID, second.ID)
+	assert.Equal(t, 1, second.QueuePosition)
+
+	_, err = c.Ack(ctx, msg2.ID)
+	require.NoError(t, err)
+	third := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, msg3.ID, third.ID)
+	assert.Equal(t, 2, third.QueuePosition)
+
+	current, err := c.DeliverySettings(ctx)
+	require.NoError(t, err)
+	assert.True(t, current.OrderedDelivery)
+}
+
+func TestStream_CoalescedMessages(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	settings, err := c.SetCoalesceWindow(ctx, 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, 50, settings.CoalesceWindowMs)
+
+	_, err = c.SetCoalesceWindow(ctx, relayclient.MaxCoalesceWindow+time.Second)
+	require.Error(t, err)
+
+	stream := c.Events(ctx, nil)
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	msg1 := srv.SendMessage("안녕하세요")
+	msg2 := srv.SendMessage("질문이 있어요")
+	srv.ExpireCallback(msg2.ID)
+
+	merged := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, msg1.ID, merged.ID, "delivered as the latest utterance with a live callback")
+	assert.Equal(t, []string{msg1.ID, msg2.ID}, merged.MessageIDs)
+	assert.Equal(t, "안녕하세요\n질문이 있어요", merged.Text())
+
+	// Replying to any of the merged utterances answers the event.
+	_, err = c.Reply(ctx, msg2.ID, relayclient.NewTextResponse("네"))
+	require.NoError(t, err)
+	reply, err := srv.WaitReply(ctx, msg1.ID)
+	require.NoError(t, err)
+	assert.Equal(t, msg1.ID, reply.MessageID)
+
+	// A single utterance is delivered as is.
+	msg3 := srv.SendMessage("고마워요")
+	single := nextEvent[*relayclient.MessageEvent](t, ctx, stream)
+	assert.Equal(t, msg3.ID, single.ID)
+	assert.Empty(t, single.MessageIDs)
+
+	current, err := c.DeliverySettings(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 50, current.CoalesceWindowMs)
+}
+
+func TestStream_Filter(t *testing.T) {
+	ctx := testContext(t)
+	srv := relaytest.NewServer()
+	defer srv.Close()
+	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+
+	stream := c.Events(ctx, &relayclient.StreamOptions{
+		Filter: relayclient.EventFilter{
+			Types:   []string{relayclient.EventMessage, relayclient.EventThreadStarted},
+			Intents: []string{"예약"},
+		},
+	})
+	defer stream.Close()
+	nextEvent[*relayclient.ConnectedEvent](t, ctx, stream)
+
+	intent := func(id, name string) relayclient.MessageEvent {
+		return relayclient.MessageEvent{
+			ID:              id,
+			ConversationKey: relaytest.DefaultConversationKey,
+			Normalized:      &relayclient.NormalizedMessage{Intent: &relayclient.NormalizedRef{Name: name}},
+		}
+	}
+	srv.PushMessage(intent("smalltalk-1", "잡담"))
+	srv.Publish(relayclient.EventConversationBlocked, relayclient.ConversationBlockedEvent{
+		ConversationKey: relaytest.DefaultConversationKey,
+	})
+	srv.PushMessage(intent("booking-1", "예약"))
+
+	// Only the booking message passes; the others never reach the client.
+	ev, err := stream.Next(ctx)
+	require.NoError(t, err)
+	msg, ok := ev.(*relayclient.MessageEvent)
+	require.True(t, ok, "got %T", ev)
+	assert.Equal(t, "booking-1", msg.ID)
+
+	t.Run("competing streams cannot filter messages", func(t *testing.T) {
+		competing := c.Events(ctx, &relayclient.StreamOptions{
+			Delivery: relayclient.DeliveryCompeting,
+			Filter:   relayclient.EventFilter{ChannelIDs: []string{"relaytest-channel"}},
+		})
+		defer competing.Close()
+
+		_, err := competing.Next(ctx)
+		assert.True(t, relayclient.IsCode(err, "INVALID_INPUT"), "got %v", err)
+	})
+}
@@ -0,0 +1,1983 @@
+// Package relaytest provides an in-process fake relay for testing OpenClaw
+// integrations built on relayclient.
+//
+//	srv := relaytest.NewServer()
+//	defer srv.Close()
+//	c := relayclient.New(srv.URL, relayclient.WithToken(srv.Token))
+//	msg := srv.SendMessage("안녕")
+//	... run the integration ...
+//	reply, _ := srv.WaitReply(ctx, msg.ID)
+//
+// The fake serves /v1/sessions, /v1/events, /v1/me, /v1/conversations,
+// /v1/pairing-{codes,settings,requests}, /v1/delivery-settings and /openclaw/{reply,ack,send,media,commands}
+// with the relay's wire formats and error codes, but keeps everything in
+// memory and never calls Kakao.
+package relaytest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.tepseg.com/ai/kakao-relay/pkg/relayclient"
+)
+
+const (
+	// DefaultConversationKey is used by SendMessage.
+	DefaultConversationKey = "relaytest-channel:relaytest-user"
+	accountID              = "relaytest-account"
+)
+
+// Reply is a response an integration posted to /openclaw/reply.
+type Reply struct {
+	MessageID string
+	Response  relayclient.Response
+	Raw       json.RawMessage
+}
+
+// Server is a fake relay backed by httptest.Server.
+type Server struct {
+	URL string
+	// Token is the relay token accepted for the paired account.
+	Token string
+
+	srv *httptest.Server
+
+	mu          sync.Mutex
+	seq         int
+	sessions    map[string]*fakeSession // by session token
+	subscribers map[*subscriber]struct{}
+	queued      []frame
+	messages    map[string]*messageState
+	replies     []Reply
+	replyWait   map[string][]chan Reply
+	sends       []relayclient.SendRequest
+	commands    []relayclient.Command
+	codes       []*relayclient.PairingCode
+	approval    bool
+	requests    []*relayclient.PairingRequest
+	convs       []*relayclient.Conversation
+	history     []historyEntry
+	state       map[string]map[string]*relayclient.StateEntry // by conversation key, then key
+	threads     map[string]string                             // current thread by conversation key
+	affinity    map[string]*subscriber                        // competing owner by conversation key
+	ordered     bool
+	held        map[string][]relayclient.MessageEvent // ordered delivery backlog by conversation key
+	inFlight    map[string]string                     // unsettled message ID by conversation key
+	coalesce    time.Duration
+	coalescing  map[string]*coalesceGroup // waiting utterances by conversation key
+	media       map[string][]byte
+	lastEventID []string
+}
+
+type fakeSession struct {
+	code   string
+	paired bool
+}
+
+type messageState struct {
+	conversationKey string
+	threadID        string
+	callbackExpired bool
+	acked           bool
+	coalescedInto   string
+}
+
+type coalesceGroup struct {
+	events []relayclient.MessageEvent
+	timer  *time.Timer
+}
+
+type historyEntry struct {
+	conversationKey string
+	msg             relayclient.HistoryMessage
+}
+
+type frame struct {
+	id        string
+	eventType string
+	data      []byte
+}
+
+type subscriber struct {
+	// scope is "account" or the session token for pending sessions.
+	scope        string
+	includeState bool
+	filter       *eventFilter
+	frames       chan frame
+	done         chan struct{}
+
+	// Competing subscribers share the account's messages. leased holds
+	// the messages sent to one that were not acked yet, oldest first.
+	competing  bool
+	consumerID string
+	leased     []frame
+}
+
+func NewServer() *Server {
+	s := &Server{
+		Token:       "relaytest-token",
+		sessions:    make(map[string]*fakeSession),
+		subscribers: make(map[*subscriber]struct{}),
+		messages:    make(map[string]*messageState),
+		replyWait:   make(map[string][]chan Reply),
+		state:       make(map[string]map[string]*relayclient.StateEntry),
+		threads:     make(map[string]string),
+		affinity:    make(map[string]*subscriber),
+		held:        make(map[string][]relayclient.MessageEvent),
+		inFlight:    make(map[string]string),
+		coalescing:  make(map[string]*coalesceGroup),
+		media:       make(map[string][]byte),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/sessions/create", s.createSession)
+	mux.HandleFunc("GET /v1/sessions/{token}/status", s.sessionStatus)
+	mux.HandleFunc("GET /v1/events", s.events)
+	mux.HandleFunc("POST /openclaw/reply", s.reply)
+	mux.HandleFunc("POST /openclaw/ack", s.ack)
+	mux.HandleFunc("POST /openclaw/send", s.send)
+	mux.HandleFunc("POST /openclaw/media", s.uploadMedia)
+	mux.HandleFunc("GET /openclaw/commands", s.listCommands)
+	mux.HandleFunc("PUT /openclaw/commands", s.replaceCommands)
+	mux.HandleFunc("POST /v1/pairing-codes", s.createPairingCode)
+	mux.HandleFunc("GET /v1/pairing-codes", s.listPairingCodes)
+	mux.HandleFunc("DELETE /v1/pairing-codes/{id}", s.revokePairingCode)
+	mux.HandleFunc("PUT /v1/pairing-settings", s.updatePairingSettings)
+	mux.HandleFunc("GET /v1/delivery-settings", s.deliverySettings)
+	mux.HandleFunc("PUT /v1/delivery-settings", s.updateDeliverySettings)
+	mux.HandleFunc("GET /v1/pairing-requests", s.listPairingRequests)
+	mux.HandleFunc("POST /v1/pairing-requests/{id}/{action}", s.decidePairingRequest)
+	mux.HandleFunc("GET /v1/me", s.me)
+	mux.HandleFunc("GET /v1/me/conversations", s.listConversations)
+	mux.HandleFunc("POST /v1/me/conversations/{id}/{action}", s.changeConversation)
+	mux.HandleFunc("POST /v1/me/token/rotate", s.rotateTokenHandler)
+	mux.HandleFunc("GET /v1/conversations/{key}/messages", s.conversationHistory)
+	mux.HandleFunc("GET /v1/conversations/{key}/state", s.listState)
+	mux.HandleFunc("GET /v1/conversations/{key}/state/{name}", s.getState)
+	mux.HandleFunc("PUT /v1/conversations/{key}/state/{name}", s.putState)
+	mux.HandleFunc("DELETE /v1/conversations/{key}/state/{name}", s.deleteState)
+
+	s.srv = httptest.NewServer(mux)
+	s.URL = s.srv.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.DisconnectAll()
+	s.srv.Close()
+}
+
+// SendMessage delivers a text message from DefaultConversationKey and
+// returns the event the integration will receive.
+func (s *Server) SendMessage(text string) relayclient.MessageEvent {
+	ev := s.newMessage(text)
+	s.PushMessage(ev)
+	return ev
+}
+
+func (s *Server) newMessage(text string) relayclient.MessageEvent {
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("msg-%d", s.seq)
+	threadID := s.threadLocked(DefaultConversationKey)
+	s.mu.Unlock()
+
+	channelID, userID, _ := strings.Cut(DefaultConversationKey, ":")
+	return relayclient.MessageEvent{
+		ID:              id,
+		ConversationKey: DefaultConversationKey,
+		KakaoPayload:    json.RawMessage(`{}`),
+		Normalized: &relayclient.NormalizedMessage{
+			Version:   1,
+			UserID:    userID,
+			ChannelID: channelID,
+			Text:      text,
+			User:      relayclient.NormalizedUser{ID: userID, PlusfriendUserKey: userID},
+		},
+		CreatedAt:   time.Now().UTC(),
+		Attachments: []relayclient.Attachment{},
+		ThreadID:    threadID,
+	}
+}
+
+// SendCommand delivers a "command" event for a custom command, as if the
+// user had typed "<name> <args...>". Unlike the relay it does not require
+// the command to be declared first. Like the relay, it first delivers any
+// utterances still waiting to be coalesced.
+func (s *Server) SendCommand(name string, args ...string) relayclient.CommandEvent {
+	s.mu.Lock()
+	group := s.coalescing[DefaultConversationKey]
+	if group != nil {
+		group.timer.Stop()
+	}
+	s.mu.Unlock()
+	if group != nil {
+		s.flushCoalesced(DefaultConversationKey, group)
+	}
+
+	msg := s.newMessage(strings.TrimSpace(name + " " + strings.Join(args, " ")))
+	if args == nil {
+		args = []string{}
+	}
+	invocation := relayclient.CommandInvocation{Name: name, Args: args, RawArgs: strings.Join(args, " ")}
+	msg.Normalized.Command = &invocation
+	ev := relayclient.CommandEvent{MessageEvent: msg, Command: invocation}
+
+	data, _ := json.Marshal(ev)
+	s.mu.Lock()
+	s.recordInboundLocked(msg, &invocation)
+	s.mu.Unlock()
+
+	s.publish("account", frame{id: ev.ID, eventType: relayclient.EventCommand, data: data}, true)
+	return ev
+}
+
+// Commands returns the commands last declared through SetCommands.
+func (s *Server) Commands() []relayclient.Command {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]relayclient.Command(nil), s.commands...)
+}
+
+// PushMessage delivers a message event. Like the relay, it is queued until
+// an account stream is connected. An empty ThreadID is set to the
+// conversation's current thread. With ordered delivery on, it is held
+// while an earlier message of the conversation is not replied to or acked;
+// unlike the relay, the fake never releases it on a timeout. With a
+// coalescing window set, it waits until no further message of the
+// conversation arrives for the window and is then delivered merged with
+// the others waiting; unlike the relay, the window is not capped.
+func (s *Server) PushMessage(ev relayclient.MessageEvent) {
+	if ev.Attachments == nil {
+		ev.Attachments = []relayclient.Attachment{}
+	}
+
+	s.mu.Lock()
+	if ev.ThreadID == "" {
+		ev.ThreadID = s.threadLocked(ev.ConversationKey)
+	}
+	s.recordInboundLocked(ev, nil)
+	if s.coalesce > 0 {
+		s.coalesceLocked(ev)
+		s.mu.Unlock()
+		return
+	}
+	f, ok := s.deliverLocked(ev)
+	s.mu.Unlock()
+
+	if ok {
+		s.publish("account", f, true)
+	}
+}
+
+// deliverLocked returns the frame to publish for ev, or false when ordered
+// delivery holds it.
+func (s *Server) deliverLocked(ev relayclient.MessageEvent) (frame, bool) {
+	if s.ordered {
+		if _, busy := s.inFlight[ev.ConversationKey]; busy {
+			ev.QueuePosition = len(s.held[ev.ConversationKey]) + 1
+			s.held[ev.ConversationKey] = append(s.held[ev.ConversationKey], ev)
+			return frame{}, false
+		}
+		s.inFlight[ev.ConversationKey] = ev.ID
+	}
+	return messageFrame(ev), true
+}
+
+// coalesceLocked adds ev to its conversation's waiting utterances and
+// restarts the window.
+func (s *Server) coalesceLocked(ev relayclient.MessageEvent) {
+	group := s.coalescing[ev.ConversationKey]
+	if group == nil {
+		group = &coalesceGroup{}
+		s.coalescing[ev.ConversationKey] = group
+	} else {
+		group.timer.Stop()
+	}
+	group.events = append(group.events, ev)
+	group.timer = time.AfterFunc(s.coalesce, func() { s.flushCoalesced(ev.ConversationKey, group) })
+}
+
+// flushCoalesced delivers the group's utterances as one event: the latest
+// one whose callback has not expired, with all texts and attachments.
+func (s *Server) flushCoalesced(conversationKey string, group *coalesceGroup) {
+	s.mu.Lock()
+	if s.coalescing[conversationKey] != group {
+		s.mu.Unlock()
+		return
+	}
+	delete(s.coalescing, conversationKey)
+
+	events := group.events
+	primary := len(events) - 1
+	for i := len(events) - 1; i >= 0; i-- {
+		if !s.messages[events[i].ID].callbackExpired {
+			primary = i
+			break
+		}
+	}
+	merged := events[primary]
+	if len(events) > 1 {
+		var texts, ids []string
+		var attachments []relayclient.Attachment
+		for _, ev := range events {
+			if text := ev.Text(); text != "" {
+				texts = append(texts, text)
+			}
+			attachments = append(attachments, ev.Attachments...)
+			ids = append(ids, ev.ID)
+			if ev.ID != merged.ID {
+				s.messages[ev.ID].acked = true
+				s.messages[ev.ID].coalescedInto = merged.ID
+			}
+		}
+		if merged.Normalized != nil {
+			normalized := *merged.Normalized
+			normalized.Text = strings.Join(texts, "\n")
+			merged.Normalized = &normalized
+		}
+		merged.Attachments = attachments
+		merged.MessageIDs = ids
+	}
+	f, ok := s.deliverLocked(merged)
+	s.mu.Unlock()
+
+	if ok {
+		s.publish("account", f, true)
+	}
+}
+
+func messageFrame(ev relayclient.MessageEvent) frame {
+	data, _ := json.Marshal(ev)
+	return frame{id: ev.ID, eventType: relayclient.EventMessage, data: data}
+}
+
+// settleLocked marks messageID handled for ordered delivery and returns
+// the conversation's next held message to publish, if any.
+func (s *Server) settleLocked(messageID string) (frame, bool) {
+	msg, ok := s.messages[messageID]
+	if !ok || s.inFlight[msg.conversationKey] != messageID {
+		return frame{}, false
+	}
+	delete(s.inFlight, msg.conversationKey)
+
+	held := s.held[msg.conversationKey]
+	if len(held) == 0 {
+		return frame{}, false
+	}
+	next := held[0]
+	s.held[msg.conversationKey] = held[1:]
+	s.inFlight[msg.conversationKey] = next.ID
+	return messageFrame(next), true
+}
+
+// recordInboundLocked makes ev repliable and adds it to the history.
+func (s *Server) recordInboundLocked(ev relayclient.MessageEvent, cmd *relayclient.CommandInvocation) {
+	s.messages[ev.ID] = &messageState{conversationKey: ev.ConversationKey, threadID: ev.ThreadID}
+	msg := relayclient.HistoryMessage{
+		ID:           ev.ID,
+		Direction:    relayclient.DirectionInbound,
+		Status:       "delivered",
+		Command:      cmd,
+		ThreadID:     ev.ThreadID,
+		CreatedAt:    ev.CreatedAt,
+		KakaoPayload: ev.KakaoPayload,
+		Normalized:   ev.Normalized,
+	}
+	if ev.Normalized != nil {
+		msg.Text = ev.Normalized.Text
+	}
+	s.history = append(s.history, historyEntry{conversationKey: ev.ConversationKey, msg: msg})
+}
+
+// recordOutboundLocked adds a reply or Event API send to the history.
+func (s *Server) recordOutboundLocked(conversationKey string, msg relayclient.HistoryMessage) {
+	s.seq++
+	msg.ID = fmt.Sprintf("out-%d", s.seq)
+	msg.Direction = relayclient.DirectionOutbound
+	msg.Status = "sent"
+	msg.CreatedAt = time.Now().UTC()
+	s.history = append(s.history, historyEntry{conversationKey: conversationKey, msg: msg})
+}
+
+// Publish sends an arbitrary event to connected account streams.
+func (s *Server) Publish(eventType string, data any) {
+	raw, _ := json.Marshal(data)
+	s.publish("account", frame{eventType: eventType, data: raw}, false)
+}
+
+// CompletePairing pairs the session with the given pairing code, as if the
+// user had sent "/pair <code>", and emits pairing_complete. Account pairing
+// codes from /v1/pairing-codes are accepted too; use JoinWithCode to pair
+// a different Kakao user.
+func (s *Server) CompletePairing(pairingCode string) error {
+	_, userID, _ := strings.Cut(DefaultConversationKey, ":")
+	return s.JoinWithCode(pairingCode, userID)
+}
+
+// JoinWithCode pairs kakaoUserID with a session or account pairing code.
+// Blocked users are refused. In approval mode an account code emits
+// pairing_request instead, and pairing_complete follows once the
+// integration approves it.
+func (s *Server) JoinWithCode(pairingCode, kakaoUserID string) error {
+	s.mu.Lock()
+	for _, c := range s.convs {
+		if c.KakaoUserID == kakaoUserID && c.State == relayclient.ConversationBlocked {
+			s.mu.Unlock()
+			return fmt.Errorf("relaytest: %s is blocked", kakaoUserID)
+		}
+	}
+	var token string
+	for t, sess := range s.sessions {
+		if sess.code == pairingCode && !sess.paired {
+			sess.paired = true
+			token = t
+		}
+	}
+	var codeID string
+	if token == "" {
+		for _, c := range s.codes {
+			if c.Code == pairingCode && c.UseCount < c.MaxUses && c.ExpiresAt.After(time.Now()) {
+				now := time.Now().UTC()
+				c.UseCount++
+				c.LastUsedAt = &now
+				codeID = c.ID
+				break
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	if token == "" && codeID == "" {
+		return fmt.Errorf("relaytest: no pending session or pairing code %s", pairingCode)
+	}
+
+	if codeID != "" {
+		s.mu.Lock()
+		approval := s.approval
+		s.mu.Unlock()
+		if approval {
+			s.requestApproval(codeID, kakaoUserID)
+			return nil
+		}
+	}
+	s.publishPairingComplete(token, codeID, kakaoUserID)
+	return nil
+}
+
+func (s *Server) requestApproval(codeID, kakaoUserID string) {
+	channelID, _, _ := strings.Cut(DefaultConversationKey, ":")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s.mu.Lock()
+	s.seq++
+	req := &relayclient.PairingRequest{
+		ID:              fmt.Sprintf("req-%d", s.seq),
+		ConversationKey: channelID + ":" + kakaoUserID,
+		PairingCodeID:   codeID,
+		Profile:         relayclient.PairingProfile{KakaoUserID: kakaoUserID, ChannelID: channelID},
+		Status:          relayclient.PairingRequestPending,
+		ExpiresAt:       now.Add(24 * time.Hour),
+		CreatedAt:       now,
+	}
+	s.requests = append(s.requests, req)
+	s.setConversationLocked(kakaoUserID, relayclient.ConversationPending)
+	s.mu.Unlock()
+
+	s.Publish(relayclient.EventPairingRequest, relayclient.PairingRequestEvent{
+		RequestID:       req.ID,
+		ConversationKey: req.ConversationKey,
+		KakaoUserID:     kakaoUserID,
+		PairingCodeID:   codeID,
+		Profile:         req.Profile,
+		RequestedAt:     req.CreatedAt,
+		ExpiresAt:       req.ExpiresAt,
+	})
+}
+
+// threadLocked returns the conversation's current thread, starting the
+// first one on demand.
+func (s *Server) threadLocked(conversationKey string) string {
+	if id, ok := s.threads[conversationKey]; ok {
+		return id
+	}
+	s.seq++
+	id := fmt.Sprintf("thread-%d", s.seq)
+	s.threads[conversationKey] = id
+	return id
+}
+
+// NewThread starts a new thread for kakaoUserID's conversation, as if the
+// user had sent "/new", and emits thread_started. It returns the new
+// thread ID; later messages from the user carry it.
+func (s *Server) NewThread(kakaoUserID string) string {
+	channelID, _, _ := strings.Cut(DefaultConversationKey, ":")
+	conversationKey := channelID + ":" + kakaoUserID
+
+	s.mu.Lock()
+	previous := s.threadLocked(conversationKey)
+	s.seq++
+	threadID := fmt.Sprintf("thread-%d", s.seq)
+	s.threads[conversationKey] = threadID
+	for _, c := range s.convs {
+		if c.ConversationKey == conversationKey {
+			c.ThreadID = threadID
+		}
+	}
+	s.mu.Unlock()
+
+	s.Publish(relayclient.EventThreadStarted, relayclient.ThreadStartedEvent{
+		ConversationKey:  conversationKey,
+		KakaoUserID:      kakaoUserID,
+		ThreadID:         threadID,
+		PreviousThreadID: previous,
+		Reason:           relayclient.ReasonUser,
+		StartedAt:        time.Now().UTC().Truncate(time.Second),
+	})
+	return threadID
+}
+
+// Unpair emits conversation_unpaired for kakaoUserID, as if the user had
+// sent "/unpair".
+func (s *Server) Unpair(kakaoUserID string) {
+	s.mu.Lock()
+	s.removeConversationLocked(kakaoUserID)
+	s.mu.Unlock()
+	s.publishUnpaired(kakaoUserID, relayclient.ReasonUser)
+}
+
+func (s *Server) publishUnpaired(kakaoUserID, reason string) {
+	channelID, _, _ := strings.Cut(DefaultConversationKey, ":")
+	conversationKey := channelID + ":" + kakaoUserID
+	s.mu.Lock()
+	threadID := s.threadLocked(conversationKey)
+	s.mu.Unlock()
+	s.Publish(relayclient.EventConversationUnpaired, relayclient.ConversationUnpairedEvent{
+		ConversationKey: conversationKey,
+		KakaoUserID:     kakaoUserID,
+		ThreadID:        threadID,
+		Reason:          reason,
+		UnpairedAt:      time.Now().UTC().Truncate(time.Second),
+	})
+}
+
+// RotateToken replaces Token, as an operator would from the dashboard, and
+// emits account_token_rotated. It returns the new token.
+func (s *Server) RotateToken() string {
+	return s.rotateToken(relayclient.ReasonAdmin)
+}
+
+func (s *Server) rotateToken(reason string) string {
+	s.mu.Lock()
+	s.seq++
+	s.Token = fmt.Sprintf("relaytest-token-%d", s.seq)
+	token := s.Token
+	s.mu.Unlock()
+
+	s.Publish(relayclient.EventAccountTokenRotated, relayclient.AccountTokenRotatedEvent{
+		AccountID: accountID,
+		Reason:    reason,
+		RotatedAt: time.Now().UTC().Truncate(time.Second),
+	})
+	return token
+}
+
+// setConversationLocked records kakaoUserID's conversation in state,
+// creating it on first sight.
+func (s *Server) setConversationLocked(kakaoUserID, state string) *relayclient.Conversation {
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, c := range s.convs {
+		if c.KakaoUserID == kakaoUserID {
+			c.State = state
+			c.LastSeenAt = now
+			if state == relayclient.ConversationPaired {
+				c.PairedAt = &now
+			}
+			return c
+		}
+	}
+
+	channelID, _, _ := strings.Cut(DefaultConversationKey, ":")
+	s.seq++
+	c := &relayclient.Conversation{
+		ID:              fmt.Sprintf("conv-%d", s.seq),
+		ConversationKey: channelID + ":" + kakaoUserID,
+		KakaoChannelID:  channelID,
+		KakaoUserID:     kakaoUserID,
+		State:           state,
+		FirstSeenAt:     now,
+		LastSeenAt:      now,
+	}
+	c.ThreadID = s.threadLocked(c.ConversationKey)
+	if state == relayclient.ConversationPaired {
+		c.PairedAt = &now
+	}
+	s.convs = append(s.convs, c)
+	return c
+}
+
+// removeConversationLocked forgets kakaoUserID's conversation and its
+// state.
+func (s *Server) removeConversationLocked(kakaoUserID string) {
+	channelID, _, _ := strings.Cut(DefaultConversationKey, ":")
+	delete(s.state, channelID+":"+kakaoUserID)
+	for i, c := range s.convs {
+		if c.KakaoUserID == kakaoUserID {
+			s.convs = append(s.convs[:i], s.convs[i+1:]...)
+			return
+		}
+	}
+}
+
+func (s *Server) publishPairingComplete(token, codeID, kakaoUserID string) {
+	s.mu.Lock()
+	s.setConversationLocked(kakaoUserID, relayclient.ConversationPaired)
+	s.mu.Unlock()
+
+	data, _ := json.Marshal(relayclient.PairingCompleteEvent{
+		KakaoUserID:   kakaoUserID,
+		AccountID:     accountID,
+		PairedAt:      time.Now().UTC().Truncate(time.Second),
+		PairingCodeID: codeID,
+	})
+	f := frame{eventType: relayclient.EventPairingComplete, data: data}
+	if token != "" {
+		s.publish(token, f, false)
+	}
+	s.publish("account", f, false)
+}
+
+// ExpireCallback makes replies to messageID fail with CALLBACK_EXPIRED.
+func (s *Server) ExpireCallback(messageID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if m, ok := s.messages[messageID]; ok {
+		m.callbackExpired = true
+	}
+}
+
+// Overflow ends every account stream with an overflow event, as the relay
+// does with a client that reads too slowly.
+func (s *Server) Overflow() {
+	data, _ := json.Marshal(relayclient.OverflowEvent{Reason: "slow_consumer", Dropped: 1, BufferSize: 100})
+	s.publish("account", frame{eventType: relayclient.EventOverflow, data: data}, false)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		if sub.scope == "account" {
+			close(sub.done)
+			delete(s.subscribers, sub)
+		}
+	}
+}
+
+// DisconnectAll drops every open event stream, e.g. to exercise reconnects.
+func (s *Server) DisconnectAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for sub := range s.subscribers {
+		close(sub.done)
+		delete(s.subscribers, sub)
+	}
+}
+
+// DisconnectSession revokes the account as an operator disconnecting its
+// session would: it emits session_disconnected, invalidates Token and the
+// paired session tokens, and drops every open stream.
+func (s *Server) DisconnectSession() {
+	s.Publish(relayclient.EventSessionDisconnected, relayclient.SessionDisconnectedEvent{
+		AccountID:      accountID,
+		Reason:         relayclient.ReasonAdmin,
+		DisconnectedAt: time.Now().UTC().Truncate(time.Second),
+	})
+
+	s.mu.Lock()
+	s.Token = ""
+	clear(s.state)
+	for token, sess := range s.sessions {
+		if sess.paired {
+			delete(s.sessions, token)
+		}
+	}
+	s.mu.Unlock()
+	s.DisconnectAll()
+}
+
+// Connections returns the number of open event streams.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.subscribers)
+}
+
+// LastEventIDs returns the Last-Event-ID header of every stream request,
+// in order ("" when absent).
+func (s *Server) LastEventIDs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.lastEventID...)
+}
+
+func (s *Server) Replies() []Reply {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Reply(nil), s.replies...)
+}
+
+// WaitReply blocks until the integration replies to messageID.
+func (s *Server) WaitReply(ctx context.Context, messageID string) (Reply, error) {
+	s.mu.Lock()
+	for _, r := range s.replies {
+		if r.MessageID == messageID {
+			s.mu.Unlock()
+			return r, nil
+		}
+	}
+	ch := make(chan Reply, 1)
+	s.replyWait[messageID] = append(s.replyWait[messageID], ch)
+	s.mu.Unlock()
+
+	select {
+	case r := <-ch:
+		return r, nil
+	case <-ctx.Done():
+		return Reply{}, ctx.Err()
+	}
+}
+
+func (s *Server) Sends() []relayclient.SendRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]relayclient.SendRequest(nil), s.sends...)
+}
+
+// Media returns the bytes of an uploaded file.
+func (s *Server) Media(id string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, ok := s.media[id]
+	return data, ok
+}
+
+func (s *Server) publish(scope string, f frame, queueIfOffline bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivered := false
+	if scope == "account" && f.id != "" {
+		if owner := s.competingOwnerLocked(f); owner != nil {
+			delivered = s.leaseLocked(owner, f)
+		}
+	}
+	for sub := range s.subscribers {
+		if sub.scope != scope || (sub.competing && f.id != "") || !sub.filter.match(f) {
+			continue
+		}
+		select {
+		case sub.frames <- f:
+			delivered = true
+		default:
+		}
+	}
+	if !delivered && queueIfOffline {
+		s.queued = append(s.queued, f)
+	}
+}
+
+// competingOwnerLocked returns the competing subscriber that gets message
+// frame f: the conversation's current owner while it is connected,
+// otherwise the competing subscriber owning the fewest conversations. Nil
+// when none is connected.
+func (s *Server) competingOwnerLocked(f frame) *subscriber {
+	var msg struct {
+		ConversationKey string `json:"conversationKey"`
+	}
+	json.Unmarshal(f.data, &msg)
+
+	if owner, ok := s.affinity[msg.ConversationKey]; ok {
+		if _, live := s.subscribers[owner]; live {
+			return owner
+		}
+	}
+
+	load := make(map[*subscriber]int)
+	for _, owner := range s.affinity {
+		load[owner]++
+	}
+	var best *subscriber
+	for sub := range s.subscribers {
+		if !sub.competing {
+			continue
+		}
+		if best == nil || load[sub] < load[best] ||
+			(load[sub] == load[best] && sub.consumerID < best.consumerID) {
+			best = sub
+		}
+	}
+	if best != nil {
+		s.affinity[msg.ConversationKey] = best
+	}
+	return best
+}
+
+// leaseLocked sends f to a competing subscriber and holds it there until
+// it is acked.
+func (s *Server) leaseLocked(sub *subscriber, f frame) bool {
+	select {
+	case sub.frames <- f:
+		sub.leased = append(sub.leased, f)
+		return true
+	default:
+		return false
+	}
+}
+
+// ackLocked ends the lease on messageID and reports whether the message
+// was still unacked.
+func (s *Server) ackLocked(messageID string) bool {
+	msg, ok := s.messages[messageID]
+	if !ok || msg.acked {
+		return false
+	}
+	msg.acked = true
+	for sub := range s.subscribers {
+		sub.leased = slices.DeleteFunc(sub.leased, func(f frame) bool { return f.id == messageID })
+	}
+	return true
+}
+
+// releaseLocked removes a disconnected subscriber. A competing one gives up
+// its conversations, and its unacked messages go to the remaining competing
+// subscribers or back to the queue.
+func (s *Server) releaseLocked(sub *subscriber) {
+	delete(s.subscribers, sub)
+	if !sub.competing {
+		return
+	}
+	for key, owner := range s.affinity {
+		if owner == sub {
+			delete(s.affinity, key)
+		}
+	}
+	for _, f := range sub.leased {
+		if msg, ok := s.messages[f.id]; ok && msg.acked {
+			continue
+		}
+		if owner := s.competingOwnerLocked(f); owner == nil || !s.leaseLocked(owner, f) {
+			s.queued = append(s.queued, f)
+		}
+	}
+	sub.leased = nil
+}
+
+// authenticate resolves a bearer token to a subscription scope and the
+// status reported in the connected event.
+func (s *Server) authenticate(r *http.Request) (scope, status string, ok bool) {
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found {
+		return "", "", false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Token != "" && token == s.Token {
+		return "account", relayclient.SessionPaired, true
+	}
+	if sess, ok := s.sessions[token]; ok {
+		if sess.paired {
+			return "account", relayclient.SessionPaired, true
+		}
+		return token, relayclient.SessionPendingPairing, true
+	}
+	return "", "", false
+}
+
+func (s *Server) createSession(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.seq++
+	token := fmt.Sprintf("relaytest-session-%d", s.seq)
+	code := fmt.Sprintf("TEST-%04d", s.seq)
+	s.sessions[token] = &fakeSession{code: code}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, relayclient.Session{
+		SessionToken: token,
+		PairingCode:  code,
+		ExpiresIn:    300,
+		Status:       relayclient.SessionPendingPairing,
+	})
+}
+
+func (s *Server) sessionStatus(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sess, ok := s.sessions[r.PathValue("token")]
+	var paired bool
+	if ok {
+		paired = sess.paired
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "Session not found"})
+		return
+	}
+	if !paired {
+		writeJSON(w, http.StatusOK, relayclient.SessionStatus{Status: relayclient.SessionPendingPairing})
+		return
+	}
+
+	_, userID, _ := strings.Cut(DefaultConversationKey, ":")
+	now := time.Now().UTC()
+	writeJSON(w, http.StatusOK, relayclient.SessionStatus{
+		Status:      relayclient.SessionPaired,
+		PairedAt:    &now,
+		KakaoUserID: userID,
+		AccountID:   accountID,
+		RelayToken:  s.Token,
+	})
+}
+
+func (s *Server) events(w http.ResponseWriter, r *http.Request) {
+	scope, status, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return
+	}
+
+	sub := &subscriber{
+		scope:        scope,
+		includeState: scope == "account" && r.URL.Query().Get("include") == "state",
+		frames:       make(chan frame, 100),
+		done:         make(chan struct{}),
+	}
+	sub.filter = parseEventFilter(r)
+	delivery := r.URL.Query().Get("delivery")
+	switch delivery {
+	case "", relayclient.DeliveryBroadcast:
+		delivery = relayclient.DeliveryBroadcast
+	case relayclient.DeliveryCompeting:
+		if scope != "account" {
+			writeError(w, http.StatusUnauthorized, relayclient.CodeSessionNotPaired, "Session is not paired")
+			return
+		}
+		if sub.filter.filtersMessages() {
+			writeError(w, http.StatusBadRequest, "INVALID_INPUT", "competing streams cannot filter messages")
+			return
+		}
+	default:
+		writeError(w, http.StatusBadRequest, "INVALID_INPUT", "delivery must be broadcast or competing")
+		return
+	}
+
+	s.mu.Lock()
+	s.lastEventID = append(s.lastEventID, r.Header.Get("Last-Event-ID"))
+	if delivery == relayclient.DeliveryCompeting {
+		s.seq++
+		sub.competing = true
+		sub.consumerID = fmt.Sprintf("c_%d", s.seq)
+	}
+	s.subscribers[sub] = struct{}{}
+	var backlog []frame
+	if scope == "account" {
+		queued := s.queued
+		s.queued = nil
+		for _, f := range queued {
+			if !sub.filter.match(f) {
+				s.queued = append(s.queued, f)
+				continue
+			}
+			if !sub.competing || f.id == "" {
+				backlog = append(backlog, f)
+				continue
+			}
+			// Queued messages of conversations another competing
+			// subscriber owns go to that subscriber.
+			if owner := s.competingOwnerLocked(f); owner == sub {
+				backlog = append(backlog, f)
+				sub.leased = append(sub.leased, f)
+			} else if owner == nil || !s.leaseLocked(owner, f) {
+				s.queued = append(s.queued, f)
+			}
+		}
+	}
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		s.releaseLocked(sub)
+		s.mu.Unlock()
+	}()
+
+	flusher := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+
+	write := func(f frame) {
+		if sub.includeState {
+			f = s.withState(f)
+		}
+		writeFrame(w, f)
+	}
+
+	for _, f := range backlog {
+		write(f)
+	}
+
+	connected, _ := json.Marshal(relayclient.ConnectedEvent{
+		Status:     status,
+		Delivery:   delivery,
+		ConsumerID: sub.consumerID,
+	})
+	writeFrame(w, frame{eventType: relayclient.EventConnected, data: connected})
+	flusher.Flush()
+
+	for {
+		select {
+		case f := <-sub.frames:
+			write(f)
+			flusher.Flush()
+		case <-sub.done:
+			// Deliver what was published before the drop.
+			for len(sub.frames) > 0 {
+				write(<-sub.frames)
+			}
+			flusher.Flush()
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeFrame(w io.Writer, f frame) {
+	if f.id != "" {
+		fmt.Fprintf(w, "id: %s\n", f.id)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", f.eventType, f.data)
+}
+
+func (s *Server) requireAccount(w http.ResponseWriter, r *http.Request) bool {
+	scope, _, ok := s.authenticate(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "Unauthorized")
+		return false
+	}
+	if scope != "account" {
+		writeError(w, http.StatusForbidden, relayclient.CodeSessionNotPaired, "Session is not paired")
+		return false
+	}
+	return true
+}
+
+func (s *Server) reply(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		MessageID string          `json:"messageId"`
+		Response  json.RawMessage `json:"response"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if req.MessageID == "" {
+		writeError(w, http.StatusBadRequest, "MISSING_REQUIRED", "messageId is required")
+		return
+	}
+
+	var resp relayclient.Response
+	if err := json.Unmarshal(req.Response, &resp); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid response payload")
+		return
+	}
+
+	s.mu.Lock()
+	msg, ok := s.messages[req.MessageID]
+	if !ok {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, relayclient.CodeNotFound, "Message not found")
+		return
+	}
+	if msg.coalescedInto != "" {
+		req.MessageID = msg.coalescedInto
+		msg = s.messages[req.MessageID]
+	}
+	if msg.callbackExpired {
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, relayclient.CodeCallbackExpired, "Callback URL has expired")
+		return
+	}
+
+	reply := Reply{MessageID: req.MessageID, Response: resp, Raw: req.Response}
+	s.replies = append(s.replies, reply)
+	s.ackLocked(req.MessageID)
+	next, release := s.settleLocked(req.MessageID)
+	var texts []string
+	if resp.Template != nil {
+		for _, out := range resp.Template.Outputs {
+			if out.SimpleText != nil {
+				texts = append(texts, out.SimpleText.Text)
+			}
+		}
+	}
+	s.recordOutboundLocked(msg.conversationKey, relayclient.HistoryMessage{
+		Text:            strings.Join(texts, "\n"),
+		InReplyTo:       req.MessageID,
+		ThreadID:        msg.threadID,
+		DeliveryType:    "callback",
+		ResponsePayload: req.Response,
+	})
+	waiters := s.replyWait[req.MessageID]
+	delete(s.replyWait, req.MessageID)
+	s.mu.Unlock()
+
+	for _, ch := range waiters {
+		ch <- reply
+	}
+	if release {
+		s.publish("account", next, true)
+	}
+
+	writeJSON(w, http.StatusOK, relayclient.ReplyResult{Success: true, DeliveredAt: time.Now().UnixMilli()})
+}
+
+func (s *Server) ack(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		MessageIDs []string `json:"messageIds"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if len(req.MessageIDs) == 0 {
+		writeError(w, http.StatusBadRequest, "MISSING_REQUIRED", "messageIds is required")
+		return
+	}
+
+	s.mu.Lock()
+	acked := 0
+	var released []frame
+	for _, id := range req.MessageIDs {
+		if m, ok := s.messages[id]; ok && m.coalescedInto != "" {
+			id = m.coalescedInto
+		}
+		if s.ackLocked(id) {
+			acked++
+		}
+		if next, ok := s.settleLocked(id); ok {
+			released = append(released, next)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, f := range released {
+		s.publish("account", f, true)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]int{"acked": acked})
+}
+
+func (s *Server) send(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req relayclient.SendRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if req.ConversationKey == "" || req.Event == "" {
+		writeError(w, http.StatusBadRequest, "MISSING_REQUIRED", "conversationKey and event are required")
+		return
+	}
+
+	s.mu.Lock()
+	s.sends = append(s.sends, req)
+	n := len(s.sends)
+	payload, _ := json.Marshal(map[string]any{"event": req.Event, "params": req.Params})
+	s.recordOutboundLocked(req.ConversationKey, relayclient.HistoryMessage{
+		ThreadID:        s.threadLocked(req.ConversationKey),
+		DeliveryType:    "event_api",
+		ResponsePayload: payload,
+	})
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, relayclient.SendResult{
+		Success:    true,
+		OutboundID: fmt.Sprintf("out-%d", n),
+		TaskID:     fmt.Sprintf("task-%d", n),
+		Status:     "SUCCESS",
+	})
+}
+
+func (s *Server) uploadMedia(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "MISSING_REQUIRED", "file is required")
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil || len(data) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_INPUT", "file is empty")
+		return
+	}
+
+	contentType := header.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+
+	s.mu.Lock()
+	s.seq++
+	id := fmt.Sprintf("media-%d", s.seq)
+	s.media[id] = data
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, relayclient.Media{
+		ID:          id,
+		Ref:         "media://" + id,
+		URL:         s.URL + "/media/" + id,
+		ContentType: contentType,
+		SizeBytes:   int64(len(data)),
+		ExpiresAt:   time.Now().Add(72 * time.Hour).UTC(),
+	})
+}
+
+func (s *Server) listCommands(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"commands": s.Commands()})
+}
+
+func (s *Server) replaceCommands(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		Commands []relayclient.CommandDefinition `json:"commands"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+
+	commands := make([]relayclient.Command, 0, len(req.Commands))
+	for i, def := range req.Commands {
+		aliases := make([]string, 0, len(def.Aliases))
+		for _, alias := range def.Aliases {
+			aliases = append(aliases, commandName(alias))
+		}
+		name := commandName(def.Name)
+		if reservedCommands[name] {
+			writeError(w, http.StatusBadRequest, "INVALID_INPUT", "Invalid commands: "+name+" is a built-in command")
+			return
+		}
+		commands = append(commands, relayclient.Command{
+			ID:          fmt.Sprintf("cmd-%d", i+1),
+			Name:        name,
+			Aliases:     aliases,
+			Usage:       def.Usage,
+			Description: def.Description,
+			CreatedAt:   time.Now().UTC(),
+		})
+	}
+
+	s.mu.Lock()
+	s.commands = commands
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"commands": commands})
+}
+
+func (s *Server) createPairingCode(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var opts relayclient.PairingCodeOptions
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&opts); err != nil {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+			return
+		}
+	}
+	if opts.MaxUses == 0 {
+		opts.MaxUses = 1
+	}
+	if opts.ExpiresInSeconds == 0 {
+		opts.ExpiresInSeconds = 24 * 60 * 60
+	}
+	if opts.MaxUses < 1 || opts.ExpiresInSeconds < 60 {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid pairing code options")
+		return
+	}
+
+	s.mu.Lock()
+	s.seq++
+	now := time.Now().UTC()
+	code := &relayclient.PairingCode{
+		ID:        fmt.Sprintf("pc-%d", s.seq),
+		Code:      fmt.Sprintf("JOIN-%04d", s.seq),
+		MaxUses:   opts.MaxUses,
+		ExpiresAt: now.Add(time.Duration(opts.ExpiresInSeconds) * time.Second),
+		CreatedAt: now,
+	}
+	s.codes = append(s.codes, code)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, code)
+}
+
+func (s *Server) listPairingCodes(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	codes := []relayclient.PairingCode{}
+	for _, c := range s.codes {
+		if c.UseCount < c.MaxUses && c.ExpiresAt.After(time.Now()) {
+			codes = append(codes, *c)
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"pairingCodes": codes})
+}
+
+func (s *Server) revokePairingCode(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, c := range s.codes {
+		if c.ID == r.PathValue("id") {
+			s.codes = append(s.codes[:i], s.codes[i+1:]...)
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "pairing code not found")
+}
+
+func (s *Server) updatePairingSettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		RequireApproval *bool `json:"requireApproval"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RequireApproval == nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "requireApproval is required")
+		return
+	}
+
+	s.mu.Lock()
+	s.approval = *req.RequireApproval
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]bool{"requireApproval": *req.RequireApproval})
+}
+
+func (s *Server) deliverySettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	settings := s.deliverySettingsLocked()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) updateDeliverySettings(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		OrderedDelivery  *bool `json:"orderedDelivery"`
+		CoalesceWindowMs *int  `json:"coalesceWindowMs"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if req.OrderedDelivery == nil && req.CoalesceWindowMs == nil {
+		writeError(w, http.StatusBadRequest, "MISSING_REQUIRED", "orderedDelivery or coalesceWindowMs is required")
+		return
+	}
+	if req.CoalesceWindowMs != nil &&
+		(*req.CoalesceWindowMs < 0 || *req.CoalesceWindowMs > int(relayclient.MaxCoalesceWindow.Milliseconds())) {
+		writeError(w, http.StatusBadRequest, "INVALID_INPUT", "coalesceWindowMs is out of range")
+		return
+	}
+
+	s.mu.Lock()
+	if req.OrderedDelivery != nil {
+		s.ordered = *req.OrderedDelivery
+	}
+	if req.CoalesceWindowMs != nil {
+		s.coalesce = time.Duration(*req.CoalesceWindowMs) * time.Millisecond
+	}
+	settings := s.deliverySettingsLocked()
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, settings)
+}
+
+func (s *Server) deliverySettingsLocked() relayclient.DeliverySettings {
+	return relayclient.DeliverySettings{
+		OrderedDelivery:    s.ordered,
+		HoldTimeoutSeconds: 60,
+		CoalesceWindowMs:   int(s.coalesce.Milliseconds()),
+	}
+}
+
+func (s *Server) listPairingRequests(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	reqs := []relayclient.PairingRequest{}
+	for _, req := range s.requests {
+		if req.Status == relayclient.PairingRequestPending {
+			reqs = append(reqs, *req)
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"pairingRequests": reqs})
+}
+
+func (s *Server) decidePairingRequest(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	status := map[string]string{
+		"approve": relayclient.PairingRequestApproved,
+		"reject":  relayclient.PairingRequestRejected,
+	}[r.PathValue("action")]
+	if status == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	var req *relayclient.PairingRequest
+	for _, candidate := range s.requests {
+		if candidate.ID == r.PathValue("id") {
+			req = candidate
+		}
+	}
+	if req == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "pairing request not found")
+		return
+	}
+	if req.Status != relayclient.PairingRequestPending {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "CONFLICT", "Pairing request is no longer pending")
+		return
+	}
+	now := time.Now().UTC()
+	req.Status = status
+	req.DecidedAt = &now
+	decided := *req
+	s.mu.Unlock()
+
+	if status == relayclient.PairingRequestApproved {
+		s.publishPairingComplete("", decided.PairingCodeID, decided.Profile.KakaoUserID)
+	} else {
+		s.mu.Lock()
+		s.removeConversationLocked(decided.Profile.KakaoUserID)
+		s.mu.Unlock()
+	}
+	writeJSON(w, http.StatusOK, decided)
+}
+
+func (s *Server) me(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	counts := map[string]int{
+		relayclient.ConversationPaired:  0,
+		relayclient.ConversationPending: 0,
+		relayclient.ConversationBlocked: 0,
+	}
+	for _, c := range s.convs {
+		counts[c.State]++
+	}
+	usage := relayclient.Usage{
+		InboundToday:  len(s.messages),
+		InboundTotal:  len(s.messages),
+		OutboundToday: len(s.replies) + len(s.sends),
+		OutboundTotal: len(s.replies) + len(s.sends),
+	}
+	me := relayclient.Me{
+		Account: relayclient.Account{
+			ID:                     accountID,
+			RateLimitPerMinute:     60,
+			RequirePairingApproval: s.approval,
+			OrderedDelivery:        s.ordered,
+			CoalesceWindowMs:       int(s.coalesce.Milliseconds()),
+		},
+		Limits:        relayclient.Limits{RateLimitPerMinute: 60},
+		Usage:         usage,
+		Conversations: counts,
+		SSEClients:    len(s.subscribers),
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, me)
+}
+
+func (s *Server) listConversations(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	state := r.URL.Query().Get("state")
+	switch state {
+	case "", relayclient.ConversationPaired, relayclient.ConversationPending, relayclient.ConversationBlocked:
+	default:
+		writeError(w, http.StatusBadRequest, "INVALID_INPUT", "Invalid state")
+		return
+	}
+
+	s.mu.Lock()
+	convs := []relayclient.Conversation{}
+	for i := len(s.convs) - 1; i >= 0; i-- {
+		if state == "" || s.convs[i].State == state {
+			convs = append(convs, *s.convs[i])
+		}
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, map[string]any{"conversations": convs})
+}
+
+func (s *Server) changeConversation(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	transitions := map[string][2]string{
+		"unpair":  {relayclient.ConversationPaired, ""},
+		"block":   {relayclient.ConversationPaired, relayclient.ConversationBlocked},
+		"unblock": {relayclient.ConversationBlocked, ""},
+	}
+	transition, ok := transitions[r.PathValue("action")]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	var conv *relayclient.Conversation
+	for _, c := range s.convs {
+		if c.ID == r.PathValue("id") {
+			conv = c
+		}
+	}
+	if conv == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "conversation not found")
+		return
+	}
+	if conv.State != transition[0] {
+		s.mu.Unlock()
+		writeError(w, http.StatusConflict, "CONFLICT", "Conversation is "+conv.State)
+		return
+	}
+	if transition[1] == "" {
+		s.removeConversationLocked(conv.KakaoUserID)
+		conv.State = "unpaired"
+	} else {
+		conv.State = transition[1]
+	}
+	changed := *conv
+	s.mu.Unlock()
+
+	switch r.PathValue("action") {
+	case "unpair":
+		s.publishUnpaired(changed.KakaoUserID, relayclient.ReasonAccount)
+	case "block":
+		s.Publish(relayclient.EventConversationBlocked, relayclient.ConversationBlockedEvent{
+			ConversationKey: changed.ConversationKey,
+			KakaoUserID:     changed.KakaoUserID,
+			Reason:          relayclient.ReasonAccount,
+			BlockedAt:       time.Now().UTC().Truncate(time.Second),
+		})
+	}
+	writeJSON(w, http.StatusOK, changed)
+}
+
+func (s *Server) rotateTokenHandler(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"relayToken": s.rotateToken(relayclient.ReasonAccount)})
+}
+
+// conversationHistory pages back through history with the index of the
+// oldest message returned so far as the cursor.
+func (s *Server) conversationHistory(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit := 50
+	if raw := query.Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > 200 {
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "limit must be between 1 and 200")
+			return
+		}
+		limit = n
+	}
+	mode := query.Get("mode")
+	if mode != "" && mode != relayclient.HistoryText && mode != relayclient.HistoryFull {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "mode must be text or full")
+		return
+	}
+	threadID := query.Get("thread")
+	matches := func(entry historyEntry) bool {
+		return entry.conversationKey == r.PathValue("key") && (threadID == "" || entry.msg.ThreadID == threadID)
+	}
+
+	s.mu.Lock()
+	end := len(s.history)
+	if cursor := query.Get("cursor"); cursor != "" {
+		n, err := strconv.Atoi(cursor)
+		if err != nil || n < 0 || n > end {
+			s.mu.Unlock()
+			writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "invalid cursor")
+			return
+		}
+		end = n
+	}
+	var page relayclient.HistoryPage
+	i := end - 1
+	for ; i >= 0 && len(page.Messages) < limit; i-- {
+		entry := s.history[i]
+		if !matches(entry) {
+			continue
+		}
+		msg := entry.msg
+		if mode != relayclient.HistoryFull {
+			msg.KakaoPayload, msg.Normalized, msg.ResponsePayload = nil, nil, nil
+		}
+		page.Messages = append([]relayclient.HistoryMessage{msg}, page.Messages...)
+		end = i
+	}
+	for ; i >= 0; i-- {
+		if matches(s.history[i]) {
+			page.NextCursor = strconv.Itoa(end)
+			break
+		}
+	}
+	s.mu.Unlock()
+
+	if page.Messages == nil {
+		page.Messages = []relayclient.HistoryMessage{}
+	}
+	writeJSON(w, http.StatusOK, page)
+}
+
+// withState adds the conversation state to message and command frames.
+func (s *Server) withState(f frame) frame {
+	if f.eventType != relayclient.EventMessage && f.eventType != relayclient.EventCommand {
+		return f
+	}
+	var fields map[string]json.RawMessage
+	var conversationKey string
+	if json.Unmarshal(f.data, &fields) != nil || json.Unmarshal(fields["conversationKey"], &conversationKey) != nil {
+		return f
+	}
+
+	s.mu.Lock()
+	values := make(map[string]json.RawMessage)
+	for key, entry := range s.liveStateLocked(conversationKey) {
+		values[key] = entry.Value
+	}
+	s.mu.Unlock()
+
+	fields["state"], _ = json.Marshal(values)
+	f.data, _ = json.Marshal(fields)
+	return f
+}
+
+// liveStateLocked returns the conversation's keys, dropping expired ones.
+func (s *Server) liveStateLocked(conversationKey string) map[string]*relayclient.StateEntry {
+	entries := s.state[conversationKey]
+	for key, entry := range entries {
+		if entry.ExpiresAt != nil && !entry.ExpiresAt.After(time.Now()) {
+			delete(entries, key)
+		}
+	}
+	return entries
+}
+
+func (s *Server) listState(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	entries := []relayclient.StateEntry{}
+	for _, entry := range s.liveStateLocked(r.PathValue("key")) {
+		entries = append(entries, *entry)
+	}
+	s.mu.Unlock()
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	writeJSON(w, http.StatusOK, map[string]any{"state": entries})
+}
+
+func (s *Server) getState(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	entry, ok := s.liveStateLocked(r.PathValue("key"))[r.PathValue("name")]
+	var found relayclient.StateEntry
+	if ok {
+		found = *entry
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		writeError(w, http.StatusNotFound, relayclient.CodeNotFound, "State key not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, found)
+}
+
+// putState accepts writes for any conversation except ones recorded as
+// pending or blocked; the relay requires a paired conversation.
+func (s *Server) putState(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	var req struct {
+		Value      json.RawMessage `json:"value"`
+		TTLSeconds int             `json:"ttlSeconds"`
+		Version    *int64          `json:"version"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "Invalid request body")
+		return
+	}
+	if len(req.Value) == 0 || string(req.Value) == "null" {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "value is required")
+		return
+	}
+	if req.TTLSeconds < 0 || (req.Version != nil && *req.Version < 0) {
+		writeError(w, http.StatusBadRequest, "VALIDATION_ERROR", "ttlSeconds and version must not be negative")
+		return
+	}
+
+	conversationKey, key := r.PathValue("key"), r.PathValue("name")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, c := range s.convs {
+		if c.ConversationKey == conversationKey && c.State != relayclient.ConversationPaired {
+			writeError(w, http.StatusNotFound, relayclient.CodeNotFound, "Conversation not found")
+			return
+		}
+	}
+
+	entries := s.liveStateLocked(conversationKey)
+	current := entries[key]
+	if req.Version != nil {
+		currentVersion := int64(0)
+		if current != nil {
+			currentVersion = current.Version
+		}
+		if *req.Version != currentVersion {
+			writeError(w, http.StatusConflict, relayclient.CodeConflict, "State key has a different version")
+			return
+		}
+	}
+
+	now := time.Now().UTC()
+	entry := &relayclient.StateEntry{Key: key, Value: req.Value, Version: 1, CreatedAt: now, UpdatedAt: now}
+	if current != nil {
+		entry.Version = current.Version + 1
+		entry.CreatedAt = current.CreatedAt
+	}
+	if req.TTLSeconds > 0 {
+		expiresAt := now.Add(time.Duration(req.TTLSeconds) * time.Second)
+		entry.ExpiresAt = &expiresAt
+	}
+	if entries == nil {
+		entries = make(map[string]*relayclient.StateEntry)
+		s.state[conversationKey] = entries
+	}
+	entries[key] = entry
+	writeJSON(w, http.StatusOK, entry)
+}
+
+func (s *Server) deleteState(w http.ResponseWriter, r *http.Request) {
+	if !s.requireAccount(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.liveStateLocked(r.PathValue("key"))
+	entry, ok := entries[r.PathValue("name")]
+	if !ok {
+		writeError(w, http.StatusNotFound, relayclient.CodeNotFound, "State key not found")
+		return
+	}
+	if raw := r.URL.Query().Get("version"); raw != "" && raw != strconv.FormatInt(entry.Version, 10) {
+		writeError(w, http.StatusConflict, relayclient.CodeConflict, "State key has a different version")
+		return
+	}
+	delete(entries, entry.Key)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+var reservedCommands = map[string]bool{"/pair": true, "/unpair": true, "/status": true, "/help": true}
+
+func commandName(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if !strings.HasPrefix(name, "/") {
+		name = "/" + name
+	}
+	return name
+}
+
+func writeJSON(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{"error": message, "code": code})
+}
+
+// eventFilter mirrors the relay's stream filter query parameters.
+type eventFilter struct {
+	types, channelIDs, conversationKeys, intents, blocks map[string]bool
+}
+
+func parseEventFilter(r *http.Request) *eventFilter {
+	list := func(name string) map[string]bool {
+		var set map[string]bool
+		for _, param := range r.URL.Query()[name] {
+			for _, v := range strings.Split(param, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					if set == nil {
+						set = make(map[string]bool)
+					}
+					set[v] = true
+				}
+			}
+		}
+		return set
+	}
+	f := &eventFilter{
+		types:            list("types"),
+		channelIDs:       list("channelIds"),
+		conversationKeys: list("conversationKeys"),
+		intents:          list("intents"),
+		blocks:           list("blocks"),
+	}
+	if f.types == nil && f.channelIDs == nil && f.conversationKeys == nil && f.intents == nil && f.blocks == nil {
+		return nil
+	}
+	return f
+}
+
+func (f *eventFilter) filtersMessages() bool {
+	return f != nil && (f.channelIDs != nil || f.conversationKeys != nil || f.intents != nil || f.blocks != nil ||
+		(f.types != nil && (!f.types[relayclient.EventMessage] || !f.types[relayclient.EventCommand])))
+}
+
+// match reports whether the subscriber receives fr. Like the relay, the
+// overflow event that ends a stream is never filtered.
+func (f *eventFilter) match(fr frame) bool {
+	if f == nil || fr.eventType == relayclient.EventOverflow {
+		return true
+	}
+	if f.types != nil && !f.types[fr.eventType] {
+		return false
+	}
+
+	var data struct {
+		ConversationKey string                         `json:"conversationKey"`
+		Normalized      *relayclient.NormalizedMessage `json:"normalized"`
+	}
+	_ = json.Unmarshal(fr.data, &data)
+
+	if data.ConversationKey != "" {
+		if f.conversationKeys != nil && !f.conversationKeys[data.ConversationKey] {
+			return false
+		}
+		channelID, _, _ := strings.Cut(data.ConversationKey, ":")
+		if data.Normalized != nil && data.Normalized.ChannelID != "" {
+			channelID = data.Normalized.ChannelID
+		}
+		if f.channelIDs != nil && !f.channelIDs[channelID] {
+			return false
+		}
+	}
+
+	if fr.eventType == relayclient.EventMessage || fr.eventType == relayclient.EventCommand {
+		ref := func(r *relayclient.NormalizedRef, set map[string]bool) bool {
+			return r != nil && (set[r.Name] || (r.ID != "" && set[r.ID]))
+		}
+		var intent, block *relayclient.NormalizedRef
+		if data.Normalized != nil {
+			intent, block = data.Normalized.Intent, data.Normalized.Block
+		}
+		if f.intents != nil && !ref(intent, f.intents) {
+			return false
+		}
+		if f.blocks != nil && !ref(block, f.blocks) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,100 @@
+package relayclient
+
+// Response is a Kakao skill response (version 2.0) as accepted by
+// /openclaw/reply.
+type Response struct {
+	Version     string         `json:"version"`
+	Template    *Template      `json:"template,omitempty"`
+	UseCallback bool           `json:"useCallback,omitempty"`
+	Context     *Context       `json:"context,omitempty"`
+	Data        map[string]any `json:"data,omitempty"`
+}
+
+type Template struct {
+	Outputs      []Output     `json:"outputs"`
+	QuickReplies []QuickReply `json:"quickReplies,omitempty"`
+}
+
+type Output struct {
+	SimpleText  *SimpleText  `json:"simpleText,omitempty"`
+	SimpleImage *SimpleImage `json:"simpleImage,omitempty"`
+}
+
+type SimpleText struct {
+	Text string `json:"text"`
+}
+
+type SimpleImage struct {
+	// ImageURL may be a media reference ("media://<id>") returned by
+	// UploadMedia; the relay resolves it to a public URL.
+	ImageURL string `json:"imageUrl"`
+	AltText  string `json:"altText,omitempty"`
+}
+
+type QuickReply struct {
+	Label       string `json:"label"`
+	Action      string `json:"action"`
+	MessageText string `json:"messageText,omitempty"`
+}
+
+type Context struct {
+	Values []ContextValue `json:"values"`
+}
+
+type ContextValue struct {
+	Name     string            `json:"name"`
+	LifeSpan int               `json:"lifeSpan"`
+	Params   map[string]string `json:"params,omitempty"`
+}
+
+// NewResponse builds a response from the given outputs. Kakao shows at most
+// three outputs per response.
+func NewResponse(outputs ...Output) *Response {
+	return &Response{
+		Version:  "2.0",
+		Template: &Template{Outputs: outputs},
+	}
+}
+
+func NewTextResponse(text string) *Response {
+	return NewResponse(Text(text))
+}
+
+func NewImageResponse(imageURL, altText string) *Response {
+	return NewResponse(Image(imageURL, altText))
+}
+
+func Text(text string) Output {
+	return Output{SimpleText: &SimpleText{Text: text}}
+}
+
+func Image(imageURL, altText string) Output {
+	return Output{SimpleImage: &SimpleImage{ImageURL: imageURL, AltText: altText}}
+}
+
+// MessageQuickReply is a button that sends messageText (the label when
+// empty) back as a user utterance.
+func MessageQuickReply(label, messageText string) QuickReply {
+	if messageText == "" {
+		messageText = label
+	}
+	return QuickReply{Label: label, Action: "message", MessageText: messageText}
+}
+
+// WithQuickReplies appends quick reply buttons and returns r.
+func (r *Response) WithQuickReplies(replies ...QuickReply) *Response {
+	if r.Template == nil {
+		r.Template = &Template{Outputs: []Output{}}
+	}
+	r.Template.QuickReplies = append(r.Template.QuickReplies, replies...)
+	return r
+}
+
+// WithContext appends an open builder context value and returns r.
+func (r *Response) WithContext(name string, lifeSpan int, params map[string]string) *Response {
+	if r.Context == nil {
+		r.Context = &Context{}
+	}
+	r.Context.Values = append(r.Context.Values, ContextValue{Name: name, LifeSpan: lifeSpan, Params: params})
+	return r
+}
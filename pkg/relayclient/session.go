@@ -0,0 +1,86 @@
+package relayclient
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Session statuses reported by /v1/sessions/{token}/status.
+const (
+	SessionPendingPairing = "pending_pairing"
+	SessionPaired         = "paired"
+	SessionExpired        = "expired"
+	SessionDisconnected   = "disconnected"
+)
+
+const defaultPollInterval = 2 * time.Second
+
+// Session is a newly created pairing session. The user sends
+// "/pair <PairingCode>" in KakaoTalk; SessionToken authenticates the event
+// stream and, once paired, the reply endpoints.
+type Session struct {
+	SessionToken string `json:"sessionToken"`
+	PairingCode  string `json:"pairingCode"`
+	// ExpiresIn is the pairing code lifetime in seconds.
+	ExpiresIn int    `json:"expiresIn"`
+	Status    string `json:"status"`
+}
+
+type SessionStatus struct {
+	Status      string     `json:"status"`
+	PairedAt    *time.Time `json:"pairedAt,omitempty"`
+	KakaoUserID string     `json:"kakaoUserId,omitempty"`
+	AccountID   string     `json:"accountId,omitempty"`
+	RelayToken  string     `json:"relayToken,omitempty"`
+}
+
+// CreateSession starts a pairing session. It needs no token.
+func (c *Client) CreateSession(ctx context.Context) (*Session, error) {
+	var session Session
+	if err := c.doJSON(ctx, "POST", "/v1/sessions/create", nil, &session); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (c *Client) SessionStatus(ctx context.Context, sessionToken string) (*SessionStatus, error) {
+	var status SessionStatus
+	path := "/v1/sessions/" + url.PathEscape(sessionToken) + "/status"
+	if err := c.doJSON(ctx, "GET", path, nil, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// WaitForPairing polls the session status until it is paired, expires or
+// ctx is done. A zero interval polls every two seconds. Integrations that
+// already hold an event stream can wait for PairingCompleteEvent instead.
+func (c *Client) WaitForPairing(ctx context.Context, sessionToken string, interval time.Duration) (*SessionStatus, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		status, err := c.SessionStatus(ctx, sessionToken)
+		if err != nil {
+			return nil, err
+		}
+
+		switch status.Status {
+		case SessionPaired:
+			return status, nil
+		case SessionExpired, SessionDisconnected:
+			return status, fmt.Errorf("relay: session %s", status.Status)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package relayclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// StateEntry is one key of the state the account keeps for a conversation.
+// The relay deletes it when the conversation is unpaired or deleted.
+type StateEntry struct {
+	Key   string          `json:"key"`
+	Value json.RawMessage `json:"value"`
+	// Version starts at 1 and grows with every write.
+	Version   int64      `json:"version"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+}
+
+// Decode unmarshals the value into v.
+func (e *StateEntry) Decode(v any) error {
+	return json.Unmarshal(e.Value, v)
+}
+
+type SetStateOptions struct {
+	// TTL expires the key, rounded down to seconds. Zero keeps it.
+	TTL time.Duration
+	// IfVersion makes the write conditional; see Version.
+	IfVersion *int64
+}
+
+// Version returns a version precondition for SetState and DeleteState.
+// Version(0) only creates a key that does not exist; any other value only
+// touches the key at that version. A mismatch fails with CodeConflict.
+func Version(v int64) *int64 {
+	return &v
+}
+
+// State lists the conversation's keys. Only conversations paired with the
+// account have state.
+func (c *Client) State(ctx context.Context, conversationKey string) ([]StateEntry, error) {
+	var result struct {
+		State []StateEntry `json:"state"`
+	}
+	if err := c.doJSON(ctx, "GET", statePath(conversationKey, ""), nil, &result); err != nil {
+		return nil, err
+	}
+	return result.State, nil
+}
+
+// GetState returns one key. A missing or expired key fails with
+// CodeNotFound.
+func (c *Client) GetState(ctx context.Context, conversationKey, key string) (*StateEntry, error) {
+	var entry StateEntry
+	if err := c.doJSON(ctx, "GET", statePath(conversationKey, key), nil, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// SetState stores value, which must marshal to JSON other than null, under
+// key. Conversations not paired with the account fail with CodeNotFound.
+func (c *Client) SetState(ctx context.Context, conversationKey, key string, value any, opts *SetStateOptions) (*StateEntry, error) {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	body := struct {
+		Value      json.RawMessage `json:"value"`
+		TTLSeconds int             `json:"ttlSeconds,omitempty"`
+		Version    *int64          `json:"version,omitempty"`
+	}{Value: raw}
+	if opts != nil {
+		body.TTLSeconds = int(opts.TTL / time.Second)
+		body.Version = opts.IfVersion
+	}
+
+	var entry StateEntry
+	if err := c.doJSON(ctx, "PUT", statePath(conversationKey, key), body, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// DeleteState removes a key; with ifVersion set, only at that version.
+func (c *Client) DeleteState(ctx context.Context, conversationKey, key string, ifVersion *int64) error {
+	path := statePath(conversationKey, key)
+	if ifVersion != nil {
+		path += "?version=" + strconv.FormatInt(*ifVersion, 10)
+	}
+	return c.doJSON(ctx, "DELETE", path, nil, nil)
+}
+
+func statePath(conversationKey, key string) string {
+	path := "/v1/conversations/" + url.PathEscape(conversationKey) + "/state"
+	if key != "" {
+		path += "/" + url.PathEscape(key)
+	}
+	return path
+}
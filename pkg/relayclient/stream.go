@@ -0,0 +1,380 @@
+package relayclient
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultMinBackoff = 500 * time.Millisecond
+	defaultMaxBackoff = 30 * time.Second
+	eventBuffer       = 64
+	seenMessageLimit  = 1024
+)
+
+// ErrStreamClosed is returned by Stream.Next after Close.
+var ErrStreamClosed = errors.New("relay: stream closed")
+
+type StreamOptions struct {
+	// LastEventID resumes after a message ID from a previous stream.
+	LastEventID string
+	// MinBackoff and MaxBackoff bound the jittered exponential reconnect
+	// delay. Defaults are 500ms and 30s.
+	MinBackoff time.Duration
+	MaxBackoff time.Duration
+	// OnReconnect, if set, is called with the error that ended the previous
+	// connection and the delay before the next attempt.
+	OnReconnect func(err error, delay time.Duration)
+	// IncludeState asks the relay to attach the conversation state to
+	// message and command events (MessageEvent.State).
+	IncludeState bool
+	// Delivery is DeliveryBroadcast (the default) or DeliveryCompeting.
+	Delivery string
+	// Filter limits the events the relay sends. A competing stream may
+	// only filter event types, and must keep message and command.
+	Filter EventFilter
+}
+
+// EventFilter selects events on the relay before they are sent. Each
+// non-empty list must match; an empty filter passes everything. The
+// connected event is always sent.
+type EventFilter struct {
+	// Types are event types such as EventMessage.
+	Types []string
+	// ChannelIDs and ConversationKeys match events about a conversation;
+	// events without one, such as account_token_rotated, pass them.
+	ChannelIDs       []string
+	ConversationKeys []string
+	// Intents and Blocks match message and command events by the name or
+	// ID of the normalized intent or block.
+	Intents []string
+	Blocks  []string
+}
+
+func (f EventFilter) encode(query url.Values) {
+	for name, values := range map[string][]string{
+		"types":            f.Types,
+		"channelIds":       f.ChannelIDs,
+		"conversationKeys": f.ConversationKeys,
+		"intents":          f.Intents,
+		"blocks":           f.Blocks,
+	} {
+		if len(values) > 0 {
+			query.Set(name, strings.Join(values, ","))
+		}
+	}
+}
+
+// Stream delivery modes.
+const (
+	// DeliveryBroadcast sends every message to every stream of the account.
+	DeliveryBroadcast = "broadcast"
+	// DeliveryCompeting sends each message to one of the account's
+	// competing streams and keeps a conversation on the same stream while
+	// it is connected. A message is leased to its stream and offered again,
+	// possibly elsewhere, unless it is answered with Reply or acked with
+	// Client.Ack before the lease lapses or the stream disconnects.
+	DeliveryCompeting = "competing"
+)
+
+// Stream is a self-healing subscription to /v1/events. It reconnects with
+// backoff on network errors and 5xx responses, sends Last-Event-ID on
+// reconnect, and drops message events it has already delivered (the relay
+// re-sends undelivered queued messages on every connect).
+//
+// A stream opened with a session token reconnects right after
+// pairing_complete so it moves to the account's channel. After an overflow
+// event, sent when the relay dropped a client that read too slowly, it
+// also reconnects without delay.
+type Stream struct {
+	client *Client
+	opts   StreamOptions
+	events chan Event
+	cancel context.CancelFunc
+
+	mu          sync.Mutex
+	err         error
+	lastEventID string
+	seen        map[string]struct{}
+	seenOrder   []string
+}
+
+// Events opens the event stream. It runs until ctx is done, Close is called
+// or the relay rejects the token.
+func (c *Client) Events(ctx context.Context, opts *StreamOptions) *Stream {
+	s := &Stream{
+		client: c,
+		events: make(chan Event, eventBuffer),
+		seen:   make(map[string]struct{}),
+	}
+	if opts != nil {
+		s.opts = *opts
+	}
+	if s.opts.MinBackoff <= 0 {
+		s.opts.MinBackoff = defaultMinBackoff
+	}
+	if s.opts.MaxBackoff < s.opts.MinBackoff {
+		s.opts.MaxBackoff = max(defaultMaxBackoff, s.opts.MinBackoff)
+	}
+	s.lastEventID = s.opts.LastEventID
+
+	ctx, s.cancel = context.WithCancel(ctx)
+	go s.run(ctx)
+	return s
+}
+
+// Next returns the next event. After the stream ends it returns the reason:
+// ErrStreamClosed, the context error, or a non-retryable *Error such as 401.
+func (s *Stream) Next(ctx context.Context) (Event, error) {
+	select {
+	case ev, ok := <-s.events:
+		if !ok {
+			return nil, s.Err()
+		}
+		return ev, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// C exposes the event channel for select loops. It is closed when the
+// stream ends; Err then reports why.
+func (s *Stream) C() <-chan Event {
+	return s.events
+}
+
+func (s *Stream) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// LastEventID returns the ID of the last message or command event, for
+// resuming a later stream.
+func (s *Stream) LastEventID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.lastEventID
+}
+
+func (s *Stream) Close() error {
+	s.setErr(ErrStreamClosed)
+	s.cancel()
+	return nil
+}
+
+func (s *Stream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err == nil {
+		s.err = err
+	}
+}
+
+func (s *Stream) run(ctx context.Context) {
+	defer close(s.events)
+
+	attempt := 0
+	for {
+		connected, reconnectNow, err := s.connect(ctx)
+		if ctx.Err() != nil {
+			s.setErr(ctx.Err())
+			return
+		}
+		if !retryable(err) {
+			s.setErr(err)
+			return
+		}
+		if connected {
+			attempt = 0
+		}
+		if reconnectNow {
+			continue
+		}
+
+		delay := s.backoff(attempt)
+		attempt++
+		if s.opts.OnReconnect != nil {
+			s.opts.OnReconnect(err, delay)
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			s.setErr(ctx.Err())
+			return
+		}
+	}
+}
+
+// backoff returns a delay in [d/2, d) where d doubles per attempt up to
+// MaxBackoff.
+func (s *Stream) backoff(attempt int) time.Duration {
+	d := s.opts.MinBackoff << min(attempt, 16)
+	if d <= 0 || d > s.opts.MaxBackoff {
+		d = s.opts.MaxBackoff
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func retryable(err error) bool {
+	var relayErr *Error
+	if errors.As(err, &relayErr) {
+		return relayErr.StatusCode >= 500 || relayErr.StatusCode == http.StatusTooManyRequests
+	}
+	return true
+}
+
+// connect runs one SSE connection. connected reports whether the relay
+// accepted it; reconnectNow asks for an immediate reconnect after pairing
+// or an overflow.
+func (s *Stream) connect(ctx context.Context) (connected, reconnectNow bool, err error) {
+	query := url.Values{}
+	if s.opts.IncludeState {
+		query.Set("include", "state")
+	}
+	if s.opts.Delivery != "" {
+		query.Set("delivery", s.opts.Delivery)
+	}
+	s.opts.Filter.encode(query)
+	path := "/v1/events"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.client.baseURL+path, nil)
+	if err != nil {
+		return false, false, err
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set("Cache-Control", "no-cache")
+	if s.client.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.client.token)
+	}
+	if id := s.LastEventID(); id != "" {
+		req.Header.Set("Last-Event-ID", id)
+	}
+
+	resp, err := s.client.streamClient.Do(req)
+	if err != nil {
+		return false, false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var body [4096]byte
+		n, _ := resp.Body.Read(body[:])
+		return false, false, parseError(resp.StatusCode, body[:n])
+	}
+
+	pending := false
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	var eventType, eventID string
+	var data strings.Builder
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line != "" {
+			field, value, _ := strings.Cut(line, ":")
+			value = strings.TrimPrefix(value, " ")
+			switch field {
+			case "event":
+				eventType = value
+			case "data":
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(value)
+			case "id":
+				eventID = value
+			}
+			continue
+		}
+
+		if eventType == "" && data.Len() == 0 {
+			continue
+		}
+		if eventType == "" {
+			eventType = EventMessage
+		}
+
+		ev, parseErr := ParseEvent(eventType, []byte(data.String()))
+		if parseErr != nil {
+			ev = &UnknownEvent{Type: eventType, Data: []byte(data.String())}
+		}
+		eventType = ""
+		data.Reset()
+
+		switch e := ev.(type) {
+		case *ConnectedEvent:
+			connected = true
+			pending = e.Status != SessionPaired
+		case *MessageEvent:
+			if !s.markSeen(e.ID, eventID) {
+				eventID = ""
+				continue
+			}
+		case *CommandEvent:
+			if !s.markSeen(e.ID, eventID) {
+				eventID = ""
+				continue
+			}
+		}
+		eventID = ""
+
+		select {
+		case s.events <- ev:
+		case <-ctx.Done():
+			return connected, false, ctx.Err()
+		}
+
+		if _, ok := ev.(*PairingCompleteEvent); ok && pending {
+			return connected, true, nil
+		}
+		if _, ok := ev.(*OverflowEvent); ok {
+			return connected, true, nil
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return connected, false, err
+	}
+	return connected, false, fmt.Errorf("relay: event stream ended")
+}
+
+// markSeen records a delivered message and reports whether it is new.
+// Competing streams pass repeats on: the relay only sends a message to one
+// again after its lease lapsed unacked.
+func (s *Stream) markSeen(messageID, eventID string) bool {
+	if eventID == "" {
+		eventID = messageID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if messageID != "" && s.opts.Delivery != DeliveryCompeting {
+		if _, dup := s.seen[messageID]; dup {
+			return false
+		}
+		s.seen[messageID] = struct{}{}
+		s.seenOrder = append(s.seenOrder, messageID)
+		if len(s.seenOrder) > seenMessageLimit {
+			delete(s.seen, s.seenOrder[0])
+			s.seenOrder = s.seenOrder[1:]
+		}
+	}
+	s.lastEventID = eventID
+	return true
+}